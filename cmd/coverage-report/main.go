@@ -0,0 +1,107 @@
+// Command coverage-report shows which parts of the server's GraphQL schema the
+// contract suites exercise. Run the suites with GRAPHQL_COVERAGE_DIR set so
+// every client logs its operations, then point this tool at that directory:
+//
+//	GRAPHQL_COVERAGE_DIR=$PWD/coverage go test ./contracts/...
+//	go run ./cmd/coverage-report -dir coverage -out coverage.html
+//
+// The schema is introspected from -endpoint, or read from a saved
+// introspection result with -schema.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/coverage"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+)
+
+func main() {
+	endpoint := flag.String("endpoint", "", "GraphQL endpoint to introspect (default $GRAPHQL_ENDPOINT or http://localhost:4001/graphql)")
+	schemaFile := flag.String("schema", "", "introspection result JSON to use instead of querying -endpoint")
+	dir := flag.String("dir", os.Getenv("GRAPHQL_COVERAGE_DIR"), "directory of recorded operations (default $GRAPHQL_COVERAGE_DIR)")
+	out := flag.String("out", "", "output file (default stdout)")
+	format := flag.String("format", "", "md or html (default from the -out extension, else md)")
+	flag.Parse()
+
+	if err := run(*endpoint, *schemaFile, *dir, *out, *format); err != nil {
+		fmt.Fprintf(os.Stderr, "coverage-report: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(endpoint, schemaFile, dir, out, format string) error {
+	if dir == "" {
+		return fmt.Errorf("no recordings: set -dir or GRAPHQL_COVERAGE_DIR")
+	}
+	if format == "" {
+		format = "md"
+		if ext := filepath.Ext(out); ext == ".html" || ext == ".htm" {
+			format = "html"
+		}
+	}
+	if format != "md" && format != "html" {
+		return fmt.Errorf("unknown format %q", format)
+	}
+
+	var schema *coverage.Schema
+	var err error
+	if schemaFile != "" {
+		data, readErr := os.ReadFile(schemaFile)
+		if readErr != nil {
+			return readErr
+		}
+		schema, err = coverage.ParseSchema(data)
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		schema, err = coverage.LoadSchema(ctx, graphql.NewClient(endpoint))
+	}
+	if err != nil {
+		return err
+	}
+
+	documents, err := coverage.LoadRecordings(dir)
+	if err != nil {
+		return err
+	}
+	if len(documents) == 0 {
+		return fmt.Errorf("no recorded operations in %s", dir)
+	}
+
+	usage := coverage.NewUsage()
+	for document, count := range documents {
+		usage.Add(schema, document, count)
+	}
+	report := coverage.BuildReport(schema, usage)
+
+	var w io.Writer = os.Stdout
+	if out != "" {
+		file, err := os.Create(out)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = file.Close() }()
+		w = file
+	}
+
+	if format == "html" {
+		err = coverage.WriteHTML(w, report)
+	} else {
+		err = coverage.WriteMarkdown(w, report)
+	}
+	if err != nil {
+		return err
+	}
+
+	covered, total := report.Totals()
+	fmt.Fprintf(os.Stderr, "%d of %d fields covered by %d operations (%d distinct)\n",
+		covered, total, report.Operations, len(documents))
+	return nil
+}
@@ -0,0 +1,73 @@
+// Command genapi generates typed Go bindings for the server's GraphQL API
+// into pkg/lacyapi, one method per query and mutation:
+//
+//	go run ./cmd/genapi -save pkg/lacyapi/schema.json    # introspect $GRAPHQL_ENDPOINT, keeping the result
+//	go run ./cmd/genapi -schema pkg/lacyapi/schema.json  # from a saved introspection result
+//
+// The bindings are generated from the schema.json checked in next to them;
+// refresh both after the server's schema changes (contracts/api reports drift).
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/apigen"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+)
+
+func main() {
+	endpoint := flag.String("endpoint", "", "GraphQL endpoint to introspect (default $GRAPHQL_ENDPOINT or http://localhost:4001/graphql)")
+	schemaFile := flag.String("schema", "", "introspection result JSON to use instead of querying -endpoint")
+	out := flag.String("out", "pkg/lacyapi/lacyapi_gen.go", "output file")
+	pkg := flag.String("package", "lacyapi", "package name of the output")
+	save := flag.String("save", "", "also write the introspection result used to this file")
+	flag.Parse()
+
+	if err := run(*endpoint, *schemaFile, *out, *pkg, *save); err != nil {
+		fmt.Fprintf(os.Stderr, "genapi: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(endpoint, schemaFile, out, pkg, save string) error {
+	var schema *apigen.Schema
+	var err error
+	if schemaFile != "" {
+		data, readErr := os.ReadFile(schemaFile)
+		if readErr != nil {
+			return readErr
+		}
+		schema, err = apigen.Parse(data)
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		schema, err = apigen.Load(ctx, graphql.NewClient(endpoint))
+	}
+	if err != nil {
+		return err
+	}
+	if save != "" {
+		data, err := schema.Marshal()
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(save, data, 0o644); err != nil {
+			return err
+		}
+		fmt.Printf("Wrote %s (%d bytes)\n", save, len(data))
+	}
+
+	src, err := apigen.Generate(schema, pkg)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(out, src, 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s (%d bytes)\n", out, len(src))
+	return nil
+}
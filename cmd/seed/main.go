@@ -0,0 +1,67 @@
+// Command seed populates a lacylights server with a demo rig for manual QA
+// and benchmarking, or removes one with -teardown.
+//
+//	go run ./cmd/seed                          # 1 project: 64 fixtures, 40 looks, 3 cue lists, 10 effects
+//	go run ./cmd/seed -projects 3 -fixtures 256
+//	go run ./cmd/seed -teardown
+//
+// The endpoint defaults to GRAPHQL_ENDPOINT, then http://localhost:4001/graphql.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/fixtures"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+)
+
+func main() {
+	size := fixtures.DefaultSize
+	endpoint := flag.String("endpoint", "", "GraphQL endpoint (default $GRAPHQL_ENDPOINT or http://localhost:4001/graphql)")
+	name := flag.String("name", "Seed Demo", "project name; several projects are numbered after it")
+	teardown := flag.Bool("teardown", false, "delete the projects and fixture definition seeded under -name instead of seeding")
+	timeout := flag.Duration("timeout", 5*time.Minute, "overall time limit")
+	flag.IntVar(&size.Projects, "projects", size.Projects, "number of projects")
+	flag.IntVar(&size.Fixtures, "fixtures", size.Fixtures, "fixtures per project (4-channel pars, 128 per universe)")
+	flag.IntVar(&size.Looks, "looks", size.Looks, "looks per project")
+	flag.IntVar(&size.CueLists, "cue-lists", size.CueLists, "cue lists per project")
+	flag.IntVar(&size.CuesPerList, "cues", size.CuesPerList, "cues per cue list")
+	flag.IntVar(&size.Effects, "effects", size.Effects, "waveform effects per project")
+	flag.Parse()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	client := graphql.NewClient(*endpoint)
+
+	if *teardown {
+		deleted, err := fixtures.Teardown(ctx, client, *name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "teardown: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Deleted %d %q project(s) from %s\n", deleted, *name, client.Endpoint())
+		return
+	}
+
+	start := time.Now()
+	rig, err := fixtures.Seed(ctx, client, *name, size)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "seed: %v\n", err)
+		if rig != nil && (len(rig.Projects) > 0 || rig.DefinitionID != "") {
+			fmt.Fprintf(os.Stderr, "partly seeded; remove with: seed -teardown -name %q\n", *name)
+		}
+		os.Exit(1)
+	}
+
+	for _, p := range rig.Projects {
+		fmt.Printf("Project %s: %d fixtures, %d looks, %d cue lists, %d effects\n",
+			p.ID, len(p.FixtureIDs), len(p.LookIDs), len(p.CueListIDs), len(p.EffectIDs))
+	}
+	fmt.Printf("Seeded %d %q project(s) on %s in %v\n",
+		len(rig.Projects), *name, client.Endpoint(), time.Since(start).Round(time.Millisecond))
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFeatureMatrixMatchesIntrospection checks the compat feature matrix for
+// the server's reported version against its schema: every feature the matrix
+// promises must be present, and every feature it rules out must be absent.
+// A failure means compat.SchemaFeatures needs updating for this server version.
+func TestFeatureMatrixMatchesIntrospection(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	server, err := compat.Detect(ctx, graphql.NewClient(""))
+	require.NoError(t, err)
+	if compat.Matrix(server.Version) == nil {
+		t.Skipf("Skipping: server reports no usable version (%q), so there is no matrix to check", server.Version)
+	}
+	t.Logf("Server version %s, Art-Net enabled: %v", server.Version, server.ArtNetEnabled)
+
+	matrix := compat.Matrix(server.Version)
+	for _, feature := range server.Mismatches {
+		probe := compat.SchemaFeatures[feature].Probe
+		assert.Fail(t, "Matrix disagrees with schema",
+			"%s: matrix for %s says %v but %s %s present=%v",
+			feature, server.Version, matrix[feature], probe.Root, probe.Field, !matrix[feature])
+	}
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/apigen"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLacyAPISchemaMatchesServer checks the schema pkg/lacyapi is generated
+// from against the server's introspection, so the typed bindings cannot fall
+// behind the API without a failure. Run `make genapi` to refresh them.
+func TestLacyAPISchemaMatchesServer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	data, err := os.ReadFile("../../pkg/lacyapi/schema.json")
+	require.NoError(t, err)
+	committed, err := apigen.Parse(data)
+	require.NoError(t, err)
+
+	live, err := apigen.Load(ctx, graphql.NewTestClient(t, ""))
+	require.NoError(t, err)
+
+	if drift := committed.Drift(live); len(drift) > 0 {
+		t.Errorf("pkg/lacyapi/schema.json has drifted from the server; run make genapi:\n  %s",
+			strings.Join(drift, "\n  "))
+	}
+}
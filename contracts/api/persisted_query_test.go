@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sendPersisted sends a query by hash, with its document when withDocument is set.
+func sendPersisted(ctx context.Context, client *graphql.Client, hash, query string, withDocument bool, variables map[string]interface{}) (*graphql.Response, error) {
+	req := graphql.Request{Variables: variables, Extensions: graphql.PersistedQueryExtensions(hash)}
+	if withDocument {
+		req.Query = query
+	}
+	return client.Send(ctx, req)
+}
+
+// TestPersistedQueries checks automatic persisted queries on servers that
+// advertise them by answering an unknown hash with PersistedQueryNotFound:
+// a hash is registered by sending it with its document, is then enough on
+// its own, and is never registered against a document it does not match.
+// It also runs a real query through the client's APQ mode.
+func TestPersistedQueries(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	client := graphql.NewClient("")
+
+	// A unique document, so no earlier run has registered it
+	query := fmt.Sprintf("query PersistedProbe%d { __typename }", time.Now().UnixNano())
+	hash := graphql.PersistedQueryHash(query)
+
+	resp, err := sendPersisted(ctx, client, hash, query, false, nil)
+	switch graphql.PersistedQueryError(resp, err) {
+	case graphql.PersistedQueryNotFound:
+	case graphql.PersistedQueryNotSupported:
+		t.Skip("Skipping: server answers PersistedQueryNotSupported")
+	default:
+		t.Skipf("Skipping: server does not advertise persisted queries (unknown hash answered with %v)", describeResult(resp, err))
+	}
+
+	t.Run("Register", func(t *testing.T) {
+		resp, err := sendPersisted(ctx, client, hash, query, true, nil)
+		require.NoError(t, err)
+		require.Empty(t, resp.Errors)
+		assert.JSONEq(t, `{"__typename":"Query"}`, string(resp.Data))
+	})
+
+	t.Run("HashOnly", func(t *testing.T) {
+		resp, err := sendPersisted(ctx, client, hash, query, false, nil)
+		require.NoError(t, err)
+		require.Empty(t, resp.Errors, "A registered hash should run without its document")
+		assert.JSONEq(t, `{"__typename":"Query"}`, string(resp.Data))
+	})
+
+	t.Run("Mismatch", func(t *testing.T) {
+		other := fmt.Sprintf("query PersistedMismatch%d { __typename }", time.Now().UnixNano())
+		otherHash := graphql.PersistedQueryHash(other)
+		resp, err := sendPersisted(ctx, client, otherHash, query, true, nil)
+		assert.True(t, err != nil || len(resp.Errors) > 0, "A document that does not match its hash should be rejected, got %s", describeResult(resp, err))
+
+		resp, err = sendPersisted(ctx, client, otherHash, "", false, nil)
+		assert.Equal(t, graphql.PersistedQueryNotFound, graphql.PersistedQueryError(resp, err),
+			"A rejected hash must not be registered")
+	})
+
+	t.Run("Variables", func(t *testing.T) {
+		settingQuery := fmt.Sprintf("query PersistedSetting%d($key: String!) { setting(key: $key) { key } }", time.Now().UnixNano())
+		settingHash := graphql.PersistedQueryHash(settingQuery)
+		_, err := sendPersisted(ctx, client, settingHash, settingQuery, true, map[string]interface{}{"key": "fade_update_rate_hz"})
+		require.NoError(t, err)
+
+		// The registered query runs with whatever variables come with the hash
+		resp, err := sendPersisted(ctx, client, settingHash, settingQuery, false, map[string]interface{}{"key": "persisted_query_missing_key"})
+		require.NoError(t, err)
+		require.Empty(t, resp.Errors)
+		assert.JSONEq(t, `{"setting":null}`, string(resp.Data))
+	})
+
+	t.Run("Client", func(t *testing.T) {
+		apqClient := graphql.NewClientWithOptions("", graphql.ClientOptions{PersistedQueries: true})
+		systemInfo := `query { systemInfo { artnetEnabled artnetBroadcastAddress } }`
+
+		want, err := client.ExecuteRaw(ctx, systemInfo, nil)
+		require.NoError(t, err)
+		for i := 0; i < 3; i++ {
+			got, err := apqClient.ExecuteRaw(ctx, systemInfo, nil)
+			require.NoError(t, err)
+			assert.JSONEq(t, string(want), string(got))
+		}
+
+		stats := apqClient.PersistedQueryStats()
+		t.Logf("Client APQ stats: %+v", stats)
+		assert.False(t, stats.Unsupported, "The client should keep using persisted queries")
+		assert.LessOrEqual(t, stats.Registered, int64(1), "The query should be registered at most once")
+		assert.GreaterOrEqual(t, stats.Hits, int64(2), "Repeated queries should be answered by hash")
+	})
+}
+
+// describeResult describes a response for failure messages: its error, its
+// GraphQL errors, or its data.
+func describeResult(resp *graphql.Response, err error) string {
+	switch {
+	case err != nil:
+		return err.Error()
+	case len(resp.Errors) > 0:
+		return graphql.Errors(resp.Errors).Error()
+	}
+	return "data " + string(resp.Data)
+}
@@ -0,0 +1,166 @@
+package crud
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/fixtures"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// copyFixtureValuesContract is the cross-fixture copy these tests expect.
+const copyFixtureValuesContract = `copyFixtureValues(input: CopyFixtureValuesInput!): Look! (the target look)
+  input CopyFixtureValuesInput { sourceLookId: ID!, sourceFixtureId: ID!, targetLookId: ID!, targetFixtureId: ID! }
+  copies by offset; a target with more channels pads with its defaults, one with fewer truncates,
+  or the server rejects a channel-count mismatch with an error; undo reverts the copy`
+
+// TestCopyFixtureValues copies one fixture's values in a look onto another
+// fixture in another look, between fixtures of the same layout and of
+// different channel counts, and undoes a copy. See copyFixtureValuesContract.
+func TestCopyFixtureValues(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewTestClient(t, "")
+	compat.RequireMutation(t, client, "copyFixtureValues", copyFixtureValuesContract)
+
+	parDefID, err := fixtures.CreateParDefinition(ctx, client, "Test Copy Values", fmt.Sprintf("Copy Values Par %d", time.Now().UnixNano()))
+	require.NoError(t, err)
+	// The wider and narrower layouts share the par's leading channel types, so
+	// copying by offset and by type agree
+	wideDefID, _ := createLifecycleDefinition(t, client, ctx, "Test Copy Values", "LED_PAR",
+		[]string{"INTENSITY", "RED", "GREEN", "BLUE", "WHITE"})
+	narrowDefID, _ := createLifecycleDefinition(t, client, ctx, "Test Copy Values", "LED_PAR",
+		[]string{"INTENSITY", "RED", "GREEN"})
+	projectID, err := fixtures.CreateProject(ctx, client, "Copy Fixture Values Test Project")
+	require.NoError(t, err)
+	defer func() {
+		_ = fixtures.DeleteProject(ctx, client, projectID)
+		for _, id := range []string{parDefID, wideDefID, narrowDefID} {
+			_ = fixtures.DeleteFixtureDefinition(ctx, client, id)
+		}
+	}()
+
+	patch := func(definitionID, name string, start int) string {
+		id, err := fixtures.CreateFixture(ctx, client, projectID, definitionID, fixtures.Fixture{Name: name, Universe: 1, StartChannel: start})
+		require.NoError(t, err)
+		return id
+	}
+	parA := patch(parDefID, "Copy Par A", 1)
+	parB := patch(parDefID, "Copy Par B", 10)
+	wide := patch(wideDefID, "Copy Wide", 20)
+	narrow := patch(narrowDefID, "Copy Narrow", 30)
+
+	sourceLook, err := fixtures.CreateLook(ctx, client, projectID, "Copy Source", []fixtures.FixtureValues{
+		{FixtureID: parA, Values: []int{200, 255, 100, 50}},
+		{FixtureID: wide, Values: []int{10, 20, 30, 40, 250}},
+	})
+	require.NoError(t, err)
+	targetLook, err := fixtures.CreateLook(ctx, client, projectID, "Copy Target", []fixtures.FixtureValues{
+		{FixtureID: parA, Values: []int{5, 5, 5, 5}},
+		{FixtureID: parB, Values: []int{0, 0, 0, 0}},
+		{FixtureID: wide, Values: []int{0, 0, 0, 0, 99}},
+		{FixtureID: narrow, Values: []int{9, 9, 9}},
+	})
+	require.NoError(t, err)
+
+	copyValues := func(sourceLookID, sourceFixtureID, targetLookID, targetFixtureID string) error {
+		var resp struct {
+			CopyFixtureValues struct {
+				ID string `json:"id"`
+			} `json:"copyFixtureValues"`
+		}
+		err := client.Mutate(ctx, `
+			mutation CopyFixtureValues($input: CopyFixtureValuesInput!) {
+				copyFixtureValues(input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"sourceLookId":    sourceLookID,
+				"sourceFixtureId": sourceFixtureID,
+				"targetLookId":    targetLookID,
+				"targetFixtureId": targetFixtureID,
+			},
+		}, &resp)
+		if err == nil {
+			assert.Equal(t, targetLookID, resp.CopyFixtureValues.ID, "The target look should be returned")
+		}
+		return err
+	}
+	sourceBefore := map[string]map[int]int{
+		parA: lookChannels(t, client, ctx, sourceLook, parA),
+		wide: lookChannels(t, client, ctx, sourceLook, wide),
+	}
+
+	t.Run("SameLayout", func(t *testing.T) {
+		require.NoError(t, copyValues(sourceLook, parA, targetLook, parB))
+		assert.Equal(t, map[int]int{0: 200, 1: 255, 2: 100, 3: 50}, lookChannels(t, client, ctx, targetLook, parB))
+		assert.Equal(t, map[int]int{0: 5, 1: 5, 2: 5, 3: 5}, lookChannels(t, client, ctx, targetLook, parA),
+			"The source fixture's values in the target look should not change")
+	})
+
+	t.Run("ToMoreChannels", func(t *testing.T) {
+		err := copyValues(sourceLook, parA, targetLook, wide)
+		values := lookChannels(t, client, ctx, targetLook, wide)
+		if err != nil {
+			t.Logf("Contract: copying onto a fixture with more channels is rejected: %v", err)
+			assert.Equal(t, map[int]int{0: 0, 1: 0, 2: 0, 3: 0, 4: 99}, values, "A rejected copy should change nothing")
+			return
+		}
+		assert.Equal(t, map[int]int{0: 200, 1: 255, 2: 100, 3: 50, 4: 0}, values,
+			"The source's channels should be copied and the extra channel padded with its default")
+		t.Log("Contract: copying onto a fixture with more channels pads with defaults")
+	})
+
+	t.Run("ToFewerChannels", func(t *testing.T) {
+		err := copyValues(sourceLook, wide, targetLook, narrow)
+		values := lookChannels(t, client, ctx, targetLook, narrow)
+		if err != nil {
+			t.Logf("Contract: copying onto a fixture with fewer channels is rejected: %v", err)
+			assert.Equal(t, map[int]int{0: 9, 1: 9, 2: 9}, values, "A rejected copy should change nothing")
+			return
+		}
+		assert.Equal(t, map[int]int{0: 10, 1: 20, 2: 30}, values, "The source's channels should be truncated to the target's")
+		t.Log("Contract: copying onto a fixture with fewer channels truncates")
+	})
+
+	t.Run("SourceNotInLook", func(t *testing.T) {
+		err := copyValues(sourceLook, narrow, targetLook, parB)
+		assert.Error(t, err, "Copying a fixture the source look does not hold should be an error")
+		assert.Equal(t, map[int]int{0: 200, 1: 255, 2: 100, 3: 50}, lookChannels(t, client, ctx, targetLook, parB),
+			"A failed copy should change nothing")
+	})
+
+	t.Run("SourceUnchanged", func(t *testing.T) {
+		for fixtureID, want := range sourceBefore {
+			assert.Equal(t, want, lookChannels(t, client, ctx, sourceLook, fixtureID), "Copying should not modify the source look")
+		}
+	})
+
+	t.Run("Undo", func(t *testing.T) {
+		undoLook, err := fixtures.CreateLook(ctx, client, projectID, "Copy Undo", []fixtures.FixtureValues{
+			{FixtureID: parB, Values: []int{7, 7, 7, 7}},
+		})
+		require.NoError(t, err)
+		require.NoError(t, copyValues(sourceLook, parA, undoLook, parB))
+		require.Equal(t, map[int]int{0: 200, 1: 255, 2: 100, 3: 50}, lookChannels(t, client, ctx, undoLook, parB))
+
+		var resp struct {
+			Undo struct {
+				Success bool `json:"success"`
+			} `json:"undo"`
+		}
+		err = client.Mutate(ctx, `mutation Undo($projectId: ID!) { undo(projectId: $projectId) { success } }`,
+			map[string]interface{}{"projectId": projectID}, &resp)
+		require.NoError(t, err)
+		require.True(t, resp.Undo.Success, "Undo should succeed")
+		assert.Equal(t, map[int]int{0: 7, 1: 7, 2: 7, 3: 7}, lookChannels(t, client, ctx, undoLook, parB),
+			"Undo should restore the target's values")
+		assert.Equal(t, sourceBefore[parA], lookChannels(t, client, ctx, sourceLook, parA), "Undo should not touch the source")
+	})
+}
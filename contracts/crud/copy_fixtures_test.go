@@ -6,7 +6,9 @@ import (
 	"testing"
 	"time"
 
+	_ "github.com/bbernstein/lacylights-test/pkg/artifacts"
 	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/bbernstein/lacylights-test/pkg/lacyapi"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -17,28 +19,15 @@ func TestCopyFixturesToLooks(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	client := graphql.NewClient("")
+	client := graphql.NewTestClient(t, "")
+	api := lacyapi.New(client)
 
 	// Create project
-	var projectResp struct {
-		CreateProject struct {
-			ID string `json:"id"`
-		} `json:"createProject"`
-	}
-
-	err := client.Mutate(ctx, `
-		mutation CreateProject($input: CreateProjectInput!) {
-			createProject(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{"name": "Copy Fixtures Test Project"},
-	}, &projectResp)
-
+	project, err := api.CreateProject(ctx, lacyapi.CreateProjectInput{Name: "Copy Fixtures Test Project"})
 	require.NoError(t, err)
-	projectID := projectResp.CreateProject.ID
+	projectID := project.ID
 	defer func() {
-		_ = client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
-			map[string]interface{}{"id": projectID}, nil)
+		_, _ = api.DeleteProject(ctx, projectID)
 	}()
 
 	// Create fixtures
@@ -106,69 +95,45 @@ func TestCopyFixturesToLooks(t *testing.T) {
 	sourceLookID := sourceLookResp.CreateLook.ID
 
 	// Create target looks with different initial values
-	var target1Resp struct {
-		CreateLook struct {
-			ID string `json:"id"`
-		} `json:"createLook"`
-	}
-
-	err = client.Mutate(ctx, `
-		mutation CreateLook($input: CreateLookInput!) {
-			createLook(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"projectId":   projectID,
-			"name":        "Target Look 1",
-			"description": "First target",
-			"fixtureValues": []map[string]interface{}{
-				{
-					"fixtureId": fixture1ID,
-					"channels": []map[string]interface{}{
-						{"offset": 0, "value": 50},
-					},
+	target1, err := api.CreateLook(ctx, lacyapi.CreateLookInput{
+		ProjectID:   projectID,
+		Name:        "Target Look 1",
+		Description: lacyapi.Ptr("First target"),
+		FixtureValues: []lacyapi.FixtureValueInput{
+			{
+				FixtureID: fixture1ID,
+				Channels: []lacyapi.ChannelValueInput{
+					{Offset: 0, Value: 50},
 				},
 			},
 		},
-	}, &target1Resp)
+	})
 
 	require.NoError(t, err)
-	target1ID := target1Resp.CreateLook.ID
-
-	var target2Resp struct {
-		CreateLook struct {
-			ID string `json:"id"`
-		} `json:"createLook"`
-	}
-
-	err = client.Mutate(ctx, `
-		mutation CreateLook($input: CreateLookInput!) {
-			createLook(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"projectId":   projectID,
-			"name":        "Target Look 2",
-			"description": "Second target",
-			"fixtureValues": []map[string]interface{}{
-				{
-					"fixtureId": fixture1ID,
-					"channels": []map[string]interface{}{
-						{"offset": 0, "value": 75},
-					},
+	target1ID := target1.ID
+
+	target2, err := api.CreateLook(ctx, lacyapi.CreateLookInput{
+		ProjectID:   projectID,
+		Name:        "Target Look 2",
+		Description: lacyapi.Ptr("Second target"),
+		FixtureValues: []lacyapi.FixtureValueInput{
+			{
+				FixtureID: fixture1ID,
+				Channels: []lacyapi.ChannelValueInput{
+					{Offset: 0, Value: 75},
 				},
-				{
-					"fixtureId": fixture2ID,
-					"channels": []map[string]interface{}{
-						{"offset": 0, "value": 25},
-					},
+			},
+			{
+				FixtureID: fixture2ID,
+				Channels: []lacyapi.ChannelValueInput{
+					{Offset: 0, Value: 25},
 				},
 			},
 		},
-	}, &target2Resp)
+	})
 
 	require.NoError(t, err)
-	target2ID := target2Resp.CreateLook.ID
+	target2ID := target2.ID
 
 	t.Run("BasicCopyFlow", func(t *testing.T) {
 		// Copy fixture1 from source to both targets
@@ -242,26 +207,14 @@ func TestCopyFixturesToLooks(t *testing.T) {
 
 	t.Run("CopyMultipleFixtures", func(t *testing.T) {
 		// Create a fresh target look
-		var freshTargetResp struct {
-			CreateLook struct {
-				ID string `json:"id"`
-			} `json:"createLook"`
-		}
-
-		err := client.Mutate(ctx, `
-			mutation CreateLook($input: CreateLookInput!) {
-				createLook(input: $input) { id }
-			}
-		`, map[string]interface{}{
-			"input": map[string]interface{}{
-				"projectId":     projectID,
-				"name":          "Fresh Target",
-				"fixtureValues": []map[string]interface{}{},
-			},
-		}, &freshTargetResp)
+		freshTarget, err := api.CreateLook(ctx, lacyapi.CreateLookInput{
+			ProjectID:     projectID,
+			Name:          "Fresh Target",
+			FixtureValues: []lacyapi.FixtureValueInput{},
+		})
 
 		require.NoError(t, err)
-		freshTargetID := freshTargetResp.CreateLook.ID
+		freshTargetID := freshTarget.ID
 
 		// Copy multiple fixtures
 		var copyResp struct {
@@ -404,90 +357,53 @@ func TestCopyFixturesToLooks_UndoSupport(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	client := graphql.NewClient("")
+	client := graphql.NewTestClient(t, "")
+	api := lacyapi.New(client)
 
 	// Create project
-	var projectResp struct {
-		CreateProject struct {
-			ID string `json:"id"`
-		} `json:"createProject"`
-	}
-
-	err := client.Mutate(ctx, `
-		mutation CreateProject($input: CreateProjectInput!) {
-			createProject(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{"name": "Copy Fixtures Undo Test"},
-	}, &projectResp)
-
+	project, err := api.CreateProject(ctx, lacyapi.CreateProjectInput{Name: "Copy Fixtures Undo Test"})
 	require.NoError(t, err)
-	projectID := projectResp.CreateProject.ID
+	projectID := project.ID
 	defer func() {
-		_ = client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
-			map[string]interface{}{"id": projectID}, nil)
+		_, _ = api.DeleteProject(ctx, projectID)
 	}()
 
 	// Create fixture
 	fixtureID := createTestFixture(t, client, ctx, projectID, "Undo Test Fixture", 1)
 
 	// Create source look with value 255
-	var sourceLookResp struct {
-		CreateLook struct {
-			ID string `json:"id"`
-		} `json:"createLook"`
-	}
-
-	err = client.Mutate(ctx, `
-		mutation CreateLook($input: CreateLookInput!) {
-			createLook(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"projectId": projectID,
-			"name":      "Undo Source Look",
-			"fixtureValues": []map[string]interface{}{
-				{
-					"fixtureId": fixtureID,
-					"channels": []map[string]interface{}{
-						{"offset": 0, "value": 255},
-					},
+	sourceLook, err := api.CreateLook(ctx, lacyapi.CreateLookInput{
+		ProjectID: projectID,
+		Name:      "Undo Source Look",
+		FixtureValues: []lacyapi.FixtureValueInput{
+			{
+				FixtureID: fixtureID,
+				Channels: []lacyapi.ChannelValueInput{
+					{Offset: 0, Value: 255},
 				},
 			},
 		},
-	}, &sourceLookResp)
+	})
 
 	require.NoError(t, err)
-	sourceLookID := sourceLookResp.CreateLook.ID
+	sourceLookID := sourceLook.ID
 
 	// Create target look with value 50
-	var targetLookResp struct {
-		CreateLook struct {
-			ID string `json:"id"`
-		} `json:"createLook"`
-	}
-
-	err = client.Mutate(ctx, `
-		mutation CreateLook($input: CreateLookInput!) {
-			createLook(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"projectId": projectID,
-			"name":      "Undo Target Look",
-			"fixtureValues": []map[string]interface{}{
-				{
-					"fixtureId": fixtureID,
-					"channels": []map[string]interface{}{
-						{"offset": 0, "value": 50},
-					},
+	targetLook, err := api.CreateLook(ctx, lacyapi.CreateLookInput{
+		ProjectID: projectID,
+		Name:      "Undo Target Look",
+		FixtureValues: []lacyapi.FixtureValueInput{
+			{
+				FixtureID: fixtureID,
+				Channels: []lacyapi.ChannelValueInput{
+					{Offset: 0, Value: 50},
 				},
 			},
 		},
-	}, &targetLookResp)
+	})
 
 	require.NoError(t, err)
-	targetLookID := targetLookResp.CreateLook.ID
+	targetLookID := targetLook.ID
 
 	// Helper to get fixture value from a look
 	getFixtureValue := func(lookID, fixtureID string) int {
@@ -568,9 +484,9 @@ func TestCopyFixturesToLooks_UndoSupport(t *testing.T) {
 	// Undo the operation
 	var undoResp struct {
 		Undo struct {
-			Success           bool   `json:"success"`
-			Message           string `json:"message"`
-			RestoredEntityID  string `json:"restoredEntityId"`
+			Success          bool   `json:"success"`
+			Message          string `json:"message"`
+			RestoredEntityID string `json:"restoredEntityId"`
 		} `json:"undo"`
 	}
 
@@ -627,104 +543,55 @@ func TestCopyFixturesToLooks_AffectedCueCount(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	client := graphql.NewClient("")
+	client := graphql.NewTestClient(t, "")
+	api := lacyapi.New(client)
 
 	// Create project
-	var projectResp struct {
-		CreateProject struct {
-			ID string `json:"id"`
-		} `json:"createProject"`
-	}
-
-	err := client.Mutate(ctx, `
-		mutation CreateProject($input: CreateProjectInput!) {
-			createProject(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{"name": "Affected Cue Count Test"},
-	}, &projectResp)
-
+	project, err := api.CreateProject(ctx, lacyapi.CreateProjectInput{Name: "Affected Cue Count Test"})
 	require.NoError(t, err)
-	projectID := projectResp.CreateProject.ID
+	projectID := project.ID
 	defer func() {
-		_ = client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
-			map[string]interface{}{"id": projectID}, nil)
+		_, _ = api.DeleteProject(ctx, projectID)
 	}()
 
 	// Create fixture
 	fixtureID := createTestFixture(t, client, ctx, projectID, "Cue Count Test Fixture", 1)
 
 	// Create source look
-	var sourceLookResp struct {
-		CreateLook struct {
-			ID string `json:"id"`
-		} `json:"createLook"`
-	}
-
-	err = client.Mutate(ctx, `
-		mutation CreateLook($input: CreateLookInput!) {
-			createLook(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"projectId": projectID,
-			"name":      "Cue Source Look",
-			"fixtureValues": []map[string]interface{}{
-				{
-					"fixtureId": fixtureID,
-					"channels": []map[string]interface{}{
-						{"offset": 0, "value": 200},
-					},
+	sourceLook, err := api.CreateLook(ctx, lacyapi.CreateLookInput{
+		ProjectID: projectID,
+		Name:      "Cue Source Look",
+		FixtureValues: []lacyapi.FixtureValueInput{
+			{
+				FixtureID: fixtureID,
+				Channels: []lacyapi.ChannelValueInput{
+					{Offset: 0, Value: 200},
 				},
 			},
 		},
-	}, &sourceLookResp)
+	})
 
 	require.NoError(t, err)
-	sourceLookID := sourceLookResp.CreateLook.ID
+	sourceLookID := sourceLook.ID
 
 	// Create target look
-	var targetLookResp struct {
-		CreateLook struct {
-			ID string `json:"id"`
-		} `json:"createLook"`
-	}
-
-	err = client.Mutate(ctx, `
-		mutation CreateLook($input: CreateLookInput!) {
-			createLook(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"projectId":     projectID,
-			"name":          "Cue Target Look",
-			"fixtureValues": []map[string]interface{}{},
-		},
-	}, &targetLookResp)
+	targetLook, err := api.CreateLook(ctx, lacyapi.CreateLookInput{
+		ProjectID:     projectID,
+		Name:          "Cue Target Look",
+		FixtureValues: []lacyapi.FixtureValueInput{},
+	})
 
 	require.NoError(t, err)
-	targetLookID := targetLookResp.CreateLook.ID
+	targetLookID := targetLook.ID
 
 	// Create a cue list with cues using the target look
-	var cueListResp struct {
-		CreateCueList struct {
-			ID string `json:"id"`
-		} `json:"createCueList"`
-	}
-
-	err = client.Mutate(ctx, `
-		mutation CreateCueList($input: CreateCueListInput!) {
-			createCueList(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"projectId": projectID,
-			"name":      "Test Cue List",
-		},
-	}, &cueListResp)
+	cueList, err := api.CreateCueList(ctx, lacyapi.CreateCueListInput{
+		ProjectID: projectID,
+		Name:      "Test Cue List",
+	})
 
 	require.NoError(t, err)
-	cueListID := cueListResp.CreateCueList.ID
+	cueListID := cueList.ID
 
 	// Add cues that use the target look
 	for i := 1; i <= 3; i++ {
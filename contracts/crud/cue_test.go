@@ -7,32 +7,21 @@ import (
 	"time"
 
 	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/bbernstein/lacylights-test/pkg/lacyapi"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 // createTestLook creates a look for cue tests.
 func createTestLook(t *testing.T, client *graphql.Client, ctx context.Context, projectID string, name string) string {
-	var resp struct {
-		CreateLook struct {
-			ID string `json:"id"`
-		} `json:"createLook"`
-	}
-
-	err := client.Mutate(ctx, `
-		mutation CreateLook($input: CreateLookInput!) {
-			createLook(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"projectId":     projectID,
-			"name":          name,
-			"fixtureValues": []map[string]interface{}{},
-		},
-	}, &resp)
+	look, err := lacyapi.New(client).CreateLook(ctx, lacyapi.CreateLookInput{
+		ProjectID:     projectID,
+		Name:          name,
+		FixtureValues: []lacyapi.FixtureValueInput{},
+	})
 
 	require.NoError(t, err)
-	return resp.CreateLook.ID
+	return look.ID
 }
 
 // TestCueListCRUD tests all cue list CRUD operations.
@@ -40,69 +29,34 @@ func TestCueListCRUD(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	client := graphql.NewClient("")
+	client := graphql.NewTestClient(t, "")
+	api := lacyapi.New(client)
 
 	// Create project
-	var projectResp struct {
-		CreateProject struct {
-			ID string `json:"id"`
-		} `json:"createProject"`
-	}
-
-	err := client.Mutate(ctx, `
-		mutation CreateProject($input: CreateProjectInput!) {
-			createProject(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{"name": "Cue List CRUD Test Project"},
-	}, &projectResp)
-
+	project, err := api.CreateProject(ctx, lacyapi.CreateProjectInput{Name: "Cue List CRUD Test Project"})
 	require.NoError(t, err)
-	projectID := projectResp.CreateProject.ID
+	projectID := project.ID
 	defer func() {
-		_ = client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
-			map[string]interface{}{"id": projectID}, nil)
+		_, _ = api.DeleteProject(ctx, projectID)
 	}()
 
 	// CREATE
 	t.Run("CreateCueList", func(t *testing.T) {
-		var createResp struct {
-			CreateCueList struct {
-				ID          string  `json:"id"`
-				Name        string  `json:"name"`
-				Description *string `json:"description"`
-				Loop        bool    `json:"loop"`
-				CueCount    int     `json:"cueCount"`
-			} `json:"createCueList"`
-		}
-
-		err := client.Mutate(ctx, `
-			mutation CreateCueList($input: CreateCueListInput!) {
-				createCueList(input: $input) {
-					id
-					name
-					description
-					loop
-					cueCount
-				}
-			}
-		`, map[string]interface{}{
-			"input": map[string]interface{}{
-				"projectId":   projectID,
-				"name":        "Act 1",
-				"description": "First act cue list",
-				"loop":        false,
-			},
-		}, &createResp)
+		cueList, err := api.CreateCueList(ctx, lacyapi.CreateCueListInput{
+			ProjectID:   projectID,
+			Name:        "Act 1",
+			Description: lacyapi.Ptr("First act cue list"),
+			Loop:        lacyapi.Ptr(false),
+		})
 
 		require.NoError(t, err)
-		assert.NotEmpty(t, createResp.CreateCueList.ID)
-		assert.Equal(t, "Act 1", createResp.CreateCueList.Name)
-		assert.NotNil(t, createResp.CreateCueList.Description)
-		assert.False(t, createResp.CreateCueList.Loop)
-		assert.Equal(t, 0, createResp.CreateCueList.CueCount)
+		assert.NotEmpty(t, cueList.ID)
+		assert.Equal(t, "Act 1", cueList.Name)
+		assert.NotNil(t, cueList.Description)
+		assert.False(t, cueList.Loop)
+		assert.Equal(t, 0, cueList.CueCount)
 
-		cueListID := createResp.CreateCueList.ID
+		cueListID := cueList.ID
 
 		// READ
 		t.Run("ReadCueList", func(t *testing.T) {
@@ -145,65 +99,26 @@ func TestCueListCRUD(t *testing.T) {
 
 		// UPDATE
 		t.Run("UpdateCueList", func(t *testing.T) {
-			var updateResp struct {
-				UpdateCueList struct {
-					ID          string  `json:"id"`
-					Name        string  `json:"name"`
-					Description *string `json:"description"`
-					Loop        bool    `json:"loop"`
-				} `json:"updateCueList"`
-			}
-
-			err := client.Mutate(ctx, `
-				mutation UpdateCueList($id: ID!, $input: CreateCueListInput!) {
-					updateCueList(id: $id, input: $input) {
-						id
-						name
-						description
-						loop
-					}
-				}
-			`, map[string]interface{}{
-				"id": cueListID,
-				"input": map[string]interface{}{
-					"projectId":   projectID,
-					"name":        "Act 1 - Updated",
-					"description": "Updated description",
-					"loop":        true,
-				},
-			}, &updateResp)
+			cueList, err := api.UpdateCueList(ctx, cueListID, lacyapi.CreateCueListInput{
+				ProjectID:   projectID,
+				Name:        "Act 1 - Updated",
+				Description: lacyapi.Ptr("Updated description"),
+				Loop:        lacyapi.Ptr(true),
+			})
 
 			require.NoError(t, err)
-			assert.Equal(t, "Act 1 - Updated", updateResp.UpdateCueList.Name)
-			assert.True(t, updateResp.UpdateCueList.Loop)
+			assert.Equal(t, "Act 1 - Updated", cueList.Name)
+			assert.True(t, cueList.Loop)
 		})
 
 		// LIST
 		t.Run("ListCueLists", func(t *testing.T) {
-			var listResp struct {
-				CueLists []struct {
-					ID            string  `json:"id"`
-					Name          string  `json:"name"`
-					CueCount      int     `json:"cueCount"`
-					TotalDuration float64 `json:"totalDuration"`
-				} `json:"cueLists"`
-			}
-
-			err := client.Query(ctx, `
-				query ListCueLists($projectId: ID!) {
-					cueLists(projectId: $projectId) {
-						id
-						name
-						cueCount
-						totalDuration
-					}
-				}
-			`, map[string]interface{}{"projectId": projectID}, &listResp)
+			cueLists, err := api.CueLists(ctx, projectID)
 
 			require.NoError(t, err)
-			assert.NotEmpty(t, listResp.CueLists)
+			assert.NotEmpty(t, cueLists)
 			found := false
-			for _, cl := range listResp.CueLists {
+			for _, cl := range cueLists {
 				if cl.ID == cueListID {
 					found = true
 					break
@@ -214,36 +129,16 @@ func TestCueListCRUD(t *testing.T) {
 
 		// DELETE
 		t.Run("DeleteCueList", func(t *testing.T) {
-			var deleteResp struct {
-				DeleteCueList bool `json:"deleteCueList"`
-			}
-
-			err := client.Mutate(ctx, `
-				mutation DeleteCueList($id: ID!) {
-					deleteCueList(id: $id)
-				}
-			`, map[string]interface{}{"id": cueListID}, &deleteResp)
+			deleted, err := api.DeleteCueList(ctx, cueListID)
 
 			require.NoError(t, err)
-			assert.True(t, deleteResp.DeleteCueList)
+			assert.True(t, deleted)
 
 			// Verify deletion
-			var verifyResp struct {
-				CueList *struct {
-					ID string `json:"id"`
-				} `json:"cueList"`
-			}
-
-			err = client.Query(ctx, `
-				query GetCueList($id: ID!) {
-					cueList(id: $id) {
-						id
-					}
-				}
-			`, map[string]interface{}{"id": cueListID}, &verifyResp)
+			cueList, err := api.CueList(ctx, cueListID)
 
 			if err == nil {
-				assert.Nil(t, verifyResp.CueList, "Deleted cue list should not be found")
+				assert.Nil(t, cueList, "Deleted cue list should not be found")
 			}
 		})
 	})
@@ -254,52 +149,27 @@ func TestCueCRUD(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	client := graphql.NewClient("")
+	client := graphql.NewTestClient(t, "")
+	api := lacyapi.New(client)
 
 	// Create project
-	var projectResp struct {
-		CreateProject struct {
-			ID string `json:"id"`
-		} `json:"createProject"`
-	}
-
-	err := client.Mutate(ctx, `
-		mutation CreateProject($input: CreateProjectInput!) {
-			createProject(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{"name": "Cue CRUD Test Project"},
-	}, &projectResp)
-
+	project, err := api.CreateProject(ctx, lacyapi.CreateProjectInput{Name: "Cue CRUD Test Project"})
 	require.NoError(t, err)
-	projectID := projectResp.CreateProject.ID
+	projectID := project.ID
 	defer func() {
-		_ = client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
-			map[string]interface{}{"id": projectID}, nil)
+		_, _ = api.DeleteProject(ctx, projectID)
 	}()
 
 	// Create look and cue list
 	lookID := createTestLook(t, client, ctx, projectID, "Cue Test Look")
 
-	var cueListResp struct {
-		CreateCueList struct {
-			ID string `json:"id"`
-		} `json:"createCueList"`
-	}
-
-	err = client.Mutate(ctx, `
-		mutation CreateCueList($input: CreateCueListInput!) {
-			createCueList(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"projectId": projectID,
-			"name":      "Cue Test List",
-		},
-	}, &cueListResp)
+	cueList, err := api.CreateCueList(ctx, lacyapi.CreateCueListInput{
+		ProjectID: projectID,
+		Name:      "Cue Test List",
+	})
 
 	require.NoError(t, err)
-	cueListID := cueListResp.CreateCueList.ID
+	cueListID := cueList.ID
 
 	// CREATE CUE
 	t.Run("CreateCue", func(t *testing.T) {
@@ -313,7 +183,7 @@ func TestCueCRUD(t *testing.T) {
 				FollowTime  *float64 `json:"followTime"`
 				EasingType  *string  `json:"easingType"`
 				Notes       *string  `json:"notes"`
-				Look struct {
+				Look        struct {
 					ID   string `json:"id"`
 					Name string `json:"name"`
 				} `json:"look"`
@@ -403,73 +273,32 @@ func TestCueCRUD(t *testing.T) {
 
 		// UPDATE CUE
 		t.Run("UpdateCue", func(t *testing.T) {
-			var updateResp struct {
-				UpdateCue struct {
-					ID          string  `json:"id"`
-					Name        string  `json:"name"`
-					FadeInTime  float64 `json:"fadeInTime"`
-					FadeOutTime float64 `json:"fadeOutTime"`
-				} `json:"updateCue"`
-			}
-
-			err := client.Mutate(ctx, `
-				mutation UpdateCue($id: ID!, $input: CreateCueInput!) {
-					updateCue(id: $id, input: $input) {
-						id
-						name
-						fadeInTime
-						fadeOutTime
-					}
-				}
-			`, map[string]interface{}{
-				"id": cueID,
-				"input": map[string]interface{}{
-					"cueListId":   cueListID,
-					"lookId":      lookID,
-					"name":        "Updated Opening Cue",
-					"cueNumber":   1.0,
-					"fadeInTime":  5.0,
-					"fadeOutTime": 3.0,
-				},
-			}, &updateResp)
+			cue, err := api.UpdateCue(ctx, cueID, lacyapi.CreateCueInput{
+				CueListID:   cueListID,
+				LookID:      lookID,
+				Name:        "Updated Opening Cue",
+				CueNumber:   1.0,
+				FadeInTime:  5.0,
+				FadeOutTime: 3.0,
+			})
 
 			require.NoError(t, err)
-			assert.Equal(t, "Updated Opening Cue", updateResp.UpdateCue.Name)
-			assert.Equal(t, 5.0, updateResp.UpdateCue.FadeInTime)
+			assert.Equal(t, "Updated Opening Cue", cue.Name)
+			assert.Equal(t, 5.0, cue.FadeInTime)
 		})
 
 		// DELETE CUE
 		t.Run("DeleteCue", func(t *testing.T) {
-			var deleteResp struct {
-				DeleteCue bool `json:"deleteCue"`
-			}
-
-			err := client.Mutate(ctx, `
-				mutation DeleteCue($id: ID!) {
-					deleteCue(id: $id)
-				}
-			`, map[string]interface{}{"id": cueID}, &deleteResp)
+			deleted, err := api.DeleteCue(ctx, cueID)
 
 			require.NoError(t, err)
-			assert.True(t, deleteResp.DeleteCue)
+			assert.True(t, deleted)
 
 			// Verify deletion
-			var verifyResp struct {
-				Cue *struct {
-					ID string `json:"id"`
-				} `json:"cue"`
-			}
-
-			err = client.Query(ctx, `
-				query GetCue($id: ID!) {
-					cue(id: $id) {
-						id
-					}
-				}
-			`, map[string]interface{}{"id": cueID}, &verifyResp)
+			cue, err := api.Cue(ctx, cueID)
 
 			if err == nil {
-				assert.Nil(t, verifyResp.Cue, "Deleted cue should not be found")
+				assert.Nil(t, cue, "Deleted cue should not be found")
 			}
 		})
 	})
@@ -480,52 +309,27 @@ func TestCueOrdering(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	client := graphql.NewClient("")
+	client := graphql.NewTestClient(t, "")
+	api := lacyapi.New(client)
 
 	// Create project
-	var projectResp struct {
-		CreateProject struct {
-			ID string `json:"id"`
-		} `json:"createProject"`
-	}
-
-	err := client.Mutate(ctx, `
-		mutation CreateProject($input: CreateProjectInput!) {
-			createProject(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{"name": "Cue Ordering Test Project"},
-	}, &projectResp)
-
+	project, err := api.CreateProject(ctx, lacyapi.CreateProjectInput{Name: "Cue Ordering Test Project"})
 	require.NoError(t, err)
-	projectID := projectResp.CreateProject.ID
+	projectID := project.ID
 	defer func() {
-		_ = client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
-			map[string]interface{}{"id": projectID}, nil)
+		_, _ = api.DeleteProject(ctx, projectID)
 	}()
 
 	// Create look and cue list
 	lookID := createTestLook(t, client, ctx, projectID, "Ordering Test Look")
 
-	var cueListResp struct {
-		CreateCueList struct {
-			ID string `json:"id"`
-		} `json:"createCueList"`
-	}
-
-	err = client.Mutate(ctx, `
-		mutation CreateCueList($input: CreateCueListInput!) {
-			createCueList(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"projectId": projectID,
-			"name":      "Ordering Test List",
-		},
-	}, &cueListResp)
+	cueList, err := api.CreateCueList(ctx, lacyapi.CreateCueListInput{
+		ProjectID: projectID,
+		Name:      "Ordering Test List",
+	})
 
 	require.NoError(t, err)
-	cueListID := cueListResp.CreateCueList.ID
+	cueListID := cueList.ID
 
 	// Create multiple cues
 	cueIDs := make([]string, 3)
@@ -623,52 +427,27 @@ func TestBulkCueOperations(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	client := graphql.NewClient("")
+	client := graphql.NewTestClient(t, "")
+	api := lacyapi.New(client)
 
 	// Create project
-	var projectResp struct {
-		CreateProject struct {
-			ID string `json:"id"`
-		} `json:"createProject"`
-	}
-
-	err := client.Mutate(ctx, `
-		mutation CreateProject($input: CreateProjectInput!) {
-			createProject(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{"name": "Bulk Cue Test Project"},
-	}, &projectResp)
-
+	project, err := api.CreateProject(ctx, lacyapi.CreateProjectInput{Name: "Bulk Cue Test Project"})
 	require.NoError(t, err)
-	projectID := projectResp.CreateProject.ID
+	projectID := project.ID
 	defer func() {
-		_ = client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
-			map[string]interface{}{"id": projectID}, nil)
+		_, _ = api.DeleteProject(ctx, projectID)
 	}()
 
 	// Create look and cue list
 	lookID := createTestLook(t, client, ctx, projectID, "Bulk Cue Test Look")
 
-	var cueListResp struct {
-		CreateCueList struct {
-			ID string `json:"id"`
-		} `json:"createCueList"`
-	}
-
-	err = client.Mutate(ctx, `
-		mutation CreateCueList($input: CreateCueListInput!) {
-			createCueList(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"projectId": projectID,
-			"name":      "Bulk Cue Test List",
-		},
-	}, &cueListResp)
+	cueList, err := api.CreateCueList(ctx, lacyapi.CreateCueListInput{
+		ProjectID: projectID,
+		Name:      "Bulk Cue Test List",
+	})
 
 	require.NoError(t, err)
-	cueListID := cueListResp.CreateCueList.ID
+	cueListID := cueList.ID
 
 	// Create multiple cues
 	cueIDs := make([]string, 3)
@@ -738,104 +517,55 @@ func TestCueListWithLookDetails(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	client := graphql.NewClient("")
+	client := graphql.NewTestClient(t, "")
+	api := lacyapi.New(client)
 
 	// Create project
-	var projectResp struct {
-		CreateProject struct {
-			ID string `json:"id"`
-		} `json:"createProject"`
-	}
-
-	err := client.Mutate(ctx, `
-		mutation CreateProject($input: CreateProjectInput!) {
-			createProject(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{"name": "Look Details Test Project"},
-	}, &projectResp)
-
+	project, err := api.CreateProject(ctx, lacyapi.CreateProjectInput{Name: "Look Details Test Project"})
 	require.NoError(t, err)
-	projectID := projectResp.CreateProject.ID
+	projectID := project.ID
 	defer func() {
-		_ = client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
-			map[string]interface{}{"id": projectID}, nil)
+		_, _ = api.DeleteProject(ctx, projectID)
 	}()
 
 	// Create fixture and look with values
 	definitionID := getOrCreateFixtureDefinition(t, client, ctx)
 
-	var fixtureResp struct {
-		CreateFixtureInstance struct {
-			ID string `json:"id"`
-		} `json:"createFixtureInstance"`
-	}
-
-	err = client.Mutate(ctx, `
-		mutation CreateFixtureInstance($input: CreateFixtureInstanceInput!) {
-			createFixtureInstance(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"projectId":    projectID,
-			"definitionId": definitionID,
-			"name":         "Look Details Fixture",
-			"universe":     1,
-			"startChannel": 1,
-		},
-	}, &fixtureResp)
+	fixture, err := api.CreateFixtureInstance(ctx, lacyapi.CreateFixtureInstanceInput{
+		ProjectID:    projectID,
+		DefinitionID: definitionID,
+		Name:         "Look Details Fixture",
+		Universe:     1,
+		StartChannel: 1,
+	})
 
 	require.NoError(t, err)
-	fixtureID := fixtureResp.CreateFixtureInstance.ID
-
-	var lookResp struct {
-		CreateLook struct {
-			ID string `json:"id"`
-		} `json:"createLook"`
-	}
-
-	err = client.Mutate(ctx, `
-		mutation CreateLook($input: CreateLookInput!) {
-			createLook(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"projectId": projectID,
-			"name":      "Look Details Test Look",
-			"fixtureValues": []map[string]interface{}{
-				{
-					"fixtureId": fixtureID,
-					"channels": []map[string]interface{}{
-						{"offset": 0, "value": 200},
-					},
+	fixtureID := fixture.ID
+
+	look, err := api.CreateLook(ctx, lacyapi.CreateLookInput{
+		ProjectID: projectID,
+		Name:      "Look Details Test Look",
+		FixtureValues: []lacyapi.FixtureValueInput{
+			{
+				FixtureID: fixtureID,
+				Channels: []lacyapi.ChannelValueInput{
+					{Offset: 0, Value: 200},
 				},
 			},
 		},
-	}, &lookResp)
+	})
 
 	require.NoError(t, err)
-	lookID := lookResp.CreateLook.ID
+	lookID := look.ID
 
 	// Create cue list with cue
-	var cueListResp struct {
-		CreateCueList struct {
-			ID string `json:"id"`
-		} `json:"createCueList"`
-	}
-
-	err = client.Mutate(ctx, `
-		mutation CreateCueList($input: CreateCueListInput!) {
-			createCueList(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"projectId": projectID,
-			"name":      "Look Details Test List",
-		},
-	}, &cueListResp)
+	cueList, err := api.CreateCueList(ctx, lacyapi.CreateCueListInput{
+		ProjectID: projectID,
+		Name:      "Look Details Test List",
+	})
 
 	require.NoError(t, err)
-	cueListID := cueListResp.CreateCueList.ID
+	cueListID := cueList.ID
 
 	err = client.Mutate(ctx, `
 		mutation CreateCue($input: CreateCueInput!) {
@@ -924,52 +654,27 @@ func TestSearchCues(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	client := graphql.NewClient("")
+	client := graphql.NewTestClient(t, "")
+	api := lacyapi.New(client)
 
 	// Create project
-	var projectResp struct {
-		CreateProject struct {
-			ID string `json:"id"`
-		} `json:"createProject"`
-	}
-
-	err := client.Mutate(ctx, `
-		mutation CreateProject($input: CreateProjectInput!) {
-			createProject(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{"name": "Search Cues Test Project"},
-	}, &projectResp)
-
+	project, err := api.CreateProject(ctx, lacyapi.CreateProjectInput{Name: "Search Cues Test Project"})
 	require.NoError(t, err)
-	projectID := projectResp.CreateProject.ID
+	projectID := project.ID
 	defer func() {
-		_ = client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
-			map[string]interface{}{"id": projectID}, nil)
+		_, _ = api.DeleteProject(ctx, projectID)
 	}()
 
 	// Create look and cue list
 	lookID := createTestLook(t, client, ctx, projectID, "Search Test Look")
 
-	var cueListResp struct {
-		CreateCueList struct {
-			ID string `json:"id"`
-		} `json:"createCueList"`
-	}
-
-	err = client.Mutate(ctx, `
-		mutation CreateCueList($input: CreateCueListInput!) {
-			createCueList(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"projectId": projectID,
-			"name":      "Search Test List",
-		},
-	}, &cueListResp)
+	cueList, err := api.CreateCueList(ctx, lacyapi.CreateCueListInput{
+		ProjectID: projectID,
+		Name:      "Search Test List",
+	})
 
 	require.NoError(t, err)
-	cueListID := cueListResp.CreateCueList.ID
+	cueListID := cueList.ID
 
 	// Create cues with different names
 	cueNames := []string{"Opening Look", "Blackout", "Final Bow", "Look Change"}
@@ -1042,53 +747,28 @@ func TestCueSkip(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	client := graphql.NewClient("")
+	client := graphql.NewTestClient(t, "")
+	api := lacyapi.New(client)
 
 	// Create project
-	var projectResp struct {
-		CreateProject struct {
-			ID string `json:"id"`
-		} `json:"createProject"`
-	}
-
-	err := client.Mutate(ctx, `
-		mutation CreateProject($input: CreateProjectInput!) {
-			createProject(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{"name": "Skip Cue Test Project"},
-	}, &projectResp)
-
+	project, err := api.CreateProject(ctx, lacyapi.CreateProjectInput{Name: "Skip Cue Test Project"})
 	require.NoError(t, err)
-	projectID := projectResp.CreateProject.ID
+	projectID := project.ID
 	defer func() {
-		_ = client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
-			map[string]interface{}{"id": projectID}, nil)
+		_, _ = api.DeleteProject(ctx, projectID)
 	}()
 
 	// Create look
 	lookID := createTestLook(t, client, ctx, projectID, "Skip Test Look")
 
 	// Create cue list
-	var cueListResp struct {
-		CreateCueList struct {
-			ID string `json:"id"`
-		} `json:"createCueList"`
-	}
-
-	err = client.Mutate(ctx, `
-		mutation CreateCueList($input: CreateCueListInput!) {
-			createCueList(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"projectId": projectID,
-			"name":      "Skip Test Cue List",
-		},
-	}, &cueListResp)
+	cueList, err := api.CreateCueList(ctx, lacyapi.CreateCueListInput{
+		ProjectID: projectID,
+		Name:      "Skip Test Cue List",
+	})
 
 	require.NoError(t, err)
-	cueListID := cueListResp.CreateCueList.ID
+	cueListID := cueList.ID
 
 	// Test creating a cue with skip=true
 	t.Run("CreateCueWithSkip", func(t *testing.T) {
@@ -0,0 +1,460 @@
+// Package crud provides CRUD contract tests for all LacyLights entities.
+package crud
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/bbernstein/lacylights-test/pkg/lacyapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// definitionChannel mirrors the channel fields needed to round-trip a definition
+// through updateFixtureDefinition.
+type definitionChannel struct {
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	Offset       int    `json:"offset"`
+	DefaultValue int    `json:"defaultValue"`
+	MinValue     int    `json:"minValue"`
+	MaxValue     int    `json:"maxValue"`
+}
+
+// createLifecycleDefinition creates a uniquely named definition with the given channel types.
+func createLifecycleDefinition(t *testing.T, client *graphql.Client, ctx context.Context, manufacturer, fixtureType string, channelTypes []string) (string, string) {
+	model := fmt.Sprintf("Lifecycle Model %d", time.Now().UnixNano())
+
+	channels := make([]map[string]interface{}, len(channelTypes))
+	for i, channelType := range channelTypes {
+		channels[i] = map[string]interface{}{
+			"name":         channelType,
+			"type":         channelType,
+			"offset":       i,
+			"defaultValue": 0,
+			"minValue":     0,
+			"maxValue":     255,
+		}
+	}
+
+	var resp struct {
+		CreateFixtureDefinition struct {
+			ID string `json:"id"`
+		} `json:"createFixtureDefinition"`
+	}
+
+	err := client.Mutate(ctx, `
+		mutation CreateFixtureDefinition($input: CreateFixtureDefinitionInput!) {
+			createFixtureDefinition(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"manufacturer": manufacturer,
+			"model":        model,
+			"type":         fixtureType,
+			"channels":     channels,
+		},
+	}, &resp)
+
+	require.NoError(t, err)
+	return resp.CreateFixtureDefinition.ID, model
+}
+
+// TestFixtureDefinitionUpdateWithExistingInstances verifies that updating a definition
+// which already has instances patched leaves those instances in a consistent state.
+// The server may either migrate instances to the new channel layout or keep them
+// pinned to the layout they were created with, but it must do one or the other
+// completely and never leave an instance half-updated.
+func TestFixtureDefinitionUpdateWithExistingInstances(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewTestClient(t, "")
+	api := lacyapi.New(client)
+
+	project, err := api.CreateProject(ctx, lacyapi.CreateProjectInput{Name: "Definition Versioning Test Project"})
+	require.NoError(t, err)
+	projectID := project.ID
+	defer func() {
+		_, _ = api.DeleteProject(ctx, projectID)
+	}()
+
+	definitionID, model := createLifecycleDefinition(t, client, ctx, "Test Lifecycle", "LED_PAR",
+		[]string{"INTENSITY", "RED"})
+	defer func() {
+		_ = client.Mutate(ctx, `mutation DeleteFixtureDefinition($id: ID!) { deleteFixtureDefinition(id: $id) }`,
+			map[string]interface{}{"id": definitionID}, nil)
+	}()
+
+	var fixtureResp struct {
+		CreateFixtureInstance struct {
+			ID           string `json:"id"`
+			ChannelCount int    `json:"channelCount"`
+		} `json:"createFixtureInstance"`
+	}
+
+	err = client.Mutate(ctx, `
+		mutation CreateFixtureInstance($input: CreateFixtureInstanceInput!) {
+			createFixtureInstance(input: $input) { id channelCount }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId":    projectID,
+			"definitionId": definitionID,
+			"name":         "Versioned Fixture",
+			"universe":     1,
+			"startChannel": 1,
+		},
+	}, &fixtureResp)
+
+	require.NoError(t, err)
+	fixtureID := fixtureResp.CreateFixtureInstance.ID
+	assert.Equal(t, 2, fixtureResp.CreateFixtureInstance.ChannelCount)
+
+	// Grow the definition from 2 to 3 channels
+	updatedModel := model + " v2"
+	err = client.Mutate(ctx, `
+		mutation UpdateFixtureDefinition($id: ID!, $input: CreateFixtureDefinitionInput!) {
+			updateFixtureDefinition(id: $id, input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"id": definitionID,
+		"input": map[string]interface{}{
+			"manufacturer": "Test Lifecycle",
+			"model":        updatedModel,
+			"type":         "LED_PAR",
+			"channels": []map[string]interface{}{
+				{"name": "INTENSITY", "type": "INTENSITY", "offset": 0, "defaultValue": 0, "minValue": 0, "maxValue": 255},
+				{"name": "RED", "type": "RED", "offset": 1, "defaultValue": 0, "minValue": 0, "maxValue": 255},
+				{"name": "GREEN", "type": "GREEN", "offset": 2, "defaultValue": 0, "minValue": 0, "maxValue": 255},
+			},
+		},
+	}, nil)
+
+	require.NoError(t, err)
+
+	var instanceResp struct {
+		FixtureInstance struct {
+			ID           string `json:"id"`
+			Model        string `json:"model"`
+			Universe     int    `json:"universe"`
+			StartChannel int    `json:"startChannel"`
+			ChannelCount int    `json:"channelCount"`
+			Channels     []struct {
+				Type string `json:"type"`
+			} `json:"channels"`
+		} `json:"fixtureInstance"`
+	}
+
+	err = client.Query(ctx, `
+		query GetFixtureInstance($id: ID!) {
+			fixtureInstance(id: $id) {
+				id
+				model
+				universe
+				startChannel
+				channelCount
+				channels {
+					type
+				}
+			}
+		}
+	`, map[string]interface{}{"id": fixtureID}, &instanceResp)
+
+	require.NoError(t, err)
+	instance := instanceResp.FixtureInstance
+
+	// Patch must never move as a side effect of a definition edit
+	assert.Equal(t, 1, instance.Universe)
+	assert.Equal(t, 1, instance.StartChannel)
+
+	// channelCount and channels must agree, whichever contract the server follows
+	assert.Equal(t, instance.ChannelCount, len(instance.Channels),
+		"channelCount should match the number of instance channels")
+
+	switch len(instance.Channels) {
+	case 3:
+		t.Log("Contract: existing instances migrate to the updated definition")
+		assert.Equal(t, "GREEN", instance.Channels[2].Type)
+	case 2:
+		t.Log("Contract: existing instances stay pinned to the definition they were created from")
+		assert.Equal(t, "INTENSITY", instance.Channels[0].Type)
+		assert.Equal(t, "RED", instance.Channels[1].Type)
+	default:
+		t.Errorf("Instance should have either the original (2) or updated (3) channel layout, got %d", len(instance.Channels))
+	}
+}
+
+// TestDeleteFixtureDefinitionInUse verifies that deleting a definition referenced by
+// a fixture instance is either rejected (leaving both intact) or cascades to the
+// instance. A dangling instance whose definition no longer exists is never acceptable.
+func TestDeleteFixtureDefinitionInUse(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewTestClient(t, "")
+	api := lacyapi.New(client)
+
+	project, err := api.CreateProject(ctx, lacyapi.CreateProjectInput{Name: "Definition In Use Test Project"})
+	require.NoError(t, err)
+	projectID := project.ID
+	defer func() {
+		_, _ = api.DeleteProject(ctx, projectID)
+	}()
+
+	definitionID, _ := createLifecycleDefinition(t, client, ctx, "Test Lifecycle", "DIMMER",
+		[]string{"INTENSITY"})
+	defer func() {
+		_ = client.Mutate(ctx, `mutation DeleteFixtureDefinition($id: ID!) { deleteFixtureDefinition(id: $id) }`,
+			map[string]interface{}{"id": definitionID}, nil)
+	}()
+
+	fixture, err := api.CreateFixtureInstance(ctx, lacyapi.CreateFixtureInstanceInput{
+		ProjectID:    projectID,
+		DefinitionID: definitionID,
+		Name:         "In Use Fixture",
+		Universe:     1,
+		StartChannel: 1,
+	})
+
+	require.NoError(t, err)
+	fixtureID := fixture.ID
+
+	var deleteResp struct {
+		DeleteFixtureDefinition bool `json:"deleteFixtureDefinition"`
+	}
+
+	deleteErr := client.Mutate(ctx, `
+		mutation DeleteFixtureDefinition($id: ID!) {
+			deleteFixtureDefinition(id: $id)
+		}
+	`, map[string]interface{}{"id": definitionID}, &deleteResp)
+
+	var definitionResp struct {
+		FixtureDefinition *struct {
+			ID string `json:"id"`
+		} `json:"fixtureDefinition"`
+	}
+	definitionErr := client.Query(ctx, `
+		query GetFixtureDefinition($id: ID!) {
+			fixtureDefinition(id: $id) { id }
+		}
+	`, map[string]interface{}{"id": definitionID}, &definitionResp)
+	definitionExists := definitionErr == nil && definitionResp.FixtureDefinition != nil
+
+	var instanceResp struct {
+		FixtureInstance *struct {
+			ID string `json:"id"`
+		} `json:"fixtureInstance"`
+	}
+	instanceErr := client.Query(ctx, `
+		query GetFixtureInstance($id: ID!) {
+			fixtureInstance(id: $id) { id }
+		}
+	`, map[string]interface{}{"id": fixtureID}, &instanceResp)
+	instanceExists := instanceErr == nil && instanceResp.FixtureInstance != nil
+
+	if deleteErr != nil || !deleteResp.DeleteFixtureDefinition {
+		t.Logf("Contract: deleting an in-use definition is rejected (err: %v)", deleteErr)
+		assert.True(t, definitionExists, "Rejected delete should leave the definition intact")
+		assert.True(t, instanceExists, "Rejected delete should leave the instance intact")
+		return
+	}
+
+	t.Log("Contract: deleting an in-use definition cascades to its instances")
+	assert.False(t, definitionExists, "Deleted definition should not be found")
+	assert.False(t, instanceExists, "Instances of a deleted definition should be removed, not left dangling")
+}
+
+// TestFixtureDefinitionFilterCombinations verifies that manufacturer, type, and
+// isBuiltIn filters narrow results exactly and combine with AND semantics.
+func TestFixtureDefinitionFilterCombinations(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewTestClient(t, "")
+
+	// Unique manufacturer so the filter result set is fully owned by this test
+	manufacturer := fmt.Sprintf("Filter Matrix %d", time.Now().UnixNano())
+
+	parID, _ := createLifecycleDefinition(t, client, ctx, manufacturer, "LED_PAR", []string{"RED", "GREEN", "BLUE"})
+	dimmerID, _ := createLifecycleDefinition(t, client, ctx, manufacturer, "DIMMER", []string{"INTENSITY"})
+	defer func() {
+		for _, id := range []string{parID, dimmerID} {
+			_ = client.Mutate(ctx, `mutation DeleteFixtureDefinition($id: ID!) { deleteFixtureDefinition(id: $id) }`,
+				map[string]interface{}{"id": id}, nil)
+		}
+	}()
+
+	listDefinitions := func(t *testing.T, filter map[string]interface{}) []string {
+		var resp struct {
+			FixtureDefinitions []struct {
+				ID string `json:"id"`
+			} `json:"fixtureDefinitions"`
+		}
+
+		err := client.Query(ctx, `
+			query ListFixtureDefinitions($filter: FixtureDefinitionFilter) {
+				fixtureDefinitions(filter: $filter) { id }
+			}
+		`, map[string]interface{}{"filter": filter}, &resp)
+
+		require.NoError(t, err)
+		ids := make([]string, len(resp.FixtureDefinitions))
+		for i, def := range resp.FixtureDefinitions {
+			ids[i] = def.ID
+		}
+		return ids
+	}
+
+	t.Run("ManufacturerOnly", func(t *testing.T) {
+		ids := listDefinitions(t, map[string]interface{}{"manufacturer": manufacturer})
+		assert.ElementsMatch(t, []string{parID, dimmerID}, ids)
+	})
+
+	t.Run("ManufacturerAndType", func(t *testing.T) {
+		ids := listDefinitions(t, map[string]interface{}{"manufacturer": manufacturer, "type": "DIMMER"})
+		assert.Equal(t, []string{dimmerID}, ids)
+	})
+
+	t.Run("ManufacturerAndNotBuiltIn", func(t *testing.T) {
+		ids := listDefinitions(t, map[string]interface{}{"manufacturer": manufacturer, "isBuiltIn": false})
+		assert.ElementsMatch(t, []string{parID, dimmerID}, ids)
+	})
+
+	t.Run("ManufacturerAndBuiltIn", func(t *testing.T) {
+		ids := listDefinitions(t, map[string]interface{}{"manufacturer": manufacturer, "isBuiltIn": true})
+		assert.Empty(t, ids, "User-created definitions should never be reported as built-in")
+	})
+}
+
+// TestBuiltInFixtureDefinitionsImmutable verifies that built-in library definitions
+// cannot be edited or deleted through the API.
+// Note: Built-in fixtures may not exist in all database configurations.
+func TestBuiltInFixtureDefinitionsImmutable(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewTestClient(t, "")
+
+	var listResp struct {
+		FixtureDefinitions []struct {
+			ID           string              `json:"id"`
+			Manufacturer string              `json:"manufacturer"`
+			Model        string              `json:"model"`
+			Type         string              `json:"type"`
+			Channels     []definitionChannel `json:"channels"`
+		} `json:"fixtureDefinitions"`
+	}
+
+	err := client.Query(ctx, `
+		query ListFixtureDefinitions($filter: FixtureDefinitionFilter) {
+			fixtureDefinitions(filter: $filter) {
+				id
+				manufacturer
+				model
+				type
+				channels {
+					name
+					type
+					offset
+					defaultValue
+					minValue
+					maxValue
+				}
+			}
+		}
+	`, map[string]interface{}{
+		"filter": map[string]interface{}{"isBuiltIn": true},
+	}, &listResp)
+
+	require.NoError(t, err)
+	if len(listResp.FixtureDefinitions) == 0 {
+		t.Skip("No built-in fixtures found - built-in fixtures may not be configured")
+	}
+
+	// Prefer a definition other than Generic Dimmer, which other suites rely on
+	builtIn := listResp.FixtureDefinitions[0]
+	for _, def := range listResp.FixtureDefinitions {
+		if def.Manufacturer != "Generic" || def.Model != "Dimmer" {
+			builtIn = def
+			break
+		}
+	}
+
+	t.Run("UpdateRejected", func(t *testing.T) {
+		var updateResp struct {
+			UpdateFixtureDefinition struct {
+				Model string `json:"model"`
+			} `json:"updateFixtureDefinition"`
+		}
+
+		err := client.Mutate(ctx, `
+			mutation UpdateFixtureDefinition($id: ID!, $input: CreateFixtureDefinitionInput!) {
+				updateFixtureDefinition(id: $id, input: $input) { model }
+			}
+		`, map[string]interface{}{
+			"id": builtIn.ID,
+			"input": map[string]interface{}{
+				"manufacturer": builtIn.Manufacturer,
+				"model":        builtIn.Model + " (modified)",
+				"type":         builtIn.Type,
+				"channels":     builtIn.Channels,
+			},
+		}, &updateResp)
+
+		if err == nil && updateResp.UpdateFixtureDefinition.Model != builtIn.Model {
+			// Put the library back the way we found it before failing
+			_ = client.Mutate(ctx, `
+				mutation UpdateFixtureDefinition($id: ID!, $input: CreateFixtureDefinitionInput!) {
+					updateFixtureDefinition(id: $id, input: $input) { id }
+				}
+			`, map[string]interface{}{
+				"id": builtIn.ID,
+				"input": map[string]interface{}{
+					"manufacturer": builtIn.Manufacturer,
+					"model":        builtIn.Model,
+					"type":         builtIn.Type,
+					"channels":     builtIn.Channels,
+				},
+			}, nil)
+			t.Errorf("Built-in definition %s/%s should not be editable", builtIn.Manufacturer, builtIn.Model)
+		}
+	})
+
+	t.Run("DeleteRejected", func(t *testing.T) {
+		var deleteResp struct {
+			DeleteFixtureDefinition bool `json:"deleteFixtureDefinition"`
+		}
+
+		err := client.Mutate(ctx, `
+			mutation DeleteFixtureDefinition($id: ID!) {
+				deleteFixtureDefinition(id: $id)
+			}
+		`, map[string]interface{}{"id": builtIn.ID}, &deleteResp)
+
+		if err == nil {
+			assert.False(t, deleteResp.DeleteFixtureDefinition, "Built-in definition should not be deletable")
+		}
+
+		var verifyResp struct {
+			FixtureDefinition *struct {
+				ID        string `json:"id"`
+				IsBuiltIn bool   `json:"isBuiltIn"`
+			} `json:"fixtureDefinition"`
+		}
+
+		err = client.Query(ctx, `
+			query GetFixtureDefinition($id: ID!) {
+				fixtureDefinition(id: $id) { id isBuiltIn }
+			}
+		`, map[string]interface{}{"id": builtIn.ID}, &verifyResp)
+
+		require.NoError(t, err)
+		require.NotNil(t, verifyResp.FixtureDefinition, "Built-in definition should still exist")
+		assert.True(t, verifyResp.FixtureDefinition.IsBuiltIn)
+	})
+}
@@ -0,0 +1,232 @@
+package crud
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// capabilitySearchContract is the fixture library search by channel capability these tests expect.
+const capabilitySearchContract = `FixtureDefinitionFilter.channelTypes: [ChannelType!]
+  matches definitions with at least one channel of every listed type; combines with the other filters by AND`
+
+// sixteenBitSearchContract is the 16-bit channel search these tests expect.
+const sixteenBitSearchContract = `ChannelDefinitionInput.fineOffset: Int, ChannelDefinition.fineOffset: Int
+  the offset of the channel's least significant byte, null for 8-bit channels
+  FixtureDefinitionFilter.sixteenBitChannelTypes: [ChannelType!]
+  matches definitions with a 16-bit channel (fineOffset set) of every listed type`
+
+// searchChannel is a channel of a definition created for capability search.
+// fineOffset >= 0 makes it 16-bit, with its fine byte at that offset.
+type searchChannel struct {
+	channelType string
+	fineOffset  int
+}
+
+// createSearchDefinition creates a definition with one channel per entry,
+// each followed by its fine channel when it is 16-bit.
+func createSearchDefinition(t *testing.T, client *graphql.Client, ctx context.Context, manufacturer, fixtureType string, spec []searchChannel) string {
+	var channels []map[string]interface{}
+	for _, c := range spec {
+		channel := map[string]interface{}{
+			"name":         c.channelType,
+			"type":         c.channelType,
+			"offset":       len(channels),
+			"defaultValue": 0,
+			"minValue":     0,
+			"maxValue":     255,
+		}
+		channels = append(channels, channel)
+		if c.fineOffset >= 0 {
+			channel["fineOffset"] = len(channels)
+			channels = append(channels, map[string]interface{}{
+				"name":         c.channelType + " Fine",
+				"type":         c.channelType,
+				"offset":       len(channels),
+				"defaultValue": 0,
+				"minValue":     0,
+				"maxValue":     255,
+			})
+		}
+	}
+
+	var resp struct {
+		CreateFixtureDefinition struct {
+			ID string `json:"id"`
+		} `json:"createFixtureDefinition"`
+	}
+	err := client.Mutate(ctx, `
+		mutation CreateFixtureDefinition($input: CreateFixtureDefinitionInput!) {
+			createFixtureDefinition(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"manufacturer": manufacturer,
+			"model":        fmt.Sprintf("Search Model %d", time.Now().UnixNano()),
+			"type":         fixtureType,
+			"channels":     channels,
+		},
+	}, &resp)
+	require.NoError(t, err)
+	return resp.CreateFixtureDefinition.ID
+}
+
+// eightBit lists 8-bit channels of the given types.
+func eightBit(types ...string) []searchChannel {
+	channels := make([]searchChannel, len(types))
+	for i, channelType := range types {
+		channels[i] = searchChannel{channelType: channelType, fineOffset: -1}
+	}
+	return channels
+}
+
+// searchResult is a definition returned by a capability search.
+type searchResult struct {
+	ID       string `json:"id"`
+	Model    string `json:"model"`
+	Channels []struct {
+		Type string `json:"type"`
+	} `json:"channels"`
+}
+
+func (r searchResult) hasChannelType(channelType string) bool {
+	for _, c := range r.Channels {
+		if c.Type == channelType {
+			return true
+		}
+	}
+	return false
+}
+
+// TestFixtureDefinitionCapabilitySearch verifies searching the fixture library
+// by the channel types a definition has, against definitions created here.
+func TestFixtureDefinitionCapabilitySearch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewTestClient(t, "")
+	compat.RequireTypeField(t, client, "FixtureDefinitionFilter", "channelTypes", capabilitySearchContract)
+
+	// Unique manufacturer so the result sets are fully owned by this test
+	manufacturer := fmt.Sprintf("Capability Search %d", time.Now().UnixNano())
+
+	strobeParID := createSearchDefinition(t, client, ctx, manufacturer, "LED_PAR", eightBit("INTENSITY", "RED", "GREEN", "BLUE", "STROBE"))
+	parID := createSearchDefinition(t, client, ctx, manufacturer, "LED_PAR", eightBit("INTENSITY", "RED", "GREEN", "BLUE"))
+	headID := createSearchDefinition(t, client, ctx, manufacturer, "MOVING_HEAD", eightBit("PAN", "TILT", "INTENSITY"))
+	ids := []string{strobeParID, parID, headID}
+	defer func() {
+		for _, id := range ids {
+			_ = client.Mutate(ctx, `mutation DeleteFixtureDefinition($id: ID!) { deleteFixtureDefinition(id: $id) }`,
+				map[string]interface{}{"id": id}, nil)
+		}
+	}()
+
+	search := func(t *testing.T, filter map[string]interface{}) []searchResult {
+		var resp struct {
+			FixtureDefinitions []searchResult `json:"fixtureDefinitions"`
+		}
+		err := client.Query(ctx, `
+			query SearchFixtureDefinitions($filter: FixtureDefinitionFilter) {
+				fixtureDefinitions(filter: $filter) {
+					id
+					model
+					channels { type }
+				}
+			}
+		`, map[string]interface{}{"filter": filter}, &resp)
+		require.NoError(t, err)
+		return resp.FixtureDefinitions
+	}
+	searchIDs := func(t *testing.T, filter map[string]interface{}) []string {
+		var ids []string
+		for _, def := range search(t, filter) {
+			ids = append(ids, def.ID)
+		}
+		return ids
+	}
+
+	t.Run("HasStrobe", func(t *testing.T) {
+		found := searchIDs(t, map[string]interface{}{"manufacturer": manufacturer, "channelTypes": []string{"STROBE"}})
+		assert.Equal(t, []string{strobeParID}, found)
+	})
+
+	t.Run("AllTypesRequired", func(t *testing.T) {
+		found := searchIDs(t, map[string]interface{}{"manufacturer": manufacturer, "channelTypes": []string{"RED", "STROBE"}})
+		assert.Equal(t, []string{strobeParID}, found, "Every listed type must be present, not any")
+
+		found = searchIDs(t, map[string]interface{}{"manufacturer": manufacturer, "channelTypes": []string{"INTENSITY"}})
+		assert.ElementsMatch(t, ids, found)
+	})
+
+	t.Run("NoMatch", func(t *testing.T) {
+		found := searchIDs(t, map[string]interface{}{"manufacturer": manufacturer, "channelTypes": []string{"UV"}})
+		assert.Empty(t, found)
+	})
+
+	t.Run("EmptyListMatchesAll", func(t *testing.T) {
+		found := searchIDs(t, map[string]interface{}{"manufacturer": manufacturer, "channelTypes": []string{}})
+		assert.ElementsMatch(t, ids, found)
+	})
+
+	t.Run("CombinesWithType", func(t *testing.T) {
+		found := searchIDs(t, map[string]interface{}{"manufacturer": manufacturer, "type": "LED_PAR", "channelTypes": []string{"INTENSITY"}})
+		assert.ElementsMatch(t, []string{strobeParID, parID}, found)
+	})
+
+	t.Run("WholeLibraryResultsAreCorrect", func(t *testing.T) {
+		// Built-in definitions are included, so only check what was returned
+		results := search(t, map[string]interface{}{"channelTypes": []string{"PAN", "TILT"}})
+		require.NotEmpty(t, results)
+		for _, def := range results {
+			assert.True(t, def.hasChannelType("PAN") && def.hasChannelType("TILT"),
+				"%s was returned for PAN+TILT but lacks one of them", def.Model)
+		}
+	})
+
+	t.Run("SixteenBitPan", func(t *testing.T) {
+		compat.RequireTypeField(t, client, "FixtureDefinitionFilter", "sixteenBitChannelTypes", sixteenBitSearchContract)
+		compat.RequireTypeField(t, client, "ChannelDefinition", "fineOffset", sixteenBitSearchContract)
+
+		fineHeadID := createSearchDefinition(t, client, ctx, manufacturer, "MOVING_HEAD", []searchChannel{
+			{channelType: "PAN", fineOffset: 0},
+			{channelType: "TILT", fineOffset: 0},
+			{channelType: "INTENSITY", fineOffset: -1},
+		})
+		ids = append(ids, fineHeadID)
+
+		found := searchIDs(t, map[string]interface{}{"manufacturer": manufacturer, "sixteenBitChannelTypes": []string{"PAN"}})
+		assert.Equal(t, []string{fineHeadID}, found, "Only the head with a fine pan channel is 16-bit")
+
+		found = searchIDs(t, map[string]interface{}{"manufacturer": manufacturer, "channelTypes": []string{"PAN"}})
+		assert.ElementsMatch(t, []string{headID, fineHeadID}, found, "channelTypes matches 8- and 16-bit channels alike")
+
+		found = searchIDs(t, map[string]interface{}{"manufacturer": manufacturer, "sixteenBitChannelTypes": []string{"INTENSITY"}})
+		assert.Empty(t, found)
+
+		var resp struct {
+			FixtureDefinition struct {
+				Channels []struct {
+					Type       string `json:"type"`
+					Offset     int    `json:"offset"`
+					FineOffset *int   `json:"fineOffset"`
+				} `json:"channels"`
+			} `json:"fixtureDefinition"`
+		}
+		err := client.Query(ctx, `
+			query GetFixtureDefinition($id: ID!) {
+				fixtureDefinition(id: $id) { channels { type offset fineOffset } }
+			}
+		`, map[string]interface{}{"id": fineHeadID}, &resp)
+		require.NoError(t, err)
+		require.NotEmpty(t, resp.FixtureDefinition.Channels)
+		pan := resp.FixtureDefinition.Channels[0]
+		require.NotNil(t, pan.FineOffset, "The coarse pan channel reports its fine byte")
+		assert.Equal(t, 1, *pan.FineOffset)
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/bbernstein/lacylights-test/pkg/lacyapi"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -17,151 +18,56 @@ func TestFixtureDefinitionCRUD(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	client := graphql.NewClient("")
+	client := graphql.NewTestClient(t, "")
+	api := lacyapi.New(client)
 
 	// CREATE
 	t.Run("CreateFixtureDefinition", func(t *testing.T) {
 		// Use unique model name to avoid conflicts
 		modelName := fmt.Sprintf("Test CRUD Model %d", time.Now().UnixNano())
 
-		var createResp struct {
-			CreateFixtureDefinition struct {
-				ID           string `json:"id"`
-				Manufacturer string `json:"manufacturer"`
-				Model        string `json:"model"`
-				Type         string `json:"type"`
-				IsBuiltIn    bool   `json:"isBuiltIn"`
-				Channels     []struct {
-					ID           string `json:"id"`
+		definition, err := api.CreateFixtureDefinition(ctx, lacyapi.CreateFixtureDefinitionInput{
+			Manufacturer: "Test Manufacturer",
+			Model:        modelName,
+			Type:         lacyapi.FixtureTypeLedPar,
+			Channels: []lacyapi.CreateChannelDefinitionInput{
+				{Name: "Red", Type: lacyapi.ChannelTypeRed, Offset: 0, DefaultValue: 0, MinValue: 0, MaxValue: 255, FadeBehavior: lacyapi.Ptr(lacyapi.FadeBehaviorFade)},
+				{Name: "Green", Type: lacyapi.ChannelTypeGreen, Offset: 1, DefaultValue: 0, MinValue: 0, MaxValue: 255, FadeBehavior: lacyapi.Ptr(lacyapi.FadeBehaviorFade)},
+				{Name: "Blue", Type: lacyapi.ChannelTypeBlue, Offset: 2, DefaultValue: 0, MinValue: 0, MaxValue: 255, FadeBehavior: lacyapi.Ptr(lacyapi.FadeBehaviorFade)},
+				{Name: "Cyan", Type: lacyapi.ChannelTypeCyan, Offset: 3, DefaultValue: 0, MinValue: 0, MaxValue: 255, FadeBehavior: lacyapi.Ptr(lacyapi.FadeBehaviorFade)},
+				{Name: "Magenta", Type: lacyapi.ChannelTypeMagenta, Offset: 4, DefaultValue: 0, MinValue: 0, MaxValue: 255, FadeBehavior: lacyapi.Ptr(lacyapi.FadeBehaviorFade)},
+				{Name: "Yellow", Type: lacyapi.ChannelTypeYellow, Offset: 5, DefaultValue: 0, MinValue: 0, MaxValue: 255, FadeBehavior: lacyapi.Ptr(lacyapi.FadeBehaviorFade)},
+				{Name: "Cold White", Type: lacyapi.ChannelTypeColdWhite, Offset: 6, DefaultValue: 0, MinValue: 0, MaxValue: 255, FadeBehavior: lacyapi.Ptr(lacyapi.FadeBehaviorFade)},
+				{Name: "Dimmer", Type: lacyapi.ChannelTypeIntensity, Offset: 7, DefaultValue: 0, MinValue: 0, MaxValue: 255, FadeBehavior: lacyapi.Ptr(lacyapi.FadeBehaviorFade)},
+			},
+		})
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, definition.ID)
+		assert.Equal(t, "Test Manufacturer", definition.Manufacturer)
+		assert.Equal(t, modelName, definition.Model)
+		assert.Equal(t, lacyapi.FixtureTypeLedPar, definition.Type)
+		assert.False(t, definition.IsBuiltIn)
+
+		var channelsResp struct {
+			FixtureDefinition struct {
+				Channels []struct {
 					Name         string `json:"name"`
-					Type         string `json:"type"`
-					Offset       int    `json:"offset"`
-					DefaultValue int    `json:"defaultValue"`
-					MinValue     int    `json:"minValue"`
-					MaxValue     int    `json:"maxValue"`
 					FadeBehavior string `json:"fadeBehavior"`
-					IsDiscrete   bool   `json:"isDiscrete"`
 				} `json:"channels"`
-			} `json:"createFixtureDefinition"`
+			} `json:"fixtureDefinition" graphql:"fixtureDefinition(id: $id)"`
 		}
-
-		err := client.Mutate(ctx, `
-			mutation CreateFixtureDefinition($input: CreateFixtureDefinitionInput!) {
-				createFixtureDefinition(input: $input) {
-					id
-					manufacturer
-					model
-					type
-					isBuiltIn
-					channels {
-						id
-						name
-						type
-						offset
-						defaultValue
-						minValue
-						maxValue
-						fadeBehavior
-						isDiscrete
-					}
-				}
-			}
-		`, map[string]interface{}{
-			"input": map[string]interface{}{
-				"manufacturer": "Test Manufacturer",
-				"model":        modelName,
-				"type":         "LED_PAR",
-				"channels": []map[string]interface{}{
-					{
-						"name":         "Red",
-						"type":         "RED",
-						"offset":       0,
-						"defaultValue": 0,
-						"minValue":     0,
-						"maxValue":     255,
-						"fadeBehavior": "FADE",
-					},
-					{
-						"name":         "Green",
-						"type":         "GREEN",
-						"offset":       1,
-						"defaultValue": 0,
-						"minValue":     0,
-						"maxValue":     255,
-						"fadeBehavior": "FADE",
-					},
-					{
-						"name":         "Blue",
-						"type":         "BLUE",
-						"offset":       2,
-						"defaultValue": 0,
-						"minValue":     0,
-						"maxValue":     255,
-						"fadeBehavior": "FADE",
-					},
-					{
-						"name":         "Cyan",
-						"type":         "CYAN",
-						"offset":       3,
-						"defaultValue": 0,
-						"minValue":     0,
-						"maxValue":     255,
-						"fadeBehavior": "FADE",
-					},
-					{
-						"name":         "Magenta",
-						"type":         "MAGENTA",
-						"offset":       4,
-						"defaultValue": 0,
-						"minValue":     0,
-						"maxValue":     255,
-						"fadeBehavior": "FADE",
-					},
-					{
-						"name":         "Yellow",
-						"type":         "YELLOW",
-						"offset":       5,
-						"defaultValue": 0,
-						"minValue":     0,
-						"maxValue":     255,
-						"fadeBehavior": "FADE",
-					},
-					{
-						"name":         "Cold White",
-						"type":         "COLD_WHITE",
-						"offset":       6,
-						"defaultValue": 0,
-						"minValue":     0,
-						"maxValue":     255,
-						"fadeBehavior": "FADE",
-					},
-					{
-						"name":         "Dimmer",
-						"type":         "INTENSITY",
-						"offset":       7,
-						"defaultValue": 0,
-						"minValue":     0,
-						"maxValue":     255,
-						"fadeBehavior": "FADE",
-					},
-				},
-			},
-		}, &createResp)
-
+		err = client.QueryFor(ctx, "query FixtureDefinitionChannels($id: ID!)", map[string]interface{}{"id": definition.ID}, &channelsResp)
 		require.NoError(t, err)
-		assert.NotEmpty(t, createResp.CreateFixtureDefinition.ID)
-		assert.Equal(t, "Test Manufacturer", createResp.CreateFixtureDefinition.Manufacturer)
-		assert.Equal(t, modelName, createResp.CreateFixtureDefinition.Model)
-		assert.Equal(t, "LED_PAR", createResp.CreateFixtureDefinition.Type)
-		assert.False(t, createResp.CreateFixtureDefinition.IsBuiltIn)
-		assert.Len(t, createResp.CreateFixtureDefinition.Channels, 8)
+		channels := channelsResp.FixtureDefinition.Channels
+		assert.Len(t, channels, 8)
 
 		// Verify FadeBehavior is returned for channels
-		for _, ch := range createResp.CreateFixtureDefinition.Channels {
+		for _, ch := range channels {
 			assert.Equal(t, "FADE", ch.FadeBehavior, "Channel %s should have FADE behavior", ch.Name)
 		}
 
-		definitionID := createResp.CreateFixtureDefinition.ID
+		definitionID := definition.ID
 
 		// READ
 		t.Run("ReadFixtureDefinition", func(t *testing.T) {
@@ -211,6 +117,7 @@ func TestFixtureDefinitionCRUD(t *testing.T) {
 			assert.Equal(t, definitionID, readResp.FixtureDefinition.ID)
 			assert.Equal(t, "Test Manufacturer", readResp.FixtureDefinition.Manufacturer)
 			assert.Equal(t, modelName, readResp.FixtureDefinition.Model)
+			assert.Len(t, readResp.FixtureDefinition.Channels, 8)
 
 			// Verify FadeBehavior and IsDiscrete are readable
 			for _, ch := range readResp.FixtureDefinition.Channels {
@@ -221,89 +128,32 @@ func TestFixtureDefinitionCRUD(t *testing.T) {
 
 		// UPDATE
 		t.Run("UpdateFixtureDefinition", func(t *testing.T) {
-			var updateResp struct {
-				UpdateFixtureDefinition struct {
-					ID           string `json:"id"`
-					Manufacturer string `json:"manufacturer"`
-					Model        string `json:"model"`
-					Type         string `json:"type"`
-				} `json:"updateFixtureDefinition"`
-			}
-
-			err := client.Mutate(ctx, `
-				mutation UpdateFixtureDefinition($id: ID!, $input: CreateFixtureDefinitionInput!) {
-					updateFixtureDefinition(id: $id, input: $input) {
-						id
-						manufacturer
-						model
-						type
-					}
-				}
-			`, map[string]interface{}{
-				"id": definitionID,
-				"input": map[string]interface{}{
-					"manufacturer": "Updated Manufacturer",
-					"model":        "Updated Model",
-					"type":         "MOVING_HEAD",
-					"channels": []map[string]interface{}{
-						{
-							"name":         "Pan",
-							"type":         "PAN",
-							"offset":       0,
-							"defaultValue": 128,
-							"minValue":     0,
-							"maxValue":     255,
-						},
-						{
-							"name":         "Tilt",
-							"type":         "TILT",
-							"offset":       1,
-							"defaultValue": 128,
-							"minValue":     0,
-							"maxValue":     255,
-						},
-					},
+			definition, err := api.UpdateFixtureDefinition(ctx, definitionID, lacyapi.CreateFixtureDefinitionInput{
+				Manufacturer: "Updated Manufacturer",
+				Model:        "Updated Model",
+				Type:         lacyapi.FixtureTypeMovingHead,
+				Channels: []lacyapi.CreateChannelDefinitionInput{
+					{Name: "Pan", Type: lacyapi.ChannelTypePan, Offset: 0, DefaultValue: 128, MinValue: 0, MaxValue: 255},
+					{Name: "Tilt", Type: lacyapi.ChannelTypeTilt, Offset: 1, DefaultValue: 128, MinValue: 0, MaxValue: 255},
 				},
-			}, &updateResp)
+			})
 
 			require.NoError(t, err)
-			assert.Equal(t, "Updated Manufacturer", updateResp.UpdateFixtureDefinition.Manufacturer)
-			assert.Equal(t, "Updated Model", updateResp.UpdateFixtureDefinition.Model)
-			assert.Equal(t, "MOVING_HEAD", updateResp.UpdateFixtureDefinition.Type)
+			assert.Equal(t, "Updated Manufacturer", definition.Manufacturer)
+			assert.Equal(t, "Updated Model", definition.Model)
+			assert.Equal(t, lacyapi.FixtureTypeMovingHead, definition.Type)
 		})
 
 		// LIST with filter
 		t.Run("ListFixtureDefinitions", func(t *testing.T) {
-			var listResp struct {
-				FixtureDefinitions []struct {
-					ID           string `json:"id"`
-					Manufacturer string `json:"manufacturer"`
-					Model        string `json:"model"`
-					Type         string `json:"type"`
-					IsBuiltIn    bool   `json:"isBuiltIn"`
-				} `json:"fixtureDefinitions"`
-			}
-
-			err := client.Query(ctx, `
-				query ListFixtureDefinitions($filter: FixtureDefinitionFilter) {
-					fixtureDefinitions(filter: $filter) {
-						id
-						manufacturer
-						model
-						type
-						isBuiltIn
-					}
-				}
-			`, map[string]interface{}{
-				"filter": map[string]interface{}{
-					"manufacturer": "Updated Manufacturer",
-				},
-			}, &listResp)
+			definitions, err := api.FixtureDefinitions(ctx, &lacyapi.FixtureDefinitionFilter{
+				Manufacturer: lacyapi.Ptr("Updated Manufacturer"),
+			})
 
 			require.NoError(t, err)
 			// Find our definition
 			found := false
-			for _, def := range listResp.FixtureDefinitions {
+			for _, def := range definitions {
 				if def.ID == definitionID {
 					found = true
 					assert.Equal(t, "Updated Manufacturer", def.Manufacturer)
@@ -315,37 +165,17 @@ func TestFixtureDefinitionCRUD(t *testing.T) {
 
 		// DELETE
 		t.Run("DeleteFixtureDefinition", func(t *testing.T) {
-			var deleteResp struct {
-				DeleteFixtureDefinition bool `json:"deleteFixtureDefinition"`
-			}
-
-			err := client.Mutate(ctx, `
-				mutation DeleteFixtureDefinition($id: ID!) {
-					deleteFixtureDefinition(id: $id)
-				}
-			`, map[string]interface{}{"id": definitionID}, &deleteResp)
+			deleted, err := api.DeleteFixtureDefinition(ctx, definitionID)
 
 			require.NoError(t, err)
-			assert.True(t, deleteResp.DeleteFixtureDefinition)
+			assert.True(t, deleted)
 
 			// Verify deletion
-			var verifyResp struct {
-				FixtureDefinition *struct {
-					ID string `json:"id"`
-				} `json:"fixtureDefinition"`
-			}
-
-			err = client.Query(ctx, `
-				query GetFixtureDefinition($id: ID!) {
-					fixtureDefinition(id: $id) {
-						id
-					}
-				}
-			`, map[string]interface{}{"id": definitionID}, &verifyResp)
+			definition, err := api.FixtureDefinition(ctx, definitionID)
 
 			// Should either return null or error
 			if err == nil {
-				assert.Nil(t, verifyResp.FixtureDefinition, "Deleted definition should not be found")
+				assert.Nil(t, definition, "Deleted definition should not be found")
 			}
 		})
 	})
@@ -356,65 +186,33 @@ func TestFixtureDefinitionWithFilters(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	client := graphql.NewClient("")
+	api := lacyapi.New(graphql.NewTestClient(t, ""))
 
 	// Test filtering by type
 	t.Run("FilterByType", func(t *testing.T) {
-		var resp struct {
-			FixtureDefinitions []struct {
-				ID   string `json:"id"`
-				Type string `json:"type"`
-			} `json:"fixtureDefinitions"`
-		}
-
-		err := client.Query(ctx, `
-			query ListFixtureDefinitions($filter: FixtureDefinitionFilter) {
-				fixtureDefinitions(filter: $filter) {
-					id
-					type
-				}
-			}
-		`, map[string]interface{}{
-			"filter": map[string]interface{}{
-				"type": "DIMMER",
-			},
-		}, &resp)
+		definitions, err := api.FixtureDefinitions(ctx, &lacyapi.FixtureDefinitionFilter{
+			Type: lacyapi.Ptr(lacyapi.FixtureTypeDimmer),
+		})
 
 		require.NoError(t, err)
-		for _, def := range resp.FixtureDefinitions {
-			assert.Equal(t, "DIMMER", def.Type)
+		for _, def := range definitions {
+			assert.Equal(t, lacyapi.FixtureTypeDimmer, def.Type)
 		}
 	})
 
 	// Test filtering by built-in status
 	// Note: Built-in fixtures may not exist in all database configurations
 	t.Run("FilterByBuiltIn", func(t *testing.T) {
-		var resp struct {
-			FixtureDefinitions []struct {
-				ID        string `json:"id"`
-				IsBuiltIn bool   `json:"isBuiltIn"`
-			} `json:"fixtureDefinitions"`
-		}
-
-		err := client.Query(ctx, `
-			query ListFixtureDefinitions($filter: FixtureDefinitionFilter) {
-				fixtureDefinitions(filter: $filter) {
-					id
-					isBuiltIn
-				}
-			}
-		`, map[string]interface{}{
-			"filter": map[string]interface{}{
-				"isBuiltIn": true,
-			},
-		}, &resp)
+		definitions, err := api.FixtureDefinitions(ctx, &lacyapi.FixtureDefinitionFilter{
+			IsBuiltIn: lacyapi.Ptr(true),
+		})
 
 		require.NoError(t, err)
 		// All returned fixtures should be built-in (if any exist)
-		for _, def := range resp.FixtureDefinitions {
+		for _, def := range definitions {
 			assert.True(t, def.IsBuiltIn, "Filtered fixtures should all be built-in")
 		}
-		if len(resp.FixtureDefinitions) == 0 {
+		if len(definitions) == 0 {
 			t.Log("No built-in fixtures found - built-in fixtures may not be configured")
 		}
 	})
@@ -426,7 +224,7 @@ func TestBuiltInFixtureDefinitions(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	client := graphql.NewClient("")
+	client := graphql.NewTestClient(t, "")
 
 	var resp struct {
 		FixtureDefinitions []struct {
@@ -7,73 +7,45 @@ import (
 	"time"
 
 	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/bbernstein/lacylights-test/pkg/lacyapi"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 // getOrCreateFixtureDefinition ensures we have a fixture definition to use.
 func getOrCreateFixtureDefinition(t *testing.T, client *graphql.Client, ctx context.Context) string {
-	// First try to find existing Generic Dimmer
-	var listResp struct {
-		FixtureDefinitions []struct {
-			ID           string `json:"id"`
-			Manufacturer string `json:"manufacturer"`
-			Model        string `json:"model"`
-		} `json:"fixtureDefinitions"`
-	}
-
-	err := client.Query(ctx, `
-		query {
-			fixtureDefinitions {
-				id
-				manufacturer
-				model
-			}
-		}
-	`, nil, &listResp)
+	api := lacyapi.New(client)
 
+	// First try to find existing Generic Dimmer
+	definitions, err := api.FixtureDefinitions(ctx, nil)
 	require.NoError(t, err)
 
 	// Find Generic Dimmer
-	for _, def := range listResp.FixtureDefinitions {
+	for _, def := range definitions {
 		if def.Manufacturer == "Generic" && def.Model == "Dimmer" {
 			return def.ID
 		}
 	}
 
 	// If not found, create one
-	var createResp struct {
-		CreateFixtureDefinition struct {
-			ID string `json:"id"`
-		} `json:"createFixtureDefinition"`
-	}
-
-	err = client.Mutate(ctx, `
-		mutation CreateFixtureDefinition($input: CreateFixtureDefinitionInput!) {
-			createFixtureDefinition(input: $input) {
-				id
-			}
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"manufacturer": "Generic",
-			"model":        "Dimmer",
-			"type":         "DIMMER",
-			"channels": []map[string]interface{}{
-				{
-					"name":         "Intensity",
-					"type":         "INTENSITY",
-					"offset":       0,
-					"defaultValue": 0,
-					"minValue":     0,
-					"maxValue":     255,
-				},
+	definition, err := api.CreateFixtureDefinition(ctx, lacyapi.CreateFixtureDefinitionInput{
+		Manufacturer: "Generic",
+		Model:        "Dimmer",
+		Type:         lacyapi.FixtureTypeDimmer,
+		Channels: []lacyapi.CreateChannelDefinitionInput{
+			{
+				Name:         "Intensity",
+				Type:         lacyapi.ChannelTypeIntensity,
+				Offset:       0,
+				DefaultValue: 0,
+				MinValue:     0,
+				MaxValue:     255,
 			},
 		},
-	}, &createResp)
+	})
 
 	require.NoError(t, err)
-	return createResp.CreateFixtureDefinition.ID
+	return definition.ID
 }
 
 // TestFixtureInstanceCRUD tests all fixture instance CRUD operations.
@@ -81,28 +53,15 @@ func TestFixtureInstanceCRUD(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	client := graphql.NewClient("")
+	client := graphql.NewTestClient(t, "")
+	api := lacyapi.New(client)
 
 	// Create a project first
-	var projectResp struct {
-		CreateProject struct {
-			ID string `json:"id"`
-		} `json:"createProject"`
-	}
-
-	err := client.Mutate(ctx, `
-		mutation CreateProject($input: CreateProjectInput!) {
-			createProject(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{"name": "Fixture Instance Test Project"},
-	}, &projectResp)
-
+	project, err := api.CreateProject(ctx, lacyapi.CreateProjectInput{Name: "Fixture Instance Test Project"})
 	require.NoError(t, err)
-	projectID := projectResp.CreateProject.ID
+	projectID := project.ID
 	defer func() {
-		_ = client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
-			map[string]interface{}{"id": projectID}, nil)
+		_, _ = api.DeleteProject(ctx, projectID)
 	}()
 
 	// Get or create a fixture definition
@@ -110,54 +69,24 @@ func TestFixtureInstanceCRUD(t *testing.T) {
 
 	// CREATE
 	t.Run("CreateFixtureInstance", func(t *testing.T) {
-		var createResp struct {
-			CreateFixtureInstance struct {
-				ID           string   `json:"id"`
-				Name         string   `json:"name"`
-				Manufacturer string   `json:"manufacturer"`
-				Model        string   `json:"model"`
-				Universe     int      `json:"universe"`
-				StartChannel int      `json:"startChannel"`
-				ChannelCount int      `json:"channelCount"`
-				Tags         []string `json:"tags"`
-				Description  *string  `json:"description"`
-			} `json:"createFixtureInstance"`
-		}
-
-		err := client.Mutate(ctx, `
-			mutation CreateFixtureInstance($input: CreateFixtureInstanceInput!) {
-				createFixtureInstance(input: $input) {
-					id
-					name
-					manufacturer
-					model
-					universe
-					startChannel
-					channelCount
-					tags
-					description
-				}
-			}
-		`, map[string]interface{}{
-			"input": map[string]interface{}{
-				"projectId":    projectID,
-				"definitionId": definitionID,
-				"name":         "Stage Left Par 1",
-				"universe":     1,
-				"startChannel": 1,
-				"tags":         []string{"stage-left", "par"},
-				"description":  "First par on stage left",
-			},
-		}, &createResp)
+		fixture, err := api.CreateFixtureInstance(ctx, lacyapi.CreateFixtureInstanceInput{
+			ProjectID:    projectID,
+			DefinitionID: definitionID,
+			Name:         "Stage Left Par 1",
+			Universe:     1,
+			StartChannel: 1,
+			Tags:         []string{"stage-left", "par"},
+			Description:  lacyapi.Ptr("First par on stage left"),
+		})
 
 		require.NoError(t, err)
-		assert.NotEmpty(t, createResp.CreateFixtureInstance.ID)
-		assert.Equal(t, "Stage Left Par 1", createResp.CreateFixtureInstance.Name)
-		assert.Equal(t, 1, createResp.CreateFixtureInstance.Universe)
-		assert.Equal(t, 1, createResp.CreateFixtureInstance.StartChannel)
-		assert.Contains(t, createResp.CreateFixtureInstance.Tags, "stage-left")
+		assert.NotEmpty(t, fixture.ID)
+		assert.Equal(t, "Stage Left Par 1", fixture.Name)
+		assert.Equal(t, 1, fixture.Universe)
+		assert.Equal(t, 1, fixture.StartChannel)
+		assert.Contains(t, fixture.Tags, "stage-left")
 
-		fixtureID := createResp.CreateFixtureInstance.ID
+		fixtureID := fixture.ID
 
 		// READ
 		t.Run("ReadFixtureInstance", func(t *testing.T) {
@@ -213,41 +142,18 @@ func TestFixtureInstanceCRUD(t *testing.T) {
 
 		// UPDATE
 		t.Run("UpdateFixtureInstance", func(t *testing.T) {
-			var updateResp struct {
-				UpdateFixtureInstance struct {
-					ID           string   `json:"id"`
-					Name         string   `json:"name"`
-					Universe     int      `json:"universe"`
-					StartChannel int      `json:"startChannel"`
-					Tags         []string `json:"tags"`
-				} `json:"updateFixtureInstance"`
-			}
-
-			err := client.Mutate(ctx, `
-				mutation UpdateFixtureInstance($id: ID!, $input: UpdateFixtureInstanceInput!) {
-					updateFixtureInstance(id: $id, input: $input) {
-						id
-						name
-						universe
-						startChannel
-						tags
-					}
-				}
-			`, map[string]interface{}{
-				"id": fixtureID,
-				"input": map[string]interface{}{
-					"name":         "Stage Left Par 1 Updated",
-					"universe":     2,
-					"startChannel": 50,
-					"tags":         []string{"stage-left", "par", "updated"},
-				},
-			}, &updateResp)
+			fixture, err := api.UpdateFixtureInstance(ctx, fixtureID, lacyapi.UpdateFixtureInstanceInput{
+				Name:         lacyapi.Ptr("Stage Left Par 1 Updated"),
+				Universe:     lacyapi.Ptr(2),
+				StartChannel: lacyapi.Ptr(50),
+				Tags:         []string{"stage-left", "par", "updated"},
+			})
 
 			require.NoError(t, err)
-			assert.Equal(t, "Stage Left Par 1 Updated", updateResp.UpdateFixtureInstance.Name)
-			assert.Equal(t, 2, updateResp.UpdateFixtureInstance.Universe)
-			assert.Equal(t, 50, updateResp.UpdateFixtureInstance.StartChannel)
-			assert.Contains(t, updateResp.UpdateFixtureInstance.Tags, "updated")
+			assert.Equal(t, "Stage Left Par 1 Updated", fixture.Name)
+			assert.Equal(t, 2, fixture.Universe)
+			assert.Equal(t, 50, fixture.StartChannel)
+			assert.Contains(t, fixture.Tags, "updated")
 		})
 
 		// LIST with pagination
@@ -304,36 +210,16 @@ func TestFixtureInstanceCRUD(t *testing.T) {
 
 		// DELETE
 		t.Run("DeleteFixtureInstance", func(t *testing.T) {
-			var deleteResp struct {
-				DeleteFixtureInstance bool `json:"deleteFixtureInstance"`
-			}
-
-			err := client.Mutate(ctx, `
-				mutation DeleteFixtureInstance($id: ID!) {
-					deleteFixtureInstance(id: $id)
-				}
-			`, map[string]interface{}{"id": fixtureID}, &deleteResp)
+			deleted, err := api.DeleteFixtureInstance(ctx, fixtureID)
 
 			require.NoError(t, err)
-			assert.True(t, deleteResp.DeleteFixtureInstance)
+			assert.True(t, deleted)
 
 			// Verify deletion
-			var verifyResp struct {
-				FixtureInstance *struct {
-					ID string `json:"id"`
-				} `json:"fixtureInstance"`
-			}
-
-			err = client.Query(ctx, `
-				query GetFixtureInstance($id: ID!) {
-					fixtureInstance(id: $id) {
-						id
-					}
-				}
-			`, map[string]interface{}{"id": fixtureID}, &verifyResp)
+			fixture, err := api.FixtureInstance(ctx, fixtureID)
 
 			if err == nil {
-				assert.Nil(t, verifyResp.FixtureInstance, "Deleted fixture should not be found")
+				assert.Nil(t, fixture, "Deleted fixture should not be found")
 			}
 		})
 	})
@@ -344,28 +230,15 @@ func TestBulkFixtureOperations(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	client := graphql.NewClient("")
+	client := graphql.NewTestClient(t, "")
+	api := lacyapi.New(client)
 
 	// Create project
-	var projectResp struct {
-		CreateProject struct {
-			ID string `json:"id"`
-		} `json:"createProject"`
-	}
-
-	err := client.Mutate(ctx, `
-		mutation CreateProject($input: CreateProjectInput!) {
-			createProject(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{"name": "Bulk Fixture Test Project"},
-	}, &projectResp)
-
+	project, err := api.CreateProject(ctx, lacyapi.CreateProjectInput{Name: "Bulk Fixture Test Project"})
 	require.NoError(t, err)
-	projectID := projectResp.CreateProject.ID
+	projectID := project.ID
 	defer func() {
-		_ = client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
-			map[string]interface{}{"id": projectID}, nil)
+		_, _ = api.DeleteProject(ctx, projectID)
 	}()
 
 	definitionID := getOrCreateFixtureDefinition(t, client, ctx)
@@ -473,81 +346,42 @@ func TestFixtureInstanceUsage(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	client := graphql.NewClient("")
+	client := graphql.NewTestClient(t, "")
+	api := lacyapi.New(client)
 
 	// Create project
-	var projectResp struct {
-		CreateProject struct {
-			ID string `json:"id"`
-		} `json:"createProject"`
-	}
-
-	err := client.Mutate(ctx, `
-		mutation CreateProject($input: CreateProjectInput!) {
-			createProject(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{"name": "Fixture Usage Test Project"},
-	}, &projectResp)
-
+	project, err := api.CreateProject(ctx, lacyapi.CreateProjectInput{Name: "Fixture Usage Test Project"})
 	require.NoError(t, err)
-	projectID := projectResp.CreateProject.ID
+	projectID := project.ID
 	defer func() {
-		_ = client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
-			map[string]interface{}{"id": projectID}, nil)
+		_, _ = api.DeleteProject(ctx, projectID)
 	}()
 
 	definitionID := getOrCreateFixtureDefinition(t, client, ctx)
 
 	// Create fixture
-	var fixtureResp struct {
-		CreateFixtureInstance struct {
-			ID string `json:"id"`
-		} `json:"createFixtureInstance"`
-	}
-
-	err = client.Mutate(ctx, `
-		mutation CreateFixtureInstance($input: CreateFixtureInstanceInput!) {
-			createFixtureInstance(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"projectId":    projectID,
-			"definitionId": definitionID,
-			"name":         "Usage Test Fixture",
-			"universe":     1,
-			"startChannel": 1,
-		},
-	}, &fixtureResp)
+	fixture, err := api.CreateFixtureInstance(ctx, lacyapi.CreateFixtureInstanceInput{
+		ProjectID:    projectID,
+		DefinitionID: definitionID,
+		Name:         "Usage Test Fixture",
+		Universe:     1,
+		StartChannel: 1,
+	})
 
 	require.NoError(t, err)
-	fixtureID := fixtureResp.CreateFixtureInstance.ID
+	fixtureID := fixture.ID
 
 	// Create look with this fixture
-	var lookResp struct {
-		CreateLook struct {
-			ID string `json:"id"`
-		} `json:"createLook"`
-	}
-
-	err = client.Mutate(ctx, `
-		mutation CreateLook($input: CreateLookInput!) {
-			createLook(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"projectId": projectID,
-			"name":      "Usage Test Look",
-			"fixtureValues": []map[string]interface{}{
-				{
-					"fixtureId": fixtureID,
-					"channels": []map[string]interface{}{
-						{"offset": 0, "value": 255},
-					},
-				},
+	_, err = api.CreateLook(ctx, lacyapi.CreateLookInput{
+		ProjectID: projectID,
+		Name:      "Usage Test Look",
+		FixtureValues: []lacyapi.FixtureValueInput{
+			{
+				FixtureID: fixtureID,
+				Channels:  []lacyapi.ChannelValueInput{{Offset: 0, Value: 255}},
 			},
 		},
-	}, &lookResp)
+	})
 
 	require.NoError(t, err)
 
@@ -587,47 +421,28 @@ func TestChannelMap(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	client := graphql.NewClient("")
+	client := graphql.NewTestClient(t, "")
+	api := lacyapi.New(client)
 
 	// Create project with fixtures
-	var projectResp struct {
-		CreateProject struct {
-			ID string `json:"id"`
-		} `json:"createProject"`
-	}
-
-	err := client.Mutate(ctx, `
-		mutation CreateProject($input: CreateProjectInput!) {
-			createProject(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{"name": "Channel Map Test Project"},
-	}, &projectResp)
-
+	project, err := api.CreateProject(ctx, lacyapi.CreateProjectInput{Name: "Channel Map Test Project"})
 	require.NoError(t, err)
-	projectID := projectResp.CreateProject.ID
+	projectID := project.ID
 	defer func() {
-		_ = client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
-			map[string]interface{}{"id": projectID}, nil)
+		_, _ = api.DeleteProject(ctx, projectID)
 	}()
 
 	definitionID := getOrCreateFixtureDefinition(t, client, ctx)
 
 	// Create fixtures at different channel addresses
 	for i := 0; i < 3; i++ {
-		err = client.Mutate(ctx, `
-			mutation CreateFixtureInstance($input: CreateFixtureInstanceInput!) {
-				createFixtureInstance(input: $input) { id }
-			}
-		`, map[string]interface{}{
-			"input": map[string]interface{}{
-				"projectId":    projectID,
-				"definitionId": definitionID,
-				"name":         "Channel Map Fixture " + string(rune('A'+i)),
-				"universe":     1,
-				"startChannel": 1 + i*10,
-			},
-		}, nil)
+		_, err = api.CreateFixtureInstance(ctx, lacyapi.CreateFixtureInstanceInput{
+			ProjectID:    projectID,
+			DefinitionID: definitionID,
+			Name:         "Channel Map Fixture " + string(rune('A'+i)),
+			Universe:     1,
+			StartChannel: 1 + i*10,
+		})
 		require.NoError(t, err)
 	}
 
@@ -0,0 +1,166 @@
+package crud
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/fixtures"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// replaceFixtureDefinitionContract is the fixture swap these tests expect.
+const replaceFixtureDefinitionContract = `replaceFixtureDefinition(fixtureId: ID!, definitionId: ID!): FixtureInstance!
+  keeps the fixture's ID, name and patch; look values move to the new definition's channel of the same type,
+  channels with no counterpart in the new definition take its default value`
+
+// lookChannels reads the values a look sets on one fixture, by offset.
+func lookChannels(t *testing.T, client *graphql.Client, ctx context.Context, lookID, fixtureID string) map[int]int {
+	var resp struct {
+		Look struct {
+			FixtureValues []struct {
+				Fixture struct {
+					ID string `json:"id"`
+				} `json:"fixture"`
+				Channels []struct {
+					Offset int `json:"offset"`
+					Value  int `json:"value"`
+				} `json:"channels"`
+			} `json:"fixtureValues"`
+		} `json:"look"`
+	}
+	err := client.Query(ctx, `
+		query GetLook($id: ID!) {
+			look(id: $id) {
+				fixtureValues {
+					fixture { id }
+					channels { offset value }
+				}
+			}
+		}
+	`, map[string]interface{}{"id": lookID}, &resp)
+	require.NoError(t, err)
+
+	values := map[int]int{}
+	for _, fv := range resp.Look.FixtureValues {
+		if fv.Fixture.ID == fixtureID {
+			for _, ch := range fv.Channels {
+				values[ch.Offset] = ch.Value
+			}
+		}
+	}
+	return values
+}
+
+// TestReplaceFixtureDefinition swaps a patched RGB par for an RGBW par whose
+// channels are in a different order. Look values must follow each channel by
+// type, the new white channel must come up at its default, and the look must
+// render accordingly without disturbing the fixture patched after it.
+func TestReplaceFixtureDefinition(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewTestClient(t, "")
+	compat.RequireMutation(t, client, "replaceFixtureDefinition", replaceFixtureDefinitionContract)
+
+	rgbID, err := fixtures.CreateParDefinition(ctx, client, "Test Replace", fmt.Sprintf("Replace RGB Par %d", time.Now().UnixNano()))
+	require.NoError(t, err)
+	// Dimmer last, so a swap that maps by offset rather than type is caught
+	rgbwID, _ := createLifecycleDefinition(t, client, ctx, "Test Replace", "LED_PAR",
+		[]string{"RED", "GREEN", "BLUE", "WHITE", "INTENSITY"})
+	projectID, err := fixtures.CreateProject(ctx, client, "Replace Definition Test Project")
+	require.NoError(t, err)
+	defer func() {
+		_ = client.Mutate(ctx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+		_ = fixtures.DeleteProject(ctx, client, projectID)
+		_ = fixtures.DeleteFixtureDefinition(ctx, client, rgbID)
+		_ = fixtures.DeleteFixtureDefinition(ctx, client, rgbwID)
+	}()
+
+	parID, err := fixtures.CreateFixture(ctx, client, projectID, rgbID, fixtures.Fixture{Name: "Replace Par", Universe: 1, StartChannel: 321})
+	require.NoError(t, err)
+	// The RGBW par grows to 321-325, up to but not into its neighbour
+	neighbourID, err := fixtures.CreateFixture(ctx, client, projectID, rgbID, fixtures.Fixture{Name: "Replace Neighbour", Universe: 1, StartChannel: 326})
+	require.NoError(t, err)
+	lookID, err := fixtures.CreateLook(ctx, client, projectID, "Amber", []fixtures.FixtureValues{
+		{FixtureID: parID, Values: []int{200, 255, 120, 10}},
+		{FixtureID: neighbourID, Values: []int{255, 0, 0, 255}},
+	})
+	require.NoError(t, err)
+
+	var resp struct {
+		ReplaceFixtureDefinition struct {
+			ID           string `json:"id"`
+			Name         string `json:"name"`
+			DefinitionID string `json:"definitionId"`
+			Universe     int    `json:"universe"`
+			StartChannel int    `json:"startChannel"`
+			ChannelCount int    `json:"channelCount"`
+			Channels     []struct {
+				Type string `json:"type"`
+			} `json:"channels"`
+		} `json:"replaceFixtureDefinition"`
+	}
+	err = client.Mutate(ctx, `
+		mutation ReplaceFixtureDefinition($fixtureId: ID!, $definitionId: ID!) {
+			replaceFixtureDefinition(fixtureId: $fixtureId, definitionId: $definitionId) {
+				id
+				name
+				definitionId
+				universe
+				startChannel
+				channelCount
+				channels { type }
+			}
+		}
+	`, map[string]interface{}{"fixtureId": parID, "definitionId": rgbwID}, &resp)
+	require.NoError(t, err)
+
+	t.Run("Instance", func(t *testing.T) {
+		replaced := resp.ReplaceFixtureDefinition
+		assert.Equal(t, parID, replaced.ID, "The fixture keeps its ID")
+		assert.Equal(t, "Replace Par", replaced.Name)
+		assert.Equal(t, rgbwID, replaced.DefinitionID)
+		assert.Equal(t, 1, replaced.Universe, "The patch should not move")
+		assert.Equal(t, 321, replaced.StartChannel, "The patch should not move")
+		assert.Equal(t, 5, replaced.ChannelCount)
+		var types []string
+		for _, ch := range replaced.Channels {
+			types = append(types, ch.Type)
+		}
+		assert.Equal(t, []string{"RED", "GREEN", "BLUE", "WHITE", "INTENSITY"}, types)
+	})
+
+	t.Run("LookMappedByType", func(t *testing.T) {
+		values := lookChannels(t, client, ctx, lookID, parID)
+		assert.Equal(t, 255, values[0], "RED keeps its value")
+		assert.Equal(t, 120, values[1], "GREEN keeps its value")
+		assert.Equal(t, 10, values[2], "BLUE keeps its value")
+		assert.Equal(t, 200, values[4], "INTENSITY keeps its value at its new offset")
+		if white, ok := values[3]; ok {
+			assert.Equal(t, 0, white, "WHITE has no counterpart and should be stored at its default")
+		} else {
+			t.Log("Contract: channels with no counterpart are left unset in the look")
+		}
+		assert.Equal(t, map[int]int{0: 255, 1: 0, 2: 0, 3: 255}, lookChannels(t, client, ctx, lookID, neighbourID),
+			"The neighbouring fixture's values should not change")
+	})
+
+	t.Run("Render", func(t *testing.T) {
+		require.NoError(t, client.Mutate(ctx, `mutation SetLookLive($lookId: ID!) { setLookLive(lookId: $lookId) }`,
+			map[string]interface{}{"lookId": lookID}, nil))
+		time.Sleep(300 * time.Millisecond)
+
+		var dmxResp struct {
+			DMXOutput []int `json:"dmxOutput"`
+		}
+		require.NoError(t, client.Query(ctx, `query { dmxOutput(universe: 1) }`, nil, &dmxResp))
+		require.Len(t, dmxResp.DMXOutput, 512, "dmxOutput should cover the universe")
+		assert.Equal(t, []int{255, 120, 10, 0, 200}, dmxResp.DMXOutput[320:325], "The par should render as R, G, B, W, dimmer")
+		assert.Equal(t, []int{255, 0, 0, 255}, dmxResp.DMXOutput[325:329], "The neighbour should render unchanged")
+	})
+}
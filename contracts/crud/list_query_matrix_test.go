@@ -0,0 +1,452 @@
+package crud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// matrixFilterContract is the list query shape the matrix expects
+	matrixFilterContract = `<list>(projectId: ID!, filter: <Entity>FilterInput, sortBy: <Entity>SortField)
+  filter fields are ANDed; sortBy orders the whole result consistently in one direction`
+
+	// matrixNameFragment appears in the names of some, but not all, seeded items
+	matrixNameFragment = "Wash"
+
+	// matrixTimestampGap separates the two creation batches so second-resolution
+	// timestamps still fall on either side of the cutoff
+	matrixTimestampGap = 1100 * time.Millisecond
+)
+
+// matrixNames are created in this order, which differs from alphabetical
+// order and from its reverse, so name sorting cannot pass by accident.
+var matrixNames = []string{"Delta Wash", "Alpha Spot", "Charlie Wash", "Bravo Spot", "Echo Beam"}
+
+// matrixItem is one seeded entity with the values the matrix filters and sorts by.
+type matrixItem struct {
+	id    string
+	name  string
+	seq   int                    // creation order
+	attrs map[string]interface{} // filterable attributes, keyed by filter field
+	ranks map[string]int         // sort position by sortBy value, ascending
+}
+
+// matrixList describes one list query and how to seed it.
+type matrixList struct {
+	field string // root query field
+	items string // field wrapping the items, or "" when the query returns a list
+	seed  func(t *testing.T, seq int) matrixItem
+}
+
+// matrixArgTypes reports the named types of a root query field's filter and sortBy arguments.
+func matrixArgTypes(t *testing.T, client *graphql.Client, ctx context.Context, field string) (filterType, sortType string) {
+	type typeRef struct {
+		Name   *string  `json:"name"`
+		OfType *typeRef `json:"ofType"`
+	}
+	var resp struct {
+		Schema struct {
+			QueryType struct {
+				Fields []struct {
+					Name string `json:"name"`
+					Args []struct {
+						Name string  `json:"name"`
+						Type typeRef `json:"type"`
+					} `json:"args"`
+				} `json:"fields"`
+			} `json:"queryType"`
+		} `json:"__schema"`
+	}
+	err := client.Query(ctx, `
+		query ListArgs {
+			__schema { queryType { fields { name args { name type { name ofType { name ofType { name } } } } } } }
+		}
+	`, nil, &resp)
+	require.NoError(t, err)
+
+	named := func(r typeRef) string {
+		for r.Name == nil && r.OfType != nil {
+			r = *r.OfType
+		}
+		if r.Name == nil {
+			return ""
+		}
+		return *r.Name
+	}
+	for _, f := range resp.Schema.QueryType.Fields {
+		if f.Name != field {
+			continue
+		}
+		for _, a := range f.Args {
+			switch a.Name {
+			case "filter":
+				filterType = named(a.Type)
+			case "sortBy":
+				sortType = named(a.Type)
+			}
+		}
+	}
+	return filterType, sortType
+}
+
+// matrixTypeMembers lists an input type's fields or an enum's values.
+func matrixTypeMembers(t *testing.T, client *graphql.Client, ctx context.Context, typeName string) []string {
+	var resp struct {
+		Type *struct {
+			InputFields []struct {
+				Name string `json:"name"`
+			} `json:"inputFields"`
+			EnumValues []struct {
+				Name string `json:"name"`
+			} `json:"enumValues"`
+		} `json:"__type"`
+	}
+	err := client.Query(ctx, `
+		query TypeMembers($name: String!) {
+			__type(name: $name) { inputFields { name } enumValues { name } }
+		}
+	`, map[string]interface{}{"name": typeName}, &resp)
+	require.NoError(t, err)
+	require.NotNil(t, resp.Type, "Type %s should exist", typeName)
+
+	var names []string
+	for _, f := range resp.Type.InputFields {
+		names = append(names, f.Name)
+	}
+	for _, v := range resp.Type.EnumValues {
+		names = append(names, v.Name)
+	}
+	return names
+}
+
+// rankBy assigns each item its ascending position under less.
+func rankBy(items []matrixItem, key string, less func(a, b matrixItem) bool) {
+	sorted := append([]matrixItem(nil), items...)
+	sort.SliceStable(sorted, func(i, j int) bool { return less(sorted[i], sorted[j]) })
+	for pos, item := range sorted {
+		items[item.seq].ranks[key] = pos
+	}
+}
+
+// TestListQueryFilterSortMatrix exercises every filter field and sortBy value
+// the schema documents for looks, fixtures, effects and cue lists. Seeded data
+// fixes the expected membership of each filter and the order of each sort.
+// Fields the matrix has no rule for are skipped by name, so new schema
+// additions show up in the test output.
+func TestListQueryFilterSortMatrix(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewTestClient(t, "")
+
+	var projectResp struct {
+		CreateProject struct {
+			ID string `json:"id"`
+		} `json:"createProject"`
+	}
+	err := client.Mutate(ctx, `
+		mutation CreateProject($input: CreateProjectInput!) {
+			createProject(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{"name": "List Matrix Test Project"},
+	}, &projectResp)
+	require.NoError(t, err)
+	projectID := projectResp.CreateProject.ID
+	defer func() {
+		_ = client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
+			map[string]interface{}{"id": projectID}, nil)
+	}()
+
+	suffix := time.Now().UnixNano()
+	dimmerMaker := fmt.Sprintf("Matrix Dimmers %d", suffix)
+	parMaker := fmt.Sprintf("Matrix Pars %d", suffix)
+	dimmerDefID, _ := createLifecycleDefinition(t, client, ctx, dimmerMaker, "DIMMER", []string{"INTENSITY"})
+	parDefID, _ := createLifecycleDefinition(t, client, ctx, parMaker, "LED_PAR", []string{"RED", "GREEN", "BLUE"})
+	defer func() {
+		for _, id := range []string{dimmerDefID, parDefID} {
+			_ = client.Mutate(ctx, `mutation DeleteFixtureDefinition($id: ID!) { deleteFixtureDefinition(id: $id) }`,
+				map[string]interface{}{"id": id}, nil)
+		}
+	}()
+
+	create := func(t *testing.T, field, inputType string, input map[string]interface{}) string {
+		var resp map[string]struct {
+			ID string `json:"id"`
+		}
+		err := client.Mutate(ctx, fmt.Sprintf(`
+			mutation Create($input: %s!) {
+				%s(input: $input) { id }
+			}
+		`, inputType, field), map[string]interface{}{"input": input}, &resp)
+		require.NoError(t, err)
+		return resp[field].ID
+	}
+
+	var fixtureIDs []string
+	startChannels := []int{10, 1, 30, 20, 40}
+	lookFixtureCounts := []int{3, 5, 1, 4, 2}
+	effectTypes := []string{"WAVEFORM", "STATIC", "WAVEFORM", "MASTER", "WAVEFORM"}
+
+	lists := []matrixList{
+		{
+			field: "fixtureInstances",
+			items: "fixtures",
+			seed: func(t *testing.T, seq int) matrixItem {
+				defID, maker, fixtureType := dimmerDefID, dimmerMaker, "DIMMER"
+				if seq%2 == 1 {
+					defID, maker, fixtureType = parDefID, parMaker, "LED_PAR"
+				}
+				universe := 1 + seq%2
+				id := create(t, "createFixtureInstance", "CreateFixtureInstanceInput", map[string]interface{}{
+					"projectId":    projectID,
+					"definitionId": defID,
+					"name":         matrixNames[seq],
+					"universe":     universe,
+					"startChannel": startChannels[seq],
+				})
+				fixtureIDs = append(fixtureIDs, id)
+				return matrixItem{id: id, attrs: map[string]interface{}{
+					"type":         fixtureType,
+					"manufacturer": maker,
+					"universe":     universe,
+					"startChannel": startChannels[seq],
+				}}
+			},
+		},
+		{
+			field: "looks",
+			items: "looks",
+			seed: func(t *testing.T, seq int) matrixItem {
+				var fixtureValues []map[string]interface{}
+				for _, fixtureID := range fixtureIDs[:lookFixtureCounts[seq]] {
+					fixtureValues = append(fixtureValues, map[string]interface{}{
+						"fixtureId": fixtureID,
+						"channels":  []map[string]interface{}{{"offset": 0, "value": 255}},
+					})
+				}
+				id := create(t, "createLook", "CreateLookInput", map[string]interface{}{
+					"projectId":     projectID,
+					"name":          matrixNames[seq],
+					"fixtureValues": fixtureValues,
+				})
+				return matrixItem{id: id, attrs: map[string]interface{}{"fixtureCount": lookFixtureCounts[seq]}}
+			},
+		},
+		{
+			field: "cueLists",
+			seed: func(t *testing.T, seq int) matrixItem {
+				id := create(t, "createCueList", "CreateCueListInput", map[string]interface{}{
+					"projectId": projectID,
+					"name":      matrixNames[seq],
+				})
+				return matrixItem{id: id, attrs: map[string]interface{}{}}
+			},
+		},
+		{
+			field: "effects",
+			seed: func(t *testing.T, seq int) matrixItem {
+				input := map[string]interface{}{
+					"projectId":  projectID,
+					"name":       matrixNames[seq],
+					"effectType": effectTypes[seq],
+				}
+				switch effectTypes[seq] {
+				case "WAVEFORM":
+					input["waveform"] = "SINE"
+					input["frequency"] = 1.0
+				case "MASTER":
+					input["masterValue"] = 0.5
+				}
+				id := create(t, "createEffect", "CreateEffectInput", input)
+				return matrixItem{id: id, attrs: map[string]interface{}{"effectType": effectTypes[seq]}}
+			},
+		},
+	}
+
+	for _, list := range lists {
+		t.Run(list.field, func(t *testing.T) {
+			if list.field == "effects" {
+				compat.Require(t, compat.Effects)
+			}
+
+			// Seed in two batches either side of a cutoff for the date filters
+			var items []matrixItem
+			var cutoff string
+			for seq := range matrixNames {
+				if seq == 2 {
+					time.Sleep(matrixTimestampGap)
+					cutoff = time.Now().UTC().Format(time.RFC3339Nano)
+					time.Sleep(matrixTimestampGap)
+				}
+				item := list.seed(t, seq)
+				item.name, item.seq, item.ranks = matrixNames[seq], seq, map[string]int{}
+				items = append(items, item)
+			}
+
+			// Gate after seeding: later lists build on the fixtures seeded here
+			filterType, sortType := matrixArgTypes(t, client, ctx, list.field)
+			if filterType == "" && sortType == "" {
+				compat.RequireQueryArgument(t, client, list.field, "filter", matrixFilterContract)
+			}
+
+			query := func(t *testing.T, filter map[string]interface{}, sortBy string) []string {
+				var params, args []string
+				vars := map[string]interface{}{"projectId": projectID}
+				if filter != nil {
+					params, args = append(params, "$filter: "+filterType), append(args, "filter: $filter")
+					vars["filter"] = filter
+				}
+				if sortBy != "" {
+					params, args = append(params, "$sortBy: "+sortType), append(args, "sortBy: $sortBy")
+					vars["sortBy"] = sortBy
+				}
+				selection := "id"
+				if list.items != "" {
+					selection = list.items + " { id }"
+				}
+				data, err := client.ExecuteRaw(ctx, fmt.Sprintf(`
+					query Matrix($projectId: ID!%s) {
+						%s(projectId: $projectId%s) { %s }
+					}
+				`, prefixJoin(params), list.field, prefixJoin(args), selection), vars)
+				require.NoError(t, err)
+
+				var resp map[string]json.RawMessage
+				require.NoError(t, json.Unmarshal(data, &resp))
+				raw := resp[list.field]
+				if list.items != "" {
+					var wrapper map[string]json.RawMessage
+					require.NoError(t, json.Unmarshal(raw, &wrapper))
+					raw = wrapper[list.items]
+				}
+				var found []struct {
+					ID string `json:"id"`
+				}
+				require.NoError(t, json.Unmarshal(raw, &found))
+				ids := make([]string, len(found))
+				for i, f := range found {
+					ids[i] = f.ID
+				}
+				return ids
+			}
+
+			if filterType != "" {
+				for _, field := range matrixTypeMembers(t, client, ctx, filterType) {
+					t.Run("Filter_"+field, func(t *testing.T) {
+						value, want, ok := matrixFilterCase(field, items, cutoff)
+						if !ok {
+							t.Skipf("Skipping: no rule for %s.%s", filterType, field)
+						}
+						got := query(t, map[string]interface{}{field: value}, "")
+						assert.ElementsMatch(t, want, got, "%s filter %s=%v", list.field, field, value)
+					})
+				}
+			}
+
+			if sortType != "" {
+				rankBy(items, "NAME", func(a, b matrixItem) bool { return a.name < b.name })
+				for _, key := range []string{"CREATED_AT", "UPDATED_AT"} {
+					rankBy(items, key, func(a, b matrixItem) bool { return a.seq < b.seq })
+				}
+				for _, attr := range []string{"fixtureCount", "startChannel"} {
+					if _, ok := items[0].attrs[attr]; ok {
+						rankBy(items, toEnumCase(attr), func(a, b matrixItem) bool {
+							return a.attrs[attr].(int) < b.attrs[attr].(int)
+						})
+					}
+				}
+
+				for _, value := range matrixTypeMembers(t, client, ctx, sortType) {
+					t.Run("Sort_"+value, func(t *testing.T) {
+						if _, ok := items[0].ranks[value]; !ok {
+							t.Skipf("Skipping: no rule for %s.%s", sortType, value)
+						}
+						got := query(t, nil, value)
+						require.Len(t, got, len(items))
+
+						byID := make(map[string]matrixItem, len(items))
+						for _, item := range items {
+							byID[item.id] = item
+						}
+						ranks := make([]int, len(got))
+						for i, id := range got {
+							ranks[i] = byID[id].ranks[value]
+						}
+
+						ascending := sort.IntsAreSorted(ranks)
+						descending := sort.SliceIsSorted(ranks, func(i, j int) bool { return ranks[i] > ranks[j] })
+						assert.True(t, ascending || descending, "%s sortBy %s returned ranks %v", list.field, value, ranks)
+						if descending && !ascending {
+							t.Logf("Contract: %s sortBy %s orders descending", list.field, value)
+						}
+					})
+				}
+			}
+		})
+	}
+}
+
+// matrixFilterCase picks a filter value for a filter field and the seeded IDs it must match.
+func matrixFilterCase(field string, items []matrixItem, cutoff string) (interface{}, []string, bool) {
+	var keep func(matrixItem) bool
+	var value interface{}
+
+	switch field {
+	case "nameContains":
+		value = matrixNameFragment
+		keep = func(it matrixItem) bool { return strings.Contains(it.name, matrixNameFragment) }
+	case "createdAfter":
+		value = cutoff
+		keep = func(it matrixItem) bool { return it.seq >= 2 }
+	case "createdBefore":
+		value = cutoff
+		keep = func(it matrixItem) bool { return it.seq < 2 }
+	default:
+		// Equality filters on a seeded attribute use the first item's value
+		first, ok := items[0].attrs[field]
+		if !ok {
+			return nil, nil, false
+		}
+		value = first
+		keep = func(it matrixItem) bool { return it.attrs[field] == first }
+	}
+
+	want := []string{}
+	for _, it := range items {
+		if keep(it) {
+			want = append(want, it.id)
+		}
+	}
+	return value, want, true
+}
+
+// prefixJoin renders extra parameters or arguments after a leading one.
+func prefixJoin(parts []string) string {
+	if len(parts) == 0 {
+		return ""
+	}
+	return ", " + strings.Join(parts, ", ")
+}
+
+// toEnumCase converts a field name like fixtureCount to FIXTURE_COUNT.
+func toEnumCase(field string) string {
+	var b strings.Builder
+	for i, r := range field {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			b.WriteByte('_')
+		}
+		b.WriteRune(r)
+	}
+	return strings.ToUpper(b.String())
+}
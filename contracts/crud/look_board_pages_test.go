@@ -0,0 +1,316 @@
+// Package crud provides CRUD contract tests for all LacyLights entities.
+package crud
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lookBoardPagesContract is the API shape the page tests expect.
+const lookBoardPagesContract = `createLookBoardPage(input: { lookBoardId, name, order, defaultFadeTime }): LookBoardPage!
+  LookBoard.pages: [LookBoardPage!]! ordered by order, LookBoardPage { id, name, order, defaultFadeTime: Float, buttons }
+  lookBoardPage(id: ID!): LookBoardPage, CreateLookBoardButtonInput.pageId: ID
+  moveLookBoardButton(buttonId: ID!, pageId: ID!): LookBoardButton!, deleteLookBoardPage(id: ID!): Boolean!
+  A page's defaultFadeTime, when set, overrides the board's for buttons on that page`
+
+type lookBoardPage struct {
+	ID              string   `json:"id"`
+	Name            string   `json:"name"`
+	Order           int      `json:"order"`
+	DefaultFadeTime *float64 `json:"defaultFadeTime"`
+	Buttons         []struct {
+		ID   string `json:"id"`
+		Look struct {
+			ID string `json:"id"`
+		} `json:"look"`
+	} `json:"buttons"`
+}
+
+// TestLookBoardPages covers board pages: creating pages, placing buttons on a
+// page, moving a button between pages, querying one page's buttons, per-page
+// fade time overrides, and deleting a page that still has buttons.
+func TestLookBoardPages(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewTestClient(t, "")
+	compat.RequireMutation(t, client, "createLookBoardPage", lookBoardPagesContract)
+
+	// Create project
+	var projectResp struct {
+		CreateProject struct {
+			ID string `json:"id"`
+		} `json:"createProject"`
+	}
+
+	err := client.Mutate(ctx, `
+		mutation CreateProject($input: CreateProjectInput!) {
+			createProject(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{"name": "Look Board Pages Test Project"},
+	}, &projectResp)
+
+	require.NoError(t, err)
+	projectID := projectResp.CreateProject.ID
+	defer func() {
+		_ = client.Mutate(ctx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+		_ = client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
+			map[string]interface{}{"id": projectID}, nil)
+	}()
+
+	fixtureID := createTestFixture(t, client, ctx, projectID, "Pages Fixture", 1)
+
+	var lookIDs []string
+	for _, name := range []string{"Page Look 1", "Page Look 2", "Page Look 3"} {
+		var lookResp struct {
+			CreateLook struct {
+				ID string `json:"id"`
+			} `json:"createLook"`
+		}
+		err = client.Mutate(ctx, `
+			mutation CreateLook($input: CreateLookInput!) {
+				createLook(input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"projectId": projectID,
+				"name":      name,
+				"fixtureValues": []map[string]interface{}{
+					{"fixtureId": fixtureID, "channels": []map[string]interface{}{{"offset": 0, "value": 255}}},
+				},
+			},
+		}, &lookResp)
+		require.NoError(t, err)
+		lookIDs = append(lookIDs, lookResp.CreateLook.ID)
+	}
+
+	var boardResp struct {
+		CreateLookBoard struct {
+			ID string `json:"id"`
+		} `json:"createLookBoard"`
+	}
+	err = client.Mutate(ctx, `
+		mutation CreateLookBoard($input: CreateLookBoardInput!) {
+			createLookBoard(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId":       projectID,
+			"name":            "Paged Board",
+			"defaultFadeTime": 0.0,
+		},
+	}, &boardResp)
+	require.NoError(t, err)
+	boardID := boardResp.CreateLookBoard.ID
+
+	createPage := func(t *testing.T, name string, order int, fade interface{}) string {
+		var resp struct {
+			CreateLookBoardPage lookBoardPage `json:"createLookBoardPage"`
+		}
+		err := client.Mutate(ctx, `
+			mutation CreateLookBoardPage($input: CreateLookBoardPageInput!) {
+				createLookBoardPage(input: $input) { id name order defaultFadeTime }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"lookBoardId":     boardID,
+				"name":            name,
+				"order":           order,
+				"defaultFadeTime": fade,
+			},
+		}, &resp)
+		require.NoError(t, err)
+		require.NotEmpty(t, resp.CreateLookBoardPage.ID)
+		return resp.CreateLookBoardPage.ID
+	}
+
+	getPage := func(t *testing.T, pageID string) *lookBoardPage {
+		var resp struct {
+			LookBoardPage *lookBoardPage `json:"lookBoardPage"`
+		}
+		err := client.Query(ctx, `
+			query GetLookBoardPage($id: ID!) {
+				lookBoardPage(id: $id) {
+					id
+					name
+					order
+					defaultFadeTime
+					buttons { id look { id } }
+				}
+			}
+		`, map[string]interface{}{"id": pageID}, &resp)
+		require.NoError(t, err)
+		return resp.LookBoardPage
+	}
+
+	// Created out of order to check the board sorts by order, not creation time
+	pageB := createPage(t, "Bank B", 2, 2.0)
+	pageA := createPage(t, "Bank A", 1, nil)
+
+	t.Run("PagesOrdered", func(t *testing.T) {
+		var resp struct {
+			LookBoard struct {
+				Pages []lookBoardPage `json:"pages"`
+			} `json:"lookBoard"`
+		}
+		err := client.Query(ctx, `
+			query GetLookBoardPages($id: ID!) {
+				lookBoard(id: $id) {
+					pages { id name order defaultFadeTime }
+				}
+			}
+		`, map[string]interface{}{"id": boardID}, &resp)
+		require.NoError(t, err)
+
+		pages := resp.LookBoard.Pages
+		require.Len(t, pages, 2)
+		assert.Equal(t, pageA, pages[0].ID)
+		assert.Equal(t, pageB, pages[1].ID)
+		assert.Nil(t, pages[0].DefaultFadeTime, "A page without an override inherits the board fade time")
+		require.NotNil(t, pages[1].DefaultFadeTime)
+		assert.InDelta(t, 2.0, *pages[1].DefaultFadeTime, 0.001)
+	})
+
+	buttonIDs := make([]string, len(lookIDs))
+	t.Run("ButtonsPerPage", func(t *testing.T) {
+		for i, lookID := range lookIDs {
+			pageID := pageA
+			if i == 2 {
+				pageID = pageB
+			}
+			var resp struct {
+				AddLookToBoard struct {
+					ID string `json:"id"`
+				} `json:"addLookToBoard"`
+			}
+			err := client.Mutate(ctx, `
+				mutation AddLookToBoard($input: CreateLookBoardButtonInput!) {
+					addLookToBoard(input: $input) { id }
+				}
+			`, map[string]interface{}{
+				"input": map[string]interface{}{
+					"lookBoardId": boardID,
+					"pageId":      pageID,
+					"lookId":      lookID,
+					"layoutX":     i * 200,
+					"layoutY":     0,
+				},
+			}, &resp)
+			require.NoError(t, err)
+			buttonIDs[i] = resp.AddLookToBoard.ID
+		}
+
+		a := getPage(t, pageA)
+		require.NotNil(t, a)
+		assert.Len(t, a.Buttons, 2, "Bank A should only return its own buttons")
+		b := getPage(t, pageB)
+		require.NotNil(t, b)
+		require.Len(t, b.Buttons, 1)
+		assert.Equal(t, lookIDs[2], b.Buttons[0].Look.ID)
+	})
+
+	t.Run("MoveButtonBetweenPages", func(t *testing.T) {
+		err := client.Mutate(ctx, `
+			mutation MoveLookBoardButton($buttonId: ID!, $pageId: ID!) {
+				moveLookBoardButton(buttonId: $buttonId, pageId: $pageId) { id }
+			}
+		`, map[string]interface{}{"buttonId": buttonIDs[1], "pageId": pageB}, nil)
+		require.NoError(t, err)
+
+		a := getPage(t, pageA)
+		b := getPage(t, pageB)
+		require.NotNil(t, a)
+		require.NotNil(t, b)
+		assert.Len(t, a.Buttons, 1)
+		assert.Len(t, b.Buttons, 2)
+
+		found := false
+		for _, button := range b.Buttons {
+			if button.ID == buttonIDs[1] {
+				found = true
+				assert.Equal(t, lookIDs[1], button.Look.ID, "A moved button keeps its look")
+			}
+		}
+		assert.True(t, found, "Moved button should keep its ID on the new page")
+	})
+
+	t.Run("PageFadeTimeOverride", func(t *testing.T) {
+		if compat.DMXChecksDisabled() {
+			t.Skip("Skipping fade timing check: SKIP_DMX_TESTS or SKIP_FADE_TESTS is set")
+		}
+
+		// The board default is a snap; a button on Bank B should fade over 2s instead
+		_ = client.Mutate(ctx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+		time.Sleep(200 * time.Millisecond)
+
+		err := client.Mutate(ctx, `
+			mutation ActivateLookFromBoard($lookBoardId: ID!, $lookId: ID!) {
+				activateLookFromBoard(lookBoardId: $lookBoardId, lookId: $lookId)
+			}
+		`, map[string]interface{}{"lookBoardId": boardID, "lookId": lookIDs[2]}, nil)
+		require.NoError(t, err)
+		time.Sleep(500 * time.Millisecond)
+
+		var dmxResp struct {
+			DMXOutput []int `json:"dmxOutput"`
+		}
+		err = client.Query(ctx, `query { dmxOutput(universe: 1) }`, nil, &dmxResp)
+		require.NoError(t, err)
+		require.Len(t, dmxResp.DMXOutput, 512, "dmxOutput should cover the universe")
+		assert.Greater(t, dmxResp.DMXOutput[0], 0, "Fade should have started")
+		assert.Less(t, dmxResp.DMXOutput[0], 255, "Bank B's 2s fade time should override the board's snap")
+	})
+
+	t.Run("DeleteNonEmptyPage", func(t *testing.T) {
+		var resp struct {
+			DeleteLookBoardPage bool `json:"deleteLookBoardPage"`
+		}
+		err := client.Mutate(ctx, `
+			mutation DeleteLookBoardPage($id: ID!) {
+				deleteLookBoardPage(id: $id)
+			}
+		`, map[string]interface{}{"id": pageB}, &resp)
+
+		if err != nil || !resp.DeleteLookBoardPage {
+			t.Logf("Contract: deleting a non-empty page is rejected (err: %v)", err)
+			b := getPage(t, pageB)
+			require.NotNil(t, b, "Rejected delete should leave the page intact")
+			assert.Len(t, b.Buttons, 2, "Rejected delete should leave the buttons intact")
+			return
+		}
+
+		assert.Nil(t, getPage(t, pageB), "Deleted page should not be found")
+
+		// The page's buttons either move to a remaining page or go with it; looks always survive
+		a := getPage(t, pageA)
+		require.NotNil(t, a)
+		switch len(a.Buttons) {
+		case 3:
+			t.Log("Contract: deleting a page moves its buttons to the first page")
+		case 1:
+			t.Log("Contract: deleting a page deletes its buttons")
+		default:
+			t.Errorf("Unexpected button count on Bank A after deleting Bank B: %d", len(a.Buttons))
+		}
+
+		var lookResp struct {
+			Look *struct {
+				ID string `json:"id"`
+			} `json:"look"`
+		}
+		err = client.Query(ctx, `
+			query GetLook($id: ID!) {
+				look(id: $id) { id }
+			}
+		`, map[string]interface{}{"id": lookIDs[2]}, &lookResp)
+		require.NoError(t, err)
+		assert.NotNil(t, lookResp.Look, "Deleting a page must never delete looks")
+	})
+}
@@ -0,0 +1,235 @@
+package crud
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/fixtures"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lookTagsContract is the look tagging API these tests expect.
+const lookTagsContract = `Look.tags: [String!]!
+  addTagToLook(lookId: ID!, tag: String!): Look! and removeTagFromLook(lookId: ID!, tag: String!): Look!
+  LookFilterInput.tags: [String!] matches looks with all (AND) or any (OR) of the tags
+  renameLookTag(projectId: ID!, from: String!, to: String!): Int! and deleteLookTag(projectId: ID!, tag: String!): Int!
+  apply to every look in the project and return how many looks changed`
+
+// lookTagSetup is a project of looks to tag, plus a look in a second project
+// that project-wide tag changes must not reach.
+type lookTagSetup struct {
+	client    *graphql.Client
+	ctx       context.Context
+	projectID string
+	looks     map[string]string
+	otherLook string
+}
+
+func newLookTagSetup(t *testing.T, client *graphql.Client, ctx context.Context) *lookTagSetup {
+	s := &lookTagSetup{client: client, ctx: ctx, looks: map[string]string{}}
+
+	definitionID, err := fixtures.CreateParDefinition(ctx, client, "Test Look Tags", fmt.Sprintf("Tag Par %d", time.Now().UnixNano()))
+	require.NoError(t, err)
+	s.projectID, err = fixtures.CreateProject(ctx, client, "Look Tags Test Project")
+	require.NoError(t, err)
+	otherProjectID, err := fixtures.CreateProject(ctx, client, "Look Tags Other Project")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cleanupCancel()
+		_ = fixtures.DeleteProject(cleanupCtx, client, s.projectID)
+		_ = fixtures.DeleteProject(cleanupCtx, client, otherProjectID)
+		_ = fixtures.DeleteFixtureDefinition(cleanupCtx, client, definitionID)
+	})
+
+	createLook := func(projectID, name string) string {
+		fixtureID, err := fixtures.CreateFixture(ctx, client, projectID, definitionID, fixtures.Fixture{Name: name + " Par", Universe: 1, StartChannel: 1})
+		require.NoError(t, err)
+		lookID, err := fixtures.CreateLook(ctx, client, projectID, name,
+			[]fixtures.FixtureValues{{FixtureID: fixtureID, Values: []int{255, 0, 0, 0}}})
+		require.NoError(t, err)
+		return lookID
+	}
+	for _, name := range []string{"Warm Front", "Warm Only", "Front Only", "Untagged"} {
+		s.looks[name] = createLook(s.projectID, name)
+	}
+	s.otherLook = createLook(otherProjectID, "Other Warm")
+	return s
+}
+
+// tag adds or removes a tag and returns the look's tags as reported.
+func (s *lookTagSetup) tag(t *testing.T, mutation, lookID, tag string) []string {
+	var resp map[string]struct {
+		Tags []string `json:"tags"`
+	}
+	err := s.client.Mutate(s.ctx, fmt.Sprintf(`
+		mutation Tag($lookId: ID!, $tag: String!) {
+			%s(lookId: $lookId, tag: $tag) { tags }
+		}
+	`, mutation), map[string]interface{}{"lookId": lookID, "tag": tag}, &resp)
+	require.NoError(t, err)
+	return sortedTags(resp[mutation].Tags)
+}
+
+// tagsOf reads a look's tags.
+func (s *lookTagSetup) tagsOf(t *testing.T, lookID string) []string {
+	var resp struct {
+		Look struct {
+			Tags []string `json:"tags"`
+		} `json:"look"`
+	}
+	require.NoError(t, s.client.Query(s.ctx, `query GetLook($id: ID!) { look(id: $id) { tags } }`,
+		map[string]interface{}{"id": lookID}, &resp))
+	return sortedTags(resp.Look.Tags)
+}
+
+// filter returns the names of the project's looks matching the tags.
+func (s *lookTagSetup) filter(t *testing.T, tags ...string) []string {
+	var resp struct {
+		Looks struct {
+			Looks []struct {
+				Name string `json:"name"`
+			} `json:"looks"`
+		} `json:"looks"`
+	}
+	err := s.client.Query(s.ctx, `
+		query ListLooks($projectId: ID!, $filter: LookFilterInput) {
+			looks(projectId: $projectId, filter: $filter) {
+				looks { name }
+			}
+		}
+	`, map[string]interface{}{
+		"projectId": s.projectID,
+		"filter":    map[string]interface{}{"tags": tags},
+	}, &resp)
+	require.NoError(t, err)
+
+	var names []string
+	for _, look := range resp.Looks.Looks {
+		names = append(names, look.Name)
+	}
+	return sortedTags(names)
+}
+
+// renameTag renames a tag across the project and returns the number of looks changed.
+func (s *lookTagSetup) renameTag(t *testing.T, from, to string) int {
+	compat.RequireMutation(t, s.client, "renameLookTag", lookTagsContract)
+	var resp struct {
+		RenameLookTag int `json:"renameLookTag"`
+	}
+	err := s.client.Mutate(s.ctx, `
+		mutation RenameLookTag($projectId: ID!, $from: String!, $to: String!) {
+			renameLookTag(projectId: $projectId, from: $from, to: $to)
+		}
+	`, map[string]interface{}{"projectId": s.projectID, "from": from, "to": to}, &resp)
+	require.NoError(t, err)
+	return resp.RenameLookTag
+}
+
+// deleteTag removes a tag from every look in the project and returns the number of looks changed.
+func (s *lookTagSetup) deleteTag(t *testing.T, tag string) int {
+	compat.RequireMutation(t, s.client, "deleteLookTag", lookTagsContract)
+	var resp struct {
+		DeleteLookTag int `json:"deleteLookTag"`
+	}
+	err := s.client.Mutate(s.ctx, `
+		mutation DeleteLookTag($projectId: ID!, $tag: String!) {
+			deleteLookTag(projectId: $projectId, tag: $tag)
+		}
+	`, map[string]interface{}{"projectId": s.projectID, "tag": tag}, &resp)
+	require.NoError(t, err)
+	return resp.DeleteLookTag
+}
+
+// sortedTags copies and sorts tags, as the API does not promise an order.
+func sortedTags(values []string) []string {
+	out := append([]string{}, values...)
+	sort.Strings(out)
+	return out
+}
+
+// TestLookTags tags looks, filters by one and by several tags, and renames
+// and deletes a tag across the project. See lookTagsContract.
+func TestLookTags(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewTestClient(t, "")
+	compat.RequireMutation(t, client, "addTagToLook", lookTagsContract)
+	s := newLookTagSetup(t, client, ctx)
+
+	assert.Empty(t, s.tagsOf(t, s.looks["Untagged"]), "New looks should have no tags")
+	assert.Equal(t, []string{"warm"}, s.tag(t, "addTagToLook", s.looks["Warm Front"], "warm"))
+	assert.Equal(t, []string{"front", "warm"}, s.tag(t, "addTagToLook", s.looks["Warm Front"], "front"))
+	s.tag(t, "addTagToLook", s.looks["Warm Only"], "warm")
+	s.tag(t, "addTagToLook", s.looks["Front Only"], "front")
+	s.tag(t, "addTagToLook", s.otherLook, "warm")
+
+	t.Run("AddTwice", func(t *testing.T) {
+		assert.Equal(t, []string{"warm"}, s.tag(t, "addTagToLook", s.looks["Warm Only"], "warm"),
+			"Adding a tag the look already has should not duplicate it")
+	})
+
+	t.Run("RemoveTag", func(t *testing.T) {
+		compat.RequireMutation(t, client, "removeTagFromLook", lookTagsContract)
+		s.tag(t, "addTagToLook", s.looks["Untagged"], "scratch")
+		assert.Empty(t, s.tag(t, "removeTagFromLook", s.looks["Untagged"], "scratch"))
+		assert.Empty(t, s.tagsOf(t, s.looks["Untagged"]), "The removal should be stored")
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		compat.RequireTypeField(t, client, "LookFilterInput", "tags", lookTagsContract)
+
+		assert.Equal(t, []string{"Warm Front", "Warm Only"}, s.filter(t, "warm"))
+		assert.Equal(t, []string{"Front Only", "Warm Front"}, s.filter(t, "front"))
+		assert.Empty(t, s.filter(t, "no-such-tag"))
+
+		both := s.filter(t, "warm", "front")
+		switch len(both) {
+		case 1:
+			assert.Equal(t, []string{"Warm Front"}, both)
+			t.Log("Contract: filtering by several tags matches looks with all of them (AND)")
+		default:
+			assert.Equal(t, []string{"Front Only", "Warm Front", "Warm Only"}, both)
+			t.Log("Contract: filtering by several tags matches looks with any of them (OR)")
+		}
+	})
+
+	t.Run("Rename", func(t *testing.T) {
+		changed := s.renameTag(t, "warm", "amber")
+		assert.Equal(t, 2, changed, "Both warm looks in the project should be renamed")
+		assert.Equal(t, []string{"amber", "front"}, s.tagsOf(t, s.looks["Warm Front"]))
+		assert.Equal(t, []string{"amber"}, s.tagsOf(t, s.looks["Warm Only"]))
+		assert.Equal(t, []string{"warm"}, s.tagsOf(t, s.otherLook), "Another project's tags should not change")
+
+		t.Run("OntoExistingTag", func(t *testing.T) {
+			s.renameTag(t, "amber", "front")
+			assert.Equal(t, []string{"front"}, s.tagsOf(t, s.looks["Warm Front"]), "Renaming onto a tag the look has should merge them")
+		})
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		// Warm Front and Front Only carry the tag whether or not Rename ran
+		changed := s.deleteTag(t, "front")
+		assert.Equal(t, 2, changed, "Every look with the tag should change")
+		assert.Empty(t, s.tagsOf(t, s.looks["Front Only"]))
+		assert.NotContains(t, s.tagsOf(t, s.looks["Warm Front"]), "front")
+
+		var resp struct {
+			Look *struct {
+				Name string `json:"name"`
+			} `json:"look"`
+		}
+		require.NoError(t, client.Query(ctx, `query GetLook($id: ID!) { look(id: $id) { name } }`,
+			map[string]interface{}{"id": s.looks["Front Only"]}, &resp))
+		require.NotNil(t, resp.Look, "Deleting a tag should not delete its looks")
+		assert.Equal(t, "Front Only", resp.Look.Name)
+		assert.Equal(t, []string{"warm"}, s.tagsOf(t, s.otherLook), "Another project's tags should not change")
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/bbernstein/lacylights-test/pkg/lacyapi"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -15,28 +16,16 @@ import (
 func createTestFixture(t *testing.T, client *graphql.Client, ctx context.Context, projectID string, name string, startChannel int) string {
 	definitionID := getOrCreateFixtureDefinition(t, client, ctx)
 
-	var resp struct {
-		CreateFixtureInstance struct {
-			ID string `json:"id"`
-		} `json:"createFixtureInstance"`
-	}
-
-	err := client.Mutate(ctx, `
-		mutation CreateFixtureInstance($input: CreateFixtureInstanceInput!) {
-			createFixtureInstance(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"projectId":    projectID,
-			"definitionId": definitionID,
-			"name":         name,
-			"universe":     1,
-			"startChannel": startChannel,
-		},
-	}, &resp)
+	fixture, err := lacyapi.New(client).CreateFixtureInstance(ctx, lacyapi.CreateFixtureInstanceInput{
+		ProjectID:    projectID,
+		DefinitionID: definitionID,
+		Name:         name,
+		Universe:     1,
+		StartChannel: startChannel,
+	})
 
 	require.NoError(t, err)
-	return resp.CreateFixtureInstance.ID
+	return fixture.ID
 }
 
 // TestLookCRUD tests all look CRUD operations.
@@ -44,28 +33,15 @@ func TestLookCRUD(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	client := graphql.NewClient("")
+	client := graphql.NewTestClient(t, "")
+	api := lacyapi.New(client)
 
 	// Create project
-	var projectResp struct {
-		CreateProject struct {
-			ID string `json:"id"`
-		} `json:"createProject"`
-	}
-
-	err := client.Mutate(ctx, `
-		mutation CreateProject($input: CreateProjectInput!) {
-			createProject(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{"name": "Look CRUD Test Project"},
-	}, &projectResp)
-
+	project, err := api.CreateProject(ctx, lacyapi.CreateProjectInput{Name: "Look CRUD Test Project"})
 	require.NoError(t, err)
-	projectID := projectResp.CreateProject.ID
+	projectID := project.ID
 	defer func() {
-		_ = client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
-			map[string]interface{}{"id": projectID}, nil)
+		_, _ = api.DeleteProject(ctx, projectID)
 	}()
 
 	// Create fixtures for looks
@@ -302,36 +278,16 @@ func TestLookCRUD(t *testing.T) {
 
 		// DELETE
 		t.Run("DeleteLook", func(t *testing.T) {
-			var deleteResp struct {
-				DeleteLook bool `json:"deleteLook"`
-			}
-
-			err := client.Mutate(ctx, `
-				mutation DeleteLook($id: ID!) {
-					deleteLook(id: $id)
-				}
-			`, map[string]interface{}{"id": lookID}, &deleteResp)
+			deleted, err := api.DeleteLook(ctx, lookID)
 
 			require.NoError(t, err)
-			assert.True(t, deleteResp.DeleteLook)
+			assert.True(t, deleted)
 
 			// Verify deletion
-			var verifyResp struct {
-				Look *struct {
-					ID string `json:"id"`
-				} `json:"look"`
-			}
-
-			err = client.Query(ctx, `
-				query GetLook($id: ID!) {
-					look(id: $id) {
-						id
-					}
-				}
-			`, map[string]interface{}{"id": lookID}, &verifyResp)
+			look, err := api.Look(ctx, lookID)
 
 			if err == nil {
-				assert.Nil(t, verifyResp.Look, "Deleted look should not be found")
+				assert.Nil(t, look, "Deleted look should not be found")
 			}
 		})
 	})
@@ -342,28 +298,15 @@ func TestLookFixtureManagement(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	client := graphql.NewClient("")
+	client := graphql.NewTestClient(t, "")
+	api := lacyapi.New(client)
 
 	// Create project
-	var projectResp struct {
-		CreateProject struct {
-			ID string `json:"id"`
-		} `json:"createProject"`
-	}
-
-	err := client.Mutate(ctx, `
-		mutation CreateProject($input: CreateProjectInput!) {
-			createProject(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{"name": "Look Fixture Management Test"},
-	}, &projectResp)
-
+	project, err := api.CreateProject(ctx, lacyapi.CreateProjectInput{Name: "Look Fixture Management Test"})
 	require.NoError(t, err)
-	projectID := projectResp.CreateProject.ID
+	projectID := project.ID
 	defer func() {
-		_ = client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
-			map[string]interface{}{"id": projectID}, nil)
+		_, _ = api.DeleteProject(ctx, projectID)
 	}()
 
 	// Create fixtures
@@ -372,33 +315,21 @@ func TestLookFixtureManagement(t *testing.T) {
 	fixture3ID := createTestFixture(t, client, ctx, projectID, "Managed Fixture 3", 20)
 
 	// Create look with one fixture
-	var lookResp struct {
-		CreateLook struct {
-			ID string `json:"id"`
-		} `json:"createLook"`
-	}
-
-	err = client.Mutate(ctx, `
-		mutation CreateLook($input: CreateLookInput!) {
-			createLook(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"projectId": projectID,
-			"name":      "Fixture Management Look",
-			"fixtureValues": []map[string]interface{}{
-				{
-					"fixtureId": fixture1ID,
-					"channels": []map[string]interface{}{
-						{"offset": 0, "value": 100},
-					},
+	look, err := api.CreateLook(ctx, lacyapi.CreateLookInput{
+		ProjectID: projectID,
+		Name:      "Fixture Management Look",
+		FixtureValues: []lacyapi.FixtureValueInput{
+			{
+				FixtureID: fixture1ID,
+				Channels: []lacyapi.ChannelValueInput{
+					{Offset: 0, Value: 100},
 				},
 			},
 		},
-	}, &lookResp)
+	})
 
 	require.NoError(t, err)
-	lookID := lookResp.CreateLook.ID
+	lookID := look.ID
 
 	// ADD FIXTURES TO LOOK
 	t.Run("AddFixturesToLook", func(t *testing.T) {
@@ -503,61 +434,36 @@ func TestLookCloneAndDuplicate(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	client := graphql.NewClient("")
+	client := graphql.NewTestClient(t, "")
+	api := lacyapi.New(client)
 
 	// Create project
-	var projectResp struct {
-		CreateProject struct {
-			ID string `json:"id"`
-		} `json:"createProject"`
-	}
-
-	err := client.Mutate(ctx, `
-		mutation CreateProject($input: CreateProjectInput!) {
-			createProject(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{"name": "Look Clone Test Project"},
-	}, &projectResp)
-
+	project, err := api.CreateProject(ctx, lacyapi.CreateProjectInput{Name: "Look Clone Test Project"})
 	require.NoError(t, err)
-	projectID := projectResp.CreateProject.ID
+	projectID := project.ID
 	defer func() {
-		_ = client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
-			map[string]interface{}{"id": projectID}, nil)
+		_, _ = api.DeleteProject(ctx, projectID)
 	}()
 
 	// Create fixture and look
 	fixtureID := createTestFixture(t, client, ctx, projectID, "Clone Test Fixture", 1)
 
-	var lookResp struct {
-		CreateLook struct {
-			ID string `json:"id"`
-		} `json:"createLook"`
-	}
-
-	err = client.Mutate(ctx, `
-		mutation CreateLook($input: CreateLookInput!) {
-			createLook(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"projectId":   projectID,
-			"name":        "Original Look",
-			"description": "Look to be cloned",
-			"fixtureValues": []map[string]interface{}{
-				{
-					"fixtureId": fixtureID,
-					"channels": []map[string]interface{}{
-						{"offset": 0, "value": 200},
-					},
+	look, err := api.CreateLook(ctx, lacyapi.CreateLookInput{
+		ProjectID:   projectID,
+		Name:        "Original Look",
+		Description: lacyapi.Ptr("Look to be cloned"),
+		FixtureValues: []lacyapi.FixtureValueInput{
+			{
+				FixtureID: fixtureID,
+				Channels: []lacyapi.ChannelValueInput{
+					{Offset: 0, Value: 200},
 				},
 			},
 		},
-	}, &lookResp)
+	})
 
 	require.NoError(t, err)
-	originalLookID := lookResp.CreateLook.ID
+	originalLookID := look.ID
 
 	// Verify original look has the channels before cloning
 	var verifyResp struct {
@@ -674,28 +580,15 @@ func TestLookComparison(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	client := graphql.NewClient("")
+	client := graphql.NewTestClient(t, "")
+	api := lacyapi.New(client)
 
 	// Create project
-	var projectResp struct {
-		CreateProject struct {
-			ID string `json:"id"`
-		} `json:"createProject"`
-	}
-
-	err := client.Mutate(ctx, `
-		mutation CreateProject($input: CreateProjectInput!) {
-			createProject(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{"name": "Look Compare Test Project"},
-	}, &projectResp)
-
+	project, err := api.CreateProject(ctx, lacyapi.CreateProjectInput{Name: "Look Compare Test Project"})
 	require.NoError(t, err)
-	projectID := projectResp.CreateProject.ID
+	projectID := project.ID
 	defer func() {
-		_ = client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
-			map[string]interface{}{"id": projectID}, nil)
+		_, _ = api.DeleteProject(ctx, projectID)
 	}()
 
 	// Create fixtures
@@ -703,68 +596,44 @@ func TestLookComparison(t *testing.T) {
 	fixture2ID := createTestFixture(t, client, ctx, projectID, "Compare Fixture 2", 10)
 
 	// Create two looks with different values
-	var look1Resp struct {
-		CreateLook struct {
-			ID string `json:"id"`
-		} `json:"createLook"`
-	}
-
-	err = client.Mutate(ctx, `
-		mutation CreateLook($input: CreateLookInput!) {
-			createLook(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"projectId": projectID,
-			"name":      "Look 1",
-			"fixtureValues": []map[string]interface{}{
-				{
-					"fixtureId": fixture1ID,
-					"channels": []map[string]interface{}{
-						{"offset": 0, "value": 255},
-					},
+	look1, err := api.CreateLook(ctx, lacyapi.CreateLookInput{
+		ProjectID: projectID,
+		Name:      "Look 1",
+		FixtureValues: []lacyapi.FixtureValueInput{
+			{
+				FixtureID: fixture1ID,
+				Channels: []lacyapi.ChannelValueInput{
+					{Offset: 0, Value: 255},
 				},
-				{
-					"fixtureId": fixture2ID,
-					"channels": []map[string]interface{}{
-						{"offset": 0, "value": 100},
-					},
+			},
+			{
+				FixtureID: fixture2ID,
+				Channels: []lacyapi.ChannelValueInput{
+					{Offset: 0, Value: 100},
 				},
 			},
 		},
-	}, &look1Resp)
+	})
 
 	require.NoError(t, err)
-	look1ID := look1Resp.CreateLook.ID
-
-	var look2Resp struct {
-		CreateLook struct {
-			ID string `json:"id"`
-		} `json:"createLook"`
-	}
-
-	err = client.Mutate(ctx, `
-		mutation CreateLook($input: CreateLookInput!) {
-			createLook(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"projectId": projectID,
-			"name":      "Look 2",
-			"fixtureValues": []map[string]interface{}{
-				{
-					"fixtureId": fixture1ID,
-					"channels": []map[string]interface{}{
-						{"offset": 0, "value": 100}, // Different from Look 1
-					},
+	look1ID := look1.ID
+
+	look2, err := api.CreateLook(ctx, lacyapi.CreateLookInput{
+		ProjectID: projectID,
+		Name:      "Look 2",
+		FixtureValues: []lacyapi.FixtureValueInput{
+			{
+				FixtureID: fixture1ID,
+				Channels: []lacyapi.ChannelValueInput{
+					{Offset: 0, Value: 100}, // Different from Look 1
 				},
-				// fixture2 is NOT in this look
 			},
+			// fixture2 is NOT in this look
 		},
-	}, &look2Resp)
+	})
 
 	require.NoError(t, err)
-	look2ID := look2Resp.CreateLook.ID
+	look2ID := look2.ID
 
 	// Compare looks
 	var compareResp struct {
@@ -830,51 +699,26 @@ func TestLookUsage(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	client := graphql.NewClient("")
+	client := graphql.NewTestClient(t, "")
+	api := lacyapi.New(client)
 
 	// Create project
-	var projectResp struct {
-		CreateProject struct {
-			ID string `json:"id"`
-		} `json:"createProject"`
-	}
-
-	err := client.Mutate(ctx, `
-		mutation CreateProject($input: CreateProjectInput!) {
-			createProject(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{"name": "Look Usage Test Project"},
-	}, &projectResp)
-
+	project, err := api.CreateProject(ctx, lacyapi.CreateProjectInput{Name: "Look Usage Test Project"})
 	require.NoError(t, err)
-	projectID := projectResp.CreateProject.ID
+	projectID := project.ID
 	defer func() {
-		_ = client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
-			map[string]interface{}{"id": projectID}, nil)
+		_, _ = api.DeleteProject(ctx, projectID)
 	}()
 
 	// Create look
-	var lookResp struct {
-		CreateLook struct {
-			ID string `json:"id"`
-		} `json:"createLook"`
-	}
-
-	err = client.Mutate(ctx, `
-		mutation CreateLook($input: CreateLookInput!) {
-			createLook(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"projectId":     projectID,
-			"name":          "Usage Test Look",
-			"fixtureValues": []map[string]interface{}{},
-		},
-	}, &lookResp)
+	look, err := api.CreateLook(ctx, lacyapi.CreateLookInput{
+		ProjectID:     projectID,
+		Name:          "Usage Test Look",
+		FixtureValues: []lacyapi.FixtureValueInput{},
+	})
 
 	require.NoError(t, err)
-	lookID := lookResp.CreateLook.ID
+	lookID := look.ID
 
 	// Query look usage (should be empty initially)
 	var usageResp struct {
@@ -919,28 +763,15 @@ func TestUpdateLookPartial(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	client := graphql.NewClient("")
+	client := graphql.NewTestClient(t, "")
+	api := lacyapi.New(client)
 
 	// Create project
-	var projectResp struct {
-		CreateProject struct {
-			ID string `json:"id"`
-		} `json:"createProject"`
-	}
-
-	err := client.Mutate(ctx, `
-		mutation CreateProject($input: CreateProjectInput!) {
-			createProject(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{"name": "Partial Update Test Project"},
-	}, &projectResp)
-
+	project, err := api.CreateProject(ctx, lacyapi.CreateProjectInput{Name: "Partial Update Test Project"})
 	require.NoError(t, err)
-	projectID := projectResp.CreateProject.ID
+	projectID := project.ID
 	defer func() {
-		_ = client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
-			map[string]interface{}{"id": projectID}, nil)
+		_, _ = api.DeleteProject(ctx, projectID)
 	}()
 
 	// Create fixtures
@@ -948,33 +779,21 @@ func TestUpdateLookPartial(t *testing.T) {
 	fixture2ID := createTestFixture(t, client, ctx, projectID, "Partial Update Fixture 2", 10)
 
 	// Create look with fixture1
-	var lookResp struct {
-		CreateLook struct {
-			ID string `json:"id"`
-		} `json:"createLook"`
-	}
-
-	err = client.Mutate(ctx, `
-		mutation CreateLook($input: CreateLookInput!) {
-			createLook(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"projectId": projectID,
-			"name":      "Original Name",
-			"fixtureValues": []map[string]interface{}{
-				{
-					"fixtureId": fixture1ID,
-					"channels": []map[string]interface{}{
-						{"offset": 0, "value": 100},
-					},
+	look, err := api.CreateLook(ctx, lacyapi.CreateLookInput{
+		ProjectID: projectID,
+		Name:      "Original Name",
+		FixtureValues: []lacyapi.FixtureValueInput{
+			{
+				FixtureID: fixture1ID,
+				Channels: []lacyapi.ChannelValueInput{
+					{Offset: 0, Value: 100},
 				},
 			},
 		},
-	}, &lookResp)
+	})
 
 	require.NoError(t, err)
-	lookID := lookResp.CreateLook.ID
+	lookID := look.ID
 
 	// Update only the name (partial update)
 	t.Run("UpdateNameOnly", func(t *testing.T) {
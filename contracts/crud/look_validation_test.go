@@ -0,0 +1,174 @@
+package crud
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/bbernstein/lacylights-test/pkg/lacyapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lookChannelValue is a stored channel value as returned by look queries.
+type lookChannelValue struct {
+	Offset int `json:"offset"`
+	Value  int `json:"value"`
+}
+
+// lookValidationOutcome describes how the server handled a look write.
+type lookValidationOutcome struct {
+	Rejected bool
+	Channels []lookChannelValue
+}
+
+// TestLookChannelValueValidation verifies the contract for out-of-range channel data
+// in looks. For each invalid input the server must either reject the write with an
+// error or store a sanitized value (clamped to 0-255, or the out-of-range offset
+// dropped). createLook and updateLook must handle the same input the same way so
+// clients can rely on a single rule.
+func TestLookChannelValueValidation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewTestClient(t, "")
+	api := lacyapi.New(client)
+
+	project, err := api.CreateProject(ctx, lacyapi.CreateProjectInput{Name: "Look Validation Test Project"})
+	require.NoError(t, err)
+	projectID := project.ID
+	defer func() {
+		_, _ = api.DeleteProject(ctx, projectID)
+	}()
+
+	// Single-channel dimmer, so offset 0 is the only valid offset
+	fixtureID := createTestFixture(t, client, ctx, projectID, "Validation Fixture", 1)
+
+	fixtureValues := func(offset, value int) []map[string]interface{} {
+		return []map[string]interface{}{
+			{
+				"fixtureId": fixtureID,
+				"channels": []map[string]interface{}{
+					{"offset": offset, "value": value},
+				},
+			},
+		}
+	}
+
+	createOutcome := func(name string, offset, value int) lookValidationOutcome {
+		var resp struct {
+			CreateLook struct {
+				FixtureValues []struct {
+					Channels []lookChannelValue `json:"channels"`
+				} `json:"fixtureValues"`
+			} `json:"createLook"`
+		}
+
+		err := client.Mutate(ctx, `
+			mutation CreateLook($input: CreateLookInput!) {
+				createLook(input: $input) {
+					fixtureValues {
+						channels { offset value }
+					}
+				}
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"projectId":     projectID,
+				"name":          name,
+				"fixtureValues": fixtureValues(offset, value),
+			},
+		}, &resp)
+
+		if err != nil {
+			return lookValidationOutcome{Rejected: true}
+		}
+
+		var channels []lookChannelValue
+		for _, fv := range resp.CreateLook.FixtureValues {
+			channels = append(channels, fv.Channels...)
+		}
+		return lookValidationOutcome{Channels: channels}
+	}
+
+	updateOutcome := func(t *testing.T, offset, value int) lookValidationOutcome {
+		lookID := createTestLook(t, client, ctx, projectID, "Validation Update Target")
+
+		var resp struct {
+			UpdateLook struct {
+				FixtureValues []struct {
+					Channels []lookChannelValue `json:"channels"`
+				} `json:"fixtureValues"`
+			} `json:"updateLook"`
+		}
+
+		err := client.Mutate(ctx, `
+			mutation UpdateLook($id: ID!, $input: UpdateLookInput!) {
+				updateLook(id: $id, input: $input) {
+					fixtureValues {
+						channels { offset value }
+					}
+				}
+			}
+		`, map[string]interface{}{
+			"id":    lookID,
+			"input": map[string]interface{}{"fixtureValues": fixtureValues(offset, value)},
+		}, &resp)
+
+		if err != nil {
+			return lookValidationOutcome{Rejected: true}
+		}
+
+		var channels []lookChannelValue
+		for _, fv := range resp.UpdateLook.FixtureValues {
+			channels = append(channels, fv.Channels...)
+		}
+		return lookValidationOutcome{Channels: channels}
+	}
+
+	tests := []struct {
+		name    string
+		offset  int
+		value   int
+		clamped int // expected stored value when the server sanitizes instead of rejecting
+		dropped bool
+	}{
+		{name: "ValueAboveMax", offset: 0, value: 300, clamped: 255},
+		{name: "NegativeValue", offset: 0, value: -5, clamped: 0},
+		{name: "OffsetBeyondChannelCount", offset: 1, value: 128, dropped: true},
+		{name: "NegativeOffset", offset: -1, value: 128, dropped: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created := createOutcome("Validation "+tt.name, tt.offset, tt.value)
+
+			if created.Rejected {
+				t.Logf("Contract: createLook rejects %s", tt.name)
+			} else if tt.dropped {
+				t.Logf("Contract: createLook drops %s", tt.name)
+				for _, ch := range created.Channels {
+					assert.Equal(t, 0, ch.Offset, "Only offset 0 is valid for a single-channel fixture")
+				}
+			} else {
+				t.Logf("Contract: createLook clamps %s", tt.name)
+				require.Len(t, created.Channels, 1)
+				assert.Equal(t, tt.clamped, created.Channels[0].Value)
+			}
+
+			for _, ch := range created.Channels {
+				assert.GreaterOrEqual(t, ch.Value, 0, "Stored values must be valid DMX")
+				assert.LessOrEqual(t, ch.Value, 255, "Stored values must be valid DMX")
+			}
+
+			updated := updateOutcome(t, tt.offset, tt.value)
+			assert.Equal(t, created.Rejected, updated.Rejected,
+				"createLook and updateLook should apply the same validation rule")
+			if !created.Rejected && !updated.Rejected {
+				assert.ElementsMatch(t, created.Channels, updated.Channels,
+					"createLook and updateLook should sanitize to the same stored values")
+			}
+		})
+	}
+}
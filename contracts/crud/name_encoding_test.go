@@ -0,0 +1,200 @@
+package crud
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nameCase is an entity name that commonly trips up escaping, with a
+// fragment of it that nameContains must match.
+type nameCase struct {
+	label    string
+	name     string
+	fragment string
+}
+
+var nameCases = []nameCase{
+	{label: "Emoji", name: "Opening 🎭✨ family 👩‍👩‍👧 look", fragment: "👩‍👩‍👧"},
+	{label: "RightToLeft", name: "مشهد الافتتاح – מערכה שלום", fragment: "שלום"},
+	{label: "CombiningMarks", name: "Cafe\u0301 Tableau", fragment: "e\u0301 T"},
+	{label: "QuotesAndNewlines", name: "He said \"go\"\nthen 'stop'\t\\ {braces} $var", fragment: "\"go\"\nthen"},
+	{label: "Long", name: strings.Repeat("Long name ", 99) + "end.123456", fragment: "name end.123456"},
+	{label: "LongMultibyte", name: strings.Repeat("ü", 1000), fragment: "üüü"},
+}
+
+// TestEntityNameEncoding creates projects, looks, fixtures and effects named
+// with emoji, right-to-left text, combining marks, quotes, newlines and 1000
+// character strings. Each name must read back byte-identical, without
+// normalization or truncation, and nameContains must match on any fragment.
+func TestEntityNameEncoding(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewTestClient(t, "")
+
+	var projectResp struct {
+		CreateProject struct {
+			ID string `json:"id"`
+		} `json:"createProject"`
+	}
+	err := client.Mutate(ctx, `
+		mutation CreateProject($input: CreateProjectInput!) {
+			createProject(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{"name": "Name Encoding Test Project"},
+	}, &projectResp)
+	require.NoError(t, err)
+	projectID := projectResp.CreateProject.ID
+
+	projectIDs := []string{projectID}
+	defer func() {
+		for _, id := range projectIDs {
+			_ = client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
+				map[string]interface{}{"id": id}, nil)
+		}
+	}()
+
+	definitionID := getOrCreateFixtureDefinition(t, client, ctx)
+
+	create := func(t *testing.T, field, inputType string, input map[string]interface{}) string {
+		var resp map[string]struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		}
+		err := client.Mutate(ctx, fmt.Sprintf(`
+			mutation Create($input: %s!) {
+				%s(input: $input) { id name }
+			}
+		`, inputType, field), map[string]interface{}{"input": input}, &resp)
+		require.NoError(t, err)
+		assert.Equal(t, input["name"], resp[field].Name, "%s should echo the name unchanged", field)
+		return resp[field].ID
+	}
+
+	readName := func(t *testing.T, field, id string) string {
+		var resp map[string]*struct {
+			Name string `json:"name"`
+		}
+		err := client.Query(ctx, fmt.Sprintf(`
+			query Get($id: ID!) {
+				%s(id: $id) { name }
+			}
+		`, field), map[string]interface{}{"id": id}, &resp)
+		require.NoError(t, err)
+		require.NotNil(t, resp[field], "%s %s should exist", field, id)
+		return resp[field].Name
+	}
+
+	entities := []struct {
+		field  string
+		create func(t *testing.T, i int, name string) string
+	}{
+		{
+			field: "project",
+			create: func(t *testing.T, _ int, name string) string {
+				id := create(t, "createProject", "CreateProjectInput", map[string]interface{}{"name": name})
+				projectIDs = append(projectIDs, id)
+				return id
+			},
+		},
+		{
+			field: "look",
+			create: func(t *testing.T, _ int, name string) string {
+				return create(t, "createLook", "CreateLookInput", map[string]interface{}{
+					"projectId":     projectID,
+					"name":          name,
+					"fixtureValues": []map[string]interface{}{},
+				})
+			},
+		},
+		{
+			field: "fixtureInstance",
+			create: func(t *testing.T, i int, name string) string {
+				return create(t, "createFixtureInstance", "CreateFixtureInstanceInput", map[string]interface{}{
+					"projectId":    projectID,
+					"definitionId": definitionID,
+					"name":         name,
+					"universe":     1,
+					"startChannel": i + 1,
+				})
+			},
+		},
+		{
+			field: "effect",
+			create: func(t *testing.T, _ int, name string) string {
+				compat.Require(t, compat.Effects)
+				return create(t, "createEffect", "CreateEffectInput", map[string]interface{}{
+					"projectId":  projectID,
+					"name":       name,
+					"effectType": "WAVEFORM",
+					"waveform":   "SINE",
+					"frequency":  1.0,
+				})
+			},
+		},
+	}
+
+	lookIDs := make(map[string]string, len(nameCases))
+
+	for _, entity := range entities {
+		t.Run(entity.field, func(t *testing.T) {
+			for i, tc := range nameCases {
+				t.Run(tc.label, func(t *testing.T) {
+					id := entity.create(t, i, tc.name)
+					got := readName(t, entity.field, id)
+					assert.Equal(t, []byte(tc.name), []byte(got), "Name should round-trip byte-identical")
+					if entity.field == "look" {
+						lookIDs[tc.label] = id
+					}
+				})
+			}
+		})
+	}
+
+	t.Run("LookNameContains", func(t *testing.T) {
+		for _, tc := range nameCases {
+			t.Run(tc.label, func(t *testing.T) {
+				lookID, ok := lookIDs[tc.label]
+				if !ok {
+					t.Skip("Skipping: look was not created")
+				}
+
+				var resp struct {
+					Looks struct {
+						Looks []struct {
+							ID   string `json:"id"`
+							Name string `json:"name"`
+						} `json:"looks"`
+					} `json:"looks"`
+				}
+				err := client.Query(ctx, `
+					query ListLooks($projectId: ID!, $filter: LookFilterInput) {
+						looks(projectId: $projectId, filter: $filter) {
+							looks { id name }
+						}
+					}
+				`, map[string]interface{}{
+					"projectId": projectID,
+					"filter":    map[string]interface{}{"nameContains": tc.fragment},
+				}, &resp)
+				require.NoError(t, err)
+
+				var ids []string
+				for _, look := range resp.Looks.Looks {
+					ids = append(ids, look.ID)
+					assert.Contains(t, look.Name, tc.fragment, "Every match should contain the fragment")
+				}
+				assert.Contains(t, ids, lookID, "nameContains %q should find its look", tc.fragment)
+			})
+		}
+	})
+}
@@ -0,0 +1,291 @@
+package crud
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/fixtures"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// duplicateProjectContract is the deep project copy these tests expect.
+const duplicateProjectContract = `duplicateProject(id: ID!, name: String!): Project!
+  copies fixtures (same definitions and DMX patch), looks, cue lists with their cues and effects with new IDs;
+  references inside the copy point at the copy's entities; the copy starts with an empty undo history`
+
+// projectGraph is a project's contents with every internal reference
+// rendered by name, so two projects can be compared regardless of IDs.
+type projectGraph struct {
+	ids   map[string]string // "look Warm" -> ID
+	lines []string
+}
+
+// render names an entity of the graph, or marks an ID from outside the project.
+func (g *projectGraph) render(names map[string]string, id string) string {
+	if name, ok := names[id]; ok {
+		return name
+	}
+	return "foreign:" + id
+}
+
+func readProjectGraph(t *testing.T, client *graphql.Client, ctx context.Context, projectID string) *projectGraph {
+	var resp struct {
+		FixtureInstances struct {
+			Fixtures []struct {
+				ID           string `json:"id"`
+				Name         string `json:"name"`
+				DefinitionID string `json:"definitionId"`
+				Universe     int    `json:"universe"`
+				StartChannel int    `json:"startChannel"`
+			} `json:"fixtures"`
+		} `json:"fixtureInstances"`
+		Project struct {
+			Looks []struct {
+				ID            string `json:"id"`
+				Name          string `json:"name"`
+				FixtureValues []struct {
+					Fixture struct {
+						ID string `json:"id"`
+					} `json:"fixture"`
+					Channels []struct {
+						Offset int `json:"offset"`
+						Value  int `json:"value"`
+					} `json:"channels"`
+				} `json:"fixtureValues"`
+			} `json:"looks"`
+		} `json:"project"`
+		CueLists []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"cueLists"`
+		Effects []struct {
+			ID       string `json:"id"`
+			Name     string `json:"name"`
+			Fixtures []struct {
+				FixtureID string `json:"fixtureId"`
+				Channels  []struct {
+					ChannelOffset int `json:"channelOffset"`
+				} `json:"channels"`
+			} `json:"fixtures"`
+		} `json:"effects"`
+	}
+	err := client.Query(ctx, `
+		query ProjectGraph($projectId: ID!) {
+			fixtureInstances(projectId: $projectId) {
+				fixtures { id name definitionId universe startChannel }
+			}
+			project(id: $projectId) {
+				looks {
+					id
+					name
+					fixtureValues {
+						fixture { id }
+						channels { offset value }
+					}
+				}
+			}
+			cueLists(projectId: $projectId) { id name }
+			effects(projectId: $projectId) {
+				id
+				name
+				fixtures { fixtureId channels { channelOffset } }
+			}
+		}
+	`, map[string]interface{}{"projectId": projectID}, &resp)
+	require.NoError(t, err)
+
+	g := &projectGraph{ids: map[string]string{}}
+	names := map[string]string{}
+	add := func(kind, id, name string) {
+		key := kind + " " + name
+		require.NotContains(t, g.ids, key, "Names must be unique to compare projects")
+		g.ids[key] = id
+		names[id] = name
+	}
+	for _, f := range resp.FixtureInstances.Fixtures {
+		add("fixture", f.ID, f.Name)
+		g.lines = append(g.lines, fmt.Sprintf("fixture %s: definition %s at %d/%d", f.Name, f.DefinitionID, f.Universe, f.StartChannel))
+	}
+	for _, l := range resp.Project.Looks {
+		add("look", l.ID, l.Name)
+	}
+	for _, l := range resp.Project.Looks {
+		for _, fv := range l.FixtureValues {
+			for _, ch := range fv.Channels {
+				g.lines = append(g.lines, fmt.Sprintf("look %s: %s[%d]=%d", l.Name, g.render(names, fv.Fixture.ID), ch.Offset, ch.Value))
+			}
+		}
+	}
+	for _, cl := range resp.CueLists {
+		add("cueList", cl.ID, cl.Name)
+		var listResp struct {
+			CueList struct {
+				Cues []struct {
+					ID        string  `json:"id"`
+					Name      string  `json:"name"`
+					CueNumber float64 `json:"cueNumber"`
+					Look      struct {
+						ID string `json:"id"`
+					} `json:"look"`
+				} `json:"cues"`
+			} `json:"cueList"`
+		}
+		err := client.Query(ctx, `
+			query GetCueList($id: ID!) {
+				cueList(id: $id) { cues { id name cueNumber look { id } } }
+			}
+		`, map[string]interface{}{"id": cl.ID}, &listResp)
+		require.NoError(t, err)
+		for _, c := range listResp.CueList.Cues {
+			add("cue", c.ID, cl.Name+"/"+c.Name)
+			g.lines = append(g.lines, fmt.Sprintf("cue %s/%g %s: look %s", cl.Name, c.CueNumber, c.Name, g.render(names, c.Look.ID)))
+		}
+	}
+	for _, e := range resp.Effects {
+		add("effect", e.ID, e.Name)
+		for _, ef := range e.Fixtures {
+			for _, ch := range ef.Channels {
+				g.lines = append(g.lines, fmt.Sprintf("effect %s: %s[%d]", e.Name, g.render(names, ef.FixtureID), ch.ChannelOffset))
+			}
+		}
+	}
+	sort.Strings(g.lines)
+	return g
+}
+
+func operationCount(t *testing.T, client *graphql.Client, ctx context.Context, projectID string) int {
+	var resp struct {
+		OperationHistory struct {
+			Operations []struct {
+				ID string `json:"id"`
+			} `json:"operations"`
+		} `json:"operationHistory"`
+	}
+	err := client.Query(ctx, `
+		query GetOperationHistory($projectId: ID!) {
+			operationHistory(projectId: $projectId) { operations { id } }
+		}
+	`, map[string]interface{}{"projectId": projectID}, &resp)
+	require.NoError(t, err)
+	return len(resp.OperationHistory.Operations)
+}
+
+// TestDuplicateProject builds a project with every kind of nested entity,
+// duplicates it, and compares the two graphs with references rendered by
+// name: they must match line for line, share no IDs, and only the original
+// may carry undo history. Changes to the copy must not reach the original.
+func TestDuplicateProject(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewTestClient(t, "")
+	compat.RequireMutation(t, client, "duplicateProject", duplicateProjectContract)
+
+	definitionID, err := fixtures.CreateParDefinition(ctx, client, "Test Duplicate", fmt.Sprintf("Duplicate Par %d", time.Now().UnixNano()))
+	require.NoError(t, err)
+	projectID, err := fixtures.CreateProject(ctx, client, "Duplicate Source Project")
+	require.NoError(t, err)
+	var copyID string
+	defer func() {
+		_ = fixtures.DeleteProject(ctx, client, projectID)
+		if copyID != "" {
+			_ = fixtures.DeleteProject(ctx, client, copyID)
+		}
+		_ = fixtures.DeleteFixtureDefinition(ctx, client, definitionID)
+	}()
+
+	var fixtureIDs []string
+	for i, patch := range []fixtures.Fixture{
+		{Name: "Duplicate Par 1", Universe: 1, StartChannel: 1},
+		{Name: "Duplicate Par 2", Universe: 1, StartChannel: 37},
+		{Name: "Duplicate Par 3", Universe: 2, StartChannel: 501},
+	} {
+		id, err := fixtures.CreateFixture(ctx, client, projectID, definitionID, patch)
+		require.NoError(t, err, "fixture %d", i+1)
+		fixtureIDs = append(fixtureIDs, id)
+	}
+	warm, err := fixtures.CreateLook(ctx, client, projectID, "Warm", []fixtures.FixtureValues{
+		{FixtureID: fixtureIDs[0], Values: []int{255, 200, 80, 0}},
+		{FixtureID: fixtureIDs[2], Values: []int{128, 255, 0, 0}},
+	})
+	require.NoError(t, err)
+	cool, err := fixtures.CreateLook(ctx, client, projectID, "Cool", []fixtures.FixtureValues{
+		{FixtureID: fixtureIDs[1], Values: []int{200, 0, 60, 255}},
+	})
+	require.NoError(t, err)
+	_, err = fixtures.CreateCueList(ctx, client, projectID, "Main", []fixtures.Cue{
+		{Name: "Preset", LookID: warm, FadeTime: 2},
+		{Name: "Shift", LookID: cool, FadeTime: 3},
+		{Name: "Return", LookID: warm},
+	})
+	require.NoError(t, err)
+	_, err = fixtures.CreateWaveformEffect(ctx, client, projectID, "Pulse", "SINE", 1.0, fixtureIDs[1:])
+	require.NoError(t, err)
+
+	original := readProjectGraph(t, client, ctx, projectID)
+	require.NotZero(t, operationCount(t, client, ctx, projectID), "Building the source project should leave undo history")
+
+	var resp struct {
+		DuplicateProject struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"duplicateProject"`
+	}
+	err = client.Mutate(ctx, `
+		mutation DuplicateProject($id: ID!, $name: String!) {
+			duplicateProject(id: $id, name: $name) { id name }
+		}
+	`, map[string]interface{}{"id": projectID, "name": "Duplicate Copy Project"}, &resp)
+	require.NoError(t, err)
+	copyID = resp.DuplicateProject.ID
+	require.NotEmpty(t, copyID)
+	assert.NotEqual(t, projectID, copyID)
+	assert.Equal(t, "Duplicate Copy Project", resp.DuplicateProject.Name)
+
+	copied := readProjectGraph(t, client, ctx, copyID)
+
+	t.Run("SameContents", func(t *testing.T) {
+		// Any reference left pointing at the original renders as foreign:<id> and differs
+		assert.Equal(t, strings.Join(original.lines, "\n"), strings.Join(copied.lines, "\n"))
+	})
+
+	t.Run("NewIDs", func(t *testing.T) {
+		require.Len(t, copied.ids, len(original.ids))
+		originalIDs := map[string]bool{}
+		for _, id := range original.ids {
+			originalIDs[id] = true
+		}
+		for key, id := range copied.ids {
+			assert.False(t, originalIDs[id], "%s in the copy reuses an ID from the original", key)
+		}
+	})
+
+	t.Run("NoUndoHistory", func(t *testing.T) {
+		assert.Zero(t, operationCount(t, client, ctx, copyID), "The copy should start with an empty undo history")
+		var undoResp struct {
+			Undo struct {
+				Success bool `json:"success"`
+			} `json:"undo"`
+		}
+		err := client.Mutate(ctx, `mutation Undo($projectId: ID!) { undo(projectId: $projectId) { success } }`,
+			map[string]interface{}{"projectId": copyID}, &undoResp)
+		require.NoError(t, err)
+		assert.False(t, undoResp.Undo.Success, "There should be nothing to undo in the copy")
+		assert.Equal(t, copied.lines, readProjectGraph(t, client, ctx, copyID).lines)
+	})
+
+	t.Run("Independent", func(t *testing.T) {
+		require.NoError(t, client.Mutate(ctx, `mutation DeleteFixtureInstance($id: ID!) { deleteFixtureInstance(id: $id) }`,
+			map[string]interface{}{"id": copied.ids["fixture Duplicate Par 3"]}, nil))
+		assert.NotEqual(t, copied.lines, readProjectGraph(t, client, ctx, copyID).lines, "The copy should have changed")
+		assert.Equal(t, original.lines, readProjectGraph(t, client, ctx, projectID).lines,
+			"Changing the copy must not change the original")
+	})
+}
@@ -0,0 +1,169 @@
+package crud
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/fixtures"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// projectStatsContract is the summary dashboards show for a project.
+const projectStatsContract = `projectStats(projectId: ID!): ProjectStats!
+  ProjectStats { fixtureCount: Int!, lookCount: Int!, cueListCount: Int!, cueCount: Int!, effectCount: Int!,
+                 lastModified: String! (RFC 3339), dmxFootprint: [UniverseFootprint!]! (by universe) }
+  UniverseFootprint { universe: Int!, channelsUsed: Int!, highestChannel: Int! }
+  every mutation and every undo is reflected by the next read; lastModified moves forward with each change`
+
+type universeFootprint struct {
+	Universe       int `json:"universe"`
+	ChannelsUsed   int `json:"channelsUsed"`
+	HighestChannel int `json:"highestChannel"`
+}
+
+type projectStats struct {
+	FixtureCount int                 `json:"fixtureCount"`
+	LookCount    int                 `json:"lookCount"`
+	CueListCount int                 `json:"cueListCount"`
+	CueCount     int                 `json:"cueCount"`
+	EffectCount  int                 `json:"effectCount"`
+	LastModified string              `json:"lastModified"`
+	DMXFootprint []universeFootprint `json:"dmxFootprint"`
+}
+
+func getProjectStats(t *testing.T, client *graphql.Client, ctx context.Context, projectID string) projectStats {
+	// The selection is derived from projectStats, so the two cannot drift apart
+	var resp struct {
+		ProjectStats projectStats `json:"projectStats" graphql:"projectStats(projectId: $projectId)"`
+	}
+	err := client.QueryFor(ctx, "query ProjectStats($projectId: ID!)", map[string]interface{}{"projectId": projectID}, &resp)
+	require.NoError(t, err)
+	return resp.ProjectStats
+}
+
+// TestProjectStats builds a project one change at a time and checks every
+// statistic after each change and after undoing one, comparing against what
+// the test itself created.
+func TestProjectStats(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewTestClient(t, "")
+	compat.RequireQuery(t, client, "projectStats", projectStatsContract)
+
+	definitionID, err := fixtures.CreateParDefinition(ctx, client, "Test Stats", fmt.Sprintf("Stats Par %d", time.Now().UnixNano()))
+	require.NoError(t, err)
+	projectID, err := fixtures.CreateProject(ctx, client, "Project Stats Test Project")
+	require.NoError(t, err)
+	defer func() {
+		_ = fixtures.DeleteProject(ctx, client, projectID)
+		_ = fixtures.DeleteFixtureDefinition(ctx, client, definitionID)
+	}()
+
+	// want is updated alongside every change; lastModified is checked separately
+	want := projectStats{DMXFootprint: []universeFootprint{}}
+	var previous time.Time
+	check := func(t *testing.T, step string) {
+		t.Helper()
+		got := getProjectStats(t, client, ctx, projectID)
+		modified, err := time.Parse(time.RFC3339, got.LastModified)
+		require.NoError(t, err, "%s: lastModified should be RFC 3339, got %q", step, got.LastModified)
+		assert.False(t, modified.Before(previous), "%s: lastModified went backwards (%v -> %v)", step, previous, modified)
+		previous = modified
+
+		got.LastModified = ""
+		if got.DMXFootprint == nil {
+			got.DMXFootprint = []universeFootprint{}
+		}
+		assert.Equal(t, want, got, "%s: projectStats", step)
+	}
+
+	check(t, "Empty project")
+	created := previous
+
+	parID, err := fixtures.CreateFixture(ctx, client, projectID, definitionID, fixtures.Fixture{Name: "Stats Par 1", Universe: 1, StartChannel: 1})
+	require.NoError(t, err)
+	want.FixtureCount = 1
+	want.DMXFootprint = []universeFootprint{{Universe: 1, ChannelsUsed: 4, HighestChannel: 4}}
+	check(t, "First fixture")
+
+	_, err = fixtures.CreateFixture(ctx, client, projectID, definitionID, fixtures.Fixture{Name: "Stats Par 2", Universe: 1, StartChannel: 101})
+	require.NoError(t, err)
+	want.FixtureCount = 2
+	want.DMXFootprint = []universeFootprint{{Universe: 1, ChannelsUsed: 8, HighestChannel: 104}}
+	check(t, "Second fixture with a gap")
+
+	_, err = fixtures.CreateFixture(ctx, client, projectID, definitionID, fixtures.Fixture{Name: "Stats Par 3", Universe: 2, StartChannel: 509})
+	require.NoError(t, err)
+	want.FixtureCount = 3
+	want.DMXFootprint = append(want.DMXFootprint, universeFootprint{Universe: 2, ChannelsUsed: 4, HighestChannel: 512})
+	check(t, "Fixture at the end of universe 2")
+
+	var lookIDs []string
+	for i := 1; i <= 3; i++ {
+		lookID, err := fixtures.CreateLook(ctx, client, projectID, fmt.Sprintf("Stats Look %d", i),
+			[]fixtures.FixtureValues{{FixtureID: parID, Values: []int{i * 100, 0, 0, 0}}})
+		require.NoError(t, err)
+		lookIDs = append(lookIDs, lookID)
+	}
+	want.LookCount = 3
+	check(t, "Three looks")
+
+	_, err = fixtures.CreateCueList(ctx, client, projectID, "Stats Cue List", []fixtures.Cue{
+		{Name: "Stats Cue 1", LookID: lookIDs[0]},
+		{Name: "Stats Cue 2", LookID: lookIDs[1]},
+		{Name: "Stats Cue 3", LookID: lookIDs[0]},
+	})
+	require.NoError(t, err)
+	want.CueListCount = 1
+	want.CueCount = 3
+	check(t, "Cue list with three cues")
+
+	_, err = fixtures.CreateWaveformEffect(ctx, client, projectID, "Stats Effect", "SINE", 1.0, []string{parID})
+	require.NoError(t, err)
+	want.EffectCount = 1
+	check(t, "Effect")
+
+	// The third look is in no cue, so deleting it changes only the look count
+	require.NoError(t, client.Mutate(ctx, `mutation DeleteLook($id: ID!) { deleteLook(id: $id) }`,
+		map[string]interface{}{"id": lookIDs[2]}, nil))
+	want.LookCount = 2
+	check(t, "Look deleted")
+
+	t.Run("Undo", func(t *testing.T) {
+		undone := func() bool {
+			var resp struct {
+				Undo struct {
+					Success bool `json:"success"`
+				} `json:"undo"`
+			}
+			err := client.Mutate(ctx, `mutation Undo($projectId: ID!) { undo(projectId: $projectId) { success } }`,
+				map[string]interface{}{"projectId": projectID}, &resp)
+			require.NoError(t, err)
+			return resp.Undo.Success
+		}
+
+		// Undo the look deletion, then build a fixture to undo next
+		require.True(t, undone(), "Undo of the look deletion should succeed")
+		want.LookCount = 3
+		check(t, "Undo look deletion")
+
+		_, err := fixtures.CreateFixture(ctx, client, projectID, definitionID, fixtures.Fixture{Name: "Stats Par 4", Universe: 3, StartChannel: 1})
+		require.NoError(t, err)
+		want.FixtureCount = 4
+		want.DMXFootprint = append(want.DMXFootprint, universeFootprint{Universe: 3, ChannelsUsed: 4, HighestChannel: 4})
+		check(t, "Fixture on universe 3")
+
+		require.True(t, undone(), "Undo of the fixture creation should succeed")
+		want.FixtureCount = 3
+		want.DMXFootprint = want.DMXFootprint[:2]
+		check(t, "Undo fixture on universe 3")
+	})
+
+	assert.True(t, previous.After(created), "lastModified should have moved past the project's creation (%v)", created)
+}
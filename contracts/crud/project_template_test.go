@@ -0,0 +1,236 @@
+package crud
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// projectTemplateContract is the API shape the template tests expect.
+const projectTemplateContract = `projectTemplates: [ProjectTemplate!]!
+  ProjectTemplate { id, name, description, fixtureCount: Int!, lookCount: Int!, cueListCount: Int! }
+  createProjectFromTemplate(templateId: ID!, name: String!): Project!
+  Projects created from a template are independent copies`
+
+type projectTemplate struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	FixtureCount int    `json:"fixtureCount"`
+	LookCount    int    `json:"lookCount"`
+	CueListCount int    `json:"cueListCount"`
+}
+
+// templateSnapshot is the ID-free content of a project, comparable between projects.
+type templateSnapshot struct {
+	Fixtures []string
+	Looks    []string
+	CueLists []string
+}
+
+func listProjectTemplates(t *testing.T, client *graphql.Client, ctx context.Context) []projectTemplate {
+	var resp struct {
+		ProjectTemplates []projectTemplate `json:"projectTemplates"`
+	}
+	err := client.Query(ctx, `
+		query ListProjectTemplates {
+			projectTemplates {
+				id
+				name
+				fixtureCount
+				lookCount
+				cueListCount
+			}
+		}
+	`, nil, &resp)
+	require.NoError(t, err)
+	return resp.ProjectTemplates
+}
+
+func createProjectFromTemplate(t *testing.T, client *graphql.Client, ctx context.Context, templateID, name string) string {
+	var resp struct {
+		CreateProjectFromTemplate struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"createProjectFromTemplate"`
+	}
+	err := client.Mutate(ctx, `
+		mutation CreateProjectFromTemplate($templateId: ID!, $name: String!) {
+			createProjectFromTemplate(templateId: $templateId, name: $name) { id name }
+		}
+	`, map[string]interface{}{"templateId": templateID, "name": name}, &resp)
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.CreateProjectFromTemplate.ID)
+	assert.Equal(t, name, resp.CreateProjectFromTemplate.Name)
+	return resp.CreateProjectFromTemplate.ID
+}
+
+// snapshotProject reads a project's fixtures, looks and cue lists as sorted descriptions.
+func snapshotProject(t *testing.T, client *graphql.Client, ctx context.Context, projectID string) templateSnapshot {
+	var resp struct {
+		FixtureInstances struct {
+			Fixtures []struct {
+				Name         string `json:"name"`
+				Universe     int    `json:"universe"`
+				StartChannel int    `json:"startChannel"`
+			} `json:"fixtures"`
+		} `json:"fixtureInstances"`
+		Looks struct {
+			Looks []struct {
+				Name string `json:"name"`
+			} `json:"looks"`
+		} `json:"looks"`
+		CueLists []struct {
+			Name     string `json:"name"`
+			CueCount int    `json:"cueCount"`
+		} `json:"cueLists"`
+	}
+	err := client.Query(ctx, `
+		query SnapshotProject($projectId: ID!) {
+			fixtureInstances(projectId: $projectId) {
+				fixtures { name universe startChannel }
+			}
+			looks(projectId: $projectId) {
+				looks { name }
+			}
+			cueLists(projectId: $projectId) { name cueCount }
+		}
+	`, map[string]interface{}{"projectId": projectID}, &resp)
+	require.NoError(t, err)
+
+	var snap templateSnapshot
+	for _, f := range resp.FixtureInstances.Fixtures {
+		snap.Fixtures = append(snap.Fixtures, fmt.Sprintf("%s@%d/%d", f.Name, f.Universe, f.StartChannel))
+	}
+	for _, l := range resp.Looks.Looks {
+		snap.Looks = append(snap.Looks, l.Name)
+	}
+	for _, cl := range resp.CueLists {
+		snap.CueLists = append(snap.CueLists, fmt.Sprintf("%s (%d cues)", cl.Name, cl.CueCount))
+	}
+	sort.Strings(snap.Fixtures)
+	sort.Strings(snap.Looks)
+	sort.Strings(snap.CueLists)
+	return snap
+}
+
+// TestProjectFromTemplate creates projects from a starter template and checks
+// that the content is deterministic and matches the template's advertised
+// counts, and that editing a created project changes neither the template nor
+// projects created from it later.
+func TestProjectFromTemplate(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewTestClient(t, "")
+	compat.RequireMutation(t, client, "createProjectFromTemplate", projectTemplateContract)
+
+	templates := listProjectTemplates(t, client, ctx)
+	require.NotEmpty(t, templates, "Server should ship at least one project template")
+
+	// Prefer the basic theatre rig when it exists
+	template := templates[0]
+	for _, tmpl := range templates {
+		if tmpl.Name == "Basic Theatre Rig" {
+			template = tmpl
+		}
+	}
+	t.Logf("Using template %q (%d fixtures, %d looks, %d cue lists)",
+		template.Name, template.FixtureCount, template.LookCount, template.CueListCount)
+
+	var projectIDs []string
+	defer func() {
+		for _, id := range projectIDs {
+			_ = client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
+				map[string]interface{}{"id": id}, nil)
+		}
+	}()
+
+	firstID := createProjectFromTemplate(t, client, ctx, template.ID, "Template Project A")
+	projectIDs = append(projectIDs, firstID)
+	first := snapshotProject(t, client, ctx, firstID)
+
+	t.Run("MatchesAdvertisedCounts", func(t *testing.T) {
+		assert.Len(t, first.Fixtures, template.FixtureCount)
+		assert.Len(t, first.Looks, template.LookCount)
+		assert.Len(t, first.CueLists, template.CueListCount)
+		assert.NotEmpty(t, first.Fixtures, "A starter template should include a rig")
+	})
+
+	t.Run("Deterministic", func(t *testing.T) {
+		secondID := createProjectFromTemplate(t, client, ctx, template.ID, "Template Project B")
+		projectIDs = append(projectIDs, secondID)
+		assert.Equal(t, first, snapshotProject(t, client, ctx, secondID),
+			"Two projects from the same template should have identical content")
+	})
+
+	t.Run("EditsDoNotMutateTemplate", func(t *testing.T) {
+		// Add a fixture and rename the project's looks
+		var defResp struct {
+			FixtureDefinitions []struct {
+				ID string `json:"id"`
+			} `json:"fixtureDefinitions"`
+		}
+		err := client.Query(ctx, `query { fixtureDefinitions { id } }`, nil, &defResp)
+		require.NoError(t, err)
+		require.NotEmpty(t, defResp.FixtureDefinitions)
+
+		err = client.Mutate(ctx, `
+			mutation CreateFixtureInstance($input: CreateFixtureInstanceInput!) {
+				createFixtureInstance(input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"projectId":    firstID,
+				"definitionId": defResp.FixtureDefinitions[0].ID,
+				"name":         "Added After Template",
+				"universe":     4,
+				"startChannel": 1,
+			},
+		}, nil)
+		require.NoError(t, err)
+
+		var looksResp struct {
+			Looks struct {
+				Looks []struct {
+					ID string `json:"id"`
+				} `json:"looks"`
+			} `json:"looks"`
+		}
+		err = client.Query(ctx, `
+			query ListLooks($projectId: ID!) {
+				looks(projectId: $projectId) { looks { id } }
+			}
+		`, map[string]interface{}{"projectId": firstID}, &looksResp)
+		require.NoError(t, err)
+		for _, look := range looksResp.Looks.Looks {
+			err := client.Mutate(ctx, `
+				mutation UpdateLook($id: ID!, $input: UpdateLookInput!) {
+					updateLook(id: $id, input: $input) { id }
+				}
+			`, map[string]interface{}{
+				"id":    look.ID,
+				"input": map[string]interface{}{"name": "Edited " + look.ID},
+			}, nil)
+			require.NoError(t, err)
+		}
+		assert.NotEqual(t, first, snapshotProject(t, client, ctx, firstID), "The edits should have changed the project")
+
+		for _, tmpl := range listProjectTemplates(t, client, ctx) {
+			if tmpl.ID == template.ID {
+				assert.Equal(t, template, tmpl, "Editing a project must not change its template")
+			}
+		}
+
+		freshID := createProjectFromTemplate(t, client, ctx, template.ID, "Template Project C")
+		projectIDs = append(projectIDs, freshID)
+		assert.Equal(t, first, snapshotProject(t, client, ctx, freshID),
+			"A project created after the edits should still match the original template")
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/bbernstein/lacylights-test/pkg/lacyapi"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -17,122 +18,57 @@ func TestProjectCRUD(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	client := graphql.NewClient("")
+	client := graphql.NewTestClient(t, "")
+	api := lacyapi.New(client)
 
 	// CREATE
 	t.Run("CreateProject", func(t *testing.T) {
-		var createResp struct {
-			CreateProject struct {
-				ID          string  `json:"id"`
-				Name        string  `json:"name"`
-				Description *string `json:"description"`
-			} `json:"createProject"`
-		}
-
-		err := client.Mutate(ctx, `
-			mutation CreateProject($input: CreateProjectInput!) {
-				createProject(input: $input) {
-					id
-					name
-					description
-				}
-			}
-		`, map[string]interface{}{
-			"input": map[string]interface{}{
-				"name":        "CRUD Test Project",
-				"description": "Created by CRUD tests",
-			},
-		}, &createResp)
+		project, err := api.CreateProject(ctx, lacyapi.CreateProjectInput{
+			Name:        "CRUD Test Project",
+			Description: lacyapi.Ptr("Created by CRUD tests"),
+		})
 
 		require.NoError(t, err)
-		assert.NotEmpty(t, createResp.CreateProject.ID)
-		assert.Equal(t, "CRUD Test Project", createResp.CreateProject.Name)
-		assert.NotNil(t, createResp.CreateProject.Description)
-		assert.Equal(t, "Created by CRUD tests", *createResp.CreateProject.Description)
+		assert.NotEmpty(t, project.ID)
+		assert.Equal(t, "CRUD Test Project", project.Name)
+		assert.NotNil(t, project.Description)
+		assert.Equal(t, "Created by CRUD tests", *project.Description)
 
-		projectID := createResp.CreateProject.ID
+		projectID := project.ID
 
 		// READ
 		t.Run("ReadProject", func(t *testing.T) {
-			var readResp struct {
-				Project struct {
-					ID          string  `json:"id"`
-					Name        string  `json:"name"`
-					Description *string `json:"description"`
-				} `json:"project"`
-			}
-
-			err := client.Query(ctx, `
-				query GetProject($id: ID!) {
-					project(id: $id) {
-						id
-						name
-						description
-					}
-				}
-			`, map[string]interface{}{"id": projectID}, &readResp)
+			project, err := api.Project(ctx, projectID)
 
 			require.NoError(t, err)
-			assert.Equal(t, projectID, readResp.Project.ID)
-			assert.Equal(t, "CRUD Test Project", readResp.Project.Name)
+			require.NotNil(t, project)
+			assert.Equal(t, projectID, project.ID)
+			assert.Equal(t, "CRUD Test Project", project.Name)
 		})
 
 		// UPDATE
 		t.Run("UpdateProject", func(t *testing.T) {
-			var updateResp struct {
-				UpdateProject struct {
-					ID          string  `json:"id"`
-					Name        string  `json:"name"`
-					Description *string `json:"description"`
-				} `json:"updateProject"`
-			}
-
-			err := client.Mutate(ctx, `
-				mutation UpdateProject($id: ID!, $input: CreateProjectInput!) {
-					updateProject(id: $id, input: $input) {
-						id
-						name
-						description
-					}
-				}
-			`, map[string]interface{}{
-				"id": projectID,
-				"input": map[string]interface{}{
-					"name":        "Updated Project Name",
-					"description": "Updated description",
-				},
-			}, &updateResp)
+			project, err := api.UpdateProject(ctx, projectID, lacyapi.CreateProjectInput{
+				Name:        "Updated Project Name",
+				Description: lacyapi.Ptr("Updated description"),
+			})
 
 			require.NoError(t, err)
-			assert.Equal(t, "Updated Project Name", updateResp.UpdateProject.Name)
-			assert.NotNil(t, updateResp.UpdateProject.Description)
-			assert.Equal(t, "Updated description", *updateResp.UpdateProject.Description)
+			assert.Equal(t, "Updated Project Name", project.Name)
+			assert.NotNil(t, project.Description)
+			assert.Equal(t, "Updated description", *project.Description)
 		})
 
 		// LIST
 		t.Run("ListProjects", func(t *testing.T) {
-			var listResp struct {
-				Projects []struct {
-					ID   string `json:"id"`
-					Name string `json:"name"`
-				} `json:"projects"`
-			}
-
-			err := client.Query(ctx, `
-				query {
-					projects {
-						id
-						name
-					}
-				}
-			`, nil, &listResp)
+			projects, err := api.Projects(ctx)
 
 			require.NoError(t, err)
-			assert.NotEmpty(t, listResp.Projects)
+			assert.NotEmpty(t, projects)
 
 			// Find our project in the list
 			found := false
-			for _, p := range listResp.Projects {
+			for _, p := range projects {
 				if p.ID == projectID {
 					found = true
 					assert.Equal(t, "Updated Project Name", p.Name)
@@ -144,37 +80,17 @@ func TestProjectCRUD(t *testing.T) {
 
 		// DELETE
 		t.Run("DeleteProject", func(t *testing.T) {
-			var deleteResp struct {
-				DeleteProject bool `json:"deleteProject"`
-			}
-
-			err := client.Mutate(ctx, `
-				mutation DeleteProject($id: ID!) {
-					deleteProject(id: $id)
-				}
-			`, map[string]interface{}{"id": projectID}, &deleteResp)
+			deleted, err := api.DeleteProject(ctx, projectID)
 
 			require.NoError(t, err)
-			assert.True(t, deleteResp.DeleteProject)
+			assert.True(t, deleted)
 
 			// Verify deletion - project should not be found
-			var verifyResp struct {
-				Project *struct {
-					ID string `json:"id"`
-				} `json:"project"`
-			}
-
-			err = client.Query(ctx, `
-				query GetProject($id: ID!) {
-					project(id: $id) {
-						id
-					}
-				}
-			`, map[string]interface{}{"id": projectID}, &verifyResp)
+			project, err := api.Project(ctx, projectID)
 
 			// Should either return null or error
 			if err == nil {
-				assert.Nil(t, verifyResp.Project, "Deleted project should not be found")
+				assert.Nil(t, project, "Deleted project should not be found")
 			}
 		})
 	})
@@ -185,99 +101,49 @@ func TestProjectWithRelations(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	client := graphql.NewClient("")
+	client := graphql.NewTestClient(t, "")
+	api := lacyapi.New(client)
 
 	// Create project
-	var createResp struct {
-		CreateProject struct {
-			ID string `json:"id"`
-		} `json:"createProject"`
-	}
-
-	err := client.Mutate(ctx, `
-		mutation CreateProject($input: CreateProjectInput!) {
-			createProject(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{"name": "Relations Test Project"},
-	}, &createResp)
-
+	project, err := api.CreateProject(ctx, lacyapi.CreateProjectInput{Name: "Relations Test Project"})
 	require.NoError(t, err)
-	projectID := createResp.CreateProject.ID
+	projectID := project.ID
 	defer func() {
-		_ = client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
-			map[string]interface{}{"id": projectID}, nil)
+		_, _ = api.DeleteProject(ctx, projectID)
 	}()
 
 	// Create a fixture definition for testing (don't rely on built-in fixtures)
-	var defResp struct {
-		CreateFixtureDefinition struct {
-			ID string `json:"id"`
-		} `json:"createFixtureDefinition"`
-	}
-
-	err = client.Mutate(ctx, `
-		mutation CreateFixtureDefinition($input: CreateFixtureDefinitionInput!) {
-			createFixtureDefinition(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"manufacturer": "Test Relations",
-			"model":        fmt.Sprintf("Fixture %d", time.Now().UnixNano()),
-			"type":         "LED_PAR",
-			"channels": []map[string]interface{}{
-				{"name": "Dimmer", "type": "INTENSITY", "offset": 0, "minValue": 0, "maxValue": 255, "defaultValue": 0},
-			},
+	definition, err := api.CreateFixtureDefinition(ctx, lacyapi.CreateFixtureDefinitionInput{
+		Manufacturer: "Test Relations",
+		Model:        fmt.Sprintf("Fixture %d", time.Now().UnixNano()),
+		Type:         lacyapi.FixtureTypeLedPar,
+		Channels: []lacyapi.CreateChannelDefinitionInput{
+			{Name: "Dimmer", Type: lacyapi.ChannelTypeIntensity, Offset: 0, MinValue: 0, MaxValue: 255, DefaultValue: 0},
 		},
-	}, &defResp)
+	})
 	require.NoError(t, err)
-	definitionID := defResp.CreateFixtureDefinition.ID
+	definitionID := definition.ID
 	defer func() {
-		_ = client.Mutate(ctx, `mutation DeleteFixtureDefinition($id: ID!) { deleteFixtureDefinition(id: $id) }`,
-			map[string]interface{}{"id": definitionID}, nil)
+		_, _ = api.DeleteFixtureDefinition(ctx, definitionID)
 	}()
 
 	// Create fixture instance
-	var fixtureResp struct {
-		CreateFixtureInstance struct {
-			ID string `json:"id"`
-		} `json:"createFixtureInstance"`
-	}
-
-	err = client.Mutate(ctx, `
-		mutation CreateFixtureInstance($input: CreateFixtureInstanceInput!) {
-			createFixtureInstance(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"projectId":    projectID,
-			"definitionId": definitionID,
-			"name":         "Test Fixture",
-			"universe":     1,
-			"startChannel": 1,
-		},
-	}, &fixtureResp)
+	_, err = api.CreateFixtureInstance(ctx, lacyapi.CreateFixtureInstanceInput{
+		ProjectID:    projectID,
+		DefinitionID: definitionID,
+		Name:         "Test Fixture",
+		Universe:     1,
+		StartChannel: 1,
+	})
 
 	require.NoError(t, err)
 
 	// Create look
-	var lookResp struct {
-		CreateLook struct {
-			ID string `json:"id"`
-		} `json:"createLook"`
-	}
-
-	err = client.Mutate(ctx, `
-		mutation CreateLook($input: CreateLookInput!) {
-			createLook(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"projectId":     projectID,
-			"name":          "Test Look",
-			"fixtureValues": []map[string]interface{}{},
-		},
-	}, &lookResp)
+	_, err = api.CreateLook(ctx, lacyapi.CreateLookInput{
+		ProjectID:     projectID,
+		Name:          "Test Look",
+		FixtureValues: []lacyapi.FixtureValueInput{},
+	})
 
 	require.NoError(t, err)
 
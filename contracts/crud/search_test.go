@@ -0,0 +1,163 @@
+package crud
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/fixtures"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// globalSearchContract is the project-wide search these tests expect.
+const globalSearchContract = `search(projectId: ID!, text: String!): [SearchResult!]!
+  SearchResult { type: SearchResultType!, id: ID!, name: String! }
+  enum SearchResultType { FIXTURE LOOK CUE EFFECT }
+  case-insensitive substring match on names, with Unicode case folding; accents are significant;
+  ranked exact name first, then names starting with the text, then other matches, by name within each;
+  only the given project is searched`
+
+type searchHit struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// key identifies a hit by what the test created, since cue IDs are not returned by the fixtures helpers.
+func (h searchHit) key() string {
+	return h.Type + " " + h.Name
+}
+
+func globalSearch(t *testing.T, client *graphql.Client, ctx context.Context, projectID, text string) []searchHit {
+	var resp struct {
+		Search []searchHit `json:"search"`
+	}
+	err := client.Query(ctx, `
+		query Search($projectId: ID!, $text: String!) {
+			search(projectId: $projectId, text: $text) { type id name }
+		}
+	`, map[string]interface{}{"projectId": projectID, "text": text}, &resp)
+	require.NoError(t, err)
+	return resp.Search
+}
+
+func hitKeys(hits []searchHit) []string {
+	keys := make([]string, len(hits))
+	for i, h := range hits {
+		keys[i] = h.key()
+	}
+	return keys
+}
+
+// TestGlobalSearch creates fixtures, looks, cues and an effect with
+// overlapping names and checks what search returns for each query: the
+// right entities with the right type, ranked, across Unicode names, and
+// nothing from another project.
+func TestGlobalSearch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewTestClient(t, "")
+	compat.RequireQuery(t, client, "search", globalSearchContract)
+
+	definitionID, err := fixtures.CreateParDefinition(ctx, client, "Test Search", fmt.Sprintf("Search Par %d", time.Now().UnixNano()))
+	require.NoError(t, err)
+	projectID, err := fixtures.CreateProject(ctx, client, "Global Search Test Project")
+	require.NoError(t, err)
+	otherID, err := fixtures.CreateProject(ctx, client, "Global Search Other Project")
+	require.NoError(t, err)
+	defer func() {
+		_ = fixtures.DeleteProject(ctx, client, projectID)
+		_ = fixtures.DeleteProject(ctx, client, otherID)
+		_ = fixtures.DeleteFixtureDefinition(ctx, client, definitionID)
+	}()
+
+	// ids maps each created entity's key to its ID
+	ids := map[string]string{}
+	var firstFixture string
+	for i, name := range []string{"Stage Left Wash", "Stage Right Wash", "Beam Spot", "Ünïcode Spot"} {
+		id, err := fixtures.CreateFixture(ctx, client, projectID, definitionID, fixtures.Fixture{Name: name, Universe: 1, StartChannel: 1 + 4*i})
+		require.NoError(t, err)
+		ids["FIXTURE "+name] = id
+		if firstFixture == "" {
+			firstFixture = id
+		}
+	}
+	values := []fixtures.FixtureValues{{FixtureID: firstFixture, Values: []int{255}}}
+	for _, name := range []string{"Wash", "Wash Warm", "Sunset Wash", "Café Glow"} {
+		id, err := fixtures.CreateLook(ctx, client, projectID, name, values)
+		require.NoError(t, err)
+		ids["LOOK "+name] = id
+	}
+	_, err = fixtures.CreateCueList(ctx, client, projectID, "Search Cues", []fixtures.Cue{
+		{Name: "Washout", LookID: ids["LOOK Wash"]},
+		{Name: "Opening", LookID: ids["LOOK Sunset Wash"]},
+		{Name: "舞台 Cue", LookID: ids["LOOK Café Glow"]},
+	})
+	require.NoError(t, err)
+	effectID, err := fixtures.CreateWaveformEffect(ctx, client, projectID, "Wash Pulse", "SINE", 1.0, []string{firstFixture})
+	require.NoError(t, err)
+	ids["EFFECT Wash Pulse"] = effectID
+
+	otherFixture, err := fixtures.CreateFixture(ctx, client, otherID, definitionID, fixtures.Fixture{Name: "Other Wash", Universe: 1, StartChannel: 1})
+	require.NoError(t, err)
+	_, err = fixtures.CreateLook(ctx, client, otherID, "Wash",
+		[]fixtures.FixtureValues{{FixtureID: otherFixture, Values: []int{255}}})
+	require.NoError(t, err)
+
+	t.Run("TypeDiscriminators", func(t *testing.T) {
+		hits := globalSearch(t, client, ctx, projectID, "wash")
+		assert.ElementsMatch(t, []string{
+			"FIXTURE Stage Left Wash", "FIXTURE Stage Right Wash",
+			"LOOK Wash", "LOOK Wash Warm", "LOOK Sunset Wash",
+			"CUE Washout", "EFFECT Wash Pulse",
+		}, hitKeys(hits), "Every entity named with wash, and only this project's")
+		for _, h := range hits {
+			assert.NotEmpty(t, h.ID, "%s should have an ID", h.key())
+			if id, ok := ids[h.key()]; ok {
+				assert.Equal(t, id, h.ID, "%s should carry its entity's ID", h.key())
+			}
+		}
+	})
+
+	t.Run("Ranking", func(t *testing.T) {
+		hits := hitKeys(globalSearch(t, client, ctx, projectID, "wash"))
+		require.Len(t, hits, 7)
+		assert.Equal(t, "LOOK Wash", hits[0], "The exact name ranks first")
+		assert.Equal(t, []string{"EFFECT Wash Pulse", "LOOK Wash Warm", "CUE Washout"}, hits[1:4],
+			"Names starting with the text rank next, by name")
+		assert.Equal(t, []string{"FIXTURE Stage Left Wash", "FIXTURE Stage Right Wash", "LOOK Sunset Wash"}, hits[4:],
+			"Other matches rank last, by name")
+	})
+
+	t.Run("CaseAndSubstring", func(t *testing.T) {
+		assert.Equal(t, hitKeys(globalSearch(t, client, ctx, projectID, "wash")),
+			hitKeys(globalSearch(t, client, ctx, projectID, "WaSH")), "Search should ignore case")
+		assert.Equal(t, []string{"LOOK Sunset Wash"}, hitKeys(globalSearch(t, client, ctx, projectID, "unse")),
+			"Text in the middle of a name should match")
+		assert.Equal(t, []string{"FIXTURE Stage Right Wash"}, hitKeys(globalSearch(t, client, ctx, projectID, "right w")),
+			"Text spanning words should match")
+	})
+
+	t.Run("Unicode", func(t *testing.T) {
+		assert.Equal(t, []string{"LOOK Café Glow"}, hitKeys(globalSearch(t, client, ctx, projectID, "café")))
+		assert.Equal(t, []string{"LOOK Café Glow"}, hitKeys(globalSearch(t, client, ctx, projectID, "CAFÉ")),
+			"Non-ASCII letters should fold case too")
+		assert.Equal(t, []string{"FIXTURE Ünïcode Spot"}, hitKeys(globalSearch(t, client, ctx, projectID, "üNÏ")))
+		assert.Equal(t, []string{"CUE 舞台 Cue"}, hitKeys(globalSearch(t, client, ctx, projectID, "舞台")))
+		assert.Empty(t, globalSearch(t, client, ctx, projectID, "cafe"), "Accents are significant")
+	})
+
+	t.Run("NoMatch", func(t *testing.T) {
+		assert.Empty(t, globalSearch(t, client, ctx, projectID, "zzz"))
+	})
+
+	t.Run("OtherProject", func(t *testing.T) {
+		assert.Equal(t, []string{"LOOK Wash", "FIXTURE Other Wash"}, hitKeys(globalSearch(t, client, ctx, otherID, "wash")),
+			"The other project sees only its own entities")
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/bbernstein/lacylights-test/pkg/lacyapi"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -18,28 +19,15 @@ func TestSparseChannelsCRUD(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	client := graphql.NewClient("")
+	client := graphql.NewTestClient(t, "")
+	api := lacyapi.New(client)
 
 	// Create project
-	var projectResp struct {
-		CreateProject struct {
-			ID string `json:"id"`
-		} `json:"createProject"`
-	}
-
-	err := client.Mutate(ctx, `
-		mutation CreateProject($input: CreateProjectInput!) {
-			createProject(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{"name": "Sparse Channels CRUD Test Project"},
-	}, &projectResp)
-
+	project, err := api.CreateProject(ctx, lacyapi.CreateProjectInput{Name: "Sparse Channels CRUD Test Project"})
 	require.NoError(t, err)
-	projectID := projectResp.CreateProject.ID
+	projectID := project.ID
 	defer func() {
-		_ = client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
-			map[string]interface{}{"id": projectID}, nil)
+		_, _ = api.DeleteProject(ctx, projectID)
 	}()
 
 	// Create fixtures for testing
@@ -268,28 +256,15 @@ func TestSparseChannelsAddFixtures(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	client := graphql.NewClient("")
+	client := graphql.NewTestClient(t, "")
+	api := lacyapi.New(client)
 
 	// Create project
-	var projectResp struct {
-		CreateProject struct {
-			ID string `json:"id"`
-		} `json:"createProject"`
-	}
-
-	err := client.Mutate(ctx, `
-		mutation CreateProject($input: CreateProjectInput!) {
-			createProject(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{"name": "Sparse Add Fixtures Test"},
-	}, &projectResp)
-
+	project, err := api.CreateProject(ctx, lacyapi.CreateProjectInput{Name: "Sparse Add Fixtures Test"})
 	require.NoError(t, err)
-	projectID := projectResp.CreateProject.ID
+	projectID := project.ID
 	defer func() {
-		_ = client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
-			map[string]interface{}{"id": projectID}, nil)
+		_, _ = api.DeleteProject(ctx, projectID)
 	}()
 
 	// Create fixtures
@@ -298,33 +273,21 @@ func TestSparseChannelsAddFixtures(t *testing.T) {
 	fixture3ID := createTestFixture(t, client, ctx, projectID, "Add Fixture 3", 20)
 
 	// Create look with one fixture using sparse channels
-	var lookResp struct {
-		CreateLook struct {
-			ID string `json:"id"`
-		} `json:"createLook"`
-	}
-
-	err = client.Mutate(ctx, `
-		mutation CreateLook($input: CreateLookInput!) {
-			createLook(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"projectId": projectID,
-			"name":      "Sparse Add Fixtures Look",
-			"fixtureValues": []map[string]interface{}{
-				{
-					"fixtureId": fixture1ID,
-					"channels": []map[string]interface{}{
-						{"offset": 0, "value": 100},
-					},
+	look, err := api.CreateLook(ctx, lacyapi.CreateLookInput{
+		ProjectID: projectID,
+		Name:      "Sparse Add Fixtures Look",
+		FixtureValues: []lacyapi.FixtureValueInput{
+			{
+				FixtureID: fixture1ID,
+				Channels: []lacyapi.ChannelValueInput{
+					{Offset: 0, Value: 100},
 				},
 			},
 		},
-	}, &lookResp)
+	})
 
 	require.NoError(t, err)
-	lookID := lookResp.CreateLook.ID
+	lookID := look.ID
 
 	// ADD FIXTURES with sparse channels
 	t.Run("AddFixturesToLookWithSparseChannels", func(t *testing.T) {
@@ -397,28 +360,15 @@ func TestSparseChannelsPartialUpdate(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	client := graphql.NewClient("")
+	client := graphql.NewTestClient(t, "")
+	api := lacyapi.New(client)
 
 	// Create project
-	var projectResp struct {
-		CreateProject struct {
-			ID string `json:"id"`
-		} `json:"createProject"`
-	}
-
-	err := client.Mutate(ctx, `
-		mutation CreateProject($input: CreateProjectInput!) {
-			createProject(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{"name": "Sparse Partial Update Test"},
-	}, &projectResp)
-
+	project, err := api.CreateProject(ctx, lacyapi.CreateProjectInput{Name: "Sparse Partial Update Test"})
 	require.NoError(t, err)
-	projectID := projectResp.CreateProject.ID
+	projectID := project.ID
 	defer func() {
-		_ = client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
-			map[string]interface{}{"id": projectID}, nil)
+		_, _ = api.DeleteProject(ctx, projectID)
 	}()
 
 	// Create fixtures
@@ -426,34 +376,22 @@ func TestSparseChannelsPartialUpdate(t *testing.T) {
 	fixture2ID := createTestFixture(t, client, ctx, projectID, "Partial Update Fixture 2", 10)
 
 	// Create look with multiple sparse channels
-	var lookResp struct {
-		CreateLook struct {
-			ID string `json:"id"`
-		} `json:"createLook"`
-	}
-
-	err = client.Mutate(ctx, `
-		mutation CreateLook($input: CreateLookInput!) {
-			createLook(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"projectId": projectID,
-			"name":      "Original Sparse Look",
-			"fixtureValues": []map[string]interface{}{
-				{
-					"fixtureId": fixture1ID,
-					"channels": []map[string]interface{}{
-						{"offset": 0, "value": 100},
-						{"offset": 1, "value": 150},
-					},
+	look, err := api.CreateLook(ctx, lacyapi.CreateLookInput{
+		ProjectID: projectID,
+		Name:      "Original Sparse Look",
+		FixtureValues: []lacyapi.FixtureValueInput{
+			{
+				FixtureID: fixture1ID,
+				Channels: []lacyapi.ChannelValueInput{
+					{Offset: 0, Value: 100},
+					{Offset: 1, Value: 150},
 				},
 			},
 		},
-	}, &lookResp)
+	})
 
 	require.NoError(t, err)
-	lookID := lookResp.CreateLook.ID
+	lookID := look.ID
 
 	// Partial update: merge new fixture without replacing existing
 	t.Run("MergeFixturesWithSparseChannels", func(t *testing.T) {
@@ -525,28 +463,15 @@ func TestSparseChannelsLookOrder(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	client := graphql.NewClient("")
+	client := graphql.NewTestClient(t, "")
+	api := lacyapi.New(client)
 
 	// Create project
-	var projectResp struct {
-		CreateProject struct {
-			ID string `json:"id"`
-		} `json:"createProject"`
-	}
-
-	err := client.Mutate(ctx, `
-		mutation CreateProject($input: CreateProjectInput!) {
-			createProject(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{"name": "Sparse Look Order Test"},
-	}, &projectResp)
-
+	project, err := api.CreateProject(ctx, lacyapi.CreateProjectInput{Name: "Sparse Look Order Test"})
 	require.NoError(t, err)
-	projectID := projectResp.CreateProject.ID
+	projectID := project.ID
 	defer func() {
-		_ = client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
-			map[string]interface{}{"id": projectID}, nil)
+		_, _ = api.DeleteProject(ctx, projectID)
 	}()
 
 	// Create fixtures
@@ -0,0 +1,116 @@
+package dmx
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// syncChannel is faded on every universe in syncUniverses, clear of the
+	// other DMX tests' channels
+	syncChannel = 235
+
+	// syncUniverses are the lacylights universes faded together
+	syncUniverses = 2
+
+	syncFade = 2.0
+)
+
+// TestMultiUniverseFadeStepsSynchronized fades the same channel on two
+// universes and checks each fade step reaches both universes together. With
+// ArtSync, every step must land in one sync window on both universes; without
+// it, the packets for a step must go out back to back, well within one frame
+// period, so nodes latch them on the same refresh.
+func TestMultiUniverseFadeStepsSynchronized(t *testing.T) {
+	skipDMXTests(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	receiver := artnet.Capture(t)
+
+	client := graphql.NewClient("")
+	defer func() {
+		_ = client.Mutate(context.Background(), `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+	}()
+
+	for universe := 1; universe <= syncUniverses; universe++ {
+		err := client.Mutate(ctx, `
+			mutation SetChannel($universe: Int!, $channel: Int!) {
+				setChannelValue(universe: $universe, channel: $channel, value: 255)
+			}
+		`, map[string]interface{}{"universe": universe, "channel": syncChannel}, nil)
+		require.NoError(t, err)
+	}
+	time.Sleep(300 * time.Millisecond)
+
+	receiver.ClearFrames()
+	err := client.Mutate(ctx, `
+		mutation FadeToBlack($fadeOutTime: Float!) {
+			fadeToBlack(fadeOutTime: $fadeOutTime)
+		}
+	`, map[string]interface{}{"fadeOutTime": syncFade}, nil)
+	require.NoError(t, err)
+	time.Sleep(time.Duration((syncFade + 0.5) * float64(time.Second)))
+
+	frames := receiver.GetFrames()
+	if len(frames) == 0 {
+		t.Skip("No Art-Net frames captured - Art-Net may not be enabled")
+	}
+
+	// steps maps each mid-fade level to the frames that carried it, per universe
+	steps := make(map[byte]map[int][]artnet.Frame)
+	for _, f := range frames {
+		if f.Universe >= syncUniverses {
+			continue
+		}
+		v := f.Channels[syncChannel-1]
+		if v == 0 || v == 255 {
+			continue
+		}
+		if steps[v] == nil {
+			steps[v] = make(map[int][]artnet.Frame)
+		}
+		steps[v][f.Universe] = append(steps[v][f.Universe], f)
+	}
+	require.NotEmpty(t, steps, "The fade should produce mid-fade frames")
+
+	syncs := receiver.GetSyncs()
+	period := framePeriod(frames)
+	t.Logf("Captured %d frames, %d ArtSync packets, %d mid-fade steps (frame period %v)",
+		len(frames), len(syncs), len(steps), period)
+
+	var problems []string
+	for level, byUniverse := range steps {
+		if len(byUniverse) < syncUniverses {
+			problems = append(problems, fmt.Sprintf("level %d only reached %d of %d universes", level, len(byUniverse), syncUniverses))
+			continue
+		}
+		first, second := byUniverse[0][0], byUniverse[1][0]
+
+		if len(syncs) > 0 {
+			if first.SyncWindow != second.SyncWindow {
+				problems = append(problems, fmt.Sprintf("level %d: sync window %d on universe 1, %d on universe 2",
+					level, first.SyncWindow, second.SyncWindow))
+			}
+			continue
+		}
+
+		skew := first.Timestamp.Sub(second.Timestamp).Abs()
+		if period > 0 && skew > period/2 {
+			problems = append(problems, fmt.Sprintf("level %d: universes sent %v apart", level, skew))
+		}
+	}
+
+	if len(syncs) == 0 {
+		t.Log("Contract: server does not send ArtSync; checked that each step goes out on both universes within half a frame period")
+	}
+	assert.Empty(t, problems, "Each fade step should reach every universe in the same sync window")
+}
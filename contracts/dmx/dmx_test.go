@@ -7,7 +7,9 @@ import (
 	"testing"
 	"time"
 
+	_ "github.com/bbernstein/lacylights-test/pkg/artifacts"
 	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/bbernstein/lacylights-test/pkg/dmxassert"
 	"github.com/bbernstein/lacylights-test/pkg/graphql"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -21,28 +23,9 @@ func skipDMXTests(t *testing.T) {
 	}
 }
 
-// getArtNetPort returns the Art-Net listening address from env or default.
-func getArtNetPort() string {
-	port := os.Getenv("ARTNET_LISTEN_PORT")
-	if port == "" {
-		port = "6454"
-	}
-	// If running locally with localhost broadcast, bind to localhost
-	if os.Getenv("ARTNET_BROADCAST") == "127.0.0.1" {
-		return "127.0.0.1:" + port
-	}
-	return ":" + port
-}
-
 func TestArtNetReceiver(t *testing.T) {
 	// This test verifies the Art-Net receiver works
-	receiver := artnet.NewReceiver(getArtNetPort())
-
-	err := receiver.Start()
-	if err != nil {
-		t.Skipf("Could not start Art-Net receiver (port may be in use): %v", err)
-	}
-	defer func() { _ = receiver.Stop() }()
+	receiver := artnet.Capture(t)
 
 	// Just verify it starts without error
 	assert.NotNil(t, receiver)
@@ -162,9 +145,7 @@ func TestSetMultipleChannels(t *testing.T) {
 		query { dmxOutput(universe: 1) }
 	`, nil, &dmxResp)
 	require.NoError(t, err)
-	assert.Equal(t, 100, dmxResp.DMXOutput[0])
-	assert.Equal(t, 150, dmxResp.DMXOutput[1])
-	assert.Equal(t, 200, dmxResp.DMXOutput[2])
+	dmxassert.ExpectFrame(t, dmxResp.DMXOutput, []int{100, 150, 200}, 0, dmxassert.Msg("Channels 1-3 should hold the values set"))
 
 	// Reset the channels
 	err = client.Mutate(ctx, `
@@ -254,12 +235,7 @@ func TestArtNetCaptureDuringChannelChange(t *testing.T) {
 	defer cancel()
 
 	// Start Art-Net receiver
-	receiver := artnet.NewReceiver(getArtNetPort())
-	err := receiver.Start()
-	if err != nil {
-		t.Skipf("Could not start Art-Net receiver (port may be in use or Art-Net disabled): %v", err)
-	}
-	defer func() { _ = receiver.Stop() }()
+	receiver := artnet.Capture(t)
 
 	client := graphql.NewClient("")
 
@@ -272,7 +248,7 @@ func TestArtNetCaptureDuringChannelChange(t *testing.T) {
 		SetChannelValue bool `json:"setChannelValue"`
 	}
 
-	err = client.Mutate(ctx, `
+	err := client.Mutate(ctx, `
 		mutation SetChannel {
 			setChannelValue(universe: 1, channel: 10, value: 177)
 		}
@@ -0,0 +1,172 @@
+package dmx
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/fixtures"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// highlightContract is the locate function a tech uses to find a fixture in the rig.
+const highlightContract = `highlightFixture(fixtureId: ID!, duration: Float): Boolean!
+  releaseHighlight(fixtureId: ID!): Boolean!
+  locate state: intensity and every color channel at full; duration in seconds, omitted holds until released`
+
+const (
+	// highlightStart and neighbourStart are the start channels of the two RGB pars,
+	// clear of the channels the other DMX tests write
+	highlightStart = 101
+	neighbourStart = 105
+)
+
+// locateState is the output of a highlighted RGB par: dimmer, red, green, blue.
+var locateState = []int{255, 255, 255, 255}
+
+// highlightSetup is a project with two dimmer+RGB pars on universe 1.
+type highlightSetup struct {
+	client     *graphql.Client
+	ctx        context.Context
+	project    *fixtures.TestProject
+	fixtureIDs []string
+}
+
+func newHighlightSetup(t *testing.T, ctx context.Context) *highlightSetup {
+	skipDMXTests(t)
+	client := graphql.NewClient("")
+	compat.RequireMutation(t, client, "highlightFixture", highlightContract)
+
+	project := fixtures.NewParProject(t, ctx, client, "Highlight")
+	s := &highlightSetup{client: client, ctx: ctx, project: project}
+	project.OnCleanup(func(ctx context.Context) {
+		for _, id := range s.fixtureIDs {
+			_ = client.Mutate(ctx, `mutation Release($id: ID!) { releaseHighlight(fixtureId: $id) }`,
+				map[string]interface{}{"id": id}, nil)
+		}
+	})
+
+	for _, start := range []int{highlightStart, neighbourStart} {
+		s.fixtureIDs = append(s.fixtureIDs, project.AddFixture(t, ctx,
+			fixtures.Fixture{Name: fmt.Sprintf("Highlight Par %d", start), Universe: 1, StartChannel: start}))
+	}
+
+	return s
+}
+
+// setLook creates and activates a look giving each par its dimmer, red, green and blue values.
+func (s *highlightSetup) setLook(t *testing.T, name string, target, neighbour []int) {
+	lookID := s.project.AddLook(t, s.ctx, name, []fixtures.FixtureValues{
+		{FixtureID: s.fixtureIDs[0], Values: target},
+		{FixtureID: s.fixtureIDs[1], Values: neighbour},
+	})
+	fixtures.SetLookLive(t, s.ctx, s.client, lookID)
+}
+
+// highlight locates the target par; a nil duration holds until released.
+func (s *highlightSetup) highlight(t *testing.T, duration *float64) {
+	var resp struct {
+		HighlightFixture bool `json:"highlightFixture"`
+	}
+	err := s.client.Mutate(s.ctx, `
+		mutation Highlight($fixtureId: ID!, $duration: Float) {
+			highlightFixture(fixtureId: $fixtureId, duration: $duration)
+		}
+	`, map[string]interface{}{"fixtureId": s.fixtureIDs[0], "duration": duration}, &resp)
+	require.NoError(t, err)
+	require.True(t, resp.HighlightFixture, "highlightFixture should succeed")
+	time.Sleep(fixtures.Settle)
+}
+
+func (s *highlightSetup) release(t *testing.T) {
+	var resp struct {
+		ReleaseHighlight bool `json:"releaseHighlight"`
+	}
+	err := s.client.Mutate(s.ctx, `
+		mutation Release($fixtureId: ID!) {
+			releaseHighlight(fixtureId: $fixtureId)
+		}
+	`, map[string]interface{}{"fixtureId": s.fixtureIDs[0]}, &resp)
+	require.NoError(t, err)
+	require.True(t, resp.ReleaseHighlight, "releaseHighlight should succeed")
+	time.Sleep(fixtures.Settle)
+}
+
+// expect asserts the target and neighbour pars' four channels.
+func (s *highlightSetup) expect(t *testing.T, target, neighbour []int, msg string) {
+	t.Helper()
+	var resp struct {
+		DMXOutput []int `json:"dmxOutput"`
+	}
+	err := s.client.Query(s.ctx, `query { dmxOutput(universe: 1) }`, nil, &resp)
+	require.NoError(t, err)
+	require.Len(t, resp.DMXOutput, 512, "%s: dmxOutput should cover the universe", msg)
+
+	assert.Equal(t, target, resp.DMXOutput[highlightStart-1:highlightStart+3], "%s: highlighted par", msg)
+	assert.Equal(t, neighbour, resp.DMXOutput[neighbourStart-1:neighbourStart+3], "%s: neighbouring par", msg)
+}
+
+// TestHighlightFixture drives a par to the locate state and checks it returns
+// to whatever playback says once the highlight is released or times out. The
+// neighbouring par must never be touched.
+func TestHighlightFixture(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	s := newHighlightSetup(t, ctx)
+
+	amber := []int{50, 200, 80, 0}
+	dimBlue := []int{100, 0, 0, 180}
+	s.setLook(t, "Before Highlight", amber, dimBlue)
+	s.expect(t, amber, dimBlue, "Look before highlighting")
+
+	t.Run("ExplicitRelease", func(t *testing.T) {
+		s.highlight(t, nil)
+		s.expect(t, locateState, dimBlue, "While highlighted")
+
+		// Without a duration the highlight holds
+		time.Sleep(1500 * time.Millisecond)
+		s.expect(t, locateState, dimBlue, "Highlight without a duration should hold")
+
+		s.release(t)
+		s.expect(t, amber, dimBlue, "After release the look should be restored")
+	})
+
+	t.Run("AutoRestoreAfterTimeout", func(t *testing.T) {
+		duration := 1.0
+		s.highlight(t, &duration)
+		s.expect(t, locateState, dimBlue, "During a timed highlight")
+
+		time.Sleep(time.Duration(duration*float64(time.Second)) + fixtures.Settle)
+		s.expect(t, amber, dimBlue, "After the timeout the look should be restored")
+	})
+
+	t.Run("LookChangeWhileHighlighted", func(t *testing.T) {
+		s.highlight(t, nil)
+
+		green := []int{180, 0, 255, 0}
+		red := []int{30, 255, 0, 0}
+		s.setLook(t, "During Highlight", green, red)
+		s.expect(t, locateState, red, "A look change must not break the highlight")
+
+		s.release(t)
+		s.expect(t, green, red, "Release should restore the current look, not the one active when highlighting began")
+	})
+
+	t.Run("ReleaseWithoutHighlight", func(t *testing.T) {
+		var resp struct {
+			ReleaseHighlight bool `json:"releaseHighlight"`
+		}
+		err := s.client.Mutate(ctx, `
+			mutation Release($fixtureId: ID!) {
+				releaseHighlight(fixtureId: $fixtureId)
+			}
+		`, map[string]interface{}{"fixtureId": s.fixtureIDs[1]}, &resp)
+		require.NoError(t, err, "Releasing a fixture that is not highlighted should be harmless")
+		t.Logf("Contract: releaseHighlight on an unhighlighted fixture returned %v", resp.ReleaseHighlight)
+	})
+}
@@ -0,0 +1,96 @@
+package dmx
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// idleCapture is how long output is watched with nothing changing
+	idleCapture = 10 * time.Second
+
+	// keepAliveMinRate is the lowest acceptable average frame rate while idle
+	keepAliveMinRate = 1.0
+
+	// keepAliveMaxGap is the Art-Net limit between refreshes of an unchanged universe;
+	// nodes may drop their output after it
+	keepAliveMaxGap = 4 * time.Second
+
+	// keepAliveChannel holds a known value while idle, clear of the other DMX tests' channels
+	keepAliveChannel = 220
+	keepAliveValue   = 123
+)
+
+// TestIdleOutputKeepAlive leaves the output unchanged for ten seconds and
+// checks Art-Net keeps refreshing the universe: at least keepAliveMinRate on
+// average, never silent for keepAliveMaxGap, and always with the held values.
+func TestIdleOutputKeepAlive(t *testing.T) {
+	skipDMXTests(t)
+	if testing.Short() {
+		t.Skip("Skipping idle keep-alive capture in short mode")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	receiver := artnet.Capture(t)
+
+	client := graphql.NewClient("")
+	err := client.Mutate(ctx, `
+		mutation SetChannel($channel: Int!, $value: Int!) {
+			setChannelValue(universe: 1, channel: $channel, value: $value)
+		}
+	`, map[string]interface{}{"channel": keepAliveChannel, "value": keepAliveValue}, nil)
+	require.NoError(t, err)
+	defer func() {
+		_ = client.Mutate(context.Background(), `
+			mutation SetChannel($channel: Int!) { setChannelValue(universe: 1, channel: $channel, value: 0) }
+		`, map[string]interface{}{"channel": keepAliveChannel}, nil)
+	}()
+
+	// Let any change-driven burst of frames pass before measuring the idle rate
+	time.Sleep(time.Second)
+	receiver.ClearFrames()
+	start := time.Now()
+	time.Sleep(idleCapture)
+	end := time.Now()
+
+	frames := receiver.GetFrames()
+	if len(frames) == 0 {
+		t.Skip("No Art-Net frames captured - Art-Net may not be enabled")
+	}
+
+	var stamps []time.Time
+	mismatched := 0
+	for _, frame := range frames {
+		if frame.Universe != 0 {
+			continue
+		}
+		stamps = append(stamps, frame.Timestamp)
+		if frame.Channels[keepAliveChannel-1] != keepAliveValue {
+			mismatched++
+		}
+	}
+	require.NotEmpty(t, stamps, "Universe 1 should be refreshed while idle")
+	assert.Zero(t, mismatched, "Idle frames should carry the held value %d on channel %d (%d of %d did not)",
+		keepAliveValue, keepAliveChannel, mismatched, len(stamps))
+
+	rate := float64(len(stamps)) / idleCapture.Seconds()
+	t.Logf("Idle refresh: %d frames in %v (%.1f Hz)", len(stamps), idleCapture, rate)
+	assert.GreaterOrEqual(t, rate, keepAliveMinRate, "Idle output should refresh at least %.0f Hz", keepAliveMinRate)
+
+	// The silence before the first frame and after the last counts as a gap too
+	longest := stamps[0].Sub(start)
+	for i := 1; i < len(stamps); i++ {
+		longest = max(longest, stamps[i].Sub(stamps[i-1]))
+	}
+	longest = max(longest, end.Sub(stamps[len(stamps)-1]))
+	t.Logf("Longest idle gap: %v", longest)
+	assert.Less(t, longest, keepAliveMaxGap, "Idle output must not go silent for %v", keepAliveMaxGap)
+}
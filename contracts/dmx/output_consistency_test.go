@@ -0,0 +1,186 @@
+package dmx
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// consistencyChannels are the 1-based channels driven by the consistency tests,
+	// clear of the channels the other DMX tests write
+	consistencyFirst = 201
+	consistencyCount = 8
+
+	// consistencyFade is long enough to take many samples mid-fade
+	consistencyFade = 2.0
+)
+
+// querySample is one dmxOutput read, timed at the midpoint of the request.
+type querySample struct {
+	At     time.Time
+	Values []int
+}
+
+// sampleQueries reads dmxOutput(universe: 1) back to back for the duration.
+func sampleQueries(t *testing.T, ctx context.Context, client *graphql.Client, duration time.Duration) []querySample {
+	var samples []querySample
+	for deadline := time.Now().Add(duration); time.Now().Before(deadline); {
+		var resp struct {
+			DMXOutput []int `json:"dmxOutput"`
+		}
+		sent := time.Now()
+		err := client.Query(ctx, `query { dmxOutput(universe: 1) }`, nil, &resp)
+		require.NoError(t, err)
+		received := time.Now()
+		require.Len(t, resp.DMXOutput, 512, "dmxOutput should cover the universe")
+		samples = append(samples, querySample{At: sent.Add(received.Sub(sent) / 2), Values: resp.DMXOutput})
+	}
+	return samples
+}
+
+// framePeriod is the median interval between captured universe 1 frames.
+func framePeriod(frames []artnet.Frame) time.Duration {
+	var gaps []time.Duration
+	var prev time.Time
+	for _, f := range frames {
+		if f.Universe != 0 {
+			continue
+		}
+		if !prev.IsZero() {
+			gaps = append(gaps, f.Timestamp.Sub(prev))
+		}
+		prev = f.Timestamp
+	}
+	if len(gaps) == 0 {
+		return 0
+	}
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+	return gaps[len(gaps)/2]
+}
+
+// disagreements compares each query sample with the Art-Net frames sent within
+// window of it: every driven channel must lie within the range those frames
+// transmitted. Samples with no frames in the window are skipped.
+func disagreements(samples []querySample, frames []artnet.Frame, window time.Duration) (problems []string, compared int) {
+	for _, s := range samples {
+		lo := make([]int, consistencyCount)
+		hi := make([]int, consistencyCount)
+		seen := false
+		for _, f := range frames {
+			if f.Universe != 0 || f.Timestamp.Before(s.At.Add(-window)) || f.Timestamp.After(s.At.Add(window)) {
+				continue
+			}
+			for i := range lo {
+				v := int(f.Channels[consistencyFirst-1+i])
+				if !seen || v < lo[i] {
+					lo[i] = v
+				}
+				if !seen || v > hi[i] {
+					hi[i] = v
+				}
+			}
+			seen = true
+		}
+		if !seen {
+			continue
+		}
+		compared++
+		for i := range lo {
+			v := s.Values[consistencyFirst-1+i]
+			if v < lo[i] || v > hi[i] {
+				problems = append(problems, fmt.Sprintf("channel %d at %s: query %d, Art-Net %d..%d",
+					consistencyFirst+i, s.At.Format("15:04:05.000"), v, lo[i], hi[i]))
+			}
+		}
+	}
+	return problems, compared
+}
+
+func setConsistencyChannels(t *testing.T, ctx context.Context, client *graphql.Client, value func(i int) int) {
+	for i := 0; i < consistencyCount; i++ {
+		err := client.Mutate(ctx, `
+			mutation SetChannel($channel: Int!, $value: Int!) {
+				setChannelValue(universe: 1, channel: $channel, value: $value)
+			}
+		`, map[string]interface{}{"channel": consistencyFirst + i, "value": value(i)}, nil)
+		require.NoError(t, err)
+	}
+}
+
+// TestDMXOutputMatchesArtNet reads dmxOutput while capturing Art-Net and
+// checks both report the same channel values to within one frame period,
+// at steady state and through a fade. A mismatch means the query reads a
+// different buffer from the one being transmitted.
+func TestDMXOutputMatchesArtNet(t *testing.T) {
+	skipDMXTests(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	receiver := artnet.Capture(t)
+
+	client := graphql.NewClient("")
+	defer func() {
+		_ = client.Mutate(context.Background(), `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+	}()
+
+	// Distinct values so a channel offset shows up as a mismatch
+	setConsistencyChannels(t, ctx, client, func(i int) int { return 255 - 20*i })
+	time.Sleep(300 * time.Millisecond)
+
+	check := func(t *testing.T, samples []querySample) {
+		frames := receiver.GetFrames()
+		if len(frames) == 0 {
+			t.Skip("No Art-Net frames captured - Art-Net may not be enabled")
+		}
+		period := framePeriod(frames)
+		require.Positive(t, period, "Need at least two frames to find the frame period")
+
+		problems, compared := disagreements(samples, frames, period)
+		t.Logf("Compared %d of %d query samples against %d frames (frame period %v)",
+			compared, len(samples), len(frames), period)
+		require.Positive(t, compared, "No query sample had Art-Net frames within one frame period")
+		assert.Empty(t, problems, "dmxOutput should match transmitted Art-Net within one frame period")
+	}
+
+	t.Run("SteadyState", func(t *testing.T) {
+		receiver.ClearFrames()
+		samples := sampleQueries(t, ctx, client, time.Second)
+		check(t, samples)
+
+		for _, s := range samples {
+			for i := 0; i < consistencyCount; i++ {
+				require.Equal(t, 255-20*i, s.Values[consistencyFirst-1+i], "Channel %d should hold its value", consistencyFirst+i)
+			}
+		}
+	})
+
+	t.Run("DuringFade", func(t *testing.T) {
+		receiver.ClearFrames()
+		err := client.Mutate(ctx, `
+			mutation FadeToBlack($fadeOutTime: Float!) {
+				fadeToBlack(fadeOutTime: $fadeOutTime)
+			}
+		`, map[string]interface{}{"fadeOutTime": consistencyFade}, nil)
+		require.NoError(t, err)
+
+		samples := sampleQueries(t, ctx, client, time.Duration(consistencyFade*float64(time.Second)))
+
+		midFade := 0
+		for _, s := range samples {
+			if v := s.Values[consistencyFirst-1]; v > 10 && v < 245 {
+				midFade++
+			}
+		}
+		require.Positive(t, midFade, "Some query samples should land mid-fade")
+		check(t, samples)
+	})
+}
@@ -0,0 +1,149 @@
+package dmx
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// outputRateKey is the setting that drives the fade engine and Art-Net refresh
+	outputRateKey = "fade_update_rate_hz"
+
+	outputRateContract = `setting "fade_update_rate_hz": output refresh rate in Hz; Art-Net frames follow it
+  while output is changing, and a new value takes effect without a restart`
+
+	// outputRateChannel is faded by the rate tests, clear of the other DMX tests' channels
+	outputRateChannel = 225
+
+	// outputRateFade is long enough to count frames and steps at the lowest rate
+	outputRateFade = 2.0
+
+	// outputRateTolerance is the allowed relative error of the measured frame rate
+	outputRateTolerance = 0.15
+)
+
+func getSettingValue(t *testing.T, ctx context.Context, client *graphql.Client, key string) string {
+	var resp struct {
+		Setting *struct {
+			Value string `json:"value"`
+		} `json:"setting"`
+	}
+	err := client.Query(ctx, `
+		query GetSetting($key: String!) {
+			setting(key: $key) { value }
+		}
+	`, map[string]interface{}{"key": key}, &resp)
+	require.NoError(t, err)
+	require.NotNil(t, resp.Setting, "setting %s should exist", key)
+	return resp.Setting.Value
+}
+
+func updateSettingValue(t *testing.T, ctx context.Context, client *graphql.Client, key, value string) {
+	err := client.Mutate(ctx, `
+		mutation UpdateSetting($input: UpdateSettingInput!) {
+			updateSetting(input: $input) { key value }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{"key": key, "value": value},
+	}, nil)
+	require.NoError(t, err)
+}
+
+// fadeFrames returns the universe 1 frames from the first that leaves full
+// to the first that reaches zero on the rate channel.
+func fadeFrames(frames []artnet.Frame) []artnet.Frame {
+	var fade []artnet.Frame
+	for _, f := range frames {
+		if f.Universe != 0 {
+			continue
+		}
+		v := f.Channels[outputRateChannel-1]
+		if len(fade) == 0 && v == 255 {
+			continue
+		}
+		fade = append(fade, f)
+		if v == 0 {
+			break
+		}
+	}
+	return fade
+}
+
+// TestOutputRateSetting fades a channel at 30 and 44 Hz and measures the
+// Art-Net frame rate during each fade. The measured rate must match the
+// setting within outputRateTolerance, and the fade must stay smooth at the
+// lower rate: never rising, and no step larger than a few frames' worth.
+func TestOutputRateSetting(t *testing.T) {
+	skipDMXTests(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewClient("")
+	compat.RequireSetting(t, client, outputRateKey, outputRateContract)
+
+	original := getSettingValue(t, ctx, client, outputRateKey)
+	t.Cleanup(func() {
+		cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cleanupCancel()
+		updateSettingValue(t, cleanupCtx, client, outputRateKey, original)
+		_ = client.Mutate(cleanupCtx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+	})
+
+	receiver := artnet.Capture(t)
+
+	for _, rate := range []int{30, 44} {
+		t.Run(fmt.Sprintf("%dHz", rate), func(t *testing.T) {
+			updateSettingValue(t, ctx, client, outputRateKey, fmt.Sprint(rate))
+
+			err := client.Mutate(ctx, `
+				mutation SetChannel($channel: Int!, $value: Int!) {
+					setChannelValue(universe: 1, channel: $channel, value: $value)
+				}
+			`, map[string]interface{}{"channel": outputRateChannel, "value": 255}, nil)
+			require.NoError(t, err)
+			time.Sleep(300 * time.Millisecond)
+
+			receiver.ClearFrames()
+			err = client.Mutate(ctx, `
+				mutation FadeToBlack($fadeOutTime: Float!) {
+					fadeToBlack(fadeOutTime: $fadeOutTime)
+				}
+			`, map[string]interface{}{"fadeOutTime": outputRateFade}, nil)
+			require.NoError(t, err)
+			time.Sleep(time.Duration((outputRateFade + 0.5) * float64(time.Second)))
+
+			all := receiver.GetFrames()
+			if len(all) == 0 {
+				t.Skip("No Art-Net frames captured - Art-Net may not be enabled")
+			}
+			frames := fadeFrames(all)
+			require.GreaterOrEqual(t, len(frames), 2, "The fade should span several frames")
+			require.Zero(t, frames[len(frames)-1].Channels[outputRateChannel-1], "The fade should reach zero")
+
+			span := frames[len(frames)-1].Timestamp.Sub(frames[0].Timestamp).Seconds()
+			measured := float64(len(frames)-1) / span
+			t.Logf("%d frames over %.2fs: %.1f Hz (median period %v)", len(frames), span, measured, framePeriod(frames))
+			assert.InEpsilon(t, float64(rate), measured, outputRateTolerance,
+				"Art-Net should be sent at the configured rate during a fade")
+
+			// One frame's share of the fade, with room for a dropped or late frame
+			maxStep := 3 * 255 / (outputRateFade * float64(rate))
+			prev := 255
+			for i, f := range frames {
+				v := int(f.Channels[outputRateChannel-1])
+				assert.LessOrEqual(t, v, prev, "frame %d: the fade to black rose from %d to %d", i, prev, v)
+				assert.LessOrEqual(t, float64(prev-v), maxStep, "frame %d: step %d -> %d is not smooth", i, prev, v)
+				prev = v
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package dmx
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/fixtures"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// partialActivationContract is look activation that leaves fixtures outside the look alone.
+const partialActivationContract = `setLookLive(lookId: ID!, mode: LookActivationMode): Boolean!
+  enum LookActivationMode { EXCLUSIVE ADDITIVE }
+  EXCLUSIVE releases every fixture the look does not set to its default; ADDITIVE merges the look
+  onto current output, holding the fixtures it does not set; fixtures in both take the look's values`
+
+// partialStarts are the start channels of the three pars, clear of the
+// channels the other DMX tests write.
+var partialStarts = []int{121, 125, 129}
+
+// partialSetup is a project with three dimmer+RGB pars on universe 1 and two
+// overlapping looks: base sets pars 1 and 2, partial sets pars 2 and 3.
+type partialSetup struct {
+	client     *graphql.Client
+	ctx        context.Context
+	projectID  string
+	fixtureIDs []string
+	baseID     string
+	partialID  string
+}
+
+var (
+	baseValues    = [][]int{{200, 255, 100, 0}, {120, 0, 255, 0}}
+	partialValues = [][]int{{60, 0, 0, 255}, {255, 255, 255, 255}}
+	releasedPar   = []int{0, 0, 0, 0}
+)
+
+func newPartialSetup(t *testing.T, ctx context.Context) *partialSetup {
+	skipDMXTests(t)
+	client := graphql.NewClient("")
+	compat.RequireMutationArgument(t, client, "setLookLive", "mode", partialActivationContract)
+
+	project := fixtures.NewParProject(t, ctx, client, "Partial Activation")
+	s := &partialSetup{client: client, ctx: ctx, projectID: project.ID}
+	for i, start := range partialStarts {
+		s.fixtureIDs = append(s.fixtureIDs, project.AddFixture(t, ctx,
+			fixtures.Fixture{Name: fmt.Sprintf("Partial Par %d", i+1), Universe: 1, StartChannel: start}))
+	}
+
+	s.baseID = project.AddLook(t, ctx, "Base", []fixtures.FixtureValues{
+		{FixtureID: s.fixtureIDs[0], Values: baseValues[0]},
+		{FixtureID: s.fixtureIDs[1], Values: baseValues[1]},
+	})
+	s.partialID = project.AddLook(t, ctx, "Partial", []fixtures.FixtureValues{
+		{FixtureID: s.fixtureIDs[1], Values: partialValues[0]},
+		{FixtureID: s.fixtureIDs[2], Values: partialValues[1]},
+	})
+	return s
+}
+
+// activate sets a look live; an empty mode omits the argument.
+func (s *partialSetup) activate(t *testing.T, lookID, mode string) {
+	vars := map[string]interface{}{"lookId": lookID}
+	mutation := `mutation SetLookLive($lookId: ID!) { setLookLive(lookId: $lookId) }`
+	if mode != "" {
+		vars["mode"] = mode
+		mutation = `mutation SetLookLive($lookId: ID!, $mode: LookActivationMode) { setLookLive(lookId: $lookId, mode: $mode) }`
+	}
+	require.NoError(t, s.client.Mutate(s.ctx, mutation, vars, nil))
+	time.Sleep(fixtures.Settle)
+}
+
+// pars returns the four channels of each par.
+func (s *partialSetup) pars(t *testing.T) [][]int {
+	t.Helper()
+	var resp struct {
+		DMXOutput []int `json:"dmxOutput"`
+	}
+	require.NoError(t, s.client.Query(s.ctx, `query { dmxOutput(universe: 1) }`, nil, &resp))
+	require.Len(t, resp.DMXOutput, 512, "dmxOutput should cover the universe")
+	pars := make([][]int, len(partialStarts))
+	for i, start := range partialStarts {
+		pars[i] = resp.DMXOutput[start-1 : start+3]
+	}
+	return pars
+}
+
+// expectPars compares every channel of the three pars.
+func expectPars(t *testing.T, got, want [][]int, msg string) {
+	t.Helper()
+	for i := range want {
+		assert.Equal(t, want[i], got[i], "%s: par %d", msg, i+1)
+	}
+}
+
+// TestPartialLookActivation activates the base look, then the partial look in
+// each mode, and checks all three pars channel by channel.
+func TestPartialLookActivation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	s := newPartialSetup(t, ctx)
+
+	exclusive := [][]int{releasedPar, partialValues[0], partialValues[1]}
+	additive := [][]int{baseValues[0], partialValues[0], partialValues[1]}
+
+	t.Run("Exclusive", func(t *testing.T) {
+		s.activate(t, s.baseID, "EXCLUSIVE")
+		expectPars(t, s.pars(t), [][]int{baseValues[0], baseValues[1], releasedPar}, "Base look")
+
+		s.activate(t, s.partialID, "EXCLUSIVE")
+		expectPars(t, s.pars(t), exclusive, "EXCLUSIVE should release par 1, which the look does not set")
+	})
+
+	t.Run("Additive", func(t *testing.T) {
+		s.activate(t, s.baseID, "EXCLUSIVE")
+		s.activate(t, s.partialID, "ADDITIVE")
+		expectPars(t, s.pars(t), additive, "ADDITIVE should hold par 1 and take the look's values for pars 2 and 3")
+
+		// Re-activating the base additively restores its pars and keeps par 3
+		s.activate(t, s.baseID, "ADDITIVE")
+		expectPars(t, s.pars(t), [][]int{baseValues[0], baseValues[1], partialValues[1]}, "Second ADDITIVE")
+	})
+
+	t.Run("Default", func(t *testing.T) {
+		s.activate(t, s.baseID, "EXCLUSIVE")
+		s.activate(t, s.partialID, "")
+		got := s.pars(t)
+		switch {
+		case assert.ObjectsAreEqual(exclusive, got):
+			t.Logf("Contract: setLookLive without a mode is EXCLUSIVE")
+		case assert.ObjectsAreEqual(additive, got):
+			t.Logf("Contract: setLookLive without a mode is ADDITIVE")
+		default:
+			t.Errorf("setLookLive without a mode matches neither mode: %v", got)
+		}
+	})
+}
+
+// TestPartialActivationWithEffect runs a sine effect on par 1's dimmer and
+// records how each mode treats it. Looks and effects are separate layers, so
+// either outcome is acceptable; the test documents which one the server uses.
+func TestPartialActivationWithEffect(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	s := newPartialSetup(t, ctx)
+	compat.Require(t, compat.Effects)
+
+	effectID, err := fixtures.CreateWaveformEffect(ctx, s.client, s.projectID, "Partial Sine", "SINE", 1.0, []string{s.fixtureIDs[0]})
+	require.NoError(t, err)
+	defer func() {
+		_ = s.client.Mutate(ctx, `mutation Stop($id: ID!) { stopEffect(effectId: $id, fadeTime: 0) }`,
+			map[string]interface{}{"id": effectID}, nil)
+	}()
+
+	// moving samples par 1's dimmer for a second and reports whether it changed.
+	moving := func() bool {
+		seen := map[int]bool{}
+		for i := 0; i < 10; i++ {
+			seen[s.pars(t)[0][0]] = true
+			time.Sleep(100 * time.Millisecond)
+		}
+		return len(seen) > 2
+	}
+
+	for _, mode := range []string{"ADDITIVE", "EXCLUSIVE"} {
+		s.activate(t, s.baseID, "EXCLUSIVE")
+		require.NoError(t, s.client.Mutate(ctx, `mutation Activate($id: ID!) { activateEffect(effectId: $id, fadeTime: 0) }`,
+			map[string]interface{}{"id": effectID}, nil))
+		time.Sleep(fixtures.Settle)
+		require.True(t, moving(), "The effect should be moving par 1 before the partial look")
+
+		s.activate(t, s.partialID, mode)
+		pars := s.pars(t)
+		assert.Equal(t, partialValues[0], pars[1], "%s: the effect must not disturb par 2", mode)
+		assert.Equal(t, partialValues[1], pars[2], "%s: the effect must not disturb par 3", mode)
+		if moving() {
+			t.Logf("Contract: %s activation leaves the effect running on a fixture outside the look", mode)
+		} else {
+			t.Logf("Contract: %s activation stops the effect on a fixture outside the look", mode)
+		}
+
+		require.NoError(t, s.client.Mutate(ctx, `mutation Stop($id: ID!) { stopEffect(effectId: $id, fadeTime: 0) }`,
+			map[string]interface{}{"id": effectID}, nil))
+	}
+}
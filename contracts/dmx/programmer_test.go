@@ -0,0 +1,234 @@
+package dmx
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/fixtures"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// programmerContract is the manual channel override ("programmer") these tests expect.
+const programmerContract = `setChannelValue(fixtureId: ID, offset: Int, value: Int!): Boolean!
+  sets one channel of a fixture live, above any look or cue, until the programmer is cleared;
+  clearProgrammer: Boolean! drops every override and the channels return to what playback outputs`
+
+// programmerStart is the start channel of the par, clear of the channels the
+// other DMX tests write.
+const programmerStart = 161
+
+var (
+	programmerLookA = []int{200, 255, 0, 0}
+	programmerLookB = []int{100, 0, 255, 0}
+)
+
+type programmerSetup struct {
+	client    *graphql.Client
+	ctx       context.Context
+	projectID string
+	fixtureID string
+	lookA     string
+	lookB     string
+}
+
+func newProgrammerSetup(t *testing.T, ctx context.Context) *programmerSetup {
+	skipDMXTests(t)
+	client := graphql.NewClient("")
+	compat.RequireMutationArgument(t, client, "setChannelValue", "fixtureId", programmerContract)
+
+	project := fixtures.NewParProject(t, ctx, client, "Programmer")
+	project.OnCleanup(func(ctx context.Context) {
+		_ = client.Mutate(ctx, `mutation { clearProgrammer }`, nil, nil)
+	})
+	s := &programmerSetup{client: client, ctx: ctx, projectID: project.ID}
+
+	s.fixtureID = project.AddFixture(t, ctx, fixtures.Fixture{Name: "Programmer Par", Universe: 1, StartChannel: programmerStart})
+	s.lookA = project.AddLook(t, ctx, "Programmer Look A", []fixtures.FixtureValues{{FixtureID: s.fixtureID, Values: programmerLookA}})
+	s.lookB = project.AddLook(t, ctx, "Programmer Look B", []fixtures.FixtureValues{{FixtureID: s.fixtureID, Values: programmerLookB}})
+	return s
+}
+
+// set overrides one channel of the par by offset.
+func (s *programmerSetup) set(t *testing.T, offset, value int) {
+	var resp struct {
+		SetChannelValue bool `json:"setChannelValue"`
+	}
+	require.NoError(t, s.client.Mutate(s.ctx, `
+		mutation SetFixtureChannel($fixtureId: ID!, $offset: Int!, $value: Int!) {
+			setChannelValue(fixtureId: $fixtureId, offset: $offset, value: $value)
+		}
+	`, map[string]interface{}{"fixtureId": s.fixtureID, "offset": offset, "value": value}, &resp))
+	require.True(t, resp.SetChannelValue, "setChannelValue should succeed")
+	time.Sleep(fixtures.Settle)
+}
+
+// clear drops every override, skipping the test if the server has no clearProgrammer.
+func (s *programmerSetup) clear(t *testing.T) {
+	compat.RequireMutation(t, s.client, "clearProgrammer", programmerContract)
+	var resp struct {
+		ClearProgrammer bool `json:"clearProgrammer"`
+	}
+	require.NoError(t, s.client.Mutate(s.ctx, `mutation { clearProgrammer }`, nil, &resp))
+	require.True(t, resp.ClearProgrammer, "clearProgrammer should succeed")
+	time.Sleep(fixtures.Settle)
+}
+
+func (s *programmerSetup) setLookLive(t *testing.T, lookID string) {
+	fixtures.SetLookLive(t, s.ctx, s.client, lookID)
+}
+
+// output reads the par's four channels.
+func (s *programmerSetup) output(t *testing.T) []int {
+	t.Helper()
+	var resp struct {
+		DMXOutput []int `json:"dmxOutput"`
+	}
+	require.NoError(t, s.client.Query(s.ctx, `query { dmxOutput(universe: 1) }`, nil, &resp))
+	require.Len(t, resp.DMXOutput, 512, "dmxOutput should cover the universe")
+	return resp.DMXOutput[programmerStart-1 : programmerStart+3]
+}
+
+func (s *programmerSetup) expect(t *testing.T, want []int, msg string) {
+	t.Helper()
+	assert.Equal(t, want, s.output(t), msg)
+}
+
+// TestProgrammerOverridesLooks overrides the par's red channel and checks the
+// override sits above look activation until the programmer is cleared.
+func TestProgrammerOverridesLooks(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	s := newProgrammerSetup(t, ctx)
+
+	s.setLookLive(t, s.lookA)
+	s.expect(t, programmerLookA, "Look A before any override")
+
+	s.set(t, 1, 50)
+	s.expect(t, []int{200, 50, 0, 0}, "The override should replace only the red channel")
+
+	s.setLookLive(t, s.lookA)
+	s.expect(t, []int{200, 50, 0, 0}, "Reactivating the look should not replace the override")
+	s.setLookLive(t, s.lookB)
+	s.expect(t, []int{100, 50, 255, 0}, "A different look should play under the override")
+
+	s.set(t, 1, 0)
+	s.expect(t, []int{100, 0, 255, 0}, "An override of 0 should hold the channel at 0")
+
+	s.clear(t)
+	s.expect(t, programmerLookB, "Clearing the programmer should return the par to the live look")
+
+	t.Run("UnknownFixture", func(t *testing.T) {
+		err := s.client.Mutate(ctx, `
+			mutation SetFixtureChannel($fixtureId: ID!) { setChannelValue(fixtureId: $fixtureId, offset: 0, value: 10) }
+		`, map[string]interface{}{"fixtureId": "non-existent-fixture-id"}, nil)
+		assert.Error(t, err, "Overriding an unknown fixture should be an error")
+	})
+
+	t.Run("OffsetOutOfRange", func(t *testing.T) {
+		err := s.client.Mutate(ctx, `
+			mutation SetFixtureChannel($fixtureId: ID!) { setChannelValue(fixtureId: $fixtureId, offset: 4, value: 10) }
+		`, map[string]interface{}{"fixtureId": s.fixtureID}, nil)
+		assert.Error(t, err, "The par has no offset 4")
+		s.expect(t, programmerLookB, "A rejected override should change nothing")
+	})
+}
+
+// TestProgrammerAcrossCues holds an override while a cue list plays through
+// both looks, then clears it mid-list.
+func TestProgrammerAcrossCues(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	s := newProgrammerSetup(t, ctx)
+
+	cueListID, err := fixtures.CreateCueList(ctx, s.client, s.projectID, "Programmer Cues", []fixtures.Cue{
+		{Name: "A", LookID: s.lookA, FadeTime: 0.5},
+		{Name: "B", LookID: s.lookB, FadeTime: 0.5},
+	})
+	require.NoError(t, err)
+	cueList := func(mutation string) {
+		require.NoError(t, s.client.Mutate(ctx, fmt.Sprintf(`mutation Control($cueListId: ID!) { %s(cueListId: $cueListId) }`, mutation),
+			map[string]interface{}{"cueListId": cueListID}, nil))
+		time.Sleep(time.Second)
+	}
+	defer func() {
+		_ = s.client.Mutate(ctx, `mutation StopCueList($cueListId: ID!) { stopCueList(cueListId: $cueListId) }`,
+			map[string]interface{}{"cueListId": cueListID}, nil)
+	}()
+
+	s.set(t, 3, 180)
+	cueList("startCueList")
+	s.expect(t, []int{200, 255, 0, 180}, "Cue A should play under the override")
+	cueList("nextCue")
+	s.expect(t, []int{100, 0, 255, 180}, "The override should persist into cue B")
+
+	s.clear(t)
+	s.expect(t, programmerLookB, "Clearing the programmer should reveal cue B")
+	cueList("previousCue")
+	s.expect(t, programmerLookA, "Cues after a clear should play unmodified")
+}
+
+// TestProgrammerWithEffect runs a dimmer effect on the par while its dimmer
+// is overridden. Either may win, but the output must follow one consistently
+// and the effect must not outlive the clear or the override its stop.
+func TestProgrammerWithEffect(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	s := newProgrammerSetup(t, ctx)
+
+	effectID, err := fixtures.CreateWaveformEffect(ctx, s.client, s.projectID, "Programmer Sine", "SINE", 1.0, []string{s.fixtureID})
+	require.NoError(t, err)
+	defer func() {
+		_ = s.client.Mutate(ctx, `mutation StopEffect($effectId: ID!) { stopEffect(effectId: $effectId, fadeTime: 0) }`,
+			map[string]interface{}{"effectId": effectID}, nil)
+	}()
+
+	s.setLookLive(t, s.lookA)
+	s.set(t, 0, 30)
+	require.NoError(t, s.client.Mutate(ctx, `mutation ActivateEffect($effectId: ID!) { activateEffect(effectId: $effectId, fadeTime: 0) }`,
+		map[string]interface{}{"effectId": effectID}, nil))
+	time.Sleep(300 * time.Millisecond)
+
+	// sampleDimmer polls the dimmer for a full period of the 1Hz sine
+	sampleDimmer := func() (lo, hi int) {
+		lo, hi = 255, 0
+		for i := 0; i < 12; i++ {
+			v := s.output(t)[0]
+			lo, hi = min(lo, v), max(hi, v)
+			time.Sleep(100 * time.Millisecond)
+		}
+		return lo, hi
+	}
+
+	lo, hi := sampleDimmer()
+	overrideWins := lo == hi
+	if overrideWins {
+		assert.Equal(t, 30, lo, "A held override should output its own value")
+		t.Log("Contract: the programmer holds its channel over running effects")
+	} else {
+		assert.Greater(t, hi-lo, 50, "An effect running over the override should swing the dimmer")
+		t.Logf("Contract: effects run over programmer values (dimmer %d-%d)", lo, hi)
+	}
+	assert.Equal(t, []int{255, 0, 0}, s.output(t)[1:], "Channels the effect does not drive should keep the look")
+
+	t.Run("StopEffect", func(t *testing.T) {
+		require.NoError(t, s.client.Mutate(ctx, `mutation StopEffect($effectId: ID!) { stopEffect(effectId: $effectId, fadeTime: 0) }`,
+			map[string]interface{}{"effectId": effectID}, nil))
+		time.Sleep(fixtures.Settle)
+		lo, hi := sampleDimmer()
+		assert.Equal(t, lo, hi, "The dimmer should settle once the effect stops")
+		assert.Equal(t, 30, lo, "The override should still hold after the effect stops")
+	})
+
+	t.Run("ClearUnderEffect", func(t *testing.T) {
+		require.NoError(t, s.client.Mutate(ctx, `mutation ActivateEffect($effectId: ID!) { activateEffect(effectId: $effectId, fadeTime: 0) }`,
+			map[string]interface{}{"effectId": effectID}, nil))
+		s.clear(t)
+		lo, hi := sampleDimmer()
+		assert.Greater(t, hi-lo, 50, "With the programmer cleared the effect should drive the dimmer")
+	})
+}
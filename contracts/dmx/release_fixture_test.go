@@ -0,0 +1,114 @@
+package dmx
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/fixtures"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// releaseFixtureContract is knocking one fixture out of live output without a blackout.
+const releaseFixtureContract = `releaseFixture(fixtureId: ID!): Boolean!
+  the fixture's channels return to their defaults while every other fixture holds;
+  the next look activation that sets the fixture includes it again`
+
+// releaseStarts are the start channels of the released par and its neighbour,
+// clear of the channels the other DMX tests write.
+var releaseStarts = []int{141, 145}
+
+// releaseDefaults are the par's channel defaults (fixtures.ParChannels).
+var releaseDefaults = []int{0, 0, 0, 0}
+
+type releaseSetup struct {
+	client     *graphql.Client
+	ctx        context.Context
+	fixtureIDs []string
+	lookID     string
+}
+
+// lookValues are the levels the look gives the released par and its neighbour.
+var lookValues = [][]int{{220, 255, 40, 0}, {90, 0, 128, 255}}
+
+func newReleaseSetup(t *testing.T, ctx context.Context) *releaseSetup {
+	skipDMXTests(t)
+	client := graphql.NewClient("")
+	compat.RequireMutation(t, client, "releaseFixture", releaseFixtureContract)
+
+	s := &releaseSetup{client: client, ctx: ctx}
+	project := fixtures.NewParProject(t, ctx, client, "Release Fixture")
+
+	var values []fixtures.FixtureValues
+	for i, start := range releaseStarts {
+		id := project.AddFixture(t, ctx, fixtures.Fixture{Name: fmt.Sprintf("Release Par %d", i+1), Universe: 1, StartChannel: start})
+		s.fixtureIDs = append(s.fixtureIDs, id)
+		values = append(values, fixtures.FixtureValues{FixtureID: id, Values: lookValues[i]})
+	}
+	s.lookID = project.AddLook(t, ctx, "Release Look", values)
+	return s
+}
+
+func (s *releaseSetup) setLookLive(t *testing.T) {
+	fixtures.SetLookLive(t, s.ctx, s.client, s.lookID)
+}
+
+func (s *releaseSetup) release(t *testing.T, fixtureID string) bool {
+	var resp struct {
+		ReleaseFixture bool `json:"releaseFixture"`
+	}
+	require.NoError(t, s.client.Mutate(s.ctx, `mutation Release($fixtureId: ID!) { releaseFixture(fixtureId: $fixtureId) }`,
+		map[string]interface{}{"fixtureId": fixtureID}, &resp))
+	time.Sleep(fixtures.Settle)
+	return resp.ReleaseFixture
+}
+
+// expect asserts the four channels of both pars.
+func (s *releaseSetup) expect(t *testing.T, released, neighbour []int, msg string) {
+	t.Helper()
+	var resp struct {
+		DMXOutput []int `json:"dmxOutput"`
+	}
+	require.NoError(t, s.client.Query(s.ctx, `query { dmxOutput(universe: 1) }`, nil, &resp))
+	require.Len(t, resp.DMXOutput, 512, "%s: dmxOutput should cover the universe", msg)
+	assert.Equal(t, released, resp.DMXOutput[releaseStarts[0]-1:releaseStarts[0]+3], "%s: released par", msg)
+	assert.Equal(t, neighbour, resp.DMXOutput[releaseStarts[1]-1:releaseStarts[1]+3], "%s: neighbouring par", msg)
+}
+
+// TestReleaseFixture knocks one par out of a live look and checks only its
+// channels drop to their defaults, then that activating the look brings it back.
+func TestReleaseFixture(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	s := newReleaseSetup(t, ctx)
+
+	s.setLookLive(t)
+	s.expect(t, lookValues[0], lookValues[1], "Look before release")
+
+	require.True(t, s.release(t, s.fixtureIDs[0]), "releaseFixture should succeed")
+	s.expect(t, releaseDefaults, lookValues[1], "Only the released par should return to its defaults")
+
+	// The release holds; nothing restores the fixture on its own
+	time.Sleep(time.Second)
+	s.expect(t, releaseDefaults, lookValues[1], "The release should hold")
+
+	s.setLookLive(t)
+	s.expect(t, lookValues[0], lookValues[1], "Activating the look should include the released par again")
+
+	t.Run("ReleaseTwice", func(t *testing.T) {
+		s.release(t, s.fixtureIDs[0])
+		released := s.release(t, s.fixtureIDs[0])
+		s.expect(t, releaseDefaults, lookValues[1], "Releasing a released par should change nothing")
+		t.Logf("Contract: releaseFixture on an already released fixture returned %v", released)
+	})
+
+	t.Run("UnknownFixture", func(t *testing.T) {
+		err := s.client.Mutate(ctx, `mutation Release($fixtureId: ID!) { releaseFixture(fixtureId: $fixtureId) }`,
+			map[string]interface{}{"fixtureId": "non-existent-fixture-id"}, nil)
+		assert.Error(t, err, "Releasing an unknown fixture should be an error")
+	})
+}
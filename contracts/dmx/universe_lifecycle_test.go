@@ -0,0 +1,196 @@
+package dmx
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// runtimeUniverse is a universe no other test patches into
+	runtimeUniverse = 8
+
+	// universeStartBound is how soon a newly patched universe must appear on Art-Net
+	universeStartBound = 2 * time.Second
+
+	// universeStopSettle is how long the server gets to stop or zero a removed universe
+	universeStopSettle = 2 * time.Second
+
+	runtimeUniverseValue = 200
+)
+
+// universeFrames returns the captured frames of a lacylights universe.
+func universeFrames(receiver *artnet.Subscription, universe int) []artnet.Frame {
+	var frames []artnet.Frame
+	for _, f := range receiver.GetFrames() {
+		if f.Universe == universe-1 {
+			frames = append(frames, f)
+		}
+	}
+	return frames
+}
+
+// TestUniverseAddRemoveAtRuntime patches a fixture into an unused universe and
+// checks Art-Net output for it starts within universeStartBound. Deleting the
+// fixture must then either stop the universe or send it as zeros.
+func TestUniverseAddRemoveAtRuntime(t *testing.T) {
+	skipDMXTests(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	receiver := artnet.Capture(t)
+
+	client := graphql.NewClient("")
+
+	time.Sleep(time.Second)
+	if len(receiver.GetFrames()) == 0 {
+		t.Skip("No Art-Net frames captured - Art-Net may not be enabled")
+	}
+	if before := universeFrames(receiver, runtimeUniverse); len(before) > 0 {
+		t.Logf("Contract: universe %d was already transmitted before anything was patched into it (%d frames)",
+			runtimeUniverse, len(before))
+	}
+
+	var projectResp struct {
+		CreateProject struct {
+			ID string `json:"id"`
+		} `json:"createProject"`
+	}
+	err := client.Mutate(ctx, `
+		mutation CreateProject($input: CreateProjectInput!) {
+			createProject(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{"name": "Runtime Universe Test Project"},
+	}, &projectResp)
+	require.NoError(t, err)
+	projectID := projectResp.CreateProject.ID
+
+	var defResp struct {
+		CreateFixtureDefinition struct {
+			ID string `json:"id"`
+		} `json:"createFixtureDefinition"`
+	}
+	err = client.Mutate(ctx, `
+		mutation CreateFixtureDefinition($input: CreateFixtureDefinitionInput!) {
+			createFixtureDefinition(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"manufacturer": "Test Universe",
+			"model":        fmt.Sprintf("Universe Dimmer %d", time.Now().UnixNano()),
+			"type":         "DIMMER",
+			"channels": []map[string]interface{}{
+				{"name": "Intensity", "type": "INTENSITY", "offset": 0, "defaultValue": 0, "minValue": 0, "maxValue": 255},
+			},
+		},
+	}, &defResp)
+	require.NoError(t, err)
+	definitionID := defResp.CreateFixtureDefinition.ID
+
+	defer func() {
+		cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cleanupCancel()
+		_ = client.Mutate(cleanupCtx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+		_ = client.Mutate(cleanupCtx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
+			map[string]interface{}{"id": projectID}, nil)
+		_ = client.Mutate(cleanupCtx, `mutation DeleteFixtureDefinition($id: ID!) { deleteFixtureDefinition(id: $id) }`,
+			map[string]interface{}{"id": definitionID}, nil)
+	}()
+
+	var fixtureResp struct {
+		CreateFixtureInstance struct {
+			ID string `json:"id"`
+		} `json:"createFixtureInstance"`
+	}
+	err = client.Mutate(ctx, `
+		mutation CreateFixtureInstance($input: CreateFixtureInstanceInput!) {
+			createFixtureInstance(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId":    projectID,
+			"definitionId": definitionID,
+			"name":         "Universe 8 Dimmer",
+			"universe":     runtimeUniverse,
+			"startChannel": 1,
+		},
+	}, &fixtureResp)
+	if err != nil {
+		t.Skipf("Contract: universe %d is rejected for patching: %v", runtimeUniverse, err)
+	}
+	fixtureID := fixtureResp.CreateFixtureInstance.ID
+
+	var lookResp struct {
+		CreateLook struct {
+			ID string `json:"id"`
+		} `json:"createLook"`
+	}
+	err = client.Mutate(ctx, `
+		mutation CreateLook($input: CreateLookInput!) {
+			createLook(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId": projectID,
+			"name":      "Universe 8 Look",
+			"fixtureValues": []map[string]interface{}{
+				{"fixtureId": fixtureID, "channels": []map[string]int{{"offset": 0, "value": runtimeUniverseValue}}},
+			},
+		},
+	}, &lookResp)
+	require.NoError(t, err)
+
+	t.Run("PatchStartsEmission", func(t *testing.T) {
+		receiver.ClearFrames()
+		err := client.Mutate(ctx, `mutation SetLookLive($lookId: ID!) { setLookLive(lookId: $lookId) }`,
+			map[string]interface{}{"lookId": lookResp.CreateLook.ID}, nil)
+		require.NoError(t, err)
+		activated := time.Now()
+
+		// A frame can arrive before the mutation's response does; that counts as no delay
+		var seen time.Duration
+		found := false
+		for !found && time.Since(activated) < universeStartBound {
+			time.Sleep(50 * time.Millisecond)
+			for _, f := range universeFrames(receiver, runtimeUniverse) {
+				if f.Channels[0] == runtimeUniverseValue {
+					seen = max(f.Timestamp.Sub(activated), 0)
+					found = true
+					break
+				}
+			}
+		}
+		require.True(t, found, "Universe %d should be on Art-Net with the look's value within %v",
+			runtimeUniverse, universeStartBound)
+		t.Logf("Universe %d appeared %v after activation", runtimeUniverse, seen)
+	})
+
+	t.Run("RemovingLastFixtureStopsOrZeros", func(t *testing.T) {
+		err := client.Mutate(ctx, `mutation DeleteFixtureInstance($id: ID!) { deleteFixtureInstance(id: $id) }`,
+			map[string]interface{}{"id": fixtureID}, nil)
+		require.NoError(t, err)
+
+		time.Sleep(universeStopSettle)
+		receiver.ClearFrames()
+		time.Sleep(1500 * time.Millisecond)
+
+		frames := universeFrames(receiver, runtimeUniverse)
+		if len(frames) == 0 {
+			t.Logf("Contract: universe %d stops being transmitted once its last fixture is removed", runtimeUniverse)
+			return
+		}
+		t.Logf("Contract: universe %d keeps being transmitted after its last fixture is removed (%d frames)",
+			runtimeUniverse, len(frames))
+		for _, f := range frames {
+			assert.Equal(t, byte(0), f.Channels[0], "A universe with no fixtures should be sent as zeros")
+		}
+	})
+}
@@ -0,0 +1,158 @@
+package effects
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/bbernstein/lacylights-test/pkg/calibration"
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tempoContract is the API shape the beat-sync tests expect.
+const tempoContract = `setTempo(bpm: Float!): TempoState!, tapTempo: TempoState!, tempo: TempoState!
+  TempoState { bpm: Float!, beatPeriodMs: Float! }
+  CreateEffectInput.tempoSync: Boolean, CreateEffectInput.beatDivision: Float (cycles per beat, default 1)`
+
+// setTempo sets the global tempo and returns the beat period the server reports.
+func (s *effectTestSetup) setTempo(t *testing.T, bpm float64) time.Duration {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var resp struct {
+		SetTempo struct {
+			BPM          float64 `json:"bpm"`
+			BeatPeriodMs float64 `json:"beatPeriodMs"`
+		} `json:"setTempo"`
+	}
+	err := s.client.Mutate(ctx, `
+		mutation SetTempo($bpm: Float!) {
+			setTempo(bpm: $bpm) { bpm beatPeriodMs }
+		}
+	`, map[string]any{"bpm": bpm}, &resp)
+	require.NoError(t, err)
+	assert.InDelta(t, bpm, resp.SetTempo.BPM, 0.001)
+	assert.InDelta(t, 60000/bpm, resp.SetTempo.BeatPeriodMs, 0.01, "beatPeriodMs should be 60000/bpm")
+
+	return time.Duration(60 / bpm * float64(time.Second))
+}
+
+// risingEdgesAfter returns the rising edge times at or after from.
+func risingEdgesAfter(edges []levelEdge, from time.Time) []time.Time {
+	var out []time.Time
+	for _, e := range edges {
+		if e.Rising && !e.At.Before(from) {
+			out = append(out, e.At)
+		}
+	}
+	return out
+}
+
+// ============================================================================
+// Beat Sync Tests
+// ============================================================================
+
+// TestBeatSyncedEffect sets a tempo, runs a tempo-synced square wave at one cycle
+// per beat, and checks that rising edges land on the beat grid within one frame.
+// It then changes tempo mid-run and checks that the effect follows the new grid
+// without stalling, and that tap tempo derives the BPM from tap spacing.
+func TestBeatSyncedEffect(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping beat sync timing test in short mode")
+	}
+	compat.Require(t, compat.Effects, compat.ArtNet)
+
+	receiver := artnet.Capture(t)
+
+	setup := newEffectTestSetup(t)
+	defer setup.cleanup(t)
+
+	compat.RequireMutation(t, setup.client, "setTempo", tempoContract)
+	compat.RequireTypeField(t, setup.client, "CreateEffectInput", "tempoSync", tempoContract)
+	cal := calibration.Get(t)
+
+	tolerance := cal.FramePeriod() + cal.Duration(5*time.Millisecond)
+
+	lookID := setup.createLook(t, "Beat Base", []int{0, 0, 0, 0})
+	setup.activateLook(t, lookID, 0)
+	time.Sleep(100 * time.Millisecond)
+
+	beat := setup.setTempo(t, 120)
+
+	effectID := setup.createWaveformEffect(t, "beat", map[string]any{
+		"name":            "Beat Square",
+		"effectType":      "WAVEFORM",
+		"waveform":        "SQUARE",
+		"frequency":       1.0, // ignored while tempo-synced
+		"amplitude":       100.0,
+		"offset":          50.0,
+		"compositionMode": "OVERRIDE",
+		"tempoSync":       true,
+		"beatDivision":    1.0,
+	})
+
+	receiver.ClearFrames()
+	setup.activateEffect(t, effectID, 0)
+
+	// assertOnGrid checks consecutive rising edges are one beat apart with no drift
+	assertOnGrid := func(t *testing.T, edges []time.Time, beat time.Duration, label string) {
+		require.GreaterOrEqual(t, len(edges), 4, "%s: expected at least 4 beats, got %d", label, len(edges))
+		origin := edges[0]
+		for k, at := range edges {
+			expected := origin.Add(time.Duration(k) * beat)
+			assert.InDelta(t, 0, float64(at.Sub(expected)), float64(tolerance),
+				"%s: beat %d is off the %v grid by %v", label, k+1, beat, at.Sub(expected))
+		}
+	}
+
+	t.Run("120BPM", func(t *testing.T) {
+		time.Sleep(beat*9 + 100*time.Millisecond)
+		edges, _, _ := squareEdges(receiver.GetFrames())
+		rising := risingEdgesAfter(edges, time.Time{})
+		if len(rising) > 0 {
+			// The first edge may be the activation itself rather than a beat
+			rising = rising[1:]
+		}
+		assertOnGrid(t, rising, beat, "120 BPM")
+	})
+
+	t.Run("TempoChange", func(t *testing.T) {
+		changedAt := time.Now()
+		newBeat := setup.setTempo(t, 150)
+
+		receiver.ClearFrames()
+		time.Sleep(newBeat*9 + 100*time.Millisecond)
+
+		edges, _, _ := squareEdges(receiver.GetFrames())
+		rising := risingEdgesAfter(edges, changedAt)
+		require.NotEmpty(t, rising, "Effect stalled after the tempo change")
+		assert.LessOrEqual(t, rising[0].Sub(changedAt), beat+tolerance,
+			"First beat after a tempo change should arrive within one old beat")
+		assertOnGrid(t, rising[1:], newBeat, "150 BPM")
+	})
+
+	t.Run("TapTempo", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		compat.RequireMutation(t, setup.client, "tapTempo", tempoContract)
+
+		// Taps every 600ms = 100 BPM; API round trips add a few ms of jitter
+		var bpm float64
+		for range 5 {
+			var resp struct {
+				TapTempo struct {
+					BPM float64 `json:"bpm"`
+				} `json:"tapTempo"`
+			}
+			err := setup.client.Mutate(ctx, `mutation { tapTempo { bpm } }`, nil, &resp)
+			require.NoError(t, err)
+			bpm = resp.TapTempo.BPM
+			time.Sleep(600 * time.Millisecond)
+		}
+		assert.InDelta(t, 100, bpm, cal.Value(5), "Tap tempo should derive ~100 BPM from 600ms taps")
+	})
+}
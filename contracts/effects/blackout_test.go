@@ -0,0 +1,144 @@
+package effects
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ============================================================================
+// Effect and fadeToBlack Interaction Tests
+// ============================================================================
+
+// sampleDimmer reads channel 1 several times over the given window.
+func (s *effectTestSetup) sampleDimmer(t *testing.T, samples int, interval time.Duration) []int {
+	values := make([]int, 0, samples)
+	for range samples {
+		output := s.getDMXOutput(t)
+		require.Len(t, output, 512, "dmxOutput should cover the universe")
+		values = append(values, output[0])
+		time.Sleep(interval)
+	}
+	return values
+}
+
+// fadeToBlack runs a fadeToBlack and waits for it to finish.
+func (s *effectTestSetup) fadeToBlack(t *testing.T, fadeTime float64) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := s.client.Mutate(ctx, `
+		mutation FadeToBlack($fadeOutTime: Float!) {
+			fadeToBlack(fadeOutTime: $fadeOutTime)
+		}
+	`, map[string]any{"fadeOutTime": fadeTime}, nil)
+	require.NoError(t, err)
+	time.Sleep(time.Duration(fadeTime*float64(time.Second)) + 300*time.Millisecond)
+}
+
+// logPostBlackoutState reports whether the effect resumed once a look was brought back up.
+func logPostBlackoutState(t *testing.T, samples []int, baseline int) {
+	moving := false
+	for _, v := range samples[1:] {
+		if v != samples[0] {
+			moving = true
+		}
+	}
+	switch {
+	case moving:
+		t.Logf("Contract: effect survives fadeToBlack and resumes with the next look (samples %v)", samples)
+	case samples[0] != baseline:
+		t.Logf("Contract: effect survives fadeToBlack as a static modifier (samples %v, look %d)", samples, baseline)
+	default:
+		t.Logf("Contract: fadeToBlack stops running effects (samples %v)", samples)
+	}
+}
+
+// TestFadeToBlackWithMasterEffect verifies that fadeToBlack wins over an active
+// MASTER effect: the output goes fully dark and stays dark. Whether the master
+// is still applied once a new look goes live is recorded in the test log.
+func TestFadeToBlackWithMasterEffect(t *testing.T) {
+	compat.Require(t, compat.Effects, compat.ArtNet)
+
+	setup := newEffectTestSetup(t)
+	defer setup.cleanup(t)
+
+	lookID := setup.createLook(t, "Master Base", []int{200, 200, 200, 200})
+	setup.activateLook(t, lookID, 0)
+	time.Sleep(100 * time.Millisecond)
+
+	effectID := setup.createWaveformEffect(t, "master", map[string]any{
+		"name":        "Blackout Master",
+		"effectType":  "MASTER",
+		"masterValue": 0.5,
+	})
+	setup.activateEffect(t, effectID, 0)
+	time.Sleep(300 * time.Millisecond)
+
+	before := setup.getDMXOutput(t)
+	require.Len(t, before, 512, "dmxOutput should cover the universe")
+	t.Logf("Dimmer with master active: %d", before[0])
+	assert.Less(t, before[0], 200, "MASTER at 0.5 should scale the dimmer down before blackout")
+
+	setup.fadeToBlack(t, 0.5)
+
+	samples := setup.sampleDimmer(t, 10, 50*time.Millisecond)
+	for i, v := range samples {
+		assert.Equal(t, 0, v, "Dimmer should stay at 0 after fadeToBlack with a master active (sample %d)", i)
+	}
+	output := setup.getDMXOutput(t)
+	require.Len(t, output, 512, "dmxOutput should cover the universe")
+	for ch := 0; ch < 4; ch++ {
+		assert.Equal(t, 0, output[ch], "Channel %d should be dark after fadeToBlack", ch+1)
+	}
+
+	setup.activateLook(t, lookID, 0)
+	time.Sleep(300 * time.Millisecond)
+	logPostBlackoutState(t, setup.sampleDimmer(t, 5, 50*time.Millisecond), 200)
+}
+
+// TestFadeToBlackWithUserWaveform verifies that fadeToBlack silences an active
+// USER-priority additive waveform. An additive effect that kept modulating on top
+// of the blacked-out base would leak light, so every sample must read 0.
+func TestFadeToBlackWithUserWaveform(t *testing.T) {
+	compat.Require(t, compat.Effects, compat.ArtNet)
+
+	setup := newEffectTestSetup(t)
+	defer setup.cleanup(t)
+
+	lookID := setup.createLook(t, "Waveform Base", []int{128, 128, 128, 128})
+	setup.activateLook(t, lookID, 0)
+	time.Sleep(100 * time.Millisecond)
+
+	effectID := setup.createWaveformEffect(t, "user_wave", map[string]any{
+		"name":            "Blackout Waveform",
+		"effectType":      "WAVEFORM",
+		"priorityBand":    "USER",
+		"waveform":        "SINE",
+		"frequency":       2.0,
+		"amplitude":       50.0,
+		"offset":          50.0,
+		"compositionMode": "ADDITIVE",
+	})
+	setup.activateEffect(t, effectID, 0)
+	time.Sleep(500 * time.Millisecond)
+
+	running := setup.sampleDimmer(t, 5, 60*time.Millisecond)
+	t.Logf("Dimmer with waveform running: %v", running)
+
+	setup.fadeToBlack(t, 0.5)
+
+	// Cover a full 2 Hz period so any surviving modulation would show up
+	samples := setup.sampleDimmer(t, 12, 50*time.Millisecond)
+	for i, v := range samples {
+		assert.Equal(t, 0, v, "Waveform should not modulate light back in after fadeToBlack (sample %d)", i)
+	}
+
+	setup.activateLook(t, lookID, 0)
+	time.Sleep(300 * time.Millisecond)
+	logPostBlackoutState(t, setup.sampleDimmer(t, 8, 60*time.Millisecond), 128)
+}
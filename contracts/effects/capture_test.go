@@ -0,0 +1,185 @@
+package effects
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/dmxassert"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ============================================================================
+// Live Capture Tests
+// ============================================================================
+
+// TestCaptureLiveOutputAsLook verifies that capturing the live DMX state freezes
+// whatever is on stage, including effect contributions, into a new static look.
+// Replaying that look with everything else stopped must reproduce the frozen values.
+func TestCaptureLiveOutputAsLook(t *testing.T) {
+	compat.Require(t, compat.Effects, compat.ArtNet)
+
+	setup := newEffectTestSetup(t)
+	defer setup.cleanup(t)
+
+	compat.RequireMutation(t, setup.client, "captureCurrentStateAsLook",
+		"captureCurrentStateAsLook(projectId: ID!, name: String!): Look!")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	// Base look with distinct values so a mis-mapped capture is obvious
+	baseLookID := setup.createLook(t, "Capture Base", []int{128, 200, 100, 50})
+	setup.activateLook(t, baseLookID, 0)
+	time.Sleep(100 * time.Millisecond)
+
+	// Slow sine on the dimmer so the captured value sits somewhere off the base value
+	var effectResp struct {
+		CreateEffect struct {
+			ID string `json:"id"`
+		} `json:"createEffect"`
+	}
+	err := setup.client.Mutate(ctx, `
+		mutation CreateEffect($input: CreateEffectInput!) {
+			createEffect(input: $input) { id }
+		}
+	`, map[string]any{
+		"input": map[string]any{
+			"projectId":       setup.projectID,
+			"name":            "Capture Effect",
+			"effectType":      "WAVEFORM",
+			"waveform":        "SINE",
+			"frequency":       0.25,
+			"amplitude":       50.0,
+			"offset":          50.0,
+			"compositionMode": "ADDITIVE",
+		},
+	}, &effectResp)
+	require.NoError(t, err)
+	effectID := effectResp.CreateEffect.ID
+	setup.effects["capture"] = effectID
+
+	var efResp struct {
+		AddFixtureToEffect struct {
+			ID string `json:"id"`
+		} `json:"addFixtureToEffect"`
+	}
+	err = setup.client.Mutate(ctx, `
+		mutation AddFixture($input: AddFixtureToEffectInput!) {
+			addFixtureToEffect(input: $input) { id }
+		}
+	`, map[string]any{
+		"input": map[string]any{
+			"effectId":  effectID,
+			"fixtureId": setup.fixtureID,
+		},
+	}, &efResp)
+	require.NoError(t, err)
+
+	err = setup.client.Mutate(ctx, `
+		mutation AddChannel($effectFixtureId: ID!, $input: EffectChannelInput!) {
+			addChannelToEffectFixture(effectFixtureId: $effectFixtureId, input: $input) { id }
+		}
+	`, map[string]any{
+		"effectFixtureId": efResp.AddFixtureToEffect.ID,
+		"input":           map[string]any{"channelOffset": 0},
+	}, nil)
+	require.NoError(t, err)
+
+	err = setup.client.Mutate(ctx, `
+		mutation ActivateEffect($effectId: ID!, $fadeTime: Float) {
+			activateEffect(effectId: $effectId, fadeTime: $fadeTime)
+		}
+	`, map[string]any{"effectId": effectID, "fadeTime": 0.0}, nil)
+	require.NoError(t, err)
+	time.Sleep(1 * time.Second)
+
+	// Capture
+	var captureResp struct {
+		CaptureCurrentStateAsLook struct {
+			ID            string `json:"id"`
+			FixtureValues []struct {
+				Fixture struct {
+					ID string `json:"id"`
+				} `json:"fixture"`
+				Channels []struct {
+					Offset int `json:"offset"`
+					Value  int `json:"value"`
+				} `json:"channels"`
+			} `json:"fixtureValues"`
+		} `json:"captureCurrentStateAsLook"`
+	}
+	err = setup.client.Mutate(ctx, `
+		mutation CaptureLook($projectId: ID!, $name: String!) {
+			captureCurrentStateAsLook(projectId: $projectId, name: $name) {
+				id
+				fixtureValues {
+					fixture { id }
+					channels { offset value }
+				}
+			}
+		}
+	`, map[string]any{
+		"projectId": setup.projectID,
+		"name":      "Captured Look",
+	}, &captureResp)
+	require.NoError(t, err)
+
+	captured := captureResp.CaptureCurrentStateAsLook
+	require.NotEmpty(t, captured.ID)
+
+	// Frozen values for fixture 1, indexed by offset
+	frozen := map[int]int{}
+	for _, fv := range captured.FixtureValues {
+		if fv.Fixture.ID != setup.fixtureID {
+			continue
+		}
+		for _, ch := range fv.Channels {
+			frozen[ch.Offset] = ch.Value
+		}
+	}
+	require.Len(t, frozen, 4, "Captured look should include every channel of the lit fixture")
+	t.Logf("Captured values: %v", frozen)
+
+	// Channels the effect does not touch must be captured exactly
+	assert.Equal(t, 200, frozen[1], "Red should be captured from the base look")
+	assert.Equal(t, 100, frozen[2], "Green should be captured from the base look")
+	assert.Equal(t, 50, frozen[3], "Blue should be captured from the base look")
+
+	// Stop everything and confirm the stage is dark
+	err = setup.client.Mutate(ctx, `
+		mutation StopEffect($effectId: ID!, $fadeTime: Float) {
+			stopEffect(effectId: $effectId, fadeTime: $fadeTime)
+		}
+	`, map[string]any{"effectId": effectID, "fadeTime": 0.0}, nil)
+	require.NoError(t, err)
+	err = setup.client.Mutate(ctx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+	require.NoError(t, err)
+	time.Sleep(200 * time.Millisecond)
+
+	dark := setup.getDMXOutput(t)
+	require.Len(t, dark, 512, "dmxOutput should cover the universe")
+	for i := 0; i < 4; i++ {
+		require.Equal(t, 0, dark[i], "Channel %d should be dark before replaying the capture", i+1)
+	}
+
+	// Replay the captured look; output must match the frozen snapshot, not the live effect
+	setup.activateLook(t, captured.ID, 0)
+	time.Sleep(200 * time.Millisecond)
+
+	output := setup.getDMXOutput(t)
+	require.Len(t, output, 512, "dmxOutput should cover the universe")
+	want := make([]int, len(frozen))
+	for offset, value := range frozen {
+		want[offset] = value
+	}
+	dmxassert.ExpectFrame(t, output, want, 0, dmxassert.Msg("Captured look should replay the frozen snapshot"))
+
+	// The snapshot is static: a second read some time later must not have moved
+	time.Sleep(500 * time.Millisecond)
+	later := setup.getDMXOutput(t)
+	require.Len(t, later, 512, "dmxOutput should cover the universe")
+	assert.Equal(t, output[:4], later[:4], "Captured look should not carry the effect's motion")
+}
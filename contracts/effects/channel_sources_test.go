@@ -0,0 +1,207 @@
+package effects
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// channelSourcesContract is the query shape this suite expects. It is reported
+// verbatim when the query is missing so the server side has a spec to build to.
+const channelSourcesContract = `channelSources(universe: Int!, channels: [Int!]): [ChannelSource!]!
+  ChannelSource { universe: Int!, channel: Int!, value: Int!, layers: [ChannelSourceLayer!]! }
+  ChannelSourceLayer { sourceType: LOOK|CUE|EFFECT|FADE|MANUAL, sourceId: ID, sourceName: String,
+                       priorityBand: String, value: Int!, contribution: Int! }
+  layers are ordered lowest to highest priority; value is the final output`
+
+type channelSourceLayer struct {
+	SourceType   string  `json:"sourceType"`
+	SourceID     *string `json:"sourceId"`
+	SourceName   *string `json:"sourceName"`
+	PriorityBand *string `json:"priorityBand"`
+	Value        int     `json:"value"`
+	Contribution int     `json:"contribution"`
+}
+
+type channelSource struct {
+	Universe int                  `json:"universe"`
+	Channel  int                  `json:"channel"`
+	Value    int                  `json:"value"`
+	Layers   []channelSourceLayer `json:"layers"`
+}
+
+// getChannelSources queries channelSources for the first fixture's four channels.
+func (s *effectTestSetup) getChannelSources(t *testing.T) map[int]channelSource {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var resp struct {
+		ChannelSources []channelSource `json:"channelSources"`
+	}
+	err := s.client.Query(ctx, `
+		query ChannelSources($universe: Int!, $channels: [Int!]) {
+			channelSources(universe: $universe, channels: $channels) {
+				universe
+				channel
+				value
+				layers {
+					sourceType
+					sourceId
+					sourceName
+					priorityBand
+					value
+					contribution
+				}
+			}
+		}
+	`, map[string]any{"universe": 1, "channels": []int{1, 2, 3, 4}}, &resp)
+	require.NoError(t, err)
+
+	byChannel := make(map[int]channelSource, len(resp.ChannelSources))
+	for _, cs := range resp.ChannelSources {
+		byChannel[cs.Channel] = cs
+	}
+	return byChannel
+}
+
+// findLayer returns the first layer of the given type and source, or nil.
+func findLayer(cs channelSource, sourceType, sourceID string) *channelSourceLayer {
+	for i, layer := range cs.Layers {
+		if layer.SourceType == sourceType && layer.SourceID != nil && *layer.SourceID == sourceID {
+			return &cs.Layers[i]
+		}
+	}
+	return nil
+}
+
+// ============================================================================
+// Channel Ownership Diagnostics Tests
+// ============================================================================
+
+// TestChannelSourcesDiagnostics verifies that channelSources explains who is
+// driving each channel: the live look alone, an effect layered over it, both
+// looks during a crossfade, and nothing after a blackout. The reported final
+// value must always agree with dmxOutput.
+func TestChannelSourcesDiagnostics(t *testing.T) {
+	// Gate on the query first, so a missing channelSources reports the contract
+	// (and fails under PENDING_CONTRACTS) even where effects or Art-Net are off
+	compat.RequireQuery(t, graphql.NewClient(""), "channelSources", channelSourcesContract)
+	compat.Require(t, compat.Effects, compat.ArtNet)
+
+	setup := newEffectTestSetup(t)
+	defer setup.cleanup(t)
+
+	lookA := setup.createLook(t, "Sources Look A", []int{100, 200, 50, 0})
+	lookB := setup.createLook(t, "Sources Look B", []int{200, 0, 150, 255})
+
+	// checkAgainstOutput asserts the diagnostic's final values match what is on the wire
+	checkAgainstOutput := func(t *testing.T, sources map[int]channelSource, tolerance int) {
+		output := setup.getDMXOutput(t)
+		require.Len(t, output, 512, "dmxOutput should cover the universe")
+		for ch := 1; ch <= 4; ch++ {
+			cs, ok := sources[ch]
+			require.True(t, ok, "channelSources should report channel %d", ch)
+			assert.Equal(t, 1, cs.Universe)
+			assert.InDelta(t, output[ch-1], cs.Value, float64(tolerance),
+				"channelSources value for channel %d should match dmxOutput", ch)
+		}
+	}
+
+	t.Run("StaticLook", func(t *testing.T) {
+		setup.activateLook(t, lookA, 0)
+		time.Sleep(200 * time.Millisecond)
+
+		sources := setup.getChannelSources(t)
+		checkAgainstOutput(t, sources, 0)
+
+		for ch := 1; ch <= 3; ch++ {
+			layer := findLayer(sources[ch], "LOOK", lookA)
+			require.NotNil(t, layer, "Channel %d should be attributed to look A, got %+v", ch, sources[ch].Layers)
+			assert.Equal(t, sources[ch].Value, layer.Contribution, "Look A is the only source on channel %d", ch)
+		}
+	})
+
+	t.Run("EffectOverLook", func(t *testing.T) {
+		effectID := setup.createWaveformEffect(t, "sources", map[string]any{
+			"name":            "Sources Waveform",
+			"effectType":      "WAVEFORM",
+			"waveform":        "SINE",
+			"frequency":       0.5,
+			"amplitude":       40.0,
+			"offset":          50.0,
+			"compositionMode": "ADDITIVE",
+		})
+		setup.activateEffect(t, effectID, 0)
+		time.Sleep(500 * time.Millisecond)
+
+		sources := setup.getChannelSources(t)
+		// The effect keeps moving between the two reads
+		checkAgainstOutput(t, sources, 15)
+
+		dimmer := sources[1]
+		assert.NotNil(t, findLayer(dimmer, "LOOK", lookA), "Dimmer should still list look A underneath the effect")
+		effectLayer := findLayer(dimmer, "EFFECT", effectID)
+		require.NotNil(t, effectLayer, "Dimmer should list the active effect, got %+v", dimmer.Layers)
+		assert.Equal(t, "EFFECT", dimmer.Layers[len(dimmer.Layers)-1].SourceType,
+			"The effect is the highest layer on the dimmer")
+
+		// The effect only targets the dimmer
+		for ch := 2; ch <= 4; ch++ {
+			assert.Nil(t, findLayer(sources[ch], "EFFECT", effectID),
+				"Channel %d is not in the effect and must not be attributed to it", ch)
+		}
+
+		err := setup.client.Mutate(context.Background(), `
+			mutation StopEffect($effectId: ID!, $fadeTime: Float) {
+				stopEffect(effectId: $effectId, fadeTime: $fadeTime)
+			}
+		`, map[string]any{"effectId": effectID, "fadeTime": 0.0}, nil)
+		require.NoError(t, err)
+		time.Sleep(200 * time.Millisecond)
+
+		sources = setup.getChannelSources(t)
+		assert.Nil(t, findLayer(sources[1], "EFFECT", effectID), "A stopped effect must drop out of the diagnostics")
+	})
+
+	t.Run("Crossfade", func(t *testing.T) {
+		setup.activateLook(t, lookB, 2.0)
+		time.Sleep(1 * time.Second)
+
+		sources := setup.getChannelSources(t)
+		checkAgainstOutput(t, sources, 20)
+
+		// Mid-fade, channel 2 (200 -> 0) is owned by the fade toward look B
+		red := sources[2]
+		t.Logf("Mid-fade layers on channel 2: %+v", red.Layers)
+		assert.True(t, findLayer(red, "LOOK", lookB) != nil || findLayer(red, "FADE", lookB) != nil,
+			"Channel 2 should be attributed to the fade toward look B")
+		assert.Greater(t, red.Value, 0, "Channel 2 should be mid-fade")
+		assert.Less(t, red.Value, 200, "Channel 2 should be mid-fade")
+
+		time.Sleep(1500 * time.Millisecond)
+		sources = setup.getChannelSources(t)
+		checkAgainstOutput(t, sources, 0)
+		for ch := 1; ch <= 4; ch++ {
+			assert.Nil(t, findLayer(sources[ch], "LOOK", lookA), "Look A must not linger on channel %d after the fade", ch)
+		}
+	})
+
+	t.Run("Blackout", func(t *testing.T) {
+		err := setup.client.Mutate(context.Background(), `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+		require.NoError(t, err)
+		time.Sleep(200 * time.Millisecond)
+
+		sources := setup.getChannelSources(t)
+		for ch := 1; ch <= 4; ch++ {
+			assert.Equal(t, 0, sources[ch].Value, "Channel %d should be dark", ch)
+			for _, layer := range sources[ch].Layers {
+				assert.Zero(t, layer.Contribution, "Nothing should contribute to channel %d after blackout: %+v", ch, layer)
+			}
+		}
+	})
+}
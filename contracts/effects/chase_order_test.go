@@ -0,0 +1,150 @@
+package effects
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/bbernstein/lacylights-test/pkg/calibration"
+	"github.com/bbernstein/lacylights-test/pkg/chaseassert"
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// chaseFixtureCount is the number of fixtures the chase steps through
+	chaseFixtureCount = 4
+
+	// chaseStartChannel keeps the chase clear of the spread tests' fixtures
+	chaseStartChannel = 141
+
+	// chaseFrequency gives one full cycle per second, a step every 250 ms
+	chaseFrequency = 1.0
+
+	// chaseCapture covers more than two cycles so order and spacing repeat
+	chaseCapture = 2500 * time.Millisecond
+)
+
+// TestChaseFiresInPatchOrder builds a four-fixture square-wave chase with
+// phaseOffsets of 0, 90, 180 and 270 degrees in patch order. A larger offset
+// delays a fixture, so the dimmers must switch on in patch order, one after
+// another, at equal intervals of a quarter period. A mismatch prints the
+// activation timeline.
+func TestChaseFiresInPatchOrder(t *testing.T) {
+	compat.Require(t, compat.Effects, compat.ArtNet)
+	cal := calibration.Get(t)
+
+	setup := newEffectTestSetup(t)
+	defer setup.cleanup(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	receiver := artnet.Capture(t)
+
+	var effectResp struct {
+		CreateEffect struct {
+			ID string `json:"id"`
+		} `json:"createEffect"`
+	}
+	err := setup.client.Mutate(ctx, `
+		mutation CreateEffect($input: CreateEffectInput!) {
+			createEffect(input: $input) { id }
+		}
+	`, map[string]any{
+		"input": map[string]any{
+			"projectId":       setup.projectID,
+			"name":            "Patch Order Chase",
+			"effectType":      "WAVEFORM",
+			"waveform":        "SQUARE",
+			"frequency":       chaseFrequency,
+			"amplitude":       100.0,
+			"offset":          50.0,
+			"compositionMode": "OVERRIDE",
+		},
+	}, &effectResp)
+	require.NoError(t, err)
+	effectID := effectResp.CreateEffect.ID
+	setup.effects["chase"] = effectID
+
+	channels := make([]int, chaseFixtureCount)
+	order := make([]int, chaseFixtureCount)
+	for i := range channels {
+		channels[i] = chaseStartChannel + i*4
+		order[i] = i
+
+		var fixtureResp struct {
+			CreateFixtureInstance struct {
+				ID string `json:"id"`
+			} `json:"createFixtureInstance"`
+		}
+		err := setup.client.Mutate(ctx, `
+			mutation CreateFixtureInstance($input: CreateFixtureInstanceInput!) {
+				createFixtureInstance(input: $input) { id }
+			}
+		`, map[string]any{
+			"input": map[string]any{
+				"projectId":    setup.projectID,
+				"definitionId": setup.definitionID,
+				"name":         fmt.Sprintf("Chase Fixture %d", i+1),
+				"universe":     1,
+				"startChannel": channels[i],
+			},
+		}, &fixtureResp)
+		require.NoError(t, err)
+
+		var efResp struct {
+			AddFixtureToEffect struct {
+				ID string `json:"id"`
+			} `json:"addFixtureToEffect"`
+		}
+		err = setup.client.Mutate(ctx, `
+			mutation AddFixture($input: AddFixtureToEffectInput!) {
+				addFixtureToEffect(input: $input) { id }
+			}
+		`, map[string]any{
+			"input": map[string]any{
+				"effectId":    effectID,
+				"fixtureId":   fixtureResp.CreateFixtureInstance.ID,
+				"phaseOffset": float64(i) * 360 / chaseFixtureCount,
+				"effectOrder": i + 1,
+			},
+		}, &efResp)
+		require.NoError(t, err)
+
+		err = setup.client.Mutate(ctx, `
+			mutation AddChannel($effectFixtureId: ID!, $input: EffectChannelInput!) {
+				addChannelToEffectFixture(effectFixtureId: $effectFixtureId, input: $input) { id }
+			}
+		`, map[string]any{
+			"effectFixtureId": efResp.AddFixtureToEffect.ID,
+			"input":           map[string]any{"channelOffset": 0},
+		}, nil)
+		require.NoError(t, err)
+	}
+
+	setup.activateEffect(t, effectID, 0)
+	time.Sleep(300 * time.Millisecond)
+
+	receiver.ClearFrames()
+	time.Sleep(chaseCapture)
+	frames := receiver.GetFrames()
+	if len(frames) == 0 {
+		t.Skip("No Art-Net frames captured - Art-Net may not be enabled")
+	}
+
+	acts := chaseassert.Activations(frames, 0, channels, 128)
+	step := time.Duration(float64(time.Second) / chaseFrequency / chaseFixtureCount)
+	t.Logf("%d activations from %d frames, median spacing %v (want %v)",
+		len(acts), len(frames), chaseassert.MedianDelay(acts), step)
+
+	// Two frames of jitter at the measured refresh rate
+	tolerance := 2 * cal.FramePeriod()
+	if chaseassert.ExpectChaseFrames(t, frames, 0, channels, 128, order, tolerance) {
+		median := chaseassert.MedianDelay(acts)
+		require.InDelta(t, step.Seconds(), median.Seconds(), tolerance.Seconds(),
+			"Fixtures should fire a quarter period apart")
+	}
+}
@@ -0,0 +1,154 @@
+package effects
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// deletedEffectFixtureContract is how deleting a fixture under a running effect should behave.
+const deletedEffectFixtureContract = `deleteFixtureInstance on a fixture attached to a running effect
+  either succeeds, removing the fixture from the effect, or fails with a GraphQL error (never a 5xx);
+  after a delete the effect keeps running on its remaining fixtures or stops cleanly,
+  and the deleted fixture's channels drop to 0 instead of holding the last effect value`
+
+// sampleChannels reads universe 1 every interval and returns each channel's values.
+func (s *effectTestSetup) sampleChannels(t *testing.T, channels []int, samples int, interval time.Duration) map[int][]int {
+	values := make(map[int][]int, len(channels))
+	for i := 0; i < samples; i++ {
+		output := s.getDMXOutput(t)
+		for _, ch := range channels {
+			values[ch] = append(values[ch], output[ch-1])
+		}
+		time.Sleep(interval)
+	}
+	return values
+}
+
+func varies(values []int) bool {
+	return slices.Min(values) != slices.Max(values)
+}
+
+// requireNotServerError fails the test if err is a server fault rather than a
+// GraphQL error the client can act on.
+func requireNotServerError(t *testing.T, err error, action string) {
+	t.Helper()
+	if err == nil {
+		return
+	}
+	require.Less(t, graphql.HTTPStatus(err), 500, "%s should not fail with a server error: %v", action, err)
+	require.NotContains(t, graphql.ErrorCodes(err), "INTERNAL_SERVER_ERROR", "%s should fail with a user-facing error: %v", action, err)
+	require.NotEmpty(t, graphql.ResponseErrors(err), "%s failed without a GraphQL error: %v", action, err)
+}
+
+// TestEffectFixtureDeletedWhileRunning deletes one of two fixtures a running
+// waveform effect drives. The server must not fault, and the deleted fixture's
+// channels must not be left frozen mid-waveform.
+func TestEffectFixtureDeletedWhileRunning(t *testing.T) {
+	setup := newEffectTestSetup(t)
+	defer setup.cleanup(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	effectID := setup.createWaveformEffect(t, "doomed", map[string]any{
+		"name":            "Deleted Fixture Sine",
+		"effectType":      "WAVEFORM",
+		"waveform":        "SINE",
+		"frequency":       2.0,
+		"amplitude":       100.0,
+		"offset":          50.0,
+		"compositionMode": "OVERRIDE",
+	})
+
+	// Also drive the second fixture's dimmer, channel 5
+	var efResp struct {
+		AddFixtureToEffect struct {
+			ID string `json:"id"`
+		} `json:"addFixtureToEffect"`
+	}
+	err := setup.client.Mutate(ctx, `
+		mutation AddFixture($input: AddFixtureToEffectInput!) {
+			addFixtureToEffect(input: $input) { id }
+		}
+	`, map[string]any{
+		"input": map[string]any{"effectId": effectID, "fixtureId": setup.fixtureID2},
+	}, &efResp)
+	require.NoError(t, err)
+	err = setup.client.Mutate(ctx, `
+		mutation AddChannel($effectFixtureId: ID!, $input: EffectChannelInput!) {
+			addChannelToEffectFixture(effectFixtureId: $effectFixtureId, input: $input) { id }
+		}
+	`, map[string]any{
+		"effectFixtureId": efResp.AddFixtureToEffect.ID,
+		"input":           map[string]any{"channelOffset": 0},
+	}, nil)
+	require.NoError(t, err)
+
+	setup.activateEffect(t, effectID, 0)
+	time.Sleep(200 * time.Millisecond)
+
+	before := setup.sampleChannels(t, []int{1, 5}, 10, 50*time.Millisecond)
+	require.True(t, varies(before[1]), "The effect should be moving fixture 1 before the delete: %v", before[1])
+	require.True(t, varies(before[5]), "The effect should be moving fixture 2 before the delete: %v", before[5])
+
+	err = setup.client.Mutate(ctx, `mutation DeleteFixtureInstance($id: ID!) { deleteFixtureInstance(id: $id) }`,
+		map[string]any{"id": setup.fixtureID2}, nil)
+	requireNotServerError(t, err, "Deleting a fixture under a running effect")
+	if err != nil {
+		t.Logf("Contract: deleting a fixture used by a running effect is refused: %v", err)
+		after := setup.sampleChannels(t, []int{1, 5}, 10, 50*time.Millisecond)
+		assert.True(t, varies(after[1]), "A refused delete should leave the effect running")
+		assert.True(t, varies(after[5]), "A refused delete should leave the effect running")
+		return
+	}
+
+	t.Run("FixtureLeavesEffect", func(t *testing.T) {
+		var resp struct {
+			Effect struct {
+				Fixtures []struct {
+					FixtureID string `json:"fixtureId"`
+				} `json:"fixtures"`
+			} `json:"effect"`
+		}
+		err := setup.client.Query(ctx, `
+			query GetEffect($id: ID!) {
+				effect(id: $id) { fixtures { fixtureId } }
+			}
+		`, map[string]any{"id": effectID}, &resp)
+		require.NoError(t, err)
+		for _, f := range resp.Effect.Fixtures {
+			assert.NotEqual(t, setup.fixtureID2, f.FixtureID, "The deleted fixture should no longer be in the effect")
+		}
+	})
+
+	t.Run("OutputAfterDelete", func(t *testing.T) {
+		time.Sleep(300 * time.Millisecond)
+		after := setup.sampleChannels(t, []int{1, 5}, 10, 50*time.Millisecond)
+
+		assert.False(t, varies(after[5]), "Nothing should drive the deleted fixture's channel: %v", after[5])
+		assert.Equal(t, 0, after[5][len(after[5])-1], "The deleted fixture's channel should not hold the last effect value")
+
+		if varies(after[1]) {
+			t.Logf("Contract: the effect keeps running on its remaining fixture")
+		} else {
+			t.Logf("Contract: the effect stops when one of its fixtures is deleted (channel 1 at %d)", after[1][0])
+		}
+	})
+
+	t.Run("StopsCleanly", func(t *testing.T) {
+		err := setup.client.Mutate(ctx, `mutation StopEffect($id: ID!) { stopEffect(effectId: $id, fadeTime: 0) }`,
+			map[string]any{"id": effectID}, nil)
+		requireNotServerError(t, err, "Stopping the effect")
+		time.Sleep(200 * time.Millisecond)
+
+		after := setup.sampleChannels(t, []int{1, 5}, 6, 50*time.Millisecond)
+		assert.False(t, varies(after[1]), "Channel 1 should settle once the effect is stopped: %v", after[1])
+		assert.False(t, varies(after[5]), "Channel 5 should stay settled: %v", after[5])
+	})
+}
@@ -0,0 +1,293 @@
+package effects
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// effectLookupContract is the reverse lookups a rig change needs: which
+// effects touch a fixture, and where an effect is used.
+const effectLookupContract = `effectsForFixture(fixtureId: ID!): [Effect!]!
+  every effect with the fixture among its fixtures, whatever channels it drives
+effectUsage(effectId: ID!): EffectUsage!
+  EffectUsage { effectId: ID!, fixtureIds: [ID!]!, cueIds: [ID!]! }
+  both lookups follow addFixtureToEffect/removeFixtureFromEffect and addEffectToCue/removeEffectFromCue immediately`
+
+type effectUsage struct {
+	EffectID   string   `json:"effectId"`
+	FixtureIDs []string `json:"fixtureIds"`
+	CueIDs     []string `json:"cueIds"`
+}
+
+// effectsForFixture returns the IDs of the effects that reference the fixture.
+func (s *effectTestSetup) effectsForFixture(t *testing.T, fixtureID string) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var resp struct {
+		EffectsForFixture []struct {
+			ID string `json:"id"`
+		} `json:"effectsForFixture"`
+	}
+	err := s.client.Query(ctx, `
+		query EffectsForFixture($fixtureId: ID!) {
+			effectsForFixture(fixtureId: $fixtureId) { id }
+		}
+	`, map[string]any{"fixtureId": fixtureID}, &resp)
+	require.NoError(t, err)
+
+	ids := []string{}
+	for _, e := range resp.EffectsForFixture {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func (s *effectTestSetup) effectUsage(t *testing.T, effectID string) effectUsage {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var resp struct {
+		EffectUsage effectUsage `json:"effectUsage"`
+	}
+	err := s.client.Query(ctx, `
+		query EffectUsage($effectId: ID!) {
+			effectUsage(effectId: $effectId) { effectId fixtureIds cueIds }
+		}
+	`, map[string]any{"effectId": effectID}, &resp)
+	require.NoError(t, err)
+	assert.Equal(t, effectID, resp.EffectUsage.EffectID)
+	return resp.EffectUsage
+}
+
+// mutateEffect runs an effect/fixture or effect/cue association mutation.
+func (s *effectTestSetup) mutateEffect(t *testing.T, mutation string, variables map[string]any) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	require.NoError(t, s.client.Mutate(ctx, mutation, variables, nil))
+}
+
+func (s *effectTestSetup) addFixtureToEffect(t *testing.T, effectID, fixtureID string) {
+	s.mutateEffect(t, `
+		mutation AddFixture($input: AddFixtureToEffectInput!) {
+			addFixtureToEffect(input: $input) { id }
+		}
+	`, map[string]any{"input": map[string]any{"effectId": effectID, "fixtureId": fixtureID}})
+}
+
+func (s *effectTestSetup) removeFixtureFromEffect(t *testing.T, effectID, fixtureID string) {
+	s.mutateEffect(t, `
+		mutation RemoveFixture($effectId: ID!, $fixtureId: ID!) {
+			removeFixtureFromEffect(effectId: $effectId, fixtureId: $fixtureId)
+		}
+	`, map[string]any{"effectId": effectID, "fixtureId": fixtureID})
+}
+
+// cueEffectIDs returns the effect IDs the cue itself lists.
+func (s *effectTestSetup) cueEffectIDs(t *testing.T, cueID string) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var resp struct {
+		Cue struct {
+			Effects []struct {
+				EffectID string `json:"effectId"`
+			} `json:"effects"`
+		} `json:"cue"`
+	}
+	err := s.client.Query(ctx, `
+		query GetCue($id: ID!) {
+			cue(id: $id) { effects { effectId } }
+		}
+	`, map[string]any{"id": cueID}, &resp)
+	require.NoError(t, err)
+
+	ids := []string{}
+	for _, e := range resp.Cue.Effects {
+		ids = append(ids, e.EffectID)
+	}
+	return ids
+}
+
+// ============================================================================
+// Effect Reverse Lookup Tests
+// ============================================================================
+
+// TestEffectsForFixture attaches and detaches fixtures from two effects and
+// checks effectsForFixture after every change. A third effect without
+// fixtures must never be listed.
+func TestEffectsForFixture(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	setup := newEffectTestSetup(t)
+	defer setup.cleanup(t)
+
+	compat.RequireQuery(t, setup.client, "effectsForFixture", effectLookupContract)
+
+	// Both start on the first fixture's dimmer
+	effectA := setup.createWaveformEffect(t, "lookupA", map[string]any{
+		"name": "Lookup A", "effectType": "WAVEFORM", "waveform": "SINE", "frequency": 1.0,
+	})
+	effectB := setup.createWaveformEffect(t, "lookupB", map[string]any{
+		"name": "Lookup B", "effectType": "WAVEFORM", "waveform": "SQUARE", "frequency": 1.0,
+	})
+	var unattached struct {
+		CreateEffect struct {
+			ID string `json:"id"`
+		} `json:"createEffect"`
+	}
+	err := setup.client.Mutate(ctx, `
+		mutation CreateEffect($input: CreateEffectInput!) {
+			createEffect(input: $input) { id }
+		}
+	`, map[string]any{
+		"input": map[string]any{
+			"projectId":  setup.projectID,
+			"name":       "Lookup Unattached",
+			"effectType": "WAVEFORM",
+			"waveform":   "SINE",
+			"frequency":  1.0,
+		},
+	}, &unattached)
+	require.NoError(t, err)
+	setup.effects["lookupUnattached"] = unattached.CreateEffect.ID
+
+	assert.ElementsMatch(t, []string{effectA, effectB}, setup.effectsForFixture(t, setup.fixtureID))
+	assert.Empty(t, setup.effectsForFixture(t, setup.fixtureID2), "No effect references the second fixture yet")
+
+	t.Run("AddFixture", func(t *testing.T) {
+		// An effect fixture with no channels still references the fixture
+		setup.addFixtureToEffect(t, effectB, setup.fixtureID2)
+		assert.ElementsMatch(t, []string{effectA, effectB}, setup.effectsForFixture(t, setup.fixtureID))
+		assert.ElementsMatch(t, []string{effectB}, setup.effectsForFixture(t, setup.fixtureID2))
+	})
+
+	t.Run("RemoveFixture", func(t *testing.T) {
+		setup.removeFixtureFromEffect(t, effectB, setup.fixtureID)
+		assert.ElementsMatch(t, []string{effectA}, setup.effectsForFixture(t, setup.fixtureID),
+			"Effect B no longer references the first fixture")
+		assert.ElementsMatch(t, []string{effectB}, setup.effectsForFixture(t, setup.fixtureID2),
+			"Removing one fixture must not affect the effect's others")
+
+		setup.removeFixtureFromEffect(t, effectB, setup.fixtureID2)
+		assert.Empty(t, setup.effectsForFixture(t, setup.fixtureID2))
+	})
+
+	t.Run("DeleteEffect", func(t *testing.T) {
+		setup.mutateEffect(t, `mutation DeleteEffect($id: ID!) { deleteEffect(id: $id) }`,
+			map[string]any{"id": effectA})
+		delete(setup.effects, "lookupA")
+		assert.Empty(t, setup.effectsForFixture(t, setup.fixtureID), "A deleted effect must not be listed")
+	})
+
+	t.Run("UnknownFixture", func(t *testing.T) {
+		var resp struct {
+			EffectsForFixture []struct {
+				ID string `json:"id"`
+			} `json:"effectsForFixture"`
+		}
+		err := setup.client.Query(ctx, `
+			query EffectsForFixture($fixtureId: ID!) {
+				effectsForFixture(fixtureId: $fixtureId) { id }
+			}
+		`, map[string]any{"fixtureId": "non-existent-fixture-id"}, &resp)
+		if err != nil {
+			t.Logf("Contract: effectsForFixture rejects an unknown fixture: %v", err)
+		} else {
+			assert.Empty(t, resp.EffectsForFixture)
+			t.Logf("Contract: effectsForFixture returns an empty list for an unknown fixture")
+		}
+	})
+}
+
+// TestEffectUsage adds an effect to two cues and checks effectUsage against
+// each cue's own effects list as cues and fixtures are removed.
+func TestEffectUsage(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	setup := newEffectTestSetup(t)
+	defer setup.cleanup(t)
+
+	compat.RequireQuery(t, setup.client, "effectUsage", effectLookupContract)
+
+	lookID := setup.createLook(t, "Usage Look", []int{128, 128, 128, 128})
+	var cueIDs []string
+	for i, name := range []string{"Usage Cue 1", "Usage Cue 2"} {
+		var resp struct {
+			CreateCue struct {
+				ID string `json:"id"`
+			} `json:"createCue"`
+		}
+		err := setup.client.Mutate(ctx, `
+			mutation CreateCue($input: CreateCueInput!) {
+				createCue(input: $input) { id }
+			}
+		`, map[string]any{
+			"input": map[string]any{
+				"cueListId":   setup.cueListID,
+				"name":        name,
+				"cueNumber":   float64(i + 1),
+				"lookId":      lookID,
+				"fadeInTime":  0.0,
+				"fadeOutTime": 0.0,
+			},
+		}, &resp)
+		require.NoError(t, err)
+		cueIDs = append(cueIDs, resp.CreateCue.ID)
+	}
+
+	effectID := setup.createWaveformEffect(t, "usage", map[string]any{
+		"name": "Usage Effect", "effectType": "WAVEFORM", "waveform": "SINE", "frequency": 1.0,
+	})
+
+	usage := setup.effectUsage(t, effectID)
+	assert.ElementsMatch(t, []string{setup.fixtureID}, usage.FixtureIDs)
+	assert.Empty(t, usage.CueIDs, "The effect is not in any cue yet")
+
+	t.Run("AddToCues", func(t *testing.T) {
+		for _, cueID := range cueIDs {
+			setup.mutateEffect(t, `
+				mutation AddEffectToCue($input: AddEffectToCueInput!) {
+					addEffectToCue(input: $input) { id }
+				}
+			`, map[string]any{"input": map[string]any{"cueId": cueID, "effectId": effectID, "intensity": 100.0}})
+		}
+		assert.ElementsMatch(t, cueIDs, setup.effectUsage(t, effectID).CueIDs)
+		for _, cueID := range cueIDs {
+			assert.Equal(t, []string{effectID}, setup.cueEffectIDs(t, cueID), "The cue lists the effect as well")
+		}
+	})
+
+	t.Run("RemoveFromCue", func(t *testing.T) {
+		setup.mutateEffect(t, `
+			mutation RemoveEffectFromCue($cueId: ID!, $effectId: ID!) {
+				removeEffectFromCue(cueId: $cueId, effectId: $effectId)
+			}
+		`, map[string]any{"cueId": cueIDs[0], "effectId": effectID})
+		assert.ElementsMatch(t, cueIDs[1:], setup.effectUsage(t, effectID).CueIDs)
+		assert.Empty(t, setup.cueEffectIDs(t, cueIDs[0]))
+	})
+
+	t.Run("Fixtures", func(t *testing.T) {
+		setup.addFixtureToEffect(t, effectID, setup.fixtureID2)
+		assert.ElementsMatch(t, []string{setup.fixtureID, setup.fixtureID2}, setup.effectUsage(t, effectID).FixtureIDs)
+
+		setup.removeFixtureFromEffect(t, effectID, setup.fixtureID)
+		usage := setup.effectUsage(t, effectID)
+		assert.ElementsMatch(t, []string{setup.fixtureID2}, usage.FixtureIDs)
+		assert.ElementsMatch(t, cueIDs[1:], usage.CueIDs, "Fixture changes must not affect cue usage")
+	})
+
+	t.Run("DeleteCue", func(t *testing.T) {
+		setup.mutateEffect(t, `mutation DeleteCue($id: ID!) { deleteCue(id: $id) }`,
+			map[string]any{"id": cueIDs[1]})
+		assert.Empty(t, setup.effectUsage(t, effectID).CueIDs, "A deleted cue must not be listed")
+	})
+}
@@ -0,0 +1,311 @@
+package effects
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// spreadFixtureCount is the size of the fixture group the effect is spread across
+	spreadFixtureCount = 8
+
+	// spreadStartChannel keeps the group clear of the setup's two fixtures
+	spreadStartChannel = 101
+
+	// spreadMatchTolerance is the largest per-fixture difference between a spread
+	// frame and its closest explicit-offset frame, about one frame of sine slope
+	spreadMatchTolerance = 12
+
+	// groupSpreadContract is the API shape the spread tests expect
+	groupSpreadContract = `createFixtureGroup(input: { projectId, name, fixtureIds }): FixtureGroup { id fixtures { id } }
+  addFixtureGroupToEffect(input: { effectId, groupId, distribution: NONE|SPREAD, channelOffsets: [Int!]! }): Effect!
+  SPREAD assigns phaseOffset = i * 360 / groupSize in group order`
+)
+
+// effectPhaseOffsets returns the phaseOffset of each fixture in an effect, keyed by fixture ID.
+func (s *effectTestSetup) effectPhaseOffsets(t *testing.T, effectID string) map[string]float64 {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var resp struct {
+		Effect struct {
+			Fixtures []struct {
+				FixtureID   string  `json:"fixtureId"`
+				PhaseOffset float64 `json:"phaseOffset"`
+			} `json:"fixtures"`
+		} `json:"effect"`
+	}
+	err := s.client.Query(ctx, `
+		query GetEffectPhases($id: ID!) {
+			effect(id: $id) {
+				fixtures { fixtureId phaseOffset }
+			}
+		}
+	`, map[string]any{"id": effectID}, &resp)
+	require.NoError(t, err)
+
+	offsets := make(map[string]float64, len(resp.Effect.Fixtures))
+	for _, f := range resp.Effect.Fixtures {
+		offsets[f.FixtureID] = f.PhaseOffset
+	}
+	return offsets
+}
+
+// captureGroupDimmers runs an effect alone and returns the group's dimmer values for every frame.
+func (s *effectTestSetup) captureGroupDimmers(t *testing.T, receiver *artnet.Subscription, effectID string, window time.Duration) [][]int {
+	s.activateEffect(t, effectID, 0)
+	time.Sleep(300 * time.Millisecond)
+
+	receiver.ClearFrames()
+	time.Sleep(window)
+	frames := receiver.GetFrames()
+
+	err := s.client.Mutate(context.Background(), `
+		mutation StopEffect($effectId: ID!, $fadeTime: Float) {
+			stopEffect(effectId: $effectId, fadeTime: $fadeTime)
+		}
+	`, map[string]any{"effectId": effectID, "fadeTime": 0.0}, nil)
+	require.NoError(t, err)
+	time.Sleep(200 * time.Millisecond)
+
+	var vectors [][]int
+	for _, frame := range frames {
+		if frame.Universe != 0 {
+			continue
+		}
+		v := make([]int, spreadFixtureCount)
+		for i := range v {
+			v[i] = int(frame.Channels[spreadStartChannel-1+i*4])
+		}
+		vectors = append(vectors, v)
+	}
+	return vectors
+}
+
+// ============================================================================
+// Fixture Group Effect Distribution Tests
+// ============================================================================
+
+// TestGroupSpreadDistribution attaches a sine effect to an 8-fixture group with
+// SPREAD distribution and checks that each member gets an evenly spaced phase
+// offset (i * 45 degrees). The result is compared with the same effect built by
+// hand from explicit per-fixture phaseOffsets: both the stored offsets and every
+// captured Art-Net frame must match.
+func TestGroupSpreadDistribution(t *testing.T) {
+	compat.Require(t, compat.Effects, compat.ArtNet)
+
+	setup := newEffectTestSetup(t)
+	defer setup.cleanup(t)
+
+	compat.RequireMutation(t, setup.client, "addFixtureGroupToEffect", groupSpreadContract)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+	defer cancel()
+
+	// Eight 4-channel fixtures, in group order
+	fixtureIDs := make([]string, 0, spreadFixtureCount)
+	for i := 0; i < spreadFixtureCount; i++ {
+		var resp struct {
+			CreateFixtureInstance struct {
+				ID string `json:"id"`
+			} `json:"createFixtureInstance"`
+		}
+		err := setup.client.Mutate(ctx, `
+			mutation CreateFixtureInstance($input: CreateFixtureInstanceInput!) {
+				createFixtureInstance(input: $input) { id }
+			}
+		`, map[string]any{
+			"input": map[string]any{
+				"projectId":    setup.projectID,
+				"definitionId": setup.definitionID,
+				"name":         fmt.Sprintf("Spread Fixture %d", i+1),
+				"universe":     1,
+				"startChannel": spreadStartChannel + i*4,
+			},
+		}, &resp)
+		require.NoError(t, err)
+		fixtureIDs = append(fixtureIDs, resp.CreateFixtureInstance.ID)
+	}
+
+	var groupResp struct {
+		CreateFixtureGroup struct {
+			ID       string `json:"id"`
+			Fixtures []struct {
+				ID string `json:"id"`
+			} `json:"fixtures"`
+		} `json:"createFixtureGroup"`
+	}
+	err := setup.client.Mutate(ctx, `
+		mutation CreateFixtureGroup($input: CreateFixtureGroupInput!) {
+			createFixtureGroup(input: $input) {
+				id
+				fixtures { id }
+			}
+		}
+	`, map[string]any{
+		"input": map[string]any{
+			"projectId":  setup.projectID,
+			"name":       "Spread Group",
+			"fixtureIds": fixtureIDs,
+		},
+	}, &groupResp)
+	require.NoError(t, err)
+	require.Len(t, groupResp.CreateFixtureGroup.Fixtures, spreadFixtureCount)
+
+	sine := func(name string) map[string]any {
+		return map[string]any{
+			"name":            name,
+			"effectType":      "WAVEFORM",
+			"waveform":        "SINE",
+			"frequency":       0.5,
+			"amplitude":       100.0,
+			"offset":          50.0,
+			"compositionMode": "OVERRIDE",
+		}
+	}
+
+	// Effect A: SPREAD across the group
+	input := sine("Group Spread")
+	input["projectId"] = setup.projectID
+	var effectResp struct {
+		CreateEffect struct {
+			ID string `json:"id"`
+		} `json:"createEffect"`
+	}
+	err = setup.client.Mutate(ctx, `
+		mutation CreateEffect($input: CreateEffectInput!) {
+			createEffect(input: $input) { id }
+		}
+	`, map[string]any{"input": input}, &effectResp)
+	require.NoError(t, err)
+	spreadID := effectResp.CreateEffect.ID
+	setup.effects["spread"] = spreadID
+
+	err = setup.client.Mutate(ctx, `
+		mutation AddGroup($input: AddFixtureGroupToEffectInput!) {
+			addFixtureGroupToEffect(input: $input) { id }
+		}
+	`, map[string]any{
+		"input": map[string]any{
+			"effectId":       spreadID,
+			"groupId":        groupResp.CreateFixtureGroup.ID,
+			"distribution":   "SPREAD",
+			"channelOffsets": []int{0},
+		},
+	}, nil)
+	require.NoError(t, err)
+
+	// Effect B: the same thing by hand with explicit phase offsets
+	input = sine("Explicit Offsets")
+	input["projectId"] = setup.projectID
+	err = setup.client.Mutate(ctx, `
+		mutation CreateEffect($input: CreateEffectInput!) {
+			createEffect(input: $input) { id }
+		}
+	`, map[string]any{"input": input}, &effectResp)
+	require.NoError(t, err)
+	explicitID := effectResp.CreateEffect.ID
+	setup.effects["explicit"] = explicitID
+
+	for i, fixtureID := range fixtureIDs {
+		var efResp struct {
+			AddFixtureToEffect struct {
+				ID string `json:"id"`
+			} `json:"addFixtureToEffect"`
+		}
+		err = setup.client.Mutate(ctx, `
+			mutation AddFixture($input: AddFixtureToEffectInput!) {
+				addFixtureToEffect(input: $input) { id }
+			}
+		`, map[string]any{
+			"input": map[string]any{
+				"effectId":    explicitID,
+				"fixtureId":   fixtureID,
+				"phaseOffset": float64(i) * 360 / spreadFixtureCount,
+				"effectOrder": i + 1,
+			},
+		}, &efResp)
+		require.NoError(t, err)
+
+		err = setup.client.Mutate(ctx, `
+			mutation AddChannel($effectFixtureId: ID!, $input: EffectChannelInput!) {
+				addChannelToEffectFixture(effectFixtureId: $effectFixtureId, input: $input) { id }
+			}
+		`, map[string]any{
+			"effectFixtureId": efResp.AddFixtureToEffect.ID,
+			"input":           map[string]any{"channelOffset": 0},
+		}, nil)
+		require.NoError(t, err)
+	}
+
+	t.Run("PhaseOffsetsEvenlySpaced", func(t *testing.T) {
+		spread := setup.effectPhaseOffsets(t, spreadID)
+		explicit := setup.effectPhaseOffsets(t, explicitID)
+		require.Len(t, spread, spreadFixtureCount, "SPREAD should expand the group into one entry per fixture")
+
+		for i, fixtureID := range fixtureIDs {
+			want := float64(i) * 360 / spreadFixtureCount
+			assert.InDelta(t, want, spread[fixtureID], 0.01, "Fixture %d should get phase %v", i+1, want)
+			assert.InDelta(t, explicit[fixtureID], spread[fixtureID], 0.01,
+				"Fixture %d: SPREAD should match the explicit phaseOffset", i+1)
+		}
+
+		values := make([]float64, 0, len(spread))
+		for _, v := range spread {
+			values = append(values, v)
+		}
+		sort.Float64s(values)
+		for i := 1; i < len(values); i++ {
+			assert.InDelta(t, 360.0/spreadFixtureCount, values[i]-values[i-1], 0.01, "Offsets should be evenly spaced")
+		}
+	})
+
+	t.Run("OutputMatchesExplicitOffsets", func(t *testing.T) {
+		receiver := artnet.Capture(t)
+
+		// Slightly more than one 2 s period each, so every phase is visited
+		spreadFrames := setup.captureGroupDimmers(t, receiver, spreadID, 2200*time.Millisecond)
+		explicitFrames := setup.captureGroupDimmers(t, receiver, explicitID, 2200*time.Millisecond)
+		if len(spreadFrames) == 0 || len(explicitFrames) == 0 {
+			t.Skip("No Art-Net frames captured")
+		}
+
+		// The effects run from different start times, so match each spread frame to
+		// its closest explicit frame rather than comparing by index
+		worst := 0
+		for _, sv := range spreadFrames {
+			best := 256
+			for _, ev := range explicitFrames {
+				d := 0
+				for i := range sv {
+					d = max(d, abs(sv[i]-ev[i]))
+				}
+				best = min(best, d)
+			}
+			worst = max(worst, best)
+		}
+		t.Logf("Worst spread frame differs from its closest explicit frame by %d", worst)
+		assert.LessOrEqual(t, worst, spreadMatchTolerance,
+			"Every SPREAD frame should be reproducible with explicit phase offsets")
+
+		// Evenly spread sines average out: fixtures must never all sit at the same level
+		for i, sv := range spreadFrames {
+			lo, hi := sv[0], sv[0]
+			for _, v := range sv {
+				lo, hi = min(lo, v), max(hi, v)
+			}
+			if hi-lo < 50 {
+				t.Errorf("Frame %d: group values %v are not spread across the waveform", i, sv)
+				break
+			}
+		}
+	})
+}
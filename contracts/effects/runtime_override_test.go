@@ -0,0 +1,202 @@
+package effects
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/bbernstein/lacylights-test/pkg/calibration"
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runtimeOverrideContract is the shape of per-activation effect overrides.
+const runtimeOverrideContract = `activateEffect(effectId: ID!, fadeTime: Float, speed: Float, intensity: Float): Boolean!
+  speed multiplies the stored frequency, intensity (0-1) scales the output; neither edits the stored effect
+  or setEffectRate(effectId: ID!, rate: Float!): Boolean! to change the speed of a running effect`
+
+const (
+	// overrideBaseFrequency is slow enough that a 2x override stays far below the refresh rate
+	overrideBaseFrequency = 1.0
+
+	// overrideWindow holds several periods at the base frequency
+	overrideWindow = 4 * time.Second
+
+	// frequencyTolerance is the allowed relative error of a measured frequency,
+	// widened when the server outputs slower than the reference rate
+	frequencyTolerance = 0.1
+)
+
+// measureSquare captures channel 1 for the window and returns the square
+// wave's frequency, from the spacing of its rising edges, and its peak level.
+func measureSquare(t *testing.T, receiver *artnet.Subscription, window time.Duration) (float64, int) {
+	t.Helper()
+	receiver.ClearFrames()
+	time.Sleep(window)
+
+	frames := receiver.GetFrames()
+	edges, levels, _ := squareEdges(frames)
+	if len(levels) == 0 {
+		t.Skip("No Art-Net frames captured")
+	}
+
+	var rising []time.Time
+	for _, e := range edges {
+		if e.Rising {
+			rising = append(rising, e.At)
+		}
+	}
+	require.GreaterOrEqual(t, len(rising), 2, "Need at least two rising edges to measure a frequency")
+
+	peak := 0
+	for _, frame := range frames {
+		if frame.Universe == 0 {
+			peak = max(peak, int(frame.Channels[0]))
+		}
+	}
+	return float64(len(rising)-1) / rising[len(rising)-1].Sub(rising[0]).Seconds(), peak
+}
+
+// TestEffectRuntimeOverrides runs a square wave at its stored frequency, then
+// with a 2x speed override and a half intensity override. The measured output
+// must change accordingly while the stored effect definition stays as it was.
+func TestEffectRuntimeOverrides(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping effect override timing test in short mode")
+	}
+	compat.Require(t, compat.Effects, compat.ArtNet)
+
+	receiver := artnet.Capture(t)
+
+	setup := newEffectTestSetup(t)
+	defer setup.cleanup(t)
+	tolerance := calibration.Get(t).Frames(frequencyTolerance)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+	defer cancel()
+
+	lookID := setup.createLook(t, "Override Base", []int{0, 0, 0, 0})
+	setup.activateLook(t, lookID, 0)
+	time.Sleep(100 * time.Millisecond)
+
+	effectID := setup.createWaveformEffect(t, "override", map[string]any{
+		"name":            "Override Effect",
+		"effectType":      "WAVEFORM",
+		"waveform":        "SQUARE",
+		"frequency":       overrideBaseFrequency,
+		"amplitude":       100.0,
+		"offset":          50.0,
+		"compositionMode": "OVERRIDE",
+	})
+
+	stop := func() {
+		_ = setup.client.Mutate(ctx, `
+			mutation StopEffect($effectId: ID!) { stopEffect(effectId: $effectId, fadeTime: 0) }
+		`, map[string]any{"effectId": effectID}, nil)
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	// activate starts the effect with extra Float arguments to activateEffect
+	activate := func(t *testing.T, overrides map[string]float64) {
+		params, args := "", ""
+		vars := map[string]any{"effectId": effectID, "fadeTime": 0.0}
+		for name, value := range overrides {
+			params += fmt.Sprintf(", $%s: Float", name)
+			args += fmt.Sprintf(", %s: $%s", name, name)
+			vars[name] = value
+		}
+		err := setup.client.Mutate(ctx, fmt.Sprintf(`
+			mutation ActivateEffect($effectId: ID!, $fadeTime: Float%s) {
+				activateEffect(effectId: $effectId, fadeTime: $fadeTime%s)
+			}
+		`, params, args), vars, nil)
+		require.NoError(t, err)
+		time.Sleep(300 * time.Millisecond)
+	}
+
+	// expectStoredUnchanged reads the effect back; overrides must not be persisted
+	expectStoredUnchanged := func(t *testing.T) {
+		var resp struct {
+			Effect struct {
+				Frequency float64 `json:"frequency"`
+				Amplitude float64 `json:"amplitude"`
+			} `json:"effect"`
+		}
+		err := setup.client.Query(ctx, `
+			query GetEffect($id: ID!) { effect(id: $id) { frequency amplitude } }
+		`, map[string]any{"id": effectID}, &resp)
+		require.NoError(t, err)
+		assert.Equal(t, overrideBaseFrequency, resp.Effect.Frequency, "Stored frequency must not change")
+		assert.Equal(t, 100.0, resp.Effect.Amplitude, "Stored amplitude must not change")
+	}
+
+	setup.activateEffect(t, effectID, 0)
+	time.Sleep(300 * time.Millisecond)
+	baseFrequency, basePeak := measureSquare(t, receiver, overrideWindow)
+	stop()
+	t.Logf("Baseline: %.2f Hz, peak %d", baseFrequency, basePeak)
+	require.InDelta(t, overrideBaseFrequency, baseFrequency, overrideBaseFrequency*tolerance,
+		"Without overrides the effect should run at its stored frequency")
+
+	t.Run("ActivateSpeedOverride", func(t *testing.T) {
+		compat.RequireMutationArgument(t, setup.client, "activateEffect", "speed", runtimeOverrideContract)
+		defer stop()
+
+		activate(t, map[string]float64{"speed": 2.0})
+		frequency, peak := measureSquare(t, receiver, overrideWindow)
+		t.Logf("speed 2.0: %.2f Hz, peak %d", frequency, peak)
+
+		assert.InDelta(t, 2*baseFrequency, frequency, 2*baseFrequency*tolerance,
+			"A 2x speed override should double the output frequency")
+		assert.InDelta(t, basePeak, peak, 2, "A speed override must not change the level")
+		expectStoredUnchanged(t)
+	})
+
+	t.Run("SetEffectRate", func(t *testing.T) {
+		compat.RequireMutation(t, setup.client, "setEffectRate", runtimeOverrideContract)
+		defer stop()
+
+		setup.activateEffect(t, effectID, 0)
+		err := setup.client.Mutate(ctx, `
+			mutation SetEffectRate($effectId: ID!, $rate: Float!) {
+				setEffectRate(effectId: $effectId, rate: $rate)
+			}
+		`, map[string]any{"effectId": effectID, "rate": 2.0}, nil)
+		require.NoError(t, err)
+		time.Sleep(300 * time.Millisecond)
+
+		frequency, _ := measureSquare(t, receiver, overrideWindow)
+		t.Logf("rate 2.0: %.2f Hz", frequency)
+		assert.InDelta(t, 2*baseFrequency, frequency, 2*baseFrequency*tolerance,
+			"setEffectRate(2) should double the output frequency")
+		expectStoredUnchanged(t)
+	})
+
+	t.Run("ActivateIntensityOverride", func(t *testing.T) {
+		compat.RequireMutationArgument(t, setup.client, "activateEffect", "intensity", runtimeOverrideContract)
+		defer stop()
+
+		activate(t, map[string]float64{"intensity": 0.5})
+		frequency, peak := measureSquare(t, receiver, overrideWindow)
+		t.Logf("intensity 0.5: %.2f Hz, peak %d", frequency, peak)
+
+		assert.InDelta(t, float64(basePeak)/2, peak, 3, "A half intensity override should halve the peak level")
+		assert.InDelta(t, baseFrequency, frequency, baseFrequency*tolerance,
+			"An intensity override must not change the frequency")
+		expectStoredUnchanged(t)
+	})
+
+	t.Run("OverridesDoNotOutliveActivation", func(t *testing.T) {
+		compat.RequireMutationArgument(t, setup.client, "activateEffect", "speed", runtimeOverrideContract)
+		defer stop()
+
+		setup.activateEffect(t, effectID, 0)
+		time.Sleep(300 * time.Millisecond)
+		frequency, _ := measureSquare(t, receiver, overrideWindow)
+		assert.InDelta(t, baseFrequency, frequency, baseFrequency*tolerance,
+			"Activating again without overrides should run at the stored frequency")
+	})
+}
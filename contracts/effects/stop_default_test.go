@@ -0,0 +1,236 @@
+package effects
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/bbernstein/lacylights-test/pkg/calibration"
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/fixtures"
+	"github.com/bbernstein/lacylights-test/pkg/flicker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// stopDimmerChannel and stopPanChannel are the dimmer and pan of the
+	// fixture at channel 9, clear of the setup's two pars.
+	stopDimmerChannel = 9
+	stopPanChannel    = 10
+
+	// stopPanDefault is the pan channel's defaultValue.
+	stopPanDefault = 128
+
+	// stopHighFloor is the lowest value the slow, high sine reaches: offset 80%
+	// minus amplitude 20% of 255.
+	stopHighFloor = 150
+
+	// stopGlitchThreshold is the largest single-frame spike or dip tolerated
+	// while the channels fade to their defaults.
+	stopGlitchThreshold = 10
+)
+
+// newStopDefaultEffect patches a dimmer+pan fixture at stopDimmerChannel and
+// creates a slow OVERRIDE sine holding both channels between 60% and 100%.
+func newStopDefaultEffect(t *testing.T, setup *effectTestSetup, ctx context.Context) string {
+	var defResp struct {
+		CreateFixtureDefinition struct {
+			ID string `json:"id"`
+		} `json:"createFixtureDefinition"`
+	}
+	err := setup.client.Mutate(ctx, `
+		mutation CreateFixtureDefinition($input: CreateFixtureDefinitionInput!) {
+			createFixtureDefinition(input: $input) { id }
+		}
+	`, map[string]any{
+		"input": map[string]any{
+			"manufacturer": "Test Effects",
+			"model":        fmt.Sprintf("Stop Default Head %d", time.Now().UnixNano()),
+			"type":         "MOVING_HEAD",
+			"channels": []map[string]any{
+				{"name": "Dimmer", "type": "INTENSITY", "offset": 0, "minValue": 0, "maxValue": 255, "defaultValue": 0},
+				{"name": "Pan", "type": "PAN", "offset": 1, "minValue": 0, "maxValue": 255, "defaultValue": stopPanDefault},
+			},
+		},
+	}, &defResp)
+	require.NoError(t, err)
+	definitionID := defResp.CreateFixtureDefinition.ID
+	// Runs after the setup's cleanup has deleted the project and its fixture
+	t.Cleanup(func() {
+		cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cleanupCancel()
+		_ = fixtures.DeleteFixtureDefinition(cleanupCtx, setup.client, definitionID)
+	})
+
+	fixtureID, err := fixtures.CreateFixture(ctx, setup.client, setup.projectID, definitionID,
+		fixtures.Fixture{Name: "Stop Default Head", Universe: 1, StartChannel: stopDimmerChannel})
+	require.NoError(t, err)
+
+	var effectResp struct {
+		CreateEffect struct {
+			ID string `json:"id"`
+		} `json:"createEffect"`
+	}
+	err = setup.client.Mutate(ctx, `
+		mutation CreateEffect($input: CreateEffectInput!) {
+			createEffect(input: $input) { id }
+		}
+	`, map[string]any{
+		"input": map[string]any{
+			"projectId":       setup.projectID,
+			"name":            "Stop Default Sine",
+			"effectType":      "WAVEFORM",
+			"waveform":        "SINE",
+			"frequency":       0.1,
+			"amplitude":       20.0,
+			"offset":          80.0,
+			"compositionMode": "OVERRIDE",
+		},
+	}, &effectResp)
+	require.NoError(t, err)
+	effectID := effectResp.CreateEffect.ID
+	setup.effects["stopDefault"] = effectID
+
+	var efResp struct {
+		AddFixtureToEffect struct {
+			ID string `json:"id"`
+		} `json:"addFixtureToEffect"`
+	}
+	err = setup.client.Mutate(ctx, `
+		mutation AddFixture($input: AddFixtureToEffectInput!) {
+			addFixtureToEffect(input: $input) { id }
+		}
+	`, map[string]any{
+		"input": map[string]any{"effectId": effectID, "fixtureId": fixtureID},
+	}, &efResp)
+	require.NoError(t, err)
+	for _, offset := range []int{0, 1} {
+		err = setup.client.Mutate(ctx, `
+			mutation AddChannel($effectFixtureId: ID!, $input: EffectChannelInput!) {
+				addChannelToEffectFixture(effectFixtureId: $effectFixtureId, input: $input) { id }
+			}
+		`, map[string]any{
+			"effectFixtureId": efResp.AddFixtureToEffect.ID,
+			"input":           map[string]any{"channelOffset": offset},
+		}, nil)
+		require.NoError(t, err)
+	}
+	return effectID
+}
+
+// stopAndCapture starts the effect, stops it with fadeTime and returns the
+// frames from the stop until a few frames after the dimmer lands at 0, with
+// the time the stop was requested.
+func stopAndCapture(t *testing.T, setup *effectTestSetup, receiver *artnet.Subscription, effectID string, fadeTime float64) ([]artnet.Frame, time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	setup.activateEffect(t, effectID, 0)
+	time.Sleep(500 * time.Millisecond)
+	level, ok := receiver.GetChannelValue(0, stopDimmerChannel)
+	require.True(t, ok, "No Art-Net frames captured for universe 1")
+	require.GreaterOrEqual(t, int(level), stopHighFloor, "The effect should hold the dimmer high before the stop")
+
+	receiver.ClearFrames()
+	stopped := time.Now()
+	err := setup.client.Mutate(ctx, `
+		mutation StopEffect($effectId: ID!, $fadeTime: Float) {
+			stopEffect(effectId: $effectId, fadeTime: $fadeTime)
+		}
+	`, map[string]any{"effectId": effectID, "fadeTime": fadeTime}, nil)
+	require.NoError(t, err)
+
+	fade := time.Duration(fadeTime * float64(time.Second))
+	waitCtx, waitCancel := context.WithTimeout(ctx, fade+2*time.Second)
+	defer waitCancel()
+	_, err = receiver.WaitUntil(waitCtx, artnet.ChannelReaches(0, stopDimmerChannel, 0))
+	require.NoError(t, err, "The dimmer never reached 0 after stopEffect")
+
+	// Frames after landing show whether the channels stay put
+	holdCtx, holdCancel := context.WithTimeout(ctx, time.Second)
+	defer holdCancel()
+	_, _ = receiver.WaitUntil(holdCtx, artnet.UniverseFrames(0, 8))
+	return artnet.InOrder(receiver.GetFrames()), stopped
+}
+
+// landing returns the index of the first frame with the dimmer at 0.
+func landing(frames []artnet.Frame) int {
+	for i, f := range frames {
+		if f.Channels[stopDimmerChannel-1] == 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// TestStopEffectToDefault stops an OVERRIDE effect with no look beneath it.
+// Every channel the effect drove must return to its defaultValue: with
+// fadeTime 0 in the next frame or two without intermediate values, otherwise
+// falling steadily from the effect's level over fadeTime.
+func TestStopEffectToDefault(t *testing.T) {
+	compat.Require(t, compat.Effects, compat.ArtNet)
+
+	setup := newEffectTestSetup(t)
+	defer setup.cleanup(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	effectID := newStopDefaultEffect(t, setup, ctx)
+	receiver := artnet.Capture(t, artnet.Universes(0))
+	cal := calibration.Get(t)
+	channels := []int{stopDimmerChannel, stopPanChannel}
+
+	t.Run("Snap", func(t *testing.T) {
+		frames, stopped := stopAndCapture(t, setup, receiver, effectID, 0)
+		landed := landing(frames)
+		require.GreaterOrEqual(t, landed, 0)
+
+		took := frames[landed].Timestamp.Sub(stopped)
+		limit := cal.RoundTrip + 2*cal.FramePeriod() + cal.Duration(50*time.Millisecond)
+		assert.LessOrEqual(t, took, limit, "fadeTime 0 should snap within a couple of frames")
+
+		for i, f := range frames[:landed] {
+			assert.GreaterOrEqual(t, int(f.Channels[stopDimmerChannel-1]), stopHighFloor,
+				"frame %d: a snap should not pass through intermediate dimmer values", i)
+		}
+		assert.Equal(t, byte(stopPanDefault), frames[landed].Channels[stopPanChannel-1],
+			"Pan should snap to its defaultValue with the dimmer")
+		flicker.ExpectSteady(t, frames[landed:], 0, stopDimmerChannel, 0, 0)
+		flicker.ExpectSteady(t, frames[landed:], 0, stopPanChannel, stopPanDefault, 0)
+	})
+
+	t.Run("Fade", func(t *testing.T) {
+		const fadeTime = 1.0
+		frames, stopped := stopAndCapture(t, setup, receiver, effectID, fadeTime)
+		landed := landing(frames)
+		require.GreaterOrEqual(t, landed, 0)
+
+		fade := time.Duration(fadeTime * float64(time.Second))
+		took := frames[landed].Timestamp.Sub(stopped)
+		tolerance := cal.RoundTrip + cal.FramePeriod() + cal.Duration(150*time.Millisecond)
+		assert.InDelta(t, fade.Seconds(), took.Seconds(), tolerance.Seconds(),
+			"The dimmer should reach 0 after about fadeTime (took %v)", took)
+
+		// Falling all the way: no frame rises by more than the sine can move in one frame
+		mid := 0
+		for i := 1; i <= landed; i++ {
+			prev, cur := int(frames[i-1].Channels[stopDimmerChannel-1]), int(frames[i].Channels[stopDimmerChannel-1])
+			assert.LessOrEqual(t, cur, prev+3, "frame %d: the fade should not rise (%d -> %d)", i, prev, cur)
+			if frames[i].Timestamp.Sub(stopped) < fade/2 {
+				mid = i
+			}
+		}
+		midLevel := int(frames[mid].Channels[stopDimmerChannel-1])
+		assert.Greater(t, midLevel, 0, "Halfway through the fade the dimmer should not be out yet")
+		assert.Less(t, midLevel, int(frames[0].Channels[stopDimmerChannel-1]),
+			"Halfway through the fade the dimmer should be below the effect's level")
+
+		assert.InDelta(t, stopPanDefault, int(frames[landed].Channels[stopPanChannel-1]), 10,
+			"Pan should fade to its defaultValue alongside the dimmer")
+		flicker.ExpectNoFlicker(t, frames, 0, channels, stopGlitchThreshold)
+		flicker.ExpectSteady(t, frames[landed+1:], 0, stopPanChannel, stopPanDefault, 0)
+	})
+}
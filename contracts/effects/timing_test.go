@@ -0,0 +1,314 @@
+package effects
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/bbernstein/lacylights-test/pkg/calibration"
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// levelEdge is a transition of a square wave between its low and high levels.
+type levelEdge struct {
+	At     time.Time
+	Rising bool
+}
+
+// squareEdges classifies each frame of channel 1 as high or low around the
+// midpoint of the observed range and returns the transitions.
+func squareEdges(frames []artnet.Frame) ([]levelEdge, []bool, []time.Time) {
+	var values []int
+	var times []time.Time
+	for _, frame := range frames {
+		if frame.Universe == 0 {
+			values = append(values, int(frame.Channels[0]))
+			times = append(times, frame.Timestamp)
+		}
+	}
+	if len(values) == 0 {
+		return nil, nil, nil
+	}
+
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo = min(lo, v)
+		hi = max(hi, v)
+	}
+	mid := (lo + hi) / 2
+
+	levels := make([]bool, len(values))
+	var edges []levelEdge
+	for i, v := range values {
+		levels[i] = v > mid
+		if i > 0 && levels[i] != levels[i-1] {
+			edges = append(edges, levelEdge{At: times[i], Rising: levels[i]})
+		}
+	}
+	return edges, levels, times
+}
+
+// TestSquareWaveStepAccuracy runs a 1 Hz square wave and checks, over 10 periods,
+// that the duty cycle is 50% within one refresh frame and that transition instants
+// stay locked to the period without accumulating drift.
+func TestSquareWaveStepAccuracy(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping square wave timing test in short mode")
+	}
+	compat.Require(t, compat.Effects, compat.ArtNet)
+	cal := calibration.Get(t)
+
+	receiver := artnet.Capture(t)
+
+	setup := newEffectTestSetup(t)
+	defer setup.cleanup(t)
+
+	const (
+		frequency = 1.0
+		periods   = 10
+	)
+	period := time.Duration(float64(time.Second) / frequency)
+
+	frame := cal.FramePeriod()
+	// One refresh frame, plus a little for UDP delivery jitter on the capture side
+	tolerance := frame + cal.Duration(5*time.Millisecond)
+	t.Logf("Refresh rate %.0f Hz (%s, frame %v, tolerance %v)", cal.FrameRate, cal.FrameSource, frame, tolerance)
+
+	lookID := setup.createLook(t, "Square Base", []int{0, 0, 0, 0})
+	setup.activateLook(t, lookID, 0)
+	time.Sleep(100 * time.Millisecond)
+
+	effectID := setup.createWaveformEffect(t, "square_timing", map[string]any{
+		"name":            "Square Timing Effect",
+		"effectType":      "WAVEFORM",
+		"waveform":        "SQUARE",
+		"frequency":       frequency,
+		"amplitude":       100.0,
+		"offset":          50.0,
+		"compositionMode": "OVERRIDE",
+	})
+
+	receiver.ClearFrames()
+	setup.activateEffect(t, effectID, 0)
+
+	// One warm-up period, ten measured periods, and a little slack for the last edge
+	time.Sleep(period*(periods+2) + 200*time.Millisecond)
+
+	edges, levels, times := squareEdges(receiver.GetFrames())
+	if len(levels) == 0 {
+		t.Skip("No Art-Net frames captured")
+	}
+
+	var rising []int
+	for i, e := range edges {
+		if e.Rising {
+			rising = append(rising, i)
+		}
+	}
+	// Skip the first rising edge so the effect has settled
+	require.GreaterOrEqual(t, len(rising), periods+2,
+		"Expected at least %d rising edges, got %d", periods+2, len(rising))
+	rising = rising[1 : periods+2]
+
+	origin := edges[rising[0]].At
+	for k := 0; k < periods; k++ {
+		start := edges[rising[k]]
+		next := edges[rising[k+1]]
+		require.Greater(t, rising[k+1], rising[k]+1, "Period %d has no falling edge", k+1)
+		fall := edges[rising[k]+1]
+		require.False(t, fall.Rising)
+
+		measured := next.At.Sub(start.At)
+		high := fall.At.Sub(start.At)
+		low := next.At.Sub(fall.At)
+
+		// Frame counts, meaningful when the server streams at its refresh rate
+		highFrames, lowFrames := 0, 0
+		for i, ts := range times {
+			if ts.Before(start.At) || !ts.Before(next.At) {
+				continue
+			}
+			if levels[i] {
+				highFrames++
+			} else {
+				lowFrames++
+			}
+		}
+		t.Logf("Period %2d: %v (high %v / low %v, frames %d / %d)",
+			k+1, measured, high, low, highFrames, lowFrames)
+
+		assert.InDelta(t, float64(period), float64(measured), float64(tolerance),
+			"Period %d length should match 1/frequency within one frame", k+1)
+		assert.InDelta(t, float64(measured)/2, float64(high), float64(tolerance),
+			"Period %d duty cycle should be 50%% within one frame", k+1)
+
+		expectedFrames := cal.FrameRate / frequency
+		if math.Abs(float64(highFrames+lowFrames)-expectedFrames) <= expectedFrames*0.2 {
+			assert.LessOrEqual(t, abs(highFrames-lowFrames), 2,
+				"Period %d should spend equal frames high and low (±1 frame each)", k+1)
+		}
+
+		// Drift: every edge should sit on the grid laid down by the first one
+		expected := origin.Add(time.Duration(k) * period)
+		assert.InDelta(t, 0, float64(start.At.Sub(expected)), float64(tolerance),
+			"Rising edge %d drifted from the period grid", k+1)
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// TestEffectFrequencySweep pins down the effect frequency contract relative to the
+// output refresh rate. Frequencies up to the Nyquist limit (half the refresh rate)
+// must be stored as given and show up on the wire at the requested rate. Above
+// Nyquist the server may reject, clamp, or accept and alias, but it must handle
+// every such frequency the same way and keep producing valid output.
+func TestEffectFrequencySweep(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping frequency sweep in short mode")
+	}
+	compat.Require(t, compat.Effects, compat.ArtNet)
+	rate := calibration.Get(t).FrameRate
+
+	receiver := artnet.Capture(t)
+
+	setup := newEffectTestSetup(t)
+	defer setup.cleanup(t)
+
+	nyquist := rate / 2
+	t.Logf("Refresh rate %.0f Hz, Nyquist limit %.1f Hz", rate, nyquist)
+
+	lookID := setup.createLook(t, "Sweep Base", []int{0, 0, 0, 0})
+	setup.activateLook(t, lookID, 0)
+	time.Sleep(100 * time.Millisecond)
+
+	aboveNyquist := map[float64]string{}
+
+	for _, frequency := range []float64{0.1, 1, 5, 10, 20, 44, 100} {
+		t.Run(strconv.FormatFloat(frequency, 'f', -1, 64)+"Hz", func(t *testing.T) {
+			// Two seconds, or two full periods for frequencies too slow to fit them
+			window := max(2*time.Second, time.Duration(2*float64(time.Second)/frequency))
+
+			ctx, cancel := context.WithTimeout(context.Background(), window+10*time.Second)
+			defer cancel()
+
+			var resp struct {
+				CreateEffect struct {
+					ID        string  `json:"id"`
+					Frequency float64 `json:"frequency"`
+				} `json:"createEffect"`
+			}
+			err := setup.client.Mutate(ctx, `
+				mutation CreateEffect($input: CreateEffectInput!) {
+					createEffect(input: $input) { id frequency }
+				}
+			`, map[string]any{
+				"input": map[string]any{
+					"projectId":       setup.projectID,
+					"name":            "Sweep Effect",
+					"effectType":      "WAVEFORM",
+					"waveform":        "SQUARE",
+					"frequency":       frequency,
+					"amplitude":       100.0,
+					"offset":          50.0,
+					"compositionMode": "OVERRIDE",
+				},
+			}, &resp)
+
+			if frequency <= nyquist {
+				require.NoError(t, err, "Frequencies up to Nyquist must be accepted")
+				assert.Equal(t, frequency, resp.CreateEffect.Frequency, "Frequency should be stored as given")
+			} else {
+				switch {
+				case err != nil:
+					aboveNyquist[frequency] = "rejected"
+					t.Logf("Contract: %.0f Hz rejected: %v", frequency, err)
+					return
+				case resp.CreateEffect.Frequency <= nyquist:
+					aboveNyquist[frequency] = "clamped"
+					t.Logf("Contract: %.0f Hz clamped to %.1f Hz", frequency, resp.CreateEffect.Frequency)
+				default:
+					aboveNyquist[frequency] = "aliased"
+					t.Logf("Contract: %.0f Hz accepted as-is (output will alias)", frequency)
+				}
+			}
+
+			effectID := resp.CreateEffect.ID
+			setup.effects["sweep-"+strconv.FormatFloat(frequency, 'f', -1, 64)] = effectID
+			var efResp struct {
+				AddFixtureToEffect struct {
+					ID string `json:"id"`
+				} `json:"addFixtureToEffect"`
+			}
+			err = setup.client.Mutate(ctx, `
+				mutation AddFixture($input: AddFixtureToEffectInput!) {
+					addFixtureToEffect(input: $input) { id }
+				}
+			`, map[string]any{
+				"input": map[string]any{"effectId": effectID, "fixtureId": setup.fixtureID},
+			}, &efResp)
+			require.NoError(t, err)
+			err = setup.client.Mutate(ctx, `
+				mutation AddChannel($effectFixtureId: ID!, $input: EffectChannelInput!) {
+					addChannelToEffectFixture(effectFixtureId: $effectFixtureId, input: $input) { id }
+				}
+			`, map[string]any{
+				"effectFixtureId": efResp.AddFixtureToEffect.ID,
+				"input":           map[string]any{"channelOffset": 0},
+			}, nil)
+			require.NoError(t, err)
+
+			setup.activateEffect(t, effectID, 0)
+			time.Sleep(200 * time.Millisecond)
+			receiver.ClearFrames()
+			time.Sleep(window)
+
+			edges, levels, _ := squareEdges(receiver.GetFrames())
+
+			_ = setup.client.Mutate(ctx, `
+				mutation StopEffect($effectId: ID!) { stopEffect(effectId: $effectId, fadeTime: 0) }
+			`, map[string]any{"effectId": effectID}, nil)
+			time.Sleep(100 * time.Millisecond)
+
+			if len(levels) == 0 {
+				t.Skip("No Art-Net frames captured")
+			}
+
+			rising := 0
+			for _, e := range edges {
+				if e.Rising {
+					rising++
+				}
+			}
+			t.Logf("%d rising edges in %v across %d frames", rising, window, len(levels))
+
+			expected := frequency * window.Seconds()
+			switch {
+			case frequency <= rate/4:
+				// At least four frames per period: every cycle must be visible
+				assert.InDelta(t, expected, float64(rising), 1.5,
+					"%.1f Hz should produce ~%.1f rising edges in %v", frequency, expected, window)
+			case frequency <= nyquist:
+				// Near Nyquist individual cycles can merge, but the output must toggle
+				assert.Positive(t, rising, "%.0f Hz should still toggle the output", frequency)
+			}
+		})
+	}
+
+	// Above Nyquist every frequency must be handled by the same rule
+	seen := map[string]bool{}
+	for _, behavior := range aboveNyquist {
+		seen[behavior] = true
+	}
+	assert.LessOrEqual(t, len(seen), 1,
+		"Frequencies above Nyquist should be handled consistently, got %v", aboveNyquist)
+}
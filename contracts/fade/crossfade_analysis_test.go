@@ -0,0 +1,106 @@
+package fade
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// diplessFloor is 95% of full: red+blue must hold at least this level at
+	// every frame of a dipless crossfade
+	diplessFloor = 242
+
+	// crossfadeOvershoot allows for rounding when both channels are mid-fade
+	crossfadeOvershoot = 2
+
+	// analysisFadeTime is long enough to capture about 90 frames at 44 fps
+	analysisFadeTime = 2.0
+)
+
+// captureCrossfade snaps to the from look, crossfades to the to look over
+// fadeTime seconds, and returns the first n channels of universe 1 for every
+// Art-Net frame captured from the start of the fade until it settles.
+func (s *testSetup) captureCrossfade(t *testing.T, receiver *artnet.Subscription, fromID, toID string, fadeTime float64, n int) [][]int {
+	s.activateLook(t, fromID, 0)
+	time.Sleep(300 * time.Millisecond)
+
+	receiver.ClearFrames()
+	s.activateLook(t, toID, fadeTime)
+	time.Sleep(time.Duration(fadeTime*float64(time.Second)) + 500*time.Millisecond)
+
+	var frames [][]int
+	for _, frame := range receiver.GetFrames() {
+		if frame.Universe != 0 {
+			continue
+		}
+		values := make([]int, n)
+		for i := range values {
+			values[i] = int(frame.Channels[i])
+		}
+		frames = append(frames, values)
+	}
+	return frames
+}
+
+// midFadeFrames counts frames where channel a is leaving and channel b is arriving.
+func midFadeFrames(frames [][]int, a, b int) int {
+	count := 0
+	for _, f := range frames {
+		if f[a] > 10 && f[a] < 245 && f[b] > 10 && f[b] < 245 {
+			count++
+		}
+	}
+	return count
+}
+
+// TestRedBlueCrossfadeIsDipless captures a full red to blue crossfade frame by
+// frame and pins down the crossfade contract: the combined red+blue level must
+// never fall below 95% of full (no dip through black) and never rise above full
+// (no bump), red must only fall and blue only rise.
+func TestRedBlueCrossfadeIsDipless(t *testing.T) {
+	receiver := artnet.Capture(t)
+
+	setup := newTestSetup(t)
+	defer setup.cleanup(t)
+
+	// Dimmer, Red, Green, Blue
+	redID := setup.createLook(t, "Red", []int{255, 255, 0, 0})
+	blueID := setup.createLook(t, "Blue", []int{255, 0, 0, 255})
+
+	frames := setup.captureCrossfade(t, receiver, redID, blueID, analysisFadeTime, 4)
+	if len(frames) == 0 {
+		t.Skip("No Art-Net frames captured")
+	}
+	require.Greater(t, midFadeFrames(frames, 1, 3), 10, "Capture should include frames from the middle of the fade")
+
+	minCombined, maxCombined := 510, 0
+	for i, f := range frames {
+		combined := f[1] + f[3]
+		minCombined = min(minCombined, combined)
+		maxCombined = max(maxCombined, combined)
+
+		if combined < diplessFloor {
+			t.Errorf("Frame %d: red %d + blue %d = %d dips below %d", i, f[1], f[3], combined, diplessFloor)
+		}
+		if combined > 255+crossfadeOvershoot {
+			t.Errorf("Frame %d: red %d + blue %d = %d bumps above full", i, f[1], f[3], combined)
+		}
+		if i > 0 {
+			prev := frames[i-1]
+			if f[1] > prev[1] {
+				t.Errorf("Frame %d: red rose from %d to %d during the fade out", i, prev[1], f[1])
+			}
+			if f[3] < prev[3] {
+				t.Errorf("Frame %d: blue fell from %d to %d during the fade in", i, prev[3], f[3])
+			}
+		}
+	}
+	t.Logf("Analyzed %d frames: red+blue ranged %d..%d (floor %d)", len(frames), minCombined, maxCombined, diplessFloor)
+
+	last := frames[len(frames)-1]
+	assert.Equal(t, []int{255, 0, 0, 255}, last, "Crossfade should settle on the blue look")
+}
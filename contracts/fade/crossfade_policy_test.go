@@ -0,0 +1,171 @@
+package fade
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// addFixture creates another RGB fixture in the setup's project and returns its ID.
+func (s *testSetup) addFixture(t *testing.T, name string, startChannel int) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var resp struct {
+		CreateFixtureInstance struct {
+			ID string `json:"id"`
+		} `json:"createFixtureInstance"`
+	}
+	err := s.client.Mutate(ctx, `
+		mutation CreateFixtureInstance($input: CreateFixtureInstanceInput!) {
+			createFixtureInstance(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId":    s.projectID,
+			"definitionId": s.definitionID,
+			"name":         name,
+			"universe":     1,
+			"startChannel": startChannel,
+		},
+	}, &resp)
+	require.NoError(t, err)
+	return resp.CreateFixtureInstance.ID
+}
+
+// createFixturesLook creates a look covering only the given fixtures (fixture ID
+// -> dense channel values) and adds it to the look board.
+func (s *testSetup) createFixturesLook(t *testing.T, name string, values map[string][]int) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	fixtureValues := make([]map[string]interface{}, 0, len(values))
+	for fixtureID, channelValues := range values {
+		channels := make([]map[string]int, len(channelValues))
+		for i, value := range channelValues {
+			channels[i] = map[string]int{"offset": i, "value": value}
+		}
+		fixtureValues = append(fixtureValues, map[string]interface{}{
+			"fixtureId": fixtureID,
+			"channels":  channels,
+		})
+	}
+
+	var lookResp struct {
+		CreateLook struct {
+			ID string `json:"id"`
+		} `json:"createLook"`
+	}
+	err := s.client.Mutate(ctx, `
+		mutation CreateLook($input: CreateLookInput!) {
+			createLook(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId":     s.projectID,
+			"name":          name,
+			"fixtureValues": fixtureValues,
+		},
+	}, &lookResp)
+	require.NoError(t, err)
+	lookID := lookResp.CreateLook.ID
+
+	err = s.client.Mutate(ctx, `
+		mutation AddLookToBoard($input: CreateLookBoardButtonInput!) {
+			addLookToBoard(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"lookBoardId": s.lookBoardID,
+			"lookId":      lookID,
+			"layoutX":     len(s.looks) * 200,
+			"layoutY":     0,
+		},
+	}, nil)
+	require.NoError(t, err)
+
+	s.looks[name] = lookID
+	return lookID
+}
+
+// ============================================================================
+// Non-Intensity Crossfade Policy Tests
+// ============================================================================
+
+// TestColorOnlyCrossfadeHoldsIntensity crossfades between two looks that share
+// an intensity and differ only in color. The intensity channel must hold its
+// level at every frame while red and blue interpolate toward their targets.
+func TestColorOnlyCrossfadeHoldsIntensity(t *testing.T) {
+	receiver := artnet.Capture(t)
+
+	setup := newTestSetup(t)
+	defer setup.cleanup(t)
+
+	// Dimmer, Red, Green, Blue
+	redID := setup.createLook(t, "Red 200", []int{200, 255, 0, 0})
+	blueID := setup.createLook(t, "Blue 200", []int{200, 0, 0, 255})
+
+	frames := setup.captureCrossfade(t, receiver, redID, blueID, analysisFadeTime, 4)
+	if len(frames) == 0 {
+		t.Skip("No Art-Net frames captured")
+	}
+	mid := midFadeFrames(frames, 1, 3)
+	require.Greater(t, mid, 10, "Color channels should interpolate, not snap")
+
+	for i, f := range frames {
+		if f[0] != 200 {
+			t.Errorf("Frame %d: intensity %d should hold at 200 during a color-only crossfade", i, f[0])
+		}
+		assert.Equal(t, 0, f[2], "Frame %d: green is 0 in both looks and must stay there", i)
+	}
+	t.Logf("Analyzed %d frames, %d mid-fade", len(frames), mid)
+
+	assert.Equal(t, []int{200, 0, 0, 255}, frames[len(frames)-1], "Crossfade should settle on the blue look")
+}
+
+// TestFixtureNewToLookFadesFromZero crossfades from a look that does not include
+// a fixture to one that does. The contract is that the new fixture fades up from
+// its current output (0) over the fade time, like every other FADE channel,
+// rather than snapping to its target at the start or end of the fade.
+func TestFixtureNewToLookFadesFromZero(t *testing.T) {
+	receiver := artnet.Capture(t)
+
+	setup := newTestSetup(t)
+	defer setup.cleanup(t)
+
+	// Fixture 2 occupies channels 5-8 and is only in the second look
+	secondID := setup.addFixture(t, "RGB Fixture 2", 5)
+	onlyFirstID := setup.createFixturesLook(t, "First Only", map[string][]int{
+		setup.fixtureID: {255, 255, 0, 0},
+	})
+	bothID := setup.createFixturesLook(t, "Both", map[string][]int{
+		setup.fixtureID: {255, 255, 0, 0},
+		secondID:        {255, 0, 255, 0},
+	})
+
+	frames := setup.captureCrossfade(t, receiver, onlyFirstID, bothID, analysisFadeTime, 8)
+	if len(frames) == 0 {
+		t.Skip("No Art-Net frames captured")
+	}
+
+	assert.Equal(t, []int{0, 0, 0, 0}, frames[0][4:8], "Fixture 2 should be dark when the fade starts")
+
+	intermediate := 0
+	for i, f := range frames {
+		assert.Equal(t, []int{255, 255, 0, 0}, f[:4], "Frame %d: fixture 1 is the same in both looks and must not move", i)
+		if f[4] > 10 && f[4] < 245 {
+			intermediate++
+		}
+		if i > 0 && f[4] < frames[i-1][4] {
+			t.Errorf("Frame %d: fixture 2 intensity fell from %d to %d while fading in", i, frames[i-1][4], f[4])
+		}
+	}
+
+	assert.Greater(t, intermediate, 10, "Fixture 2 should fade up from 0, not snap")
+
+	assert.Equal(t, []int{255, 0, 255, 0}, frames[len(frames)-1][4:8], "Fixture 2 should settle on its look values")
+}
@@ -0,0 +1,183 @@
+package fade
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// previewFadeContract is the offline fade preview this suite expects.
+const previewFadeContract = `query previewFade(fromLookId: ID, toLookId: ID!, fadeTime: Float!, at: Float!, universe: Int = 1): [Int!]!
+  the 512 DMX values of universe "at" seconds into a fade from fromLookId (blackout when null) to toLookId,
+  with the same easing as a real fade; "at" is clamped to 0..fadeTime; nothing is output`
+
+// previewFade asks the server for the DMX values at seconds into a fade
+// from one look (or blackout when fromLookID is empty) to another.
+func (s *testSetup) previewFade(t *testing.T, fromLookID, toLookID string, fadeTime, at float64) []int {
+	t.Helper()
+
+	values, err := s.tryPreviewFade(fromLookID, toLookID, fadeTime, at)
+	require.NoError(t, err)
+	require.Len(t, values, 512, "previewFade returns a full universe")
+	return values
+}
+
+func (s *testSetup) tryPreviewFade(fromLookID, toLookID string, fadeTime, at float64) ([]int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	vars := map[string]interface{}{
+		"toLookId": toLookID,
+		"fadeTime": fadeTime,
+		"at":       at,
+	}
+	if fromLookID != "" {
+		vars["fromLookId"] = fromLookID
+	}
+
+	var resp struct {
+		PreviewFade []int `json:"previewFade"`
+	}
+	err := s.client.Query(ctx, `
+		query PreviewFade($fromLookId: ID, $toLookId: ID!, $fadeTime: Float!, $at: Float!) {
+			previewFade(fromLookId: $fromLookId, toLookId: $toLookId, fadeTime: $fadeTime, at: $at, universe: 1)
+		}
+	`, vars, &resp)
+	return resp.PreviewFade, err
+}
+
+// fadeStart returns the time of the last frame still showing from on the
+// first four channels of universe 0, the moment the fade began to within a
+// frame, and the frames after it.
+func fadeStart(frames []artnet.Frame, from []int) (time.Time, []artnet.Frame, bool) {
+	var universe []artnet.Frame
+	for _, frame := range artnet.InOrder(frames) {
+		if frame.Universe == 0 {
+			universe = append(universe, frame)
+		}
+	}
+	for i := 1; i < len(universe); i++ {
+		for ch, want := range from {
+			if int(universe[i].Channels[ch]) != want {
+				return universe[i-1].Timestamp, universe[i:], true
+			}
+		}
+	}
+	return time.Time{}, nil, false
+}
+
+// frameAt returns the first frame at or after t.
+func frameAt(frames []artnet.Frame, t time.Time) (artnet.Frame, bool) {
+	for _, frame := range frames {
+		if !frame.Timestamp.Before(t) {
+			return frame, true
+		}
+	}
+	return artnet.Frame{}, false
+}
+
+// TestPreviewFade checks the offline fade preview against itself and against
+// a real fade captured over Art-Net, so UIs can rely on it to draw a fade.
+func TestPreviewFade(t *testing.T) {
+	receiver := artnet.Capture(t)
+
+	setup := newTestSetup(t)
+	defer setup.cleanup(t)
+
+	compat.RequireQuery(t, setup.client, "previewFade", previewFadeContract)
+
+	// Dimmer, Red, Green, Blue; blue is the same in both looks
+	from := []int{40, 200, 0, 100}
+	to := []int{240, 0, 180, 100}
+	fromID := setup.createLook(t, "Preview From", from)
+	toID := setup.createLook(t, "Preview To", to)
+	const fade = 2.0
+
+	t.Run("Endpoints", func(t *testing.T) {
+		assert.Equal(t, from, setup.previewFade(t, fromID, toID, fade, 0)[:4])
+		assert.Equal(t, to, setup.previewFade(t, fromID, toID, fade, fade)[:4])
+		assert.Equal(t, to, setup.previewFade(t, fromID, toID, fade, fade*2)[:4], "at is clamped to the fade time")
+		assert.Equal(t, from, setup.previewFade(t, fromID, toID, fade, -1)[:4], "at is clamped to zero")
+		assert.Equal(t, []int{0, 0, 0, 0}, setup.previewFade(t, "", toID, fade, 0)[:4], "No fromLookId fades from blackout")
+	})
+
+	t.Run("Midpoint", func(t *testing.T) {
+		mid := setup.previewFade(t, fromID, toID, fade, fade/2)
+		for ch := range from {
+			lo, hi := min(from[ch], to[ch]), max(from[ch], to[ch])
+			assert.GreaterOrEqual(t, mid[ch], lo, "channel %d", ch+1)
+			assert.LessOrEqual(t, mid[ch], hi, "channel %d", ch+1)
+		}
+		assert.Equal(t, 100, mid[3], "A channel with no change holds its value")
+		assert.NotEqual(t, from[0], mid[0], "The dimmer is part way through the fade")
+		assert.NotEqual(t, to[0], mid[0], "The dimmer is part way through the fade")
+		assert.Zero(t, mid[4], "Channels outside both looks stay at zero")
+	})
+
+	t.Run("Monotonic", func(t *testing.T) {
+		prev := from
+		for i := 1; i <= 10; i++ {
+			values := setup.previewFade(t, fromID, toID, fade, fade*float64(i)/10)
+			assert.GreaterOrEqual(t, values[0], prev[0], "Dimmer rises at %d/10", i)
+			assert.LessOrEqual(t, values[1], prev[1], "Red falls at %d/10", i)
+			prev = values
+		}
+	})
+
+	t.Run("DoesNotOutput", func(t *testing.T) {
+		setup.fadeToBlack(t, 0)
+		time.Sleep(100 * time.Millisecond)
+		setup.previewFade(t, fromID, toID, fade, fade/2)
+		time.Sleep(100 * time.Millisecond)
+		out := setup.getDMXOutput(t)
+		require.Len(t, out, 512, "dmxOutput should cover the universe")
+		assert.Equal(t, []int{0, 0, 0, 0}, out[:4], "Previewing must not change live output")
+	})
+
+	t.Run("UnknownLook", func(t *testing.T) {
+		_, err := setup.tryPreviewFade(fromID, "00000000-0000-0000-0000-000000000000", fade, 1)
+		assert.Error(t, err, "Previewing a look that doesn't exist should fail")
+	})
+
+	t.Run("MatchesCapturedFade", func(t *testing.T) {
+		setup.activateLook(t, fromID, 0)
+		time.Sleep(300 * time.Millisecond)
+		receiver.ClearFrames()
+		setup.activateLook(t, toID, fade)
+		time.Sleep(time.Duration((fade + 0.5) * float64(time.Second)))
+
+		frames := receiver.GetFrames()
+		if len(frames) == 0 {
+			t.Skip("No Art-Net frames captured - Art-Net may not be enabled")
+		}
+		stats := artnet.Stats(frames, 0)
+		for _, line := range stats.Diagnostics() {
+			t.Logf("Degraded capture: %s", line)
+		}
+		start, fading, ok := fadeStart(frames, from)
+		require.True(t, ok, "The captured output never left the first look")
+
+		// The start is known to within a frame, so allow the change two
+		// frames of the fade would make, plus rounding.
+		period := max(stats.MedianPeriod, stats.MaxGap)
+		for _, f := range []float64{0.25, 0.5, 0.75} {
+			at := fade * f
+			frame, ok := frameAt(fading, start.Add(time.Duration(at*float64(time.Second))))
+			require.True(t, ok, "No frame captured %.2fs into the fade", at)
+
+			preview := setup.previewFade(t, fromID, toID, fade, at)
+			for ch := range from {
+				change := math.Abs(float64(to[ch] - from[ch]))
+				tolerance := change*2*period.Seconds()/fade + 2
+				assert.InDelta(t, preview[ch], int(frame.Channels[ch]), tolerance,
+					"channel %d at %.2fs: preview %d, captured %d", ch+1, at, preview[ch], frame.Channels[ch])
+			}
+		}
+	})
+}
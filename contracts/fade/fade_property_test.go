@@ -0,0 +1,189 @@
+package fade
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/calibration"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// propertyCompletionEpsilon allows for API round trips and polling granularity,
+	// scaled by the calibration profile
+	propertyCompletionEpsilon = 250 * time.Millisecond
+
+	// propertyPollInterval is how often dmxOutput is sampled during a fade
+	propertyPollInterval = 20 * time.Millisecond
+
+	// defaultPropertyCases is the number of random cases when FADE_PROPERTY_CASES is not set
+	defaultPropertyCases = 10
+)
+
+// fadeCase is one randomly generated fade: four channel start and end values and a duration.
+type fadeCase struct {
+	Start    [4]int
+	End      [4]int
+	Duration float64 // seconds
+}
+
+// Generate implements quick.Generator. Values are biased toward the DMX extremes
+// so off-by-one fades like 1->0 and 254->255 come up regularly.
+func (fadeCase) Generate(r *rand.Rand, _ int) reflect.Value {
+	value := func() int {
+		switch r.Intn(4) {
+		case 0:
+			return r.Intn(3) // 0-2
+		case 1:
+			return 253 + r.Intn(3) // 253-255
+		default:
+			return r.Intn(256)
+		}
+	}
+
+	var c fadeCase
+	for i := range c.Start {
+		c.Start[i] = value()
+		c.End[i] = value()
+	}
+	c.Duration = 0.2 + r.Float64()*1.3 // 0.2s - 1.5s
+	return reflect.ValueOf(c)
+}
+
+func (c fadeCase) String() string {
+	return fmt.Sprintf("%v -> %v over %.2fs", c.Start, c.End, c.Duration)
+}
+
+// checkFadeInvariants runs a single fade and returns a description of the first
+// violated invariant, or "" if the fade behaved.
+func checkFadeInvariants(t *testing.T, setup *testSetup, cal calibration.Profile, c fadeCase) string {
+	suffix := time.Now().UnixNano()
+	startLook := setup.createLook(t, fmt.Sprintf("Property Start %d", suffix), c.Start[:])
+	endLook := setup.createLook(t, fmt.Sprintf("Property End %d", suffix), c.End[:])
+
+	setup.activateLook(t, startLook, 0)
+	time.Sleep(150 * time.Millisecond)
+
+	output := setup.getDMXOutput(t)
+	for i := range c.Start {
+		if output[i] != c.Start[i] {
+			return fmt.Sprintf("channel %d did not reach start value %d (got %d)", i+1, c.Start[i], output[i])
+		}
+	}
+
+	begin := time.Now()
+	setup.activateLook(t, endLook, c.Duration)
+	deadline := time.Duration(c.Duration*float64(time.Second)) + cal.Duration(propertyCompletionEpsilon)
+
+	previous := c.Start
+	var completedAt time.Duration
+	for {
+		elapsed := time.Since(begin)
+		output := setup.getDMXOutput(t)
+
+		done := true
+		for i := range c.End {
+			// Monotonic approach: distance to target never grows
+			if abs(c.End[i]-output[i]) > abs(c.End[i]-previous[i]) {
+				return fmt.Sprintf("channel %d moved away from target %d: %d -> %d at %v",
+					i+1, c.End[i], previous[i], output[i], elapsed)
+			}
+			previous[i] = output[i]
+			if output[i] != c.End[i] {
+				done = false
+			}
+		}
+
+		if done {
+			completedAt = elapsed
+			break
+		}
+		if elapsed > deadline {
+			return fmt.Sprintf("fade not complete after %v (budget %v): at %v, want %v",
+				elapsed, deadline, previous, c.End)
+		}
+		time.Sleep(propertyPollInterval)
+	}
+
+	// Exact final values, and they stay put once the fade is over
+	time.Sleep(100 * time.Millisecond)
+	output = setup.getDMXOutput(t)
+	for i := range c.End {
+		if output[i] != c.End[i] {
+			return fmt.Sprintf("channel %d drifted after completion: want %d, got %d", i+1, c.End[i], output[i])
+		}
+	}
+
+	t.Logf("%v: completed in %v", c, completedAt)
+	return ""
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// TestFadePropertiesRandomized generates random start/end values and fade durations
+// and checks that every fade approaches its target monotonically, completes within
+// its duration plus a small epsilon, and lands on exact final values.
+// Set FADE_PROPERTY_CASES to change the number of cases and FADE_PROPERTY_SEED to
+// reproduce a failing run.
+func TestFadePropertiesRandomized(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping randomized fade properties in short mode")
+	}
+
+	setup := newTestSetup(t)
+	defer setup.cleanup(t)
+	cal := calibration.Get(t)
+
+	cases := defaultPropertyCases
+	if raw := os.Getenv("FADE_PROPERTY_CASES"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		require.NoError(t, err, "FADE_PROPERTY_CASES must be an integer")
+		cases = n
+	}
+
+	seed := time.Now().UnixNano()
+	if raw := os.Getenv("FADE_PROPERTY_SEED"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		require.NoError(t, err, "FADE_PROPERTY_SEED must be an integer")
+		seed = n
+	}
+	t.Logf("FADE_PROPERTY_SEED=%d", seed)
+
+	// Known edge cases always run before the random ones
+	edgeCases := []fadeCase{
+		{Start: [4]int{1, 254, 0, 255}, End: [4]int{0, 255, 255, 0}, Duration: 0.5},
+		{Start: [4]int{128, 128, 128, 128}, End: [4]int{128, 129, 127, 128}, Duration: 1.0},
+	}
+	for _, c := range edgeCases {
+		if violation := checkFadeInvariants(t, setup, cal, c); violation != "" {
+			t.Errorf("%v: %s", c, violation)
+		}
+	}
+
+	property := func(c fadeCase) bool {
+		if violation := checkFadeInvariants(t, setup, cal, c); violation != "" {
+			t.Errorf("%v: %s", c, violation)
+			return false
+		}
+		return true
+	}
+
+	err := quick.Check(property, &quick.Config{
+		MaxCount: cases,
+		Rand:     rand.New(rand.NewSource(seed)),
+	})
+	if err != nil {
+		t.Errorf("Fade property failed (FADE_PROPERTY_SEED=%d): %v", seed, err)
+	}
+}
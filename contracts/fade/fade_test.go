@@ -5,60 +5,20 @@ import (
 	"context"
 	"fmt"
 	"math"
-	"os"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/bbernstein/lacylights-test/pkg/artifacts"
 	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/bbernstein/lacylights-test/pkg/calibration"
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/dmxassert"
 	"github.com/bbernstein/lacylights-test/pkg/graphql"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-func getArtNetPort() string {
-	port := os.Getenv("ARTNET_LISTEN_PORT")
-	if port == "" {
-		port = "6454"
-	}
-	// If running locally with localhost broadcast, bind to localhost
-	if os.Getenv("ARTNET_BROADCAST") == "127.0.0.1" {
-		return "127.0.0.1:" + port
-	}
-	return ":" + port
-}
-
-// checkArtNetEnabled checks if fade tests should run.
-// Skip if:
-// 1. SKIP_FADE_TESTS environment variable is set
-// 2. Art-Net is not enabled on the server
-// 3. Server cannot be reached
-func checkArtNetEnabled(t *testing.T) {
-	// Skip if explicitly disabled via environment variable
-	if os.Getenv("SKIP_FADE_TESTS") != "" {
-		t.Skip("Skipping fade test: SKIP_FADE_TESTS is set")
-	}
-
-	client := graphql.NewClient("")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	var resp struct {
-		SystemInfo struct {
-			ArtnetEnabled bool `json:"artnetEnabled"`
-		} `json:"systemInfo"`
-	}
-
-	err := client.Query(ctx, `query { systemInfo { artnetEnabled } }`, nil, &resp)
-	if err != nil {
-		t.Skipf("Skipping fade test: cannot query systemInfo: %v", err)
-	}
-
-	if !resp.SystemInfo.ArtnetEnabled {
-		t.Skip("Skipping fade test: Art-Net is not enabled on the server")
-	}
-}
-
 // resetDMXState resets all DMX channels to 0 using an instant fadeToBlack
 // This ensures tests start from a clean state
 func resetDMXState(t *testing.T, client *graphql.Client) {
@@ -141,12 +101,13 @@ type testSetup struct {
 // Skips the test if Art-Net is not enabled on the server
 func newTestSetup(t *testing.T) *testSetup {
 	// Check if Art-Net is enabled before running fade tests
-	checkArtNetEnabled(t)
+	compat.Require(t, compat.ArtNet)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	client := graphql.NewClient("")
+	artifacts.For(t).AddClient(client)
 
 	// Reset DMX state to ensure clean starting point
 	resetDMXState(t, client)
@@ -484,10 +445,7 @@ func TestInstantFade(t *testing.T) {
 
 	// Should be immediately at target values
 	output := setup.getDMXOutput(t)
-	assert.Equal(t, 255, output[0], "Dimmer should be 255")
-	assert.Equal(t, 255, output[1], "Red should be 255")
-	assert.Equal(t, 128, output[2], "Green should be 128")
-	assert.Equal(t, 64, output[3], "Blue should be 64")
+	dmxassert.ExpectFrame(t, output, []int{255, 255, 128, 64}, 0, dmxassert.Msg("Instant activation should reach the look's values"))
 }
 
 // ============================================================================
@@ -576,10 +534,7 @@ func TestMultipleRapidInterruptions(t *testing.T) {
 
 	// Should be at look 3 (blue) - Dimmer=255, Red=0, Green=0, Blue=255
 	output := setup.getDMXOutput(t)
-	assert.InDelta(t, 255, output[0], 10, "Dimmer should be 255")
-	assert.InDelta(t, 0, output[1], 10, "Red should be 0")
-	assert.InDelta(t, 0, output[2], 10, "Green should be 0")
-	assert.InDelta(t, 255, output[3], 10, "Blue should be 255")
+	dmxassert.ExpectFrame(t, output, []int{255, 0, 0, 255}, 10, dmxassert.Msg("Should be at look 3 (blue) after the last interruption"))
 }
 
 // ============================================================================
@@ -589,6 +544,7 @@ func TestMultipleRapidInterruptions(t *testing.T) {
 func TestFadeProgressionLinear(t *testing.T) {
 	setup := newTestSetup(t)
 	defer setup.cleanup(t)
+	cal := calibration.Get(t)
 
 	// Create look at full (Dimmer=255, Red=255, Green=0, Blue=0)
 	lookID := setup.createLook(t, "Full", []int{255, 255, 0, 0})
@@ -635,8 +591,9 @@ func TestFadeProgressionLinear(t *testing.T) {
 		t.Logf("At %.3fs (%.1f%% progress): value=%d (%.1f%%), expected=%.1f (%.1f%%)",
 			actualElapsed.Seconds(), actualProgress*100, output[0], actualPercent, expectedValue, expectedPercent)
 
-		// Use 20% tolerance to account for timing variations and fade engine update rate (40Hz = 25ms)
-		assert.InDelta(t, expectedPercent, actualPercent, 20,
+		// Use 20% tolerance to account for timing variations and fade engine update rate (40Hz = 25ms),
+		// widened when the server outputs slower than that
+		assert.InDelta(t, expectedPercent, actualPercent, cal.Frames(20),
 			"Fade progress at %.3fs should match sine easing", actualElapsed.Seconds())
 	}
 }
@@ -667,10 +624,7 @@ func TestFadeCompletesToExactValue(t *testing.T) {
 		time.Sleep(1500 * time.Millisecond)
 
 		output := setup.getDMXOutput(t)
-		assert.Equal(t, values[0], output[0], "Dimmer should be exact")
-		assert.Equal(t, values[1], output[1], "Red should be exact")
-		assert.Equal(t, values[2], output[2], "Green should be exact")
-		assert.Equal(t, values[3], output[3], "Blue should be exact")
+		dmxassert.ExpectFrame(t, output, values, 0, dmxassert.Msg("Fade to %v should end exactly on the target", values))
 	}
 }
 
@@ -692,8 +646,8 @@ func TestCrossFadeBetweenLooks(t *testing.T) {
 
 	// Verify red (Dimmer=255, Red=255, Green=0, Blue=0)
 	output := setup.getDMXOutput(t)
-	assert.Equal(t, 255, output[1], "Should start at red (output[1]=Red)")
-	assert.Equal(t, 0, output[3], "Should start with no blue (output[3]=Blue)")
+	// Should start at red with no blue; the dimmer and green are not checked
+	dmxassert.ExpectFrame(t, output, []int{dmxassert.Any, 255, dmxassert.Any, 0}, 0, dmxassert.Msg("Should start at red with no blue"))
 
 	// Cross-fade to look 2
 	setup.activateLook(t, look2ID, 2.0)
@@ -917,9 +871,7 @@ func TestPreviewOverridesLiveAndRestoresOnCancel(t *testing.T) {
 
 	// Verify live output (Dimmer=255, Red=255, Green=0, Blue=0)
 	output := setup.getDMXOutput(t)
-	assert.Equal(t, 255, output[0], "Dimmer should be 255")
-	assert.Equal(t, 255, output[1], "Red should be 255")
-	assert.Equal(t, 0, output[2], "Green should be 0")
+	dmxassert.ExpectFrame(t, output, []int{255, 255, 0}, 0, dmxassert.Msg("Live look should be on output before the preview"))
 
 	// Start preview session
 	var sessionResp struct {
@@ -954,9 +906,7 @@ func TestPreviewOverridesLiveAndRestoresOnCancel(t *testing.T) {
 	// Preview SHOULD override live DMX output so designers can see it on actual lights
 	// (Dimmer=255, Red=0, Green=255, Blue=0)
 	output = setup.getDMXOutput(t)
-	assert.Equal(t, 255, output[0], "Dimmer should be 255 (preview)")
-	assert.Equal(t, 0, output[1], "Red should be 0 (preview overrides live)")
-	assert.Equal(t, 255, output[2], "Green should be 255 (preview overrides live)")
+	dmxassert.ExpectFrame(t, output, []int{255, 0, 255}, 0, dmxassert.Msg("Preview should override live output"))
 
 	// Cancel preview session
 	// Go server uses cancelPreviewSession instead of endPreviewSession
@@ -973,9 +923,7 @@ func TestPreviewOverridesLiveAndRestoresOnCancel(t *testing.T) {
 	// Live values should be restored after preview cancelled
 	// (Dimmer=255, Red=255, Green=0, Blue=0)
 	output = setup.getDMXOutput(t)
-	assert.Equal(t, 255, output[0], "Dimmer should be 255 after preview cancelled")
-	assert.Equal(t, 255, output[1], "Red should be 255 after preview cancelled (live restored)")
-	assert.Equal(t, 0, output[2], "Green should be 0 after preview cancelled (live restored)")
+	dmxassert.ExpectFrame(t, output, []int{255, 255, 0}, 0, dmxassert.Msg("Live values should be restored after preview cancelled"))
 }
 
 func TestPreviewSessionOutputValues(t *testing.T) {
@@ -1070,12 +1018,7 @@ func TestPreviewSessionOutputValues(t *testing.T) {
 
 func TestFadeCapturedViaArtNet(t *testing.T) {
 	// Start Art-Net receiver
-	receiver := artnet.NewReceiver(getArtNetPort())
-	err := receiver.Start()
-	if err != nil {
-		t.Skipf("Could not start Art-Net receiver: %v", err)
-	}
-	defer func() { _ = receiver.Stop() }()
+	receiver := artnet.Capture(t)
 
 	setup := newTestSetup(t)
 	defer setup.cleanup(t)
@@ -1124,12 +1067,7 @@ func TestFadeCapturedViaArtNet(t *testing.T) {
 
 func TestArtNetFrameRate(t *testing.T) {
 	// Start Art-Net receiver
-	receiver := artnet.NewReceiver(getArtNetPort())
-	err := receiver.Start()
-	if err != nil {
-		t.Skipf("Could not start Art-Net receiver: %v", err)
-	}
-	defer func() { _ = receiver.Stop() }()
+	receiver := artnet.Capture(t)
 
 	setup := newTestSetup(t)
 	defer setup.cleanup(t)
@@ -1455,7 +1393,8 @@ func TestEasingTypes(t *testing.T) {
 // TestFadeAllChannels4Universes tests fading 2048 channels (4 universes × 512)
 // This verifies the system can handle full DMX capacity with proper timing.
 func TestFadeAllChannels4Universes(t *testing.T) {
-	checkArtNetEnabled(t)
+	compat.Require(t, compat.ArtNet)
+	cal := calibration.Get(t)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
 	defer cancel()
@@ -1621,14 +1560,15 @@ func TestFadeAllChannels4Universes(t *testing.T) {
 	}
 
 	// Verify timing was within acceptable range
-	// Use 1.0s tolerance to account for network latency when checking all 2048 channels
-	assert.InDelta(t, 3.0, fadeDuration.Seconds(), 1.0,
+	// Use 1.0s tolerance to account for network latency when checking all 2048 channels,
+	// widened on slower machines
+	assert.InDelta(t, 3.0, fadeDuration.Seconds(), cal.Duration(time.Second).Seconds(),
 		"Fade duration should be ~3 seconds, got %v", fadeDuration)
 }
 
 // TestFadeUpAllChannels4Universes tests fading 2048 channels from 0 to 255
 func TestFadeUpAllChannels4Universes(t *testing.T) {
-	checkArtNetEnabled(t)
+	compat.Require(t, compat.ArtNet)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
@@ -0,0 +1,247 @@
+package fade
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/bbernstein/lacylights-test/pkg/calibration"
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lookDefaultFadeContract is the per-look fade time this suite expects.
+const lookDefaultFadeContract = `CreateLookInput.defaultFadeTime: Float, Look.defaultFadeTime: Float
+  precedence: activation fadeTimeOverride > cue fadeInTime > look defaultFadeTime > board defaultFadeTime`
+
+// Fade times are distinct at each level so the measured duration tells which one won
+const (
+	boardFade    = 3.0
+	lookFade     = 2.0
+	cueFade      = 1.0
+	overrideFade = 0.5
+)
+
+// fadeTimeTolerance is the allowed error of a measured fade: two frames of
+// capture granularity, plus slack and a share of the fade for easing tails
+// scaled for the machine running the server.
+func fadeTimeTolerance(cal calibration.Profile, fade float64) time.Duration {
+	return 2*cal.FramePeriod() + cal.Duration(50*time.Millisecond+time.Duration(fade*0.15*float64(time.Second)))
+}
+
+// fadeTimeCase sets some of the four fade time sources and names the one that must win.
+type fadeTimeCase struct {
+	name     string
+	board    float64
+	look     *float64
+	cue      *float64
+	override *float64
+	want     float64
+}
+
+func seconds(s float64) *float64 { return &s }
+
+// createBoard creates a look board with the given default fade time.
+func (s *testSetup) createBoard(t *testing.T, ctx context.Context, name string, defaultFade float64) string {
+	var resp struct {
+		CreateLookBoard struct {
+			ID string `json:"id"`
+		} `json:"createLookBoard"`
+	}
+	err := s.client.Mutate(ctx, `
+		mutation CreateLookBoard($input: CreateLookBoardInput!) {
+			createLookBoard(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{"projectId": s.projectID, "name": name, "defaultFadeTime": defaultFade},
+	}, &resp)
+	require.NoError(t, err)
+	return resp.CreateLookBoard.ID
+}
+
+// createTimedLook creates a full-on look with an optional default fade time.
+func (s *testSetup) createTimedLook(t *testing.T, ctx context.Context, name string, defaultFade *float64) string {
+	input := map[string]interface{}{
+		"projectId": s.projectID,
+		"name":      name,
+		"fixtureValues": []map[string]interface{}{
+			{"fixtureId": s.fixtureID, "channels": []map[string]int{
+				{"offset": 0, "value": 255}, {"offset": 1, "value": 255}, {"offset": 2, "value": 255}, {"offset": 3, "value": 255},
+			}},
+		},
+	}
+	if defaultFade != nil {
+		input["defaultFadeTime"] = *defaultFade
+	}
+
+	var resp struct {
+		CreateLook struct {
+			ID string `json:"id"`
+		} `json:"createLook"`
+	}
+	err := s.client.Mutate(ctx, `
+		mutation CreateLook($input: CreateLookInput!) {
+			createLook(input: $input) { id }
+		}
+	`, map[string]interface{}{"input": input}, &resp)
+	require.NoError(t, err)
+	return resp.CreateLook.ID
+}
+
+// measureFadeUp blacks out, runs trigger, and returns how long channel 1 took
+// to go from its first non-zero frame to full, as seen on Art-Net.
+func (s *testSetup) measureFadeUp(t *testing.T, receiver *artnet.Subscription, maxFade float64, trigger func()) time.Duration {
+	t.Helper()
+	s.fadeToBlack(t, 0)
+	time.Sleep(300 * time.Millisecond)
+
+	receiver.ClearFrames()
+	trigger()
+	time.Sleep(time.Duration(maxFade*float64(time.Second)) + time.Second)
+
+	var start, end time.Time
+	for _, frame := range receiver.GetFrames() {
+		if frame.Universe != 0 {
+			continue
+		}
+		v := frame.Channels[0]
+		if start.IsZero() && v > 0 {
+			start = frame.Timestamp
+		}
+		if !start.IsZero() && v >= 250 {
+			end = frame.Timestamp
+			break
+		}
+	}
+	if start.IsZero() {
+		t.Skip("No Art-Net frames captured - Art-Net may not be enabled")
+	}
+	require.False(t, end.IsZero(), "Channel 1 should reach full within %.1fs", maxFade+1)
+	return end.Sub(start)
+}
+
+// TestFadeTimePrecedence sets conflicting fade times on the board, the look,
+// the cue and the activation, and measures on Art-Net which one the fade
+// actually used. The most specific source must win.
+func TestFadeTimePrecedence(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping fade time precedence matrix in short mode")
+	}
+
+	receiver := artnet.Capture(t)
+
+	setup := newTestSetup(t)
+	defer setup.cleanup(t)
+	cal := calibration.Get(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+	defer cancel()
+
+	boardCases := []fadeTimeCase{
+		{name: "BoardOnly", board: boardFade, want: boardFade},
+		{name: "OverrideBeatsBoard", board: boardFade, override: seconds(overrideFade), want: overrideFade},
+		{name: "LookBeatsBoard", board: boardFade, look: seconds(lookFade), want: lookFade},
+		{name: "OverrideBeatsLook", board: boardFade, look: seconds(lookFade), override: seconds(overrideFade), want: overrideFade},
+	}
+	cueCases := []fadeTimeCase{
+		{name: "CueOnly", cue: seconds(cueFade), want: cueFade},
+		{name: "CueBeatsLook", look: seconds(lookFade), cue: seconds(cueFade), want: cueFade},
+	}
+
+	requireLookDefault := func(t *testing.T, c fadeTimeCase) {
+		if c.look != nil {
+			compat.RequireTypeField(t, setup.client, "CreateLookInput", "defaultFadeTime", lookDefaultFadeContract)
+		}
+	}
+
+	for i, c := range boardCases {
+		t.Run("Board/"+c.name, func(t *testing.T) {
+			requireLookDefault(t, c)
+
+			boardID := setup.createBoard(t, ctx, fmt.Sprintf("Precedence Board %d", i), c.board)
+			lookID := setup.createTimedLook(t, ctx, "Precedence "+c.name, c.look)
+			err := setup.client.Mutate(ctx, `
+				mutation AddLookToBoard($input: CreateLookBoardButtonInput!) {
+					addLookToBoard(input: $input) { id }
+				}
+			`, map[string]interface{}{
+				"input": map[string]interface{}{"lookBoardId": boardID, "lookId": lookID, "layoutX": 0, "layoutY": 0},
+			}, nil)
+			require.NoError(t, err)
+
+			measured := setup.measureFadeUp(t, receiver, boardFade, func() {
+				vars := map[string]interface{}{"lookBoardId": boardID, "lookId": lookID, "fadeTimeOverride": nil}
+				if c.override != nil {
+					vars["fadeTimeOverride"] = *c.override
+				}
+				err := setup.client.Mutate(ctx, `
+					mutation ActivateLookFromBoard($lookBoardId: ID!, $lookId: ID!, $fadeTimeOverride: Float) {
+						activateLookFromBoard(lookBoardId: $lookBoardId, lookId: $lookId, fadeTimeOverride: $fadeTimeOverride)
+					}
+				`, vars, nil)
+				require.NoError(t, err)
+			})
+
+			t.Logf("%s: measured %v, want %.1fs", c.name, measured, c.want)
+			assert.InDelta(t, c.want*float64(time.Second), float64(measured), float64(fadeTimeTolerance(cal, c.want)),
+				"Fade should take the %s time", c.name)
+		})
+	}
+
+	for _, c := range cueCases {
+		t.Run("Cue/"+c.name, func(t *testing.T) {
+			requireLookDefault(t, c)
+
+			lookID := setup.createTimedLook(t, ctx, "Precedence "+c.name, c.look)
+
+			var listResp struct {
+				CreateCueList struct {
+					ID string `json:"id"`
+				} `json:"createCueList"`
+			}
+			err := setup.client.Mutate(ctx, `
+				mutation CreateCueList($input: CreateCueListInput!) {
+					createCueList(input: $input) { id }
+				}
+			`, map[string]interface{}{
+				"input": map[string]interface{}{"projectId": setup.projectID, "name": "Precedence " + c.name},
+			}, &listResp)
+			require.NoError(t, err)
+			cueListID := listResp.CreateCueList.ID
+			defer func() {
+				_ = setup.client.Mutate(ctx, `mutation StopCueList($cueListId: ID!) { stopCueList(cueListId: $cueListId) }`,
+					map[string]interface{}{"cueListId": cueListID}, nil)
+			}()
+
+			err = setup.client.Mutate(ctx, `
+				mutation CreateCue($input: CreateCueInput!) {
+					createCue(input: $input) { id }
+				}
+			`, map[string]interface{}{
+				"input": map[string]interface{}{
+					"cueListId":   cueListID,
+					"lookId":      lookID,
+					"name":        c.name,
+					"cueNumber":   1.0,
+					"fadeInTime":  *c.cue,
+					"fadeOutTime": *c.cue,
+				},
+			}, nil)
+			require.NoError(t, err)
+
+			measured := setup.measureFadeUp(t, receiver, lookFade, func() {
+				err := setup.client.Mutate(ctx, `
+					mutation StartCueList($cueListId: ID!) { startCueList(cueListId: $cueListId) }
+				`, map[string]interface{}{"cueListId": cueListID}, nil)
+				require.NoError(t, err)
+			})
+
+			t.Logf("%s: measured %v, want %.1fs", c.name, measured, c.want)
+			assert.InDelta(t, c.want*float64(time.Second), float64(measured), float64(fadeTimeTolerance(cal, c.want)),
+				"Fade should take the %s time", c.name)
+		})
+	}
+}
@@ -0,0 +1,313 @@
+package fade
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/flicker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// continuityFade is the fade used for transitions under capture
+	continuityFade = 1.0
+
+	// maxGapPeriods is the largest inter-frame gap, in frame periods, that is not a stutter
+	maxGapPeriods = 2
+
+	// maxSequenceStep allows for a sequence counter shared by the four output universes
+	maxSequenceStep = 4
+
+	// maxFrozenFrames is how many identical frames may repeat mid-fade
+	maxFrozenFrames = 2
+
+	// flickerThreshold is the largest single-frame spike or dip that is not a
+	// glitch; a 1 Hz sine moves about 20 per frame at its steepest, and only
+	// turns around at its peaks
+	flickerThreshold = 25
+)
+
+// fixtureChannels are the DMX channels of the setup fixture.
+var fixtureChannels = []int{1, 2, 3, 4}
+
+// universeOneFrames returns the captured frames of Art-Net universe 0 (lacylights universe 1).
+func universeOneFrames(frames []artnet.Frame) []artnet.Frame {
+	var out []artnet.Frame
+	for _, f := range frames {
+		if f.Universe == 0 {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// medianFrameInterval is the typical spacing of the frames.
+func medianFrameInterval(frames []artnet.Frame) time.Duration {
+	if len(frames) < 2 {
+		return 0
+	}
+	gaps := make([]time.Duration, 0, len(frames)-1)
+	for i := 1; i < len(frames); i++ {
+		gaps = append(gaps, frames[i].Timestamp.Sub(frames[i-1].Timestamp))
+	}
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+	return gaps[len(gaps)/2]
+}
+
+// continuityProblems reports skipped or duplicated sequence numbers and
+// gaps of more than maxGapPeriods frame periods. Sequence 0 means the sender
+// does not number frames, so those are only checked for gaps.
+func continuityProblems(frames []artnet.Frame, period time.Duration) []string {
+	var problems []string
+	for i := 1; i < len(frames); i++ {
+		prev, cur := frames[i-1], frames[i]
+
+		if gap := cur.Timestamp.Sub(prev.Timestamp); gap > maxGapPeriods*period {
+			problems = append(problems, fmt.Sprintf("frame %d: %v gap (period %v)", i, gap, period))
+		}
+
+		if prev.Sequence == 0 || cur.Sequence == 0 {
+			continue
+		}
+		// Sequence numbers wrap from 255 to 1, skipping 0
+		step := int(cur.Sequence) - int(prev.Sequence)
+		if step < 0 {
+			step += 255
+		}
+		switch {
+		case step == 0:
+			problems = append(problems, fmt.Sprintf("frame %d: duplicated sequence %d", i, cur.Sequence))
+		case step > maxSequenceStep:
+			problems = append(problems, fmt.Sprintf("frame %d: sequence %d after %d", i, cur.Sequence, prev.Sequence))
+		}
+	}
+	return problems
+}
+
+// frozenMidFade reports runs of identical channel 1 values longer than
+// maxFrozenFrames while the channel is strictly between from and to.
+func frozenMidFade(frames []artnet.Frame, from, to int) []string {
+	lo, hi := min(from, to), max(from, to)
+	var problems []string
+	run := 1
+	for i := 1; i < len(frames); i++ {
+		v := int(frames[i].Channels[0])
+		if v == int(frames[i-1].Channels[0]) && v > lo+5 && v < hi-5 {
+			run++
+			if run == maxFrozenFrames+1 {
+				problems = append(problems, fmt.Sprintf("frame %d: channel 1 frozen at %d mid-fade", i, v))
+			}
+			continue
+		}
+		run = 1
+	}
+	return problems
+}
+
+// TestFrameContinuityAcrossTransitions captures Art-Net through look
+// activation, cue GO, effect start and stop, and undo. Frames must keep their
+// cadence and sequence numbering throughout, and fades must not freeze
+// partway: either would be a visible stutter on stage. No channel may spike or
+// dip for a single frame, which would be a visible flash.
+func TestFrameContinuityAcrossTransitions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping frame continuity capture in short mode")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+	defer cancel()
+
+	receiver := artnet.Capture(t)
+
+	setup := newTestSetup(t)
+	defer setup.cleanup(t)
+
+	fullID := setup.createLook(t, "Continuity Full", []int{255, 255, 255, 255})
+	dimID := setup.createLook(t, "Continuity Dim", []int{40, 40, 40, 40})
+
+	// capture runs action and returns the universe 1 frames sent during it and for settle afterwards
+	capture := func(t *testing.T, settle time.Duration, action func()) []artnet.Frame {
+		receiver.ClearFrames()
+		time.Sleep(200 * time.Millisecond)
+		action()
+		time.Sleep(settle)
+
+		frames := universeOneFrames(receiver.GetFrames())
+		if len(frames) < 2 {
+			t.Skip("No Art-Net frames captured - Art-Net may not be enabled")
+		}
+		return frames
+	}
+
+	check := func(t *testing.T, frames []artnet.Frame) {
+		period := medianFrameInterval(frames)
+		require.Positive(t, period)
+		t.Logf("%d frames, period %v, sequence %d..%d", len(frames), period, frames[0].Sequence, frames[len(frames)-1].Sequence)
+		assert.Empty(t, continuityProblems(frames, period), "Art-Net output should stay continuous")
+	}
+
+	fadeSettle := time.Duration(continuityFade*float64(time.Second)) + 500*time.Millisecond
+
+	t.Run("LookActivation", func(t *testing.T) {
+		setup.activateLook(t, dimID, 0)
+		time.Sleep(200 * time.Millisecond)
+
+		frames := capture(t, fadeSettle, func() { setup.activateLook(t, fullID, continuityFade) })
+		check(t, frames)
+		assert.Empty(t, frozenMidFade(frames, 40, 255), "Look fade should not freeze")
+		flicker.ExpectNoFlicker(t, frames, 0, fixtureChannels, flickerThreshold)
+	})
+
+	t.Run("CueGo", func(t *testing.T) {
+		var listResp struct {
+			CreateCueList struct {
+				ID string `json:"id"`
+			} `json:"createCueList"`
+		}
+		err := setup.client.Mutate(ctx, `
+			mutation CreateCueList($input: CreateCueListInput!) {
+				createCueList(input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{"projectId": setup.projectID, "name": "Continuity Cues"},
+		}, &listResp)
+		require.NoError(t, err)
+		cueListID := listResp.CreateCueList.ID
+		defer func() {
+			_ = setup.client.Mutate(ctx, `mutation StopCueList($cueListId: ID!) { stopCueList(cueListId: $cueListId) }`,
+				map[string]interface{}{"cueListId": cueListID}, nil)
+		}()
+
+		for i, lookID := range []string{dimID, fullID} {
+			err = setup.client.Mutate(ctx, `
+				mutation CreateCue($input: CreateCueInput!) {
+					createCue(input: $input) { id }
+				}
+			`, map[string]interface{}{
+				"input": map[string]interface{}{
+					"cueListId":   cueListID,
+					"lookId":      lookID,
+					"name":        fmt.Sprintf("Continuity Cue %d", i+1),
+					"cueNumber":   float64(i + 1),
+					"fadeInTime":  continuityFade,
+					"fadeOutTime": continuityFade,
+				},
+			}, nil)
+			require.NoError(t, err)
+		}
+
+		err = setup.client.Mutate(ctx, `mutation StartCueList($cueListId: ID!) { startCueList(cueListId: $cueListId) }`,
+			map[string]interface{}{"cueListId": cueListID}, nil)
+		require.NoError(t, err)
+		time.Sleep(fadeSettle)
+
+		frames := capture(t, fadeSettle, func() {
+			err := setup.client.Mutate(ctx, `mutation NextCue($cueListId: ID!) { nextCue(cueListId: $cueListId) }`,
+				map[string]interface{}{"cueListId": cueListID}, nil)
+			require.NoError(t, err)
+		})
+		check(t, frames)
+		assert.Empty(t, frozenMidFade(frames, 40, 255), "Cue fade should not freeze")
+		flicker.ExpectNoFlicker(t, frames, 0, fixtureChannels, flickerThreshold)
+	})
+
+	t.Run("EffectStartStop", func(t *testing.T) {
+		compat.Require(t, compat.Effects)
+
+		var effectResp struct {
+			CreateEffect struct {
+				ID string `json:"id"`
+			} `json:"createEffect"`
+		}
+		err := setup.client.Mutate(ctx, `
+			mutation CreateEffect($input: CreateEffectInput!) {
+				createEffect(input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"projectId":  setup.projectID,
+				"name":       "Continuity Effect",
+				"effectType": "WAVEFORM",
+				"waveform":   "SINE",
+				"frequency":  1.0,
+			},
+		}, &effectResp)
+		require.NoError(t, err)
+		effectID := effectResp.CreateEffect.ID
+
+		var efResp struct {
+			AddFixtureToEffect struct {
+				ID string `json:"id"`
+			} `json:"addFixtureToEffect"`
+		}
+		err = setup.client.Mutate(ctx, `
+			mutation AddFixture($input: AddFixtureToEffectInput!) {
+				addFixtureToEffect(input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{"effectId": effectID, "fixtureId": setup.fixtureID},
+		}, &efResp)
+		require.NoError(t, err)
+		err = setup.client.Mutate(ctx, `
+			mutation AddChannel($effectFixtureId: ID!, $input: EffectChannelInput!) {
+				addChannelToEffectFixture(effectFixtureId: $effectFixtureId, input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"effectFixtureId": efResp.AddFixtureToEffect.ID,
+			"input":           map[string]interface{}{"channelOffset": 0},
+		}, nil)
+		require.NoError(t, err)
+
+		var stopped int
+		frames := capture(t, 300*time.Millisecond, func() {
+			err := setup.client.Mutate(ctx, `
+				mutation ActivateEffect($effectId: ID!) { activateEffect(effectId: $effectId, fadeTime: 0) }
+			`, map[string]interface{}{"effectId": effectID}, nil)
+			require.NoError(t, err)
+			time.Sleep(1500 * time.Millisecond)
+			err = setup.client.Mutate(ctx, `
+				mutation StopEffect($effectId: ID!) { stopEffect(effectId: $effectId, fadeTime: 0) }
+			`, map[string]interface{}{"effectId": effectID}, nil)
+			require.NoError(t, err)
+			stopped = len(receiver.GetFrames())
+		})
+		check(t, frames)
+		flicker.ExpectNoFlicker(t, frames, 0, fixtureChannels, flickerThreshold)
+
+		// Once stopped, the dimmer settles on one value and stays there; skip
+		// the frames the stop may still be in flight for
+		if after := universeOneFrames(receiver.GetFrames()[stopped:]); len(after) > 4 {
+			after = after[4:]
+			flicker.ExpectSteady(t, after, 0, 1, int(after[0].Channels[0]), 0)
+		}
+	})
+
+	t.Run("Undo", func(t *testing.T) {
+		compat.Require(t, compat.Undo)
+
+		setup.activateLook(t, fullID, 0)
+		time.Sleep(200 * time.Millisecond)
+		setup.createLook(t, "Continuity Undone", []int{10, 20, 30, 40})
+
+		frames := capture(t, 500*time.Millisecond, func() {
+			err := setup.client.Mutate(ctx, `
+				mutation Undo($projectId: ID!) {
+					undo(projectId: $projectId) { success }
+				}
+			`, map[string]interface{}{"projectId": setup.projectID}, nil)
+			require.NoError(t, err)
+		})
+		check(t, frames)
+
+		// Undoing a look that is not live must not touch the output, even for a frame
+		for _, ch := range fixtureChannels {
+			flicker.ExpectSteady(t, frames, 0, ch, 255, 0)
+		}
+	})
+}
@@ -0,0 +1,173 @@
+package fade
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/bbernstein/lacylights-test/pkg/calibration"
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// multipartCueContract is the multi-part cue API this suite expects.
+const multipartCueContract = `CreateCueInput.parts: [CuePartInput!], input CuePartInput { lookId: ID!, fadeInTime: Float! }
+  Cue.parts: [CuePart!]! with CuePart { look { id }, fadeInTime: Float! }, in input order; lookId is the
+  first part's look. Triggering the cue starts every part at once, each fading its own look over its own time`
+
+// multipartFades are the fade times of the three parts, distinct so each
+// measured fade tells which part it belongs to.
+var multipartFades = []float64{1.0, 2.0, 3.0}
+
+// fadeSpan returns when channel (0-based) of universe 0 first left zero and
+// when it first reached full in frames.
+func fadeSpan(frames []artnet.Frame, channel int) (start, end time.Time) {
+	for _, frame := range frames {
+		if frame.Universe != 0 {
+			continue
+		}
+		v := frame.Channels[channel]
+		if start.IsZero() && v > 0 {
+			start = frame.Timestamp
+		}
+		if !start.IsZero() && v >= 250 {
+			return start, frame.Timestamp
+		}
+	}
+	return start, time.Time{}
+}
+
+// TestMultipartCueFades builds a cue of three parts, each bringing one
+// fixture's dimmer to full over its own fade time, and checks from a single
+// Art-Net capture that the parts start together and finish on their own
+// times rather than one after another.
+func TestMultipartCueFades(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping multi-part cue timing in short mode")
+	}
+
+	receiver := artnet.Capture(t)
+
+	setup := newTestSetup(t)
+	defer setup.cleanup(t)
+	compat.RequireTypeField(t, setup.client, "CreateCueInput", "parts", multipartCueContract)
+	cal := calibration.Get(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	// One RGB fixture per part, at channels 1, 5 and 9
+	fixtureIDs := []string{setup.fixtureID, setup.addFixture(t, "Part Fixture 2", 5), setup.addFixture(t, "Part Fixture 3", 9)}
+	channels := []int{0, 4, 8}
+	parts := make([]map[string]interface{}, len(fixtureIDs))
+	lookIDs := make([]string, len(fixtureIDs))
+	for i, fixtureID := range fixtureIDs {
+		lookIDs[i] = setup.createFixturesLook(t, "Part "+string(rune('A'+i)), map[string][]int{fixtureID: {255}})
+		parts[i] = map[string]interface{}{"lookId": lookIDs[i], "fadeInTime": multipartFades[i]}
+	}
+
+	var listResp struct {
+		CreateCueList struct {
+			ID string `json:"id"`
+		} `json:"createCueList"`
+	}
+	err := setup.client.Mutate(ctx, `
+		mutation CreateCueList($input: CreateCueListInput!) {
+			createCueList(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{"projectId": setup.projectID, "name": "Multi-part Cues"},
+	}, &listResp)
+	require.NoError(t, err)
+	cueListID := listResp.CreateCueList.ID
+	defer func() {
+		_ = setup.client.Mutate(ctx, `mutation StopCueList($cueListId: ID!) { stopCueList(cueListId: $cueListId) }`,
+			map[string]interface{}{"cueListId": cueListID}, nil)
+	}()
+
+	var cueResp struct {
+		CreateCue struct {
+			Parts []struct {
+				Look struct {
+					ID string `json:"id"`
+				} `json:"look"`
+				FadeInTime float64 `json:"fadeInTime"`
+			} `json:"parts"`
+		} `json:"createCue"`
+	}
+	err = setup.client.Mutate(ctx, `
+		mutation CreateCue($input: CreateCueInput!) {
+			createCue(input: $input) { id parts { look { id } fadeInTime } }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"cueListId":   cueListID,
+			"lookId":      lookIDs[0],
+			"name":        "Three Parts",
+			"cueNumber":   1.0,
+			"fadeInTime":  multipartFades[0],
+			"fadeOutTime": multipartFades[0],
+			"parts":       parts,
+		},
+	}, &cueResp)
+	require.NoError(t, err)
+	require.Len(t, cueResp.CreateCue.Parts, len(parts), "The cue should keep all its parts")
+	for i, part := range cueResp.CreateCue.Parts {
+		assert.Equal(t, lookIDs[i], part.Look.ID, "Part %d look", i+1)
+		assert.Equal(t, multipartFades[i], part.FadeInTime, "Part %d fade time", i+1)
+	}
+
+	setup.fadeToBlack(t, 0)
+	time.Sleep(300 * time.Millisecond)
+	receiver.ClearFrames()
+	triggered := time.Now()
+	err = setup.client.Mutate(ctx, `mutation StartCueList($cueListId: ID!) { startCueList(cueListId: $cueListId) }`,
+		map[string]interface{}{"cueListId": cueListID}, nil)
+	require.NoError(t, err)
+	time.Sleep(time.Duration(multipartFades[len(multipartFades)-1]*float64(time.Second)) + time.Second)
+	frames := receiver.GetFrames()
+	if len(frames) == 0 {
+		t.Skip("No Art-Net frames captured - Art-Net may not be enabled")
+	}
+
+	starts := make([]time.Time, len(channels))
+	ends := make([]time.Time, len(channels))
+	for i, channel := range channels {
+		starts[i], ends[i] = fadeSpan(frames, channel)
+		require.False(t, starts[i].IsZero(), "Part %d never started", i+1)
+		require.False(t, ends[i].IsZero(), "Part %d never reached full", i+1)
+
+		measured := ends[i].Sub(starts[i])
+		t.Logf("Part %d: started %v after the trigger, faded in %v, want %.1fs",
+			i+1, starts[i].Sub(triggered), measured, multipartFades[i])
+		assert.InDelta(t, multipartFades[i]*float64(time.Second), float64(measured),
+			float64(fadeTimeTolerance(cal, multipartFades[i])), "Part %d should fade over its own time", i+1)
+	}
+
+	// Two frames, since a part reaching its first non-zero value can lag a frame behind
+	startSpread := 2*cal.FramePeriod() + cal.Duration(20*time.Millisecond)
+	for i := 1; i < len(starts); i++ {
+		assert.WithinDuration(t, starts[0], starts[i], startSpread, "Part %d should start with part 1", i+1)
+		assert.True(t, ends[i].After(ends[i-1]), "Part %d should finish after part %d", i+1, i)
+	}
+
+	// Sequential parts would take the sum of the fades
+	total := ends[len(ends)-1].Sub(starts[0])
+	last := multipartFades[len(multipartFades)-1]
+	assert.InDelta(t, last*float64(time.Second), float64(total), float64(fadeTimeTolerance(cal, last)),
+		"The cue should take as long as its longest part")
+
+	for _, frame := range frames {
+		if frame.Universe != 0 {
+			continue
+		}
+		for channel := 0; channel < 12; channel++ {
+			if channel%4 != 0 && frame.Channels[channel] != 0 {
+				require.Failf(t, "Channel outside the parts changed", "Channel %d is %d at %v",
+					channel+1, frame.Channels[channel], frame.Timestamp.Format("15:04:05.000"))
+			}
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package fade
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// retriggerFade is the fade used by every re-trigger test
+	retriggerFade = 2.0
+
+	// retriggerAfter is how far into the fade the re-trigger lands
+	retriggerAfter = 800 * time.Millisecond
+
+	// retriggerJitter is the largest step against the fade direction that is not a flash
+	retriggerJitter = 3
+
+	// retriggerSlack is how much later than the fade time a restarted fade may settle
+	retriggerSlack = 500 * time.Millisecond
+)
+
+// retriggerContract is the re-trigger semantics these tests pin down.
+const retriggerContract = `Re-activating a look, or going to a cue, that is still fading in starts a
+  new fade from the current output level that takes the full fade time; the
+  original fade's remaining time is discarded`
+
+// channelSample is one Art-Net frame's value of channel 1.
+type channelSample struct {
+	At    time.Time
+	Value int
+}
+
+// channelOneSamples returns channel 1 of universe 1 for every captured frame.
+func channelOneSamples(receiver *artnet.Subscription) []channelSample {
+	var samples []channelSample
+	for _, frame := range receiver.GetFrames() {
+		if frame.Universe == 0 {
+			samples = append(samples, channelSample{At: frame.Timestamp, Value: int(frame.Channels[0])})
+		}
+	}
+	return samples
+}
+
+// requireMonotonic asserts channel 1 never steps against the direction of the
+// fade after the given instant, which is how a restart-from-zero flash shows.
+func requireMonotonic(t *testing.T, samples []channelSample, after time.Time, rising bool) {
+	t.Helper()
+	prev := -1
+	for _, s := range samples {
+		if s.At.Before(after) {
+			continue
+		}
+		if prev >= 0 {
+			step := s.Value - prev
+			if !rising {
+				step = -step
+			}
+			require.GreaterOrEqual(t, step, -retriggerJitter,
+				"Channel 1 jumped from %d to %d at +%v: re-trigger must not restart the fade from its source",
+				prev, s.Value, s.At.Sub(after))
+		}
+		prev = s.Value
+	}
+}
+
+// requireRestartedFade asserts a re-triggered fade settled one full fade time
+// after the re-trigger, per retriggerContract. Continuing the original fade
+// would settle retriggerAfter sooner.
+func requireRestartedFade(t *testing.T, done, retriggered time.Time) {
+	t.Helper()
+	fade := time.Duration(retriggerFade * float64(time.Second))
+	took := done.Sub(retriggered)
+	t.Logf("Re-triggered fade settled %v after the re-trigger", took)
+	require.GreaterOrEqual(t, took, fade-retriggerAfter/2,
+		"Re-trigger should restart the fade from the current level: %s", retriggerContract)
+	require.LessOrEqual(t, took, fade+retriggerSlack,
+		"Re-triggered fade should take the fade time: %s", retriggerContract)
+}
+
+// settledAt returns when channel 1 first reached the target, or the zero time.
+func settledAt(samples []channelSample, target int) time.Time {
+	for _, s := range samples {
+		if s.Value >= target-2 && s.Value <= target+2 {
+			return s.At
+		}
+	}
+	return time.Time{}
+}
+
+// TestLookRetriggerDuringFade activates a look with a fade and activates it
+// again partway through. The output must keep rising from where it is, never
+// flashing back to zero, and arrive at full one fade time after the re-trigger.
+func TestLookRetriggerDuringFade(t *testing.T) {
+	receiver := artnet.Capture(t)
+	setup := newTestSetup(t)
+	defer setup.cleanup(t)
+
+	lookID := setup.createLook(t, "Retrigger Full", []int{255, 255, 255, 255})
+
+	t.Run("MidFade", func(t *testing.T) {
+		setup.fadeToBlack(t, 0)
+		time.Sleep(300 * time.Millisecond)
+		receiver.ClearFrames()
+
+		setup.activateLook(t, lookID, retriggerFade)
+		time.Sleep(retriggerAfter)
+		retriggered := time.Now()
+		setup.activateLook(t, lookID, retriggerFade)
+		time.Sleep(time.Duration(retriggerFade*float64(time.Second)) + 700*time.Millisecond)
+
+		samples := channelOneSamples(receiver)
+		if len(samples) == 0 {
+			t.Skip("No Art-Net frames captured - Art-Net may not be enabled")
+		}
+		requireMonotonic(t, samples, samples[0].At, true)
+
+		done := settledAt(samples, 255)
+		require.False(t, done.IsZero(), "Re-triggered fade should still reach full")
+		requireRestartedFade(t, done, retriggered)
+	})
+
+	t.Run("AlreadyLive", func(t *testing.T) {
+		setup.activateLook(t, lookID, 0)
+		time.Sleep(300 * time.Millisecond)
+		receiver.ClearFrames()
+
+		setup.activateLook(t, lookID, retriggerFade)
+		time.Sleep(time.Duration(retriggerFade*float64(time.Second)) + 300*time.Millisecond)
+
+		samples := channelOneSamples(receiver)
+		if len(samples) == 0 {
+			t.Skip("No Art-Net frames captured - Art-Net may not be enabled")
+		}
+		for _, s := range samples {
+			require.GreaterOrEqual(t, s.Value, 255-retriggerJitter, "Activating the live look again must not dip the output")
+		}
+	})
+}
+
+// TestCueRetriggerViaGoToCue re-triggers cues with goToCue: the live cue once
+// it has settled, and the incoming cue while it is still fading. Neither may
+// flash back to the previous cue's levels, the fading cue restarts its fade per
+// retriggerContract, and the list stays on the cue.
+func TestCueRetriggerViaGoToCue(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	receiver := artnet.Capture(t)
+	setup := newTestSetup(t)
+	defer setup.cleanup(t)
+
+	fullID := setup.createLook(t, "Retrigger Cue Full", []int{255, 255, 255, 255})
+	dimID := setup.createLook(t, "Retrigger Cue Dim", []int{100, 100, 100, 100})
+
+	var listResp struct {
+		CreateCueList struct {
+			ID string `json:"id"`
+		} `json:"createCueList"`
+	}
+	err := setup.client.Mutate(ctx, `
+		mutation CreateCueList($input: CreateCueListInput!) {
+			createCueList(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{"projectId": setup.projectID, "name": "Retrigger Cues"},
+	}, &listResp)
+	require.NoError(t, err)
+	cueListID := listResp.CreateCueList.ID
+	defer func() {
+		_ = setup.client.Mutate(ctx, `mutation StopCueList($cueListId: ID!) { stopCueList(cueListId: $cueListId) }`,
+			map[string]interface{}{"cueListId": cueListID}, nil)
+	}()
+
+	for i, lookID := range []string{fullID, dimID} {
+		err = setup.client.Mutate(ctx, `
+			mutation CreateCue($input: CreateCueInput!) {
+				createCue(input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"cueListId":   cueListID,
+				"lookId":      lookID,
+				"name":        []string{"Full", "Dim"}[i],
+				"cueNumber":   float64(i + 1),
+				"fadeInTime":  retriggerFade,
+				"fadeOutTime": retriggerFade,
+			},
+		}, nil)
+		require.NoError(t, err)
+	}
+
+	goToCue := func(t *testing.T, index int) {
+		var resp struct {
+			GoToCue bool `json:"goToCue"`
+		}
+		err := setup.client.Mutate(ctx, `
+			mutation GoToCue($cueListId: ID!, $cueIndex: Int!) {
+				goToCue(cueListId: $cueListId, cueIndex: $cueIndex)
+			}
+		`, map[string]interface{}{"cueListId": cueListID, "cueIndex": index}, &resp)
+		require.NoError(t, err)
+		assert.True(t, resp.GoToCue)
+	}
+
+	currentCueIndex := func(t *testing.T) *int {
+		var resp struct {
+			CueListPlaybackStatus *struct {
+				CurrentCueIndex *int `json:"currentCueIndex"`
+			} `json:"cueListPlaybackStatus"`
+		}
+		err := setup.client.Query(ctx, `
+			query GetPlaybackStatus($cueListId: ID!) {
+				cueListPlaybackStatus(cueListId: $cueListId) { currentCueIndex }
+			}
+		`, map[string]interface{}{"cueListId": cueListID}, &resp)
+		require.NoError(t, err)
+		if resp.CueListPlaybackStatus == nil {
+			return nil
+		}
+		return resp.CueListPlaybackStatus.CurrentCueIndex
+	}
+
+	setup.fadeToBlack(t, 0)
+	time.Sleep(300 * time.Millisecond)
+	err = setup.client.Mutate(ctx, `mutation StartCueList($cueListId: ID!) { startCueList(cueListId: $cueListId) }`,
+		map[string]interface{}{"cueListId": cueListID}, nil)
+	require.NoError(t, err)
+	time.Sleep(time.Duration(retriggerFade*float64(time.Second)) + 500*time.Millisecond)
+
+	t.Run("LiveCue", func(t *testing.T) {
+		receiver.ClearFrames()
+		goToCue(t, 0)
+		time.Sleep(time.Duration(retriggerFade*float64(time.Second)) + 300*time.Millisecond)
+
+		samples := channelOneSamples(receiver)
+		if len(samples) == 0 {
+			t.Skip("No Art-Net frames captured - Art-Net may not be enabled")
+		}
+		for _, s := range samples {
+			require.GreaterOrEqual(t, s.Value, 255-retriggerJitter, "Re-triggering the live cue must not dip the output")
+		}
+		if index := currentCueIndex(t); assert.NotNil(t, index) {
+			assert.Equal(t, 0, *index, "Re-triggering the live cue should keep it current")
+		}
+	})
+
+	t.Run("FadingCue", func(t *testing.T) {
+		receiver.ClearFrames()
+		goToCue(t, 1)
+		time.Sleep(retriggerAfter)
+		retriggered := time.Now()
+		goToCue(t, 1)
+		time.Sleep(time.Duration(retriggerFade*float64(time.Second)) + 700*time.Millisecond)
+
+		samples := channelOneSamples(receiver)
+		if len(samples) == 0 {
+			t.Skip("No Art-Net frames captured - Art-Net may not be enabled")
+		}
+		requireMonotonic(t, samples, samples[0].At, false)
+
+		done := settledAt(samples, 100)
+		require.False(t, done.IsZero(), "Re-triggered cue should still arrive at its level")
+		requireRestartedFade(t, done, retriggered)
+
+		if index := currentCueIndex(t); assert.NotNil(t, index) {
+			assert.Equal(t, 1, *index)
+		}
+	})
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/bbernstein/lacylights-test/pkg/compat"
 	"github.com/bbernstein/lacylights-test/pkg/graphql"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -27,7 +28,7 @@ type sparseChannelTestSetup struct {
 
 // newSparseChannelTestSetup creates test fixtures with 4-channel DRGB (Dimmer, Red, Green, Blue) fixture.
 func newSparseChannelTestSetup(t *testing.T) *sparseChannelTestSetup {
-	checkArtNetEnabled(t)
+	compat.Require(t, compat.ArtNet)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
@@ -222,18 +223,13 @@ func TestSparseChannelsDMXOutput(t *testing.T) {
 	})
 
 	// Start Art-Net receiver
-	receiver := artnet.NewReceiver(getArtNetPort())
-	err := receiver.Start()
-	if err != nil {
-		t.Skipf("Could not start Art-Net receiver: %v", err)
-	}
-	defer func() { _ = receiver.Stop() }()
+	receiver := artnet.Capture(t)
 
 	// Ensure we start from black
 	var fadeResp struct {
 		FadeToBlack bool `json:"fadeToBlack"`
 	}
-	err = setup.client.Mutate(ctx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, &fadeResp)
+	err := setup.client.Mutate(ctx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, &fadeResp)
 	require.NoError(t, err)
 	time.Sleep(100 * time.Millisecond)
 
@@ -312,18 +308,13 @@ func TestSparseChannelsExcludedRetainValues(t *testing.T) {
 	})
 
 	// Start Art-Net receiver
-	receiver := artnet.NewReceiver(getArtNetPort())
-	err := receiver.Start()
-	if err != nil {
-		t.Skipf("Could not start Art-Net receiver: %v", err)
-	}
-	defer func() { _ = receiver.Stop() }()
+	receiver := artnet.Capture(t)
 
 	// Start from black
 	var fadeResp struct {
 		FadeToBlack bool `json:"fadeToBlack"`
 	}
-	err = setup.client.Mutate(ctx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, &fadeResp)
+	err := setup.client.Mutate(ctx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, &fadeResp)
 	require.NoError(t, err)
 	time.Sleep(100 * time.Millisecond)
 
@@ -411,18 +402,13 @@ func TestSparseChannelsFadeOnlySpecified(t *testing.T) {
 	})
 
 	// Start Art-Net receiver
-	receiver := artnet.NewReceiver(getArtNetPort())
-	err := receiver.Start()
-	if err != nil {
-		t.Skipf("Could not start Art-Net receiver: %v", err)
-	}
-	defer func() { _ = receiver.Stop() }()
+	receiver := artnet.Capture(t)
 
 	// Activate Look 1 (instant)
 	var activateResp struct {
 		ActivateLookFromBoard bool `json:"activateLookFromBoard"`
 	}
-	err = setup.client.Mutate(ctx, `
+	err := setup.client.Mutate(ctx, `
 		mutation ActivateLook($boardId: ID!, $lookId: ID!, $fadeTime: Float) {
 			activateLookFromBoard(lookBoardId: $boardId, lookId: $lookId, fadeTimeOverride: $fadeTime)
 		}
@@ -565,12 +551,7 @@ func TestSparseChannelsMultipleFixtures(t *testing.T) {
 	lookID := lookResp.CreateLook.ID
 
 	// Start Art-Net receiver
-	receiver := artnet.NewReceiver(getArtNetPort())
-	err = receiver.Start()
-	if err != nil {
-		t.Skipf("Could not start Art-Net receiver: %v", err)
-	}
-	defer func() { _ = receiver.Stop() }()
+	receiver := artnet.Capture(t)
 
 	// Activate look
 	var activateResp struct {
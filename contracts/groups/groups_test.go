@@ -0,0 +1,435 @@
+// Package groups provides fixture group contract tests.
+// These tests cover group CRUD, membership changes, and looks that target a
+// group rather than individual fixtures.
+package groups
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	_ "github.com/bbernstein/lacylights-test/pkg/artifacts"
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// groupsContract is the API shape this suite expects.
+const groupsContract = `createFixtureGroup(input: CreateFixtureGroupInput!): FixtureGroup!
+  fixtureGroup(id: ID!): FixtureGroup, fixtureGroups(projectId: ID!): [FixtureGroup!]!
+  updateFixtureGroup(id: ID!, input: UpdateFixtureGroupInput!): FixtureGroup!
+  addFixturesToGroup(groupId: ID!, fixtureIds: [ID!]!): FixtureGroup!
+  removeFixturesFromGroup(groupId: ID!, fixtureIds: [ID!]!): FixtureGroup!
+  deleteFixtureGroup(id: ID!): Boolean!
+  FixtureGroup { id, name, fixtureCount, fixtures: [FixtureInstance!]! }
+  CreateLookInput.groupValues: [{ groupId: ID!, channels: [{ offset, value }] }]`
+
+type fixtureGroup struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	FixtureCount int    `json:"fixtureCount"`
+	Fixtures     []struct {
+		ID string `json:"id"`
+	} `json:"fixtures"`
+}
+
+// memberIDs returns the group's fixture IDs in order.
+func (g fixtureGroup) memberIDs() []string {
+	ids := make([]string, 0, len(g.Fixtures))
+	for _, f := range g.Fixtures {
+		ids = append(ids, f.ID)
+	}
+	return ids
+}
+
+// setupGroupsTest creates a project with count single-channel dimmers at channels 1..count.
+func setupGroupsTest(t *testing.T, client *graphql.Client, ctx context.Context, count int) (projectID, definitionID string, fixtureIDs []string) {
+	var projectResp struct {
+		CreateProject struct {
+			ID string `json:"id"`
+		} `json:"createProject"`
+	}
+	err := client.Mutate(ctx, `
+		mutation CreateProject($input: CreateProjectInput!) {
+			createProject(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{"name": "Fixture Groups Test Project"},
+	}, &projectResp)
+	require.NoError(t, err)
+	projectID = projectResp.CreateProject.ID
+
+	var defResp struct {
+		CreateFixtureDefinition struct {
+			ID string `json:"id"`
+		} `json:"createFixtureDefinition"`
+	}
+	err = client.Mutate(ctx, `
+		mutation CreateFixtureDefinition($input: CreateFixtureDefinitionInput!) {
+			createFixtureDefinition(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"manufacturer": "Test Groups",
+			"model":        fmt.Sprintf("Group Dimmer %d", time.Now().UnixNano()),
+			"type":         "DIMMER",
+			"channels": []map[string]interface{}{
+				{"name": "Dimmer", "type": "INTENSITY", "offset": 0, "minValue": 0, "maxValue": 255, "defaultValue": 0},
+			},
+		},
+	}, &defResp)
+	require.NoError(t, err)
+	definitionID = defResp.CreateFixtureDefinition.ID
+
+	for i := 0; i < count; i++ {
+		var fixtureResp struct {
+			CreateFixtureInstance struct {
+				ID string `json:"id"`
+			} `json:"createFixtureInstance"`
+		}
+		err = client.Mutate(ctx, `
+			mutation CreateFixtureInstance($input: CreateFixtureInstanceInput!) {
+				createFixtureInstance(input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"projectId":    projectID,
+				"definitionId": definitionID,
+				"name":         fmt.Sprintf("Group Dimmer %d", i+1),
+				"universe":     1,
+				"startChannel": i + 1,
+			},
+		}, &fixtureResp)
+		require.NoError(t, err)
+		fixtureIDs = append(fixtureIDs, fixtureResp.CreateFixtureInstance.ID)
+	}
+
+	return projectID, definitionID, fixtureIDs
+}
+
+func cleanupGroupsTest(client *graphql.Client, ctx context.Context, projectID, definitionID string) {
+	_ = client.Mutate(ctx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+	time.Sleep(200 * time.Millisecond)
+	_ = client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
+		map[string]interface{}{"id": projectID}, nil)
+	_ = client.Mutate(ctx, `mutation DeleteFixtureDefinition($id: ID!) { deleteFixtureDefinition(id: $id) }`,
+		map[string]interface{}{"id": definitionID}, nil)
+}
+
+// getGroup reads a group, returning nil when it does not exist.
+func getGroup(t *testing.T, client *graphql.Client, ctx context.Context, groupID string) *fixtureGroup {
+	var resp struct {
+		FixtureGroup *fixtureGroup `json:"fixtureGroup"`
+	}
+	err := client.Query(ctx, `
+		query GetFixtureGroup($id: ID!) {
+			fixtureGroup(id: $id) {
+				id
+				name
+				fixtureCount
+				fixtures { id }
+			}
+		}
+	`, map[string]interface{}{"id": groupID}, &resp)
+	if err != nil {
+		return nil
+	}
+	return resp.FixtureGroup
+}
+
+// ============================================================================
+// Group CRUD Tests
+// ============================================================================
+
+// TestFixtureGroupCRUD covers the group lifecycle: create with members, list,
+// rename, add and remove members, and delete without touching the fixtures.
+func TestFixtureGroupCRUD(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewClient("")
+	compat.RequireMutation(t, client, "createFixtureGroup", groupsContract)
+
+	projectID, definitionID, fixtureIDs := setupGroupsTest(t, client, ctx, 4)
+	defer cleanupGroupsTest(client, ctx, projectID, definitionID)
+
+	var groupID string
+
+	t.Run("Create", func(t *testing.T) {
+		var resp struct {
+			CreateFixtureGroup fixtureGroup `json:"createFixtureGroup"`
+		}
+		err := client.Mutate(ctx, `
+			mutation CreateFixtureGroup($input: CreateFixtureGroupInput!) {
+				createFixtureGroup(input: $input) {
+					id
+					name
+					fixtureCount
+					fixtures { id }
+				}
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"projectId":  projectID,
+				"name":       "Front Wash",
+				"fixtureIds": fixtureIDs[:2],
+			},
+		}, &resp)
+		require.NoError(t, err)
+
+		group := resp.CreateFixtureGroup
+		require.NotEmpty(t, group.ID)
+		groupID = group.ID
+		assert.Equal(t, "Front Wash", group.Name)
+		assert.Equal(t, 2, group.FixtureCount)
+		assert.Equal(t, fixtureIDs[:2], group.memberIDs(), "Members should keep the order they were given in")
+	})
+
+	require.NotEmpty(t, groupID, "Create must succeed for the remaining subtests")
+
+	t.Run("List", func(t *testing.T) {
+		var resp struct {
+			FixtureGroups []fixtureGroup `json:"fixtureGroups"`
+		}
+		err := client.Query(ctx, `
+			query ListFixtureGroups($projectId: ID!) {
+				fixtureGroups(projectId: $projectId) { id name fixtureCount }
+			}
+		`, map[string]interface{}{"projectId": projectID}, &resp)
+		require.NoError(t, err)
+		require.Len(t, resp.FixtureGroups, 1)
+		assert.Equal(t, groupID, resp.FixtureGroups[0].ID)
+	})
+
+	t.Run("Rename", func(t *testing.T) {
+		err := client.Mutate(ctx, `
+			mutation UpdateFixtureGroup($id: ID!, $input: UpdateFixtureGroupInput!) {
+				updateFixtureGroup(id: $id, input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"id":    groupID,
+			"input": map[string]interface{}{"name": "Front Wash Renamed"},
+		}, nil)
+		require.NoError(t, err)
+
+		group := getGroup(t, client, ctx, groupID)
+		require.NotNil(t, group)
+		assert.Equal(t, "Front Wash Renamed", group.Name)
+		assert.Equal(t, 2, group.FixtureCount, "Renaming must not change membership")
+	})
+
+	t.Run("AddMembers", func(t *testing.T) {
+		err := client.Mutate(ctx, `
+			mutation AddFixturesToGroup($groupId: ID!, $fixtureIds: [ID!]!) {
+				addFixturesToGroup(groupId: $groupId, fixtureIds: $fixtureIds) { id }
+			}
+		`, map[string]interface{}{
+			"groupId":    groupID,
+			"fixtureIds": []string{fixtureIDs[2], fixtureIDs[3], fixtureIDs[0]},
+		}, nil)
+		require.NoError(t, err)
+
+		group := getGroup(t, client, ctx, groupID)
+		require.NotNil(t, group)
+		assert.Equal(t, fixtureIDs, group.memberIDs(), "Adding an existing member must not duplicate it")
+		assert.Equal(t, 4, group.FixtureCount)
+	})
+
+	t.Run("RemoveMembers", func(t *testing.T) {
+		err := client.Mutate(ctx, `
+			mutation RemoveFixturesFromGroup($groupId: ID!, $fixtureIds: [ID!]!) {
+				removeFixturesFromGroup(groupId: $groupId, fixtureIds: $fixtureIds) { id }
+			}
+		`, map[string]interface{}{
+			"groupId":    groupID,
+			"fixtureIds": []string{fixtureIDs[1]},
+		}, nil)
+		require.NoError(t, err)
+
+		group := getGroup(t, client, ctx, groupID)
+		require.NotNil(t, group)
+		assert.Equal(t, []string{fixtureIDs[0], fixtureIDs[2], fixtureIDs[3]}, group.memberIDs())
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		var resp struct {
+			DeleteFixtureGroup bool `json:"deleteFixtureGroup"`
+		}
+		err := client.Mutate(ctx, `
+			mutation DeleteFixtureGroup($id: ID!) {
+				deleteFixtureGroup(id: $id)
+			}
+		`, map[string]interface{}{"id": groupID}, &resp)
+		require.NoError(t, err)
+		assert.True(t, resp.DeleteFixtureGroup)
+		assert.Nil(t, getGroup(t, client, ctx, groupID), "Deleted group should not be found")
+
+		// Fixtures outlive their group
+		var fixtureResp struct {
+			FixtureInstance *struct {
+				ID string `json:"id"`
+			} `json:"fixtureInstance"`
+		}
+		err = client.Query(ctx, `
+			query GetFixtureInstance($id: ID!) {
+				fixtureInstance(id: $id) { id }
+			}
+		`, map[string]interface{}{"id": fixtureIDs[0]}, &fixtureResp)
+		require.NoError(t, err)
+		assert.NotNil(t, fixtureResp.FixtureInstance, "Deleting a group must not delete its fixtures")
+	})
+}
+
+// TestFixtureGroupRejectsForeignFixtures verifies a group cannot take members
+// from another project.
+func TestFixtureGroupRejectsForeignFixtures(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewClient("")
+	compat.RequireMutation(t, client, "createFixtureGroup", groupsContract)
+
+	projectA, definitionA, _ := setupGroupsTest(t, client, ctx, 1)
+	defer cleanupGroupsTest(client, ctx, projectA, definitionA)
+	projectB, definitionB, fixturesB := setupGroupsTest(t, client, ctx, 1)
+	defer cleanupGroupsTest(client, ctx, projectB, definitionB)
+
+	err := client.Mutate(ctx, `
+		mutation CreateFixtureGroup($input: CreateFixtureGroupInput!) {
+			createFixtureGroup(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId":  projectA,
+			"name":       "Cross Project",
+			"fixtureIds": fixturesB,
+		},
+	}, nil)
+	assert.Error(t, err, "A group must not include fixtures from another project")
+}
+
+// ============================================================================
+// Look-by-Group Tests
+// ============================================================================
+
+// TestLookTargetsGroup creates a look through groupValues and checks that it
+// drives every member, then changes membership and checks that the look follows
+// one documented semantic consistently: either the look resolves the group when
+// it plays (live) or it captured the members when it was saved (snapshot).
+func TestLookTargetsGroup(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewClient("")
+	compat.RequireMutation(t, client, "createFixtureGroup", groupsContract)
+
+	projectID, definitionID, fixtureIDs := setupGroupsTest(t, client, ctx, 4)
+	defer cleanupGroupsTest(client, ctx, projectID, definitionID)
+
+	var groupResp struct {
+		CreateFixtureGroup struct {
+			ID string `json:"id"`
+		} `json:"createFixtureGroup"`
+	}
+	err := client.Mutate(ctx, `
+		mutation CreateFixtureGroup($input: CreateFixtureGroupInput!) {
+			createFixtureGroup(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId":  projectID,
+			"name":       "Look Group",
+			"fixtureIds": fixtureIDs[:2],
+		},
+	}, &groupResp)
+	require.NoError(t, err)
+	groupID := groupResp.CreateFixtureGroup.ID
+
+	var lookResp struct {
+		CreateLook struct {
+			ID string `json:"id"`
+		} `json:"createLook"`
+	}
+	err = client.Mutate(ctx, `
+		mutation CreateLook($input: CreateLookInput!) {
+			createLook(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId": projectID,
+			"name":      "Group Look",
+			"groupValues": []map[string]interface{}{
+				{"groupId": groupID, "channels": []map[string]interface{}{{"offset": 0, "value": 180}}},
+			},
+		},
+	}, &lookResp)
+	require.NoError(t, err)
+	lookID := lookResp.CreateLook.ID
+
+	// dimmers activates the look and returns channels 1-4
+	dimmers := func(t *testing.T) []int {
+		_ = client.Mutate(ctx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+		time.Sleep(100 * time.Millisecond)
+		err := client.Mutate(ctx, `
+			mutation SetLookLive($lookId: ID!) {
+				setLookLive(lookId: $lookId)
+			}
+		`, map[string]interface{}{"lookId": lookID}, nil)
+		require.NoError(t, err)
+		time.Sleep(300 * time.Millisecond)
+
+		var resp struct {
+			DMXOutput []int `json:"dmxOutput"`
+		}
+		err = client.Query(ctx, `query { dmxOutput(universe: 1) }`, nil, &resp)
+		require.NoError(t, err)
+		require.Len(t, resp.DMXOutput, 512, "dmxOutput should cover the universe")
+		return resp.DMXOutput[:4]
+	}
+
+	t.Run("DrivesMembers", func(t *testing.T) {
+		if compat.DMXChecksDisabled() {
+			t.Skip("Skipping DMX output check: SKIP_DMX_TESTS or SKIP_FADE_TESTS is set")
+		}
+		assert.Equal(t, []int{180, 180, 0, 0}, dimmers(t), "Only group members should be driven")
+	})
+
+	t.Run("MembershipChangesFollowOneSemantic", func(t *testing.T) {
+		if compat.DMXChecksDisabled() {
+			t.Skip("Skipping DMX output check: SKIP_DMX_TESTS or SKIP_FADE_TESTS is set")
+		}
+
+		err := client.Mutate(ctx, `
+			mutation AddFixturesToGroup($groupId: ID!, $fixtureIds: [ID!]!) {
+				addFixturesToGroup(groupId: $groupId, fixtureIds: $fixtureIds) { id }
+			}
+		`, map[string]interface{}{"groupId": groupID, "fixtureIds": []string{fixtureIDs[2]}}, nil)
+		require.NoError(t, err)
+
+		afterAdd := dimmers(t)
+		live := afterAdd[2] == 180
+		if live {
+			t.Log("Contract: looks resolve group membership at playback (live)")
+		} else {
+			t.Log("Contract: looks snapshot group membership when saved")
+			assert.Equal(t, 0, afterAdd[2], "A snapshot look must leave a new member untouched")
+		}
+
+		err = client.Mutate(ctx, `
+			mutation RemoveFixturesFromGroup($groupId: ID!, $fixtureIds: [ID!]!) {
+				removeFixturesFromGroup(groupId: $groupId, fixtureIds: $fixtureIds) { id }
+			}
+		`, map[string]interface{}{"groupId": groupID, "fixtureIds": []string{fixtureIDs[0]}}, nil)
+		require.NoError(t, err)
+
+		afterRemove := dimmers(t)
+		if live {
+			assert.Equal(t, []int{0, 180, 180, 0}, afterRemove, "A live group look should drop a removed member")
+		} else {
+			assert.Equal(t, []int{180, 180, 0, 0}, afterRemove, "A snapshot look should keep its original members")
+		}
+		assert.Equal(t, 0, afterRemove[3], "A fixture never in the group must never be driven")
+	})
+}
@@ -0,0 +1,252 @@
+package importexport
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/fixtures"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cueSheetContract is the cue sheet export and import these tests expect.
+const cueSheetContract = `query exportCueSheet(cueListId: ID!): CueSheet! { cueListName, csv: String!, rows: [CueSheetRow!]! }
+  CueSheetRow { cueNumber: Float!, name, lookName, fadeInTime, fadeOutTime, followTime: Float, notes: String }
+  rows are in cue number order; csv is UTF-8 RFC 4180 with the header
+  Cue,Name,Look,Fade In,Fade Out,Follow,Notes and the same rows, numbers in shortest decimal form
+  mutation importCueSheet(cueListId: ID!, csv: String!): ImportCueSheetResult! { cuesCreated: Int!, warnings: [String!]! }
+  looks are matched by name in the cue list's project; a row whose look is missing is skipped with a warning`
+
+// cueSheetHeader is the CSV header row of an exported cue sheet.
+var cueSheetHeader = []string{"Cue", "Name", "Look", "Fade In", "Fade Out", "Follow", "Notes"}
+
+// sheetCue is a cue as created for, and read back from, a cue sheet.
+type sheetCue struct {
+	CueNumber   float64  `json:"cueNumber"`
+	Name        string   `json:"name"`
+	LookName    string   `json:"lookName"`
+	FadeInTime  float64  `json:"fadeInTime"`
+	FadeOutTime float64  `json:"fadeOutTime"`
+	FollowTime  *float64 `json:"followTime"`
+	Notes       *string  `json:"notes"`
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// exportCueSheet returns the cue sheet of a cue list.
+func exportCueSheet(t *testing.T, client *graphql.Client, ctx context.Context, cueListID string) (string, []sheetCue) {
+	var resp struct {
+		ExportCueSheet struct {
+			CueListName string     `json:"cueListName"`
+			CSV         string     `json:"csv"`
+			Rows        []sheetCue `json:"rows"`
+		} `json:"exportCueSheet"`
+	}
+	err := client.Query(ctx, `
+		query ExportCueSheet($cueListId: ID!) {
+			exportCueSheet(cueListId: $cueListId) {
+				cueListName
+				csv
+				rows { cueNumber name lookName fadeInTime fadeOutTime followTime notes }
+			}
+		}
+	`, map[string]interface{}{"cueListId": cueListID}, &resp)
+	require.NoError(t, err)
+	return resp.ExportCueSheet.CSV, resp.ExportCueSheet.Rows
+}
+
+// readSheetCues reads a cue list's cues in the order the server lists them.
+func readSheetCues(t *testing.T, client *graphql.Client, ctx context.Context, cueListID string) []sheetCue {
+	var resp struct {
+		CueList struct {
+			Cues []struct {
+				sheetCue
+				Look struct {
+					Name string `json:"name"`
+				} `json:"look"`
+			} `json:"cues"`
+		} `json:"cueList"`
+	}
+	err := client.Query(ctx, `
+		query GetCueList($id: ID!) {
+			cueList(id: $id) {
+				cues { cueNumber name fadeInTime fadeOutTime followTime notes look { name } }
+			}
+		}
+	`, map[string]interface{}{"id": cueListID}, &resp)
+	require.NoError(t, err)
+
+	cues := make([]sheetCue, len(resp.CueList.Cues))
+	for i, c := range resp.CueList.Cues {
+		cues[i] = c.sheetCue
+		cues[i].LookName = c.Look.Name
+	}
+	return cues
+}
+
+// formatCueNumber formats a cue number as the cue sheet writes it: "1", "1.5", "10".
+func formatCueNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+func TestCueSheetExport(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewClient("")
+	compat.RequireQuery(t, client, "exportCueSheet", cueSheetContract)
+
+	definitionID, err := fixtures.CreateParDefinition(ctx, client, "Test Cue Sheet", fmt.Sprintf("Sheet Par %d", time.Now().UnixNano()))
+	require.NoError(t, err)
+	projectID, err := fixtures.CreateProject(ctx, client, "Cue Sheet Project")
+	require.NoError(t, err)
+	defer func() {
+		_ = fixtures.DeleteProject(ctx, client, projectID)
+		_ = fixtures.DeleteFixtureDefinition(ctx, client, definitionID)
+	}()
+
+	fixtureID, err := fixtures.CreateFixture(ctx, client, projectID, definitionID, fixtures.Fixture{Name: "Par", Universe: 1, StartChannel: 1})
+	require.NoError(t, err)
+	lookIDs := make(map[string]string)
+	for _, name := range []string{"Préshow Wash", "桜 Blossom"} {
+		lookIDs[name], err = fixtures.CreateLook(ctx, client, projectID, name, []fixtures.FixtureValues{{FixtureID: fixtureID, Values: []int{255, 128, 0, 64}}})
+		require.NoError(t, err)
+	}
+	cueListID, err := fixtures.CreateCueList(ctx, client, projectID, "Act 1 — Cue Sheet", nil)
+	require.NoError(t, err)
+
+	// Created out of order: the sheet must sort numerically, so 10 follows 2
+	created := []sheetCue{
+		{CueNumber: 10, Name: "Finale", LookName: "桜 Blossom", FadeInTime: 5, FadeOutTime: 8},
+		{CueNumber: 1, Name: "Ouverture", LookName: "Préshow Wash", FadeInTime: 3, FadeOutTime: 3, Notes: ptr("Wait for house to settle")},
+		{CueNumber: 2, Name: "Scène 2 🌙", LookName: "桜 Blossom", FadeInTime: 2.5, FadeOutTime: 1, FollowTime: ptr(4.0)},
+		{CueNumber: 1.5, Name: `Door slam, "bang"`, LookName: "Préshow Wash", FadeInTime: 0, FadeOutTime: 0, Notes: ptr("Line one\nLine two")},
+		{CueNumber: 0.5, Name: "Preset", LookName: "Préshow Wash", FadeInTime: 1, FadeOutTime: 1},
+	}
+	var ops []graphql.BatchOperation
+	for _, c := range created {
+		input := map[string]interface{}{
+			"cueListId":   cueListID,
+			"lookId":      lookIDs[c.LookName],
+			"name":        c.Name,
+			"cueNumber":   c.CueNumber,
+			"fadeInTime":  c.FadeInTime,
+			"fadeOutTime": c.FadeOutTime,
+		}
+		if c.FollowTime != nil {
+			input["followTime"] = *c.FollowTime
+		}
+		if c.Notes != nil {
+			input["notes"] = *c.Notes
+		}
+		ops = append(ops, graphql.BatchOperation{
+			Query:     `mutation CreateCue($input: CreateCueInput!) { createCue(input: $input) { id } }`,
+			Variables: map[string]interface{}{"input": input},
+		})
+	}
+	require.NoError(t, client.QueryBatch(ctx, ops))
+
+	order := []int{4, 1, 3, 2, 0} // indexes into created by cue number
+	content, rows := exportCueSheet(t, client, ctx, cueListID)
+
+	t.Run("RowsMatchCueList", func(t *testing.T) {
+		require.Len(t, rows, len(created))
+		for i, idx := range order {
+			want := created[idx]
+			got := rows[i]
+			assert.Equal(t, want.CueNumber, got.CueNumber, "row %d", i)
+			assert.Equal(t, want.Name, got.Name, "row %d", i)
+			assert.Equal(t, want.LookName, got.LookName, "row %d", i)
+			assert.InDelta(t, want.FadeInTime, got.FadeInTime, 0.001, "row %d", i)
+			assert.InDelta(t, want.FadeOutTime, got.FadeOutTime, 0.001, "row %d", i)
+			assert.Equal(t, want.FollowTime, got.FollowTime, "row %d", i)
+			assert.Equal(t, want.Notes, got.Notes, "row %d", i)
+		}
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		records, err := csv.NewReader(strings.NewReader(content)).ReadAll()
+		require.NoError(t, err, "The cue sheet should be valid CSV:\n%s", content)
+		require.Len(t, records, len(created)+1)
+		assert.Equal(t, cueSheetHeader, records[0])
+
+		for i, idx := range order {
+			want := created[idx]
+			record := records[i+1]
+			assert.Equal(t, formatCueNumber(want.CueNumber), record[0], "Cue numbers keep their decimals and drop trailing zeros")
+			assert.Equal(t, want.Name, record[1], "Names survive quoting and unicode")
+			assert.Equal(t, want.LookName, record[2])
+			assert.Equal(t, formatCueNumber(want.FadeInTime), record[3])
+			if want.FollowTime == nil {
+				assert.Empty(t, record[5], "No follow time is an empty cell")
+			} else {
+				assert.Equal(t, formatCueNumber(*want.FollowTime), record[5])
+			}
+			if want.Notes != nil {
+				assert.Equal(t, *want.Notes, record[6], "Notes keep their line breaks")
+			}
+		}
+	})
+
+	t.Run("RoundTripImport", func(t *testing.T) {
+		compat.RequireMutation(t, client, "importCueSheet", cueSheetContract)
+
+		copyID, err := fixtures.CreateCueList(ctx, client, projectID, "Imported Cue Sheet", nil)
+		require.NoError(t, err)
+
+		var importResp struct {
+			ImportCueSheet struct {
+				CuesCreated int      `json:"cuesCreated"`
+				Warnings    []string `json:"warnings"`
+			} `json:"importCueSheet"`
+		}
+		err = client.Mutate(ctx, `
+			mutation ImportCueSheet($cueListId: ID!, $csv: String!) {
+				importCueSheet(cueListId: $cueListId, csv: $csv) { cuesCreated warnings }
+			}
+		`, map[string]interface{}{"cueListId": copyID, "csv": content}, &importResp)
+		require.NoError(t, err)
+		assert.Equal(t, len(created), importResp.ImportCueSheet.CuesCreated)
+		assert.Empty(t, importResp.ImportCueSheet.Warnings)
+
+		assert.Equal(t, readSheetCues(t, client, ctx, cueListID), readSheetCues(t, client, ctx, copyID),
+			"Importing an exported cue sheet should reproduce the cue list")
+
+		_, reexported := exportCueSheet(t, client, ctx, copyID)
+		assert.Equal(t, rows, reexported)
+	})
+
+	t.Run("ImportUnknownLook", func(t *testing.T) {
+		compat.RequireMutation(t, client, "importCueSheet", cueSheetContract)
+
+		copyID, err := fixtures.CreateCueList(ctx, client, projectID, "Partial Cue Sheet", nil)
+		require.NoError(t, err)
+
+		sheet := strings.Join(cueSheetHeader, ",") + "\n" +
+			"1,Known,Préshow Wash,1,1,,\n" +
+			"2,Unknown,No Such Look,1,1,,\n"
+		var importResp struct {
+			ImportCueSheet struct {
+				CuesCreated int      `json:"cuesCreated"`
+				Warnings    []string `json:"warnings"`
+			} `json:"importCueSheet"`
+		}
+		err = client.Mutate(ctx, `
+			mutation ImportCueSheet($cueListId: ID!, $csv: String!) {
+				importCueSheet(cueListId: $cueListId, csv: $csv) { cuesCreated warnings }
+			}
+		`, map[string]interface{}{"cueListId": copyID, "csv": sheet}, &importResp)
+		require.NoError(t, err)
+		assert.Equal(t, 1, importResp.ImportCueSheet.CuesCreated)
+		require.Len(t, importResp.ImportCueSheet.Warnings, 1)
+		assert.Contains(t, importResp.ImportCueSheet.Warnings[0], "No Such Look")
+		assert.Len(t, readSheetCues(t, client, ctx, copyID), 1)
+	})
+}
@@ -0,0 +1,377 @@
+package importexport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// effectLibraryContract is the API shape the effect library tests expect.
+const effectLibraryContract = `exportEffect(effectId: ID!): EffectExport! { effectName, jsonContent }
+  importEffect(projectId: ID!, jsonContent: String!, fixtureMapping: [EffectFixtureMappingInput!]): ImportEffectResult!
+  EffectFixtureMappingInput { sourceFixtureName: String!, targetFixtureId: ID! }
+  ImportEffectResult { effectId, warnings: [String!]!, unmappedFixtures: [String!]! }
+  Fixtures are matched by name unless fixtureMapping is given; unmatched fixtures are skipped and reported`
+
+// libraryEffect is an effect as read back for comparison, with fixtures by name.
+type libraryEffect struct {
+	Name      string
+	Waveform  string
+	Frequency float64
+	Amplitude float64
+	Offset    float64
+	Fixtures  map[string]libraryEffectFixture
+}
+
+type libraryEffectFixture struct {
+	PhaseOffset    float64
+	ChannelOffsets []int
+}
+
+// createNamedFixtures creates a project with one RGB fixture per name and returns fixture IDs by name.
+func createNamedFixtures(t *testing.T, client *graphql.Client, ctx context.Context, projectName, definitionID string, names []string, startChannel int) (string, map[string]string) {
+	var projectResp struct {
+		CreateProject struct {
+			ID string `json:"id"`
+		} `json:"createProject"`
+	}
+	err := client.Mutate(ctx, `
+		mutation CreateProject($input: CreateProjectInput!) {
+			createProject(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{"name": projectName},
+	}, &projectResp)
+	require.NoError(t, err)
+	projectID := projectResp.CreateProject.ID
+
+	fixtureIDs := make(map[string]string, len(names))
+	for i, name := range names {
+		var fixtureResp struct {
+			CreateFixtureInstance struct {
+				ID string `json:"id"`
+			} `json:"createFixtureInstance"`
+		}
+		err := client.Mutate(ctx, `
+			mutation CreateFixtureInstance($input: CreateFixtureInstanceInput!) {
+				createFixtureInstance(input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"projectId":    projectID,
+				"definitionId": definitionID,
+				"name":         name,
+				"universe":     1,
+				"startChannel": startChannel + i*4,
+			},
+		}, &fixtureResp)
+		require.NoError(t, err)
+		fixtureIDs[name] = fixtureResp.CreateFixtureInstance.ID
+	}
+	return projectID, fixtureIDs
+}
+
+// readLibraryEffect reads an effect and keys its fixtures by name using fixtureNames (ID -> name).
+func readLibraryEffect(t *testing.T, client *graphql.Client, ctx context.Context, effectID string, fixtureNames map[string]string) libraryEffect {
+	var resp struct {
+		Effect struct {
+			Name      string  `json:"name"`
+			Waveform  string  `json:"waveform"`
+			Frequency float64 `json:"frequency"`
+			Amplitude float64 `json:"amplitude"`
+			Offset    float64 `json:"offset"`
+			Fixtures  []struct {
+				FixtureID   string  `json:"fixtureId"`
+				PhaseOffset float64 `json:"phaseOffset"`
+				Channels    []struct {
+					ChannelOffset int `json:"channelOffset"`
+				} `json:"channels"`
+			} `json:"fixtures"`
+		} `json:"effect"`
+	}
+	err := client.Query(ctx, `
+		query GetLibraryEffect($id: ID!) {
+			effect(id: $id) {
+				name
+				waveform
+				frequency
+				amplitude
+				offset
+				fixtures {
+					fixtureId
+					phaseOffset
+					channels { channelOffset }
+				}
+			}
+		}
+	`, map[string]interface{}{"id": effectID}, &resp)
+	require.NoError(t, err)
+
+	effect := libraryEffect{
+		Name:      resp.Effect.Name,
+		Waveform:  resp.Effect.Waveform,
+		Frequency: resp.Effect.Frequency,
+		Amplitude: resp.Effect.Amplitude,
+		Offset:    resp.Effect.Offset,
+		Fixtures:  make(map[string]libraryEffectFixture, len(resp.Effect.Fixtures)),
+	}
+	for _, f := range resp.Effect.Fixtures {
+		name, ok := fixtureNames[f.FixtureID]
+		require.True(t, ok, "Effect references fixture %s from outside its project", f.FixtureID)
+
+		offsets := make([]int, 0, len(f.Channels))
+		for _, ch := range f.Channels {
+			offsets = append(offsets, ch.ChannelOffset)
+		}
+		sort.Ints(offsets)
+		effect.Fixtures[name] = libraryEffectFixture{PhaseOffset: f.PhaseOffset, ChannelOffsets: offsets}
+	}
+	return effect
+}
+
+// invert turns a name -> ID map into ID -> name.
+func invert(m map[string]string) map[string]string {
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[v] = k
+	}
+	return out
+}
+
+type importEffectResult struct {
+	EffectID         string   `json:"effectId"`
+	Warnings         []string `json:"warnings"`
+	UnmappedFixtures []string `json:"unmappedFixtures"`
+}
+
+func importEffect(t *testing.T, client *graphql.Client, ctx context.Context, projectID, jsonContent string, mapping []map[string]interface{}) importEffectResult {
+	vars := map[string]interface{}{"projectId": projectID, "jsonContent": jsonContent}
+	if mapping != nil {
+		vars["fixtureMapping"] = mapping
+	}
+
+	var resp struct {
+		ImportEffect importEffectResult `json:"importEffect"`
+	}
+	err := client.Mutate(ctx, `
+		mutation ImportEffect($projectId: ID!, $jsonContent: String!, $fixtureMapping: [EffectFixtureMappingInput!]) {
+			importEffect(projectId: $projectId, jsonContent: $jsonContent, fixtureMapping: $fixtureMapping) {
+				effectId
+				warnings
+				unmappedFixtures
+			}
+		}
+	`, vars, &resp)
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.ImportEffect.EffectID)
+	return resp.ImportEffect
+}
+
+// TestEffectLibraryExportImport exports a two-fixture sine chase as JSON and
+// imports it into other projects: one with fixtures of the same names (matched
+// by name), one with different names (matched through an explicit mapping, and
+// reported as unmapped without one). Waveform parameters, phase offsets and
+// channel mappings must survive every trip.
+func TestEffectLibraryExportImport(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewClient("")
+	compat.RequireMutation(t, client, "exportEffect", effectLibraryContract)
+	compat.RequireMutation(t, client, "importEffect", effectLibraryContract)
+
+	var defResp struct {
+		CreateFixtureDefinition struct {
+			ID string `json:"id"`
+		} `json:"createFixtureDefinition"`
+	}
+	err := client.Mutate(ctx, `
+		mutation CreateFixtureDefinition($input: CreateFixtureDefinitionInput!) {
+			createFixtureDefinition(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"manufacturer": "Test Effect Library",
+			"model":        fmt.Sprintf("Library Par %d", time.Now().UnixNano()),
+			"type":         "LED_PAR",
+			"channels": []map[string]interface{}{
+				{"name": "Dimmer", "type": "INTENSITY", "offset": 0, "minValue": 0, "maxValue": 255, "defaultValue": 0},
+				{"name": "Red", "type": "RED", "offset": 1, "minValue": 0, "maxValue": 255, "defaultValue": 0},
+				{"name": "Green", "type": "GREEN", "offset": 2, "minValue": 0, "maxValue": 255, "defaultValue": 0},
+				{"name": "Blue", "type": "BLUE", "offset": 3, "minValue": 0, "maxValue": 255, "defaultValue": 0},
+			},
+		},
+	}, &defResp)
+	require.NoError(t, err)
+	definitionID := defResp.CreateFixtureDefinition.ID
+
+	var projectIDs []string
+	defer func() {
+		for _, id := range projectIDs {
+			_ = client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
+				map[string]interface{}{"id": id}, nil)
+		}
+		_ = client.Mutate(ctx, `mutation DeleteFixtureDefinition($id: ID!) { deleteFixtureDefinition(id: $id) }`,
+			map[string]interface{}{"id": definitionID}, nil)
+	}()
+
+	sourceNames := []string{"Front Left", "Front Right"}
+	sourceID, sourceFixtures := createNamedFixtures(t, client, ctx, "Effect Library Source", definitionID, sourceNames, 1)
+	projectIDs = append(projectIDs, sourceID)
+
+	var effectResp struct {
+		CreateEffect struct {
+			ID string `json:"id"`
+		} `json:"createEffect"`
+	}
+	err = client.Mutate(ctx, `
+		mutation CreateEffect($input: CreateEffectInput!) {
+			createEffect(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId":  sourceID,
+			"name":       "Shared Chase",
+			"effectType": "WAVEFORM",
+			"waveform":   "SINE",
+			"frequency":  1.5,
+			"amplitude":  80.0,
+			"offset":     40.0,
+		},
+	}, &effectResp)
+	require.NoError(t, err)
+	effectID := effectResp.CreateEffect.ID
+
+	// Front Left drives red and green at phase 0; Front Right drives blue at phase 90
+	mappings := map[string]struct {
+		phase    float64
+		channels []int
+	}{
+		"Front Left":  {0, []int{1, 2}},
+		"Front Right": {90, []int{3}},
+	}
+	for name, m := range mappings {
+		var efResp struct {
+			AddFixtureToEffect struct {
+				ID string `json:"id"`
+			} `json:"addFixtureToEffect"`
+		}
+		err := client.Mutate(ctx, `
+			mutation AddFixtureToEffect($input: AddFixtureToEffectInput!) {
+				addFixtureToEffect(input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"effectId":    effectID,
+				"fixtureId":   sourceFixtures[name],
+				"phaseOffset": m.phase,
+			},
+		}, &efResp)
+		require.NoError(t, err)
+
+		for _, offset := range m.channels {
+			err := client.Mutate(ctx, `
+				mutation AddChannelToEffectFixture($effectFixtureId: ID!, $input: EffectChannelInput!) {
+					addChannelToEffectFixture(effectFixtureId: $effectFixtureId, input: $input) { id }
+				}
+			`, map[string]interface{}{
+				"effectFixtureId": efResp.AddFixtureToEffect.ID,
+				"input":           map[string]interface{}{"channelOffset": offset},
+			}, nil)
+			require.NoError(t, err)
+		}
+	}
+
+	source := readLibraryEffect(t, client, ctx, effectID, invert(sourceFixtures))
+	require.Len(t, source.Fixtures, 2)
+
+	var exportResp struct {
+		ExportEffect struct {
+			EffectName  string `json:"effectName"`
+			JSONContent string `json:"jsonContent"`
+		} `json:"exportEffect"`
+	}
+	err = client.Mutate(ctx, `
+		mutation ExportEffect($effectId: ID!) {
+			exportEffect(effectId: $effectId) {
+				effectName
+				jsonContent
+			}
+		}
+	`, map[string]interface{}{"effectId": effectID}, &exportResp)
+	require.NoError(t, err)
+	jsonContent := exportResp.ExportEffect.JSONContent
+
+	t.Run("ExportIsPortableJSON", func(t *testing.T) {
+		assert.Equal(t, "Shared Chase", exportResp.ExportEffect.EffectName)
+
+		var parsed map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(jsonContent), &parsed), "Exported effect should be valid JSON")
+
+		for _, name := range sourceNames {
+			assert.Contains(t, jsonContent, name, "Export should identify fixtures by name")
+		}
+		for name, id := range sourceFixtures {
+			assert.NotContains(t, jsonContent, id, "Export must not depend on the source ID of %s", name)
+		}
+		assert.NotContains(t, jsonContent, sourceID, "Export must not depend on the source project ID")
+	})
+
+	t.Run("ImportMatchesByName", func(t *testing.T) {
+		// Same names, different patch: mapping is by name, not by channel
+		targetID, targetFixtures := createNamedFixtures(t, client, ctx, "Effect Library Same Names", definitionID,
+			[]string{"Front Right", "Front Left"}, 101)
+		projectIDs = append(projectIDs, targetID)
+
+		result := importEffect(t, client, ctx, targetID, jsonContent, nil)
+		assert.Empty(t, result.UnmappedFixtures)
+
+		imported := readLibraryEffect(t, client, ctx, result.EffectID, invert(targetFixtures))
+		assert.Equal(t, source, imported, "Imported effect should match the source fixture for fixture by name")
+	})
+
+	t.Run("ImportWithExplicitMapping", func(t *testing.T) {
+		targetID, targetFixtures := createNamedFixtures(t, client, ctx, "Effect Library Renamed", definitionID,
+			[]string{"Stage Left", "Stage Right"}, 1)
+		projectIDs = append(projectIDs, targetID)
+
+		result := importEffect(t, client, ctx, targetID, jsonContent, []map[string]interface{}{
+			{"sourceFixtureName": "Front Left", "targetFixtureId": targetFixtures["Stage Left"]},
+			{"sourceFixtureName": "Front Right", "targetFixtureId": targetFixtures["Stage Right"]},
+		})
+		assert.Empty(t, result.UnmappedFixtures)
+
+		imported := readLibraryEffect(t, client, ctx, result.EffectID, invert(targetFixtures))
+		assert.Equal(t, source.Waveform, imported.Waveform)
+		assert.Equal(t, source.Frequency, imported.Frequency)
+		assert.Equal(t, source.Amplitude, imported.Amplitude)
+		assert.Equal(t, source.Offset, imported.Offset)
+		assert.Equal(t, source.Fixtures["Front Left"], imported.Fixtures["Stage Left"])
+		assert.Equal(t, source.Fixtures["Front Right"], imported.Fixtures["Stage Right"])
+	})
+
+	t.Run("UnmatchedFixturesReported", func(t *testing.T) {
+		targetID, targetFixtures := createNamedFixtures(t, client, ctx, "Effect Library Partial", definitionID,
+			[]string{"Front Left", "Back Light"}, 1)
+		projectIDs = append(projectIDs, targetID)
+
+		result := importEffect(t, client, ctx, targetID, jsonContent, nil)
+		assert.Equal(t, []string{"Front Right"}, result.UnmappedFixtures)
+		if len(result.Warnings) > 0 {
+			t.Logf("Import warnings: %s", strings.Join(result.Warnings, "; "))
+		}
+
+		imported := readLibraryEffect(t, client, ctx, result.EffectID, invert(targetFixtures))
+		assert.Len(t, imported.Fixtures, 1, "Only the matched fixture should be imported")
+		assert.Equal(t, source.Fixtures["Front Left"], imported.Fixtures["Front Left"])
+	})
+}
@@ -0,0 +1,318 @@
+package importexport
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/fixtures"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// projectArchiveContract is the zip project export these tests expect.
+const projectArchiveContract = `mutation exportProjectArchive(projectId: ID!): ProjectArchive! { fileName: String!, base64Content: String! }
+  a zip holding project.json (the exportProject JSON) and fixture-definitions/<name>.json for every
+  custom definition the project uses: { manufacturer, model, type, channels: [{ name, type, offset, minValue, maxValue, defaultValue }] }
+  mutation importProjectArchive(base64Content: String!, options: ImportOptionsInput!): ImportProjectResult!
+  definitions are matched to the library by manufacturer, model and channels, created when missing,
+  and instances are linked to the matched or created definition, never to the archived ID
+  FixtureInstance.definitionId: ID!`
+
+// archivedDefinition is a fixture definition as stored in a project archive.
+type archivedDefinition struct {
+	Manufacturer string `json:"manufacturer"`
+	Model        string `json:"model"`
+	Type         string `json:"type"`
+	Channels     []struct {
+		Name   string `json:"name"`
+		Type   string `json:"type"`
+		Offset int    `json:"offset"`
+	} `json:"channels"`
+}
+
+// archiveFixture is a fixture instance of an imported project.
+type archiveFixture struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	DefinitionID string `json:"definitionId"`
+	StartChannel int    `json:"startChannel"`
+}
+
+// readArchive decodes a base64 zip into its files by name.
+func readArchive(t *testing.T, content string) map[string][]byte {
+	raw, err := base64.StdEncoding.DecodeString(content)
+	require.NoError(t, err, "The archive should be base64")
+	reader, err := zip.NewReader(bytes.NewReader(raw), int64(len(raw)))
+	require.NoError(t, err, "The archive should be a zip")
+
+	files := make(map[string][]byte, len(reader.File))
+	for _, f := range reader.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		_ = rc.Close()
+		require.NoError(t, err)
+		files[f.Name] = data
+	}
+	return files
+}
+
+// libraryDefinition is a fixture definition in the server's library.
+type libraryDefinition struct {
+	ID       string `json:"id"`
+	Model    string `json:"model"`
+	Channels []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	} `json:"channels"`
+}
+
+// definitionsByManufacturer returns the library definitions of a manufacturer.
+func definitionsByManufacturer(t *testing.T, client *graphql.Client, ctx context.Context, manufacturer string) []libraryDefinition {
+	var resp struct {
+		FixtureDefinitions []libraryDefinition `json:"fixtureDefinitions"`
+	}
+	err := client.Query(ctx, `
+		query DefinitionsByManufacturer($filter: FixtureDefinitionFilter) {
+			fixtureDefinitions(filter: $filter) { id model channels { name type } }
+		}
+	`, map[string]interface{}{"filter": map[string]interface{}{"manufacturer": manufacturer}}, &resp)
+	require.NoError(t, err)
+	return resp.FixtureDefinitions
+}
+
+// importArchive imports an archive as a new project and returns its ID and
+// how many fixture definitions the import created.
+func importArchive(t *testing.T, client *graphql.Client, ctx context.Context, content, projectName string) (string, int) {
+	var resp struct {
+		ImportProjectArchive struct {
+			ProjectID string   `json:"projectId"`
+			Warnings  []string `json:"warnings"`
+			Stats     struct {
+				FixtureDefinitionsCreated int `json:"fixtureDefinitionsCreated"`
+			} `json:"stats"`
+		} `json:"importProjectArchive"`
+	}
+	err := client.Mutate(ctx, `
+		mutation ImportProjectArchive($base64Content: String!, $options: ImportOptionsInput!) {
+			importProjectArchive(base64Content: $base64Content, options: $options) {
+				projectId
+				warnings
+				stats { fixtureDefinitionsCreated }
+			}
+		}
+	`, map[string]interface{}{
+		"base64Content": content,
+		"options":       map[string]interface{}{"mode": "CREATE", "projectName": projectName},
+	}, &resp)
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.ImportProjectArchive.ProjectID)
+	for _, w := range resp.ImportProjectArchive.Warnings {
+		t.Logf("Import warning: %s", w)
+	}
+	return resp.ImportProjectArchive.ProjectID, resp.ImportProjectArchive.Stats.FixtureDefinitionsCreated
+}
+
+// importedFixtures returns a project's fixtures by name.
+func importedFixtures(t *testing.T, client *graphql.Client, ctx context.Context, projectID string) map[string]archiveFixture {
+	var resp struct {
+		FixtureInstances struct {
+			Fixtures []archiveFixture `json:"fixtures"`
+		} `json:"fixtureInstances"`
+	}
+	err := client.Query(ctx, `
+		query ImportedFixtures($projectId: ID!) {
+			fixtureInstances(projectId: $projectId) {
+				fixtures { id name definitionId startChannel }
+			}
+		}
+	`, map[string]interface{}{"projectId": projectID}, &resp)
+	require.NoError(t, err)
+
+	byName := make(map[string]archiveFixture, len(resp.FixtureInstances.Fixtures))
+	for _, f := range resp.FixtureInstances.Fixtures {
+		byName[f.Name] = f
+	}
+	return byName
+}
+
+// TestProjectArchiveRestoresDefinitions exports a project using a custom
+// fixture definition as a zip, deletes the definition, and imports the
+// archive. The definition must come back from the archive and the fixtures
+// must point at it, and a second import must reuse it rather than add another.
+func TestProjectArchiveRestoresDefinitions(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewClient("")
+	compat.RequireMutation(t, client, "exportProjectArchive", projectArchiveContract)
+	compat.RequireMutation(t, client, "importProjectArchive", projectArchiveContract)
+	compat.RequireTypeField(t, client, "FixtureInstance", "definitionId", projectArchiveContract)
+
+	manufacturer := fmt.Sprintf("Archive Test %d", time.Now().UnixNano())
+	definitionID, err := fixtures.CreateParDefinition(ctx, client, manufacturer, "Archive Par")
+	require.NoError(t, err)
+	projectID, err := fixtures.CreateProject(ctx, client, "Archive Source Project")
+	require.NoError(t, err)
+
+	var importedIDs []string
+	defer func() {
+		for _, id := range append(importedIDs, projectID) {
+			_ = fixtures.DeleteProject(ctx, client, id)
+		}
+		for _, def := range definitionsByManufacturer(t, client, ctx, manufacturer) {
+			_ = fixtures.DeleteFixtureDefinition(ctx, client, def.ID)
+		}
+	}()
+
+	fixtureIDs := make(map[string]string)
+	for i, name := range []string{"Archive Par 1", "Archive Par 2"} {
+		fixtureIDs[name], err = fixtures.CreateFixture(ctx, client, projectID, definitionID,
+			fixtures.Fixture{Name: name, Universe: 1, StartChannel: 1 + i*4})
+		require.NoError(t, err)
+	}
+	_, err = fixtures.CreateLook(ctx, client, projectID, "Archive Look", []fixtures.FixtureValues{
+		{FixtureID: fixtureIDs["Archive Par 1"], Values: []int{255, 10, 20, 30}},
+		{FixtureID: fixtureIDs["Archive Par 2"], Values: []int{128, 40, 50, 60}},
+	})
+	require.NoError(t, err)
+
+	var exportResp struct {
+		ExportProjectArchive struct {
+			FileName      string `json:"fileName"`
+			Base64Content string `json:"base64Content"`
+		} `json:"exportProjectArchive"`
+	}
+	err = client.Mutate(ctx, `
+		mutation ExportProjectArchive($projectId: ID!) {
+			exportProjectArchive(projectId: $projectId) { fileName base64Content }
+		}
+	`, map[string]interface{}{"projectId": projectID}, &exportResp)
+	require.NoError(t, err)
+	archive := exportResp.ExportProjectArchive.Base64Content
+
+	t.Run("ArchiveContents", func(t *testing.T) {
+		assert.True(t, strings.HasSuffix(exportResp.ExportProjectArchive.FileName, ".zip"),
+			"Archive file name %q should end in .zip", exportResp.ExportProjectArchive.FileName)
+
+		files := readArchive(t, archive)
+		project, ok := files["project.json"]
+		require.True(t, ok, "The archive should hold project.json")
+		var projectJSON map[string]interface{}
+		require.NoError(t, json.Unmarshal(project, &projectJSON), "project.json should be valid JSON")
+
+		var found []archivedDefinition
+		for name, data := range files {
+			if !strings.HasPrefix(name, "fixture-definitions/") {
+				continue
+			}
+			var def archivedDefinition
+			require.NoError(t, json.Unmarshal(data, &def), "%s should be valid JSON", name)
+			if def.Manufacturer == manufacturer {
+				found = append(found, def)
+			}
+		}
+		require.Len(t, found, 1, "The custom definition should be archived once")
+		assert.Equal(t, "Archive Par", found[0].Model)
+		require.Len(t, found[0].Channels, len(fixtures.ParChannels))
+		for i, want := range fixtures.ParChannels {
+			assert.Equal(t, want["name"], found[0].Channels[i].Name)
+			assert.Equal(t, want["type"], found[0].Channels[i].Type)
+			assert.Equal(t, want["offset"], found[0].Channels[i].Offset)
+		}
+	})
+
+	// Wipe the project and the custom definition, as on a fresh server
+	require.NoError(t, fixtures.DeleteProject(ctx, client, projectID))
+	require.NoError(t, fixtures.DeleteFixtureDefinition(ctx, client, definitionID))
+	require.Empty(t, definitionsByManufacturer(t, client, ctx, manufacturer), "The custom definition should be gone")
+
+	var restoredID string
+
+	t.Run("ImportRestoresDefinition", func(t *testing.T) {
+		importedID, created := importArchive(t, client, ctx, archive, "Archive Restored Project")
+		importedIDs = append(importedIDs, importedID)
+		assert.Equal(t, 1, created, "The missing definition should be created from the archive")
+
+		defs := definitionsByManufacturer(t, client, ctx, manufacturer)
+		require.Len(t, defs, 1)
+		restoredID = defs[0].ID
+		assert.Equal(t, "Archive Par", defs[0].Model)
+		require.Len(t, defs[0].Channels, len(fixtures.ParChannels))
+		for i, want := range fixtures.ParChannels {
+			assert.Equal(t, want["type"], defs[0].Channels[i].Type)
+		}
+		if restoredID == definitionID {
+			t.Logf("Contract: the restored definition reuses its archived ID")
+		}
+
+		imported := importedFixtures(t, client, ctx, importedID)
+		require.Len(t, imported, 2)
+		for name, f := range imported {
+			assert.Equal(t, restoredID, f.DefinitionID, "%s should use the restored definition", name)
+		}
+		assert.Equal(t, 5, imported["Archive Par 2"].StartChannel)
+
+		var lookResp struct {
+			Looks struct {
+				Looks []struct {
+					Name          string `json:"name"`
+					FixtureValues []struct {
+						FixtureID string `json:"fixtureId"`
+						Channels  []struct {
+							Offset int `json:"offset"`
+							Value  int `json:"value"`
+						} `json:"channels"`
+					} `json:"fixtureValues"`
+				} `json:"looks"`
+			} `json:"looks"`
+		}
+		err := client.Query(ctx, `
+			query ImportedLooks($projectId: ID!) {
+				looks(projectId: $projectId) {
+					looks { name fixtureValues { fixtureId channels { offset value } } }
+				}
+			}
+		`, map[string]interface{}{"projectId": importedID}, &lookResp)
+		require.NoError(t, err)
+		require.Len(t, lookResp.Looks.Looks, 1)
+
+		dimmers := make(map[string]int)
+		for _, fv := range lookResp.Looks.Looks[0].FixtureValues {
+			for _, ch := range fv.Channels {
+				if ch.Offset == 0 {
+					dimmers[fv.FixtureID] = ch.Value
+				}
+			}
+		}
+		assert.Equal(t, map[string]int{
+			imported["Archive Par 1"].ID: 255,
+			imported["Archive Par 2"].ID: 128,
+		}, dimmers, "The look should point at the imported fixtures")
+	})
+
+	t.Run("SecondImportReusesDefinition", func(t *testing.T) {
+		require.NotEmpty(t, restoredID, "Needs the restored definition")
+
+		importedID, created := importArchive(t, client, ctx, archive, "Archive Second Import")
+		importedIDs = append(importedIDs, importedID)
+		assert.Zero(t, created, "A matching definition already exists")
+
+		defs := definitionsByManufacturer(t, client, ctx, manufacturer)
+		require.Len(t, defs, 1, "Importing again should not duplicate the definition")
+
+		for name, f := range importedFixtures(t, client, ctx, importedID) {
+			assert.Equal(t, restoredID, f.DefinitionID, "%s should be linked by content to the existing definition", name)
+		}
+	})
+}
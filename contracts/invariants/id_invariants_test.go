@@ -0,0 +1,316 @@
+// Package invariants contains contract tests for properties that must hold
+// across every entity type, rather than for one feature.
+package invariants
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/bbernstein/lacylights-test/pkg/artifacts"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reuseCycles is how many create/delete rounds each entity type goes through.
+const reuseCycles = 5
+
+// issuedIDs records every ID the server handed out during this run, by entity type.
+var issuedIDs = struct {
+	sync.Mutex
+	kinds map[string]string
+}{kinds: map[string]string{}}
+
+// recordID fails if the server has already issued id in this run, to this or any other entity.
+func recordID(t *testing.T, kind, id string) {
+	t.Helper()
+	issuedIDs.Lock()
+	defer issuedIDs.Unlock()
+
+	if previous, seen := issuedIDs.kinds[id]; seen {
+		t.Errorf("ID %q issued for a new %s was already issued for a %s in this run", id, kind, previous)
+	}
+	issuedIDs.kinds[id] = kind
+}
+
+// invariantEnv holds the parents that child entities are created under.
+type invariantEnv struct {
+	client       *graphql.Client
+	ctx          context.Context
+	projectID    string
+	definitionID string
+	lookID       string
+	cueListID    string
+	nextChannel  int
+	nextCue      int
+}
+
+// entityKind describes how to create, fetch and delete one entity type.
+type entityKind struct {
+	name   string // GraphQL field for fetching one entity by ID, e.g. "look"
+	create func(t *testing.T, env *invariantEnv) string
+	delete string // delete mutation taking id: ID!
+}
+
+// mutateID runs a create mutation and returns the new entity's id.
+func mutateID(t *testing.T, env *invariantEnv, field, mutation string, input map[string]interface{}) string {
+	t.Helper()
+	var resp map[string]struct {
+		ID string `json:"id"`
+	}
+	err := env.client.Mutate(env.ctx, mutation, map[string]interface{}{"input": input}, &resp)
+	require.NoError(t, err)
+	id := resp[field].ID
+	require.NotEmpty(t, id, "%s should return an id", field)
+	return id
+}
+
+func createProject(t *testing.T, env *invariantEnv, name string) string {
+	return mutateID(t, env, "createProject", `
+		mutation CreateProject($input: CreateProjectInput!) {
+			createProject(input: $input) { id }
+		}
+	`, map[string]interface{}{"name": name})
+}
+
+func createChildProject(t *testing.T, env *invariantEnv) string {
+	return createProject(t, env, "Invariant Child Project")
+}
+
+func createDefinition(t *testing.T, env *invariantEnv) string {
+	return mutateID(t, env, "createFixtureDefinition", `
+		mutation CreateFixtureDefinition($input: CreateFixtureDefinitionInput!) {
+			createFixtureDefinition(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"manufacturer": "Test Invariants",
+		"model":        fmt.Sprintf("Invariant Dimmer %d", time.Now().UnixNano()),
+		"type":         "DIMMER",
+		"channels": []map[string]interface{}{
+			{"name": "Dimmer", "type": "INTENSITY", "offset": 0, "minValue": 0, "maxValue": 255, "defaultValue": 0},
+		},
+	})
+}
+
+func createFixture(t *testing.T, env *invariantEnv) string {
+	env.nextChannel++
+	return mutateID(t, env, "createFixtureInstance", `
+		mutation CreateFixtureInstance($input: CreateFixtureInstanceInput!) {
+			createFixtureInstance(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"projectId":    env.projectID,
+		"definitionId": env.definitionID,
+		"name":         fmt.Sprintf("Invariant Dimmer %d", env.nextChannel),
+		"universe":     1,
+		"startChannel": env.nextChannel,
+	})
+}
+
+func createLook(t *testing.T, env *invariantEnv) string {
+	return mutateID(t, env, "createLook", `
+		mutation CreateLook($input: CreateLookInput!) {
+			createLook(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"projectId":     env.projectID,
+		"name":          "Invariant Look",
+		"fixtureValues": []map[string]interface{}{},
+	})
+}
+
+func createCueList(t *testing.T, env *invariantEnv) string {
+	return mutateID(t, env, "createCueList", `
+		mutation CreateCueList($input: CreateCueListInput!) {
+			createCueList(input: $input) { id }
+		}
+	`, map[string]interface{}{"projectId": env.projectID, "name": "Invariant Cue List"})
+}
+
+func createCue(t *testing.T, env *invariantEnv) string {
+	env.nextCue++
+	return mutateID(t, env, "createCue", `
+		mutation CreateCue($input: CreateCueInput!) {
+			createCue(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"cueListId":   env.cueListID,
+		"lookId":      env.lookID,
+		"name":        "Invariant Cue",
+		"cueNumber":   float64(env.nextCue),
+		"fadeInTime":  1.0,
+		"fadeOutTime": 1.0,
+	})
+}
+
+// entityKinds are the entity types every invariant is checked against.
+var entityKinds = []entityKind{
+	{name: "project", create: createChildProject, delete: "deleteProject"},
+	{name: "fixtureDefinition", create: createDefinition, delete: "deleteFixtureDefinition"},
+	{name: "fixtureInstance", create: createFixture, delete: "deleteFixtureInstance"},
+	{name: "look", create: createLook, delete: "deleteLook"},
+	{name: "cueList", create: createCueList, delete: "deleteCueList"},
+	{name: "cue", create: createCue, delete: "deleteCue"},
+}
+
+// newInvariantEnv creates a project with a fixture definition, a look and a cue list.
+func newInvariantEnv(t *testing.T, ctx context.Context) *invariantEnv {
+	env := &invariantEnv{client: graphql.NewTestClient(t, ""), ctx: ctx}
+
+	env.projectID = createProject(t, env, "Invariant Test Project")
+	recordID(t, "project", env.projectID)
+	env.definitionID = createDefinition(t, env)
+	recordID(t, "fixtureDefinition", env.definitionID)
+
+	t.Cleanup(func() {
+		cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cleanupCancel()
+		_ = env.client.Mutate(cleanupCtx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
+			map[string]interface{}{"id": env.projectID}, nil)
+		_ = env.client.Mutate(cleanupCtx, `mutation DeleteFixtureDefinition($id: ID!) { deleteFixtureDefinition(id: $id) }`,
+			map[string]interface{}{"id": env.definitionID}, nil)
+	})
+
+	env.lookID = createLook(t, env)
+	recordID(t, "look", env.lookID)
+	env.cueListID = createCueList(t, env)
+	recordID(t, "cueList", env.cueListID)
+	return env
+}
+
+// createEntity creates one entity of the given kind, records its ID and
+// registers a best-effort delete.
+func createEntity(t *testing.T, env *invariantEnv, kind entityKind) string {
+	t.Helper()
+	id := kind.create(t, env)
+	recordID(t, kind.name, id)
+	t.Cleanup(func() {
+		cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cleanupCancel()
+		_ = deleteEntity(cleanupCtx, env, kind, id)
+	})
+	return id
+}
+
+// deleteEntity deletes one entity by ID.
+func deleteEntity(ctx context.Context, env *invariantEnv, kind entityKind, id string) error {
+	return env.client.Mutate(ctx, fmt.Sprintf(`mutation Delete($id: ID!) { %s(id: $id) }`, kind.delete),
+		map[string]interface{}{"id": id}, nil)
+}
+
+// fetchByID looks an ID up through the named entity query and returns the raw
+// field value ("null" when nothing matches) and any GraphQL errors.
+func fetchByID(t *testing.T, env *invariantEnv, field, id string) (json.RawMessage, []graphql.GraphQLError) {
+	t.Helper()
+	resp, err := env.client.Execute(env.ctx, fmt.Sprintf(`query Get($id: ID!) { %s(id: $id) { id } }`, field),
+		map[string]interface{}{"id": id})
+	require.NoError(t, err, "Looking up %s(id: %q) must not crash the server", field, id)
+
+	var data map[string]json.RawMessage
+	if len(resp.Data) > 0 && string(resp.Data) != "null" {
+		require.NoError(t, json.Unmarshal(resp.Data, &data))
+	}
+	value, ok := data[field]
+	if !ok {
+		value = json.RawMessage("null")
+	}
+	return value, resp.Errors
+}
+
+// checkStableID asserts the entity's id is a non-blank JSON string that the
+// server returns unchanged on every lookup.
+func checkStableID(t *testing.T, env *invariantEnv, kind entityKind, id string) {
+	t.Helper()
+	assert.Equal(t, strings.TrimSpace(id), id, "IDs should not carry whitespace")
+
+	for range 2 {
+		value, errs := fetchByID(t, env, kind.name, id)
+		require.Empty(t, errs, "Fetching an existing %s should not error", kind.name)
+
+		var entity map[string]interface{}
+		require.NoError(t, json.Unmarshal(value, &entity))
+		require.NotNil(t, entity, "%s %q should be found", kind.name, id)
+		fetched, isString := entity["id"].(string)
+		require.True(t, isString, "id should be a JSON string, got %T", entity["id"])
+		assert.Equal(t, id, fetched, "id should be returned exactly as issued")
+	}
+}
+
+// checkNullLookup asserts that looking id up as the given kind finds nothing.
+// An accompanying error is tolerated only when it carries a code.
+func checkNullLookup(t *testing.T, env *invariantEnv, field, id, why string) {
+	t.Helper()
+	value, errs := fetchByID(t, env, field, id)
+	assert.JSONEq(t, "null", string(value), "%s(id: %q) should be null: %s", field, id, why)
+	for _, e := range errs {
+		assert.NotEmpty(t, e.Code(), "%s(id: %q) error should carry a code: %s", field, id, e.Message)
+	}
+	if len(errs) > 0 {
+		t.Logf("Contract: %s(id) with %s returns null with error %q", field, why, errs[0].Code())
+	}
+}
+
+// TestIDsAreStableStrings checks that every entity type hands out string IDs
+// that round-trip unchanged.
+func TestIDsAreStableStrings(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	env := newInvariantEnv(t, ctx)
+
+	for _, kind := range entityKinds {
+		t.Run(kind.name, func(t *testing.T) {
+			checkStableID(t, env, kind, createEntity(t, env, kind))
+		})
+	}
+}
+
+// TestCrossTypeLookupReturnsNull fetches every entity type by the ID of every
+// other entity type. IDs are opaque, so a mismatch must simply find nothing.
+func TestCrossTypeLookupReturnsNull(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	env := newInvariantEnv(t, ctx)
+
+	ids := make(map[string]string, len(entityKinds))
+	for _, kind := range entityKinds {
+		ids[kind.name] = createEntity(t, env, kind)
+	}
+
+	for _, owner := range entityKinds {
+		for _, lookup := range entityKinds {
+			if lookup.name == owner.name {
+				continue
+			}
+			t.Run(fmt.Sprintf("%sAs%s", owner.name, lookup.name), func(t *testing.T) {
+				checkNullLookup(t, env, lookup.name, ids[owner.name], "a "+owner.name+" ID")
+			})
+		}
+	}
+}
+
+// TestDeletedIDsAreNotReused creates and deletes each entity type repeatedly.
+// Every ID must be new to the run, and a deleted ID must no longer resolve.
+func TestDeletedIDsAreNotReused(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	env := newInvariantEnv(t, ctx)
+
+	for _, kind := range entityKinds {
+		t.Run(kind.name, func(t *testing.T) {
+			for range reuseCycles {
+				id := kind.create(t, env)
+				recordID(t, kind.name, id)
+				require.NoError(t, deleteEntity(ctx, env, kind, id), "Deleting %s %q", kind.name, id)
+				checkNullLookup(t, env, kind.name, id, "a deleted ID")
+			}
+		})
+	}
+}
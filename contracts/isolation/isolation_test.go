@@ -0,0 +1,261 @@
+// Package isolation provides multi-project output isolation contract tests.
+// These tests patch each project onto its own universes and check on the
+// Art-Net wire that one project's looks never light another project's universes.
+package isolation
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	_ "github.com/bbernstein/lacylights-test/pkg/artifacts"
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// fixturesPerProject is the number of dimmers patched into each project's universe
+	fixturesPerProject = 8
+
+	// captureWindow is how long Art-Net is captured after each activation
+	captureWindow = 500 * time.Millisecond
+)
+
+// isolatedProject is a project whose fixtures are all patched into a single universe.
+type isolatedProject struct {
+	ID       string
+	Universe int // 1-indexed DMX universe
+	LookID   string
+}
+
+// createIsolatedProject creates a project with fixturesPerProject dimmers at
+// channels 1..N of the given universe and a look driving them all to level.
+func createIsolatedProject(t *testing.T, client *graphql.Client, ctx context.Context, definitionID, name string, universe, level int) isolatedProject {
+	var projectResp struct {
+		CreateProject struct {
+			ID string `json:"id"`
+		} `json:"createProject"`
+	}
+	err := client.Mutate(ctx, `
+		mutation CreateProject($input: CreateProjectInput!) {
+			createProject(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{"name": name},
+	}, &projectResp)
+	require.NoError(t, err)
+	projectID := projectResp.CreateProject.ID
+
+	fixtureValues := make([]map[string]interface{}, 0, fixturesPerProject)
+	for i := 0; i < fixturesPerProject; i++ {
+		var fixtureResp struct {
+			CreateFixtureInstance struct {
+				ID string `json:"id"`
+			} `json:"createFixtureInstance"`
+		}
+		err = client.Mutate(ctx, `
+			mutation CreateFixtureInstance($input: CreateFixtureInstanceInput!) {
+				createFixtureInstance(input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"projectId":    projectID,
+				"definitionId": definitionID,
+				"name":         fmt.Sprintf("%s Dimmer %d", name, i+1),
+				"universe":     universe,
+				"startChannel": i + 1,
+			},
+		}, &fixtureResp)
+		require.NoError(t, err)
+
+		fixtureValues = append(fixtureValues, map[string]interface{}{
+			"fixtureId": fixtureResp.CreateFixtureInstance.ID,
+			"channels":  []map[string]interface{}{{"offset": 0, "value": level}},
+		})
+	}
+
+	var lookResp struct {
+		CreateLook struct {
+			ID string `json:"id"`
+		} `json:"createLook"`
+	}
+	err = client.Mutate(ctx, `
+		mutation CreateLook($input: CreateLookInput!) {
+			createLook(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId":     projectID,
+			"name":          name + " Look",
+			"fixtureValues": fixtureValues,
+		},
+	}, &lookResp)
+	require.NoError(t, err)
+
+	return isolatedProject{ID: projectID, Universe: universe, LookID: lookResp.CreateLook.ID}
+}
+
+// captureUniverses activates a look and returns the captured frames per Art-Net universe, in order.
+func captureUniverses(t *testing.T, client *graphql.Client, ctx context.Context, receiver *artnet.Subscription, lookID string) map[int][]artnet.Frame {
+	err := client.Mutate(ctx, `
+		mutation SetLookLive($lookId: ID!) {
+			setLookLive(lookId: $lookId)
+		}
+	`, map[string]interface{}{"lookId": lookID}, nil)
+	require.NoError(t, err)
+
+	// Let the snap settle before capturing so every frame reflects the new look
+	time.Sleep(200 * time.Millisecond)
+	receiver.ClearFrames()
+	frames, err := receiver.CaptureFrames(ctx, captureWindow)
+	require.NoError(t, err)
+	require.NotEmpty(t, frames, "No Art-Net frames captured - is the server broadcasting to %s?", artnet.ListenAddrFromEnv())
+
+	byUniverse := map[int][]artnet.Frame{}
+	for _, frame := range frames {
+		byUniverse[frame.Universe] = append(byUniverse[frame.Universe], frame)
+	}
+	return byUniverse
+}
+
+// litChannels returns the 1-indexed channels of a frame that are not zero.
+func litChannels(frame artnet.Frame) []int {
+	var lit []int
+	for i, v := range frame.Channels {
+		if v != 0 {
+			lit = append(lit, i+1)
+		}
+	}
+	return lit
+}
+
+// assertDark checks that no frame lights any channel, reporting the first frame that does.
+func assertDark(t *testing.T, frames []artnet.Frame, msg string) {
+	t.Helper()
+	for i, frame := range frames {
+		if lit := litChannels(frame); len(lit) > 0 {
+			assert.Empty(t, lit, "%s (frame %d of %d)", msg, i+1, len(frames))
+			return
+		}
+	}
+}
+
+// TestProjectOutputIsolation patches project A on universe 1 and project B on
+// universe 2 at identical channel numbers, then activates each project's look in
+// turn. The active project's universe must carry its levels, and no captured
+// frame for the other project's universe may contain any non-zero channel.
+// Identical channel numbers make a universe mix-up show up as leaked data.
+func TestProjectOutputIsolation(t *testing.T) {
+	compat.Require(t, compat.ArtNet)
+
+	receiver := artnet.Capture(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewClient("")
+
+	_ = client.Mutate(ctx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+
+	var defResp struct {
+		CreateFixtureDefinition struct {
+			ID string `json:"id"`
+		} `json:"createFixtureDefinition"`
+	}
+	err := client.Mutate(ctx, `
+		mutation CreateFixtureDefinition($input: CreateFixtureDefinitionInput!) {
+			createFixtureDefinition(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"manufacturer": "Test Isolation",
+			"model":        fmt.Sprintf("Isolation Dimmer %d", time.Now().UnixNano()),
+			"type":         "DIMMER",
+			"channels": []map[string]interface{}{
+				{"name": "Dimmer", "type": "INTENSITY", "offset": 0, "minValue": 0, "maxValue": 255, "defaultValue": 0},
+			},
+		},
+	}, &defResp)
+	require.NoError(t, err)
+	definitionID := defResp.CreateFixtureDefinition.ID
+
+	projectA := createIsolatedProject(t, client, ctx, definitionID, "Isolation Project A", 1, 200)
+	projectB := createIsolatedProject(t, client, ctx, definitionID, "Isolation Project B", 2, 100)
+
+	defer func() {
+		cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cleanupCancel()
+		_ = client.Mutate(cleanupCtx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+		for _, id := range []string{projectA.ID, projectB.ID} {
+			_ = client.Mutate(cleanupCtx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
+				map[string]interface{}{"id": id}, nil)
+		}
+		_ = client.Mutate(cleanupCtx, `mutation DeleteFixtureDefinition($id: ID!) { deleteFixtureDefinition(id: $id) }`,
+			map[string]interface{}{"id": definitionID}, nil)
+	}()
+
+	tests := []struct {
+		name   string
+		active isolatedProject
+		level  int
+		other  isolatedProject
+	}{
+		{"ProjectA", projectA, 200, projectB},
+		{"ProjectB", projectB, 100, projectA},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Start from a dark stage so nothing from the previous subtest is still up
+			err := client.Mutate(ctx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+			require.NoError(t, err)
+			time.Sleep(100 * time.Millisecond)
+
+			captured := captureUniverses(t, client, ctx, receiver, tt.active.LookID)
+
+			// Art-Net universes are 0-indexed, DMX universes 1-indexed
+			activeFrames, ok := captured[tt.active.Universe-1]
+			require.True(t, ok, "No frames captured for active universe %d", tt.active.Universe)
+			activeFrame := activeFrames[len(activeFrames)-1]
+			for ch := 0; ch < fixturesPerProject; ch++ {
+				assert.Equal(t, byte(tt.level), activeFrame.Channels[ch],
+					"Universe %d channel %d should carry the active look", tt.active.Universe, ch+1)
+			}
+
+			if otherFrames, ok := captured[tt.other.Universe-1]; ok {
+				assertDark(t, otherFrames,
+					fmt.Sprintf("Universe %d belongs to the inactive project and must stay dark", tt.other.Universe))
+			} else {
+				t.Logf("No frames sent for inactive universe %d", tt.other.Universe)
+			}
+
+			// Every other universe on the wire must be dark too
+			for universe, frames := range captured {
+				if universe == tt.active.Universe-1 || universe == tt.other.Universe-1 {
+					continue
+				}
+				assertDark(t, frames, fmt.Sprintf("Unpatched Art-Net universe %d must stay dark", universe))
+			}
+
+			// The server's own view must agree with the wire
+			var dmxResp struct {
+				DMXOutput []int `json:"dmxOutput"`
+			}
+			err = client.Query(ctx, `
+				query DMXOutput($universe: Int!) {
+					dmxOutput(universe: $universe)
+				}
+			`, map[string]interface{}{"universe": tt.other.Universe}, &dmxResp)
+			require.NoError(t, err)
+			for ch, v := range dmxResp.DMXOutput {
+				if v != 0 {
+					t.Errorf("dmxOutput(universe: %d) channel %d = %d, want 0", tt.other.Universe, ch+1, v)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package latency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// bulkLooks and bulkFixtures size the bulk generation run: 500 looks x 100 fixtures
+	bulkLooks    = 500
+	bulkFixtures = 100
+
+	// bulkPerPage is the page size used when listing the generated looks
+	bulkPerPage = 50
+
+	// defaultMinLookRate is the creation throughput floor when BULK_LOOK_MIN_RATE is not set
+	defaultMinLookRate = 10.0
+
+	// defaultLookPageBudget is the per-page listing budget when LOOK_PAGE_BUDGET_MS is not set
+	defaultLookPageBudget = 500 * time.Millisecond
+
+	listLooksQuery = `
+		query ListLooks($projectId: ID!, $page: Int, $perPage: Int) {
+			looks(projectId: $projectId, page: $page, perPage: $perPage) {
+				looks { id name }
+				pagination { total page perPage hasMore totalPages }
+			}
+		}
+	`
+)
+
+// bulkProject is a project with bulkFixtures single-channel dimmers.
+type bulkProject struct {
+	client       *graphql.Client
+	projectID    string
+	definitionID string
+	fixtureIDs   []string
+}
+
+// newBulkProject creates the project and fixtures, skipping when no server is reachable.
+func newBulkProject(tb testing.TB, client *graphql.Client, ctx context.Context) *bulkProject {
+	tb.Helper()
+
+	if err := client.Query(ctx, `query { __typename }`, nil, nil); err != nil {
+		tb.Skipf("Skipping: server not reachable: %v", err)
+	}
+
+	var projectResp struct {
+		CreateProject struct {
+			ID string `json:"id"`
+		} `json:"createProject"`
+	}
+	err := client.Mutate(ctx, `
+		mutation CreateProject($input: CreateProjectInput!) {
+			createProject(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{"name": "Bulk Look Generation Project"},
+	}, &projectResp)
+	require.NoError(tb, err)
+	p := &bulkProject{client: client, projectID: projectResp.CreateProject.ID}
+
+	var defResp struct {
+		CreateFixtureDefinition struct {
+			ID string `json:"id"`
+		} `json:"createFixtureDefinition"`
+	}
+	err = client.Mutate(ctx, `
+		mutation CreateFixtureDefinition($input: CreateFixtureDefinitionInput!) {
+			createFixtureDefinition(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"manufacturer": "Test Latency",
+			"model":        fmt.Sprintf("Bulk Dimmer %d", time.Now().UnixNano()),
+			"type":         "DIMMER",
+			"channels": []map[string]interface{}{
+				{"name": "Dimmer", "type": "INTENSITY", "offset": 0, "minValue": 0, "maxValue": 255, "defaultValue": 0},
+			},
+		},
+	}, &defResp)
+	require.NoError(tb, err)
+	p.definitionID = defResp.CreateFixtureDefinition.ID
+
+	fixtures := make([]map[string]interface{}, bulkFixtures)
+	for i := range fixtures {
+		fixtures[i] = map[string]interface{}{
+			"projectId":    p.projectID,
+			"definitionId": p.definitionID,
+			"name":         fmt.Sprintf("Bulk Dimmer %d", i+1),
+			"universe":     1,
+			"startChannel": i + 1,
+		}
+	}
+
+	var bulkResp struct {
+		BulkCreateFixtures []struct {
+			ID string `json:"id"`
+		} `json:"bulkCreateFixtures"`
+	}
+	err = client.Mutate(ctx, `
+		mutation BulkCreateFixtures($input: BulkFixtureCreateInput!) {
+			bulkCreateFixtures(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{"fixtures": fixtures},
+	}, &bulkResp)
+	require.NoError(tb, err)
+	require.Len(tb, bulkResp.BulkCreateFixtures, bulkFixtures)
+	for _, f := range bulkResp.BulkCreateFixtures {
+		p.fixtureIDs = append(p.fixtureIDs, f.ID)
+	}
+
+	return p
+}
+
+func (p *bulkProject) cleanup() {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	_ = p.client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
+		map[string]interface{}{"id": p.projectID}, nil)
+	_ = p.client.Mutate(ctx, `mutation DeleteFixtureDefinition($id: ID!) { deleteFixtureDefinition(id: $id) }`,
+		map[string]interface{}{"id": p.definitionID}, nil)
+}
+
+// createLook creates look n touching every fixture and returns its ID.
+func (p *bulkProject) createLook(ctx context.Context, n int) (string, error) {
+	fixtureValues := make([]map[string]interface{}, len(p.fixtureIDs))
+	for i, id := range p.fixtureIDs {
+		fixtureValues[i] = map[string]interface{}{
+			"fixtureId": id,
+			"channels":  []map[string]interface{}{{"offset": 0, "value": (n + i) % 256}},
+		}
+	}
+
+	var resp struct {
+		CreateLook struct {
+			ID string `json:"id"`
+		} `json:"createLook"`
+	}
+	err := p.client.Mutate(ctx, `
+		mutation CreateLook($input: CreateLookInput!) {
+			createLook(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId":     p.projectID,
+			"name":          fmt.Sprintf("Bulk Look %d", n+1),
+			"fixtureValues": fixtureValues,
+		},
+	}, &resp)
+	return resp.CreateLook.ID, err
+}
+
+// minLookRate returns the creation throughput floor, honoring BULK_LOOK_MIN_RATE.
+func minLookRate(t *testing.T) float64 {
+	raw := os.Getenv("BULK_LOOK_MIN_RATE")
+	if raw == "" {
+		return defaultMinLookRate
+	}
+	rate, err := strconv.ParseFloat(raw, 64)
+	require.NoError(t, err, "BULK_LOOK_MIN_RATE must be a number of looks per second")
+	return rate
+}
+
+// lookPageBudget returns the per-page listing budget, honoring LOOK_PAGE_BUDGET_MS.
+func lookPageBudget(t *testing.T) time.Duration {
+	raw := os.Getenv("LOOK_PAGE_BUDGET_MS")
+	if raw == "" {
+		return defaultLookPageBudget
+	}
+	ms, err := strconv.Atoi(raw)
+	require.NoError(t, err, "LOOK_PAGE_BUDGET_MS must be an integer number of milliseconds")
+	return time.Duration(ms) * time.Millisecond
+}
+
+// TestBulkLookGeneration creates 500 looks that each touch 100 fixtures, checks
+// creation throughput against BULK_LOOK_MIN_RATE (looks/second), then pages through
+// looks(projectId) and checks every page stays within LOOK_PAGE_BUDGET_MS and the
+// pages together return each look exactly once.
+// This takes a while, so it only runs with BULK_LOOK_TESTS=1.
+func TestBulkLookGeneration(t *testing.T) {
+	if testing.Short() || os.Getenv("BULK_LOOK_TESTS") != "1" {
+		t.Skip("Skipping bulk look generation: set BULK_LOOK_TESTS=1 to enable")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	client := graphql.NewClient("")
+	minRate := minLookRate(t)
+	pageBudget := lookPageBudget(t)
+
+	project := newBulkProject(t, client, ctx)
+	defer project.cleanup()
+
+	start := time.Now()
+	for n := 0; n < bulkLooks; n++ {
+		_, err := project.createLook(ctx, n)
+		require.NoError(t, err, "Creating look %d failed", n+1)
+	}
+	elapsed := time.Since(start)
+	rate := float64(bulkLooks) / elapsed.Seconds()
+	t.Logf("Created %d looks x %d fixtures in %v (%.1f looks/s, floor %.1f)", bulkLooks, bulkFixtures, elapsed, rate, minRate)
+	assert.GreaterOrEqual(t, rate, minRate, "Look creation throughput regressed")
+
+	seen := make(map[string]bool, bulkLooks)
+	var slowest queryTiming
+	totalPages := bulkLooks / bulkPerPage
+	for page := 1; page <= totalPages; page++ {
+		raw, timing := measureQuery(t, client, ctx, fmt.Sprintf("ListLooks page %d", page), listLooksQuery,
+			map[string]interface{}{"projectId": project.projectID, "page": page, "perPage": bulkPerPage})
+		if timing.Duration > slowest.Duration {
+			slowest = timing
+		}
+
+		var resp struct {
+			Looks struct {
+				Looks []struct {
+					ID string `json:"id"`
+				} `json:"looks"`
+				Pagination struct {
+					Total      int  `json:"total"`
+					HasMore    bool `json:"hasMore"`
+					TotalPages int  `json:"totalPages"`
+				} `json:"pagination"`
+			} `json:"looks"`
+		}
+		require.NoError(t, json.Unmarshal(raw, &resp))
+
+		assert.Equal(t, bulkLooks, resp.Looks.Pagination.Total)
+		assert.Equal(t, totalPages, resp.Looks.Pagination.TotalPages)
+		assert.Equal(t, page < totalPages, resp.Looks.Pagination.HasMore, "hasMore on page %d", page)
+		assert.Len(t, resp.Looks.Looks, bulkPerPage, "Page %d should be full", page)
+		for _, look := range resp.Looks.Looks {
+			assert.False(t, seen[look.ID], "Look %s returned on more than one page", look.ID)
+			seen[look.ID] = true
+		}
+	}
+
+	assert.Len(t, seen, bulkLooks, "Pages together should return every look")
+	t.Logf("Slowest page: %s in %v (budget %v)", slowest.Label, slowest.Duration, pageBudget)
+	assert.Less(t, slowest.Duration, pageBudget, "Listing a page of looks should stay within budget")
+}
+
+// BenchmarkCreateLook measures creating one look that touches 100 fixtures.
+// Run with: go test -run '^$' -bench CreateLook ./contracts/latency/
+func BenchmarkCreateLook(b *testing.B) {
+	ctx := context.Background()
+	project := newBulkProject(b, graphql.NewClient(""), ctx)
+	b.Cleanup(project.cleanup)
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if _, err := project.createLook(ctx, n); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "looks/s")
+}
+
+// BenchmarkListLooksPage measures one page of looks(projectId) from a project
+// holding 500 looks of 100 fixtures each.
+func BenchmarkListLooksPage(b *testing.B) {
+	ctx := context.Background()
+	client := graphql.NewClient("")
+	project := newBulkProject(b, client, ctx)
+	b.Cleanup(project.cleanup)
+
+	for n := 0; n < bulkLooks; n++ {
+		if _, err := project.createLook(ctx, n); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		page := n%(bulkLooks/bulkPerPage) + 1
+		if _, err := client.ExecuteRaw(ctx, listLooksQuery,
+			map[string]interface{}{"projectId": project.projectID, "page": page, "perPage": bulkPerPage}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
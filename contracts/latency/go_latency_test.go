@@ -0,0 +1,305 @@
+// Package latency provides timing and performance contract tests.
+// These tests measure the delay between an API call returning and the change
+// becoming visible on the Art-Net wire, and bound the cost of heavy queries.
+package latency
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	_ "github.com/bbernstein/lacylights-test/pkg/artifacts"
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// goPresses is the number of GO presses sampled per run
+	goPresses = 50
+
+	// defaultGoLatencyP95 is the p95 budget when GO_LATENCY_P95_MS is not set
+	defaultGoLatencyP95 = 100 * time.Millisecond
+
+	// cueFadeTime is long enough that the first moving frame is clearly mid-fade
+	cueFadeTime = 0.3
+
+	// movementTimeout bounds the wait for the first moving frame after a GO
+	movementTimeout = 1 * time.Second
+)
+
+// goLatencyBudget returns the p95 budget, honoring GO_LATENCY_P95_MS.
+func goLatencyBudget(t *testing.T) time.Duration {
+	raw := os.Getenv("GO_LATENCY_P95_MS")
+	if raw == "" {
+		return defaultGoLatencyP95
+	}
+	ms, err := strconv.Atoi(raw)
+	require.NoError(t, err, "GO_LATENCY_P95_MS must be an integer number of milliseconds")
+	return time.Duration(ms) * time.Millisecond
+}
+
+// percentile returns the p-th percentile (0-100) of the samples using nearest rank.
+func percentile(samples []time.Duration, p float64) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := make([]time.Duration, len(samples))
+	copy(sorted, samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := int(float64(len(sorted))*p/100+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+// firstMovement waits for the first captured universe-0 frame in which channel 1
+// has moved from start toward target, returning that frame's timestamp.
+func firstMovement(receiver *artnet.Subscription, start, target int) (time.Time, bool) {
+	deadline := time.Now().Add(movementTimeout)
+	for time.Now().Before(deadline) {
+		for _, frame := range receiver.GetFrames() {
+			if frame.Universe != 0 {
+				continue
+			}
+			value := int(frame.Channels[0])
+			if (target > start && value > start) || (target < start && value < start) {
+				return frame.Timestamp, true
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return time.Time{}, false
+}
+
+// TestCueListGoLatency measures the time from a nextCue response to the first
+// Art-Net frame moving toward the new cue, and asserts the p95 stays within budget.
+// Cues alternate between dark and full so every GO produces visible movement.
+func TestCueListGoLatency(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping GO latency benchmark in short mode")
+	}
+	compat.Require(t, compat.ArtNet)
+
+	receiver := artnet.Capture(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+	defer cancel()
+
+	client := graphql.NewClient("")
+	budget := goLatencyBudget(t)
+
+	// Create project
+	var projectResp struct {
+		CreateProject struct {
+			ID string `json:"id"`
+		} `json:"createProject"`
+	}
+	err := client.Mutate(ctx, `
+		mutation CreateProject($input: CreateProjectInput!) {
+			createProject(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{"name": "GO Latency Test Project"},
+	}, &projectResp)
+	require.NoError(t, err)
+	projectID := projectResp.CreateProject.ID
+
+	// Single-channel dimmer definition
+	var defResp struct {
+		CreateFixtureDefinition struct {
+			ID string `json:"id"`
+		} `json:"createFixtureDefinition"`
+	}
+	err = client.Mutate(ctx, `
+		mutation CreateFixtureDefinition($input: CreateFixtureDefinitionInput!) {
+			createFixtureDefinition(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"manufacturer": "Test Latency",
+			"model":        fmt.Sprintf("Latency Dimmer %d", time.Now().UnixNano()),
+			"type":         "DIMMER",
+			"channels": []map[string]interface{}{
+				{"name": "Dimmer", "type": "INTENSITY", "offset": 0, "minValue": 0, "maxValue": 255, "defaultValue": 0},
+			},
+		},
+	}, &defResp)
+	require.NoError(t, err)
+	definitionID := defResp.CreateFixtureDefinition.ID
+
+	defer func() {
+		cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cleanupCancel()
+		_ = client.Mutate(cleanupCtx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+		_ = client.Mutate(cleanupCtx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
+			map[string]interface{}{"id": projectID}, nil)
+		_ = client.Mutate(cleanupCtx, `mutation DeleteFixtureDefinition($id: ID!) { deleteFixtureDefinition(id: $id) }`,
+			map[string]interface{}{"id": definitionID}, nil)
+	}()
+
+	var fixtureResp struct {
+		CreateFixtureInstance struct {
+			ID string `json:"id"`
+		} `json:"createFixtureInstance"`
+	}
+	err = client.Mutate(ctx, `
+		mutation CreateFixtureInstance($input: CreateFixtureInstanceInput!) {
+			createFixtureInstance(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId":    projectID,
+			"definitionId": definitionID,
+			"name":         "Latency Dimmer",
+			"universe":     1,
+			"startChannel": 1,
+		},
+	}, &fixtureResp)
+	require.NoError(t, err)
+	fixtureID := fixtureResp.CreateFixtureInstance.ID
+
+	// Dark and full looks
+	lookIDs := map[int]string{}
+	for _, value := range []int{0, 255} {
+		var lookResp struct {
+			CreateLook struct {
+				ID string `json:"id"`
+			} `json:"createLook"`
+		}
+		err = client.Mutate(ctx, `
+			mutation CreateLook($input: CreateLookInput!) {
+				createLook(input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"projectId": projectID,
+				"name":      fmt.Sprintf("Level %d", value),
+				"fixtureValues": []map[string]interface{}{
+					{"fixtureId": fixtureID, "channels": []map[string]interface{}{{"offset": 0, "value": value}}},
+				},
+			},
+		}, &lookResp)
+		require.NoError(t, err)
+		lookIDs[value] = lookResp.CreateLook.ID
+	}
+
+	// Cue list alternating dark/full, one cue more than the number of presses
+	var cueListResp struct {
+		CreateCueList struct {
+			ID string `json:"id"`
+		} `json:"createCueList"`
+	}
+	err = client.Mutate(ctx, `
+		mutation CreateCueList($input: CreateCueListInput!) {
+			createCueList(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{"projectId": projectID, "name": "GO Latency Cue List"},
+	}, &cueListResp)
+	require.NoError(t, err)
+	cueListID := cueListResp.CreateCueList.ID
+
+	levelForCue := func(i int) int {
+		if i%2 == 0 {
+			return 0
+		}
+		return 255
+	}
+
+	for i := 0; i <= goPresses; i++ {
+		err = client.Mutate(ctx, `
+			mutation CreateCue($input: CreateCueInput!) {
+				createCue(input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"cueListId":   cueListID,
+				"name":        fmt.Sprintf("Cue %d", i+1),
+				"cueNumber":   float64(i + 1),
+				"lookId":      lookIDs[levelForCue(i)],
+				"fadeInTime":  cueFadeTime,
+				"fadeOutTime": cueFadeTime,
+			},
+		}, nil)
+		require.NoError(t, err)
+	}
+
+	err = client.Mutate(ctx, `
+		mutation StartCueList($cueListId: ID!) {
+			startCueList(cueListId: $cueListId)
+		}
+	`, map[string]interface{}{"cueListId": cueListID}, nil)
+	require.NoError(t, err)
+	defer func() {
+		cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cleanupCancel()
+		err := client.Mutate(cleanupCtx, `mutation StopCueList($cueListId: ID!) { stopCueList(cueListId: $cueListId) }`,
+			map[string]interface{}{"cueListId": cueListID}, nil)
+		assert.NoError(t, err, "Cue list should stop")
+	}()
+
+	settle := time.Duration(cueFadeTime*float64(time.Second)) + 200*time.Millisecond
+	time.Sleep(settle)
+
+	if len(receiver.GetFrames()) == 0 {
+		t.Skip("No Art-Net frames captured - Art-Net may not be enabled")
+	}
+
+	latencies := make([]time.Duration, 0, goPresses)
+	misses := 0
+
+	for press := 1; press <= goPresses; press++ {
+		start := levelForCue(press - 1)
+		target := levelForCue(press)
+
+		receiver.ClearFrames()
+
+		err = client.Mutate(ctx, `
+			mutation NextCue($cueListId: ID!) {
+				nextCue(cueListId: $cueListId)
+			}
+		`, map[string]interface{}{"cueListId": cueListID}, nil)
+		require.NoError(t, err)
+		responded := time.Now()
+
+		// Frames that moved before the response arrived count as zero latency
+		moved, ok := firstMovement(receiver, start, target)
+		if !ok {
+			misses++
+			latencies = append(latencies, movementTimeout)
+			continue
+		}
+
+		latency := moved.Sub(responded)
+		if latency < 0 {
+			latency = 0
+		}
+		latencies = append(latencies, latency)
+
+		// Let the fade land before the next GO so every press starts from rest
+		if remaining := settle - time.Since(responded); remaining > 0 {
+			time.Sleep(remaining)
+		}
+	}
+
+	p50 := percentile(latencies, 50)
+	p95 := percentile(latencies, 95)
+	maxLatency := percentile(latencies, 100)
+	t.Logf("GO latency over %d presses: p50=%v p95=%v max=%v misses=%d (budget p95 < %v)",
+		goPresses, p50, p95, maxLatency, misses, budget)
+
+	assert.Zero(t, misses, "Every GO should produce movement within %v", movementTimeout)
+	assert.Less(t, p95, budget, "GO latency p95 should be under budget")
+}
@@ -0,0 +1,97 @@
+package latency
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/bbernstein/lacylights-test/pkg/calibration"
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/fixtures"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/bbernstein/lacylights-test/pkg/timeline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// timelineFadeTime is the fade of the GO under test
+const timelineFadeTime = 0.5
+
+// TestCueGoTimeline checks the whole course of one GO on a single timeline:
+// the nextCue request is followed by channel 1 starting to move within the
+// GO latency budget, measured from when the request was sent, and by channel
+// 1 landing at full about one fade time after it started moving.
+func TestCueGoTimeline(t *testing.T) {
+	compat.Require(t, compat.ArtNet)
+
+	receiver := artnet.Capture(t, artnet.Universes(0))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	client := graphql.NewClientWithOptions("", graphql.ClientOptions{KeepLast: 50})
+	cal := calibration.Get(t)
+
+	suffix := time.Now().UnixNano()
+	definitionID, err := fixtures.CreateParDefinition(ctx, client, "Test Latency", fmt.Sprintf("Timeline Par %d", suffix))
+	require.NoError(t, err)
+	projectID, err := fixtures.CreateProject(ctx, client, "GO Timeline Test Project")
+	require.NoError(t, err)
+	defer func() {
+		cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cleanupCancel()
+		_ = client.Mutate(cleanupCtx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+		_ = fixtures.DeleteProject(cleanupCtx, client, projectID)
+		_ = fixtures.DeleteFixtureDefinition(cleanupCtx, client, definitionID)
+	}()
+
+	fixtureID, err := fixtures.CreateFixture(ctx, client, projectID, definitionID,
+		fixtures.Fixture{Name: "Timeline Par", Universe: 1, StartChannel: 1})
+	require.NoError(t, err)
+	darkID, err := fixtures.CreateLook(ctx, client, projectID, "Dark", []fixtures.FixtureValues{{FixtureID: fixtureID, Values: []int{0}}})
+	require.NoError(t, err)
+	fullID, err := fixtures.CreateLook(ctx, client, projectID, "Full", []fixtures.FixtureValues{{FixtureID: fixtureID, Values: []int{255}}})
+	require.NoError(t, err)
+	cueListID, err := fixtures.CreateCueList(ctx, client, projectID, "GO Timeline Cue List", []fixtures.Cue{
+		{Name: "Dark", LookID: darkID},
+		{Name: "Full", LookID: fullID, FadeTime: timelineFadeTime},
+	})
+	require.NoError(t, err)
+
+	err = client.Mutate(ctx, `mutation Start($cueListId: ID!) { startCueList(cueListId: $cueListId) }`,
+		map[string]interface{}{"cueListId": cueListID}, nil)
+	require.NoError(t, err)
+	defer func() {
+		cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cleanupCancel()
+		err := client.Mutate(cleanupCtx, `mutation StopCueList($cueListId: ID!) { stopCueList(cueListId: $cueListId) }`,
+			map[string]interface{}{"cueListId": cueListID}, nil)
+		assert.NoError(t, err, "Cue list should stop")
+	}()
+	time.Sleep(300 * time.Millisecond)
+	if len(receiver.GetFrames()) == 0 {
+		t.Skip("No Art-Net frames captured - Art-Net may not be enabled")
+	}
+
+	err = client.Mutate(ctx, `mutation NextCue($cueListId: ID!) { nextCue(cueListId: $cueListId) }`,
+		map[string]interface{}{"cueListId": cueListID}, nil)
+	require.NoError(t, err)
+
+	fade := time.Duration(timelineFadeTime * float64(time.Second))
+	landing := fade + cal.Duration(200*time.Millisecond)
+	waitCtx, waitCancel := context.WithTimeout(ctx, landing+time.Second)
+	defer waitCancel()
+	_, _ = receiver.WaitUntil(waitCtx, artnet.ChannelReaches(0, 1, 255))
+
+	tl := timeline.New(client.Recorder().Last(50), receiver.GetFrames())
+	moving := tl.Expect(t, timeline.Mutation("nextCue")).
+		FollowedWithin(cal.Duration(goLatencyBudget(t)), timeline.ChannelStartsMoving(0, 1))
+	landed := moving.FollowedWithin(landing, timeline.ChannelReaches(0, 1, 255))
+
+	if len(landed.Latencies()) == 1 {
+		t.Logf("GO timeline: moving %v after nextCue, at full %v later (fade %v)",
+			moving.Latencies()[0], landed.Latencies()[0], fade)
+	}
+}
@@ -0,0 +1,230 @@
+package latency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// longCues and longCueLooks size a tour-sized show: 500 cues over 200 looks
+	longCues     = 500
+	longCueLooks = 200
+
+	// longCueBatch is how many createCue mutations go in one request
+	longCueBatch = 50
+
+	// longCueListReads is how many times the full cue list is fetched to look for client memory growth
+	longCueListReads = 20
+
+	// defaultCueListBudget is the listing and goToCue budget when CUE_LIST_BUDGET_MS is not set
+	defaultCueListBudget = time.Second
+
+	// maxClientHeapGrowth is how far the client heap may grow over the repeated reads
+	maxClientHeapGrowth = 16 << 20
+
+	longCueListQuery = `
+		query GetCueList($id: ID!) {
+			cueList(id: $id) {
+				id
+				cueCount
+				cues {
+					id
+					name
+					cueNumber
+					fadeInTime
+					look { id name }
+				}
+			}
+		}
+	`
+)
+
+// cueListBudget returns the listing and goToCue budget, honoring CUE_LIST_BUDGET_MS.
+func cueListBudget(t *testing.T) time.Duration {
+	raw := os.Getenv("CUE_LIST_BUDGET_MS")
+	if raw == "" {
+		return defaultCueListBudget
+	}
+	ms, err := strconv.Atoi(raw)
+	require.NoError(t, err, "CUE_LIST_BUDGET_MS must be an integer number of milliseconds")
+	return time.Duration(ms) * time.Millisecond
+}
+
+// heapInUse returns the live heap after a collection.
+func heapInUse() uint64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+// TestLongCueList builds a 500-cue list over 200 looks, then checks that
+// listing it and jumping into the middle with goToCue stay within
+// CUE_LIST_BUDGET_MS, and that reading it repeatedly doesn't grow the client heap.
+// This takes a while, so it only runs with LONG_CUE_LIST_TESTS=1.
+func TestLongCueList(t *testing.T) {
+	if testing.Short() || os.Getenv("LONG_CUE_LIST_TESTS") != "1" {
+		t.Skip("Skipping long cue list stress test: set LONG_CUE_LIST_TESTS=1 to enable")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	client := graphql.NewClient("")
+	budget := cueListBudget(t)
+
+	project := newBulkProject(t, client, ctx)
+	defer project.cleanup()
+
+	start := time.Now()
+	lookIDs := make([]string, longCueLooks)
+	for n := range lookIDs {
+		id, err := project.createLook(ctx, n)
+		require.NoError(t, err, "Creating look %d failed", n+1)
+		lookIDs[n] = id
+	}
+	t.Logf("Created %d looks in %v", longCueLooks, time.Since(start))
+
+	var cueListResp struct {
+		CreateCueList struct {
+			ID string `json:"id"`
+		} `json:"createCueList"`
+	}
+	err := client.Mutate(ctx, `
+		mutation CreateCueList($input: CreateCueListInput!) {
+			createCueList(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{"projectId": project.projectID, "name": "Tour Cue List"},
+	}, &cueListResp)
+	require.NoError(t, err)
+	cueListID := cueListResp.CreateCueList.ID
+	defer func() {
+		_ = client.Mutate(context.Background(), `mutation StopCueList($cueListId: ID!) { stopCueList(cueListId: $cueListId) }`,
+			map[string]interface{}{"cueListId": cueListID}, nil)
+	}()
+
+	start = time.Now()
+	for first := 0; first < longCues; first += longCueBatch {
+		ops := make([]graphql.BatchOperation, 0, longCueBatch)
+		for i := first; i < first+longCueBatch && i < longCues; i++ {
+			ops = append(ops, graphql.BatchOperation{
+				Query: `mutation CreateCue($input: CreateCueInput!) { createCue(input: $input) { id } }`,
+				Variables: map[string]interface{}{
+					"input": map[string]interface{}{
+						"cueListId":   cueListID,
+						"lookId":      lookIDs[i%longCueLooks],
+						"name":        fmt.Sprintf("Cue %d", i+1),
+						"cueNumber":   float64(i + 1),
+						"fadeInTime":  0.0,
+						"fadeOutTime": 0.0,
+					},
+				},
+			})
+		}
+		require.NoError(t, client.QueryBatch(ctx, ops), "Creating cues from %d failed", first+1)
+	}
+	t.Logf("Created %d cues in %v", longCues, time.Since(start))
+
+	var listed struct {
+		CueList struct {
+			CueCount int `json:"cueCount"`
+			Cues     []struct {
+				ID        string  `json:"id"`
+				Name      string  `json:"name"`
+				CueNumber float64 `json:"cueNumber"`
+				Look      struct {
+					ID string `json:"id"`
+				} `json:"look"`
+			} `json:"cues"`
+		} `json:"cueList"`
+	}
+
+	t.Run("ListCues", func(t *testing.T) {
+		raw, timing := measureQuery(t, client, ctx, "Cue list with 500 cues", longCueListQuery,
+			map[string]interface{}{"id": cueListID})
+		require.NoError(t, json.Unmarshal(raw, &listed))
+
+		cues := listed.CueList.Cues
+		assert.Equal(t, longCues, listed.CueList.CueCount)
+		require.Len(t, cues, longCues)
+		for i, cue := range cues {
+			if !assert.Equal(t, float64(i+1), cue.CueNumber, "Cues should be listed in cue number order") {
+				break
+			}
+			assert.Equal(t, lookIDs[i%longCueLooks], cue.Look.ID, "Cue %d look", i+1)
+		}
+		assert.Less(t, timing.Duration, budget, "Listing a 500-cue list should stay within budget")
+	})
+
+	t.Run("GoToMiddleCue", func(t *testing.T) {
+		middle := longCues / 2
+
+		start := time.Now()
+		var gotoResp struct {
+			GoToCue bool `json:"goToCue"`
+		}
+		err := client.Mutate(ctx, `
+			mutation GoToCue($cueListId: ID!, $cueIndex: Int!) {
+				goToCue(cueListId: $cueListId, cueIndex: $cueIndex)
+			}
+		`, map[string]interface{}{"cueListId": cueListID, "cueIndex": middle}, &gotoResp)
+		elapsed := time.Since(start)
+		require.NoError(t, err)
+		assert.True(t, gotoResp.GoToCue)
+		t.Logf("goToCue(%d): %v (budget %v)", middle, elapsed, budget)
+		assert.Less(t, elapsed, budget, "Jumping into the middle of a long cue list should stay within budget")
+
+		var statusResp struct {
+			CueListPlaybackStatus struct {
+				CurrentCueIndex *int `json:"currentCueIndex"`
+				CurrentCue      *struct {
+					Name string `json:"name"`
+				} `json:"currentCue"`
+			} `json:"cueListPlaybackStatus"`
+		}
+		err = client.Query(ctx, `
+			query GetPlaybackStatus($cueListId: ID!) {
+				cueListPlaybackStatus(cueListId: $cueListId) {
+					currentCueIndex
+					currentCue { name }
+				}
+			}
+		`, map[string]interface{}{"cueListId": cueListID}, &statusResp)
+		require.NoError(t, err)
+		status := statusResp.CueListPlaybackStatus
+		require.NotNil(t, status.CurrentCueIndex)
+		assert.Equal(t, middle, *status.CurrentCueIndex)
+		require.NotNil(t, status.CurrentCue)
+		assert.Equal(t, fmt.Sprintf("Cue %d", middle+1), status.CurrentCue.Name)
+	})
+
+	t.Run("ClientMemory", func(t *testing.T) {
+		// The first read warms up buffers and connection state
+		_, err := client.ExecuteRaw(ctx, longCueListQuery, map[string]interface{}{"id": cueListID})
+		require.NoError(t, err)
+		before := heapInUse()
+
+		var bytes int
+		for i := 0; i < longCueListReads; i++ {
+			raw, err := client.ExecuteRaw(ctx, longCueListQuery, map[string]interface{}{"id": cueListID})
+			require.NoError(t, err)
+			bytes = len(raw)
+		}
+		after := heapInUse()
+
+		growth := int64(after) - int64(before)
+		t.Logf("Client heap %d -> %d bytes (%+d) over %d reads of %d bytes", before, after, growth, longCueListReads, bytes)
+		assert.Less(t, growth, int64(maxClientHeapGrowth), "Reading the cue list repeatedly should not grow the client heap")
+	})
+}
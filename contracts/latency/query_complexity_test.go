@@ -0,0 +1,249 @@
+package latency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// complexityFixtures and complexityLooks size the project used for nested query tests
+	complexityFixtures = 100
+	complexityLooks    = 50
+
+	// complexityChannels is the channel count of the fixture definition used
+	complexityChannels = 4
+
+	// defaultNestedQueryBudget is the response time budget when QUERY_TIME_BUDGET_MS is not set
+	defaultNestedQueryBudget = 2 * time.Second
+
+	// maxNestedPayloadBytes bounds the response size; ~20k channel values should fit comfortably
+	maxNestedPayloadBytes = 5 * 1024 * 1024
+)
+
+// queryTiming records how long a query took and how large its response was.
+type queryTiming struct {
+	Label    string
+	Duration time.Duration
+	Bytes    int
+}
+
+// measureQuery executes a query, logs its timing and payload size, and returns the raw data.
+func measureQuery(t *testing.T, client *graphql.Client, ctx context.Context, label, query string, variables map[string]interface{}) (json.RawMessage, queryTiming) {
+	t.Helper()
+
+	start := time.Now()
+	data, err := client.ExecuteRaw(ctx, query, variables)
+	elapsed := time.Since(start)
+	require.NoError(t, err, "%s failed", label)
+
+	timing := queryTiming{Label: label, Duration: elapsed, Bytes: len(data)}
+	t.Logf("%s: %v, %d bytes", timing.Label, timing.Duration, timing.Bytes)
+	return data, timing
+}
+
+// nestedQueryBudget returns the response time budget, honoring QUERY_TIME_BUDGET_MS.
+func nestedQueryBudget(t *testing.T) time.Duration {
+	raw := os.Getenv("QUERY_TIME_BUDGET_MS")
+	if raw == "" {
+		return defaultNestedQueryBudget
+	}
+	ms, err := strconv.Atoi(raw)
+	require.NoError(t, err, "QUERY_TIME_BUDGET_MS must be an integer number of milliseconds")
+	return time.Duration(ms) * time.Millisecond
+}
+
+// TestNestedProjectQueryComplexity loads a project with 100 fixtures and 50 looks,
+// each look setting every channel of every fixture, then fetches everything in one
+// nested query. Response time and payload size must stay within bounds; a resolver
+// that issues one database query per look, fixture value, or channel (N+1) blows
+// through the time budget long before the payload bound.
+func TestNestedProjectQueryComplexity(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping query complexity test in short mode")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+	defer cancel()
+
+	client := graphql.NewClient("")
+	budget := nestedQueryBudget(t)
+
+	// Create project
+	var projectResp struct {
+		CreateProject struct {
+			ID string `json:"id"`
+		} `json:"createProject"`
+	}
+	err := client.Mutate(ctx, `
+		mutation CreateProject($input: CreateProjectInput!) {
+			createProject(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{"name": "Query Complexity Test Project"},
+	}, &projectResp)
+	require.NoError(t, err)
+	projectID := projectResp.CreateProject.ID
+
+	var defResp struct {
+		CreateFixtureDefinition struct {
+			ID string `json:"id"`
+		} `json:"createFixtureDefinition"`
+	}
+	err = client.Mutate(ctx, `
+		mutation CreateFixtureDefinition($input: CreateFixtureDefinitionInput!) {
+			createFixtureDefinition(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"manufacturer": "Test Latency",
+			"model":        fmt.Sprintf("Complexity RGB %d", time.Now().UnixNano()),
+			"type":         "LED_PAR",
+			"channels": []map[string]interface{}{
+				{"name": "Dimmer", "type": "INTENSITY", "offset": 0, "minValue": 0, "maxValue": 255, "defaultValue": 0},
+				{"name": "Red", "type": "RED", "offset": 1, "minValue": 0, "maxValue": 255, "defaultValue": 0},
+				{"name": "Green", "type": "GREEN", "offset": 2, "minValue": 0, "maxValue": 255, "defaultValue": 0},
+				{"name": "Blue", "type": "BLUE", "offset": 3, "minValue": 0, "maxValue": 255, "defaultValue": 0},
+			},
+		},
+	}, &defResp)
+	require.NoError(t, err)
+	definitionID := defResp.CreateFixtureDefinition.ID
+
+	defer func() {
+		cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cleanupCancel()
+		_ = client.Mutate(cleanupCtx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
+			map[string]interface{}{"id": projectID}, nil)
+		_ = client.Mutate(cleanupCtx, `mutation DeleteFixtureDefinition($id: ID!) { deleteFixtureDefinition(id: $id) }`,
+			map[string]interface{}{"id": definitionID}, nil)
+	}()
+
+	// 100 fixtures x 4 channels fills 400 channels of universe 1
+	fixtures := make([]map[string]interface{}, complexityFixtures)
+	for i := range fixtures {
+		fixtures[i] = map[string]interface{}{
+			"projectId":    projectID,
+			"definitionId": definitionID,
+			"name":         fmt.Sprintf("Complexity Fixture %d", i+1),
+			"universe":     1,
+			"startChannel": i*complexityChannels + 1,
+		}
+	}
+
+	var bulkResp struct {
+		BulkCreateFixtures []struct {
+			ID string `json:"id"`
+		} `json:"bulkCreateFixtures"`
+	}
+	err = client.Mutate(ctx, `
+		mutation BulkCreateFixtures($input: BulkFixtureCreateInput!) {
+			bulkCreateFixtures(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{"fixtures": fixtures},
+	}, &bulkResp)
+	require.NoError(t, err)
+	require.Len(t, bulkResp.BulkCreateFixtures, complexityFixtures)
+
+	for l := 0; l < complexityLooks; l++ {
+		fixtureValues := make([]map[string]interface{}, complexityFixtures)
+		for i, fixture := range bulkResp.BulkCreateFixtures {
+			channels := make([]map[string]interface{}, complexityChannels)
+			for c := range channels {
+				channels[c] = map[string]interface{}{"offset": c, "value": (l*7 + i + c) % 256}
+			}
+			fixtureValues[i] = map[string]interface{}{"fixtureId": fixture.ID, "channels": channels}
+		}
+
+		err = client.Mutate(ctx, `
+			mutation CreateLook($input: CreateLookInput!) {
+				createLook(input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"projectId":     projectID,
+				"name":          fmt.Sprintf("Complexity Look %d", l+1),
+				"fixtureValues": fixtureValues,
+			},
+		}, nil)
+		require.NoError(t, err)
+	}
+
+	query := `
+		query NestedProject($id: ID!) {
+			project(id: $id) {
+				id
+				fixtures {
+					id
+					name
+					channels { id type }
+				}
+				looks {
+					id
+					name
+					fixtureValues {
+						fixture { id name }
+						channels { offset value }
+					}
+				}
+			}
+		}
+	`
+	variables := map[string]interface{}{"id": projectID}
+
+	// Warm-up run so one-off cache population does not count against the budget
+	_, _ = measureQuery(t, client, ctx, "NestedProject (warm-up)", query, variables)
+
+	// Best of three smooths over scheduler noise without hiding a systematic N+1
+	var best queryTiming
+	var data json.RawMessage
+	for run := 1; run <= 3; run++ {
+		raw, timing := measureQuery(t, client, ctx, fmt.Sprintf("NestedProject (run %d)", run), query, variables)
+		if run == 1 || timing.Duration < best.Duration {
+			best = timing
+			data = raw
+		}
+	}
+
+	var resp struct {
+		Project struct {
+			Fixtures []struct {
+				ID string `json:"id"`
+			} `json:"fixtures"`
+			Looks []struct {
+				FixtureValues []struct {
+					Channels []struct {
+						Offset int `json:"offset"`
+					} `json:"channels"`
+				} `json:"fixtureValues"`
+			} `json:"looks"`
+		} `json:"project"`
+	}
+	require.NoError(t, json.Unmarshal(data, &resp))
+
+	// The query must actually return the full graph for the timing to mean anything
+	require.Len(t, resp.Project.Fixtures, complexityFixtures)
+	require.Len(t, resp.Project.Looks, complexityLooks)
+	channelValues := 0
+	for _, look := range resp.Project.Looks {
+		for _, fv := range look.FixtureValues {
+			channelValues += len(fv.Channels)
+		}
+	}
+	assert.Equal(t, complexityLooks*complexityFixtures*complexityChannels, channelValues)
+
+	t.Logf("Best nested query: %v for %d channel values, %d bytes (budget %v, %d bytes)",
+		best.Duration, channelValues, best.Bytes, budget, maxNestedPayloadBytes)
+
+	assert.Less(t, best.Duration, budget, "Nested project query should complete within budget")
+	assert.Less(t, best.Bytes, maxNestedPayloadBytes, "Nested project payload should stay within bounds")
+}
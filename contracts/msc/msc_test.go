@@ -0,0 +1,215 @@
+// Package msc provides contract tests for triggering cue lists with MIDI Show
+// Control: the same sequence is played once through GraphQL and once through
+// MSC, and the cue list must end up in the same state after every step.
+package msc
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	_ "github.com/bbernstein/lacylights-test/pkg/artifacts"
+	"github.com/bbernstein/lacylights-test/pkg/fixtures"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/bbernstein/lacylights-test/pkg/msc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// mscContract is the MSC support these tests expect.
+	mscContract = `MSC lighting commands (format 0x01) as raw SysEx over UDP at MSC_ADDR, device MSC_DEVICE_ID or all-call,
+  addressing the playing cue list, or for RESUME the cue list last stopped:
+  GO without a cue = nextCue, GO <cue number> = goToCue of the cue with that cueNumber,
+  STOP = stopCueList, RESUME = playback continues from the cue it was stopped on`
+
+	// settleTimeout bounds the wait for an MSC command to take effect
+	settleTimeout = 2 * time.Second
+)
+
+// cueLevels are the dimmer levels of the cues, numbered 1-4.
+var cueLevels = []int{50, 100, 150, 200}
+
+// playbackState is what a cue list control is compared on.
+type playbackState struct {
+	IsPlaying       bool
+	CurrentCueIndex *int
+	Level           int
+}
+
+func (s playbackState) String() string {
+	index := "null"
+	if s.CurrentCueIndex != nil {
+		index = fmt.Sprint(*s.CurrentCueIndex)
+	}
+	return fmt.Sprintf("playing=%v cue=%s level=%d", s.IsPlaying, index, s.Level)
+}
+
+type mscSetup struct {
+	ctx       context.Context
+	client    *graphql.Client
+	cueListID string
+}
+
+// newMSCSetup creates a dimmer on universe 1 channel 1 and a cue list of
+// snap cues at cueLevels.
+func newMSCSetup(t *testing.T) *mscSetup {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	t.Cleanup(cancel)
+	client := graphql.NewTestClient(t, "")
+
+	definitionID, err := fixtures.CreateParDefinition(ctx, client, "Test MSC", fmt.Sprintf("MSC Par %d", time.Now().UnixNano()))
+	require.NoError(t, err)
+	projectID, err := fixtures.CreateProject(ctx, client, "MSC Test Project")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cleanupCancel()
+		_ = client.Mutate(cleanupCtx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+		_ = fixtures.DeleteProject(cleanupCtx, client, projectID)
+		_ = fixtures.DeleteFixtureDefinition(cleanupCtx, client, definitionID)
+	})
+
+	fixtureID, err := fixtures.CreateFixture(ctx, client, projectID, definitionID,
+		fixtures.Fixture{Name: "MSC Par", Universe: 1, StartChannel: 1})
+	require.NoError(t, err)
+
+	var cues []fixtures.Cue
+	for i, level := range cueLevels {
+		lookID, err := fixtures.CreateLook(ctx, client, projectID, fmt.Sprintf("Level %d", level),
+			[]fixtures.FixtureValues{{FixtureID: fixtureID, Values: []int{level}}})
+		require.NoError(t, err)
+		cues = append(cues, fixtures.Cue{Name: fmt.Sprintf("Cue %d", i+1), LookID: lookID})
+	}
+	cueListID, err := fixtures.CreateCueList(ctx, client, projectID, "MSC Cue List", cues)
+	require.NoError(t, err)
+
+	return &mscSetup{ctx: ctx, client: client, cueListID: cueListID}
+}
+
+// mutate runs a cue list control mutation on the setup's cue list.
+func (s *mscSetup) mutate(t *testing.T, mutation string, variables map[string]interface{}) {
+	vars := map[string]interface{}{"cueListId": s.cueListID}
+	for k, v := range variables {
+		vars[k] = v
+	}
+	require.NoError(t, s.client.Mutate(s.ctx, mutation, vars, nil))
+}
+
+// start resets output and starts the cue list from its first cue.
+func (s *mscSetup) start(t *testing.T) {
+	_ = s.client.Mutate(s.ctx, `mutation StopCueList($cueListId: ID!) { stopCueList(cueListId: $cueListId) }`,
+		map[string]interface{}{"cueListId": s.cueListID}, nil)
+	require.NoError(t, s.client.Mutate(s.ctx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil))
+	s.mutate(t, `mutation StartCueList($cueListId: ID!) { startCueList(cueListId: $cueListId) }`, nil)
+	s.waitFor(t, "start", playbackState{IsPlaying: true, CurrentCueIndex: intPtr(0), Level: cueLevels[0]})
+}
+
+func (s *mscSetup) state(t *testing.T) playbackState {
+	var resp struct {
+		CueListPlaybackStatus *struct {
+			IsPlaying       bool `json:"isPlaying"`
+			CurrentCueIndex *int `json:"currentCueIndex"`
+		} `json:"cueListPlaybackStatus"`
+		DMXOutput []int `json:"dmxOutput"`
+	}
+	err := s.client.Query(s.ctx, `
+		query State($cueListId: ID!) {
+			cueListPlaybackStatus(cueListId: $cueListId) { isPlaying currentCueIndex }
+			dmxOutput(universe: 1)
+		}
+	`, map[string]interface{}{"cueListId": s.cueListID}, &resp)
+	require.NoError(t, err)
+	require.Len(t, resp.DMXOutput, 512, "dmxOutput should cover the universe")
+
+	state := playbackState{Level: resp.DMXOutput[0]}
+	if resp.CueListPlaybackStatus != nil {
+		state.IsPlaying = resp.CueListPlaybackStatus.IsPlaying
+		state.CurrentCueIndex = resp.CueListPlaybackStatus.CurrentCueIndex
+	}
+	return state
+}
+
+// waitFor waits for the cue list to reach want, failing the test with the
+// last state seen if it does not within settleTimeout.
+func (s *mscSetup) waitFor(t *testing.T, step string, want playbackState) {
+	t.Helper()
+	var got playbackState
+	deadline := time.Now().Add(settleTimeout)
+	for time.Now().Before(deadline) {
+		if got = s.state(t); got.String() == want.String() {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	assert.Failf(t, "State differs", "After %s: got %s, want %s", step, got, want)
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+// mscStep is one control, through GraphQL and through MSC.
+type mscStep struct {
+	mutation  string
+	variables map[string]interface{}
+	message   msc.Message
+}
+
+// TestMSCMatchesGraphQL plays GO, GO with cue numbers and STOP through
+// GraphQL, recording the state after each, then plays the same steps as MSC
+// and expects the same states.
+func TestMSCMatchesGraphQL(t *testing.T) {
+	sender := msc.Require(t, mscContract)
+	s := newMSCSetup(t)
+
+	steps := []mscStep{
+		{`mutation Next($cueListId: ID!) { nextCue(cueListId: $cueListId) }`, nil,
+			msc.NewMessage(msc.Go, "", "")},
+		{`mutation GoTo($cueListId: ID!, $cueIndex: Int!) { goToCue(cueListId: $cueListId, cueIndex: $cueIndex) }`,
+			map[string]interface{}{"cueIndex": 3}, msc.NewMessage(msc.Go, "4", "")},
+		{`mutation GoTo($cueListId: ID!, $cueIndex: Int!) { goToCue(cueListId: $cueListId, cueIndex: $cueIndex) }`,
+			map[string]interface{}{"cueIndex": 0}, msc.NewMessage(msc.Go, "1", "")},
+		{`mutation Next($cueListId: ID!) { nextCue(cueListId: $cueListId) }`, nil,
+			msc.NewMessage(msc.Go, "", "")},
+		{`mutation Stop($cueListId: ID!) { stopCueList(cueListId: $cueListId) }`, nil,
+			msc.NewMessage(msc.Stop, "", "")},
+	}
+
+	s.start(t)
+	want := make([]playbackState, len(steps))
+	for i, step := range steps {
+		s.mutate(t, step.mutation, step.variables)
+		time.Sleep(200 * time.Millisecond)
+		want[i] = s.state(t)
+		t.Logf("GraphQL %s: %s", step.message, want[i])
+	}
+	require.Equal(t, cueLevels[3], want[1].Level, "goToCue(3) should output the fourth cue")
+
+	s.start(t)
+	for i, step := range steps {
+		require.NoError(t, sender.Send(step.message))
+		s.waitFor(t, "MSC "+step.message.String(), want[i])
+	}
+}
+
+// TestMSCResume checks RESUME continues from the cue playback was stopped on.
+func TestMSCResume(t *testing.T) {
+	sender := msc.Require(t, mscContract)
+	s := newMSCSetup(t)
+
+	s.start(t)
+	require.NoError(t, sender.Send(msc.NewMessage(msc.Go, "3", "")))
+	s.waitFor(t, "GO 3", playbackState{IsPlaying: true, CurrentCueIndex: intPtr(2), Level: cueLevels[2]})
+
+	require.NoError(t, sender.Send(msc.NewMessage(msc.Stop, "", "")))
+	deadline := time.Now().Add(settleTimeout)
+	for s.state(t).IsPlaying && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	require.False(t, s.state(t).IsPlaying, "STOP should stop the cue list")
+
+	require.NoError(t, sender.Send(msc.NewMessage(msc.Resume, "", "")))
+	s.waitFor(t, "RESUME", playbackState{IsPlaying: true, CurrentCueIndex: intPtr(2), Level: cueLevels[2]})
+}
@@ -0,0 +1,620 @@
+// Package negative contains contract tests for how mutations reject bad input.
+// Every rejection must be a GraphQL error carrying extensions.code, never a 5xx.
+package negative
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	_ "github.com/bbernstein/lacylights-test/pkg/artifacts"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unknownID is well formed but never assigned by the server.
+const unknownID = "00000000-0000-0000-0000-000000000000"
+
+var (
+	// validationCodes are accepted for requests the schema itself rules out.
+	validationCodes = []string{"GRAPHQL_VALIDATION_FAILED"}
+
+	// unknownIDCodes are accepted for references to entities that do not exist.
+	unknownIDCodes = []string{"NOT_FOUND", "BAD_USER_INPUT"}
+
+	// crossProjectCodes are accepted for references to entities in another project.
+	crossProjectCodes = []string{"BAD_USER_INPUT", "NOT_FOUND"}
+)
+
+// requireRejected checks that err is a structured GraphQL error: no 5xx status,
+// and at least one error code from the allowed set.
+func requireRejected(t *testing.T, err error, allowed []string) {
+	t.Helper()
+	require.Error(t, err, "Request should be rejected")
+
+	status := graphql.HTTPStatus(err)
+	require.Less(t, status, http.StatusInternalServerError, "Server error instead of a GraphQL error: %v", err)
+
+	codes := graphql.ErrorCodes(err)
+	require.NotEmpty(t, codes, "Error should carry extensions.code: %v", err)
+	assert.True(t, slices.ContainsFunc(codes, func(c string) bool { return slices.Contains(allowed, c) }),
+		"Error codes %v should include one of %v", codes, allowed)
+}
+
+// skipUnlessMutation skips when the server does not expose the mutation.
+func skipUnlessMutation(t *testing.T, client *graphql.Client, ctx context.Context, name string) {
+	t.Helper()
+	ok, err := client.HasMutationField(ctx, name)
+	require.NoError(t, err)
+	if !ok {
+		t.Skipf("Skipping: mutation %s is not in the schema", name)
+	}
+}
+
+// typeRef is an introspected type reference, possibly wrapped in NON_NULL and LIST.
+type typeRef struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	OfType *typeRef `json:"ofType"`
+}
+
+// named unwraps NON_NULL and LIST down to the named type.
+func (r typeRef) named() typeRef {
+	for r.OfType != nil {
+		r = *r.OfType
+	}
+	return r
+}
+
+type mutationField struct {
+	Name string `json:"name"`
+	Args []struct {
+		Name         string  `json:"name"`
+		Type         typeRef `json:"type"`
+		DefaultValue *string `json:"defaultValue"`
+	} `json:"args"`
+	Type typeRef `json:"type"`
+}
+
+// requiredArgs lists the arguments a caller must supply.
+func (f mutationField) requiredArgs() []string {
+	var names []string
+	for _, a := range f.Args {
+		if a.Type.Kind == "NON_NULL" && a.DefaultValue == nil {
+			names = append(names, a.Name)
+		}
+	}
+	return names
+}
+
+// mutationFields introspects every root mutation field with its arguments.
+func mutationFields(t *testing.T, client *graphql.Client, ctx context.Context) []mutationField {
+	var resp struct {
+		Schema struct {
+			MutationType struct {
+				Fields []mutationField `json:"fields"`
+			} `json:"mutationType"`
+		} `json:"__schema"`
+	}
+
+	typeRefFields := `kind name ofType { kind name ofType { kind name ofType { kind name } } }`
+	err := client.Query(ctx, fmt.Sprintf(`
+		query MutationArgs {
+			__schema {
+				mutationType {
+					fields {
+						name
+						args { name defaultValue type { %s } }
+						type { %s }
+					}
+				}
+			}
+		}
+	`, typeRefFields, typeRefFields), nil, &resp)
+	require.NoError(t, err)
+	return resp.Schema.MutationType.Fields
+}
+
+// TestMissingRequiredArguments calls every mutation that has required arguments
+// without any. The request must fail validation, so nothing is executed, and the
+// error must carry a validation code.
+func TestMissingRequiredArguments(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewTestClient(t, "")
+
+	fields := mutationFields(t, client, ctx)
+	require.NotEmpty(t, fields, "Schema should expose mutations")
+
+	for _, field := range fields {
+		if len(field.requiredArgs()) == 0 {
+			continue
+		}
+
+		t.Run(field.Name, func(t *testing.T) {
+			selection := ""
+			switch field.Type.named().Kind {
+			case "OBJECT", "INTERFACE", "UNION":
+				selection = " { __typename }"
+			}
+
+			err := client.Mutate(ctx, fmt.Sprintf(`mutation { %s%s }`, field.Name, selection), nil, nil)
+			requireRejected(t, err, validationCodes)
+		})
+	}
+}
+
+// TestWrongArgumentTypes sends variables of the wrong type. Variable coercion
+// happens before execution, so each request must fail validation.
+func TestWrongArgumentTypes(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewTestClient(t, "")
+
+	cases := []struct {
+		name      string
+		mutation  string
+		variables map[string]interface{}
+	}{
+		{
+			name:      "BooleanID",
+			mutation:  `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
+			variables: map[string]interface{}{"id": true},
+		},
+		{
+			name:     "NumberForString",
+			mutation: `mutation CreateProject($input: CreateProjectInput!) { createProject(input: $input) { id } }`,
+			variables: map[string]interface{}{
+				"input": map[string]interface{}{"name": 42},
+			},
+		},
+		{
+			name:      "StringForInt",
+			mutation:  `mutation SetChannel($universe: Int!, $channel: Int!, $value: Int!) { setChannelValue(universe: $universe, channel: $channel, value: $value) }`,
+			variables: map[string]interface{}{"universe": "one", "channel": 1, "value": 0},
+		},
+		{
+			name:      "FractionForInt",
+			mutation:  `mutation SetChannel($universe: Int!, $channel: Int!, $value: Int!) { setChannelValue(universe: $universe, channel: $channel, value: $value) }`,
+			variables: map[string]interface{}{"universe": 1, "channel": 1, "value": 12.5},
+		},
+		{
+			name:      "StringForFloat",
+			mutation:  `mutation FadeToBlack($fadeOutTime: Float!) { fadeToBlack(fadeOutTime: $fadeOutTime) }`,
+			variables: map[string]interface{}{"fadeOutTime": "slow"},
+		},
+		{
+			// A lone object would be coerced into a one-item list, so a
+			// string is the value that cannot stand in for the list.
+			name:     "StringForList",
+			mutation: `mutation CreateLook($input: CreateLookInput!) { createLook(input: $input) { id } }`,
+			variables: map[string]interface{}{
+				"input": map[string]interface{}{
+					"projectId":     unknownID,
+					"name":          "Wrong Type Look",
+					"fixtureValues": "all",
+				},
+			},
+		},
+		{
+			name:     "UnknownInputField",
+			mutation: `mutation CreateProject($input: CreateProjectInput!) { createProject(input: $input) { id } }`,
+			variables: map[string]interface{}{
+				"input": map[string]interface{}{"name": "Unknown Field Project", "notAField": 1},
+			},
+		},
+		{
+			name:     "UnknownEnumValue",
+			mutation: `mutation CreateCue($input: CreateCueInput!) { createCue(input: $input) { id } }`,
+			variables: map[string]interface{}{
+				"input": map[string]interface{}{
+					"cueListId":   unknownID,
+					"lookId":      unknownID,
+					"name":        "Wrong Easing",
+					"cueNumber":   1.0,
+					"fadeInTime":  1.0,
+					"fadeOutTime": 1.0,
+					"easingType":  "WOBBLY",
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := client.Mutate(ctx, tc.mutation, tc.variables, nil)
+			requireRejected(t, err, validationCodes)
+		})
+	}
+}
+
+// TestUnknownIDs sends IDs that do not exist to mutations that look entities up.
+// The server must report the missing entity as an error, not succeed quietly or crash.
+func TestUnknownIDs(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewTestClient(t, "")
+
+	cases := []struct {
+		field     string
+		mutation  string
+		variables map[string]interface{}
+	}{
+		{
+			field:     "updateProject",
+			mutation:  `mutation UpdateProject($id: ID!, $input: CreateProjectInput!) { updateProject(id: $id, input: $input) { id } }`,
+			variables: map[string]interface{}{"id": unknownID, "input": map[string]interface{}{"name": "Ghost"}},
+		},
+		{
+			field:     "deleteProject",
+			mutation:  `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
+			variables: map[string]interface{}{"id": unknownID},
+		},
+		{
+			field:    "createFixtureInstance",
+			mutation: `mutation CreateFixtureInstance($input: CreateFixtureInstanceInput!) { createFixtureInstance(input: $input) { id } }`,
+			variables: map[string]interface{}{
+				"input": map[string]interface{}{
+					"projectId":    unknownID,
+					"definitionId": unknownID,
+					"name":         "Ghost Fixture",
+					"universe":     1,
+					"startChannel": 1,
+				},
+			},
+		},
+		{
+			field:     "updateFixtureInstance",
+			mutation:  `mutation UpdateFixtureInstance($id: ID!, $input: UpdateFixtureInstanceInput!) { updateFixtureInstance(id: $id, input: $input) { id } }`,
+			variables: map[string]interface{}{"id": unknownID, "input": map[string]interface{}{"name": "Ghost"}},
+		},
+		{
+			field:     "deleteFixtureInstance",
+			mutation:  `mutation DeleteFixtureInstance($id: ID!) { deleteFixtureInstance(id: $id) }`,
+			variables: map[string]interface{}{"id": unknownID},
+		},
+		{
+			field:     "deleteFixtureDefinition",
+			mutation:  `mutation DeleteFixtureDefinition($id: ID!) { deleteFixtureDefinition(id: $id) }`,
+			variables: map[string]interface{}{"id": unknownID},
+		},
+		{
+			field:    "createLook",
+			mutation: `mutation CreateLook($input: CreateLookInput!) { createLook(input: $input) { id } }`,
+			variables: map[string]interface{}{
+				"input": map[string]interface{}{
+					"projectId":     unknownID,
+					"name":          "Ghost Look",
+					"fixtureValues": []map[string]interface{}{},
+				},
+			},
+		},
+		{
+			field:     "updateLook",
+			mutation:  `mutation UpdateLook($id: ID!, $input: UpdateLookInput!) { updateLook(id: $id, input: $input) { id } }`,
+			variables: map[string]interface{}{"id": unknownID, "input": map[string]interface{}{"name": "Ghost"}},
+		},
+		{
+			field:     "deleteLook",
+			mutation:  `mutation DeleteLook($id: ID!) { deleteLook(id: $id) }`,
+			variables: map[string]interface{}{"id": unknownID},
+		},
+		{
+			field:     "duplicateLook",
+			mutation:  `mutation DuplicateLook($id: ID!) { duplicateLook(id: $id) { id } }`,
+			variables: map[string]interface{}{"id": unknownID},
+		},
+		{
+			field:    "createCueList",
+			mutation: `mutation CreateCueList($input: CreateCueListInput!) { createCueList(input: $input) { id } }`,
+			variables: map[string]interface{}{
+				"input": map[string]interface{}{"projectId": unknownID, "name": "Ghost List"},
+			},
+		},
+		{
+			field:     "deleteCueList",
+			mutation:  `mutation DeleteCueList($id: ID!) { deleteCueList(id: $id) }`,
+			variables: map[string]interface{}{"id": unknownID},
+		},
+		{
+			field:     "startCueList",
+			mutation:  `mutation StartCueList($cueListId: ID!) { startCueList(cueListId: $cueListId) }`,
+			variables: map[string]interface{}{"cueListId": unknownID},
+		},
+		{
+			field:    "createCue",
+			mutation: `mutation CreateCue($input: CreateCueInput!) { createCue(input: $input) { id } }`,
+			variables: map[string]interface{}{
+				"input": map[string]interface{}{
+					"cueListId":   unknownID,
+					"lookId":      unknownID,
+					"name":        "Ghost Cue",
+					"cueNumber":   1.0,
+					"fadeInTime":  1.0,
+					"fadeOutTime": 1.0,
+				},
+			},
+		},
+		{
+			field:     "deleteCue",
+			mutation:  `mutation DeleteCue($id: ID!) { deleteCue(id: $id) }`,
+			variables: map[string]interface{}{"id": unknownID},
+		},
+		{
+			field:     "deleteEffect",
+			mutation:  `mutation DeleteEffect($id: ID!) { deleteEffect(id: $id) }`,
+			variables: map[string]interface{}{"id": unknownID},
+		},
+		{
+			field:     "deleteFixtureGroup",
+			mutation:  `mutation DeleteFixtureGroup($id: ID!) { deleteFixtureGroup(id: $id) }`,
+			variables: map[string]interface{}{"id": unknownID},
+		},
+		{
+			field:     "deletePalette",
+			mutation:  `mutation DeletePalette($id: ID!) { deletePalette(id: $id) }`,
+			variables: map[string]interface{}{"id": unknownID},
+		},
+		{
+			field:     "undo",
+			mutation:  `mutation Undo($projectId: ID!) { undo(projectId: $projectId) { success } }`,
+			variables: map[string]interface{}{"projectId": unknownID},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.field, func(t *testing.T) {
+			skipUnlessMutation(t, client, ctx, tc.field)
+
+			err := client.Mutate(ctx, tc.mutation, tc.variables, nil)
+			requireRejected(t, err, unknownIDCodes)
+		})
+	}
+}
+
+// crossProjectSetup is two projects, A holding a fixture and a look, B holding a cue list.
+type crossProjectSetup struct {
+	projectA, projectB string
+	fixtureA, lookA    string
+	cueListB           string
+}
+
+// createProject creates a project and returns its ID.
+func createProject(t *testing.T, client *graphql.Client, ctx context.Context, name string) string {
+	var resp struct {
+		CreateProject struct {
+			ID string `json:"id"`
+		} `json:"createProject"`
+	}
+	err := client.Mutate(ctx, `
+		mutation CreateProject($input: CreateProjectInput!) {
+			createProject(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{"name": name},
+	}, &resp)
+	require.NoError(t, err)
+	return resp.CreateProject.ID
+}
+
+// newCrossProjectSetup builds both projects; cleanup deletes them.
+func newCrossProjectSetup(t *testing.T, client *graphql.Client, ctx context.Context) *crossProjectSetup {
+	s := &crossProjectSetup{
+		projectA: createProject(t, client, ctx, "Negative Cross Project A"),
+		projectB: createProject(t, client, ctx, "Negative Cross Project B"),
+	}
+	t.Cleanup(func() {
+		cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cleanupCancel()
+		for _, id := range []string{s.projectA, s.projectB} {
+			_ = client.Mutate(cleanupCtx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
+				map[string]interface{}{"id": id}, nil)
+		}
+	})
+
+	var defResp struct {
+		CreateFixtureDefinition struct {
+			ID string `json:"id"`
+		} `json:"createFixtureDefinition"`
+	}
+	err := client.Mutate(ctx, `
+		mutation CreateFixtureDefinition($input: CreateFixtureDefinitionInput!) {
+			createFixtureDefinition(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"manufacturer": "Test Negative",
+			"model":        fmt.Sprintf("Negative Dimmer %d", time.Now().UnixNano()),
+			"type":         "DIMMER",
+			"channels": []map[string]interface{}{
+				{"name": "Dimmer", "type": "INTENSITY", "offset": 0, "minValue": 0, "maxValue": 255, "defaultValue": 0},
+			},
+		},
+	}, &defResp)
+	require.NoError(t, err)
+	definitionID := defResp.CreateFixtureDefinition.ID
+	t.Cleanup(func() {
+		cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cleanupCancel()
+		_ = client.Mutate(cleanupCtx, `mutation DeleteFixtureDefinition($id: ID!) { deleteFixtureDefinition(id: $id) }`,
+			map[string]interface{}{"id": definitionID}, nil)
+	})
+
+	var fixtureResp struct {
+		CreateFixtureInstance struct {
+			ID string `json:"id"`
+		} `json:"createFixtureInstance"`
+	}
+	err = client.Mutate(ctx, `
+		mutation CreateFixtureInstance($input: CreateFixtureInstanceInput!) {
+			createFixtureInstance(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId":    s.projectA,
+			"definitionId": definitionID,
+			"name":         "Project A Dimmer",
+			"universe":     1,
+			"startChannel": 1,
+		},
+	}, &fixtureResp)
+	require.NoError(t, err)
+	s.fixtureA = fixtureResp.CreateFixtureInstance.ID
+
+	var lookResp struct {
+		CreateLook struct {
+			ID string `json:"id"`
+		} `json:"createLook"`
+	}
+	err = client.Mutate(ctx, `
+		mutation CreateLook($input: CreateLookInput!) {
+			createLook(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId": s.projectA,
+			"name":      "Project A Look",
+			"fixtureValues": []map[string]interface{}{
+				{"fixtureId": s.fixtureA, "channels": []map[string]interface{}{{"offset": 0, "value": 255}}},
+			},
+		},
+	}, &lookResp)
+	require.NoError(t, err)
+	s.lookA = lookResp.CreateLook.ID
+
+	var cueListResp struct {
+		CreateCueList struct {
+			ID string `json:"id"`
+		} `json:"createCueList"`
+	}
+	err = client.Mutate(ctx, `
+		mutation CreateCueList($input: CreateCueListInput!) {
+			createCueList(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{"projectId": s.projectB, "name": "Project B List"},
+	}, &cueListResp)
+	require.NoError(t, err)
+	s.cueListB = cueListResp.CreateCueList.ID
+
+	return s
+}
+
+// projectLookCount returns how many looks a project holds.
+func projectLookCount(t *testing.T, client *graphql.Client, ctx context.Context, projectID string) int {
+	var resp struct {
+		Project struct {
+			Looks []struct {
+				ID string `json:"id"`
+			} `json:"looks"`
+		} `json:"project"`
+	}
+	err := client.Query(ctx, `
+		query ProjectLooks($id: ID!) {
+			project(id: $id) { looks { id } }
+		}
+	`, map[string]interface{}{"id": projectID}, &resp)
+	require.NoError(t, err)
+	return len(resp.Project.Looks)
+}
+
+// TestCrossProjectReferences checks that entities in one project cannot be wired
+// to entities in another: the write is rejected with a code and nothing is stored.
+func TestCrossProjectReferences(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewTestClient(t, "")
+	s := newCrossProjectSetup(t, client, ctx)
+
+	t.Run("CueWithLookFromOtherProject", func(t *testing.T) {
+		err := client.Mutate(ctx, `
+			mutation CreateCue($input: CreateCueInput!) {
+				createCue(input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"cueListId":   s.cueListB,
+				"lookId":      s.lookA,
+				"name":        "Cross Project Cue",
+				"cueNumber":   1.0,
+				"fadeInTime":  1.0,
+				"fadeOutTime": 1.0,
+			},
+		}, nil)
+		requireRejected(t, err, crossProjectCodes)
+
+		var listResp struct {
+			CueList struct {
+				Cues []struct {
+					ID string `json:"id"`
+				} `json:"cues"`
+			} `json:"cueList"`
+		}
+		err = client.Query(ctx, `
+			query CueListCues($id: ID!) {
+				cueList(id: $id) { cues { id } }
+			}
+		`, map[string]interface{}{"id": s.cueListB}, &listResp)
+		require.NoError(t, err)
+		assert.Empty(t, listResp.CueList.Cues, "Rejected cue must not be stored")
+	})
+
+	t.Run("LookWithFixtureFromOtherProject", func(t *testing.T) {
+		err := client.Mutate(ctx, `
+			mutation CreateLook($input: CreateLookInput!) {
+				createLook(input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"projectId": s.projectB,
+				"name":      "Cross Project Look",
+				"fixtureValues": []map[string]interface{}{
+					{"fixtureId": s.fixtureA, "channels": []map[string]interface{}{{"offset": 0, "value": 255}}},
+				},
+			},
+		}, nil)
+		requireRejected(t, err, crossProjectCodes)
+		assert.Zero(t, projectLookCount(t, client, ctx, s.projectB), "Rejected look must not be stored")
+	})
+
+	t.Run("GroupWithFixtureFromOtherProject", func(t *testing.T) {
+		skipUnlessMutation(t, client, ctx, "createFixtureGroup")
+
+		var groupResp struct {
+			CreateFixtureGroup struct {
+				ID string `json:"id"`
+			} `json:"createFixtureGroup"`
+		}
+		err := client.Mutate(ctx, `
+			mutation CreateFixtureGroup($input: CreateFixtureGroupInput!) {
+				createFixtureGroup(input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{"projectId": s.projectB, "name": "Project B Group"},
+		}, &groupResp)
+		require.NoError(t, err)
+
+		var addResp struct {
+			AddFixturesToGroup struct {
+				FixtureCount int `json:"fixtureCount"`
+			} `json:"addFixturesToGroup"`
+		}
+		err = client.Mutate(ctx, `
+			mutation AddFixturesToGroup($groupId: ID!, $fixtureIds: [ID!]!) {
+				addFixturesToGroup(groupId: $groupId, fixtureIds: $fixtureIds) { fixtureCount }
+			}
+		`, map[string]interface{}{
+			"groupId":    groupResp.CreateFixtureGroup.ID,
+			"fixtureIds": []string{s.fixtureA},
+		}, &addResp)
+		requireRejected(t, err, crossProjectCodes)
+	})
+}
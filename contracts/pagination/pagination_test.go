@@ -0,0 +1,245 @@
+// Package pagination applies the shared pagination contract to every list query.
+package pagination
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	_ "github.com/bbernstein/lacylights-test/pkg/artifacts"
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/bbernstein/lacylights-test/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// itemCount is how many items each list holds; with pagePerPage it gives
+	// two full pages and a partial one.
+	itemCount   = 7
+	pagePerPage = 3
+
+	paginationFields = `pagination { total page perPage hasMore totalPages }`
+
+	// effectsPageContract is the paginated shape expected of effects
+	effectsPageContract = `effects(projectId: ID!, page: Int, perPage: Int): EffectPage!
+  EffectPage { effects: [Effect!]!, pagination: PaginationInfo! }`
+
+	// historyPageContract is the paginated shape expected of operation history
+	historyPageContract = `operationHistory(projectId: ID!, page: Int, perPage: Int): OperationHistory!
+  OperationHistory { operations: [Operation!]!, pagination: PaginationInfo! }`
+
+	// searchCuesPageContract is the paginated shape expected of cue search
+	searchCuesPageContract = `searchCues(cueListId: ID!, query: String!, page: Int, perPage: Int): CuePage!`
+)
+
+// pagedFetcher builds a Fetcher for a list query of the form
+// field(args..., page, perPage) { items { id } pagination { ... } }.
+// params declares the extra variables, e.g. "$projectId: ID!", and args passes them.
+func pagedFetcher(client *graphql.Client, field, items, params, args string, variables map[string]interface{}) pagination.Fetcher {
+	query := fmt.Sprintf(`
+		query Paged(%s, $page: Int, $perPage: Int) {
+			%s(%s, page: $page, perPage: $perPage) {
+				%s { id }
+				%s
+			}
+		}
+	`, params, field, args, items, paginationFields)
+
+	return func(ctx context.Context, page, perPage int) (pagination.Page, error) {
+		vars := map[string]interface{}{"page": page, "perPage": perPage}
+		for k, v := range variables {
+			vars[k] = v
+		}
+
+		data, err := client.ExecuteRaw(ctx, query, vars)
+		if err != nil {
+			return pagination.Page{}, err
+		}
+
+		var resp map[string]map[string]json.RawMessage
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return pagination.Page{}, err
+		}
+
+		var list []struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(resp[field][items], &list); err != nil {
+			return pagination.Page{}, fmt.Errorf("decoding %s: %w", items, err)
+		}
+		var p pagination.Page
+		if err := json.Unmarshal(resp[field]["pagination"], &p); err != nil {
+			return pagination.Page{}, fmt.Errorf("decoding pagination: %w", err)
+		}
+		for _, item := range list {
+			p.IDs = append(p.IDs, item.ID)
+		}
+		return p, nil
+	}
+}
+
+// paginationProject is a project with itemCount fixtures, looks and cues.
+type paginationProject struct {
+	client       *graphql.Client
+	projectID    string
+	definitionID string
+	fixtureIDs   []string
+	lookIDs      []string
+	cueListID    string
+}
+
+// mutateID runs a mutation taking a single input and returns the created id.
+func mutateID(t *testing.T, client *graphql.Client, ctx context.Context, field, inputType string, input map[string]interface{}) string {
+	var resp map[string]struct {
+		ID string `json:"id"`
+	}
+	err := client.Mutate(ctx, fmt.Sprintf(`
+		mutation Create($input: %s!) {
+			%s(input: $input) { id }
+		}
+	`, inputType, field), map[string]interface{}{"input": input}, &resp)
+	require.NoError(t, err)
+	return resp[field].ID
+}
+
+func newPaginationProject(t *testing.T, ctx context.Context) *paginationProject {
+	client := graphql.NewTestClient(t, "")
+	p := &paginationProject{client: client}
+
+	p.projectID = mutateID(t, client, ctx, "createProject", "CreateProjectInput",
+		map[string]interface{}{"name": "Pagination Test Project"})
+	p.definitionID = mutateID(t, client, ctx, "createFixtureDefinition", "CreateFixtureDefinitionInput",
+		map[string]interface{}{
+			"manufacturer": "Test Pagination",
+			"model":        fmt.Sprintf("Pagination Dimmer %d", time.Now().UnixNano()),
+			"type":         "DIMMER",
+			"channels": []map[string]interface{}{
+				{"name": "Dimmer", "type": "INTENSITY", "offset": 0, "minValue": 0, "maxValue": 255, "defaultValue": 0},
+			},
+		})
+	t.Cleanup(func() {
+		cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cleanupCancel()
+		_ = client.Mutate(cleanupCtx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
+			map[string]interface{}{"id": p.projectID}, nil)
+		_ = client.Mutate(cleanupCtx, `mutation DeleteFixtureDefinition($id: ID!) { deleteFixtureDefinition(id: $id) }`,
+			map[string]interface{}{"id": p.definitionID}, nil)
+	})
+
+	for i := 1; i <= itemCount; i++ {
+		fixtureID := mutateID(t, client, ctx, "createFixtureInstance", "CreateFixtureInstanceInput",
+			map[string]interface{}{
+				"projectId":    p.projectID,
+				"definitionId": p.definitionID,
+				"name":         fmt.Sprintf("Page Dimmer %d", i),
+				"universe":     1,
+				"startChannel": i,
+			})
+		p.fixtureIDs = append(p.fixtureIDs, fixtureID)
+	}
+
+	for i := 1; i <= itemCount; i++ {
+		lookID := mutateID(t, client, ctx, "createLook", "CreateLookInput",
+			map[string]interface{}{
+				"projectId": p.projectID,
+				"name":      fmt.Sprintf("Page Look %d", i),
+				"fixtureValues": []map[string]interface{}{
+					{"fixtureId": p.fixtureIDs[i-1], "channels": []map[string]interface{}{{"offset": 0, "value": 255}}},
+				},
+			})
+		p.lookIDs = append(p.lookIDs, lookID)
+	}
+
+	p.cueListID = mutateID(t, client, ctx, "createCueList", "CreateCueListInput",
+		map[string]interface{}{"projectId": p.projectID, "name": "Pagination Cue List"})
+	for i := 1; i <= itemCount; i++ {
+		mutateID(t, client, ctx, "createCue", "CreateCueInput",
+			map[string]interface{}{
+				"cueListId":   p.cueListID,
+				"lookId":      p.lookIDs[i-1],
+				"name":        fmt.Sprintf("Page Cue %d", i),
+				"cueNumber":   float64(i),
+				"fadeInTime":  1.0,
+				"fadeOutTime": 1.0,
+			})
+	}
+
+	return p
+}
+
+// TestListPaginationConformance runs the shared pagination contract against
+// every paginated list query. Lists that are not paginated yet are gated on
+// their page argument.
+func TestListPaginationConformance(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	p := newPaginationProject(t, ctx)
+	client := p.client
+	byProject := map[string]interface{}{"projectId": p.projectID}
+
+	t.Run("Looks", func(t *testing.T) {
+		fetch := pagedFetcher(client, "looks", "looks", "$projectId: ID!", "projectId: $projectId", byProject)
+		pagination.Check(t, ctx, fetch, pagePerPage, itemCount)
+	})
+
+	t.Run("FixtureInstances", func(t *testing.T) {
+		fetch := pagedFetcher(client, "fixtureInstances", "fixtures", "$projectId: ID!", "projectId: $projectId", byProject)
+		pagination.Check(t, ctx, fetch, pagePerPage, itemCount)
+	})
+
+	t.Run("SearchCues", func(t *testing.T) {
+		compat.RequireQueryArgument(t, client, "searchCues", "page", searchCuesPageContract)
+
+		fetch := pagedFetcher(client, "searchCues", "cues", "$cueListId: ID!, $query: String!",
+			"cueListId: $cueListId, query: $query",
+			map[string]interface{}{"cueListId": p.cueListID, "query": "Page Cue"})
+		pagination.Check(t, ctx, fetch, pagePerPage, itemCount)
+	})
+
+	t.Run("Scenes", func(t *testing.T) {
+		compat.Require(t, compat.Scenes)
+
+		for i := 1; i <= itemCount; i++ {
+			mutateID(t, client, ctx, "createScene", "CreateSceneInput",
+				map[string]interface{}{
+					"projectId":     p.projectID,
+					"name":          fmt.Sprintf("Page Scene %d", i),
+					"fixtureValues": []map[string]interface{}{},
+				})
+		}
+		fetch := pagedFetcher(client, "scenes", "scenes", "$projectId: ID!", "projectId: $projectId", byProject)
+		pagination.Check(t, ctx, fetch, pagePerPage, itemCount)
+	})
+
+	t.Run("Effects", func(t *testing.T) {
+		compat.Require(t, compat.Effects)
+		compat.RequireQueryArgument(t, client, "effects", "page", effectsPageContract)
+
+		for i := 1; i <= itemCount; i++ {
+			mutateID(t, client, ctx, "createEffect", "CreateEffectInput",
+				map[string]interface{}{
+					"projectId":  p.projectID,
+					"name":       fmt.Sprintf("Page Effect %d", i),
+					"effectType": "WAVEFORM",
+					"waveform":   "SINE",
+					"frequency":  1.0,
+				})
+		}
+		fetch := pagedFetcher(client, "effects", "effects", "$projectId: ID!", "projectId: $projectId", byProject)
+		pagination.Check(t, ctx, fetch, pagePerPage, itemCount)
+	})
+
+	t.Run("OperationHistory", func(t *testing.T) {
+		compat.Require(t, compat.Undo)
+		compat.RequireQueryArgument(t, client, "operationHistory", "page", historyPageContract)
+
+		// Whether fixture and cue list creation record operations is up to the
+		// server, so only the consistency of the pages is checked
+		fetch := pagedFetcher(client, "operationHistory", "operations", "$projectId: ID!", "projectId: $projectId", byProject)
+		pagination.Check(t, ctx, fetch, pagePerPage, pagination.AnyTotal)
+	})
+}
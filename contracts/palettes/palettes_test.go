@@ -0,0 +1,415 @@
+// Package palettes provides palette (preset) contract tests.
+// A palette stores position, color or beam values once; looks reference it, and
+// editing the palette must change every referencing look.
+package palettes
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	_ "github.com/bbernstein/lacylights-test/pkg/artifacts"
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// palettesContract is the API shape this suite expects.
+const palettesContract = `createPalette(input: CreatePaletteInput!): Palette!
+  CreatePaletteInput { projectId: ID!, name: String!, type: POSITION|COLOR|BEAM, fixtureValues: [FixtureValueInput!]! }
+  updatePalette(id: ID!, input: UpdatePaletteInput!): Palette!, deletePalette(id: ID!): Boolean!
+  palettes(projectId: ID!): [Palette!]!, Palette { id, name, type, fixtureValues, lookCount: Int! }
+  CreateLookInput.paletteIds: [ID!] (explicit fixtureValues win over palette values)`
+
+// rgbValues builds fixtureValues setting red, green and blue on every fixture.
+func rgbValues(fixtureIDs []string, r, g, b int) []map[string]interface{} {
+	values := make([]map[string]interface{}, 0, len(fixtureIDs))
+	for _, id := range fixtureIDs {
+		values = append(values, map[string]interface{}{
+			"fixtureId": id,
+			"channels": []map[string]interface{}{
+				{"offset": 1, "value": r},
+				{"offset": 2, "value": g},
+				{"offset": 3, "value": b},
+			},
+		})
+	}
+	return values
+}
+
+// positionValues builds fixtureValues setting pan and tilt on every fixture.
+func positionValues(fixtureIDs []string, pan, tilt int) []map[string]interface{} {
+	values := make([]map[string]interface{}, 0, len(fixtureIDs))
+	for _, id := range fixtureIDs {
+		values = append(values, map[string]interface{}{
+			"fixtureId": id,
+			"channels": []map[string]interface{}{
+				{"offset": 1, "value": pan},
+				{"offset": 2, "value": tilt},
+			},
+		})
+	}
+	return values
+}
+
+// dimmerValues builds fixtureValues setting the dimmer at offset 0 on every fixture.
+func dimmerValues(fixtureIDs []string, level int) []map[string]interface{} {
+	values := make([]map[string]interface{}, 0, len(fixtureIDs))
+	for _, id := range fixtureIDs {
+		values = append(values, map[string]interface{}{
+			"fixtureId": id,
+			"channels":  []map[string]interface{}{{"offset": 0, "value": level}},
+		})
+	}
+	return values
+}
+
+// paletteRig is a project with two fixtures of one definition patched back to
+// back from channel 1 of universe 1.
+type paletteRig struct {
+	client     *graphql.Client
+	ctx        context.Context
+	projectID  string
+	fixtureIDs []string
+	width      int
+}
+
+// newPaletteRig creates the project, definition and fixtures, and removes them
+// when the test ends.
+func newPaletteRig(t *testing.T, ctx context.Context, client *graphql.Client, name, fixtureType string, channels []map[string]interface{}) *paletteRig {
+	var projectResp struct {
+		CreateProject struct {
+			ID string `json:"id"`
+		} `json:"createProject"`
+	}
+	err := client.Mutate(ctx, `
+		mutation CreateProject($input: CreateProjectInput!) {
+			createProject(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{"name": name + " Project"},
+	}, &projectResp)
+	require.NoError(t, err)
+	r := &paletteRig{client: client, ctx: ctx, projectID: projectResp.CreateProject.ID, width: len(channels)}
+
+	var defResp struct {
+		CreateFixtureDefinition struct {
+			ID string `json:"id"`
+		} `json:"createFixtureDefinition"`
+	}
+	err = client.Mutate(ctx, `
+		mutation CreateFixtureDefinition($input: CreateFixtureDefinitionInput!) {
+			createFixtureDefinition(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"manufacturer": "Test Palettes",
+			"model":        fmt.Sprintf("%s %d", name, time.Now().UnixNano()),
+			"type":         fixtureType,
+			"channels":     channels,
+		},
+	}, &defResp)
+	require.NoError(t, err)
+	definitionID := defResp.CreateFixtureDefinition.ID
+
+	t.Cleanup(func() {
+		cleanupCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		_ = client.Mutate(cleanupCtx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+		time.Sleep(200 * time.Millisecond)
+		_ = client.Mutate(cleanupCtx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
+			map[string]interface{}{"id": r.projectID}, nil)
+		_ = client.Mutate(cleanupCtx, `mutation DeleteFixtureDefinition($id: ID!) { deleteFixtureDefinition(id: $id) }`,
+			map[string]interface{}{"id": definitionID}, nil)
+	})
+
+	for i := 0; i < 2; i++ {
+		var fixtureResp struct {
+			CreateFixtureInstance struct {
+				ID string `json:"id"`
+			} `json:"createFixtureInstance"`
+		}
+		err = client.Mutate(ctx, `
+			mutation CreateFixtureInstance($input: CreateFixtureInstanceInput!) {
+				createFixtureInstance(input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"projectId":    r.projectID,
+				"definitionId": definitionID,
+				"name":         fmt.Sprintf("%s %d", name, i+1),
+				"universe":     1,
+				"startChannel": 1 + i*r.width,
+			},
+		}, &fixtureResp)
+		require.NoError(t, err)
+		r.fixtureIDs = append(r.fixtureIDs, fixtureResp.CreateFixtureInstance.ID)
+	}
+	return r
+}
+
+// createPalette creates a palette of the given type and checks the type round-trips.
+func (r *paletteRig) createPalette(t *testing.T, name, paletteType string, fixtureValues []map[string]interface{}) string {
+	var resp struct {
+		CreatePalette struct {
+			ID   string `json:"id"`
+			Type string `json:"type"`
+		} `json:"createPalette"`
+	}
+	err := r.client.Mutate(r.ctx, `
+		mutation CreatePalette($input: CreatePaletteInput!) {
+			createPalette(input: $input) { id type }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId":     r.projectID,
+			"name":          name,
+			"type":          paletteType,
+			"fixtureValues": fixtureValues,
+		},
+	}, &resp)
+	require.NoError(t, err)
+	assert.Equal(t, paletteType, resp.CreatePalette.Type)
+	return resp.CreatePalette.ID
+}
+
+// updatePalette replaces a palette's fixture values.
+func (r *paletteRig) updatePalette(t *testing.T, paletteID string, fixtureValues []map[string]interface{}) {
+	err := r.client.Mutate(r.ctx, `
+		mutation UpdatePalette($id: ID!, $input: UpdatePaletteInput!) {
+			updatePalette(id: $id, input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"id":    paletteID,
+		"input": map[string]interface{}{"fixtureValues": fixtureValues},
+	}, nil)
+	require.NoError(t, err)
+}
+
+// createLook creates a look referencing the palettes, with its own fixture values on top.
+func (r *paletteRig) createLook(t *testing.T, name string, paletteIDs []string, fixtureValues []map[string]interface{}) string {
+	var resp struct {
+		CreateLook struct {
+			ID string `json:"id"`
+		} `json:"createLook"`
+	}
+	err := r.client.Mutate(r.ctx, `
+		mutation CreateLook($input: CreateLookInput!) {
+			createLook(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId":     r.projectID,
+			"name":          name,
+			"paletteIds":    paletteIDs,
+			"fixtureValues": fixtureValues,
+		},
+	}, &resp)
+	require.NoError(t, err)
+	return resp.CreateLook.ID
+}
+
+// lookCount returns how many looks reference the palette.
+func (r *paletteRig) lookCount(t *testing.T, paletteID string) int {
+	var resp struct {
+		Palettes []struct {
+			ID        string `json:"id"`
+			LookCount int    `json:"lookCount"`
+		} `json:"palettes"`
+	}
+	err := r.client.Query(r.ctx, `
+		query ListPalettes($projectId: ID!) {
+			palettes(projectId: $projectId) { id lookCount }
+		}
+	`, map[string]interface{}{"projectId": r.projectID}, &resp)
+	require.NoError(t, err)
+	for _, p := range resp.Palettes {
+		if p.ID == paletteID {
+			return p.LookCount
+		}
+	}
+	require.Failf(t, "Palette not listed", "palettes(projectId) should include %s", paletteID)
+	return 0
+}
+
+// output snaps a look live and returns the channels of both fixtures.
+func (r *paletteRig) output(t *testing.T, lookID string) []int {
+	err := r.client.Mutate(r.ctx, `
+		mutation SetLookLive($lookId: ID!) {
+			setLookLive(lookId: $lookId)
+		}
+	`, map[string]interface{}{"lookId": lookID}, nil)
+	require.NoError(t, err)
+	time.Sleep(300 * time.Millisecond)
+
+	return r.live(t)
+}
+
+// live returns the current output of both fixtures without changing the stage.
+func (r *paletteRig) live(t *testing.T) []int {
+	var resp struct {
+		DMXOutput []int `json:"dmxOutput"`
+	}
+	err := r.client.Query(r.ctx, `query { dmxOutput(universe: 1) }`, nil, &resp)
+	require.NoError(t, err)
+	require.Len(t, resp.DMXOutput, 512, "dmxOutput should cover the universe")
+	return resp.DMXOutput[:2*r.width]
+}
+
+// ============================================================================
+// Palette Indirection Tests
+// ============================================================================
+
+// TestColorPaletteIndirection creates a color palette, references it from two
+// looks with different intensities, edits the palette, and checks that both
+// looks now output the new color while keeping their own intensities.
+func TestColorPaletteIndirection(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewClient("")
+	compat.RequireMutation(t, client, "createPalette", palettesContract)
+
+	_ = client.Mutate(ctx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+
+	// Two pars at channels 1-4 and 5-8
+	rig := newPaletteRig(t, ctx, client, "Palette Par", "LED_PAR", []map[string]interface{}{
+		{"name": "Dimmer", "type": "INTENSITY", "offset": 0, "minValue": 0, "maxValue": 255, "defaultValue": 0},
+		{"name": "Red", "type": "RED", "offset": 1, "minValue": 0, "maxValue": 255, "defaultValue": 0},
+		{"name": "Green", "type": "GREEN", "offset": 2, "minValue": 0, "maxValue": 255, "defaultValue": 0},
+		{"name": "Blue", "type": "BLUE", "offset": 3, "minValue": 0, "maxValue": 255, "defaultValue": 0},
+	})
+	fixtureIDs := rig.fixtureIDs
+
+	paletteID := rig.createPalette(t, "Show Red", "COLOR", rgbValues(fixtureIDs, 255, 0, 0))
+
+	// Two looks share the palette; each sets its own intensity
+	lookIDs := map[string]string{}
+	intensities := map[string]int{"Full": 255, "Half": 128}
+	for name, level := range intensities {
+		lookIDs[name] = rig.createLook(t, "Palette "+name, []string{paletteID}, dimmerValues(fixtureIDs, level))
+	}
+
+	t.Run("ReferenceCount", func(t *testing.T) {
+		assert.Equal(t, 2, rig.lookCount(t, paletteID), "Both looks should reference the palette")
+	})
+
+	t.Run("LooksUsePaletteValues", func(t *testing.T) {
+		if compat.DMXChecksDisabled() {
+			t.Skip("Skipping DMX output check: SKIP_DMX_TESTS or SKIP_FADE_TESTS is set")
+		}
+		for name, level := range intensities {
+			got := rig.output(t, lookIDs[name])
+			assert.Equal(t, []int{level, 255, 0, 0, level, 255, 0, 0}, got, "%s look should be red from the palette", name)
+		}
+	})
+
+	t.Run("EditPropagates", func(t *testing.T) {
+		// Leave one look live while the palette changes
+		if !compat.DMXChecksDisabled() {
+			rig.output(t, lookIDs["Full"])
+		}
+
+		rig.updatePalette(t, paletteID, rgbValues(fixtureIDs, 0, 64, 255))
+
+		if compat.DMXChecksDisabled() {
+			t.Skip("Skipping DMX output check: SKIP_DMX_TESTS or SKIP_FADE_TESTS is set")
+		}
+
+		time.Sleep(300 * time.Millisecond)
+		if live := rig.live(t); live[3] == 255 {
+			t.Log("Contract: palette edits update the live look immediately")
+		} else {
+			t.Logf("Contract: palette edits apply on next activation (live output %v)", live)
+		}
+
+		for name, level := range intensities {
+			got := rig.output(t, lookIDs[name])
+			assert.Equal(t, []int{level, 0, 64, 255, level, 0, 64, 255}, got,
+				"%s look should pick up the edited palette and keep its own intensity", name)
+		}
+	})
+
+	t.Run("DeleteReferencedPalette", func(t *testing.T) {
+		var resp struct {
+			DeletePalette bool `json:"deletePalette"`
+		}
+		err := client.Mutate(ctx, `
+			mutation DeletePalette($id: ID!) {
+				deletePalette(id: $id)
+			}
+		`, map[string]interface{}{"id": paletteID}, &resp)
+		if err != nil || !resp.DeletePalette {
+			t.Logf("Contract: deleting a referenced palette is rejected (err: %v)", err)
+			return
+		}
+
+		t.Log("Contract: deleting a referenced palette is allowed")
+		if compat.DMXChecksDisabled() {
+			return
+		}
+		// Looks must not break; they either keep the last palette values or drop them
+		got := rig.output(t, lookIDs["Full"])
+		assert.Equal(t, 255, got[0], "Look intensity must survive palette deletion")
+		assert.Contains(t, [][]int{{0, 64, 255}, {0, 0, 0}}, got[1:4],
+			"Look color should be the frozen palette values or dark, got %v", got[1:4])
+	})
+}
+
+// TestPositionPaletteIndirection is the focus palette case: two moving heads
+// share a position palette from two looks with different intensities. Editing
+// the palette must move both looks to the new pan and tilt.
+func TestPositionPaletteIndirection(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewClient("")
+	compat.RequireMutation(t, client, "createPalette", palettesContract)
+
+	_ = client.Mutate(ctx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+
+	// Two heads at channels 1-3 and 4-6
+	rig := newPaletteRig(t, ctx, client, "Palette Head", "MOVING_HEAD", []map[string]interface{}{
+		{"name": "Dimmer", "type": "INTENSITY", "offset": 0, "minValue": 0, "maxValue": 255, "defaultValue": 0},
+		{"name": "Pan", "type": "PAN", "offset": 1, "minValue": 0, "maxValue": 255, "defaultValue": 128},
+		{"name": "Tilt", "type": "TILT", "offset": 2, "minValue": 0, "maxValue": 255, "defaultValue": 128},
+	})
+	fixtureIDs := rig.fixtureIDs
+
+	paletteID := rig.createPalette(t, "Downstage Center", "POSITION", positionValues(fixtureIDs, 40, 200))
+
+	lookIDs := map[string]string{}
+	intensities := map[string]int{"Full": 255, "Half": 128}
+	for name, level := range intensities {
+		lookIDs[name] = rig.createLook(t, "Focus "+name, []string{paletteID}, dimmerValues(fixtureIDs, level))
+	}
+
+	t.Run("ReferenceCount", func(t *testing.T) {
+		assert.Equal(t, 2, rig.lookCount(t, paletteID), "Both looks should reference the palette")
+	})
+
+	t.Run("LooksUsePaletteValues", func(t *testing.T) {
+		if compat.DMXChecksDisabled() {
+			t.Skip("Skipping DMX output check: SKIP_DMX_TESTS or SKIP_FADE_TESTS is set")
+		}
+		for name, level := range intensities {
+			got := rig.output(t, lookIDs[name])
+			assert.Equal(t, []int{level, 40, 200, level, 40, 200}, got, "%s look should take pan and tilt from the palette", name)
+		}
+	})
+
+	t.Run("EditPropagates", func(t *testing.T) {
+		rig.updatePalette(t, paletteID, positionValues(fixtureIDs, 220, 16))
+
+		if compat.DMXChecksDisabled() {
+			t.Skip("Skipping DMX output check: SKIP_DMX_TESTS or SKIP_FADE_TESTS is set")
+		}
+		for name, level := range intensities {
+			got := rig.output(t, lookIDs[name])
+			assert.Equal(t, []int{level, 220, 16, level, 220, 16}, got,
+				"%s look should follow the edited palette and keep its own intensity", name)
+		}
+	})
+}
@@ -0,0 +1,287 @@
+// Package park provides contract tests for parked channels.
+// A parked channel is held at a fixed value regardless of looks, cue lists,
+// effects or blackouts until it is unparked.
+package park
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	_ "github.com/bbernstein/lacylights-test/pkg/artifacts"
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/fixtures"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parkContract is the API shape this suite expects.
+const parkContract = `parkChannel(universe: Int!, channel: Int!, value: Int!): Boolean!
+  unparkChannel(universe: Int!, channel: Int!): Boolean!
+  parkedChannels: [ParkedChannel!]!, ParkedChannel { universe: Int!, channel: Int!, value: Int! }`
+
+const (
+	// parkedChannel and freeChannel are the 1-based DMX channels of the two test dimmers
+	parkedChannel = 1
+	freeChannel   = 2
+
+	parkedValue = 255
+
+	// sampleInterval is how often output is read while watching a parked channel
+	sampleInterval = 50 * time.Millisecond
+)
+
+// parkSetup is a project with two single-channel dimmers at channels 1 and 2 of universe 1.
+type parkSetup struct {
+	client     *graphql.Client
+	ctx        context.Context
+	project    *fixtures.TestProject
+	fixtureIDs []string
+}
+
+func newParkSetup(t *testing.T, ctx context.Context) *parkSetup {
+	client := graphql.NewClient("")
+	compat.RequireMutation(t, client, "parkChannel", parkContract)
+	if compat.DMXChecksDisabled() {
+		t.Skip("Skipping park tests: they assert DMX output")
+	}
+
+	_ = client.Mutate(ctx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+	project := fixtures.NewTestProject(t, ctx, client, "Park", "DIMMER", fixtures.DimmerChannels)
+	project.OnCleanup(func(ctx context.Context) {
+		for _, channel := range []int{parkedChannel, freeChannel} {
+			_ = client.Mutate(ctx, `
+				mutation Unpark($universe: Int!, $channel: Int!) { unparkChannel(universe: $universe, channel: $channel) }
+			`, map[string]interface{}{"universe": 1, "channel": channel}, nil)
+		}
+	})
+	s := &parkSetup{client: client, ctx: ctx, project: project}
+
+	for _, channel := range []int{parkedChannel, freeChannel} {
+		s.fixtureIDs = append(s.fixtureIDs, project.AddFixture(t, ctx,
+			fixtures.Fixture{Name: fmt.Sprintf("Park Dimmer %d", channel), Universe: 1, StartChannel: channel}))
+	}
+
+	return s
+}
+
+// setLook creates a look with the given dimmer levels and makes it live.
+// It does not wait for output, so tests can watch the activation.
+func (s *parkSetup) setLook(t *testing.T, name string, parked, free int) {
+	lookID := s.project.AddLook(t, s.ctx, name, []fixtures.FixtureValues{
+		{FixtureID: s.fixtureIDs[0], Values: []int{parked}},
+		{FixtureID: s.fixtureIDs[1], Values: []int{free}},
+	})
+	require.NoError(t, fixtures.ActivateLook(s.ctx, s.client, lookID))
+}
+
+func (s *parkSetup) park(t *testing.T, channel, value int) {
+	var resp struct {
+		ParkChannel bool `json:"parkChannel"`
+	}
+	err := s.client.Mutate(s.ctx, `
+		mutation Park($universe: Int!, $channel: Int!, $value: Int!) {
+			parkChannel(universe: $universe, channel: $channel, value: $value)
+		}
+	`, map[string]interface{}{"universe": 1, "channel": channel, "value": value}, &resp)
+	require.NoError(t, err)
+	require.True(t, resp.ParkChannel, "parkChannel should succeed")
+}
+
+func (s *parkSetup) unpark(t *testing.T, channel int) {
+	var resp struct {
+		UnparkChannel bool `json:"unparkChannel"`
+	}
+	err := s.client.Mutate(s.ctx, `
+		mutation Unpark($universe: Int!, $channel: Int!) {
+			unparkChannel(universe: $universe, channel: $channel)
+		}
+	`, map[string]interface{}{"universe": 1, "channel": channel}, &resp)
+	require.NoError(t, err)
+	require.True(t, resp.UnparkChannel, "unparkChannel should succeed")
+}
+
+// parkedValues returns the parked channels of universe 1, keyed by channel.
+func (s *parkSetup) parkedValues(t *testing.T) map[int]int {
+	var resp struct {
+		ParkedChannels []struct {
+			Universe int `json:"universe"`
+			Channel  int `json:"channel"`
+			Value    int `json:"value"`
+		} `json:"parkedChannels"`
+	}
+	err := s.client.Query(s.ctx, `query ParkedChannels { parkedChannels { universe channel value } }`, nil, &resp)
+	require.NoError(t, err)
+
+	parked := make(map[int]int)
+	for _, p := range resp.ParkedChannels {
+		if p.Universe == 1 {
+			parked[p.Channel] = p.Value
+		}
+	}
+	return parked
+}
+
+// output returns the 1-based channel's current DMX value in universe 1.
+func (s *parkSetup) output(t *testing.T, channel int) int {
+	var resp struct {
+		DMXOutput []int `json:"dmxOutput"`
+	}
+	err := s.client.Query(s.ctx, `query { dmxOutput(universe: 1) }`, nil, &resp)
+	require.NoError(t, err)
+	require.Len(t, resp.DMXOutput, 512, "dmxOutput should cover the universe")
+	return resp.DMXOutput[channel-1]
+}
+
+// watch samples the channel for the duration and returns every value seen.
+func (s *parkSetup) watch(t *testing.T, channel int, duration time.Duration) []int {
+	var values []int
+	for deadline := time.Now().Add(duration); time.Now().Before(deadline); {
+		values = append(values, s.output(t, channel))
+		time.Sleep(sampleInterval)
+	}
+	return values
+}
+
+// requireHeld asserts every sample equals the parked value.
+func requireHeld(t *testing.T, samples []int, msg string) {
+	t.Helper()
+	require.NotEmpty(t, samples)
+	for i, v := range samples {
+		assert.Equal(t, parkedValue, v, "%s: sample %d of %d", msg, i+1, len(samples))
+	}
+}
+
+// TestParkedChannelIgnoresPlayback parks a dimmer at full, then throws looks,
+// a blackout and an effect at it. The parked channel must never move while
+// the unparked channel follows playback, and unparking must hand the channel
+// back to normal control.
+func TestParkedChannelIgnoresPlayback(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	s := newParkSetup(t, ctx)
+
+	s.setLook(t, "Before Park", 80, 80)
+	time.Sleep(fixtures.Settle)
+
+	s.park(t, parkedChannel, parkedValue)
+	time.Sleep(fixtures.Settle)
+
+	t.Run("ParkTakesEffect", func(t *testing.T) {
+		assert.Equal(t, parkedValue, s.output(t, parkedChannel))
+		assert.Equal(t, 80, s.output(t, freeChannel), "Parking one channel must not touch its neighbour")
+		assert.Equal(t, map[int]int{parkedChannel: parkedValue}, s.parkedValues(t))
+	})
+
+	t.Run("LookCannotMoveParkedChannel", func(t *testing.T) {
+		s.setLook(t, "While Parked", 0, 150)
+		requireHeld(t, s.watch(t, parkedChannel, 500*time.Millisecond), "Look activation")
+		assert.Equal(t, 150, s.output(t, freeChannel), "The free channel should follow the look")
+	})
+
+	t.Run("BlackoutCannotMoveParkedChannel", func(t *testing.T) {
+		err := s.client.Mutate(ctx, `
+			mutation FadeToBlack($fadeOutTime: Float!) {
+				fadeToBlack(fadeOutTime: $fadeOutTime)
+			}
+		`, map[string]interface{}{"fadeOutTime": 1.0}, nil)
+		require.NoError(t, err)
+
+		requireHeld(t, s.watch(t, parkedChannel, 1500*time.Millisecond), "Blackout")
+		assert.Equal(t, 0, s.output(t, freeChannel), "The free channel should black out")
+	})
+
+	t.Run("EffectCannotMoveParkedChannel", func(t *testing.T) {
+		compat.Require(t, compat.Effects)
+
+		effectID := s.startDimmerEffect(t)
+		defer func() {
+			_ = s.client.Mutate(ctx, `mutation StopEffect($id: ID!) { stopEffect(effectId: $id, fadeTime: 0) }`,
+				map[string]interface{}{"id": effectID}, nil)
+		}()
+
+		requireHeld(t, s.watch(t, parkedChannel, 2*time.Second), "Effect")
+
+		// Guard against a vacuous pass: the effect must be moving the free channel
+		free := s.watch(t, freeChannel, 500*time.Millisecond)
+		assert.NotEqual(t, slices.Min(free), slices.Max(free), "The effect should move the free channel")
+	})
+
+	t.Run("UnparkResumesControl", func(t *testing.T) {
+		s.unpark(t, parkedChannel)
+		time.Sleep(fixtures.Settle)
+		assert.Empty(t, s.parkedValues(t), "No channels should remain parked")
+
+		// Blacked out above, so the unparked channel drops to 0
+		assert.Equal(t, 0, s.output(t, parkedChannel), "Unparked channel should return to the playback value")
+
+		s.setLook(t, "After Unpark", 60, 90)
+		time.Sleep(fixtures.Settle)
+		assert.Equal(t, 60, s.output(t, parkedChannel), "Unparked channel should follow looks again")
+		assert.Equal(t, 90, s.output(t, freeChannel))
+	})
+}
+
+// startDimmerEffect runs a fast full-range sine on both dimmers and returns the effect id.
+func (s *parkSetup) startDimmerEffect(t *testing.T) string {
+	var effectResp struct {
+		CreateEffect struct {
+			ID string `json:"id"`
+		} `json:"createEffect"`
+	}
+	err := s.client.Mutate(s.ctx, `
+		mutation CreateEffect($input: CreateEffectInput!) {
+			createEffect(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId":  s.project.ID,
+			"name":       "Park Sine",
+			"effectType": "WAVEFORM",
+			"waveform":   "SINE",
+			"frequency":  2.0,
+			"amplitude":  100.0,
+			"offset":     50.0,
+		},
+	}, &effectResp)
+	require.NoError(t, err)
+	effectID := effectResp.CreateEffect.ID
+
+	for _, fixtureID := range s.fixtureIDs {
+		var efResp struct {
+			AddFixtureToEffect struct {
+				ID string `json:"id"`
+			} `json:"addFixtureToEffect"`
+		}
+		err = s.client.Mutate(s.ctx, `
+			mutation AddFixture($input: AddFixtureToEffectInput!) {
+				addFixtureToEffect(input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{"effectId": effectID, "fixtureId": fixtureID},
+		}, &efResp)
+		require.NoError(t, err)
+
+		err = s.client.Mutate(s.ctx, `
+			mutation AddChannel($effectFixtureId: ID!, $input: EffectChannelInput!) {
+				addChannelToEffectFixture(effectFixtureId: $effectFixtureId, input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"effectFixtureId": efResp.AddFixtureToEffect.ID,
+			"input":           map[string]interface{}{"channelOffset": 0},
+		}, nil)
+		require.NoError(t, err)
+	}
+
+	err = s.client.Mutate(s.ctx, `
+		mutation ActivateEffect($effectId: ID!, $fadeTime: Float) {
+			activateEffect(effectId: $effectId, fadeTime: $fadeTime)
+		}
+	`, map[string]interface{}{"effectId": effectID, "fadeTime": 0.0}, nil)
+	require.NoError(t, err)
+	return effectID
+}
@@ -0,0 +1,249 @@
+package playback
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// activeLookID returns the ID of currentActiveLook, or "" when nothing is live.
+func activeLookID(t *testing.T, client *graphql.Client, ctx context.Context) string {
+	var resp struct {
+		CurrentActiveLook *struct {
+			ID string `json:"id"`
+		} `json:"currentActiveLook"`
+	}
+
+	err := client.Query(ctx, `query { currentActiveLook { id } }`, nil, &resp)
+	require.NoError(t, err)
+	if resp.CurrentActiveLook == nil {
+		return ""
+	}
+	return resp.CurrentActiveLook.ID
+}
+
+// TestCurrentActiveLookActivationPaths verifies that currentActiveLook follows every
+// way a look can go live (setLookLive, look board, cue list GO) and is cleared by
+// fadeToBlack. After stopCueList it must agree with what is actually on stage.
+func TestCurrentActiveLookActivationPaths(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewClient("")
+
+	projectID, cueListID, look1ID, look2ID := setupPlaybackTest(t, client, ctx)
+	defer cleanupPlaybackTest(client, ctx, projectID)
+
+	var boardResp struct {
+		CreateLookBoard struct {
+			ID string `json:"id"`
+		} `json:"createLookBoard"`
+	}
+	err := client.Mutate(ctx, `
+		mutation CreateLookBoard($input: CreateLookBoardInput!) {
+			createLookBoard(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId":       projectID,
+			"name":            "Active Look Board",
+			"defaultFadeTime": 0.2,
+		},
+	}, &boardResp)
+	require.NoError(t, err)
+	boardID := boardResp.CreateLookBoard.ID
+
+	for i, lookID := range []string{look1ID, look2ID} {
+		err = client.Mutate(ctx, `
+			mutation AddLookToBoard($input: CreateLookBoardButtonInput!) {
+				addLookToBoard(input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"lookBoardId": boardID,
+				"lookId":      lookID,
+				"layoutX":     i * 200,
+				"layoutY":     0,
+			},
+		}, nil)
+		require.NoError(t, err)
+	}
+
+	t.Run("SetLookLive", func(t *testing.T) {
+		err := client.Mutate(ctx, `
+			mutation SetLookLive($lookId: ID!) {
+				setLookLive(lookId: $lookId)
+			}
+		`, map[string]interface{}{"lookId": look1ID}, nil)
+		require.NoError(t, err)
+		time.Sleep(cueTransitionSettleTime)
+
+		assert.Equal(t, look1ID, activeLookID(t, client, ctx))
+	})
+
+	t.Run("FadeToBlackClears", func(t *testing.T) {
+		err := client.Mutate(ctx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+		require.NoError(t, err)
+		time.Sleep(cueTransitionSettleTime)
+
+		assert.Empty(t, activeLookID(t, client, ctx), "No look should be active after fadeToBlack")
+	})
+
+	t.Run("LookBoard", func(t *testing.T) {
+		err := client.Mutate(ctx, `
+			mutation ActivateLookFromBoard($lookBoardId: ID!, $lookId: ID!) {
+				activateLookFromBoard(lookBoardId: $lookBoardId, lookId: $lookId)
+			}
+		`, map[string]interface{}{"lookBoardId": boardID, "lookId": look2ID}, nil)
+		require.NoError(t, err)
+		time.Sleep(cueTransitionSettleTime)
+
+		assert.Equal(t, look2ID, activeLookID(t, client, ctx))
+	})
+
+	t.Run("CueList", func(t *testing.T) {
+		err := client.Mutate(ctx, `
+			mutation StartCueList($cueListId: ID!) {
+				startCueList(cueListId: $cueListId)
+			}
+		`, map[string]interface{}{"cueListId": cueListID}, nil)
+		require.NoError(t, err)
+		time.Sleep(cueTransitionSettleTime)
+		assert.Equal(t, look1ID, activeLookID(t, client, ctx), "Starting the cue list should make cue A's look active")
+
+		err = client.Mutate(ctx, `
+			mutation NextCue($cueListId: ID!) {
+				nextCue(cueListId: $cueListId)
+			}
+		`, map[string]interface{}{"cueListId": cueListID}, nil)
+		require.NoError(t, err)
+		time.Sleep(cueTransitionSettleTime)
+		assert.Equal(t, look2ID, activeLookID(t, client, ctx), "GO should make cue B's look active")
+	})
+
+	t.Run("StopCueList", func(t *testing.T) {
+		err := client.Mutate(ctx, `
+			mutation StopCueList($cueListId: ID!) {
+				stopCueList(cueListId: $cueListId)
+			}
+		`, map[string]interface{}{"cueListId": cueListID}, nil)
+		require.NoError(t, err)
+		time.Sleep(cueTransitionSettleTime)
+
+		active := activeLookID(t, client, ctx)
+		if skipDMXTests() {
+			t.Logf("Active look after stopCueList: %q (DMX cross-check skipped)", active)
+			return
+		}
+
+		var dmxResp struct {
+			DMXOutput []int `json:"dmxOutput"`
+		}
+		err = client.Query(ctx, `query { dmxOutput(universe: 1) }`, nil, &dmxResp)
+		require.NoError(t, err)
+		require.Len(t, dmxResp.DMXOutput, 512, "dmxOutput should cover the universe")
+
+		// Look 2 is half bright; either it is still on stage and reported, or the stage is dark and nothing is
+		switch dmxResp.DMXOutput[0] {
+		case 128:
+			assert.Equal(t, look2ID, active, "Look still on stage after stop should still be reported")
+		case 0:
+			assert.Empty(t, active, "Dark stage after stop should report no active look")
+		default:
+			t.Logf("Output still settling after stop (%d), active look %q", dmxResp.DMXOutput[0], active)
+		}
+	})
+}
+
+// TestLiveStateSource verifies that the server reports where the live look came from,
+// so UIs can show "Board", "Cue 2 of Main", or "Direct" next to the active look.
+func TestLiveStateSource(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewClient("")
+
+	compat.RequireQuery(t, client, "currentLiveState",
+		"currentLiveState: LiveState { look: Look, source: LiveSource! (DIRECT|LOOK_BOARD|CUE_LIST|NONE), lookBoardId: ID, cueListId: ID, cueId: ID }")
+
+	projectID, cueListID, look1ID, look2ID := setupPlaybackTest(t, client, ctx)
+	defer cleanupPlaybackTest(client, ctx, projectID)
+
+	type liveState struct {
+		Look *struct {
+			ID string `json:"id"`
+		} `json:"look"`
+		Source    string  `json:"source"`
+		CueListID *string `json:"cueListId"`
+		CueID     *string `json:"cueId"`
+	}
+	query := func(t *testing.T) liveState {
+		var resp struct {
+			CurrentLiveState liveState `json:"currentLiveState"`
+		}
+		err := client.Query(ctx, `
+			query {
+				currentLiveState {
+					look { id }
+					source
+					cueListId
+					cueId
+				}
+			}
+		`, nil, &resp)
+		require.NoError(t, err)
+		return resp.CurrentLiveState
+	}
+
+	t.Run("Direct", func(t *testing.T) {
+		err := client.Mutate(ctx, `
+			mutation SetLookLive($lookId: ID!) {
+				setLookLive(lookId: $lookId)
+			}
+		`, map[string]interface{}{"lookId": look2ID}, nil)
+		require.NoError(t, err)
+		time.Sleep(cueTransitionSettleTime)
+
+		state := query(t)
+		require.NotNil(t, state.Look)
+		assert.Equal(t, look2ID, state.Look.ID)
+		assert.Equal(t, "DIRECT", state.Source)
+		assert.Nil(t, state.CueListID)
+	})
+
+	t.Run("CueList", func(t *testing.T) {
+		err := client.Mutate(ctx, `
+			mutation StartCueList($cueListId: ID!) {
+				startCueList(cueListId: $cueListId)
+			}
+		`, map[string]interface{}{"cueListId": cueListID}, nil)
+		require.NoError(t, err)
+		time.Sleep(cueTransitionSettleTime)
+
+		state := query(t)
+		require.NotNil(t, state.Look)
+		assert.Equal(t, look1ID, state.Look.ID)
+		assert.Equal(t, "CUE_LIST", state.Source)
+		require.NotNil(t, state.CueListID)
+		assert.Equal(t, cueListID, *state.CueListID)
+		assert.NotNil(t, state.CueID)
+
+		_ = client.Mutate(ctx, `mutation StopCueList($id: ID!) { stopCueList(cueListId: $id) }`,
+			map[string]interface{}{"id": cueListID}, nil)
+	})
+
+	t.Run("NoneAfterBlackout", func(t *testing.T) {
+		err := client.Mutate(ctx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+		require.NoError(t, err)
+		time.Sleep(cueTransitionSettleTime)
+
+		state := query(t)
+		assert.Nil(t, state.Look)
+		assert.Equal(t, "NONE", state.Source)
+	})
+}
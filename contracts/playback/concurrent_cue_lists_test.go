@@ -0,0 +1,342 @@
+package playback
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artifacts"
+	"github.com/bbernstein/lacylights-test/pkg/dmxassert"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// concurrentSettleTime covers a concurrent cue's 0.5s fade plus engine latency.
+const concurrentSettleTime = 1200 * time.Millisecond
+
+// concurrentSetup is a project with single-channel dimmers at channels 1..n of universe 1.
+type concurrentSetup struct {
+	client     *graphql.Client
+	ctx        context.Context
+	projectID  string
+	fixtureIDs []string
+}
+
+func newConcurrentSetup(t *testing.T, client *graphql.Client, ctx context.Context, fixtures int) *concurrentSetup {
+	_ = client.Mutate(ctx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+	time.Sleep(200 * time.Millisecond)
+
+	s := &concurrentSetup{client: client, ctx: ctx}
+	artifacts.For(t).AddClient(client)
+
+	var projectResp struct {
+		CreateProject struct {
+			ID string `json:"id"`
+		} `json:"createProject"`
+	}
+	err := client.Mutate(ctx, `
+		mutation CreateProject($input: CreateProjectInput!) {
+			createProject(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{"name": "Concurrent Cue Lists Test Project"},
+	}, &projectResp)
+	require.NoError(t, err)
+	s.projectID = projectResp.CreateProject.ID
+
+	var defResp struct {
+		CreateFixtureDefinition struct {
+			ID string `json:"id"`
+		} `json:"createFixtureDefinition"`
+	}
+	err = client.Mutate(ctx, `
+		mutation CreateFixtureDefinition($input: CreateFixtureDefinitionInput!) {
+			createFixtureDefinition(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"manufacturer": "Test Concurrent",
+			"model":        fmt.Sprintf("Concurrent Dimmer %d", time.Now().UnixNano()),
+			"type":         "DIMMER",
+			"channels": []map[string]interface{}{
+				{"name": "Intensity", "type": "INTENSITY", "offset": 0, "defaultValue": 0, "minValue": 0, "maxValue": 255},
+			},
+		},
+	}, &defResp)
+	require.NoError(t, err)
+	definitionID := defResp.CreateFixtureDefinition.ID
+	t.Cleanup(func() {
+		cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cleanupCancel()
+		_ = client.Mutate(cleanupCtx, `mutation DeleteFixtureDefinition($id: ID!) { deleteFixtureDefinition(id: $id) }`,
+			map[string]interface{}{"id": definitionID}, nil)
+	})
+
+	for i := 1; i <= fixtures; i++ {
+		var fixtureResp struct {
+			CreateFixtureInstance struct {
+				ID string `json:"id"`
+			} `json:"createFixtureInstance"`
+		}
+		err = client.Mutate(ctx, `
+			mutation CreateFixtureInstance($input: CreateFixtureInstanceInput!) {
+				createFixtureInstance(input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"projectId":    s.projectID,
+				"definitionId": definitionID,
+				"name":         fmt.Sprintf("Concurrent Dimmer %d", i),
+				"universe":     1,
+				"startChannel": i,
+			},
+		}, &fixtureResp)
+		require.NoError(t, err)
+		s.fixtureIDs = append(s.fixtureIDs, fixtureResp.CreateFixtureInstance.ID)
+	}
+
+	return s
+}
+
+// createLook creates a look holding only the given channels, keyed by 1-based DMX channel.
+func (s *concurrentSetup) createLook(t *testing.T, name string, channels map[int]int) string {
+	var fixtureValues []map[string]interface{}
+	for channel, value := range channels {
+		fixtureValues = append(fixtureValues, map[string]interface{}{
+			"fixtureId": s.fixtureIDs[channel-1],
+			"channels":  []map[string]int{{"offset": 0, "value": value}},
+		})
+	}
+
+	var resp struct {
+		CreateLook struct {
+			ID string `json:"id"`
+		} `json:"createLook"`
+	}
+	err := s.client.Mutate(s.ctx, `
+		mutation CreateLook($input: CreateLookInput!) {
+			createLook(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId":     s.projectID,
+			"name":          name,
+			"fixtureValues": fixtureValues,
+		},
+	}, &resp)
+	require.NoError(t, err)
+	return resp.CreateLook.ID
+}
+
+// createCueList creates a cue list with one 0.5s cue per look, in order.
+func (s *concurrentSetup) createCueList(t *testing.T, name string, lookIDs ...string) string {
+	var listResp struct {
+		CreateCueList struct {
+			ID string `json:"id"`
+		} `json:"createCueList"`
+	}
+	err := s.client.Mutate(s.ctx, `
+		mutation CreateCueList($input: CreateCueListInput!) {
+			createCueList(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{"projectId": s.projectID, "name": name},
+	}, &listResp)
+	require.NoError(t, err)
+	cueListID := listResp.CreateCueList.ID
+
+	for i, lookID := range lookIDs {
+		err = s.client.Mutate(s.ctx, `
+			mutation CreateCue($input: CreateCueInput!) {
+				createCue(input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"cueListId":   cueListID,
+				"lookId":      lookID,
+				"name":        fmt.Sprintf("%s Cue %d", name, i+1),
+				"cueNumber":   float64(i + 1),
+				"fadeInTime":  0.5,
+				"fadeOutTime": 0.5,
+			},
+		}, nil)
+		require.NoError(t, err)
+	}
+	return cueListID
+}
+
+// control runs a cue list mutation that takes only cueListId and returns a Boolean.
+func (s *concurrentSetup) control(t *testing.T, mutation, cueListID string) {
+	var resp map[string]bool
+	err := s.client.Mutate(s.ctx, fmt.Sprintf(`
+		mutation Control($cueListId: ID!) {
+			%s(cueListId: $cueListId)
+		}
+	`, mutation), map[string]interface{}{"cueListId": cueListID}, &resp)
+	require.NoError(t, err)
+	require.True(t, resp[mutation], "%s should succeed", mutation)
+}
+
+// status returns whether the cue list is playing and its current cue index.
+func (s *concurrentSetup) status(t *testing.T, cueListID string) (bool, *int) {
+	var resp struct {
+		CueListPlaybackStatus *struct {
+			IsPlaying       bool `json:"isPlaying"`
+			CurrentCueIndex *int `json:"currentCueIndex"`
+		} `json:"cueListPlaybackStatus"`
+	}
+	err := s.client.Query(s.ctx, `
+		query GetPlaybackStatus($cueListId: ID!) {
+			cueListPlaybackStatus(cueListId: $cueListId) {
+				isPlaying
+				currentCueIndex
+			}
+		}
+	`, map[string]interface{}{"cueListId": cueListID}, &resp)
+	require.NoError(t, err)
+	if resp.CueListPlaybackStatus == nil {
+		return false, nil
+	}
+	return resp.CueListPlaybackStatus.IsPlaying, resp.CueListPlaybackStatus.CurrentCueIndex
+}
+
+// requireCueIndex asserts the cue list is playing at the given index.
+func (s *concurrentSetup) requireCueIndex(t *testing.T, cueListID string, want int) {
+	playing, index := s.status(t, cueListID)
+	assert.True(t, playing, "Cue list %s should be playing", cueListID)
+	if assert.NotNil(t, index, "Cue list %s should report a current cue", cueListID) {
+		assert.Equal(t, want, *index, "Cue list %s current cue index", cueListID)
+	}
+}
+
+// expectChannels asserts the given 1-based channels of universe 1.
+func (s *concurrentSetup) expectChannels(t *testing.T, want map[int]int, msg string) {
+	if skipDMXTests() {
+		return
+	}
+	var resp struct {
+		DMXOutput []int `json:"dmxOutput"`
+	}
+	err := s.client.Query(s.ctx, `query { dmxOutput(universe: 1) }`, nil, &resp)
+	require.NoError(t, err)
+
+	frame := make([]int, 0, len(resp.DMXOutput))
+	for channel, value := range want {
+		for len(frame) < channel {
+			frame = append(frame, dmxassert.Any)
+		}
+		frame[channel-1] = value
+	}
+	dmxassert.ExpectFrame(t, resp.DMXOutput, frame, 5, dmxassert.Msg("%s", msg))
+}
+
+// TestConcurrentCueListsDisjointFixtures runs two cue lists at once on
+// separate fixtures. Each must advance on its own, and neither may disturb
+// the other's channels.
+func TestConcurrentCueListsDisjointFixtures(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+	defer cancel()
+
+	client := graphql.NewClient("")
+
+	s := newConcurrentSetup(t, client, ctx, 4)
+	defer cleanupPlaybackTest(client, ctx, s.projectID)
+
+	// List A drives channels 1-2, list B drives channels 3-4
+	listA := s.createCueList(t, "List A",
+		s.createLook(t, "A1", map[int]int{1: 200, 2: 200}),
+		s.createLook(t, "A2", map[int]int{1: 50, 2: 50}))
+	listB := s.createCueList(t, "List B",
+		s.createLook(t, "B1", map[int]int{3: 150, 4: 150}),
+		s.createLook(t, "B2", map[int]int{3: 250, 4: 250}))
+
+	t.Run("StartA", func(t *testing.T) {
+		s.control(t, "startCueList", listA)
+		time.Sleep(concurrentSettleTime)
+
+		s.requireCueIndex(t, listA, 0)
+		s.expectChannels(t, map[int]int{1: 200, 2: 200, 3: 0, 4: 0}, "After starting A")
+	})
+
+	t.Run("StartB", func(t *testing.T) {
+		s.control(t, "startCueList", listB)
+		time.Sleep(concurrentSettleTime)
+
+		s.requireCueIndex(t, listA, 0)
+		s.requireCueIndex(t, listB, 0)
+		s.expectChannels(t, map[int]int{1: 200, 2: 200, 3: 150, 4: 150}, "After starting B alongside A")
+	})
+
+	t.Run("AdvanceA", func(t *testing.T) {
+		s.control(t, "nextCue", listA)
+		time.Sleep(concurrentSettleTime)
+
+		s.requireCueIndex(t, listA, 1)
+		s.requireCueIndex(t, listB, 0)
+		s.expectChannels(t, map[int]int{1: 50, 2: 50, 3: 150, 4: 150}, "After advancing A only")
+	})
+
+	t.Run("AdvanceB", func(t *testing.T) {
+		s.control(t, "nextCue", listB)
+		time.Sleep(concurrentSettleTime)
+
+		s.requireCueIndex(t, listA, 1)
+		s.requireCueIndex(t, listB, 1)
+		s.expectChannels(t, map[int]int{1: 50, 2: 50, 3: 250, 4: 250}, "After advancing B only")
+	})
+
+	t.Run("StopA", func(t *testing.T) {
+		s.control(t, "stopCueList", listA)
+		time.Sleep(concurrentSettleTime)
+
+		playing, _ := s.status(t, listA)
+		assert.False(t, playing, "A should stop")
+		s.requireCueIndex(t, listB, 1)
+		s.expectChannels(t, map[int]int{3: 250, 4: 250}, "Stopping A must leave B's channels alone")
+	})
+}
+
+// TestConcurrentCueListsOverlappingFixtures runs two cue lists that share a
+// fixture. Arbitration is latest-takes-precedence: the most recently executed
+// cue owns the shared channel, while channels only one list drives keep that
+// list's value.
+func TestConcurrentCueListsOverlappingFixtures(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+	defer cancel()
+
+	client := graphql.NewClient("")
+
+	s := newConcurrentSetup(t, client, ctx, 3)
+	defer cleanupPlaybackTest(client, ctx, s.projectID)
+
+	// Channel 1 is shared; channel 2 belongs to A and channel 3 to B
+	listA := s.createCueList(t, "List A",
+		s.createLook(t, "A1", map[int]int{1: 200, 2: 180}),
+		s.createLook(t, "A2", map[int]int{1: 60, 2: 90}))
+	listB := s.createCueList(t, "List B",
+		s.createLook(t, "B1", map[int]int{1: 120, 3: 220}))
+
+	s.control(t, "startCueList", listA)
+	time.Sleep(concurrentSettleTime)
+	s.expectChannels(t, map[int]int{1: 200, 2: 180, 3: 0}, "After starting A")
+
+	t.Run("LaterListTakesSharedChannel", func(t *testing.T) {
+		s.control(t, "startCueList", listB)
+		time.Sleep(concurrentSettleTime)
+
+		s.requireCueIndex(t, listA, 0)
+		s.requireCueIndex(t, listB, 0)
+		s.expectChannels(t, map[int]int{1: 120, 2: 180, 3: 220}, "B started last, so it owns channel 1")
+	})
+
+	t.Run("AdvancedListRetakesSharedChannel", func(t *testing.T) {
+		s.control(t, "nextCue", listA)
+		time.Sleep(concurrentSettleTime)
+
+		s.requireCueIndex(t, listA, 1)
+		s.requireCueIndex(t, listB, 0)
+		s.expectChannels(t, map[int]int{1: 60, 2: 90, 3: 220}, "A advanced last, so it owns channel 1 again")
+	})
+}
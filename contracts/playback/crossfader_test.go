@@ -0,0 +1,128 @@
+package playback
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// crossfaderContract is the manual crossfader a fader wing drives
+	crossfaderContract = `setCrossfadePosition(cueListId: ID!, position: Float!): Boolean!
+  position 0.0 holds the current cue, 1.0 completes the transition to the next cue`
+
+	// crossfadeFrom and crossfadeTo are channel 1 in the two cues being crossfaded
+	crossfadeFrom = 40
+	crossfadeTo   = 240
+
+	// crossfadeHoldTime is how long a manual position must hold its output
+	crossfadeHoldTime = 750 * time.Millisecond
+)
+
+// setCrossfadePosition moves the cue list's manual crossfader.
+func (s *concurrentSetup) setCrossfadePosition(t *testing.T, cueListID string, position float64) {
+	var resp struct {
+		SetCrossfadePosition bool `json:"setCrossfadePosition"`
+	}
+	err := s.client.Mutate(s.ctx, `
+		mutation SetCrossfadePosition($cueListId: ID!, $position: Float!) {
+			setCrossfadePosition(cueListId: $cueListId, position: $position)
+		}
+	`, map[string]interface{}{"cueListId": cueListID, "position": position}, &resp)
+	require.NoError(t, err)
+	require.True(t, resp.SetCrossfadePosition, "setCrossfadePosition(%.2f) should succeed", position)
+}
+
+// crossfadeLevel is channel 1's expected value at a crossfader position.
+func crossfadeLevel(position float64) int {
+	return crossfadeFrom + int(position*float64(crossfadeTo-crossfadeFrom)+0.5)
+}
+
+// newCrossfadeCueList starts a two-cue list and waits for the first cue to finish fading in.
+func newCrossfadeCueList(t *testing.T, s *concurrentSetup) string {
+	cueListID, _ := s.createStatusCueList(t,
+		s.createLook(t, "Crossfade From", map[int]int{1: crossfadeFrom}),
+		s.createLook(t, "Crossfade To", map[int]int{1: crossfadeTo}))
+
+	s.control(t, "startCueList", cueListID)
+	time.Sleep(time.Duration((statusFadeTime + 0.5) * float64(time.Second)))
+	s.expectChannels(t, map[int]int{1: crossfadeFrom}, "First cue before crossfading")
+	return cueListID
+}
+
+// TestManualCrossfadePositions scrubs the transition to the next cue by hand.
+// Each position must put the output at the proportional point between the
+// two cues and hold it there, and position 1.0 must complete the go.
+func TestManualCrossfadePositions(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewClient("")
+	compat.RequireMutation(t, client, "setCrossfadePosition", crossfaderContract)
+
+	s := newConcurrentSetup(t, client, ctx, 1)
+	defer cleanupPlaybackTest(client, ctx, s.projectID)
+
+	cueListID := newCrossfadeCueList(t, s)
+
+	for _, position := range []float64{0.25, 0.5, 0.75, 0.5} {
+		t.Run(fmt.Sprintf("Position%.2f", position), func(t *testing.T) {
+			s.setCrossfadePosition(t, cueListID, position)
+			time.Sleep(cueTransitionSettleTime)
+
+			want := map[int]int{1: crossfadeLevel(position)}
+			s.expectChannels(t, want, "At the crossfader position")
+			time.Sleep(crossfadeHoldTime)
+			s.expectChannels(t, want, "Holding the crossfader position")
+			s.requireCueIndex(t, cueListID, 0)
+		})
+	}
+
+	t.Run("FullPositionCompletesGo", func(t *testing.T) {
+		s.setCrossfadePosition(t, cueListID, 1.0)
+		time.Sleep(cueTransitionSettleTime)
+
+		s.expectChannels(t, map[int]int{1: crossfadeTo}, "Crossfader at 1.0")
+		s.requireCueIndex(t, cueListID, 1)
+	})
+}
+
+// TestManualCrossfadeTakesOverAutoFade moves the crossfader while a timed go
+// is running. The manual position must win: the auto-fade stops advancing and
+// the output jumps to, and holds at, the crossfader's level.
+func TestManualCrossfadeTakesOverAutoFade(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewClient("")
+	compat.RequireMutation(t, client, "setCrossfadePosition", crossfaderContract)
+
+	s := newConcurrentSetup(t, client, ctx, 1)
+	defer cleanupPlaybackTest(client, ctx, s.projectID)
+
+	cueListID := newCrossfadeCueList(t, s)
+
+	// A quarter of the way into the timed fade
+	s.control(t, "nextCue", cueListID)
+	time.Sleep(time.Duration(statusFadeTime * 0.25 * float64(time.Second)))
+
+	s.setCrossfadePosition(t, cueListID, 0.75)
+	time.Sleep(cueTransitionSettleTime)
+
+	want := map[int]int{1: crossfadeLevel(0.75)}
+	s.expectChannels(t, want, "Crossfader taking over the auto-fade")
+
+	// Past the point where the timed fade would have finished
+	time.Sleep(time.Duration(statusFadeTime * float64(time.Second)))
+	s.expectChannels(t, want, "Auto-fade should not resume after the takeover")
+
+	s.setCrossfadePosition(t, cueListID, 1.0)
+	time.Sleep(cueTransitionSettleTime)
+	s.expectChannels(t, map[int]int{1: crossfadeTo}, "Crossfader at 1.0 after the takeover")
+	s.requireCueIndex(t, cueListID, 1)
+}
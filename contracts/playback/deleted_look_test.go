@@ -0,0 +1,143 @@
+package playback
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// deletedLookContract is how deleting the look of the cue on stage should behave.
+const deletedLookContract = `deleteLook on the look of a cue that is currently live either succeeds or
+  fails with a GraphQL error (never a 5xx); after a delete the cue is removed or keeps a null look,
+  the live output holds steady, and the cue list can still be driven to its other cues`
+
+// TestLookDeletedWhileCueActive deletes the look of the cue that is live. The
+// server must not fault, the output must not be left frozen mid-fade, and the
+// cue list must keep working. See deletedLookContract.
+func TestLookDeletedWhileCueActive(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewClient("")
+
+	s := newConcurrentSetup(t, client, ctx, 1)
+	defer cleanupPlaybackTest(client, ctx, s.projectID)
+
+	lookA := s.createLook(t, "Doomed Look", map[int]int{1: 200})
+	lookB := s.createLook(t, "Second Look", map[int]int{1: 80})
+	lookC := s.createLook(t, "Third Look", map[int]int{1: 150})
+	cueListID := s.createCueList(t, "Deleted Look", lookA, lookB, lookC)
+	defer func() {
+		_ = client.Mutate(ctx, `mutation StopCueList($cueListId: ID!) { stopCueList(cueListId: $cueListId) }`,
+			map[string]interface{}{"cueListId": cueListID}, nil)
+	}()
+
+	s.control(t, "startCueList", cueListID)
+	time.Sleep(concurrentSettleTime)
+	s.expectChannels(t, map[int]int{1: 200}, "Cue 1 live")
+
+	err := client.Mutate(ctx, `mutation DeleteLook($id: ID!) { deleteLook(id: $id) }`,
+		map[string]interface{}{"id": lookA}, nil)
+	if err != nil {
+		require.Less(t, graphql.HTTPStatus(err), 500, "Deleting a live look should not fail with a server error: %v", err)
+		require.NotEmpty(t, graphql.ResponseErrors(err), "Deleting a live look failed without a GraphQL error: %v", err)
+		t.Logf("Contract: deleting the look of a live cue is refused: %v", err)
+		s.expectChannels(t, map[int]int{1: 200}, "A refused delete leaves the cue live")
+		s.requireCueIndex(t, cueListID, 0)
+		return
+	}
+
+	var listResp struct {
+		CueList struct {
+			Cues []struct {
+				Name string `json:"name"`
+				Look *struct {
+					ID string `json:"id"`
+				} `json:"look"`
+			} `json:"cues"`
+		} `json:"cueList"`
+	}
+
+	t.Run("CueListAfterDelete", func(t *testing.T) {
+		err := client.Query(ctx, `
+			query GetCueList($id: ID!) {
+				cueList(id: $id) { cues { name look { id } } }
+			}
+		`, map[string]interface{}{"id": cueListID}, &listResp)
+		require.NoError(t, err, "The cue list should still be readable")
+
+		switch len(listResp.CueList.Cues) {
+		case 2:
+			t.Logf("Contract: deleting a look removes the cues that used it")
+		case 3:
+			cue := listResp.CueList.Cues[0]
+			assert.Nil(t, cue.Look, "%s should no longer reference the deleted look", cue.Name)
+			t.Logf("Contract: deleting a look leaves its cues with a null look")
+		default:
+			t.Errorf("Expected 2 or 3 cues after deleting a look, got %d", len(listResp.CueList.Cues))
+		}
+
+		var statusResp struct {
+			CueListPlaybackStatus *struct {
+				IsPlaying bool `json:"isPlaying"`
+			} `json:"cueListPlaybackStatus"`
+		}
+		err = client.Query(ctx, `
+			query GetPlaybackStatus($cueListId: ID!) {
+				cueListPlaybackStatus(cueListId: $cueListId) { isPlaying }
+			}
+		`, map[string]interface{}{"cueListId": cueListID}, &statusResp)
+		require.NoError(t, err, "Playback status should still be readable")
+	})
+
+	t.Run("OutputHoldsSteady", func(t *testing.T) {
+		if skipDMXTests() {
+			t.Skip("Skipping DMX checks")
+		}
+		time.Sleep(concurrentSettleTime)
+		first := s.channelOne(t)
+		time.Sleep(300 * time.Millisecond)
+		assert.Equal(t, first, s.channelOne(t), "Output should settle after the live look is deleted")
+		if first == 200 {
+			t.Logf("Contract: the deleted look's output stays live until the next cue")
+		} else {
+			t.Logf("Contract: deleting the live look releases its output (channel 1 at %d)", first)
+		}
+	})
+
+	t.Run("CueListStillDrives", func(t *testing.T) {
+		index := -1
+		for i, cue := range listResp.CueList.Cues {
+			if cue.Look != nil && cue.Look.ID == lookB {
+				index = i
+			}
+		}
+		require.GreaterOrEqual(t, index, 0, "The cue on the second look should still be in the list")
+
+		var gotoResp struct {
+			GoToCue bool `json:"goToCue"`
+		}
+		err := client.Mutate(ctx, `
+			mutation GoToCue($cueListId: ID!, $cueIndex: Int!) {
+				goToCue(cueListId: $cueListId, cueIndex: $cueIndex)
+			}
+		`, map[string]interface{}{"cueListId": cueListID, "cueIndex": index}, &gotoResp)
+		require.NoError(t, err)
+		assert.True(t, gotoResp.GoToCue)
+		time.Sleep(concurrentSettleTime)
+		s.expectChannels(t, map[int]int{1: 80}, "After going to the second look's cue")
+
+		s.control(t, "nextCue", cueListID)
+		time.Sleep(concurrentSettleTime)
+		s.expectChannels(t, map[int]int{1: 150}, "After the next cue")
+
+		err = client.Mutate(ctx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+		require.NoError(t, err)
+		time.Sleep(200 * time.Millisecond)
+		s.expectChannels(t, map[int]int{1: 0}, "After fade to black")
+	})
+}
@@ -0,0 +1,318 @@
+package playback
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/calibration"
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/bbernstein/lacylights-test/pkg/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// playbackStatusContract is the status shape front-ends render progress from
+	playbackStatusContract = `cueListPlaybackStatus(cueListId: ID!): CueListPlaybackStatus
+  CueListPlaybackStatus { state: IDLE | FADING | HOLDING, currentCue: Cue, nextCue: Cue, fadeProgress: Float (0-100) }
+  subscription cueListPlaybackStatusChanged(cueListId: ID!): CueListPlaybackStatus!`
+
+	// statusFadeTime is long enough to sample the fade several times
+	statusFadeTime = 2.0
+
+	// statusPollInterval is how often the fade is sampled
+	statusPollInterval = 100 * time.Millisecond
+
+	// progressTolerance is the allowed gap, in percent, between fadeProgress
+	// and elapsed time or DMX level, covering request latency between reads;
+	// scaled by the calibration profile
+	progressTolerance = 15.0
+
+	playbackStatusFields = `
+		state
+		fadeProgress
+		currentCue { id }
+		nextCue { id }
+	`
+)
+
+type playbackStatus struct {
+	State        string   `json:"state"`
+	FadeProgress *float64 `json:"fadeProgress"`
+	CurrentCue   *struct {
+		ID string `json:"id"`
+	} `json:"currentCue"`
+	NextCue *struct {
+		ID string `json:"id"`
+	} `json:"nextCue"`
+}
+
+// createStatusCueList creates a cue list of linear statusFadeTime cues and returns it with its cue IDs.
+func (s *concurrentSetup) createStatusCueList(t *testing.T, lookIDs ...string) (string, []string) {
+	var listResp struct {
+		CreateCueList struct {
+			ID string `json:"id"`
+		} `json:"createCueList"`
+	}
+	err := s.client.Mutate(s.ctx, `
+		mutation CreateCueList($input: CreateCueListInput!) {
+			createCueList(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{"projectId": s.projectID, "name": "Status List"},
+	}, &listResp)
+	require.NoError(t, err)
+	cueListID := listResp.CreateCueList.ID
+
+	var cueIDs []string
+	for i, lookID := range lookIDs {
+		var cueResp struct {
+			CreateCue struct {
+				ID string `json:"id"`
+			} `json:"createCue"`
+		}
+		err = s.client.Mutate(s.ctx, `
+			mutation CreateCue($input: CreateCueInput!) {
+				createCue(input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"cueListId":   cueListID,
+				"lookId":      lookID,
+				"name":        "Status Cue " + string(rune('A'+i)),
+				"cueNumber":   float64(i + 1),
+				"fadeInTime":  statusFadeTime,
+				"fadeOutTime": statusFadeTime,
+				"easingType":  "LINEAR",
+			},
+		}, &cueResp)
+		require.NoError(t, err)
+		cueIDs = append(cueIDs, cueResp.CreateCue.ID)
+	}
+	return cueListID, cueIDs
+}
+
+// playbackStatus reads the cue list's status; nil means the server reports none.
+func (s *concurrentSetup) playbackStatus(t *testing.T, cueListID string) *playbackStatus {
+	var resp struct {
+		CueListPlaybackStatus *playbackStatus `json:"cueListPlaybackStatus"`
+	}
+	err := s.client.Query(s.ctx, `
+		query GetPlaybackStatus($cueListId: ID!) {
+			cueListPlaybackStatus(cueListId: $cueListId) {`+playbackStatusFields+`}
+		}
+	`, map[string]interface{}{"cueListId": cueListID}, &resp)
+	require.NoError(t, err)
+	return resp.CueListPlaybackStatus
+}
+
+// channelOne reads DMX channel 1 of universe 1.
+func (s *concurrentSetup) channelOne(t *testing.T) int {
+	var resp struct {
+		DMXOutput []int `json:"dmxOutput"`
+	}
+	err := s.client.Query(s.ctx, `query { dmxOutput(universe: 1) }`, nil, &resp)
+	require.NoError(t, err)
+	require.Len(t, resp.DMXOutput, 512, "dmxOutput should cover the universe")
+	return resp.DMXOutput[0]
+}
+
+// cueID returns the id of an optional cue reference, or "" when it is null.
+func cueID(ref *struct {
+	ID string `json:"id"`
+}) string {
+	if ref == nil {
+		return ""
+	}
+	return ref.ID
+}
+
+// TestCueListPlaybackStatusPolling polls cueListPlaybackStatus through a fade
+// and checks it against elapsed time and the DMX output: the state goes
+// IDLE -> FADING -> HOLDING, fadeProgress tracks the fade, and currentCue and
+// nextCue follow the list.
+func TestCueListPlaybackStatusPolling(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+	defer cancel()
+
+	client := graphql.NewClient("")
+	compat.RequireTypeField(t, client, "CueListPlaybackStatus", "state", playbackStatusContract)
+
+	s := newConcurrentSetup(t, client, ctx, 1)
+	defer cleanupPlaybackTest(client, ctx, s.projectID)
+	tolerance := calibration.Get(t).Value(progressTolerance)
+
+	cueListID, cueIDs := s.createStatusCueList(t,
+		s.createLook(t, "Status Full", map[int]int{1: 255}),
+		s.createLook(t, "Status Half", map[int]int{1: 128}))
+
+	t.Run("IdleBeforeStart", func(t *testing.T) {
+		status := s.playbackStatus(t, cueListID)
+		if status != nil {
+			assert.Equal(t, "IDLE", status.State)
+			assert.Empty(t, cueID(status.CurrentCue), "No cue is current before start")
+		}
+	})
+
+	s.control(t, "startCueList", cueListID)
+	start := time.Now()
+
+	t.Run("FadingTracksTimeAndOutput", func(t *testing.T) {
+		lastProgress := -1.0
+		fadingSamples := 0
+
+		for time.Since(start) < time.Duration(statusFadeTime*0.9*float64(time.Second)) {
+			status := s.playbackStatus(t, cueListID)
+			elapsed := time.Since(start).Seconds()
+			level := s.channelOne(t)
+			require.NotNil(t, status, "Status should be reported while playing")
+
+			if status.State != "FADING" {
+				time.Sleep(statusPollInterval)
+				continue
+			}
+			fadingSamples++
+
+			require.NotNil(t, status.FadeProgress, "fadeProgress is required while FADING")
+			progress := *status.FadeProgress
+			assert.GreaterOrEqual(t, progress, lastProgress, "fadeProgress must not go backwards")
+			assert.InDelta(t, elapsed/statusFadeTime*100, progress, tolerance,
+				"fadeProgress should track elapsed time (%.2fs)", elapsed)
+			if !skipDMXTests() {
+				assert.InDelta(t, float64(level)/255*100, progress, tolerance,
+					"fadeProgress should track the DMX level (%d)", level)
+			}
+			assert.Equal(t, cueIDs[0], cueID(status.CurrentCue), "currentCue is the cue being faded in")
+			lastProgress = progress
+
+			time.Sleep(statusPollInterval)
+		}
+
+		assert.GreaterOrEqual(t, fadingSamples, 3, "A %.0fs fade should be observed as FADING several times", statusFadeTime)
+	})
+
+	time.Sleep(time.Until(start.Add(time.Duration((statusFadeTime + 0.5) * float64(time.Second)))))
+
+	t.Run("HoldingAfterFade", func(t *testing.T) {
+		status := s.playbackStatus(t, cueListID)
+		require.NotNil(t, status)
+		assert.Equal(t, "HOLDING", status.State)
+		if status.FadeProgress != nil {
+			assert.InDelta(t, 100, *status.FadeProgress, 0.01, "A finished fade reports 100 or null")
+		}
+		assert.Equal(t, cueIDs[0], cueID(status.CurrentCue))
+		assert.Equal(t, cueIDs[1], cueID(status.NextCue))
+		if !skipDMXTests() {
+			assert.InDelta(t, 255, s.channelOne(t), 3)
+		}
+	})
+
+	t.Run("LastCueHasNoNext", func(t *testing.T) {
+		s.control(t, "nextCue", cueListID)
+		time.Sleep(time.Duration((statusFadeTime + 0.5) * float64(time.Second)))
+
+		status := s.playbackStatus(t, cueListID)
+		require.NotNil(t, status)
+		assert.Equal(t, "HOLDING", status.State)
+		assert.Equal(t, cueIDs[1], cueID(status.CurrentCue))
+		assert.Empty(t, cueID(status.NextCue), "The last cue has no next cue")
+	})
+
+	t.Run("IdleAfterStop", func(t *testing.T) {
+		s.control(t, "stopCueList", cueListID)
+		time.Sleep(cueTransitionSettleTime)
+
+		status := s.playbackStatus(t, cueListID)
+		if status != nil {
+			assert.Equal(t, "IDLE", status.State)
+		}
+	})
+}
+
+// TestCueListPlaybackStatusSubscription subscribes to status changes and
+// starts the cue list. The pushed statuses must move from FADING to HOLDING
+// with fadeProgress never going backwards, so a progress bar driven by the
+// subscription alone ends full.
+func TestCueListPlaybackStatusSubscription(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+	defer cancel()
+
+	client := graphql.NewClient("")
+	compat.RequireSubscription(t, client, "cueListPlaybackStatusChanged", playbackStatusContract)
+
+	s := newConcurrentSetup(t, client, ctx, 1)
+	defer cleanupPlaybackTest(client, ctx, s.projectID)
+
+	cueListID, cueIDs := s.createStatusCueList(t,
+		s.createLook(t, "Status Full", map[int]int{1: 255}),
+		s.createLook(t, "Status Half", map[int]int{1: 128}))
+
+	ws := websocket.NewClient("")
+	if err := ws.Connect(ctx); err != nil {
+		t.Skipf("Skipping: cannot open WebSocket connection: %v", err)
+	}
+	defer func() { _ = ws.Close() }()
+
+	ch, _, err := ws.Subscribe(ctx, `
+		subscription CueListPlaybackStatusChanged($cueListId: ID!) {
+			cueListPlaybackStatusChanged(cueListId: $cueListId) {`+playbackStatusFields+`}
+		}
+	`, map[string]interface{}{"cueListId": cueListID})
+	require.NoError(t, err)
+
+	s.control(t, "startCueList", cueListID)
+
+	var statuses []playbackStatus
+	timeout := time.After(time.Duration((statusFadeTime + 1.5) * float64(time.Second)))
+collect:
+	for {
+		select {
+		case <-timeout:
+			break collect
+		case msg, ok := <-ch:
+			require.True(t, ok, "Subscription closed unexpectedly")
+			require.NotEqual(t, websocket.Error, msg.Type, "Subscription error: %s", string(msg.Payload))
+			if msg.Type != websocket.Next {
+				continue
+			}
+			var payload struct {
+				Data struct {
+					CueListPlaybackStatusChanged playbackStatus `json:"cueListPlaybackStatusChanged"`
+				} `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(msg.Payload, &payload))
+			statuses = append(statuses, payload.Data.CueListPlaybackStatusChanged)
+		}
+	}
+
+	require.NotEmpty(t, statuses, "Starting the cue list should push status updates")
+	t.Logf("Received %d status updates", len(statuses))
+
+	lastProgress := -1.0
+	sawFading := false
+	for i, status := range statuses {
+		switch status.State {
+		case "FADING":
+			sawFading = true
+			if status.FadeProgress != nil {
+				assert.GreaterOrEqual(t, *status.FadeProgress, lastProgress, "update %d: fadeProgress went backwards", i)
+				lastProgress = *status.FadeProgress
+			}
+		case "HOLDING":
+			assert.True(t, sawFading || i == 0, "update %d: HOLDING before any FADING update", i)
+		}
+	}
+	assert.True(t, sawFading, "A %.0fs fade should push at least one FADING update", statusFadeTime)
+
+	final := statuses[len(statuses)-1]
+	assert.Equal(t, "HOLDING", final.State, "The last update should report the fade finished")
+	if final.FadeProgress != nil {
+		assert.False(t, math.Abs(*final.FadeProgress-100) > 0.01, "A finished fade reports 100 or null")
+	}
+	assert.Equal(t, cueIDs[0], cueID(final.CurrentCue))
+	assert.Equal(t, cueIDs[1], cueID(final.NextCue))
+}
@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	_ "github.com/bbernstein/lacylights-test/pkg/artifacts"
 	"github.com/bbernstein/lacylights-test/pkg/graphql"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -132,98 +133,84 @@ func setupPlaybackTest(t *testing.T, client *graphql.Client, ctx context.Context
 	require.NoError(t, err)
 	fixtureID := fixtureResp.CreateFixtureInstance.ID
 
-	// Create two looks with different values
-	var look1Resp struct {
+	// Create two looks with different values, and the cue list, in one request
+	type lookResp struct {
 		CreateLook struct {
 			ID string `json:"id"`
 		} `json:"createLook"`
 	}
+	var look1Resp, look2Resp lookResp
+	var cueListResp struct {
+		CreateCueList struct {
+			ID string `json:"id"`
+		} `json:"createCueList"`
+	}
 
-	err = client.Mutate(ctx, `
+	createLook := `
 		mutation CreateLook($input: CreateLookInput!) {
 			createLook(input: $input) { id }
 		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"projectId": projectID,
-			"name":      "Full Bright",
-			"fixtureValues": []map[string]interface{}{
-				{
-					"fixtureId": fixtureID,
-					"channels":  []map[string]int{{"offset": 0, "value": 255}},
+	`
+	lookInput := func(name string, value int) map[string]interface{} {
+		return map[string]interface{}{
+			"input": map[string]interface{}{
+				"projectId": projectID,
+				"name":      name,
+				"fixtureValues": []map[string]interface{}{
+					{
+						"fixtureId": fixtureID,
+						"channels":  []map[string]int{{"offset": 0, "value": value}},
+					},
 				},
 			},
-		},
-	}, &look1Resp)
-
-	require.NoError(t, err)
-	look1ID = look1Resp.CreateLook.ID
-
-	var look2Resp struct {
-		CreateLook struct {
-			ID string `json:"id"`
-		} `json:"createLook"`
-	}
-
-	err = client.Mutate(ctx, `
-		mutation CreateLook($input: CreateLookInput!) {
-			createLook(input: $input) { id }
 		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"projectId": projectID,
-			"name":      "Half Bright",
-			"fixtureValues": []map[string]interface{}{
-				{
-					"fixtureId": fixtureID,
-					"channels":  []map[string]int{{"offset": 0, "value": 128}},
+	}
+	err = client.QueryBatch(ctx, []graphql.BatchOperation{
+		{Query: createLook, Variables: lookInput("Full Bright", 255), Result: &look1Resp},
+		{Query: createLook, Variables: lookInput("Half Bright", 128), Result: &look2Resp},
+		{
+			Query: `
+				mutation CreateCueList($input: CreateCueListInput!) {
+					createCueList(input: $input) { id }
+				}
+			`,
+			Variables: map[string]interface{}{
+				"input": map[string]interface{}{
+					"projectId": projectID,
+					"name":      "Playback Test List",
 				},
 			},
+			Result: &cueListResp,
 		},
-	}, &look2Resp)
+	})
 
 	require.NoError(t, err)
+	look1ID = look1Resp.CreateLook.ID
 	look2ID = look2Resp.CreateLook.ID
-
-	// Create cue list with cues
-	var cueListResp struct {
-		CreateCueList struct {
-			ID string `json:"id"`
-		} `json:"createCueList"`
-	}
-
-	err = client.Mutate(ctx, `
-		mutation CreateCueList($input: CreateCueListInput!) {
-			createCueList(input: $input) { id }
-		}
-	`, map[string]interface{}{
-		"input": map[string]interface{}{
-			"projectId": projectID,
-			"name":      "Playback Test List",
-		},
-	}, &cueListResp)
-
-	require.NoError(t, err)
 	cueListID = cueListResp.CreateCueList.ID
 
-	// Add cues
+	// Add cues; mutations in one request run in order, so cue A is created first
+	var cues []graphql.BatchOperation
 	for i, lookID := range []string{look1ID, look2ID} {
-		err = client.Mutate(ctx, `
-			mutation CreateCue($input: CreateCueInput!) {
-				createCue(input: $input) { id }
-			}
-		`, map[string]interface{}{
-			"input": map[string]interface{}{
-				"cueListId":   cueListID,
-				"lookId":     lookID,
-				"name":        "Cue " + string(rune('A'+i)),
-				"cueNumber":   float64(i + 1),
-				"fadeInTime":  1.0,
-				"fadeOutTime": 1.0,
+		cues = append(cues, graphql.BatchOperation{
+			Query: `
+				mutation CreateCue($input: CreateCueInput!) {
+					createCue(input: $input) { id }
+				}
+			`,
+			Variables: map[string]interface{}{
+				"input": map[string]interface{}{
+					"cueListId":   cueListID,
+					"lookId":      lookID,
+					"name":        "Cue " + string(rune('A'+i)),
+					"cueNumber":   float64(i + 1),
+					"fadeInTime":  1.0,
+					"fadeOutTime": 1.0,
+				},
 			},
-		}, nil)
-		require.NoError(t, err)
+		})
 	}
+	require.NoError(t, client.QueryBatch(ctx, cues))
 
 	return projectID, cueListID, look1ID, look2ID
 }
@@ -0,0 +1,156 @@
+package playback
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/require"
+)
+
+// submasterContract is the inhibitive submaster shape this suite expects.
+const submasterContract = `createSubmaster(input: CreateSubmasterInput!): Submaster!
+  CreateSubmasterInput { projectId: ID!, name: String!, mode: INHIBITIVE, fixtureIds: [ID!]! }
+  setSubmasterLevel(id: ID!, level: Float!): Boolean! (level 0-100, inhibitive output = playback * level / 100)
+  deleteSubmaster(id: ID!): Boolean!`
+
+// submasterLevels are checked in order, ending back at full.
+var submasterLevels = []float64{75, 50, 25, 0, 100}
+
+// createInhibitiveSubmaster creates an inhibitive submaster over the fixtures
+// at the given 1-based channels and returns its id.
+func (s *concurrentSetup) createInhibitiveSubmaster(t *testing.T, channels ...int) string {
+	fixtureIDs := make([]string, 0, len(channels))
+	for _, channel := range channels {
+		fixtureIDs = append(fixtureIDs, s.fixtureIDs[channel-1])
+	}
+
+	var resp struct {
+		CreateSubmaster struct {
+			ID string `json:"id"`
+		} `json:"createSubmaster"`
+	}
+	err := s.client.Mutate(s.ctx, `
+		mutation CreateSubmaster($input: CreateSubmasterInput!) {
+			createSubmaster(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId":  s.projectID,
+			"name":       "Inhibit Sub",
+			"mode":       "INHIBITIVE",
+			"fixtureIds": fixtureIDs,
+		},
+	}, &resp)
+	require.NoError(t, err)
+	id := resp.CreateSubmaster.ID
+
+	t.Cleanup(func() {
+		cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cleanupCancel()
+		_ = s.client.Mutate(cleanupCtx, `mutation DeleteSubmaster($id: ID!) { deleteSubmaster(id: $id) }`,
+			map[string]interface{}{"id": id}, nil)
+	})
+	return id
+}
+
+func (s *concurrentSetup) setSubmasterLevel(t *testing.T, id string, level float64) {
+	var resp struct {
+		SetSubmasterLevel bool `json:"setSubmasterLevel"`
+	}
+	err := s.client.Mutate(s.ctx, `
+		mutation SetSubmasterLevel($id: ID!, $level: Float!) {
+			setSubmasterLevel(id: $id, level: $level)
+		}
+	`, map[string]interface{}{"id": id, "level": level}, &resp)
+	require.NoError(t, err)
+	require.True(t, resp.SetSubmasterLevel, "setSubmasterLevel(%.0f) should succeed", level)
+	time.Sleep(cueTransitionSettleTime)
+}
+
+// inhibited scales the playback values of the inhibited channels by level.
+func inhibited(playback map[int]int, level float64, channels ...int) map[int]int {
+	want := make(map[int]int, len(playback))
+	for channel, value := range playback {
+		want[channel] = value
+	}
+	for _, channel := range channels {
+		want[channel] = int(float64(playback[channel])*level/100 + 0.5)
+	}
+	return want
+}
+
+// TestInhibitiveSubmasterScalesLook caps two of three fixtures with an
+// inhibitive submaster while a look is live. At every level the capped
+// fixtures must scale proportionally and the third must be untouched.
+func TestInhibitiveSubmasterScalesLook(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewClient("")
+	compat.RequireMutation(t, client, "createSubmaster", submasterContract)
+
+	s := newConcurrentSetup(t, client, ctx, 3)
+	defer cleanupPlaybackTest(client, ctx, s.projectID)
+
+	playback := map[int]int{1: 200, 2: 100, 3: 200}
+	lookID := s.createLook(t, "Submaster Look", playback)
+	subID := s.createInhibitiveSubmaster(t, 1, 2)
+
+	err := client.Mutate(ctx, `mutation SetLookLive($lookId: ID!) { setLookLive(lookId: $lookId) }`,
+		map[string]interface{}{"lookId": lookID}, nil)
+	require.NoError(t, err)
+	time.Sleep(cueTransitionSettleTime)
+	s.expectChannels(t, playback, "Submaster at full")
+
+	for _, level := range submasterLevels {
+		t.Run(fmt.Sprintf("Level%.0f", level), func(t *testing.T) {
+			s.setSubmasterLevel(t, subID, level)
+			s.expectChannels(t, inhibited(playback, level, 1, 2), fmt.Sprintf("Submaster at %.0f%%", level))
+		})
+	}
+}
+
+// TestInhibitiveSubmasterDuringCueList holds the submaster at a partial level
+// while a cue list runs. Each cue's values must come out scaled, so going to
+// the next cue cannot escape the cap.
+func TestInhibitiveSubmasterDuringCueList(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewClient("")
+	compat.RequireMutation(t, client, "createSubmaster", submasterContract)
+
+	s := newConcurrentSetup(t, client, ctx, 2)
+	defer cleanupPlaybackTest(client, ctx, s.projectID)
+
+	cues := []map[int]int{
+		{1: 255, 2: 120},
+		{1: 80, 2: 240},
+	}
+	cueListID := s.createCueList(t, "Submaster",
+		s.createLook(t, "Submaster Cue 1", cues[0]),
+		s.createLook(t, "Submaster Cue 2", cues[1]))
+	subID := s.createInhibitiveSubmaster(t, 1)
+
+	const level = 40.0
+	s.setSubmasterLevel(t, subID, level)
+
+	s.control(t, "startCueList", cueListID)
+	time.Sleep(concurrentSettleTime)
+	s.requireCueIndex(t, cueListID, 0)
+	s.expectChannels(t, inhibited(cues[0], level, 1), "First cue under the submaster")
+
+	s.control(t, "nextCue", cueListID)
+	time.Sleep(concurrentSettleTime)
+	s.requireCueIndex(t, cueListID, 1)
+	s.expectChannels(t, inhibited(cues[1], level, 1), "Second cue under the submaster")
+
+	t.Run("LevelChangeMidList", func(t *testing.T) {
+		s.setSubmasterLevel(t, subID, 100)
+		s.expectChannels(t, cues[1], "Submaster back at full")
+	})
+}
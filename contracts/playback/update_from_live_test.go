@@ -0,0 +1,214 @@
+package playback
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// updateCueFromLiveContract is the "record into cue" workflow these tests expect.
+const updateCueFromLiveContract = `updateCueFromLive(cueId: ID!): Cue!
+  stores the current DMX output of the patched fixtures into the cue's look, including values set
+  with setChannelValue; fixtures outside the look are either added or left out, and undo restores
+  the look's previous values`
+
+// lookChannels reads a look's values keyed by 1-based DMX channel, which for
+// a concurrentSetup is the fixture's position.
+func (s *concurrentSetup) lookChannels(t *testing.T, lookID string) map[int]int {
+	var resp struct {
+		Look struct {
+			FixtureValues []struct {
+				Fixture struct {
+					ID string `json:"id"`
+				} `json:"fixture"`
+				Channels []struct {
+					Offset int `json:"offset"`
+					Value  int `json:"value"`
+				} `json:"channels"`
+			} `json:"fixtureValues"`
+		} `json:"look"`
+	}
+	err := s.client.Query(s.ctx, `
+		query GetLook($id: ID!) {
+			look(id: $id) {
+				fixtureValues {
+					fixture { id }
+					channels { offset value }
+				}
+			}
+		}
+	`, map[string]interface{}{"id": lookID}, &resp)
+	require.NoError(t, err)
+
+	values := map[int]int{}
+	for _, fv := range resp.Look.FixtureValues {
+		for i, id := range s.fixtureIDs {
+			if id == fv.Fixture.ID && len(fv.Channels) > 0 {
+				values[i+1] = fv.Channels[0].Value
+			}
+		}
+	}
+	return values
+}
+
+// TestUpdateCueFromLive plays cue 3, adjusts the live output by hand and
+// records it back into the cue. Replaying the cue list must reproduce the
+// recorded state at cue 3 without touching the other cues, and undo must
+// put the look back. See updateCueFromLiveContract.
+func TestUpdateCueFromLive(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+	defer cancel()
+
+	client := graphql.NewTestClient(t, "")
+	compat.RequireMutation(t, client, "updateCueFromLive", updateCueFromLiveContract)
+
+	s := newConcurrentSetup(t, client, ctx, 3)
+	defer cleanupPlaybackTest(client, ctx, s.projectID)
+
+	cue3Look := s.createLook(t, "Cue 3 Look", map[int]int{1: 150, 2: 150})
+	cueListID := s.createCueList(t, "Update From Live",
+		s.createLook(t, "Cue 1 Look", map[int]int{1: 50, 2: 50}),
+		s.createLook(t, "Cue 2 Look", map[int]int{1: 100, 2: 100}),
+		cue3Look,
+		s.createLook(t, "Cue 4 Look", map[int]int{1: 200, 2: 200}),
+	)
+	defer func() {
+		_ = client.Mutate(ctx, `mutation StopCueList($cueListId: ID!) { stopCueList(cueListId: $cueListId) }`,
+			map[string]interface{}{"cueListId": cueListID}, nil)
+		s.clearManual(3)
+	}()
+
+	var listResp struct {
+		CueList struct {
+			Cues []struct {
+				ID        string  `json:"id"`
+				CueNumber float64 `json:"cueNumber"`
+			} `json:"cues"`
+		} `json:"cueList"`
+	}
+	err := client.Query(ctx, `query GetCueList($id: ID!) { cueList(id: $id) { cues { id cueNumber } } }`,
+		map[string]interface{}{"id": cueListID}, &listResp)
+	require.NoError(t, err)
+	var cue3ID string
+	for _, cue := range listResp.CueList.Cues {
+		if cue.CueNumber == 3 {
+			cue3ID = cue.ID
+		}
+	}
+	require.NotEmpty(t, cue3ID, "Cue 3 should exist")
+
+	s.control(t, "startCueList", cueListID)
+	s.goToCue(t, cueListID, 2)
+	s.expectChannels(t, map[int]int{1: 150, 2: 150, 3: 0}, "Cue 3 live")
+
+	// Trim the look by hand, including a fixture the look does not hold
+	for channel, value := range map[int]int{1: 40, 2: 220, 3: 90} {
+		s.setChannel(t, channel, value)
+	}
+	time.Sleep(300 * time.Millisecond)
+	s.expectChannels(t, map[int]int{1: 40, 2: 220, 3: 90}, "Manual values live")
+
+	var resp struct {
+		UpdateCueFromLive struct {
+			ID   string `json:"id"`
+			Look struct {
+				ID string `json:"id"`
+			} `json:"look"`
+		} `json:"updateCueFromLive"`
+	}
+	err = client.Mutate(ctx, `
+		mutation UpdateCueFromLive($cueId: ID!) {
+			updateCueFromLive(cueId: $cueId) { id look { id } }
+		}
+	`, map[string]interface{}{"cueId": cue3ID}, &resp)
+	require.NoError(t, err)
+	assert.Equal(t, cue3ID, resp.UpdateCueFromLive.ID)
+	assert.Equal(t, cue3Look, resp.UpdateCueFromLive.Look.ID, "The cue should keep its look and record into it")
+
+	recorded := map[int]int{1: 40, 2: 220, 3: 0}
+	t.Run("LookRecorded", func(t *testing.T) {
+		values := s.lookChannels(t, cue3Look)
+		assert.Equal(t, 40, values[1])
+		assert.Equal(t, 220, values[2])
+		if v, ok := values[3]; ok {
+			assert.Equal(t, 90, v, "An added fixture should be recorded at its live value")
+			recorded[3] = 90
+			t.Log("Contract: recording adds live fixtures outside the look")
+		} else {
+			t.Log("Contract: recording keeps to the fixtures already in the look")
+		}
+	})
+
+	t.Run("Replay", func(t *testing.T) {
+		s.control(t, "stopCueList", cueListID)
+		s.clearManual(3)
+
+		s.control(t, "startCueList", cueListID)
+		time.Sleep(concurrentSettleTime)
+		s.expectChannels(t, map[int]int{1: 50, 2: 50, 3: 0}, "Cue 1 should be unchanged")
+		s.goToCue(t, cueListID, 1)
+		s.expectChannels(t, map[int]int{1: 100, 2: 100, 3: 0}, "Cue 2 should be unchanged")
+		s.goToCue(t, cueListID, 2)
+		s.expectChannels(t, recorded, "Cue 3 should reproduce the recorded state")
+		s.goToCue(t, cueListID, 3)
+		s.expectChannels(t, map[int]int{1: 200, 2: 200, 3: 0}, "Cue 4 should be unchanged")
+	})
+
+	t.Run("Undo", func(t *testing.T) {
+		var undoResp struct {
+			Undo struct {
+				Success bool `json:"success"`
+			} `json:"undo"`
+		}
+		err := client.Mutate(ctx, `mutation Undo($projectId: ID!) { undo(projectId: $projectId) { success } }`,
+			map[string]interface{}{"projectId": s.projectID}, &undoResp)
+		require.NoError(t, err)
+		require.True(t, undoResp.Undo.Success, "Undo should revert the recording")
+
+		values := s.lookChannels(t, cue3Look)
+		assert.Equal(t, 150, values[1], "Undo should restore the look")
+		assert.Equal(t, 150, values[2], "Undo should restore the look")
+		assert.NotContains(t, values, 3, "Undo should remove a fixture the recording added")
+
+		s.goToCue(t, cueListID, 0)
+		s.goToCue(t, cueListID, 2)
+		s.expectChannels(t, map[int]int{1: 150, 2: 150, 3: 0}, "Cue 3 should play its restored look")
+	})
+}
+
+// goToCue jumps the cue list to a cue by index and waits for its fade.
+func (s *concurrentSetup) goToCue(t *testing.T, cueListID string, index int) {
+	err := s.client.Mutate(s.ctx, `
+		mutation GoToCue($cueListId: ID!, $cueIndex: Int!) {
+			goToCue(cueListId: $cueListId, cueIndex: $cueIndex)
+		}
+	`, map[string]interface{}{"cueListId": cueListID, "cueIndex": index}, nil)
+	require.NoError(t, err)
+	time.Sleep(concurrentSettleTime)
+}
+
+// setChannel sets a 1-based channel of universe 1 by hand.
+func (s *concurrentSetup) setChannel(t *testing.T, channel, value int) {
+	err := s.client.Mutate(s.ctx, `
+		mutation SetChannel($channel: Int!, $value: Int!) {
+			setChannelValue(universe: 1, channel: $channel, value: $value)
+		}
+	`, map[string]interface{}{"channel": channel, "value": value}, nil)
+	require.NoError(t, err)
+}
+
+// clearManual zeroes channels 1..n and fades to black, which clears values
+// set by hand as resetDMXState does for the fade suite.
+func (s *concurrentSetup) clearManual(n int) {
+	for channel := 1; channel <= n; channel++ {
+		_ = s.client.Mutate(s.ctx, `mutation SetChannel($channel: Int!) { setChannelValue(universe: 1, channel: $channel, value: 0) }`,
+			map[string]interface{}{"channel": channel}, nil)
+	}
+	_ = s.client.Mutate(s.ctx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+	time.Sleep(200 * time.Millisecond)
+}
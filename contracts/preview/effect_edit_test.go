@@ -0,0 +1,139 @@
+package preview
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/fixtures"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// previewEffectContract is blind editing of a running effect
+	previewEffectContract = `updatePreviewEffect(sessionId: ID!, effectId: ID!, input: UpdateEffectInput!): Effect!
+  stages the edit in the session: the session's dmxOutput runs the effect with the edited parameters
+  while live output and effect(id) keep the saved ones; commitPreviewSession saves the edit and live output follows it,
+  cancelPreviewSession discards it`
+
+	// The effect runs at editFrequency and is sampled editSamples times,
+	// editInterval apart, covering a full cycle
+	editFrequency = 0.5
+	editSamples   = 20
+	editInterval  = 100 * time.Millisecond
+
+	// The saved effect swings the dimmer through at least editMinSwing
+	editMinSwing = 100
+
+	// editedAmplitude and editedOffset, in percent, keep the dimmer between
+	// editedLow and editedHigh whether amplitude is read as peak or peak-to-peak
+	editedAmplitude = 10.0
+	editedOffset    = 20.0
+	editedLow       = 22
+	editedHigh      = 80
+)
+
+// dimmerSpan samples read over a full effect cycle and returns the lowest and
+// highest values seen.
+func dimmerSpan(read func() int) (int, int) {
+	low, high := 255, 0
+	for i := 0; i < editSamples; i++ {
+		v := read()
+		low, high = min(low, v), max(high, v)
+		time.Sleep(editInterval)
+	}
+	return low, high
+}
+
+type effectParams struct {
+	Amplitude float64 `json:"amplitude"`
+	Offset    float64 `json:"offset"`
+}
+
+func readEffectParams(t *testing.T, ctx context.Context, client *graphql.Client, effectID string) effectParams {
+	var resp struct {
+		Effect effectParams `json:"effect"`
+	}
+	require.NoError(t, client.Query(ctx, `query GetEffect($id: ID!) { effect(id: $id) { amplitude offset } }`,
+		map[string]interface{}{"id": effectID}, &resp))
+	return resp.Effect
+}
+
+// TestPreviewEffectEdit runs a wide sine on the par's dimmer, narrows it in a
+// preview session, and checks the edit stays blind until the session ends:
+// discarded on cancel, and live on commit.
+func TestPreviewEffectEdit(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+	defer cancel()
+
+	skipIfNoPreview(t)
+	client := graphql.NewClient("")
+	compat.RequireMutation(t, client, "updatePreviewEffect", previewEffectContract)
+	s := newParSetup(t, ctx, client, "Preview Effect")
+
+	effectID, err := fixtures.CreateWaveformEffect(ctx, client, s.projectID, "Preview Effect Sine", "SINE", editFrequency, []string{s.fixtureID})
+	require.NoError(t, err)
+	require.NoError(t, client.Mutate(ctx, `mutation Activate($id: ID!) { activateEffect(effectId: $id, fadeTime: 0) }`,
+		map[string]interface{}{"id": effectID}, nil))
+	defer func() {
+		_ = client.Mutate(ctx, `mutation Stop($id: ID!) { stopEffect(effectId: $id, fadeTime: 0) }`,
+			map[string]interface{}{"id": effectID}, nil)
+	}()
+	saved := readEffectParams(t, ctx, client, effectID)
+
+	live := func() int { return s.liveDimmer(t, ctx) }
+	expectSaved := func(t *testing.T, when string) {
+		low, high := dimmerSpan(live)
+		assert.GreaterOrEqual(t, high-low, editMinSwing, "%s: live output should still run the saved effect (saw %d-%d)", when, low, high)
+		assert.Equal(t, saved, readEffectParams(t, ctx, client, effectID), "%s: the saved effect should be unchanged", when)
+	}
+	expectEdited := func(t *testing.T, when string, read func() int) {
+		low, high := dimmerSpan(read)
+		assert.GreaterOrEqual(t, low, editedLow, "%s: output should follow the edited effect (saw %d-%d)", when, low, high)
+		assert.LessOrEqual(t, high, editedHigh, "%s: output should follow the edited effect (saw %d-%d)", when, low, high)
+	}
+	edit := func(t *testing.T, sessionID string) {
+		var resp struct {
+			UpdatePreviewEffect effectParams `json:"updatePreviewEffect"`
+		}
+		err := client.Mutate(ctx, `
+			mutation UpdatePreviewEffect($sessionId: ID!, $effectId: ID!, $input: UpdateEffectInput!) {
+				updatePreviewEffect(sessionId: $sessionId, effectId: $effectId, input: $input) { amplitude offset }
+			}
+		`, map[string]interface{}{
+			"sessionId": sessionID,
+			"effectId":  effectID,
+			"input":     map[string]interface{}{"amplitude": editedAmplitude, "offset": editedOffset},
+		}, &resp)
+		require.NoError(t, err)
+		assert.Equal(t, effectParams{Amplitude: editedAmplitude, Offset: editedOffset}, resp.UpdatePreviewEffect,
+			"The mutation should return the edited parameters")
+	}
+
+	expectSaved(t, "Before editing")
+
+	t.Run("Cancel", func(t *testing.T) {
+		sessionID := s.start(t, ctx)
+		edit(t, sessionID)
+		expectEdited(t, "In the session", func() int { return s.polledDimmer(t, ctx, sessionID) })
+		expectSaved(t, "While editing")
+
+		s.cancelSession(ctx, sessionID)
+		expectSaved(t, "After cancelling")
+	})
+
+	t.Run("Commit", func(t *testing.T) {
+		sessionID := s.start(t, ctx)
+		edit(t, sessionID)
+		expectSaved(t, "While editing")
+
+		require.NoError(t, client.Mutate(ctx, `mutation CommitPreview($sessionId: ID!) { commitPreviewSession(sessionId: $sessionId) }`,
+			map[string]interface{}{"sessionId": sessionID}, nil))
+		assert.Equal(t, effectParams{Amplitude: editedAmplitude, Offset: editedOffset}, readEffectParams(t, ctx, client, effectID),
+			"Committing should save the edit")
+		expectEdited(t, "After committing", live)
+	})
+}
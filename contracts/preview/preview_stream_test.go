@@ -0,0 +1,260 @@
+package preview
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/bbernstein/lacylights-test/pkg/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// previewStreamContract is the subscription front-ends render a preview from
+	previewStreamContract = `subscription previewOutputChanged(sessionId: ID!): PreviewOutput!
+  PreviewOutput { sessionId: ID!, universe: Int!, channels: [Int!]! }
+  pushes a universe when the session's output changes, never faster than the output refresh rate,
+  always ending on the current output; completes when the session is committed or cancelled`
+
+	// defaultOutputRate is the refresh rate, in Hz, when fade_update_rate_hz is not set
+	defaultOutputRate = 44.0
+
+	// streamTimeout bounds the wait for a streamed update
+	streamTimeout = time.Second
+
+	previewStreamSubscription = `
+		subscription PreviewOutputChanged($sessionId: ID!) {
+			previewOutputChanged(sessionId: $sessionId) { sessionId universe channels }
+		}
+	`
+)
+
+type previewOutput struct {
+	SessionID string `json:"sessionId"`
+	Universe  int    `json:"universe"`
+	Channels  []int  `json:"channels"`
+}
+
+// previewStream is a previewOutputChanged subscription on one session.
+type previewStream struct {
+	sessionID string
+	ch        <-chan *websocket.Message
+	completed bool
+}
+
+func (s *parSetup) stream(t *testing.T, ctx context.Context, sessionID string) *previewStream {
+	ws := websocket.NewClient("")
+	if err := ws.Connect(ctx); err != nil {
+		t.Skipf("Skipping: cannot open WebSocket connection: %v", err)
+	}
+	t.Cleanup(func() { _ = ws.Close() })
+
+	ch, _, err := ws.Subscribe(ctx, previewStreamSubscription, map[string]interface{}{"sessionId": sessionID})
+	require.NoError(t, err)
+	return &previewStream{sessionID: sessionID, ch: ch}
+}
+
+// next returns the next streamed output, or false when nothing arrives within
+// timeout or the subscription completes, which also sets completed.
+func (p *previewStream) next(t *testing.T, timeout time.Duration) (previewOutput, bool) {
+	deadline := time.After(timeout)
+	for {
+		select {
+		case <-deadline:
+			return previewOutput{}, false
+		case msg, ok := <-p.ch:
+			if !ok || msg.Type == websocket.Complete {
+				p.completed = true
+				return previewOutput{}, false
+			}
+			require.NotEqual(t, websocket.Error, msg.Type, "Subscription error: %s", string(msg.Payload))
+			if msg.Type != websocket.Next {
+				continue
+			}
+			var payload struct {
+				Data struct {
+					PreviewOutputChanged previewOutput `json:"previewOutputChanged"`
+				} `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(msg.Payload, &payload))
+			return payload.Data.PreviewOutputChanged, true
+		}
+	}
+}
+
+// waitForDimmer reads the stream until universe 1 channel 1 is want,
+// returning how many updates it took.
+func (p *previewStream) waitForDimmer(t *testing.T, want int) int {
+	t.Helper()
+	for n := 1; ; n++ {
+		out, ok := p.next(t, streamTimeout)
+		require.True(t, ok, "No streamed update with channel 1 at %d within %v", want, streamTimeout)
+		assert.Equal(t, p.sessionID, out.SessionID, "Updates should be for the subscribed session")
+		if out.Universe == 1 && len(out.Channels) > 0 && out.Channels[0] == want {
+			return n
+		}
+	}
+}
+
+// polledDimmer reads universe 1 channel 1 of the session by query.
+func (s *parSetup) polledDimmer(t *testing.T, ctx context.Context, sessionID string) int {
+	var resp struct {
+		PreviewSession struct {
+			DMXOutput []struct {
+				Universe int   `json:"universe"`
+				Channels []int `json:"channels"`
+			} `json:"dmxOutput"`
+		} `json:"previewSession"`
+	}
+	err := s.client.Query(ctx, `
+		query GetPreview($sessionId: ID!) {
+			previewSession(sessionId: $sessionId) { dmxOutput { universe channels } }
+		}
+	`, map[string]interface{}{"sessionId": sessionID}, &resp)
+	require.NoError(t, err)
+	for _, out := range resp.PreviewSession.DMXOutput {
+		if out.Universe == 1 {
+			require.NotEmpty(t, out.Channels)
+			return out.Channels[0]
+		}
+	}
+	require.Fail(t, "The preview session has no universe 1 output")
+	return 0
+}
+
+func (s *parSetup) cancelSession(ctx context.Context, sessionID string) {
+	_ = s.client.Mutate(ctx, `mutation CancelPreview($sessionId: ID!) { cancelPreviewSession(sessionId: $sessionId) }`,
+		map[string]interface{}{"sessionId": sessionID}, nil)
+}
+
+// outputRate reads fade_update_rate_hz, falling back to defaultOutputRate.
+func outputRate(ctx context.Context, client *graphql.Client) float64 {
+	var resp struct {
+		Setting *struct {
+			Value string `json:"value"`
+		} `json:"setting"`
+	}
+	err := client.Query(ctx, `query GetSetting($key: String!) { setting(key: $key) { value } }`,
+		map[string]interface{}{"key": "fade_update_rate_hz"}, &resp)
+	if err != nil || resp.Setting == nil {
+		return defaultOutputRate
+	}
+	rate, err := strconv.ParseFloat(resp.Setting.Value, 64)
+	if err != nil || rate <= 0 {
+		return defaultOutputRate
+	}
+	return rate
+}
+
+func newStreamSetup(t *testing.T, ctx context.Context) *parSetup {
+	skipIfNoPreview(t)
+	client := graphql.NewClient("")
+	compat.RequireSubscription(t, client, "previewOutputChanged", previewStreamContract)
+	return newParSetup(t, ctx, client, "Preview Stream")
+}
+
+// TestPreviewStreamMatchesPolling steps the par's dimmer through a preview
+// session and checks each value arrives on the stream, for the session and
+// universe subscribed, and agrees with previewSession's polled output.
+func TestPreviewStreamMatchesPolling(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	s := newStreamSetup(t, ctx)
+
+	sessionID := s.start(t, ctx)
+	defer s.cancelSession(ctx, sessionID)
+	stream := s.stream(t, ctx, sessionID)
+
+	for _, value := range []int{50, 120, 255, 0, 77} {
+		ok, err := s.setDimmer(ctx, sessionID, value)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		stream.waitForDimmer(t, value)
+		assert.Equal(t, value, s.polledDimmer(t, ctx, sessionID), "Polled output should match the streamed value")
+	}
+
+	t.Run("QuietWhenUnchanged", func(t *testing.T) {
+		// Nothing changes after the last step, so nothing more is pushed
+		out, ok := stream.next(t, 500*time.Millisecond)
+		assert.False(t, ok, "Unchanged output should not be streamed, got %+v", out)
+	})
+}
+
+// TestPreviewStreamRate sends dimmer updates much faster than the output
+// refresh rate. The stream may coalesce them but must not push faster than the
+// refresh rate, and must end on the final value.
+func TestPreviewStreamRate(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	s := newStreamSetup(t, ctx)
+	rate := outputRate(ctx, s.client)
+
+	sessionID := s.start(t, ctx)
+	defer s.cancelSession(ctx, sessionID)
+	stream := s.stream(t, ctx, sessionID)
+
+	const updates = 100
+	started := time.Now()
+	for i := 1; i <= updates; i++ {
+		_, err := s.setDimmer(ctx, sessionID, i)
+		require.NoError(t, err)
+	}
+	received := stream.waitForDimmer(t, updates)
+	elapsed := time.Since(started)
+
+	// One update per refresh, plus one for the refresh in flight at either end
+	limit := int(elapsed.Seconds()*rate) + 2
+	t.Logf("%d updates in %v streamed as %d pushes (limit %d at %.0f Hz)", updates, elapsed.Round(time.Millisecond), received, limit, rate)
+	assert.LessOrEqual(t, received, limit, "The stream should not push faster than the %.0f Hz output rate", rate)
+	assert.Equal(t, updates, s.polledDimmer(t, ctx, sessionID))
+}
+
+// TestPreviewStreamEndsWithSession checks the subscription completes when
+// its session is cancelled or committed.
+func TestPreviewStreamEndsWithSession(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	s := newStreamSetup(t, ctx)
+
+	// ended drains the stream for up to two seconds and reports whether it completed
+	ended := func(t *testing.T, stream *previewStream) bool {
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			if _, ok := stream.next(t, time.Until(deadline)); !ok {
+				break
+			}
+		}
+		return stream.completed
+	}
+
+	t.Run("Cancel", func(t *testing.T) {
+		sessionID := s.start(t, ctx)
+		stream := s.stream(t, ctx, sessionID)
+		ok, err := s.setDimmer(ctx, sessionID, 90)
+		require.NoError(t, err)
+		require.True(t, ok)
+		stream.waitForDimmer(t, 90)
+
+		s.cancelSession(ctx, sessionID)
+		assert.True(t, ended(t, stream), "The stream should complete when the session is cancelled")
+	})
+
+	t.Run("Commit", func(t *testing.T) {
+		sessionID := s.start(t, ctx)
+		stream := s.stream(t, ctx, sessionID)
+		ok, err := s.setDimmer(ctx, sessionID, 140)
+		require.NoError(t, err)
+		require.True(t, ok)
+		stream.waitForDimmer(t, 140)
+
+		require.NoError(t, s.client.Mutate(ctx, `mutation CommitPreview($sessionId: ID!) { commitPreviewSession(sessionId: $sessionId) }`,
+			map[string]interface{}{"sessionId": sessionID}, nil))
+		assert.True(t, ended(t, stream), "The stream should complete when the session is committed")
+	})
+}
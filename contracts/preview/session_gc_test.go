@@ -0,0 +1,256 @@
+package preview
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/fixtures"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// previewTTLKey is the setting that bounds how long an idle preview session lives
+	previewTTLKey = "preview_session_ttl_seconds"
+
+	previewGCContract = `setting "preview_session_ttl_seconds": a preview session with no activity for this long is reclaimed;
+  previewSession(sessionId) then returns null or isActive false, its channels stop overriding dmxOutput
+  and updatePreviewChannel on it fails; every preview mutation on a session counts as activity`
+
+	// previewTestTTL is the TTL set for the test when the server has the setting
+	previewTestTTL = 3 * time.Second
+
+	// previewGCGrace is how long past the TTL reclaiming may take, covering the sweep interval
+	previewGCGrace = 5 * time.Second
+
+	// previewGCValue is the dimmer level the abandoned session holds
+	previewGCValue = 200
+)
+
+// previewTTL returns the TTL to wait out. With the setting it is shortened to
+// previewTestTTL and restored afterwards; without it the test waits for the
+// TTL given in PREVIEW_SESSION_TTL, and is skipped if that is unset.
+func previewTTL(t *testing.T, ctx context.Context, client *graphql.Client) time.Duration {
+	var resp struct {
+		Setting *struct {
+			Value string `json:"value"`
+		} `json:"setting"`
+	}
+	err := client.Query(ctx, `query GetSetting($key: String!) { setting(key: $key) { value } }`,
+		map[string]interface{}{"key": previewTTLKey}, &resp)
+	if err == nil && resp.Setting != nil {
+		original := resp.Setting.Value
+		t.Cleanup(func() {
+			cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cleanupCancel()
+			_ = updateSetting(cleanupCtx, client, previewTTLKey, original)
+		})
+		require.NoError(t, updateSetting(ctx, client, previewTTLKey, strconv.Itoa(int(previewTestTTL.Seconds()))))
+		return previewTestTTL
+	}
+
+	wait := os.Getenv("PREVIEW_SESSION_TTL")
+	if wait == "" {
+		compat.RequireSetting(t, client, previewTTLKey, previewGCContract+
+			"\n  (or set PREVIEW_SESSION_TTL to the server's fixed TTL to wait it out)")
+	}
+	if testing.Short() {
+		t.Skip("Skipping preview session reclaim test: waiting out PREVIEW_SESSION_TTL is too long for -short")
+	}
+	ttl, err := time.ParseDuration(wait)
+	require.NoError(t, err, "PREVIEW_SESSION_TTL should be a duration such as 10m")
+	return ttl
+}
+
+func updateSetting(ctx context.Context, client *graphql.Client, key, value string) error {
+	return client.Mutate(ctx, `
+		mutation UpdateSetting($input: UpdateSettingInput!) {
+			updateSetting(input: $input) { key value }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{"key": key, "value": value},
+	}, nil)
+}
+
+// parSetup is a project with one par at universe 1 channel 1.
+type parSetup struct {
+	client    *graphql.Client
+	projectID string
+	fixtureID string
+}
+
+func newParSetup(t *testing.T, ctx context.Context, client *graphql.Client, name string) *parSetup {
+	s := &parSetup{client: client}
+
+	definitionID, err := fixtures.CreateParDefinition(ctx, client, "Test "+name, fmt.Sprintf("%s Par %d", name, time.Now().UnixNano()))
+	require.NoError(t, err)
+	s.projectID, err = fixtures.CreateProject(ctx, client, name+" Test Project")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cleanupCancel()
+		_ = client.Mutate(cleanupCtx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+		_ = fixtures.DeleteProject(cleanupCtx, client, s.projectID)
+		_ = fixtures.DeleteFixtureDefinition(cleanupCtx, client, definitionID)
+	})
+
+	s.fixtureID, err = fixtures.CreateFixture(ctx, client, s.projectID, definitionID,
+		fixtures.Fixture{Name: name + " Par", Universe: 1, StartChannel: 1})
+	require.NoError(t, err)
+	return s
+}
+
+func (s *parSetup) start(t *testing.T, ctx context.Context) string {
+	var resp struct {
+		StartPreviewSession struct {
+			ID string `json:"id"`
+		} `json:"startPreviewSession"`
+	}
+	err := s.client.Mutate(ctx, `
+		mutation StartPreview($projectId: ID!) {
+			startPreviewSession(projectId: $projectId) { id }
+		}
+	`, map[string]interface{}{"projectId": s.projectID}, &resp)
+	require.NoError(t, err)
+	return resp.StartPreviewSession.ID
+}
+
+// setDimmer sets the par's dimmer in the session, returning the mutation's
+// result and error unchecked so reclaimed sessions can be probed.
+func (s *parSetup) setDimmer(ctx context.Context, sessionID string, value int) (bool, error) {
+	var resp struct {
+		UpdatePreviewChannel bool `json:"updatePreviewChannel"`
+	}
+	err := s.client.Mutate(ctx, `
+		mutation UpdatePreview($sessionId: ID!, $fixtureId: ID!, $channelIndex: Int!, $value: Int!) {
+			updatePreviewChannel(sessionId: $sessionId, fixtureId: $fixtureId, channelIndex: $channelIndex, value: $value)
+		}
+	`, map[string]interface{}{
+		"sessionId":    sessionID,
+		"fixtureId":    s.fixtureID,
+		"channelIndex": 0,
+		"value":        value,
+	}, &resp)
+	return resp.UpdatePreviewChannel, err
+}
+
+// alive reports whether the session is still live, and if not, how the server
+// said so.
+func (s *parSetup) alive(ctx context.Context, sessionID string) (bool, string) {
+	var resp struct {
+		PreviewSession *struct {
+			IsActive bool `json:"isActive"`
+		} `json:"previewSession"`
+	}
+	err := s.client.Query(ctx, `
+		query GetPreview($sessionId: ID!) {
+			previewSession(sessionId: $sessionId) { isActive }
+		}
+	`, map[string]interface{}{"sessionId": sessionID}, &resp)
+	switch {
+	case err != nil:
+		return false, fmt.Sprintf("an error (%v)", err)
+	case resp.PreviewSession == nil:
+		return false, "null"
+	case !resp.PreviewSession.IsActive:
+		return false, "isActive false"
+	}
+	return true, ""
+}
+
+func (s *parSetup) liveDimmer(t *testing.T, ctx context.Context) int {
+	var resp struct {
+		DMXOutput []int `json:"dmxOutput"`
+	}
+	require.NoError(t, s.client.Query(ctx, `query { dmxOutput(universe: 1) }`, nil, &resp))
+	require.Len(t, resp.DMXOutput, 512, "dmxOutput should cover the universe")
+	return resp.DMXOutput[0]
+}
+
+// TestAbandonedPreviewSessionReclaimed starts a session, sets a channel and
+// walks away. The session must outlive half the TTL, be gone within the TTL
+// plus previewGCGrace, and leave nothing behind: live output back to the
+// fixture's default, the old session unusable, and a new one working.
+func TestAbandonedPreviewSessionReclaimed(t *testing.T) {
+	skipIfNoPreview(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	client := graphql.NewClient("")
+	ttl := previewTTL(t, ctx, client)
+	s := newParSetup(t, ctx, client, "Preview GC")
+
+	sessionID := s.start(t, ctx)
+	defer s.cancelSession(ctx, sessionID)
+	ok, err := s.setDimmer(ctx, sessionID, previewGCValue)
+	require.NoError(t, err)
+	require.True(t, ok)
+	abandoned := time.Now()
+
+	time.Sleep(200 * time.Millisecond)
+	drivesOutput := s.liveDimmer(t, ctx) == previewGCValue
+	t.Logf("Contract: preview session output drives dmxOutput: %v", drivesOutput)
+
+	time.Sleep(ttl/2 - time.Since(abandoned))
+	alive, _ := s.alive(ctx, sessionID)
+	require.True(t, alive, "The session must not be reclaimed before its TTL (%v)", ttl)
+
+	var how string
+	deadline := abandoned.Add(ttl + previewGCGrace)
+	for alive && time.Now().Before(deadline) {
+		time.Sleep(250 * time.Millisecond)
+		alive, how = s.alive(ctx, sessionID)
+	}
+	require.False(t, alive, "The abandoned session should be reclaimed within %v of its TTL (%v)", previewGCGrace, ttl)
+	took := time.Since(abandoned)
+	t.Logf("Contract: a reclaimed preview session reads as %s (after %v)", how, took.Round(time.Millisecond))
+	assert.GreaterOrEqual(t, took, ttl, "The session was reclaimed before its TTL")
+
+	assert.Equal(t, 0, s.liveDimmer(t, ctx), "The reclaimed session's channels must stop overriding output")
+
+	ok, err = s.setDimmer(ctx, sessionID, 50)
+	assert.True(t, err != nil || !ok, "updatePreviewChannel on a reclaimed session should fail")
+	assert.Equal(t, 0, s.liveDimmer(t, ctx), "Writing to a reclaimed session must not reach output")
+
+	t.Run("NewSession", func(t *testing.T) {
+		newID := s.start(t, ctx)
+		defer s.cancelSession(ctx, newID)
+		assert.NotEqual(t, sessionID, newID, "A reclaimed session ID must not be reused")
+
+		ok, err := s.setDimmer(ctx, newID, previewGCValue)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		if drivesOutput {
+			time.Sleep(200 * time.Millisecond)
+			assert.Equal(t, previewGCValue, s.liveDimmer(t, ctx), "A new session should drive the universe again")
+		}
+	})
+}
+
+// TestActivePreviewSessionSurvives touches a session every third of the TTL
+// for twice the TTL; activity must keep it from being reclaimed.
+func TestActivePreviewSessionSurvives(t *testing.T) {
+	skipIfNoPreview(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Minute)
+	defer cancel()
+	client := graphql.NewClient("")
+	ttl := previewTTL(t, ctx, client)
+	s := newParSetup(t, ctx, client, "Preview GC")
+
+	sessionID := s.start(t, ctx)
+	defer s.cancelSession(ctx, sessionID)
+
+	for i := 0; i < 6; i++ {
+		ok, err := s.setDimmer(ctx, sessionID, 100+i)
+		require.NoError(t, err, "touch %d", i)
+		require.True(t, ok, "An active session should accept updates (touch %d)", i)
+		time.Sleep(ttl / 3)
+	}
+	alive, how := s.alive(ctx, sessionID)
+	assert.True(t, alive, "A session in use must not be reclaimed; it reads as %s", how)
+}
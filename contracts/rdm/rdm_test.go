@@ -0,0 +1,192 @@
+// Package rdm provides RDM discovery contract tests.
+// A mock responder answers RDM over Art-Net with known devices, and the
+// server's discovery API must report exactly those devices.
+package rdm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	_ "github.com/bbernstein/lacylights-test/pkg/artifacts"
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/bbernstein/lacylights-test/pkg/rdm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rdmContract is the API shape this suite expects.
+const rdmContract = `discoverRDMDevices(universe: Int!): [RDMDevice!]! (runs Art-Net RDM discovery)
+  rdmDevices(universe: Int): [RDMDevice!]! (last discovery results)
+  RDMDevice { uid: String! (MMMM:DDDDDDDD), manufacturerId: Int!, manufacturerLabel: String, modelDescription: String,
+    deviceLabel: String, dmxStartAddress: Int!, dmxFootprint: Int!, universe: Int! }`
+
+const rdmDeviceFields = `
+	uid
+	manufacturerId
+	manufacturerLabel
+	modelDescription
+	deviceLabel
+	dmxStartAddress
+	dmxFootprint
+	universe
+`
+
+type rdmDevice struct {
+	UID               string `json:"uid"`
+	ManufacturerID    int    `json:"manufacturerId"`
+	ManufacturerLabel string `json:"manufacturerLabel"`
+	ModelDescription  string `json:"modelDescription"`
+	DeviceLabel       string `json:"deviceLabel"`
+	DMXStartAddress   int    `json:"dmxStartAddress"`
+	DMXFootprint      int    `json:"dmxFootprint"`
+	Universe          int    `json:"universe"`
+}
+
+// mockDevices are answered by the responder; the last sits on another universe.
+var mockDevices = []rdm.Device{
+	{
+		UID: rdm.NewUID(0x7A70, 0x00000101), Universe: 1,
+		ManufacturerLabel: "LacyLights Test", ModelDescription: "RDM Par", DeviceLabel: "Stage Left",
+		ModelID: 0x0042, Footprint: 4, StartAddress: 1,
+	},
+	{
+		UID: rdm.NewUID(0x7A70, 0x00000102), Universe: 1,
+		ManufacturerLabel: "LacyLights Test", ModelDescription: "RDM Mover", DeviceLabel: "Center Spot",
+		ModelID: 0x0043, Footprint: 16, StartAddress: 33,
+	},
+	{
+		UID: rdm.NewUID(0x0001, 0x000000FF), Universe: 2,
+		ManufacturerLabel: "Other Maker", ModelDescription: "RDM Dimmer",
+		ModelID: 0x0001, Footprint: 1, StartAddress: 300,
+	},
+}
+
+// startResponder runs the mock responder for the test on the shared Art-Net
+// socket (artnet.ListenAddrFromEnv), skipping when the port cannot be bound.
+func startResponder(t *testing.T) *rdm.Responder {
+	if compat.DMXChecksDisabled() {
+		t.Skip("Skipping RDM test: SKIP_DMX_TESTS or SKIP_FADE_TESTS is set")
+	}
+
+	responder := rdm.NewResponder(artnet.SharedManager(), mockDevices...)
+	if err := responder.Start(); err != nil {
+		t.Skipf("Could not start RDM responder (port may be in use): %v", err)
+	}
+	t.Cleanup(func() { _ = responder.Stop() })
+	return responder
+}
+
+func discover(t *testing.T, client *graphql.Client, ctx context.Context, universe int) map[string]rdmDevice {
+	var resp struct {
+		DiscoverRDMDevices []rdmDevice `json:"discoverRDMDevices"`
+	}
+	err := client.Mutate(ctx, `
+		mutation DiscoverRDMDevices($universe: Int!) {
+			discoverRDMDevices(universe: $universe) {`+rdmDeviceFields+`}
+		}
+	`, map[string]interface{}{"universe": universe}, &resp)
+	require.NoError(t, err)
+	return byUID(t, resp.DiscoverRDMDevices)
+}
+
+func byUID(t *testing.T, devices []rdmDevice) map[string]rdmDevice {
+	found := make(map[string]rdmDevice, len(devices))
+	for _, d := range devices {
+		_, dup := found[d.UID]
+		assert.False(t, dup, "Device %s reported twice", d.UID)
+		found[d.UID] = d
+	}
+	return found
+}
+
+// expectDevice asserts a reported device matches the mock.
+func expectDevice(t *testing.T, want rdm.Device, got rdmDevice) {
+	t.Helper()
+	assert.Equal(t, want.UID.String(), got.UID)
+	assert.Equal(t, int(want.UID.Manufacturer()), got.ManufacturerID)
+	assert.Equal(t, want.ManufacturerLabel, got.ManufacturerLabel)
+	assert.Equal(t, want.ModelDescription, got.ModelDescription)
+	assert.Equal(t, want.DeviceLabel, got.DeviceLabel)
+	assert.Equal(t, int(want.StartAddress), got.DMXStartAddress)
+	assert.Equal(t, int(want.Footprint), got.DMXFootprint)
+	assert.Equal(t, want.Universe, got.Universe)
+}
+
+// TestRDMDiscovery discovers universe 1 and checks every mocked device on it
+// is reported with its UID, manufacturer, labels and DMX address, and that
+// the device on universe 2 is not.
+func TestRDMDiscovery(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewClient("")
+	compat.RequireMutation(t, client, "discoverRDMDevices", rdmContract)
+	responder := startResponder(t)
+
+	found := discover(t, client, ctx, 1)
+
+	for _, want := range mockDevices {
+		uid := want.UID.String()
+		got, ok := found[uid]
+		if want.Universe != 1 {
+			assert.False(t, ok, "Device %s on universe %d must not be discovered on universe 1", uid, want.Universe)
+			continue
+		}
+		if assert.True(t, ok, "Device %s should be discovered", uid) {
+			expectDevice(t, want, got)
+		}
+	}
+	assert.Positive(t, responder.RequestCount(rdm.OpTodRequest)+responder.RequestCount(rdm.OpTodControl),
+		"Discovery should ask the node for its table of devices")
+	assert.Positive(t, responder.RequestCount(rdm.OpRdm), "Device details should be read over ArtRdm")
+
+	t.Run("RDMDevicesReturnsLastDiscovery", func(t *testing.T) {
+		var resp struct {
+			RDMDevices []rdmDevice `json:"rdmDevices"`
+		}
+		err := client.Query(ctx, `
+			query RDMDevices($universe: Int) {
+				rdmDevices(universe: $universe) {`+rdmDeviceFields+`}
+			}
+		`, map[string]interface{}{"universe": 1}, &resp)
+		require.NoError(t, err)
+
+		cached := byUID(t, resp.RDMDevices)
+		assert.Len(t, cached, len(found))
+		for uid, d := range found {
+			assert.Equal(t, d, cached[uid], "rdmDevices should match the discovery for %s", uid)
+		}
+	})
+
+	t.Run("SecondUniverse", func(t *testing.T) {
+		found := discover(t, client, ctx, 2)
+		require.Len(t, found, 1)
+		expectDevice(t, mockDevices[2], found[mockDevices[2].UID.String()])
+	})
+}
+
+// TestRDMDiscoveryReflectsReaddressing changes a device's start address at the
+// responder, as a tech would from the fixture's menu, and rediscovers. The new
+// address must be reported so patch-from-RDM sees the rig as it is now.
+func TestRDMDiscoveryReflectsReaddressing(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewClient("")
+	compat.RequireMutation(t, client, "discoverRDMDevices", rdmContract)
+	responder := startResponder(t)
+
+	uid := mockDevices[0].UID
+	found := discover(t, client, ctx, 1)
+	require.Contains(t, found, uid.String())
+	assert.Equal(t, int(mockDevices[0].StartAddress), found[uid.String()].DMXStartAddress)
+
+	require.True(t, responder.SetStartAddress(uid, 101))
+
+	found = discover(t, client, ctx, 1)
+	require.Contains(t, found, uid.String())
+	assert.Equal(t, 101, found[uid.String()].DMXStartAddress, "Rediscovery should report the new address")
+}
@@ -0,0 +1,243 @@
+// Package repatch provides migration tests for moving a whole project to new
+// DMX addresses: to another universe, or offset within the same one. Looks,
+// effects and cue playback must produce the same output relative to each
+// fixture, and nothing may be left at the old addresses.
+package repatch
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	_ "github.com/bbernstein/lacylights-test/pkg/artifacts"
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/fixtures"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// effectSamples are taken effectInterval apart to tell a moving channel from a static one
+	effectSamples  = 10
+	effectInterval = 100 * time.Millisecond
+)
+
+// baseStarts are the par start channels before migrating, clear of the
+// channels other suites write.
+var baseStarts = []int{301, 305, 309}
+
+// lookValues are the dimmer/RGB values of each par in the two looks.
+var lookValues = map[string][][]int{
+	"Repatch A": {{255, 200, 0, 40}, {128, 0, 255, 0}, {60, 10, 20, 30}},
+	"Repatch B": {{0, 0, 0, 0}, {255, 255, 255, 255}, {90, 180, 0, 200}},
+}
+
+// placement is where the pars are patched: universe, and a channel offset from baseStarts.
+type placement struct {
+	universe int
+	offset   int
+}
+
+func (p placement) String() string {
+	return fmt.Sprintf("universe %d +%d", p.universe, p.offset)
+}
+
+func (p placement) start(i int) int {
+	return baseStarts[i] + p.offset
+}
+
+type repatchSetup struct {
+	client     *graphql.Client
+	ctx        context.Context
+	fixtureIDs []string
+	lookIDs    map[string]string
+	cueListID  string
+	effectID   string
+}
+
+func newRepatchSetup(t *testing.T, ctx context.Context) *repatchSetup {
+	if compat.DMXChecksDisabled() {
+		t.Skip("Skipping repatch tests: SKIP_DMX_TESTS or SKIP_FADE_TESTS is set")
+	}
+	client := graphql.NewClient("")
+	s := &repatchSetup{client: client, ctx: ctx, lookIDs: map[string]string{}}
+
+	project := fixtures.NewParProject(t, ctx, client, "Repatch")
+	project.OnCleanup(func(ctx context.Context) {
+		_ = client.Mutate(ctx, `mutation StopCueList($id: ID!) { stopCueList(cueListId: $id) }`,
+			map[string]interface{}{"id": s.cueListID}, nil)
+	})
+	projectID := project.ID
+
+	for i, start := range baseStarts {
+		s.fixtureIDs = append(s.fixtureIDs, project.AddFixture(t, ctx,
+			fixtures.Fixture{Name: fmt.Sprintf("Repatch Par %d", i+1), Universe: 1, StartChannel: start}))
+	}
+	for _, name := range []string{"Repatch A", "Repatch B"} {
+		var values []fixtures.FixtureValues
+		for i, id := range s.fixtureIDs {
+			values = append(values, fixtures.FixtureValues{FixtureID: id, Values: lookValues[name][i]})
+		}
+		s.lookIDs[name] = project.AddLook(t, ctx, name, values)
+	}
+	var err error
+	s.cueListID, err = fixtures.CreateCueList(ctx, client, projectID, "Repatch Cues", []fixtures.Cue{
+		{Name: "Repatch Cue A", LookID: s.lookIDs["Repatch A"]},
+		{Name: "Repatch Cue B", LookID: s.lookIDs["Repatch B"]},
+	})
+	require.NoError(t, err)
+	s.effectID, err = fixtures.CreateWaveformEffect(ctx, client, projectID, "Repatch Sine", "SINE", 1.0, s.fixtureIDs[:1])
+	require.NoError(t, err)
+	return s
+}
+
+func (s *repatchSetup) mutate(t *testing.T, mutation string, variables map[string]interface{}) {
+	require.NoError(t, s.client.Mutate(s.ctx, mutation, variables, nil))
+}
+
+// move re-patches every par to the placement.
+func (s *repatchSetup) move(t *testing.T, p placement) {
+	for i, id := range s.fixtureIDs {
+		s.mutate(t, `
+			mutation UpdateFixtureInstance($id: ID!, $input: UpdateFixtureInstanceInput!) {
+				updateFixtureInstance(id: $id, input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"id":    id,
+			"input": map[string]interface{}{"universe": p.universe, "startChannel": p.start(i)},
+		})
+	}
+}
+
+// pars reads the four channels of each par at the placement.
+func (s *repatchSetup) pars(t *testing.T, p placement) [][]int {
+	t.Helper()
+	var resp struct {
+		DMXOutput []int `json:"dmxOutput"`
+	}
+	require.NoError(t, s.client.Query(s.ctx, `query Output($universe: Int!) { dmxOutput(universe: $universe) }`,
+		map[string]interface{}{"universe": p.universe}, &resp))
+	require.Len(t, resp.DMXOutput, 512, "dmxOutput should cover universe %d", p.universe)
+	pars := make([][]int, len(baseStarts))
+	for i := range baseStarts {
+		start := p.start(i)
+		pars[i] = resp.DMXOutput[start-1 : start+3]
+	}
+	return pars
+}
+
+// record plays the looks, the cue list and the effect, and returns the par
+// output after each, relative to the pars' start channels. Effect samples
+// are summarised per channel as "moving" or the value it held.
+func (s *repatchSetup) record(t *testing.T, p placement) map[string]interface{} {
+	out := map[string]interface{}{}
+	for _, name := range []string{"Repatch A", "Repatch B"} {
+		fixtures.SetLookLive(t, s.ctx, s.client, s.lookIDs[name])
+		out["look "+name] = s.pars(t, p)
+	}
+
+	cueList := map[string]interface{}{"cueListId": s.cueListID}
+	s.mutate(t, `mutation StartCueList($cueListId: ID!) { startCueList(cueListId: $cueListId) }`, cueList)
+	time.Sleep(fixtures.Settle)
+	out["cue 1"] = s.pars(t, p)
+	s.mutate(t, `mutation NextCue($cueListId: ID!) { nextCue(cueListId: $cueListId) }`, cueList)
+	time.Sleep(fixtures.Settle)
+	out["cue 2"] = s.pars(t, p)
+	s.mutate(t, `mutation StopCueList($cueListId: ID!) { stopCueList(cueListId: $cueListId) }`, cueList)
+
+	require.NoError(t, fixtures.ActivateLook(s.ctx, s.client, s.lookIDs["Repatch A"]))
+	s.mutate(t, `mutation Activate($id: ID!) { activateEffect(effectId: $id, fadeTime: 0) }`,
+		map[string]interface{}{"id": s.effectID})
+	time.Sleep(fixtures.Settle)
+	seen := make([][]map[int]bool, len(baseStarts))
+	for i := range seen {
+		seen[i] = []map[int]bool{{}, {}, {}, {}}
+	}
+	for n := 0; n < effectSamples; n++ {
+		for i, par := range s.pars(t, p) {
+			for ch, v := range par {
+				seen[i][ch][v] = true
+			}
+		}
+		time.Sleep(effectInterval)
+	}
+	s.mutate(t, `mutation Stop($id: ID!) { stopEffect(effectId: $id, fadeTime: 0) }`,
+		map[string]interface{}{"id": s.effectID})
+	summary := make([][]string, len(seen))
+	for i, par := range seen {
+		for _, values := range par {
+			state := "moving"
+			if len(values) == 1 {
+				for v := range values {
+					state = fmt.Sprint(v)
+				}
+			}
+			summary[i] = append(summary[i], state)
+		}
+	}
+	out["effect"] = summary
+
+	s.mutate(t, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil)
+	time.Sleep(fixtures.Settle)
+	return out
+}
+
+// expectVacated activates look A at the new placement and checks the old
+// addresses stay dark, through dmxOutput and, with Art-Net, on the wire.
+func (s *repatchSetup) expectVacated(t *testing.T, old placement) {
+	fixtures.SetLookLive(t, s.ctx, s.client, s.lookIDs["Repatch A"])
+	dark := [][]int{{0, 0, 0, 0}, {0, 0, 0, 0}, {0, 0, 0, 0}}
+	assert.Equal(t, dark, s.pars(t, old), "Nothing should be output at the old addresses (%s)", old)
+
+	t.Run("ArtNet", func(t *testing.T) {
+		compat.Require(t, compat.ArtNet)
+		receiver := artnet.Capture(t, artnet.Universes(old.universe-1))
+		frames, err := receiver.CaptureFrames(s.ctx, time.Second)
+		require.NoError(t, err)
+		if len(frames) == 0 {
+			t.Logf("Contract: universe %d stops emitting once nothing is patched to it", old.universe)
+			return
+		}
+		for _, f := range frames {
+			for i := range baseStarts {
+				start := old.start(i)
+				assert.Equal(t, []byte{0, 0, 0, 0}, f.Channels[start-1:start+3],
+					"Art-Net frame at %v still carries par %d at its old address", f.Timestamp.Format("15:04:05.000"), i+1)
+			}
+		}
+	})
+}
+
+// TestRepatchProject records the project's output at its original patch,
+// migrates every par, records again and expects the same output relative to
+// each par, with the old addresses left dark.
+func TestRepatchProject(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	s := newRepatchSetup(t, ctx)
+
+	base := placement{universe: 1}
+	s.move(t, base)
+	want := s.record(t, base)
+	require.Equal(t, "moving", want["effect"].([][]string)[0][0], "The effect should move par 1's dimmer before migrating")
+
+	for _, tc := range []struct {
+		name   string
+		target placement
+	}{
+		{"ToUniverse3", placement{universe: 3}},
+		{"OffsetBy100", placement{universe: 1, offset: 100}},
+	} {
+		target := tc.target
+		t.Run(tc.name, func(t *testing.T) {
+			s.move(t, target)
+			assert.Equal(t, want, s.record(t, target), "Output relative to each par should not change when moved to %s", target)
+			s.expectVacated(t, base)
+			s.mutate(t, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil)
+			s.move(t, base)
+		})
+	}
+}
@@ -0,0 +1,404 @@
+package resilience
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/bbernstein/lacylights-test/pkg/serverctl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// persistenceSnapshotQuery reads back every entity created by the round-trip test.
+// Values are compared as raw JSON, so any field added here is covered automatically.
+const persistenceSnapshotQuery = `
+	fragment PersistedLook on Look {
+		id
+		name
+		description
+		fixtureValues {
+			fixture { id }
+			channels { offset value }
+		}
+	}
+
+	query PersistenceSnapshot($projectId: ID!, $definitionId: ID!, $look1Id: ID!, $look2Id: ID!,
+		$cueListId: ID!, $lookBoardId: ID!, $effectId: ID!) {
+		project(id: $projectId) {
+			id
+			name
+			description
+		}
+		fixtureDefinition(id: $definitionId) {
+			id
+			manufacturer
+			model
+			type
+			isBuiltIn
+			channels {
+				name
+				type
+				offset
+				minValue
+				maxValue
+				defaultValue
+			}
+		}
+		fixtureInstances(projectId: $projectId) {
+			fixtures {
+				id
+				name
+				universe
+				startChannel
+			}
+		}
+		look1: look(id: $look1Id) {
+			...PersistedLook
+		}
+		look2: look(id: $look2Id) {
+			...PersistedLook
+		}
+		cueList(id: $cueListId) {
+			id
+			name
+			description
+			loop
+			cues {
+				id
+				name
+				cueNumber
+				fadeInTime
+				fadeOutTime
+				notes
+				look { id }
+				effects {
+					effectId
+					intensity
+				}
+			}
+		}
+		effect(id: $effectId) {
+			id
+			name
+			effectType
+			waveform
+			frequency
+			amplitude
+			offset
+			compositionMode
+		}
+		lookBoard(id: $lookBoardId) {
+			id
+			name
+			defaultFadeTime
+			buttons {
+				id
+				look { id }
+				layoutX
+				layoutY
+			}
+		}
+	}
+`
+
+// TestPersistenceRoundTrip builds a project that exercises every serialized shape -
+// a multi-channel fixture definition, looks with sparse channel values, cues with an
+// effect attached, and a look board layout - then restarts the server and requires
+// every entity to read back identically. In-session CRUD tests can pass against an
+// in-memory cache; only a restart proves the database round trip.
+func TestPersistenceRoundTrip(t *testing.T) {
+	ctrl := serverctl.Require(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+	defer cancel()
+
+	client := graphql.NewClient("")
+	suffix := time.Now().UnixNano()
+
+	// Project
+	var projectResp struct {
+		CreateProject struct {
+			ID string `json:"id"`
+		} `json:"createProject"`
+	}
+	err := client.Mutate(ctx, `
+		mutation CreateProject($input: CreateProjectInput!) {
+			createProject(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"name":        "Persistence Round Trip",
+			"description": "Unicode and punctuation survive: café, 50% \"quoted\" — done",
+		},
+	}, &projectResp)
+	require.NoError(t, err)
+	projectID := projectResp.CreateProject.ID
+
+	// Fixture definition with non-default channel ranges and defaults
+	var defResp struct {
+		CreateFixtureDefinition struct {
+			ID string `json:"id"`
+		} `json:"createFixtureDefinition"`
+	}
+	err = client.Mutate(ctx, `
+		mutation CreateFixtureDefinition($input: CreateFixtureDefinitionInput!) {
+			createFixtureDefinition(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"manufacturer": "Test Persistence",
+			"model":        fmt.Sprintf("Round Trip Par %d", suffix),
+			"type":         "LED_PAR",
+			"channels": []map[string]interface{}{
+				{"name": "Dimmer", "type": "INTENSITY", "offset": 0, "minValue": 0, "maxValue": 255, "defaultValue": 0},
+				{"name": "Red", "type": "RED", "offset": 1, "minValue": 0, "maxValue": 255, "defaultValue": 0},
+				{"name": "Green", "type": "GREEN", "offset": 2, "minValue": 0, "maxValue": 255, "defaultValue": 0},
+				{"name": "Blue", "type": "BLUE", "offset": 3, "minValue": 0, "maxValue": 255, "defaultValue": 0},
+				{"name": "Strobe", "type": "STROBE", "offset": 4, "minValue": 10, "maxValue": 250, "defaultValue": 10},
+				{"name": "Macro", "type": "OTHER", "offset": 5, "minValue": 0, "maxValue": 127, "defaultValue": 64},
+			},
+		},
+	}, &defResp)
+	require.NoError(t, err)
+	definitionID := defResp.CreateFixtureDefinition.ID
+
+	defer func() {
+		cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cleanupCancel()
+		_ = client.Mutate(cleanupCtx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
+			map[string]interface{}{"id": projectID}, nil)
+		_ = client.Mutate(cleanupCtx, `mutation DeleteFixtureDefinition($id: ID!) { deleteFixtureDefinition(id: $id) }`,
+			map[string]interface{}{"id": definitionID}, nil)
+	}()
+
+	// Two fixtures in different universes
+	fixtureIDs := make([]string, 0, 2)
+	for i, universe := range []int{1, 3} {
+		var fixtureResp struct {
+			CreateFixtureInstance struct {
+				ID string `json:"id"`
+			} `json:"createFixtureInstance"`
+		}
+		err = client.Mutate(ctx, `
+			mutation CreateFixtureInstance($input: CreateFixtureInstanceInput!) {
+				createFixtureInstance(input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"projectId":    projectID,
+				"definitionId": definitionID,
+				"name":         fmt.Sprintf("Round Trip Par %d", i+1),
+				"universe":     universe,
+				"startChannel": 100 + i*10,
+			},
+		}, &fixtureResp)
+		require.NoError(t, err)
+		fixtureIDs = append(fixtureIDs, fixtureResp.CreateFixtureInstance.ID)
+	}
+
+	// Looks with sparse channel values: gaps, a lone high offset, and explicit zeros
+	lookChannels := [][]map[string]interface{}{
+		{{"offset": 0, "value": 255}, {"offset": 3, "value": 17}},
+		{{"offset": 5, "value": 0}, {"offset": 4, "value": 250}},
+	}
+	lookIDs := make([]string, 0, len(lookChannels))
+	for i, channels := range lookChannels {
+		var lookResp struct {
+			CreateLook struct {
+				ID string `json:"id"`
+			} `json:"createLook"`
+		}
+		err = client.Mutate(ctx, `
+			mutation CreateLook($input: CreateLookInput!) {
+				createLook(input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"projectId":   projectID,
+				"name":        fmt.Sprintf("Sparse Look %d", i+1),
+				"description": fmt.Sprintf("Sparse channels on fixture %d only", i+1),
+				"fixtureValues": []map[string]interface{}{
+					{"fixtureId": fixtureIDs[i], "channels": channels},
+				},
+			},
+		}, &lookResp)
+		require.NoError(t, err)
+		lookIDs = append(lookIDs, lookResp.CreateLook.ID)
+	}
+
+	// Effect on the first fixture's dimmer
+	var effectResp struct {
+		CreateEffect struct {
+			ID string `json:"id"`
+		} `json:"createEffect"`
+	}
+	err = client.Mutate(ctx, `
+		mutation CreateEffect($input: CreateEffectInput!) {
+			createEffect(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId":       projectID,
+			"name":            "Round Trip Wave",
+			"effectType":      "WAVEFORM",
+			"waveform":        "TRIANGLE",
+			"frequency":       0.37,
+			"amplitude":       42.5,
+			"offset":          12.5,
+			"compositionMode": "ADDITIVE",
+		},
+	}, &effectResp)
+	require.NoError(t, err)
+	effectID := effectResp.CreateEffect.ID
+
+	var efResp struct {
+		AddFixtureToEffect struct {
+			ID string `json:"id"`
+		} `json:"addFixtureToEffect"`
+	}
+	err = client.Mutate(ctx, `
+		mutation AddFixture($input: AddFixtureToEffectInput!) {
+			addFixtureToEffect(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{"effectId": effectID, "fixtureId": fixtureIDs[0]},
+	}, &efResp)
+	require.NoError(t, err)
+
+	err = client.Mutate(ctx, `
+		mutation AddChannel($effectFixtureId: ID!, $input: EffectChannelInput!) {
+			addChannelToEffectFixture(effectFixtureId: $effectFixtureId, input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"effectFixtureId": efResp.AddFixtureToEffect.ID,
+		"input":           map[string]interface{}{"channelOffset": 0},
+	}, nil)
+	require.NoError(t, err)
+
+	// Cue list with fractional cue numbers and fade times, one cue carrying the effect
+	var cueListResp struct {
+		CreateCueList struct {
+			ID string `json:"id"`
+		} `json:"createCueList"`
+	}
+	err = client.Mutate(ctx, `
+		mutation CreateCueList($input: CreateCueListInput!) {
+			createCueList(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId":   projectID,
+			"name":        "Round Trip Cues",
+			"description": "Persisted across restart",
+			"loop":        true,
+		},
+	}, &cueListResp)
+	require.NoError(t, err)
+	cueListID := cueListResp.CreateCueList.ID
+
+	cueIDs := make([]string, 0, len(lookIDs))
+	for i, lookID := range lookIDs {
+		var cueResp struct {
+			CreateCue struct {
+				ID string `json:"id"`
+			} `json:"createCue"`
+		}
+		err = client.Mutate(ctx, `
+			mutation CreateCue($input: CreateCueInput!) {
+				createCue(input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"cueListId":   cueListID,
+				"lookId":      lookID,
+				"name":        fmt.Sprintf("Round Trip Cue %d", i+1),
+				"cueNumber":   1.5 + float64(i),
+				"fadeInTime":  0.25 + float64(i),
+				"fadeOutTime": 1.75,
+				"notes":       "Multi-line\nnotes",
+			},
+		}, &cueResp)
+		require.NoError(t, err)
+		cueIDs = append(cueIDs, cueResp.CreateCue.ID)
+	}
+
+	err = client.Mutate(ctx, `
+		mutation AddEffectToCue($input: AddEffectToCueInput!) {
+			addEffectToCue(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{"cueId": cueIDs[1], "effectId": effectID, "intensity": 65.0},
+	}, nil)
+	require.NoError(t, err)
+
+	// Look board with an irregular layout
+	var boardResp struct {
+		CreateLookBoard struct {
+			ID string `json:"id"`
+		} `json:"createLookBoard"`
+	}
+	err = client.Mutate(ctx, `
+		mutation CreateLookBoard($input: CreateLookBoardInput!) {
+			createLookBoard(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId":       projectID,
+			"name":            "Round Trip Board",
+			"defaultFadeTime": 2.5,
+		},
+	}, &boardResp)
+	require.NoError(t, err)
+	boardID := boardResp.CreateLookBoard.ID
+
+	for i, lookID := range lookIDs {
+		err = client.Mutate(ctx, `
+			mutation AddLookToBoard($input: CreateLookBoardButtonInput!) {
+				addLookToBoard(input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"lookBoardId": boardID,
+				"lookId":      lookID,
+				"layoutX":     37 + i*311,
+				"layoutY":     1000 - i*7,
+			},
+		}, nil)
+		require.NoError(t, err)
+	}
+
+	variables := map[string]interface{}{
+		"projectId":    projectID,
+		"definitionId": definitionID,
+		"look1Id":      lookIDs[0],
+		"look2Id":      lookIDs[1],
+		"cueListId":    cueListID,
+		"lookBoardId":  boardID,
+		"effectId":     effectID,
+	}
+	snapshot := func(t *testing.T) map[string]json.RawMessage {
+		var resp map[string]json.RawMessage
+		err := client.Query(ctx, persistenceSnapshotQuery, variables, &resp)
+		require.NoError(t, err)
+		return resp
+	}
+
+	before := snapshot(t)
+
+	restartServer(t, ctrl)
+
+	after := snapshot(t)
+
+	require.Equal(t, len(before), len(after), "Snapshot should return the same root fields after restart")
+	for field, want := range before {
+		t.Run(field, func(t *testing.T) {
+			got, ok := after[field]
+			require.True(t, ok, "%s missing after restart", field)
+			assert.JSONEq(t, string(want), string(got), "%s should read back identically after restart", field)
+		})
+	}
+}
@@ -0,0 +1,369 @@
+// Package resilience provides server restart contract tests.
+// These tests stop and start the backend through pkg/serverctl, so they only run
+// with RESTART_TESTS=1 and a configured restart command (see make test-resilience).
+package resilience
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/bbernstein/lacylights-test/pkg/artifacts"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/bbernstein/lacylights-test/pkg/serverctl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// restartFadeTime is long enough that the restart lands well inside the fade
+	restartFadeTime = 6.0
+
+	// restartFadeLevel is the target of the fade interrupted by the restart
+	restartFadeLevel = 255
+)
+
+// restartServer restarts the backend and fails the test if it does not come back.
+func restartServer(t *testing.T, ctrl *serverctl.Controller) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	start := time.Now()
+	require.NoError(t, ctrl.Restart(ctx), "Server should restart and become ready")
+	t.Logf("Server restarted in %v", time.Since(start))
+}
+
+// dmxPolicy returns the documented post-restart DMX policy from RESTART_DMX_POLICY:
+// "black" (output faults to black), "resume" (the interrupted fade lands on its
+// target), or "" to accept either.
+func dmxPolicy(t *testing.T) string {
+	policy := os.Getenv("RESTART_DMX_POLICY")
+	switch policy {
+	case "", "black", "resume":
+		return policy
+	default:
+		t.Fatalf("RESTART_DMX_POLICY must be black or resume, got %q", policy)
+		return ""
+	}
+}
+
+// TestRestartMidFade restarts the server while a cue is fading up and checks that
+// on reconnect the DMX output follows the documented policy rather than freezing
+// at the mid-fade level, that the project, look and cue are intact, and that the
+// project's undo history survived and can still be undone.
+func TestRestartMidFade(t *testing.T) {
+	ctrl := serverctl.Require(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+	defer cancel()
+
+	client := graphql.NewClient("")
+	policy := dmxPolicy(t)
+
+	// Create project
+	var projectResp struct {
+		CreateProject struct {
+			ID string `json:"id"`
+		} `json:"createProject"`
+	}
+	err := client.Mutate(ctx, `
+		mutation CreateProject($input: CreateProjectInput!) {
+			createProject(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{"name": "Restart Resilience Project"},
+	}, &projectResp)
+	require.NoError(t, err)
+	projectID := projectResp.CreateProject.ID
+
+	var defResp struct {
+		CreateFixtureDefinition struct {
+			ID string `json:"id"`
+		} `json:"createFixtureDefinition"`
+	}
+	err = client.Mutate(ctx, `
+		mutation CreateFixtureDefinition($input: CreateFixtureDefinitionInput!) {
+			createFixtureDefinition(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"manufacturer": "Test Resilience",
+			"model":        fmt.Sprintf("Restart Dimmer %d", time.Now().UnixNano()),
+			"type":         "DIMMER",
+			"channels": []map[string]interface{}{
+				{"name": "Dimmer", "type": "INTENSITY", "offset": 0, "minValue": 0, "maxValue": 255, "defaultValue": 0},
+			},
+		},
+	}, &defResp)
+	require.NoError(t, err)
+	definitionID := defResp.CreateFixtureDefinition.ID
+
+	defer func() {
+		cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cleanupCancel()
+		_ = client.Mutate(cleanupCtx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+		_ = client.Mutate(cleanupCtx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
+			map[string]interface{}{"id": projectID}, nil)
+		_ = client.Mutate(cleanupCtx, `mutation DeleteFixtureDefinition($id: ID!) { deleteFixtureDefinition(id: $id) }`,
+			map[string]interface{}{"id": definitionID}, nil)
+	}()
+
+	var fixtureResp struct {
+		CreateFixtureInstance struct {
+			ID string `json:"id"`
+		} `json:"createFixtureInstance"`
+	}
+	err = client.Mutate(ctx, `
+		mutation CreateFixtureInstance($input: CreateFixtureInstanceInput!) {
+			createFixtureInstance(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId":    projectID,
+			"definitionId": definitionID,
+			"name":         "Restart Dimmer",
+			"universe":     1,
+			"startChannel": 1,
+		},
+	}, &fixtureResp)
+	require.NoError(t, err)
+	fixtureID := fixtureResp.CreateFixtureInstance.ID
+
+	var lookResp struct {
+		CreateLook struct {
+			ID string `json:"id"`
+		} `json:"createLook"`
+	}
+	err = client.Mutate(ctx, `
+		mutation CreateLook($input: CreateLookInput!) {
+			createLook(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId": projectID,
+			"name":      "Restart Full",
+			"fixtureValues": []map[string]interface{}{
+				{"fixtureId": fixtureID, "channels": []map[string]interface{}{{"offset": 0, "value": restartFadeLevel}}},
+			},
+		},
+	}, &lookResp)
+	require.NoError(t, err)
+	lookID := lookResp.CreateLook.ID
+
+	var cueListResp struct {
+		CreateCueList struct {
+			ID string `json:"id"`
+		} `json:"createCueList"`
+	}
+	err = client.Mutate(ctx, `
+		mutation CreateCueList($input: CreateCueListInput!) {
+			createCueList(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{"projectId": projectID, "name": "Restart Cue List"},
+	}, &cueListResp)
+	require.NoError(t, err)
+	cueListID := cueListResp.CreateCueList.ID
+
+	err = client.Mutate(ctx, `
+		mutation CreateCue($input: CreateCueInput!) {
+			createCue(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"cueListId":   cueListID,
+			"lookId":      lookID,
+			"name":        "Slow Fade Up",
+			"cueNumber":   1.0,
+			"fadeInTime":  restartFadeTime,
+			"fadeOutTime": restartFadeTime,
+		},
+	}, nil)
+	require.NoError(t, err)
+
+	// An update that lands in the undo history
+	err = client.Mutate(ctx, `
+		mutation UpdateLook($id: ID!, $input: UpdateLookInput!) {
+			updateLook(id: $id, input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"id":    lookID,
+		"input": map[string]interface{}{"description": "Before restart"},
+	}, nil)
+	require.NoError(t, err)
+
+	type undoStatus struct {
+		CanUndo         bool    `json:"canUndo"`
+		CurrentSequence int     `json:"currentSequence"`
+		TotalOperations int     `json:"totalOperations"`
+		UndoDescription *string `json:"undoDescription"`
+	}
+	getUndoStatus := func(t *testing.T) undoStatus {
+		var resp struct {
+			UndoRedoStatus undoStatus `json:"undoRedoStatus"`
+		}
+		err := client.Query(ctx, `
+			query GetUndoRedoStatus($projectId: ID!) {
+				undoRedoStatus(projectId: $projectId) {
+					canUndo
+					currentSequence
+					totalOperations
+					undoDescription
+				}
+			}
+		`, map[string]interface{}{"projectId": projectID}, &resp)
+		require.NoError(t, err)
+		return resp.UndoRedoStatus
+	}
+	before := getUndoStatus(t)
+	require.True(t, before.CanUndo, "The look update should be undoable before restart")
+
+	// Start the slow fade and restart partway through it
+	_ = client.Mutate(ctx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+	time.Sleep(200 * time.Millisecond)
+	err = client.Mutate(ctx, `
+		mutation StartCueList($cueListId: ID!) {
+			startCueList(cueListId: $cueListId)
+		}
+	`, map[string]interface{}{"cueListId": cueListID}, nil)
+	require.NoError(t, err)
+	time.Sleep(2 * time.Second)
+
+	var dmxResp struct {
+		DMXOutput []int `json:"dmxOutput"`
+	}
+	err = client.Query(ctx, `query { dmxOutput(universe: 1) }`, nil, &dmxResp)
+	require.NoError(t, err)
+	require.Len(t, dmxResp.DMXOutput, 512, "dmxOutput should cover the universe")
+	midFade := dmxResp.DMXOutput[0]
+	t.Logf("Dimmer at restart: %d", midFade)
+
+	restartServer(t, ctrl)
+
+	t.Run("DMXFollowsPolicy", func(t *testing.T) {
+		// Give a resumed fade time to finish, then the output must be settled
+		time.Sleep(time.Duration(restartFadeTime*float64(time.Second)) + 500*time.Millisecond)
+
+		err := client.Query(ctx, `query { dmxOutput(universe: 1) }`, nil, &dmxResp)
+		require.NoError(t, err)
+		require.Len(t, dmxResp.DMXOutput, 512, "dmxOutput should cover the universe")
+		got := dmxResp.DMXOutput[0]
+		t.Logf("Dimmer after restart: %d (policy %q)", got, policy)
+
+		switch policy {
+		case "black":
+			assert.Equal(t, 0, got, "Output should fault to black after restart")
+		case "resume":
+			assert.Equal(t, restartFadeLevel, got, "Interrupted fade should resume to its target after restart")
+		default:
+			assert.Contains(t, []int{0, restartFadeLevel}, got,
+				"Output after restart should be black or the fade target, not frozen mid-fade")
+		}
+	})
+
+	t.Run("EntitiesIntact", func(t *testing.T) {
+		var lookQuery struct {
+			Look struct {
+				ID            string  `json:"id"`
+				Name          string  `json:"name"`
+				Description   *string `json:"description"`
+				FixtureValues []struct {
+					Fixture struct {
+						ID string `json:"id"`
+					} `json:"fixture"`
+					Channels []struct {
+						Offset int `json:"offset"`
+						Value  int `json:"value"`
+					} `json:"channels"`
+				} `json:"fixtureValues"`
+			} `json:"look"`
+		}
+		err := client.Query(ctx, `
+			query GetLook($id: ID!) {
+				look(id: $id) {
+					id
+					name
+					description
+					fixtureValues {
+						fixture { id }
+						channels { offset value }
+					}
+				}
+			}
+		`, map[string]interface{}{"id": lookID}, &lookQuery)
+		require.NoError(t, err)
+		assert.Equal(t, "Restart Full", lookQuery.Look.Name)
+		require.NotNil(t, lookQuery.Look.Description)
+		assert.Equal(t, "Before restart", *lookQuery.Look.Description)
+		require.Len(t, lookQuery.Look.FixtureValues, 1)
+		assert.Equal(t, fixtureID, lookQuery.Look.FixtureValues[0].Fixture.ID)
+		require.Len(t, lookQuery.Look.FixtureValues[0].Channels, 1)
+		assert.Equal(t, restartFadeLevel, lookQuery.Look.FixtureValues[0].Channels[0].Value)
+
+		var cueListQuery struct {
+			CueList struct {
+				Name string `json:"name"`
+				Cues []struct {
+					Name       string  `json:"name"`
+					FadeInTime float64 `json:"fadeInTime"`
+					Look       struct {
+						ID string `json:"id"`
+					} `json:"look"`
+				} `json:"cues"`
+			} `json:"cueList"`
+		}
+		err = client.Query(ctx, `
+			query GetCueList($id: ID!) {
+				cueList(id: $id) {
+					name
+					cues {
+						name
+						fadeInTime
+						look { id }
+					}
+				}
+			}
+		`, map[string]interface{}{"id": cueListID}, &cueListQuery)
+		require.NoError(t, err)
+		assert.Equal(t, "Restart Cue List", cueListQuery.CueList.Name)
+		require.Len(t, cueListQuery.CueList.Cues, 1)
+		assert.Equal(t, "Slow Fade Up", cueListQuery.CueList.Cues[0].Name)
+		assert.InDelta(t, restartFadeTime, cueListQuery.CueList.Cues[0].FadeInTime, 0.001)
+		assert.Equal(t, lookID, cueListQuery.CueList.Cues[0].Look.ID)
+	})
+
+	t.Run("UndoHistorySurvives", func(t *testing.T) {
+		after := getUndoStatus(t)
+		assert.Equal(t, before, after, "Undo status should be unchanged by a restart")
+
+		var undoResp struct {
+			Undo struct {
+				Success bool `json:"success"`
+			} `json:"undo"`
+		}
+		err := client.Mutate(ctx, `
+			mutation Undo($projectId: ID!) {
+				undo(projectId: $projectId) { success }
+			}
+		`, map[string]interface{}{"projectId": projectID}, &undoResp)
+		require.NoError(t, err)
+		require.True(t, undoResp.Undo.Success, "Undo of a pre-restart operation should succeed")
+
+		var lookQuery struct {
+			Look struct {
+				Description *string `json:"description"`
+			} `json:"look"`
+		}
+		err = client.Query(ctx, `
+			query GetLook($id: ID!) {
+				look(id: $id) { description }
+			}
+		`, map[string]interface{}{"id": lookID}, &lookQuery)
+		require.NoError(t, err)
+		if lookQuery.Look.Description != nil {
+			assert.NotEqual(t, "Before restart", *lookQuery.Look.Description, "Undo should revert the pre-restart update")
+		}
+	})
+}
@@ -0,0 +1,221 @@
+// Package rest provides contract tests for the server's REST endpoints.
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/bbernstein/lacylights-test/pkg/artifacts"
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/fixtures"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/bbernstein/lacylights-test/pkg/rest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// healthContract is the health endpoint these tests expect.
+	healthContract = `GET /health: 200 application/json { status: "ok", version: String }
+  version is the same as systemInfo.version`
+
+	// definitionUploadContract is the fixture definition upload these tests expect.
+	definitionUploadContract = `POST /api/fixture-definitions: multipart/form-data with a "file" field holding
+  a definition as JSON { manufacturer, model, type, channels: [...] } (the CreateFixtureDefinitionInput shape)
+  201 application/json { id, manufacturer, model } on success, the same definition fixtureDefinition(id) returns
+  400 application/json { error } for a missing or malformed file, 409 application/json { error } for an existing model`
+
+	// exportDownloadContract is the project export download these tests expect.
+	exportDownloadContract = `GET /api/projects/{id}/export: 200 application/json with
+  Content-Disposition: attachment; filename="<name>.json", the same document exportProject returns as jsonContent
+  404 application/json { error } for an unknown project`
+)
+
+// errorBody is the JSON body of a REST error response.
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+// requireJSONError checks a REST error response: the given status with a JSON { error } body.
+func requireJSONError(t *testing.T, resp *rest.Response, status int) {
+	t.Helper()
+	require.Equal(t, status, resp.StatusCode, "body: %s", resp.Body)
+	var body errorBody
+	require.NoError(t, resp.JSON(&body), "Errors should be JSON")
+	assert.NotEmpty(t, body.Error, "Errors should say what went wrong")
+}
+
+func TestHealth(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client := rest.NewClient("")
+	resp := rest.RequireEndpoint(t, client, http.MethodGet, "/health", healthContract)
+
+	require.Equal(t, http.StatusOK, resp.StatusCode, "body: %s", resp.Body)
+	var health struct {
+		Status  string `json:"status"`
+		Version string `json:"version"`
+	}
+	require.NoError(t, resp.JSON(&health))
+	assert.Equal(t, "ok", health.Status)
+
+	server, err := compat.Current(ctx)
+	require.NoError(t, err)
+	if server.Version != "" {
+		assert.Equal(t, server.Version, health.Version, "The health version should match systemInfo.version")
+	}
+
+	resp, err = client.Get(ctx, "/health/no-such-check")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestFixtureDefinitionUpload(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	client := rest.NewClient("")
+	gql := graphql.NewClient("")
+	probe := rest.RequireEndpoint(t, client, http.MethodPost, "/api/fixture-definitions", definitionUploadContract)
+
+	t.Run("MissingFile", func(t *testing.T) {
+		requireJSONError(t, probe, http.StatusBadRequest)
+	})
+
+	t.Run("MalformedFile", func(t *testing.T) {
+		resp, err := client.PostFile(ctx, "/api/fixture-definitions", "file", "broken.json", []byte(`{"manufacturer": `))
+		require.NoError(t, err)
+		requireJSONError(t, resp, http.StatusBadRequest)
+	})
+
+	manufacturer := fmt.Sprintf("REST Upload %d", time.Now().UnixNano())
+	definition, err := json.Marshal(map[string]interface{}{
+		"manufacturer": manufacturer,
+		"model":        "Uploaded Par",
+		"type":         "LED_PAR",
+		"channels":     fixtures.ParChannels,
+	})
+	require.NoError(t, err)
+
+	resp, err := client.PostFile(ctx, "/api/fixture-definitions", "file", "uploaded-par.json", definition)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode, "body: %s", resp.Body)
+	var created struct {
+		ID           string `json:"id"`
+		Manufacturer string `json:"manufacturer"`
+		Model        string `json:"model"`
+	}
+	require.NoError(t, resp.JSON(&created))
+	require.NotEmpty(t, created.ID)
+	defer func() { _ = fixtures.DeleteFixtureDefinition(ctx, gql, created.ID) }()
+	assert.Equal(t, manufacturer, created.Manufacturer)
+	assert.Equal(t, "Uploaded Par", created.Model)
+
+	t.Run("MatchesGraphQL", func(t *testing.T) {
+		var defResp struct {
+			FixtureDefinition struct {
+				Manufacturer string `json:"manufacturer"`
+				Model        string `json:"model"`
+				Type         string `json:"type"`
+				Channels     []struct {
+					Name   string `json:"name"`
+					Type   string `json:"type"`
+					Offset int    `json:"offset"`
+				} `json:"channels"`
+			} `json:"fixtureDefinition"`
+		}
+		err := gql.Query(ctx, `
+			query GetFixtureDefinition($id: ID!) {
+				fixtureDefinition(id: $id) { manufacturer model type channels { name type offset } }
+			}
+		`, map[string]interface{}{"id": created.ID}, &defResp)
+		require.NoError(t, err)
+
+		def := defResp.FixtureDefinition
+		assert.Equal(t, manufacturer, def.Manufacturer)
+		assert.Equal(t, "Uploaded Par", def.Model)
+		assert.Equal(t, "LED_PAR", def.Type)
+		require.Len(t, def.Channels, len(fixtures.ParChannels))
+		for i, want := range fixtures.ParChannels {
+			assert.Equal(t, want["name"], def.Channels[i].Name)
+			assert.Equal(t, want["type"], def.Channels[i].Type)
+			assert.Equal(t, want["offset"], def.Channels[i].Offset)
+		}
+	})
+
+	t.Run("DuplicateModel", func(t *testing.T) {
+		resp, err := client.PostFile(ctx, "/api/fixture-definitions", "file", "uploaded-par.json", definition)
+		require.NoError(t, err)
+		requireJSONError(t, resp, http.StatusConflict)
+	})
+}
+
+func TestProjectExportDownload(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	client := rest.NewClient("")
+	gql := graphql.NewClient("")
+
+	definitionID, err := fixtures.CreateParDefinition(ctx, gql, "REST Export", fmt.Sprintf("Export Par %d", time.Now().UnixNano()))
+	require.NoError(t, err)
+	projectID, err := fixtures.CreateProject(ctx, gql, "REST Export Project")
+	require.NoError(t, err)
+	defer func() {
+		_ = fixtures.DeleteProject(ctx, gql, projectID)
+		_ = fixtures.DeleteFixtureDefinition(ctx, gql, definitionID)
+	}()
+	fixtureID, err := fixtures.CreateFixture(ctx, gql, projectID, definitionID, fixtures.Fixture{Name: "Export Par", Universe: 1, StartChannel: 1})
+	require.NoError(t, err)
+	_, err = fixtures.CreateLook(ctx, gql, projectID, "Export Look", []fixtures.FixtureValues{{FixtureID: fixtureID, Values: []int{255, 0, 0, 0}}})
+	require.NoError(t, err)
+
+	resp := rest.RequireEndpoint(t, client, http.MethodGet, "/api/projects/"+projectID+"/export", exportDownloadContract)
+	require.Equal(t, http.StatusOK, resp.StatusCode, "body: %s", resp.Body)
+	assert.True(t, strings.HasPrefix(resp.Header.Get("Content-Disposition"), "attachment"),
+		"The export should download as a file: %q", resp.Header.Get("Content-Disposition"))
+	assert.True(t, strings.HasSuffix(resp.Filename(), ".json"), "Download file name %q should end in .json", resp.Filename())
+
+	var downloaded map[string]json.RawMessage
+	require.NoError(t, resp.JSON(&downloaded))
+
+	t.Run("MatchesGraphQL", func(t *testing.T) {
+		var exportResp struct {
+			ExportProject struct {
+				JSONContent string `json:"jsonContent"`
+			} `json:"exportProject"`
+		}
+		err := gql.Mutate(ctx, `
+			mutation ExportProject($projectId: ID!) {
+				exportProject(projectId: $projectId) { jsonContent }
+			}
+		`, map[string]interface{}{"projectId": projectID}, &exportResp)
+		require.NoError(t, err)
+		var exported map[string]json.RawMessage
+		require.NoError(t, json.Unmarshal([]byte(exportResp.ExportProject.JSONContent), &exported))
+
+		// Export metadata such as timestamps may differ; the entity sections may not
+		for key, want := range exported {
+			got, ok := downloaded[key]
+			if !assert.True(t, ok, "The download is missing %q", key) {
+				continue
+			}
+			var list []json.RawMessage
+			if json.Unmarshal(want, &list) == nil {
+				assert.JSONEq(t, string(want), string(got), "%q should match exportProject", key)
+			}
+		}
+	})
+
+	t.Run("UnknownProject", func(t *testing.T) {
+		resp, err := client.Get(ctx, "/api/projects/00000000-0000-0000-0000-000000000000/export")
+		require.NoError(t, err)
+		requireJSONError(t, resp, http.StatusNotFound)
+	})
+}
@@ -0,0 +1,346 @@
+// Package scheduler provides time-of-day scheduling contract tests.
+// These tests cover scheduled look activations (one-shot and cron-style) and how
+// schedules behave across timezones and daylight saving transitions.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	_ "github.com/bbernstein/lacylights-test/pkg/artifacts"
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// scheduleLead is how far in the future the one-shot schedule fires
+	scheduleLead = 3 * time.Second
+
+	// scheduleTolerance is how late a scheduled activation may land on the wire
+	scheduleTolerance = 1500 * time.Millisecond
+
+	// scheduleContract is the API shape this suite expects
+	scheduleContract = `createSchedule(input: CreateScheduleInput!): Schedule!
+  CreateScheduleInput { projectId: ID!, name: String!, lookId: ID!, fadeTime: Float,
+                        runAt: DateTime, cron: String, timezone: String, enabled: Boolean }
+  Schedule { id, name, runAt, cron, timezone, enabled, nextRunAt: DateTime, lastRunAt: DateTime }
+  deleteSchedule(id: ID!): Boolean!`
+
+	// occurrencesContract is the pure query used for timezone and DST checks
+	occurrencesContract = `scheduleOccurrences(cron: String!, timezone: String!, from: DateTime!, count: Int!): [DateTime!]!`
+)
+
+// schedulerFixture is a project with a single dimmer at universe 1 channel 1.
+type schedulerFixture struct {
+	projectID    string
+	definitionID string
+	lookID       string
+}
+
+// newSchedulerFixture creates the project, a dimmer, and a look driving it to level.
+func newSchedulerFixture(t *testing.T, client *graphql.Client, ctx context.Context, level int) *schedulerFixture {
+	var projectResp struct {
+		CreateProject struct {
+			ID string `json:"id"`
+		} `json:"createProject"`
+	}
+	err := client.Mutate(ctx, `
+		mutation CreateProject($input: CreateProjectInput!) {
+			createProject(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{"name": "Scheduler Test Project"},
+	}, &projectResp)
+	require.NoError(t, err)
+	f := &schedulerFixture{projectID: projectResp.CreateProject.ID}
+
+	var defResp struct {
+		CreateFixtureDefinition struct {
+			ID string `json:"id"`
+		} `json:"createFixtureDefinition"`
+	}
+	err = client.Mutate(ctx, `
+		mutation CreateFixtureDefinition($input: CreateFixtureDefinitionInput!) {
+			createFixtureDefinition(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"manufacturer": "Test Scheduler",
+			"model":        fmt.Sprintf("Scheduler Dimmer %d", time.Now().UnixNano()),
+			"type":         "DIMMER",
+			"channels": []map[string]interface{}{
+				{"name": "Dimmer", "type": "INTENSITY", "offset": 0, "minValue": 0, "maxValue": 255, "defaultValue": 0},
+			},
+		},
+	}, &defResp)
+	require.NoError(t, err)
+	f.definitionID = defResp.CreateFixtureDefinition.ID
+
+	var fixtureResp struct {
+		CreateFixtureInstance struct {
+			ID string `json:"id"`
+		} `json:"createFixtureInstance"`
+	}
+	err = client.Mutate(ctx, `
+		mutation CreateFixtureInstance($input: CreateFixtureInstanceInput!) {
+			createFixtureInstance(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId":    f.projectID,
+			"definitionId": f.definitionID,
+			"name":         "Scheduler Dimmer",
+			"universe":     1,
+			"startChannel": 1,
+		},
+	}, &fixtureResp)
+	require.NoError(t, err)
+
+	var lookResp struct {
+		CreateLook struct {
+			ID string `json:"id"`
+		} `json:"createLook"`
+	}
+	err = client.Mutate(ctx, `
+		mutation CreateLook($input: CreateLookInput!) {
+			createLook(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId": f.projectID,
+			"name":      "Scheduled Look",
+			"fixtureValues": []map[string]interface{}{
+				{"fixtureId": fixtureResp.CreateFixtureInstance.ID, "channels": []map[string]interface{}{{"offset": 0, "value": level}}},
+			},
+		},
+	}, &lookResp)
+	require.NoError(t, err)
+	f.lookID = lookResp.CreateLook.ID
+
+	return f
+}
+
+func (f *schedulerFixture) cleanup(client *graphql.Client) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	_ = client.Mutate(ctx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+	_ = client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
+		map[string]interface{}{"id": f.projectID}, nil)
+	_ = client.Mutate(ctx, `mutation DeleteFixtureDefinition($id: ID!) { deleteFixtureDefinition(id: $id) }`,
+		map[string]interface{}{"id": f.definitionID}, nil)
+}
+
+// ============================================================================
+// Scheduled Activation Tests
+// ============================================================================
+
+// TestScheduledLookActivation schedules a look a few seconds in the future and
+// asserts from captured Art-Net frames that it goes live on time, then that the
+// schedule records the run and a disabled schedule does not fire.
+func TestScheduledLookActivation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping scheduled activation in short mode")
+	}
+	compat.Require(t, compat.ArtNet)
+
+	client := graphql.NewClient("")
+	compat.RequireMutation(t, client, "createSchedule", scheduleContract)
+
+	receiver := artnet.Capture(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	fixture := newSchedulerFixture(t, client, ctx, 255)
+	defer fixture.cleanup(client)
+
+	_ = client.Mutate(ctx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+	time.Sleep(200 * time.Millisecond)
+
+	type schedule struct {
+		ID        string  `json:"id"`
+		Enabled   bool    `json:"enabled"`
+		NextRunAt *string `json:"nextRunAt"`
+		LastRunAt *string `json:"lastRunAt"`
+	}
+	createSchedule := func(t *testing.T, name string, runAt time.Time, enabled bool) schedule {
+		var resp struct {
+			CreateSchedule schedule `json:"createSchedule"`
+		}
+		err := client.Mutate(ctx, `
+			mutation CreateSchedule($input: CreateScheduleInput!) {
+				createSchedule(input: $input) { id enabled nextRunAt lastRunAt }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"projectId": fixture.projectID,
+				"name":      name,
+				"lookId":    fixture.lookID,
+				"fadeTime":  0.0,
+				"runAt":     runAt.UTC().Format(time.RFC3339Nano),
+				"enabled":   enabled,
+			},
+		}, &resp)
+		require.NoError(t, err)
+		return resp.CreateSchedule
+	}
+
+	t.Run("DisabledDoesNotFire", func(t *testing.T) {
+		runAt := time.Now().Add(scheduleLead)
+		sched := createSchedule(t, "Disabled", runAt, false)
+		assert.False(t, sched.Enabled)
+
+		receiver.ClearFrames()
+		time.Sleep(time.Until(runAt) + scheduleTolerance)
+
+		for _, frame := range receiver.GetFrames() {
+			if frame.Universe == 0 && frame.Channels[0] != 0 {
+				t.Fatalf("Disabled schedule lit the dimmer (%d) at %v", frame.Channels[0], frame.Timestamp)
+			}
+		}
+	})
+
+	t.Run("OneShotFiresOnTime", func(t *testing.T) {
+		runAt := time.Now().Add(scheduleLead)
+		sched := createSchedule(t, "One Shot", runAt, true)
+
+		require.NotNil(t, sched.NextRunAt, "An enabled one-shot schedule should report nextRunAt")
+		next, err := time.Parse(time.RFC3339Nano, *sched.NextRunAt)
+		require.NoError(t, err)
+		assert.WithinDuration(t, runAt, next, time.Second, "nextRunAt should echo runAt")
+
+		receiver.ClearFrames()
+		deadline := runAt.Add(scheduleTolerance + time.Second)
+		var litAt time.Time
+		for litAt.IsZero() && time.Now().Before(deadline) {
+			for _, frame := range receiver.GetFrames() {
+				if frame.Universe == 0 && frame.Channels[0] == 255 {
+					litAt = frame.Timestamp
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		require.False(t, litAt.IsZero(), "Scheduled look never went live")
+
+		offset := litAt.Sub(runAt)
+		t.Logf("Scheduled activation landed %v after runAt", offset)
+		assert.GreaterOrEqual(t, offset, -100*time.Millisecond, "Schedule fired early")
+		assert.LessOrEqual(t, offset, scheduleTolerance, "Schedule fired late")
+
+		var readResp struct {
+			Schedule schedule `json:"schedule"`
+		}
+		err = client.Query(ctx, `
+			query GetSchedule($id: ID!) {
+				schedule(id: $id) { id enabled nextRunAt lastRunAt }
+			}
+		`, map[string]interface{}{"id": sched.ID}, &readResp)
+		require.NoError(t, err)
+		assert.NotNil(t, readResp.Schedule.LastRunAt, "A fired schedule should record lastRunAt")
+		assert.Nil(t, readResp.Schedule.NextRunAt, "A one-shot schedule has no next run once fired")
+	})
+}
+
+// ============================================================================
+// Timezone and DST Tests
+// ============================================================================
+
+// occurrences asks the server for the next count firings of a cron expression.
+func occurrences(t *testing.T, client *graphql.Client, cron, timezone string, from time.Time, count int) []time.Time {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var resp struct {
+		ScheduleOccurrences []string `json:"scheduleOccurrences"`
+	}
+	err := client.Query(ctx, `
+		query ScheduleOccurrences($cron: String!, $timezone: String!, $from: DateTime!, $count: Int!) {
+			scheduleOccurrences(cron: $cron, timezone: $timezone, from: $from, count: $count)
+		}
+	`, map[string]interface{}{
+		"cron":     cron,
+		"timezone": timezone,
+		"from":     from.UTC().Format(time.RFC3339),
+		"count":    count,
+	}, &resp)
+	require.NoError(t, err)
+
+	times := make([]time.Time, 0, len(resp.ScheduleOccurrences))
+	for _, raw := range resp.ScheduleOccurrences {
+		ts, err := time.Parse(time.RFC3339Nano, raw)
+		require.NoError(t, err, "occurrence %q should be RFC 3339", raw)
+		times = append(times, ts)
+	}
+	return times
+}
+
+// TestScheduleTimezoneAndDST checks cron evaluation in named timezones, including
+// both US daylight saving transitions in 2026 (spring forward March 8, fall back
+// November 1). Occurrences are computed server-side from an explicit start time,
+// so no clock mocking is needed.
+func TestScheduleTimezoneAndDST(t *testing.T) {
+	client := graphql.NewClient("")
+	compat.RequireQuery(t, client, "scheduleOccurrences", occurrencesContract)
+
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+
+	t.Run("LocalNoonInTwoZones", func(t *testing.T) {
+		from := time.Date(2026, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+		ny := occurrences(t, client, "0 12 * * *", "America/New_York", from, 3)
+		require.Len(t, ny, 3)
+		for i, ts := range ny {
+			want := time.Date(2026, time.June, 1+i, 12, 0, 0, 0, newYork)
+			assert.True(t, want.Equal(ts), "New York occurrence %d: want %v, got %v", i, want, ts.In(newYork))
+		}
+
+		tk := occurrences(t, client, "0 12 * * *", "Asia/Tokyo", from, 1)
+		require.Len(t, tk, 1)
+		assert.True(t, time.Date(2026, time.June, 1, 12, 0, 0, 0, tokyo).Equal(tk[0]),
+			"Tokyo noon should be evaluated in Tokyo time, got %v", tk[0].In(tokyo))
+	})
+
+	t.Run("SpringForwardGap", func(t *testing.T) {
+		// 02:30 does not exist on March 8; the schedule must neither vanish nor double up.
+		// Starting on March 6 puts the gap day between two ordinary occurrences.
+		from := time.Date(2026, time.March, 6, 12, 0, 0, 0, newYork)
+		got := occurrences(t, client, "30 2 * * *", "America/New_York", from, 3)
+		require.Len(t, got, 3)
+
+		for i := 1; i < len(got); i++ {
+			assert.True(t, got[i].After(got[i-1]), "Occurrences must be strictly increasing: %v", got)
+		}
+		assert.True(t, time.Date(2026, time.March, 7, 2, 30, 0, 0, newYork).Equal(got[0]),
+			"First occurrence should be 02:30 on March 7, got %v", got[0].In(newYork))
+		assert.True(t, time.Date(2026, time.March, 9, 2, 30, 0, 0, newYork).Equal(got[2]),
+			"Third occurrence should be back to 02:30 on March 9, got %v", got[2].In(newYork))
+
+		gapDay := got[1].In(newYork)
+		assert.Equal(t, 8, gapDay.Day(), "The gap day should still fire once, got %v", gapDay)
+		t.Logf("Contract: 02:30 on the spring-forward day fires at %s", gapDay.Format("15:04 MST"))
+	})
+
+	t.Run("FallBackOverlap", func(t *testing.T) {
+		// 01:30 happens twice on November 1; the schedule must fire exactly once that day
+		from := time.Date(2026, time.October, 31, 12, 0, 0, 0, newYork)
+		got := occurrences(t, client, "30 1 * * *", "America/New_York", from, 3)
+		require.Len(t, got, 3)
+
+		perDay := map[int]int{}
+		for _, ts := range got {
+			perDay[ts.In(newYork).Day()]++
+		}
+		assert.Equal(t, 1, perDay[1], "01:30 should fire once on the fall-back day, got %v", got)
+		assert.Equal(t, 1, perDay[2], "01:30 should fire once the day after fall-back, got %v", got)
+		t.Logf("Contract: 01:30 on the fall-back day fires at %s", got[0].In(newYork).Format("15:04 MST"))
+	})
+}
@@ -0,0 +1,142 @@
+package settings
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unicastTargetsKey lists Art-Net unicast destinations; empty means broadcast.
+const unicastTargetsKey = "artnet_unicast_targets"
+
+// unicastContract is the destination setting this suite expects.
+const unicastContract = `setting "artnet_unicast_targets": comma-separated IPv4 addresses sent to on the Art-Net port,
+  empty for broadcast; takes effect without a restart`
+
+const (
+	// nodeA and nodeB are loopback addresses standing in for two Art-Net nodes
+	nodeA = "127.0.0.2"
+	nodeB = "127.0.0.3"
+
+	// destinationSettle is how long a destination change gets to take effect
+	destinationSettle = 1 * time.Second
+
+	destinationCapture = 1 * time.Second
+)
+
+func setSetting(t *testing.T, client *graphql.Client, key, value string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := client.Mutate(ctx, `
+		mutation UpdateSetting($input: UpdateSettingInput!) {
+			updateSetting(input: $input) { key value }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{"key": key, "value": value},
+	}, nil)
+	require.NoError(t, err)
+}
+
+func getSetting(t *testing.T, client *graphql.Client, key string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var resp struct {
+		Setting *struct {
+			Value string `json:"value"`
+		} `json:"setting"`
+	}
+	err := client.Query(ctx, `
+		query GetSetting($key: String!) {
+			setting(key: $key) { value }
+		}
+	`, map[string]interface{}{"key": key}, &resp)
+	require.NoError(t, err)
+	require.NotNil(t, resp.Setting)
+	return resp.Setting.Value
+}
+
+// startNode binds a receiver to one address on the capture port, skipping
+// where the address cannot be bound.
+func startNode(t *testing.T, host string) *artnet.Receiver {
+	_, port, err := net.SplitHostPort(artnet.ListenAddrFromEnv())
+	require.NoError(t, err)
+	receiver := artnet.NewReceiver(net.JoinHostPort(host, port))
+	if err := receiver.Start(); err != nil {
+		t.Skipf("Could not bind Art-Net receiver to %s (needs the 127.0.0.0/8 loopback range): %v", host, err)
+	}
+	t.Cleanup(func() { _ = receiver.Stop() })
+	return receiver
+}
+
+// TestArtNetUnicastDestinations points Art-Net at one node, then the other,
+// then both, and finally back to broadcast, without restarting the server.
+// Each receiver is bound to a single address, so frames only arrive where the
+// server actually sent them; after the switch back, the usual capture must see
+// broadcast frames again.
+func TestArtNetUnicastDestinations(t *testing.T) {
+	compat.Require(t, compat.ArtNet)
+
+	client := graphql.NewClient("")
+	compat.RequireSetting(t, client, unicastTargetsKey, unicastContract)
+
+	original := getSetting(t, client, unicastTargetsKey)
+	t.Cleanup(func() { setSetting(t, client, unicastTargetsKey, original) })
+
+	a := startNode(t, nodeA)
+	b := startNode(t, nodeB)
+
+	// captured switches the destinations and reports which nodes then receive frames
+	captured := func(t *testing.T, targets string) (bool, bool) {
+		setSetting(t, client, unicastTargetsKey, targets)
+		time.Sleep(destinationSettle)
+
+		a.ClearFrames()
+		b.ClearFrames()
+		time.Sleep(destinationCapture)
+		gotA, gotB := len(a.GetFrames()) > 0, len(b.GetFrames()) > 0
+		t.Logf("targets %q: node A %d frames, node B %d frames", targets, len(a.GetFrames()), len(b.GetFrames()))
+		return gotA, gotB
+	}
+
+	t.Run("SingleTarget", func(t *testing.T) {
+		gotA, gotB := captured(t, nodeA)
+		assert.True(t, gotA, "The configured node should receive Art-Net")
+		assert.False(t, gotB, "An unconfigured node must not receive unicast Art-Net")
+	})
+
+	t.Run("ChangeTakesEffectWithoutRestart", func(t *testing.T) {
+		gotA, gotB := captured(t, nodeB)
+		assert.False(t, gotA, "The previous node should stop receiving")
+		assert.True(t, gotB, "The new node should receive Art-Net")
+	})
+
+	t.Run("MultipleTargets", func(t *testing.T) {
+		gotA, gotB := captured(t, nodeA+","+nodeB)
+		assert.True(t, gotA, "Every configured node should receive Art-Net")
+		assert.True(t, gotB, "Every configured node should receive Art-Net")
+	})
+
+	t.Run("BackToBroadcast", func(t *testing.T) {
+		gotA, gotB := captured(t, "")
+		assert.False(t, gotA, "Clearing the targets should stop unicast to node A")
+		assert.False(t, gotB, "Clearing the targets should stop unicast to node B")
+
+		// Release the nodes so the usual capture can bind its address
+		_ = a.Stop()
+		_ = b.Stop()
+		broadcast := artnet.Capture(t)
+		broadcast.ClearFrames()
+		time.Sleep(destinationCapture)
+		t.Logf("targets \"\": broadcast capture %d frames", len(broadcast.GetFrames()))
+		assert.NotEmpty(t, broadcast.GetFrames(), "Clearing the targets should resume broadcast output")
+	})
+}
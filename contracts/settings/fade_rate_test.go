@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	_ "github.com/bbernstein/lacylights-test/pkg/artifacts"
 	"github.com/bbernstein/lacylights-test/pkg/graphql"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
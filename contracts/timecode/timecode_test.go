@@ -0,0 +1,309 @@
+// Package timecode provides contract tests for cue lists that follow SMPTE
+// timecode: a generator plays Art-Net timecode to the server and the cues are
+// timed on captured Art-Net output against the timecode that was sent.
+package timecode
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	_ "github.com/bbernstein/lacylights-test/pkg/artifacts"
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/bbernstein/lacylights-test/pkg/calibration"
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/fixtures"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/bbernstein/lacylights-test/pkg/timecode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// timecodeContract is the timecode support these tests expect.
+	timecodeContract = `Art-Net ArtTimeCode received at TIMECODE_ADDR drives cue lists armed with
+  setCueListTimecode(cueListId: ID!, enabled: Boolean!): CueList! (CueList.timecodeEnabled: Boolean!);
+  CreateCueInput.timecode and Cue.timecode: String ("HH:MM:SS:FF") is when the cue fires. An armed list
+  needs no startCueList, holds its cue while timecode stops, and on a jump plays the last cue at or
+  before the new timecode`
+
+	// timecodeRate is the generator's rate; 25 fps gives 40ms frames
+	timecodeRate = timecode.EBU
+
+	// timecodeSlack is how long the server may take to act on a timecode
+	// frame, beyond the frame itself and one output frame
+	timecodeSlack = 100 * time.Millisecond
+)
+
+// timecodeCues are the snap cues of the cue list, in order.
+var timecodeCues = []struct {
+	at    string
+	level int
+}{
+	{"00:00:01:00", 60},
+	{"00:00:02:00", 120},
+	{"00:00:03:12", 180},
+}
+
+type timecodeSetup struct {
+	ctx       context.Context
+	client    *graphql.Client
+	generator *timecode.Generator
+	receiver  *artnet.Subscription
+	cueListID string
+
+	// cues are the parsed timecodes of timecodeCues
+	cues []timecode.Timecode
+	// tolerance is how late a cue may land on the wire
+	tolerance time.Duration
+}
+
+// newTimecodeSetup creates a dimmer on universe 1 channel 1 and a cue list
+// of timecodeCues, armed to follow timecode.
+func newTimecodeSetup(t *testing.T) *timecodeSetup {
+	if testing.Short() {
+		t.Skip("Skipping timecode playback in short mode")
+	}
+	compat.Require(t, compat.ArtNet)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	t.Cleanup(cancel)
+
+	client := graphql.NewTestClient(t, "")
+	compat.RequireMutation(t, client, "setCueListTimecode", timecodeContract)
+	compat.RequireTypeField(t, client, "Cue", "timecode", timecodeContract)
+	s := &timecodeSetup{
+		ctx:       ctx,
+		client:    client,
+		generator: timecode.Require(t, timecodeRate, timecodeContract),
+		receiver:  artnet.Capture(t),
+	}
+
+	cal := calibration.Get(t)
+	s.tolerance = timecodeRate.FrameDuration() + cal.FramePeriod() + cal.Duration(timecodeSlack)
+	t.Logf("Timecode at %s, cue tolerance %v (%s)", timecodeRate, s.tolerance, cal)
+
+	definitionID, err := fixtures.CreateParDefinition(ctx, client, "Test Timecode", fmt.Sprintf("Timecode Par %d", time.Now().UnixNano()))
+	require.NoError(t, err)
+	projectID, err := fixtures.CreateProject(ctx, client, "Timecode Test Project")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		cleanupCtx, cleanupCancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cleanupCancel()
+		s.generator.Pause()
+		_ = client.Mutate(cleanupCtx, `mutation Disarm($cueListId: ID!) { setCueListTimecode(cueListId: $cueListId, enabled: false) { id } }`,
+			map[string]interface{}{"cueListId": s.cueListID}, nil)
+		_ = client.Mutate(cleanupCtx, `mutation StopCueList($cueListId: ID!) { stopCueList(cueListId: $cueListId) }`,
+			map[string]interface{}{"cueListId": s.cueListID}, nil)
+		_ = client.Mutate(cleanupCtx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+		_ = fixtures.DeleteProject(cleanupCtx, client, projectID)
+		_ = fixtures.DeleteFixtureDefinition(cleanupCtx, client, definitionID)
+	})
+
+	fixtureID, err := fixtures.CreateFixture(ctx, client, projectID, definitionID,
+		fixtures.Fixture{Name: "Timecode Par", Universe: 1, StartChannel: 1})
+	require.NoError(t, err)
+	s.cueListID, err = fixtures.CreateCueList(ctx, client, projectID, "Timecode Cue List", nil)
+	require.NoError(t, err)
+
+	for i, cue := range timecodeCues {
+		tc, err := timecode.Parse(cue.at, timecodeRate)
+		require.NoError(t, err)
+		s.cues = append(s.cues, tc)
+
+		lookID, err := fixtures.CreateLook(ctx, client, projectID, fmt.Sprintf("Level %d", cue.level),
+			[]fixtures.FixtureValues{{FixtureID: fixtureID, Values: []int{cue.level}}})
+		require.NoError(t, err)
+		var resp struct {
+			CreateCue struct {
+				Timecode *string `json:"timecode"`
+			} `json:"createCue"`
+		}
+		err = client.Mutate(ctx, `
+			mutation CreateCue($input: CreateCueInput!) {
+				createCue(input: $input) { id timecode }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"cueListId":   s.cueListID,
+				"lookId":      lookID,
+				"name":        fmt.Sprintf("Cue %d", i+1),
+				"cueNumber":   float64(i + 1),
+				"fadeInTime":  0.0,
+				"fadeOutTime": 0.0,
+				"timecode":    cue.at,
+			},
+		}, &resp)
+		require.NoError(t, err)
+		require.NotNil(t, resp.CreateCue.Timecode, "The cue should store its timecode")
+		assert.Equal(t, cue.at, *resp.CreateCue.Timecode)
+	}
+
+	_ = client.Mutate(ctx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+	s.arm(t, true)
+	time.Sleep(200 * time.Millisecond)
+	return s
+}
+
+// arm sets whether the cue list follows timecode.
+func (s *timecodeSetup) arm(t *testing.T, enabled bool) {
+	var resp struct {
+		SetCueListTimecode struct {
+			TimecodeEnabled bool `json:"timecodeEnabled"`
+		} `json:"setCueListTimecode"`
+	}
+	err := s.client.Mutate(s.ctx, `
+		mutation Arm($cueListId: ID!, $enabled: Boolean!) {
+			setCueListTimecode(cueListId: $cueListId, enabled: $enabled) { timecodeEnabled }
+		}
+	`, map[string]interface{}{"cueListId": s.cueListID, "enabled": enabled}, &resp)
+	require.NoError(t, err)
+	require.Equal(t, enabled, resp.SetCueListTimecode.TimecodeEnabled)
+}
+
+// litAt returns when the dimmer first output level at or after from, failing
+// the test if it has not by deadline.
+func (s *timecodeSetup) litAt(t *testing.T, level int, from, deadline time.Time) time.Time {
+	t.Helper()
+	for {
+		for _, frame := range s.receiver.GetFrames() {
+			if frame.Universe == 0 && int(frame.Channels[0]) == level && !frame.Timestamp.Before(from) {
+				return frame.Timestamp
+			}
+		}
+		if time.Now().After(deadline) {
+			require.Failf(t, "Cue never fired", "The dimmer did not reach %d by %v", level, deadline.Format("15:04:05.000"))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// expectFired waits for cue i and checks it landed on the wire within a
+// timecode frame before and the tolerance after want.
+func (s *timecodeSetup) expectFired(t *testing.T, i int, want time.Time, event string) time.Time {
+	t.Helper()
+	require.NoError(t, s.generator.Err())
+	at := s.litAt(t, timecodeCues[i].level, want.Add(-s.tolerance), want.Add(s.tolerance+time.Second))
+	offset := at.Sub(want)
+	t.Logf("Cue %d (%s) %s: landed %v after its timecode", i+1, s.cues[i], event, offset)
+	assert.GreaterOrEqual(t, offset, -timecodeRate.FrameDuration(), "Cue %d fired early", i+1)
+	assert.LessOrEqual(t, offset, s.tolerance, "Cue %d fired late", i+1)
+	return at
+}
+
+// holds checks the dimmer output only level for d.
+func (s *timecodeSetup) holds(t *testing.T, level int, d time.Duration, msg string) {
+	t.Helper()
+	from := time.Now()
+	time.Sleep(d)
+	seen := 0
+	for _, frame := range s.receiver.GetFrames() {
+		if frame.Universe != 0 || frame.Timestamp.Before(from) {
+			continue
+		}
+		seen++
+		if int(frame.Channels[0]) != level {
+			assert.Failf(t, msg, "Dimmer output %d at %v, want %d", frame.Channels[0], frame.Timestamp.Format("15:04:05.000"), level)
+			return
+		}
+	}
+	assert.NotZero(t, seen, "No frames captured while holding")
+}
+
+// TestTimecodeFiresCues runs timecode from zero through every cue and checks
+// each fires at its timecode.
+func TestTimecodeFiresCues(t *testing.T) {
+	s := newTimecodeSetup(t)
+
+	start := s.generator.Start(0)
+	for i, tc := range s.cues {
+		s.expectFired(t, i, start.Add(tc.Duration()), "from zero")
+	}
+	s.generator.Pause()
+
+	for _, frame := range s.receiver.GetFrames() {
+		if frame.Universe == 0 && frame.Timestamp.Before(start.Add(s.cues[0].Duration()-timecodeRate.FrameDuration())) {
+			require.Zero(t, frame.Channels[0], "Nothing should play before the first cue's timecode")
+		}
+	}
+}
+
+// TestTimecodePauseResume stops timecode between two cues: the cue list must
+// hold its cue while timecode is stopped, even past the next cue's wall-clock
+// time, and fire the next cue on its timecode once timecode resumes.
+func TestTimecodePauseResume(t *testing.T) {
+	s := newTimecodeSetup(t)
+
+	start := s.generator.Start(0)
+	s.expectFired(t, 0, start.Add(s.cues[0].Duration()), "from zero")
+	time.Sleep(time.Until(start.Add((s.cues[0].Duration() + s.cues[1].Duration()) / 2)))
+	paused := s.generator.Pause()
+	t.Logf("Paused at %s", timecode.At(paused, timecodeRate))
+
+	s.holds(t, timecodeCues[0].level, 2*time.Second, "The cue list should hold while timecode is stopped")
+
+	resumed := s.generator.Resume()
+	s.expectFired(t, 1, resumed.Add(s.cues[1].Duration()-paused), "after resume")
+	s.expectFired(t, 2, resumed.Add(s.cues[2].Duration()-paused), "after resume")
+}
+
+// TestTimecodeJumps locates timecode forward past several cues and back
+// again. Each jump must play the last cue at or before the new timecode, and
+// playback must carry on from there.
+func TestTimecodeJumps(t *testing.T) {
+	s := newTimecodeSetup(t)
+
+	start := s.generator.Start(0)
+	s.expectFired(t, 0, start.Add(s.cues[0].Duration()), "from zero")
+
+	t.Run("Forward", func(t *testing.T) {
+		jumped := s.generator.Locate(s.cues[2].Duration() + 500*time.Millisecond)
+		at := s.expectFired(t, 2, jumped, "on a jump forward")
+
+		skipped := false
+		for _, frame := range s.receiver.GetFrames() {
+			if frame.Universe == 0 && !frame.Timestamp.Before(jumped) && frame.Timestamp.Before(at) &&
+				int(frame.Channels[0]) == timecodeCues[1].level {
+				skipped = true
+			}
+		}
+		if skipped {
+			t.Log("Contract: a jump forward passes through the cues it skips")
+		} else {
+			t.Log("Contract: a jump forward goes straight to the last cue before the new timecode")
+		}
+	})
+
+	t.Run("Backward", func(t *testing.T) {
+		from := s.cues[0].Duration() + 500*time.Millisecond
+		jumped := s.generator.Locate(from)
+		s.expectFired(t, 0, jumped, "on a jump back")
+		s.expectFired(t, 1, jumped.Add(s.cues[1].Duration()-from), "after a jump back")
+	})
+
+	t.Run("BeforeFirstCue", func(t *testing.T) {
+		s.generator.Locate(0)
+		time.Sleep(s.tolerance)
+		level, ok := s.receiver.GetChannelValue(0, 1)
+		require.True(t, ok)
+		if level == 0 {
+			t.Log("Contract: jumping before the first cue clears the cue list's output")
+		} else {
+			assert.Equal(t, timecodeCues[1].level, int(level), "Jumping before the first cue should clear or hold the output")
+			t.Log("Contract: jumping before the first cue holds the current cue")
+		}
+		s.generator.Pause()
+	})
+}
+
+// TestTimecodeDisarmed runs timecode through the first cue of a cue list that
+// is not armed, which must not fire.
+func TestTimecodeDisarmed(t *testing.T) {
+	s := newTimecodeSetup(t)
+	s.arm(t, false)
+
+	start := s.generator.Start(0)
+	time.Sleep(time.Until(start.Add(s.cues[0].Duration())))
+	s.holds(t, 0, s.tolerance+500*time.Millisecond, "A disarmed cue list should ignore timecode")
+	s.generator.Pause()
+}
@@ -0,0 +1,205 @@
+package undo
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// attributionContract is the API shape the attribution tests expect
+	attributionContract = `operationHistory(projectId).operations { clientId: String, userId: String }
+  clientId comes from the X-Client-Id request header, userId from the authenticated user (null without auth)
+  undo/redo never rewrite the attribution of the operation they apply`
+
+	// clientIDHeader carries the originating client's identity
+	clientIDHeader = "X-Client-Id"
+
+	// operationType is the type of operationHistory's operations
+	operationType = "OperationSummary"
+)
+
+type attributedOperation struct {
+	ID          string  `json:"id"`
+	Description string  `json:"description"`
+	Sequence    int     `json:"sequence"`
+	IsCurrent   bool    `json:"isCurrent"`
+	ClientID    *string `json:"clientId"`
+	UserID      *string `json:"userId"`
+}
+
+// attributedHistory returns a project's operations in sequence order.
+func attributedHistory(client *graphql.Client, ctx context.Context, projectID string) ([]attributedOperation, error) {
+	var resp struct {
+		OperationHistory struct {
+			Operations []attributedOperation `json:"operations"`
+		} `json:"operationHistory"`
+	}
+	err := client.Query(ctx, `
+		query GetAttributedHistory($projectId: ID!) {
+			operationHistory(projectId: $projectId) {
+				operations {
+					id
+					description
+					sequence
+					isCurrent
+					clientId
+					userId
+				}
+			}
+		}
+	`, map[string]interface{}{"projectId": projectID}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	ops := resp.OperationHistory.Operations
+	sort.Slice(ops, func(i, j int) bool { return ops[i].Sequence < ops[j].Sequence })
+	return ops, nil
+}
+
+// requireAttribution gates the test on both attribution fields of the
+// operations operationHistory returns.
+func requireAttribution(t *testing.T, client *graphql.Client) {
+	t.Helper()
+	compat.RequireTypeField(t, client, operationType, "clientId", attributionContract)
+	compat.RequireTypeField(t, client, operationType, "userId", attributionContract)
+}
+
+// clientAs returns a client that identifies itself with the given client ID.
+func clientAs(clientID string) *graphql.Client {
+	return graphql.NewClientWithOptions("", graphql.ClientOptions{
+		Headers: map[string]string{clientIDHeader: clientID},
+	})
+}
+
+func clientIDOf(op attributedOperation) string {
+	if op.ClientID == nil {
+		return ""
+	}
+	return *op.ClientID
+}
+
+// TestOperationHistory_Attribution has two clients with different X-Client-Id
+// headers edit the same project and checks that each history entry records the
+// client that made it, and that undo and redo by the other client leave that
+// attribution unchanged.
+func TestOperationHistory_Attribution(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	console := clientAs("console-a")
+	tablet := clientAs("tablet-b")
+	requireAttribution(t, console)
+
+	projectID := createTestProject(t, console, ctx, "Attribution Test")
+	defer deleteTestProject(console, ctx, projectID)
+
+	fixtureID := createTestFixture(t, console, ctx, projectID, "Test Fixture", 1)
+
+	var createResp struct {
+		CreateLook struct {
+			ID string `json:"id"`
+		} `json:"createLook"`
+	}
+	err := console.Mutate(ctx, `
+		mutation CreateLook($input: CreateLookInput!) {
+			createLook(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{
+			"projectId": projectID,
+			"name":      "Attributed Look",
+			"fixtureValues": []map[string]interface{}{
+				{"fixtureId": fixtureID, "channels": []map[string]interface{}{{"offset": 0, "value": 100}}},
+			},
+		},
+	}, &createResp)
+	require.NoError(t, err)
+
+	err = tablet.Mutate(ctx, `
+		mutation UpdateLook($id: ID!, $input: UpdateLookInput!) {
+			updateLook(id: $id, input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"id":    createResp.CreateLook.ID,
+		"input": map[string]interface{}{"name": "Renamed From Tablet"},
+	}, nil)
+	require.NoError(t, err)
+
+	// attributionBySequence snapshots the history as sequence -> client ID
+	attributionBySequence := func(t *testing.T) map[int]string {
+		ops, err := attributedHistory(console, ctx, projectID)
+		require.NoError(t, err)
+		bySequence := make(map[int]string, len(ops))
+		for _, op := range ops {
+			bySequence[op.Sequence] = clientIDOf(op)
+		}
+		return bySequence
+	}
+
+	var before map[int]string
+
+	t.Run("EntriesRecordClient", func(t *testing.T) {
+		ops, err := attributedHistory(console, ctx, projectID)
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, len(ops), 2, "Should have the create and update operations")
+
+		create, update := ops[len(ops)-2], ops[len(ops)-1]
+		assert.Equal(t, "console-a", clientIDOf(create), "Create (%s) should be attributed to the console", create.Description)
+		assert.Equal(t, "tablet-b", clientIDOf(update), "Update (%s) should be attributed to the tablet", update.Description)
+		for _, op := range ops {
+			assert.Contains(t, []string{"console-a", "tablet-b"}, clientIDOf(op),
+				"Every operation so far came from one of the two clients: %s", op.Description)
+			if op.UserID != nil {
+				t.Logf("Operation %q carries user %q", op.Description, *op.UserID)
+			}
+		}
+
+		before = attributionBySequence(t)
+	})
+
+	t.Run("SurvivesUndoRedoChain", func(t *testing.T) {
+		require.NotNil(t, before, "EntriesRecordClient must pass first")
+
+		// The console undoes the tablet's update, then the tablet redoes it
+		require.True(t, undoOnce(t, console, ctx, projectID), "Undo should succeed")
+		assert.Equal(t, before, attributionBySequence(t), "Undo must not rewrite attribution")
+
+		var redoResp struct {
+			Redo struct {
+				Success bool `json:"success"`
+			} `json:"redo"`
+		}
+		err := tablet.Mutate(ctx, `
+			mutation Redo($projectId: ID!) {
+				redo(projectId: $projectId) { success }
+			}
+		`, map[string]interface{}{"projectId": projectID}, &redoResp)
+		require.NoError(t, err)
+		require.True(t, redoResp.Redo.Success, "Redo should succeed")
+		assert.Equal(t, before, attributionBySequence(t), "Redo must not rewrite attribution")
+
+		// Undo twice from different clients: both entries still name their authors
+		require.True(t, undoOnce(t, tablet, ctx, projectID), "Undo should succeed")
+		require.True(t, undoOnce(t, console, ctx, projectID), "Undo should succeed")
+		assert.Equal(t, before, attributionBySequence(t), "A chain of undos must not rewrite attribution")
+	})
+
+	t.Run("AnonymousClientUnattributed", func(t *testing.T) {
+		anonymous := graphql.NewClient("")
+		_ = createTestFixture(t, anonymous, ctx, projectID, "Anonymous Fixture", 2)
+
+		ops, err := attributedHistory(anonymous, ctx, projectID)
+		require.NoError(t, err)
+		require.NotEmpty(t, ops)
+		last := ops[len(ops)-1]
+		assert.Empty(t, clientIDOf(last), "An operation without %s should have no clientId", clientIDHeader)
+	})
+}
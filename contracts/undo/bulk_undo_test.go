@@ -0,0 +1,362 @@
+package undo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bulkDeleteContract is the API shape the bulk delete undo tests expect.
+const bulkDeleteContract = `bulkDeleteLooks(lookIds: [ID!]!): Int! (number of looks deleted)
+  Undo restores the batch either as one operation or as one operation per look`
+
+// undoOnce undoes the latest operation in a project and returns whether it succeeded.
+func undoOnce(t *testing.T, client *graphql.Client, ctx context.Context, projectID string) bool {
+	var resp struct {
+		Undo struct {
+			Success bool    `json:"success"`
+			Message *string `json:"message"`
+		} `json:"undo"`
+	}
+	err := client.Mutate(ctx, `
+		mutation Undo($projectId: ID!) {
+			undo(projectId: $projectId) { success message }
+		}
+	`, map[string]interface{}{"projectId": projectID}, &resp)
+	require.NoError(t, err)
+	if !resp.Undo.Success && resp.Undo.Message != nil {
+		t.Logf("Undo failed with message: %s", *resp.Undo.Message)
+	}
+	return resp.Undo.Success
+}
+
+type lookSummary struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	FixtureValues []struct {
+		Channels []struct {
+			Offset int `json:"offset"`
+			Value  int `json:"value"`
+		} `json:"channels"`
+	} `json:"fixtureValues"`
+}
+
+// projectLooks returns every look in a project, keyed by name.
+func projectLooks(t *testing.T, client *graphql.Client, ctx context.Context, projectID string) map[string]lookSummary {
+	var listResp struct {
+		Looks struct {
+			Looks []struct {
+				ID string `json:"id"`
+			} `json:"looks"`
+		} `json:"looks"`
+	}
+	err := client.Query(ctx, `
+		query ListLooks($projectId: ID!) {
+			looks(projectId: $projectId) { looks { id } }
+		}
+	`, map[string]interface{}{"projectId": projectID}, &listResp)
+	require.NoError(t, err)
+
+	looks := make(map[string]lookSummary, len(listResp.Looks.Looks))
+	for _, l := range listResp.Looks.Looks {
+		var lookResp struct {
+			Look lookSummary `json:"look"`
+		}
+		err := client.Query(ctx, `
+			query GetLook($id: ID!) {
+				look(id: $id) {
+					id
+					name
+					fixtureValues { channels { offset value } }
+				}
+			}
+		`, map[string]interface{}{"id": l.ID}, &lookResp)
+		require.NoError(t, err)
+		looks[lookResp.Look.Name] = lookResp.Look
+	}
+	return looks
+}
+
+type cueWithLook struct {
+	Name      string  `json:"name"`
+	CueNumber float64 `json:"cueNumber"`
+	Look      *struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"look"`
+}
+
+// findCueList returns the cues of the project's cue list with the given name, or nil if there is none.
+func findCueList(t *testing.T, client *graphql.Client, ctx context.Context, projectID, name string) []cueWithLook {
+	var listResp struct {
+		CueLists []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"cueLists"`
+	}
+	err := client.Query(ctx, `
+		query ListCueLists($projectId: ID!) {
+			cueLists(projectId: $projectId) { id name }
+		}
+	`, map[string]interface{}{"projectId": projectID}, &listResp)
+	require.NoError(t, err)
+
+	for _, cl := range listResp.CueLists {
+		if cl.Name != name {
+			continue
+		}
+		var cueListResp struct {
+			CueList struct {
+				Cues []cueWithLook `json:"cues"`
+			} `json:"cueList"`
+		}
+		err := client.Query(ctx, `
+			query GetCueList($id: ID!) {
+				cueList(id: $id) {
+					cues {
+						name
+						cueNumber
+						look { id name }
+					}
+				}
+			}
+		`, map[string]interface{}{"id": cl.ID}, &cueListResp)
+		require.NoError(t, err)
+		cues := cueListResp.CueList.Cues
+		sort.Slice(cues, func(i, j int) bool { return cues[i].CueNumber < cues[j].CueNumber })
+		return cues
+	}
+	return nil
+}
+
+// createCueListWithCues creates a cue list with one cue per look, numbered from 1.
+func createCueListWithCues(t *testing.T, client *graphql.Client, ctx context.Context, projectID, name string, lookIDs []string) {
+	var cueListResp struct {
+		CreateCueList struct {
+			ID string `json:"id"`
+		} `json:"createCueList"`
+	}
+	err := client.Mutate(ctx, `
+		mutation CreateCueList($input: CreateCueListInput!) {
+			createCueList(input: $input) { id }
+		}
+	`, map[string]interface{}{
+		"input": map[string]interface{}{"projectId": projectID, "name": name},
+	}, &cueListResp)
+	require.NoError(t, err)
+
+	for i, lookID := range lookIDs {
+		err := client.Mutate(ctx, `
+			mutation CreateCue($input: CreateCueInput!) {
+				createCue(input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"cueListId":   cueListResp.CreateCueList.ID,
+				"name":        fmt.Sprintf("Cue %d", i+1),
+				"cueNumber":   float64(i + 1),
+				"lookId":      lookID,
+				"fadeInTime":  1.0,
+				"fadeOutTime": 1.0,
+			},
+		}, nil)
+		require.NoError(t, err)
+	}
+}
+
+// createNumberedLooks creates count looks named "Bulk Look N", each setting the fixture to N*10.
+func createNumberedLooks(t *testing.T, client *graphql.Client, ctx context.Context, projectID, fixtureID string, count int) []string {
+	lookIDs := make([]string, count)
+	for i := range lookIDs {
+		var resp struct {
+			CreateLook struct {
+				ID string `json:"id"`
+			} `json:"createLook"`
+		}
+		err := client.Mutate(ctx, `
+			mutation CreateLook($input: CreateLookInput!) {
+				createLook(input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"projectId": projectID,
+				"name":      fmt.Sprintf("Bulk Look %d", i+1),
+				"fixtureValues": []map[string]interface{}{
+					{
+						"fixtureId": fixtureID,
+						"channels":  []map[string]interface{}{{"offset": 0, "value": (i + 1) * 10}},
+					},
+				},
+			},
+		}, &resp)
+		require.NoError(t, err)
+		lookIDs[i] = resp.CreateLook.ID
+	}
+	return lookIDs
+}
+
+// TestUndo_BulkDeleteLooks deletes 10 looks in one call, then undoes until all
+// are back. The server may restore the batch with one undo or need one undo per
+// look; either is accepted, but nothing in between. Restored looks must keep
+// their names and values, and cues that referenced them must point at them again.
+// A server that refuses to delete looks cues still use is tested on the eight
+// unreferenced looks instead. Redoing the same number of steps deletes them again.
+func TestUndo_BulkDeleteLooks(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewClient("")
+	compat.RequireMutation(t, client, "bulkDeleteLooks", bulkDeleteContract)
+
+	projectID := createTestProject(t, client, ctx, "Undo Bulk Delete Looks Test")
+	defer deleteTestProject(client, ctx, projectID)
+
+	fixtureID := createTestFixture(t, client, ctx, projectID, "Test Fixture", 1)
+	lookIDs := createNumberedLooks(t, client, ctx, projectID, fixtureID, 10)
+	createCueListWithCues(t, client, ctx, projectID, "Bulk Cues", lookIDs[:2])
+
+	before := projectLooks(t, client, ctx, projectID)
+	require.Len(t, before, 10)
+
+	bulkDelete := func(ids []string) (int, error) {
+		var resp struct {
+			BulkDeleteLooks int `json:"bulkDeleteLooks"`
+		}
+		err := client.Mutate(ctx, `
+			mutation BulkDeleteLooks($lookIds: [ID!]!) {
+				bulkDeleteLooks(lookIds: $lookIds)
+			}
+		`, map[string]interface{}{"lookIds": ids}, &resp)
+		return resp.BulkDeleteLooks, err
+	}
+
+	deleted := lookIDs
+	count, err := bulkDelete(deleted)
+	if err != nil {
+		t.Logf("Contract: bulk deleting looks referenced by cues is rejected (err: %v)", err)
+		require.Len(t, projectLooks(t, client, ctx, projectID), 10, "A rejected bulk delete must not delete anything")
+
+		deleted = lookIDs[2:]
+		count, err = bulkDelete(deleted)
+		require.NoError(t, err, "Bulk deleting unreferenced looks should succeed")
+	}
+	assert.Equal(t, len(deleted), count)
+	remaining := len(lookIDs) - len(deleted)
+	require.Len(t, projectLooks(t, client, ctx, projectID), remaining, "Deleted looks should be gone after the bulk delete")
+
+	undos := 0
+	for undos < len(deleted) {
+		require.True(t, undoOnce(t, client, ctx, projectID), "Undo %d should succeed", undos+1)
+		undos++
+		if len(projectLooks(t, client, ctx, projectID)) == len(lookIDs) {
+			break
+		}
+	}
+
+	switch undos {
+	case 1:
+		t.Log("Contract: one undo restores the whole bulk delete")
+	case len(deleted):
+		t.Log("Contract: a bulk delete is undone one look at a time")
+	default:
+		t.Errorf("Bulk delete of %d looks took %d undos to restore; expected 1 or %d", len(deleted), undos, len(deleted))
+	}
+
+	after := projectLooks(t, client, ctx, projectID)
+	require.Len(t, after, len(lookIDs), "Every look should be restored")
+	for name, look := range before {
+		restored, ok := after[name]
+		if !assert.True(t, ok, "%s should be restored", name) {
+			continue
+		}
+		assert.Equal(t, look.FixtureValues, restored.FixtureValues, "%s should keep its values", name)
+	}
+
+	t.Run("CuesPointToRestoredLooks", func(t *testing.T) {
+		cues := findCueList(t, client, ctx, projectID, "Bulk Cues")
+		require.Len(t, cues, 2, "Cues should be intact after the looks are restored")
+		for i, cue := range cues {
+			require.NotNil(t, cue.Look, "%s should reference a look", cue.Name)
+			want := fmt.Sprintf("Bulk Look %d", i+1)
+			assert.Equal(t, want, cue.Look.Name)
+			assert.Equal(t, after[want].ID, cue.Look.ID, "%s should point at the restored look", cue.Name)
+		}
+	})
+
+	t.Run("RedoDeletesAgain", func(t *testing.T) {
+		for i := 0; i < undos; i++ {
+			require.True(t, redoOnce(t, client, ctx, projectID), "Redo %d should succeed", i+1)
+		}
+		assert.Len(t, projectLooks(t, client, ctx, projectID), remaining,
+			"Redoing the undos should delete the same looks again")
+	})
+}
+
+// TestUndo_DeleteCueListWithCues deletes a cue list that has cues and undoes
+// until it is back. One undo should restore the list together with its cues,
+// since the delete was a single user action; the restored cues must reference
+// the same looks as before.
+func TestUndo_DeleteCueListWithCues(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewClient("")
+
+	projectID := createTestProject(t, client, ctx, "Undo Delete Cue List Test")
+	defer deleteTestProject(client, ctx, projectID)
+
+	fixtureID := createTestFixture(t, client, ctx, projectID, "Test Fixture", 1)
+	lookIDs := createNumberedLooks(t, client, ctx, projectID, fixtureID, 3)
+	createCueListWithCues(t, client, ctx, projectID, "Doomed Cues", lookIDs)
+
+	var listResp struct {
+		CueLists []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"cueLists"`
+	}
+	err := client.Query(ctx, `
+		query ListCueLists($projectId: ID!) {
+			cueLists(projectId: $projectId) { id name }
+		}
+	`, map[string]interface{}{"projectId": projectID}, &listResp)
+	require.NoError(t, err)
+	require.Len(t, listResp.CueLists, 1)
+
+	err = client.Mutate(ctx, `
+		mutation DeleteCueList($id: ID!) {
+			deleteCueList(id: $id)
+		}
+	`, map[string]interface{}{"id": listResp.CueLists[0].ID}, nil)
+	require.NoError(t, err)
+	require.Nil(t, findCueList(t, client, ctx, projectID, "Doomed Cues"), "Cue list should be gone after delete")
+
+	t.Run("OneUndoRestoresListAndCues", func(t *testing.T) {
+		require.True(t, undoOnce(t, client, ctx, projectID), "Undo should succeed")
+
+		cues := findCueList(t, client, ctx, projectID, "Doomed Cues")
+		require.NotNil(t, cues, "One undo should restore the cue list")
+		assert.Len(t, cues, 3, "One undo should restore every cue with its list")
+	})
+
+	t.Run("RestoredCuesReferenceLooks", func(t *testing.T) {
+		cues := findCueList(t, client, ctx, projectID, "Doomed Cues")
+		require.Len(t, cues, 3)
+		for i, cue := range cues {
+			assert.Equal(t, fmt.Sprintf("Cue %d", i+1), cue.Name)
+			require.NotNil(t, cue.Look, "%s should reference a look", cue.Name)
+			assert.Equal(t, lookIDs[i], cue.Look.ID, "%s should point at the same look as before", cue.Name)
+		}
+	})
+
+	t.Run("LooksUntouched", func(t *testing.T) {
+		assert.Len(t, projectLooks(t, client, ctx, projectID), 3, "Deleting and restoring a cue list must not touch its looks")
+	})
+}
@@ -0,0 +1,443 @@
+package undo
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// defaultModelRuns and modelSteps size the model-based test: how many
+	// random sequences it runs, each in a fresh project, and how long they are
+	defaultModelRuns = 5
+	modelSteps       = 40
+)
+
+type opKind int
+
+const (
+	opCreate opKind = iota
+	opUpdate
+	opDelete
+)
+
+func (k opKind) String() string {
+	return [...]string{"create", "update", "delete"}[k]
+}
+
+// historyOp is one change the history model expects the server to have recorded.
+type historyOp struct {
+	kind     opKind
+	look     string
+	value    int
+	id       string
+	sequence int
+}
+
+// historyModel tracks the undo history a project should have: the operations
+// on the current timeline and how many of them are applied.
+type historyModel struct {
+	ops      []historyOp
+	applied  int
+	recorded int // every operation ever made, including forked-off ones
+}
+
+func (m *historyModel) canUndo() bool { return m.applied > 0 }
+func (m *historyModel) canRedo() bool { return m.applied < len(m.ops) }
+
+// record adds a new operation, dropping any undone ones after it.
+func (m *historyModel) record(op historyOp) {
+	m.ops = append(m.ops[:m.applied], op)
+	m.applied++
+	m.recorded++
+}
+
+// looks returns the value of every look that exists with the applied operations.
+func (m *historyModel) looks() map[string]int {
+	looks := make(map[string]int)
+	for _, op := range m.ops[:m.applied] {
+		if op.kind == opDelete {
+			delete(looks, op.look)
+		} else {
+			looks[op.look] = op.value
+		}
+	}
+	return looks
+}
+
+// liveLooks returns the names of the looks that exist, sorted so a seeded
+// choice between them is reproducible.
+func (m *historyModel) liveLooks() []string {
+	var names []string
+	for name := range m.looks() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// modelRun drives a project's undo history and checks it against a historyModel.
+type modelRun struct {
+	t         *testing.T
+	client    *graphql.Client
+	ctx       context.Context
+	rng       *rand.Rand
+	projectID string
+	fixtureID string
+	model     historyModel
+
+	// keepsForks is whether totalOperations counts forked-off operations, -1 until seen
+	keepsForks int
+
+	// trail lists the actions taken, to explain a failure
+	trail []string
+}
+
+// newModelRun creates a project with one fixture and an empty history.
+func newModelRun(t *testing.T, client *graphql.Client, ctx context.Context, name string, rng *rand.Rand) *modelRun {
+	projectID := createTestProject(t, client, ctx, name)
+	run := &modelRun{t: t, client: client, ctx: ctx, rng: rng, projectID: projectID, keepsForks: -1}
+	run.fixtureID = createTestFixture(t, client, ctx, projectID, "Model Fixture", 1)
+
+	// Start from an empty history so the model owns every operation
+	var clearResp struct {
+		ClearOperationHistory bool `json:"clearOperationHistory"`
+	}
+	err := client.Mutate(ctx, `
+		mutation ClearHistory($projectId: ID!) {
+			clearOperationHistory(projectId: $projectId, confirmClear: true)
+		}
+	`, map[string]interface{}{"projectId": projectID}, &clearResp)
+	require.NoError(t, err)
+	require.True(t, clearResp.ClearOperationHistory)
+	return run
+}
+
+func (r *modelRun) cleanup() {
+	deleteTestProject(r.client, r.ctx, r.projectID)
+}
+
+// failed reports whether the run has failed, logging the actions that led there.
+func (r *modelRun) failed() bool {
+	if !r.t.Failed() {
+		return false
+	}
+	r.t.Logf("Actions: %s", strings.Join(r.trail, ", "))
+	return true
+}
+
+func fetchUndoRedoStatus(t *testing.T, client *graphql.Client, ctx context.Context, projectID string) undoRedoStatus {
+	var resp struct {
+		UndoRedoStatus undoRedoStatus `json:"undoRedoStatus"`
+	}
+	err := client.Query(ctx, `
+		query GetUndoRedoStatus($projectId: ID!) {
+			undoRedoStatus(projectId: $projectId) {
+				canUndo
+				canRedo
+				currentSequence
+				totalOperations
+			}
+		}
+	`, map[string]interface{}{"projectId": projectID}, &resp)
+	require.NoError(t, err)
+	return resp.UndoRedoStatus
+}
+
+func redoOnce(t *testing.T, client *graphql.Client, ctx context.Context, projectID string) bool {
+	var resp struct {
+		Redo struct {
+			Success bool    `json:"success"`
+			Message *string `json:"message"`
+		} `json:"redo"`
+	}
+	err := client.Mutate(ctx, `
+		mutation Redo($projectId: ID!) {
+			redo(projectId: $projectId) { success message }
+		}
+	`, map[string]interface{}{"projectId": projectID}, &resp)
+	require.NoError(t, err)
+	if !resp.Redo.Success && resp.Redo.Message != nil {
+		t.Logf("Redo failed with message: %s", *resp.Redo.Message)
+	}
+	return resp.Redo.Success
+}
+
+// lookID finds a live look by name; redo may restore a look under a new ID.
+func (r *modelRun) lookID(name string) string {
+	look, ok := projectLooks(r.t, r.client, r.ctx, r.projectID)[name]
+	require.True(r.t, ok, "%s should exist", name)
+	return look.ID
+}
+
+// change makes a new change of the given kind, or a create when there is no
+// look to update or delete, and records it.
+func (r *modelRun) change(step int, kind opKind) {
+	live := r.model.liveLooks()
+	if len(live) == 0 {
+		kind = opCreate
+	}
+	op := historyOp{kind: kind, value: 1 + r.rng.Intn(255)}
+	fixtureValues := []map[string]interface{}{
+		{"fixtureId": r.fixtureID, "channels": []map[string]interface{}{{"offset": 0, "value": op.value}}},
+	}
+
+	var err error
+	switch kind {
+	case opCreate:
+		op.look = fmt.Sprintf("Model Look %d", step)
+		err = r.client.Mutate(r.ctx, `
+			mutation CreateLook($input: CreateLookInput!) {
+				createLook(input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"projectId":     r.projectID,
+				"name":          op.look,
+				"fixtureValues": fixtureValues,
+			},
+		}, nil)
+	case opUpdate:
+		op.look = live[r.rng.Intn(len(live))]
+		err = r.client.Mutate(r.ctx, `
+			mutation UpdateLook($id: ID!, $input: UpdateLookInput!) {
+				updateLook(id: $id, input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"id":    r.lookID(op.look),
+			"input": map[string]interface{}{"fixtureValues": fixtureValues},
+		}, nil)
+	case opDelete:
+		op.look = live[r.rng.Intn(len(live))]
+		err = r.client.Mutate(r.ctx, `mutation DeleteLook($id: ID!) { deleteLook(id: $id) }`,
+			map[string]interface{}{"id": r.lookID(op.look)}, nil)
+	}
+	r.trail = append(r.trail, fmt.Sprintf("%s %q", kind, op.look))
+	require.NoError(r.t, err, "step %d: %s %s", step, kind, op.look)
+
+	// The new operation is the current one in the history
+	var resp struct {
+		OperationHistory struct {
+			Operations []struct {
+				ID        string `json:"id"`
+				Sequence  int    `json:"sequence"`
+				IsCurrent bool   `json:"isCurrent"`
+			} `json:"operations"`
+		} `json:"operationHistory"`
+	}
+	err = r.client.Query(r.ctx, `
+		query GetOperationHistory($projectId: ID!) {
+			operationHistory(projectId: $projectId) {
+				operations { id sequence isCurrent }
+			}
+		}
+	`, map[string]interface{}{"projectId": r.projectID}, &resp)
+	require.NoError(r.t, err)
+	for _, o := range resp.OperationHistory.Operations {
+		if o.IsCurrent {
+			op.id, op.sequence = o.ID, o.Sequence
+		}
+	}
+	require.NotEmpty(r.t, op.id, "step %d: no current operation after %s %s", step, kind, op.look)
+	if r.model.applied > 0 {
+		assert.Greater(r.t, op.sequence, r.model.ops[r.model.applied-1].sequence,
+			"step %d: a new operation gets a later sequence", step)
+	}
+	r.model.record(op)
+}
+
+// undo undoes once, which must succeed exactly when the model can undo.
+func (r *modelRun) undo(step int) {
+	r.trail = append(r.trail, "undo")
+	assert.Equal(r.t, r.model.canUndo(), undoOnce(r.t, r.client, r.ctx, r.projectID), "step %d: undo success", step)
+	if r.model.canUndo() {
+		r.model.applied--
+	}
+}
+
+// redo redoes once, which must succeed exactly when the model can redo.
+func (r *modelRun) redo(step int) {
+	r.trail = append(r.trail, "redo")
+	assert.Equal(r.t, r.model.canRedo(), redoOnce(r.t, r.client, r.ctx, r.projectID), "step %d: redo success", step)
+	if r.model.canRedo() {
+		r.model.applied++
+	}
+}
+
+// jump jumps to the state after a random operation on the timeline.
+func (r *modelRun) jump(step int) {
+	if len(r.model.ops) == 0 {
+		r.undo(step)
+		return
+	}
+	target := r.rng.Intn(len(r.model.ops))
+	r.trail = append(r.trail, fmt.Sprintf("jump %d", target+1))
+
+	var resp struct {
+		JumpToOperation struct {
+			Success bool `json:"success"`
+		} `json:"jumpToOperation"`
+	}
+	err := r.client.Mutate(r.ctx, `
+		mutation JumpToOperation($projectId: ID!, $operationId: ID!) {
+			jumpToOperation(projectId: $projectId, operationId: $operationId) { success }
+		}
+	`, map[string]interface{}{"projectId": r.projectID, "operationId": r.model.ops[target].id}, &resp)
+	require.NoError(r.t, err)
+	assert.True(r.t, resp.JumpToOperation.Success, "step %d: jump to operation %d", step, target+1)
+	r.model.applied = target + 1
+}
+
+// checkStatus compares undoRedoStatus with the model.
+func (r *modelRun) checkStatus(step int) {
+	t, m := r.t, &r.model
+	status := fetchUndoRedoStatus(t, r.client, r.ctx, r.projectID)
+	assert.Equal(t, m.canUndo(), status.CanUndo, "step %d: canUndo", step)
+	assert.Equal(t, m.canRedo(), status.CanRedo, "step %d: canRedo", step)
+
+	if m.applied > 0 {
+		assert.Equal(t, m.ops[m.applied-1].sequence, status.CurrentSequence,
+			"step %d: currentSequence is the last applied operation", step)
+	} else if len(m.ops) > 0 {
+		assert.Less(t, status.CurrentSequence, m.ops[0].sequence,
+			"step %d: currentSequence is before the first operation", step)
+	}
+
+	if m.recorded == len(m.ops) {
+		assert.Equal(t, len(m.ops), status.TotalOperations, "step %d: totalOperations", step)
+		return
+	}
+	// After a fork, the forked-off operations may be dropped or kept
+	if r.keepsForks < 0 {
+		switch status.TotalOperations {
+		case len(m.ops):
+			r.keepsForks = 0
+			t.Logf("Contract: forking the timeline drops the undone operations from totalOperations")
+		case m.recorded:
+			r.keepsForks = 1
+			t.Logf("Contract: totalOperations keeps counting operations lost to a fork")
+		default:
+			t.Errorf("step %d: totalOperations is %d, expected %d (timeline) or %d (every operation)",
+				step, status.TotalOperations, len(m.ops), m.recorded)
+			return
+		}
+	}
+	want := len(m.ops)
+	if r.keepsForks == 1 {
+		want = m.recorded
+	}
+	assert.Equal(t, want, status.TotalOperations, "step %d: totalOperations", step)
+}
+
+// checkLooks compares the project's looks with the model: the same names,
+// each once, with the values the applied operations gave them.
+func (r *modelRun) checkLooks(step int) {
+	t := r.t
+	var listResp struct {
+		Looks struct {
+			Looks []struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"looks"`
+		} `json:"looks"`
+	}
+	err := r.client.Query(r.ctx, `
+		query ListLooks($projectId: ID!) {
+			looks(projectId: $projectId) { looks { id name } }
+		}
+	`, map[string]interface{}{"projectId": r.projectID}, &listResp)
+	require.NoError(t, err)
+
+	ids := make(map[string]bool)
+	names := make(map[string]bool)
+	for _, look := range listResp.Looks.Looks {
+		assert.False(t, ids[look.ID], "step %d: look %s is listed twice", step, look.ID)
+		assert.False(t, names[look.Name], "step %d: there are two looks named %q", step, look.Name)
+		ids[look.ID] = true
+		names[look.Name] = true
+	}
+
+	want := r.model.looks()
+	looks := projectLooks(t, r.client, r.ctx, r.projectID)
+	assert.Len(t, looks, len(want), "step %d: the project should have exactly the looks the applied operations leave", step)
+	for name, value := range want {
+		look, ok := looks[name]
+		if !assert.True(t, ok, "step %d: %s should exist", step, name) {
+			continue
+		}
+		require.NotEmpty(t, look.FixtureValues, name)
+		require.NotEmpty(t, look.FixtureValues[0].Channels, name)
+		assert.Equal(t, value, look.FixtureValues[0].Channels[0].Value, "step %d: %s value", step, name)
+	}
+}
+
+// TestUndoRedo_ModelBased runs random sequences of creates, updates and
+// deletes of looks, undos, redos and jumps, and after every step checks the
+// undo/redo status and the project's looks against an in-memory model of the
+// history. Set UNDO_MODEL_RUNS to change the number of sequences and
+// UNDO_MODEL_SEED to reproduce a failing run.
+func TestUndoRedo_ModelBased(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping model-based undo test in short mode")
+	}
+
+	runs := defaultModelRuns
+	if raw := os.Getenv("UNDO_MODEL_RUNS"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		require.NoError(t, err, "UNDO_MODEL_RUNS must be an integer")
+		runs = n
+	}
+
+	seed := time.Now().UnixNano()
+	if raw := os.Getenv("UNDO_MODEL_SEED"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		require.NoError(t, err, "UNDO_MODEL_SEED must be an integer")
+		seed = n
+	}
+	t.Logf("UNDO_MODEL_SEED=%d", seed)
+	rng := rand.New(rand.NewSource(seed))
+
+	client := graphql.NewClient("")
+
+	for i := 0; i < runs; i++ {
+		t.Run(fmt.Sprintf("Run%d", i+1), func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+			defer cancel()
+
+			run := newModelRun(t, client, ctx, fmt.Sprintf("Undo Model Test %d", i+1), rng)
+			defer run.cleanup()
+
+			for step := 1; step <= modelSteps; step++ {
+				switch p := rng.Intn(100); {
+				case p < 20:
+					run.change(step, opCreate)
+				case p < 35:
+					run.change(step, opUpdate)
+				case p < 45:
+					run.change(step, opDelete)
+				case p < 70:
+					run.undo(step)
+				case p < 90:
+					run.redo(step)
+				default:
+					run.jump(step)
+				}
+				run.checkStatus(step)
+				run.checkLooks(step)
+				if run.failed() {
+					return
+				}
+			}
+		})
+	}
+}
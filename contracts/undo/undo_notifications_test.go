@@ -0,0 +1,182 @@
+package undo
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/compat"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/bbernstein/lacylights-test/pkg/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// undoNotificationsContract is the API shape the notification tests expect
+	undoNotificationsContract = `subscription undoRedoStatusChanged(projectId: ID!): UndoRedoStatus!
+  pushed after every operation, undo, redo and clear in that project`
+
+	// notificationTimeout is how long a pushed status may take to arrive
+	notificationTimeout = 2 * time.Second
+
+	undoRedoStatusSubscription = `
+		subscription UndoRedoStatusChanged($projectId: ID!) {
+			undoRedoStatusChanged(projectId: $projectId) {
+				canUndo
+				canRedo
+				currentSequence
+				totalOperations
+			}
+		}
+	`
+)
+
+type undoRedoStatus struct {
+	CanUndo         bool `json:"canUndo"`
+	CanRedo         bool `json:"canRedo"`
+	CurrentSequence int  `json:"currentSequence"`
+	TotalOperations int  `json:"totalOperations"`
+}
+
+// nextStatus waits for the next pushed undo/redo status, returning nil on timeout.
+func nextStatus(t *testing.T, ch <-chan *websocket.Message, timeout time.Duration) *undoRedoStatus {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			return nil
+		case msg, ok := <-ch:
+			require.True(t, ok, "Subscription closed unexpectedly")
+			require.NotEqual(t, websocket.Error, msg.Type, "Subscription error: %s", string(msg.Payload))
+			if msg.Type != websocket.Next {
+				continue
+			}
+
+			var payload struct {
+				Data struct {
+					UndoRedoStatusChanged undoRedoStatus `json:"undoRedoStatusChanged"`
+				} `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(msg.Payload, &payload))
+			return &payload.Data.UndoRedoStatusChanged
+		}
+	}
+}
+
+// subscribeUndoStatus opens a second client subscribed to a project's undo/redo status.
+func subscribeUndoStatus(t *testing.T, ctx context.Context, projectID string) (*websocket.Client, <-chan *websocket.Message) {
+	ws := websocket.NewClient("")
+	if err := ws.Connect(ctx); err != nil {
+		t.Skipf("Skipping: cannot open WebSocket connection: %v", err)
+	}
+
+	ch, _, err := ws.Subscribe(ctx, undoRedoStatusSubscription, map[string]interface{}{"projectId": projectID})
+	require.NoError(t, err)
+	return ws, ch
+}
+
+// TestUndoRedo_StatusNotifications subscribes a second client to a project's
+// undo/redo status and checks that every mutation, undo and redo made by the
+// first client is pushed to it with the right canUndo/canRedo flags.
+func TestUndoRedo_StatusNotifications(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	client := graphql.NewClient("")
+	compat.RequireSubscription(t, client, "undoRedoStatusChanged", undoNotificationsContract)
+
+	projectID := createTestProject(t, client, ctx, "Undo Notifications Test")
+	defer deleteTestProject(client, ctx, projectID)
+
+	fixtureID := createTestFixture(t, client, ctx, projectID, "Test Fixture", 1)
+
+	ws, ch := subscribeUndoStatus(t, ctx, projectID)
+	defer func() { _ = ws.Close() }()
+
+	// Drain anything pushed on subscribe so the next status belongs to our mutation
+	for nextStatus(t, ch, 300*time.Millisecond) != nil {
+	}
+
+	t.Run("MutationPushesCanUndo", func(t *testing.T) {
+		err := client.Mutate(ctx, `
+			mutation CreateLook($input: CreateLookInput!) {
+				createLook(input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"projectId": projectID,
+				"name":      "Notified Look",
+				"fixtureValues": []map[string]interface{}{
+					{"fixtureId": fixtureID, "channels": []map[string]interface{}{{"offset": 0, "value": 200}}},
+				},
+			},
+		}, nil)
+		require.NoError(t, err)
+
+		status := nextStatus(t, ch, notificationTimeout)
+		require.NotNil(t, status, "Second client should be notified of the new operation")
+		assert.True(t, status.CanUndo)
+		assert.False(t, status.CanRedo)
+	})
+
+	t.Run("UndoPushesCanRedo", func(t *testing.T) {
+		require.True(t, undoOnce(t, client, ctx, projectID), "Undo should succeed")
+
+		status := nextStatus(t, ch, notificationTimeout)
+		require.NotNil(t, status, "Second client should be notified of the undo")
+		assert.True(t, status.CanRedo)
+	})
+
+	t.Run("RedoClearsCanRedo", func(t *testing.T) {
+		var redoResp struct {
+			Redo struct {
+				Success bool `json:"success"`
+			} `json:"redo"`
+		}
+		err := client.Mutate(ctx, `
+			mutation Redo($projectId: ID!) {
+				redo(projectId: $projectId) { success }
+			}
+		`, map[string]interface{}{"projectId": projectID}, &redoResp)
+		require.NoError(t, err)
+		require.True(t, redoResp.Redo.Success, "Redo should succeed")
+
+		status := nextStatus(t, ch, notificationTimeout)
+		require.NotNil(t, status, "Second client should be notified of the redo")
+		assert.True(t, status.CanUndo)
+		assert.False(t, status.CanRedo)
+	})
+
+	t.Run("MatchesQueriedStatus", func(t *testing.T) {
+		var statusResp struct {
+			UndoRedoStatus undoRedoStatus `json:"undoRedoStatus"`
+		}
+		err := client.Query(ctx, `
+			query GetUndoRedoStatus($projectId: ID!) {
+				undoRedoStatus(projectId: $projectId) {
+					canUndo
+					canRedo
+					currentSequence
+					totalOperations
+				}
+			}
+		`, map[string]interface{}{"projectId": projectID}, &statusResp)
+		require.NoError(t, err)
+
+		assert.Nil(t, nextStatus(t, ch, 300*time.Millisecond), "Reading the status must not push a notification")
+		assert.True(t, statusResp.UndoRedoStatus.CanUndo)
+		assert.False(t, statusResp.UndoRedoStatus.CanRedo)
+	})
+
+	t.Run("OtherProjectNotPushed", func(t *testing.T) {
+		otherID := createTestProject(t, client, ctx, "Undo Notifications Other Project")
+		defer deleteTestProject(client, ctx, otherID)
+		createTestFixture(t, client, ctx, otherID, "Other Fixture", 1)
+
+		assert.Nil(t, nextStatus(t, ch, time.Second), "Changes in another project must not be pushed to this subscription")
+	})
+}
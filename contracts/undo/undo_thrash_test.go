@@ -0,0 +1,77 @@
+package undo
+
+import (
+	"context"
+	"math/rand"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// thrashSteps is how many undo, redo or fork steps the thrash test takes
+	thrashSteps = 200
+
+	// thrashForkChance is the chance a step makes a new change instead of undoing or redoing
+	thrashForkChance = 0.1
+)
+
+// TestUndoRedo_Thrash alternates 200 undos and redos, now and then making a
+// new change that forks the timeline, and checks after every step that the
+// undo/redo status matches an independently kept model of the history. At the
+// end the project's looks must be exactly the ones the model expects, with no
+// duplicates. Set UNDO_THRASH_SEED to reproduce a failing run.
+func TestUndoRedo_Thrash(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+	defer cancel()
+
+	client := graphql.NewClient("")
+
+	seed := time.Now().UnixNano()
+	if raw := os.Getenv("UNDO_THRASH_SEED"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		require.NoError(t, err, "UNDO_THRASH_SEED must be an integer")
+		seed = n
+	}
+	t.Logf("UNDO_THRASH_SEED=%d", seed)
+	rng := rand.New(rand.NewSource(seed))
+
+	run := newModelRun(t, client, ctx, "Undo Thrash Test", rng)
+	defer run.cleanup()
+
+	// A few operations to move through before the thrashing starts
+	for i := 0; i < 5; i++ {
+		run.change(-i-1, opCreate)
+	}
+	run.checkStatus(0)
+
+	forks := 0
+	undo := true
+	for step := 1; step <= thrashSteps && !run.failed(); step++ {
+		switch {
+		case rng.Float64() < thrashForkChance:
+			if run.model.canRedo() {
+				forks++
+			}
+			run.change(step, opKind(rng.Intn(2))) // create or update
+		case undo:
+			run.undo(step)
+		default:
+			run.redo(step)
+		}
+		run.checkStatus(step)
+
+		// Mostly alternate, with runs now and then so the position drifts
+		if rng.Intn(4) != 0 {
+			undo = !undo
+		}
+	}
+	t.Logf("%d operations on the timeline, %d applied, %d recorded, %d forks",
+		len(run.model.ops), run.model.applied, run.model.recorded, forks)
+
+	run.checkLooks(thrashSteps)
+}
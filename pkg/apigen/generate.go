@@ -0,0 +1,324 @@
+package apigen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// scalars maps built-in GraphQL scalars to Go types. Custom scalars become
+// json.RawMessage.
+var scalars = map[string]string{
+	"ID":      "string",
+	"String":  "string",
+	"Int":     "int",
+	"Float":   "float64",
+	"Boolean": "bool",
+}
+
+// initialisms are written in capitals in Go names, as in FixtureID.
+var initialisms = []string{"Id", "Url", "Json", "Http", "Dmx", "Rdm", "Msc"}
+
+// Generate writes the Go source of package pkg for the schema:
+//
+//   - an API type wrapping a *graphql.Client, with one method per query and
+//     mutation field, such as CreateLook(ctx, input) (*Look, error)
+//   - a struct per object type holding its scalar and enum fields, which is
+//     what each method selects; nested objects are left to QueryFor with a
+//     hand-written struct
+//   - a struct per input type, with nullable fields as omitempty pointers
+//   - a string type and constants per enum
+//
+// Root fields that return unions or interfaces, or objects without scalar
+// fields, are listed in a comment instead of getting a method.
+func Generate(s *Schema, pkg string) ([]byte, error) {
+	g := &generator{types: map[string]*Type{}}
+	for i := range s.Types {
+		t := &s.Types[i]
+		if !strings.HasPrefix(t.Name, "__") {
+			g.types[t.Name] = t
+		}
+	}
+	roots := map[string]string{s.QueryType.Name: "query"}
+	if s.MutationType != nil {
+		roots[s.MutationType.Name] = "mutation"
+	}
+
+	var names []string
+	for name := range g.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var body bytes.Buffer
+	for _, root := range []string{s.QueryType.Name, mutationName(s)} {
+		if t := g.types[root]; t != nil {
+			g.writeRoot(&body, roots[root], t)
+		}
+	}
+	for _, name := range names {
+		t := g.types[name]
+		if roots[name] != "" {
+			continue
+		}
+		switch t.Kind {
+		case "OBJECT":
+			g.writeObject(&body, t)
+		case "INPUT_OBJECT":
+			g.writeInput(&body, t)
+		case "ENUM":
+			g.writeEnum(&body, t)
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by cmd/genapi; DO NOT EDIT.\n\npackage %s\n\nimport (\n\t\"context\"\n", pkg)
+	if g.usesJSON {
+		out.WriteString("\t\"encoding/json\"\n")
+	}
+	out.WriteString("\n\t\"github.com/bbernstein/lacylights-test/pkg/graphql\"\n)\n\n")
+	out.WriteString("// API runs the schema's queries and mutations through a GraphQL client.\n")
+	out.WriteString("type API struct {\n\t*graphql.Client\n}\n\n")
+	out.WriteString("// New returns an API that sends requests with client.\n")
+	out.WriteString("func New(client *graphql.Client) *API {\n\treturn &API{Client: client}\n}\n")
+	out.Write(body.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return out.Bytes(), fmt.Errorf("format generated source: %w", err)
+	}
+	return src, nil
+}
+
+func mutationName(s *Schema) string {
+	if s.MutationType == nil {
+		return ""
+	}
+	return s.MutationType.Name
+}
+
+type generator struct {
+	types    map[string]*Type
+	usesJSON bool
+}
+
+// GoName exports a GraphQL name, capitalizing initialisms: fixtureId becomes
+// FixtureID. Enum values in capitals are converted from snake case, so
+// LED_PAR becomes LedPar.
+func GoName(name string) string {
+	if strings.ToUpper(name) == name && strings.ContainsAny(name, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") {
+		var b strings.Builder
+		for _, part := range strings.Split(strings.ToLower(name), "_") {
+			if part != "" {
+				b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+			}
+		}
+		name = b.String()
+	}
+	if name == "" {
+		return ""
+	}
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+	name = string(runes)
+	for _, word := range initialisms {
+		name = replaceInitialism(name, word)
+	}
+	return name
+}
+
+// replaceInitialism capitalizes word where it ends a name or is followed by the next word.
+func replaceInitialism(name, word string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(name, word)
+		if i < 0 {
+			b.WriteString(name)
+			return b.String()
+		}
+		end := i + len(word)
+		b.WriteString(name[:i])
+		if end == len(name) || unicode.IsUpper(rune(name[end])) || unicode.IsDigit(rune(name[end])) {
+			b.WriteString(strings.ToUpper(word))
+		} else {
+			b.WriteString(word)
+		}
+		name = name[end:]
+	}
+}
+
+// paramName makes an argument name usable as a Go parameter, clear of
+// keywords and the names the generated methods use.
+func paramName(name string) string {
+	switch name {
+	case "ctx", "api", "resp", "err":
+		return name + "Arg"
+	}
+	if token.IsKeyword(name) {
+		return name + "Arg"
+	}
+	return name
+}
+
+// goType is the Go type for a reference: slices for lists and pointers for
+// nullable values.
+func (g *generator) goType(r *TypeRef) string {
+	nonNull := r.Kind == "NON_NULL"
+	if nonNull {
+		r = r.OfType
+	}
+	var t string
+	if r.Kind == "LIST" {
+		return "[]" + g.goType(r.OfType)
+	} else if scalar, ok := scalars[r.Name]; ok {
+		t = scalar
+	} else if r.Kind == "SCALAR" {
+		g.usesJSON = true
+		t = "json.RawMessage"
+	} else {
+		t = GoName(r.Name)
+	}
+	if !nonNull {
+		return "*" + t
+	}
+	return t
+}
+
+// resultType is the Go type a method returns: like goType, except single
+// objects are always returned by pointer.
+func (g *generator) resultType(r *TypeRef) string {
+	t := g.goType(r)
+	if kind := g.kind(r); kind == "OBJECT" && !strings.HasPrefix(t, "[]") && !strings.HasPrefix(t, "*") {
+		return "*" + t
+	}
+	return t
+}
+
+func (g *generator) kind(r *TypeRef) string {
+	if t := g.types[r.Named()]; t != nil {
+		return t.Kind
+	}
+	return "SCALAR"
+}
+
+// leafFields are the fields an object's struct holds: scalars and enums that take no arguments.
+func (g *generator) leafFields(t *Type) []Field {
+	var fields []Field
+	for _, f := range t.Fields {
+		if kind := g.kind(&f.Type); (kind == "SCALAR" || kind == "ENUM") && len(f.Args) == 0 {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// selection is the selection set a method sends for its result, or "" for scalars.
+func (g *generator) selection(r *TypeRef) string {
+	t := g.types[r.Named()]
+	if t == nil || t.Kind != "OBJECT" {
+		return ""
+	}
+	var names []string
+	for _, f := range g.leafFields(t) {
+		names = append(names, f.Name)
+	}
+	return " { " + strings.Join(names, " ") + " }"
+}
+
+// writeDoc writes a description as comment lines after a first line.
+func writeDoc(b *bytes.Buffer, first, description string) {
+	fmt.Fprintf(b, "\n// %s\n", first)
+	if description = strings.TrimSpace(description); description != "" {
+		b.WriteString("//\n")
+		for _, line := range strings.Split(description, "\n") {
+			fmt.Fprintf(b, "// %s\n", strings.TrimRight(line, " \t"))
+		}
+	}
+}
+
+func (g *generator) writeRoot(b *bytes.Buffer, op string, t *Type) {
+	fields := append([]Field(nil), t.Fields...)
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+
+	var skipped []string
+	for _, f := range fields {
+		kind := g.kind(&f.Type)
+		if kind == "UNION" || kind == "INTERFACE" || kind == "OBJECT" && len(g.leafFields(g.types[f.Type.Named()])) == 0 {
+			skipped = append(skipped, f.Name)
+			continue
+		}
+
+		name := GoName(f.Name)
+		var params, varDefs, args, vars []string
+		for _, a := range f.Args {
+			p := paramName(a.Name)
+			params = append(params, fmt.Sprintf("%s %s", p, g.goType(&a.Type)))
+			varDefs = append(varDefs, fmt.Sprintf("$%s: %s", a.Name, a.Type.String()))
+			args = append(args, fmt.Sprintf("%s: $%s", a.Name, a.Name))
+			vars = append(vars, fmt.Sprintf("%q: %s", a.Name, p))
+		}
+		document := op + " " + name
+		if len(varDefs) > 0 {
+			document += "(" + strings.Join(varDefs, ", ") + ")"
+		}
+		document += " { " + f.Name
+		if len(args) > 0 {
+			document += "(" + strings.Join(args, ", ") + ")"
+		}
+		document += g.selection(&f.Type) + " }"
+		variables := "nil"
+		if len(vars) > 0 {
+			variables = "map[string]interface{}{" + strings.Join(vars, ", ") + "}"
+		}
+		result := g.resultType(&f.Type)
+
+		writeDoc(b, fmt.Sprintf("%s runs the %s field %s.", name, op, f.Name), f.Description)
+		fmt.Fprintf(b, "func (api *API) %s(%s) (%s, error) {\n", name, strings.Join(append([]string{"ctx context.Context"}, params...), ", "), result)
+		fmt.Fprintf(b, "\tvar resp struct {\n\t\tResult %s `json:%q`\n\t}\n", result, f.Name)
+		fmt.Fprintf(b, "\terr := api.Client.Query(ctx, %q, %s, &resp)\n", document, variables)
+		b.WriteString("\treturn resp.Result, err\n}\n")
+	}
+	if len(skipped) > 0 {
+		fmt.Fprintf(b, "\n// Not generated (%s fields returning unions, interfaces or objects without scalar fields):\n//\t%s\n",
+			op, strings.Join(skipped, ", "))
+	}
+}
+
+func (g *generator) writeObject(b *bytes.Buffer, t *Type) {
+	name := GoName(t.Name)
+	writeDoc(b, fmt.Sprintf("%s holds the scalar and enum fields of %s.", name, t.Name), t.Description)
+	fmt.Fprintf(b, "type %s struct {\n", name)
+	for _, f := range g.leafFields(t) {
+		fmt.Fprintf(b, "\t%s %s `json:%q`\n", GoName(f.Name), g.goType(&f.Type), f.Name)
+	}
+	b.WriteString("}\n")
+}
+
+func (g *generator) writeInput(b *bytes.Buffer, t *Type) {
+	name := GoName(t.Name)
+	writeDoc(b, fmt.Sprintf("%s is the input type %s.", name, t.Name), t.Description)
+	fmt.Fprintf(b, "type %s struct {\n", name)
+	for _, f := range t.InputFields {
+		tag := f.Name
+		if f.Type.Kind != "NON_NULL" {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(b, "\t%s %s `json:%q`\n", GoName(f.Name), g.goType(&f.Type), tag)
+	}
+	b.WriteString("}\n")
+}
+
+func (g *generator) writeEnum(b *bytes.Buffer, t *Type) {
+	name := GoName(t.Name)
+	writeDoc(b, fmt.Sprintf("%s is the enum %s.", name, t.Name), t.Description)
+	fmt.Fprintf(b, "type %s string\n\n", name)
+	fmt.Fprintf(b, "// %s values.\nconst (\n", name)
+	for _, v := range t.EnumValues {
+		fmt.Fprintf(b, "\t%s%s %s = %q\n", name, GoName(v.Name), name, v.Name)
+	}
+	b.WriteString(")\n")
+}
@@ -0,0 +1,142 @@
+package apigen
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSchema is a trimmed introspection result in the shape IntrospectionQuery returns.
+const testSchema = `{"data": {"__schema": {
+	"queryType": {"name": "Query"},
+	"mutationType": {"name": "Mutation"},
+	"types": [
+		{"kind": "OBJECT", "name": "Query", "fields": [
+			{"name": "look", "description": "A look by ID.", "args": [{"name": "id", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}}],
+				"type": {"kind": "OBJECT", "name": "Look"}},
+			{"name": "dmxOutput", "args": [{"name": "universe", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "Int"}}}],
+				"type": {"kind": "NON_NULL", "ofType": {"kind": "LIST", "ofType": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "Int"}}}}},
+			{"name": "searchAll", "args": [], "type": {"kind": "UNION", "name": "SearchResult"}}
+		]},
+		{"kind": "OBJECT", "name": "Mutation", "fields": [
+			{"name": "createLook", "args": [{"name": "input", "type": {"kind": "NON_NULL", "ofType": {"kind": "INPUT_OBJECT", "name": "CreateLookInput"}}}],
+				"type": {"kind": "NON_NULL", "ofType": {"kind": "OBJECT", "name": "Look"}}},
+			{"name": "deleteLook", "args": [{"name": "id", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}}],
+				"type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "Boolean"}}}
+		]},
+		{"kind": "OBJECT", "name": "Look", "description": "A stored state of fixtures.", "fields": [
+			{"name": "id", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}},
+			{"name": "name", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}},
+			{"name": "projectId", "args": [], "type": {"kind": "SCALAR", "name": "ID"}},
+			{"name": "kind", "args": [], "type": {"kind": "ENUM", "name": "LookKind"}},
+			{"name": "metadata", "args": [], "type": {"kind": "SCALAR", "name": "JSON"}},
+			{"name": "fixtures", "args": [], "type": {"kind": "LIST", "ofType": {"kind": "OBJECT", "name": "Look"}}},
+			{"name": "channel", "args": [{"name": "offset", "type": {"kind": "SCALAR", "name": "Int"}}], "type": {"kind": "SCALAR", "name": "Int"}}
+		]},
+		{"kind": "INPUT_OBJECT", "name": "CreateLookInput", "inputFields": [
+			{"name": "projectId", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}},
+			{"name": "name", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}},
+			{"name": "description", "type": {"kind": "SCALAR", "name": "String"}},
+			{"name": "kind", "type": {"kind": "ENUM", "name": "LookKind"}}
+		]},
+		{"kind": "ENUM", "name": "LookKind", "enumValues": [{"name": "STATIC"}, {"name": "LED_PAR"}]},
+		{"kind": "UNION", "name": "SearchResult"},
+		{"kind": "SCALAR", "name": "JSON"},
+		{"kind": "OBJECT", "name": "__Type", "fields": [{"name": "name", "args": [], "type": {"kind": "SCALAR", "name": "String"}}]}
+	]
+}}}`
+
+func TestGoName(t *testing.T) {
+	for in, want := range map[string]string{
+		"look":            "Look",
+		"fixtureId":       "FixtureID",
+		"dmxOutput":       "DMXOutput",
+		"identity":        "Identity",
+		"baseUrl":         "BaseURL",
+		"LED_PAR":         "LedPar",
+		"STATIC":          "Static",
+		"CreateLookInput": "CreateLookInput",
+	} {
+		assert.Equal(t, want, GoName(in), in)
+	}
+}
+
+func TestParse(t *testing.T) {
+	s, err := Parse([]byte(testSchema))
+	require.NoError(t, err)
+	assert.Equal(t, "Query", s.QueryType.Name)
+	assert.Len(t, s.Types, 8)
+
+	var data struct {
+		Data json.RawMessage `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(testSchema), &data))
+	_, err = Parse(data.Data)
+	assert.NoError(t, err, "The bare data object should parse too")
+
+	_, err = Parse([]byte(`{"__schema": {"types": []}}`))
+	assert.Error(t, err)
+}
+
+func TestDrift(t *testing.T) {
+	s, err := Parse([]byte(testSchema))
+	require.NoError(t, err)
+	same, err := Parse([]byte(testSchema))
+	require.NoError(t, err)
+	assert.Empty(t, s.Drift(same))
+
+	live := strings.NewReplacer(
+		`, {"name": "LED_PAR"}`, ``,
+		`{"name": "projectId", "args": [], "type": {"kind": "SCALAR", "name": "ID"}}`,
+		`{"name": "projectId", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}}`,
+		`{"name": "kind", "type": {"kind": "ENUM", "name": "LookKind"}}`,
+		`{"name": "kind", "type": {"kind": "ENUM", "name": "LookKind"}},
+			{"name": "universe", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "Int"}}},
+			{"name": "notes", "type": {"kind": "SCALAR", "name": "String"}}`,
+		`{"kind": "SCALAR", "name": "JSON"},`, `{"kind": "SCALAR", "name": "Upload"},`,
+	).Replace(testSchema)
+	changed, err := Parse([]byte(live))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"field Look.projectId is ID!, not ID",
+		"input field CreateLookInput universe is new and required",
+		"enum value LookKind.LED_PAR is missing",
+		"type JSON is missing",
+	}, s.Drift(changed), "Optional additions and new types are not drift")
+}
+
+func TestGenerate(t *testing.T) {
+	s, err := Parse([]byte(testSchema))
+	require.NoError(t, err)
+	src, err := Generate(s, "lacyapi")
+	require.NoError(t, err, "%s", src)
+	// Collapse gofmt's alignment so fields can be matched
+	code := strings.Join(strings.Fields(string(src)), " ")
+
+	assert.True(t, strings.HasPrefix(string(src), "// Code generated by cmd/genapi; DO NOT EDIT.\n"))
+	assert.Contains(t, code, "package lacyapi")
+
+	// Methods, with documents selecting the object's scalar fields
+	assert.Contains(t, code, "func (api *API) Look(ctx context.Context, id string) (*Look, error) {")
+	assert.Contains(t, code, `"query Look($id: ID!) { look(id: $id) { id name projectId kind metadata } }"`)
+	assert.Contains(t, code, "func (api *API) CreateLook(ctx context.Context, input CreateLookInput) (*Look, error) {")
+	assert.Contains(t, code, `"mutation CreateLook($input: CreateLookInput!) { createLook(input: $input) { id name projectId kind metadata } }"`)
+	assert.Contains(t, code, "func (api *API) DMXOutput(ctx context.Context, universe int) ([]int, error) {")
+	assert.Contains(t, code, "func (api *API) DeleteLook(ctx context.Context, id string) (bool, error) {")
+	assert.Contains(t, code, "// A look by ID.")
+	assert.Contains(t, code, "searchAll", "Skipped fields should be listed")
+	assert.NotContains(t, code, "func (api *API) SearchAll")
+
+	// Types
+	assert.Contains(t, code, "ProjectID *string `json:\"projectId\"`")
+	assert.Contains(t, code, "Metadata *json.RawMessage `json:\"metadata\"`")
+	assert.NotContains(t, code, "`json:\"fixtures\"`", "Nested objects are not selected")
+	assert.NotContains(t, code, "`json:\"channel\"`", "Fields with arguments are not selected")
+	assert.Contains(t, code, "Description *string `json:\"description,omitempty\"`")
+	assert.Contains(t, code, "ProjectID string `json:\"projectId\"`")
+	assert.Contains(t, code, "LookKindLedPar LookKind = \"LED_PAR\"")
+	assert.NotContains(t, code, "__Type")
+}
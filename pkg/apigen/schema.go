@@ -0,0 +1,233 @@
+// Package apigen generates typed Go bindings for the server's GraphQL API
+// from schema introspection. cmd/genapi runs it to write pkg/lacyapi.
+package apigen
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+)
+
+// IntrospectionQuery fetches what Generate needs: root types, and the fields,
+// arguments, input fields and enum values of every type.
+const IntrospectionQuery = `
+	query GenAPIIntrospection {
+		__schema {
+			queryType { name }
+			mutationType { name }
+			types {
+				kind
+				name
+				description
+				fields {
+					name
+					description
+					args { name type { ...TypeRef } }
+					type { ...TypeRef }
+				}
+				inputFields { name type { ...TypeRef } }
+				enumValues { name }
+			}
+		}
+	}
+	fragment TypeRef on __Type {
+		kind name
+		ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } }
+	}
+`
+
+// TypeRef is a reference to a type, wrapped in NON_NULL and LIST as needed.
+type TypeRef struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	OfType *TypeRef `json:"ofType"`
+}
+
+// Named unwraps lists and non-null to the underlying type name.
+func (r *TypeRef) Named() string {
+	for r != nil && r.Name == "" {
+		r = r.OfType
+	}
+	if r == nil {
+		return ""
+	}
+	return r.Name
+}
+
+// String renders the reference in GraphQL syntax, such as [ID!]!.
+func (r *TypeRef) String() string {
+	switch {
+	case r == nil:
+		return ""
+	case r.Kind == "NON_NULL":
+		return r.OfType.String() + "!"
+	case r.Kind == "LIST":
+		return "[" + r.OfType.String() + "]"
+	}
+	return r.Name
+}
+
+// InputValue is an argument or an input object field.
+type InputValue struct {
+	Name string  `json:"name"`
+	Type TypeRef `json:"type"`
+}
+
+// Field is a field of an object type.
+type Field struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Args        []InputValue `json:"args"`
+	Type        TypeRef      `json:"type"`
+}
+
+// Type is a named type of the schema.
+type Type struct {
+	Kind        string       `json:"kind"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Fields      []Field      `json:"fields"`
+	InputFields []InputValue `json:"inputFields"`
+	EnumValues  []struct {
+		Name string `json:"name"`
+	} `json:"enumValues"`
+}
+
+// RootType names the type of a root operation.
+type RootType struct {
+	Name string `json:"name"`
+}
+
+// Schema is an introspected schema.
+type Schema struct {
+	QueryType    *RootType `json:"queryType"`
+	MutationType *RootType `json:"mutationType"`
+	Types        []Type    `json:"types"`
+}
+
+// Load introspects the server's schema.
+func Load(ctx context.Context, client *graphql.Client) (*Schema, error) {
+	var resp struct {
+		Schema Schema `json:"__schema"`
+	}
+	if err := client.Query(ctx, IntrospectionQuery, nil, &resp); err != nil {
+		return nil, fmt.Errorf("introspection: %w", err)
+	}
+	return checked(&resp.Schema)
+}
+
+// Parse reads an introspection result saved as JSON, either the data object
+// ({"__schema": ...}) or a full response ({"data": {"__schema": ...}}).
+func Parse(data []byte) (*Schema, error) {
+	var resp struct {
+		Data *struct {
+			Schema Schema `json:"__schema"`
+		} `json:"data"`
+		Schema Schema `json:"__schema"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+	if resp.Data != nil {
+		return checked(&resp.Data.Schema)
+	}
+	return checked(&resp.Schema)
+}
+
+// Marshal writes the schema as a saved introspection result Parse reads.
+func (s *Schema) Marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(struct {
+		Schema *Schema `json:"__schema"`
+	}{s}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Drift lists where live, the server's schema, no longer matches s, the one
+// bindings were generated from: types, fields, arguments, input fields and
+// enum values of s that live lacks or types differently, and required
+// arguments or input fields that live adds. Other additions are not drift.
+func (s *Schema) Drift(live *Schema) []string {
+	types := make(map[string]*Type, len(live.Types))
+	for i := range live.Types {
+		types[live.Types[i].Name] = &live.Types[i]
+	}
+
+	var drift []string
+	for _, t := range s.Types {
+		lt := types[t.Name]
+		if lt == nil {
+			drift = append(drift, fmt.Sprintf("type %s is missing", t.Name))
+			continue
+		}
+		if lt.Kind != t.Kind {
+			drift = append(drift, fmt.Sprintf("type %s is %s, not %s", t.Name, lt.Kind, t.Kind))
+			continue
+		}
+
+		fields := make(map[string]Field, len(lt.Fields))
+		for _, f := range lt.Fields {
+			fields[f.Name] = f
+		}
+		for _, f := range t.Fields {
+			lf, ok := fields[f.Name]
+			if !ok {
+				drift = append(drift, fmt.Sprintf("field %s.%s is missing", t.Name, f.Name))
+				continue
+			}
+			if got, want := lf.Type.String(), f.Type.String(); got != want {
+				drift = append(drift, fmt.Sprintf("field %s.%s is %s, not %s", t.Name, f.Name, got, want))
+			}
+			drift = append(drift, inputDrift("argument "+t.Name+"."+f.Name, f.Args, lf.Args)...)
+		}
+		drift = append(drift, inputDrift("input field "+t.Name, t.InputFields, lt.InputFields)...)
+
+		values := make(map[string]bool, len(lt.EnumValues))
+		for _, v := range lt.EnumValues {
+			values[v.Name] = true
+		}
+		for _, v := range t.EnumValues {
+			if !values[v.Name] {
+				drift = append(drift, fmt.Sprintf("enum value %s.%s is missing", t.Name, v.Name))
+			}
+		}
+	}
+	return drift
+}
+
+// inputDrift compares the arguments or input fields of one field or type.
+func inputDrift(what string, want, got []InputValue) []string {
+	var drift []string
+	live := make(map[string]InputValue, len(got))
+	for _, v := range got {
+		live[v.Name] = v
+	}
+	known := make(map[string]bool, len(want))
+	for _, v := range want {
+		known[v.Name] = true
+		lv, ok := live[v.Name]
+		switch {
+		case !ok:
+			drift = append(drift, fmt.Sprintf("%s %s is missing", what, v.Name))
+		case lv.Type.String() != v.Type.String():
+			drift = append(drift, fmt.Sprintf("%s %s is %s, not %s", what, v.Name, lv.Type.String(), v.Type.String()))
+		}
+	}
+	for _, v := range got {
+		if !known[v.Name] && v.Type.Kind == "NON_NULL" {
+			drift = append(drift, fmt.Sprintf("%s %s is new and required", what, v.Name))
+		}
+	}
+	return drift
+}
+
+func checked(s *Schema) (*Schema, error) {
+	if s.QueryType == nil {
+		return nil, fmt.Errorf("introspection result has no query type")
+	}
+	return s, nil
+}
@@ -0,0 +1,422 @@
+// Package artifacts saves a bundle of evidence when a contract test fails,
+// so a failure in CI can be diagnosed without rerunning it. When
+// ARTIFACTS_DIR is set, a failing test gets <dir>/<test name>/ holding:
+//
+//	artnet.csv        the last Window of Art-Net frames from its captures
+//	dmx_output.json   dmxOutput for universe 1 and every captured universe
+//	graphql.ndjson    the recent exchanges of its recorded clients
+//	system_info.json  the server's systemInfo
+//	artnet_u<N>.png   a chart of the channels that changed in each captured
+//	                  universe, over the whole capture (see pkg/plot)
+//	<name>.png        charts the test added with AddChart
+//
+// Importing the package attaches every graphql.NewTestClient and
+// artnet.Capture to the test that created it; contract packages import it
+// for that side effect:
+//
+//	import _ "github.com/bbernstein/lacylights-test/pkg/artifacts"
+//
+// With ARTIFACTS_DIR set, clients from graphql.NewClient keep their recent
+// exchanges too, and a bundle includes the ones they made while its test ran.
+// Since such clients do not know their test, tests running in parallel see
+// each other's. Tests using plain receivers, or only plain clients, create
+// their bundle with For, and tests that poll values rather than capture them
+// can chart the samples:
+//
+//	artifacts.For(t).AddClient(client)
+//	artifacts.For(t).AddChart("dimmer", plot.Chart{Series: []plot.Series{plot.Samples("dimmer", 100*time.Millisecond, samples)}})
+package artifacts
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/bbernstein/lacylights-test/pkg/plot"
+)
+
+// Window is how much Art-Net history a bundle keeps, ending when the test ends.
+const Window = 5 * time.Second
+
+// snapshotTimeout bounds the queries made for a bundle, so a hung server
+// does not hang the test run as well.
+const snapshotTimeout = 10 * time.Second
+
+// maxPlotChannels is how many changed channels a universe's chart shows.
+const maxPlotChannels = 8
+
+// maxTrackedClients is how many of the newest plain clients bundles look at.
+const maxTrackedClients = 64
+
+// FrameSource is a capture whose frames go in the bundle, such as an
+// *artnet.Subscription or *artnet.Receiver.
+type FrameSource interface {
+	GetFrames() []artnet.Frame
+}
+
+// Bundle collects what a test's artifacts are built from. A nil Bundle,
+// returned by For when ARTIFACTS_DIR is unset, ignores everything.
+type Bundle struct {
+	t     testing.TB
+	dir   string
+	start time.Time
+
+	mu       sync.Mutex
+	clients  []*graphql.Client
+	captures []FrameSource
+	charts   []namedChart
+}
+
+type namedChart struct {
+	name  string
+	chart plot.Chart
+}
+
+var (
+	bundlesMu sync.Mutex
+	bundles   = map[testing.TB]*Bundle{}
+
+	// tracked holds the newest clients from graphql.NewClient, which bundles
+	// take the exchanges made during their test from.
+	trackedMu sync.Mutex
+	tracked   []*graphql.Client
+)
+
+func init() {
+	graphql.OnNewClient(func(c *graphql.Client) {
+		if Dir() == "" {
+			return
+		}
+		c.KeepLast(graphql.DefaultKeepLast)
+		trackedMu.Lock()
+		defer trackedMu.Unlock()
+		tracked = append(tracked, c)
+		if len(tracked) > maxTrackedClients {
+			tracked = tracked[len(tracked)-maxTrackedClients:]
+		}
+	})
+	graphql.OnTestClient(func(t testing.TB, c *graphql.Client) {
+		For(t).AddClient(c)
+	})
+	artnet.OnCapture(func(t testing.TB, s *artnet.Subscription) {
+		For(t).AddCapture(s)
+	})
+}
+
+// Dir returns the ARTIFACTS_DIR environment variable.
+func Dir() string {
+	return os.Getenv("ARTIFACTS_DIR")
+}
+
+// For returns t's bundle, creating it on first use with a cleanup that
+// writes the artifacts if t failed. It returns nil when ARTIFACTS_DIR is unset.
+func For(t testing.TB) *Bundle {
+	root := Dir()
+	if root == "" {
+		return nil
+	}
+
+	bundlesMu.Lock()
+	defer bundlesMu.Unlock()
+	if b := bundles[t]; b != nil {
+		return b
+	}
+	b := &Bundle{t: t, dir: filepath.Join(root, dirName(t.Name())), start: time.Now()}
+	bundles[t] = b
+	t.Cleanup(func() {
+		bundlesMu.Lock()
+		delete(bundles, t)
+		bundlesMu.Unlock()
+		if t.Failed() {
+			b.write()
+		}
+	})
+	return b
+}
+
+// AddClient includes the client's recent exchanges, if it records them. The
+// first client added is also used for the dmxOutput and systemInfo snapshots.
+func (b *Bundle) AddClient(c *graphql.Client) {
+	if b == nil || c == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.clients = append(b.clients, c)
+}
+
+// AddCapture includes the frames of an Art-Net capture.
+func (b *Bundle) AddCapture(src FrameSource) {
+	if b == nil || src == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.captures = append(b.captures, src)
+}
+
+// AddChart includes a chart, written as <name>.png.
+func (b *Bundle) AddChart(name string, chart plot.Chart) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.charts = append(b.charts, namedChart{name, chart})
+}
+
+// write saves the bundle to its directory, logging what could not be saved.
+func (b *Bundle) write() {
+	b.mu.Lock()
+	clients := append([]*graphql.Client(nil), b.clients...)
+	captures := append([]FrameSource(nil), b.captures...)
+	charts := append([]namedChart(nil), b.charts...)
+	b.mu.Unlock()
+	// Before the snapshot client below adds exchanges of its own
+	exchanges := b.exchanges(clients)
+
+	if err := os.MkdirAll(b.dir, 0o755); err != nil {
+		b.t.Logf("artifacts: %v", err)
+		return
+	}
+
+	frames := recentFrames(captures, time.Now().Add(-Window))
+	endpoint := ""
+	if len(clients) > 0 {
+		endpoint = clients[0].Endpoint()
+	}
+	snapshot := graphql.NewClient(endpoint)
+	ctx, cancel := context.WithTimeout(context.Background(), snapshotTimeout)
+	defer cancel()
+
+	files := []artifactFile{
+		{"artnet.csv", func(f *os.File) error { return writeFrames(f, frames) }},
+		{"dmx_output.json", func(f *os.File) error { return writeDMXOutput(ctx, f, snapshot, universes(frames)) }},
+		{"graphql.ndjson", func(f *os.File) error { return writeExchanges(f, exchanges) }},
+		{"system_info.json", func(f *os.File) error { return writeSystemInfo(ctx, f, snapshot) }},
+	}
+	for _, chart := range append(captureCharts(captures), charts...) {
+		chart := chart
+		files = append(files, artifactFile{chart.name + ".png", func(f *os.File) error { return chart.chart.WritePNG(f) }})
+	}
+	for _, file := range files {
+		if err := writeFile(filepath.Join(b.dir, file.name), file.write); err != nil {
+			b.t.Logf("artifacts: %s: %v", file.name, err)
+		}
+	}
+	b.t.Logf("Failure artifacts written to %s", b.dir)
+}
+
+// artifactFile is a file of the bundle and how to write it.
+type artifactFile struct {
+	name  string
+	write func(*os.File) error
+}
+
+func writeFile(path string, write func(*os.File) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// dirName turns a test name (which may contain subtest slashes) into a
+// directory name, as graphql recordings name their files.
+func dirName(testName string) string {
+	return strings.NewReplacer("/", "__", " ", "_", ":", "_").Replace(testName)
+}
+
+// capturedFrame is a frame with the index of the capture it came from.
+type capturedFrame struct {
+	capture int
+	artnet.Frame
+}
+
+// recentFrames merges the captures' frames since cutoff in arrival order.
+func recentFrames(captures []FrameSource, cutoff time.Time) []capturedFrame {
+	var frames []capturedFrame
+	for i, src := range captures {
+		for _, f := range src.GetFrames() {
+			if !f.Timestamp.Before(cutoff) {
+				frames = append(frames, capturedFrame{i, f})
+			}
+		}
+	}
+	sort.SliceStable(frames, func(i, j int) bool { return frames[i].Timestamp.Before(frames[j].Timestamp) })
+	return frames
+}
+
+// captureCharts charts the channels that changed in each universe the
+// captures saw, over all their frames, since a fade's shape is often longer
+// than the CSV's window.
+func captureCharts(captures []FrameSource) []namedChart {
+	var frames []artnet.Frame
+	for _, c := range recentFrames(captures, time.Time{}) {
+		frames = append(frames, c.Frame)
+	}
+	seen := map[int]bool{}
+	var charts []namedChart
+	for _, f := range frames {
+		if seen[f.Universe] {
+			continue
+		}
+		seen[f.Universe] = true
+		channels := plot.ChangedChannels(frames, f.Universe, maxPlotChannels)
+		if len(channels) == 0 {
+			continue
+		}
+		charts = append(charts, namedChart{
+			name: fmt.Sprintf("artnet_u%d", f.Universe),
+			chart: plot.Chart{
+				Title:  fmt.Sprintf("Art-Net universe %d", f.Universe),
+				Series: plot.Channels(frames, f.Universe, channels...),
+			},
+		})
+	}
+	sort.Slice(charts, func(i, j int) bool { return charts[i].name < charts[j].name })
+	return charts
+}
+
+// universes lists universe 1 and the universe of every frame, in order.
+// dmxOutput numbers universes from 1 where Art-Net numbers them from 0.
+func universes(frames []capturedFrame) []int {
+	seen := map[int]bool{1: true}
+	for _, f := range frames {
+		seen[f.Universe+1] = true
+	}
+	list := make([]int, 0, len(seen))
+	for u := range seen {
+		list = append(list, u)
+	}
+	sort.Ints(list)
+	return list
+}
+
+// writeFrames writes one CSV row per frame: when it arrived, where it came
+// from and its 512 channel values.
+func writeFrames(f *os.File, frames []capturedFrame) error {
+	w := csv.NewWriter(f)
+	header := []string{"time", "capture", "universe", "sequence", "sync_window"}
+	for ch := 1; ch <= artnet.DMXChannels; ch++ {
+		header = append(header, "ch"+strconv.Itoa(ch))
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	row := make([]string, len(header))
+	for _, frame := range frames {
+		row[0] = frame.Timestamp.Format(time.RFC3339Nano)
+		row[1] = strconv.Itoa(frame.capture)
+		row[2] = strconv.Itoa(frame.Universe)
+		row[3] = strconv.Itoa(int(frame.Sequence))
+		row[4] = strconv.Itoa(frame.SyncWindow)
+		for ch, v := range frame.Channels {
+			row[5+ch] = strconv.Itoa(int(v))
+		}
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// writeDMXOutput writes dmxOutput per universe, keyed by universe number.
+// A universe that cannot be read is recorded with its error.
+func writeDMXOutput(ctx context.Context, f *os.File, client *graphql.Client, universes []int) error {
+	output := map[string]interface{}{}
+	for _, u := range universes {
+		var resp struct {
+			DMXOutput []int `json:"dmxOutput"`
+		}
+		err := client.Query(ctx, `query DMXOutput($universe: Int!) { dmxOutput(universe: $universe) }`,
+			map[string]interface{}{"universe": u}, &resp)
+		if err != nil {
+			output[strconv.Itoa(u)] = map[string]string{"error": err.Error()}
+			continue
+		}
+		output[strconv.Itoa(u)] = resp.DMXOutput
+	}
+	return writeJSON(f, output)
+}
+
+// writeSystemInfo writes the server's systemInfo, falling back to the fields
+// every server version has.
+func writeSystemInfo(ctx context.Context, f *os.File, client *graphql.Client) error {
+	data, err := client.ExecuteRaw(ctx, `query SystemInfo { systemInfo { version artnetEnabled artnetBroadcastAddress } }`, nil)
+	if err != nil {
+		data, err = client.ExecuteRaw(ctx, `query SystemInfo { systemInfo { artnetEnabled artnetBroadcastAddress } }`, nil)
+	}
+	if err != nil {
+		return writeJSON(f, map[string]string{"error": err.Error()})
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	return writeJSON(f, v)
+}
+
+// exchanges returns the recent exchanges of the attached clients, and those
+// other tracked clients made since the bundle was created, in time order.
+func (b *Bundle) exchanges(clients []*graphql.Client) []graphql.Exchange {
+	trackedMu.Lock()
+	others := append([]*graphql.Client(nil), tracked...)
+	trackedMu.Unlock()
+
+	attached := map[*graphql.Client]bool{}
+	var exchanges []graphql.Exchange
+	for _, c := range clients {
+		attached[c] = true
+		if r := c.Recorder(); r != nil {
+			exchanges = append(exchanges, r.Last(graphql.DefaultKeepLast)...)
+		}
+	}
+	for _, c := range others {
+		r := c.Recorder()
+		if attached[c] || r == nil {
+			continue
+		}
+		for _, ex := range r.Last(graphql.DefaultKeepLast) {
+			if !ex.Time.Before(b.start) {
+				exchanges = append(exchanges, ex)
+			}
+		}
+	}
+	sort.SliceStable(exchanges, func(i, j int) bool { return exchanges[i].Time.Before(exchanges[j].Time) })
+	return exchanges
+}
+
+// writeExchanges writes exchanges as NDJSON.
+func writeExchanges(f *os.File, exchanges []graphql.Exchange) error {
+	enc := json.NewEncoder(f)
+	for _, ex := range exchanges {
+		if err := enc.Encode(ex); err != nil {
+			return fmt.Errorf("encode exchange: %w", err)
+		}
+	}
+	return nil
+}
+
+func writeJSON(f *os.File, v interface{}) error {
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
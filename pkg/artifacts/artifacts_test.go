@@ -0,0 +1,165 @@
+package artifacts
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/bbernstein/lacylights-test/pkg/plot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCapture []artnet.Frame
+
+func (c fakeCapture) GetFrames() []artnet.Frame { return c }
+
+// fakeServer answers dmxOutput with the universe number on every channel,
+// systemInfo, and anything else with an empty object.
+func fakeServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphql.Request
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		switch {
+		case strings.Contains(req.Query, "dmxOutput"):
+			values := make([]int, 512)
+			for i := range values {
+				values[i] = int(req.Variables["universe"].(float64))
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"dmxOutput": values}})
+		case strings.Contains(req.Query, "systemInfo"):
+			_, _ = w.Write([]byte(`{"data":{"systemInfo":{"version":"1.2.3","artnetEnabled":true}}}`))
+		default:
+			_, _ = w.Write([]byte(`{"data":{}}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestForDisabled(t *testing.T) {
+	t.Setenv("ARTIFACTS_DIR", "")
+	b := For(t)
+	assert.Nil(t, b)
+	b.AddClient(graphql.NewClient("http://localhost:1"))
+	b.AddCapture(fakeCapture{})
+}
+
+func TestForAttachesTestClients(t *testing.T) {
+	t.Setenv("ARTIFACTS_DIR", t.TempDir())
+	b := For(t)
+	require.NotNil(t, b)
+	assert.Same(t, b, For(t), "A test has one bundle")
+
+	client := graphql.NewTestClient(t, "http://localhost:1")
+	assert.Equal(t, []*graphql.Client{client}, b.clients)
+
+	t.Run("Subtest", func(t *testing.T) {
+		assert.NotSame(t, b, For(t), "Subtests get their own bundle")
+	})
+}
+
+func TestBundleTracksPlainClients(t *testing.T) {
+	t.Setenv("ARTIFACTS_DIR", t.TempDir())
+	server := fakeServer(t)
+	ctx := context.Background()
+
+	before := graphql.NewClient(server.URL)
+	require.NotNil(t, before.Recorder(), "Plain clients record when ARTIFACTS_DIR is set")
+	require.NoError(t, before.Query(ctx, `query Before { projects { id } }`, nil, nil))
+
+	b := For(t)
+	client := graphql.NewClient(server.URL)
+	require.NoError(t, client.Query(ctx, `query During { projects { id } }`, nil, nil))
+	require.NoError(t, before.Query(ctx, `query Reused { projects { id } }`, nil, nil))
+
+	var queries []string
+	for _, ex := range b.exchanges(nil) {
+		queries = append(queries, ex.Query)
+	}
+	assert.Equal(t, []string{`query During { projects { id } }`, `query Reused { projects { id } }`}, queries,
+		"Only exchanges made since the bundle was created are included")
+
+	t.Setenv("ARTIFACTS_DIR", "")
+	assert.Nil(t, graphql.NewClient(server.URL).Recorder(), "Plain clients do not record without ARTIFACTS_DIR")
+}
+
+func TestWrite(t *testing.T) {
+	server := fakeServer(t)
+	client := graphql.NewClientWithOptions(server.URL, graphql.ClientOptions{KeepLast: 5})
+	require.NoError(t, client.Query(context.Background(), `query { projects { id } }`, nil, nil))
+
+	now := time.Now()
+	old := artnet.Frame{Timestamp: now.Add(-2 * Window), Universe: 1}
+	first := artnet.Frame{Timestamp: now.Add(-time.Second), Universe: 1, Sequence: 7}
+	first.Channels[0] = 255
+	second := artnet.Frame{Timestamp: now.Add(-time.Second / 2), Universe: 3, SyncWindow: 2}
+	second.Channels[511] = 9
+
+	b := &Bundle{t: t, dir: filepath.Join(t.TempDir(), dirName("TestX/Sub case")), start: now}
+	b.AddClient(client)
+	b.AddCapture(fakeCapture{old, second})
+	b.AddCapture(fakeCapture{first})
+	b.AddChart("dimmer", plot.Chart{Series: []plot.Series{plot.Samples("dimmer", 100*time.Millisecond, []int{0, 128, 255})}})
+	b.write()
+	assert.True(t, strings.HasSuffix(b.dir, "TestX__Sub_case"))
+
+	t.Run("ArtNet", func(t *testing.T) {
+		f, err := os.Open(filepath.Join(b.dir, "artnet.csv"))
+		require.NoError(t, err)
+		defer f.Close()
+		rows, err := csv.NewReader(f).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, rows, 3, "Frames older than the window are dropped")
+		assert.Equal(t, []string{"time", "capture", "universe", "sequence", "sync_window", "ch1"}, rows[0][:6])
+		assert.Equal(t, "ch512", rows[0][516])
+		assert.Equal(t, []string{"1", "1", "7", "0", "255"}, rows[1][1:6], "Frames are in arrival order")
+		assert.Equal(t, []string{"0", "3", "0", "2"}, rows[2][1:5])
+		assert.Equal(t, "9", rows[2][516])
+	})
+
+	t.Run("DMXOutput", func(t *testing.T) {
+		var output map[string][]int
+		data, err := os.ReadFile(filepath.Join(b.dir, "dmx_output.json"))
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(data, &output))
+		assert.Len(t, output, 3, "Universe 1 and the captured universes are saved")
+		assert.Equal(t, 4, output["4"][0], "Art-Net universe 3 is dmxOutput universe 4")
+	})
+
+	t.Run("Exchanges", func(t *testing.T) {
+		f, err := os.Open(filepath.Join(b.dir, "graphql.ndjson"))
+		require.NoError(t, err)
+		defer f.Close()
+		var queries []string
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var ex graphql.Exchange
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &ex))
+			queries = append(queries, ex.Query)
+		}
+		assert.Equal(t, []string{`query { projects { id } }`}, queries, "Snapshot queries are not recorded")
+	})
+
+	t.Run("Charts", func(t *testing.T) {
+		assert.FileExists(t, filepath.Join(b.dir, "dimmer.png"))
+		assert.FileExists(t, filepath.Join(b.dir, "artnet_u1.png"), "Charts cover the whole capture, not the window")
+		assert.NoFileExists(t, filepath.Join(b.dir, "artnet_u3.png"), "Universes without changes are not charted")
+	})
+
+	t.Run("SystemInfo", func(t *testing.T) {
+		data, err := os.ReadFile(filepath.Join(b.dir, "system_info.json"))
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"version": "1.2.3"`)
+	})
+}
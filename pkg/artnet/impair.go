@@ -0,0 +1,127 @@
+package artnet
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Impairment simulates a lossy network between the server and a receiver:
+// packets can be lost, delivered twice, or delayed. With jitter larger than
+// the frame period, delayed packets arrive out of order.
+type Impairment struct {
+	Drop      float64       // probability a packet is lost
+	Duplicate float64       // probability a packet is delivered twice
+	Delay     time.Duration // added to every delivery
+	Jitter    time.Duration // random extra delay per delivery, up to this much
+	Seed      int64         // makes the pattern repeatable; 0 seeds from the clock
+}
+
+// ParseImpairment reads an impairment such as "drop=0.05,dup=0.02,delay=5ms,jitter=10ms,seed=1".
+// Omitted keys are zero.
+func ParseImpairment(s string) (Impairment, error) {
+	var imp Impairment
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return imp, fmt.Errorf("impairment %q: expected key=value", part)
+		}
+
+		var err error
+		switch key {
+		case "drop":
+			imp.Drop, err = parseProbability(value)
+		case "dup":
+			imp.Duplicate, err = parseProbability(value)
+		case "delay":
+			imp.Delay, err = time.ParseDuration(value)
+		case "jitter":
+			imp.Jitter, err = time.ParseDuration(value)
+		case "seed":
+			imp.Seed, err = strconv.ParseInt(value, 10, 64)
+		default:
+			err = fmt.Errorf("unknown key")
+		}
+		if err != nil {
+			return imp, fmt.Errorf("impairment %q: %w", part, err)
+		}
+	}
+	return imp, nil
+}
+
+func parseProbability(s string) (float64, error) {
+	p, err := strconv.ParseFloat(s, 64)
+	if err == nil && (p < 0 || p > 1) {
+		err = fmt.Errorf("probability %v outside 0-1", p)
+	}
+	return p, err
+}
+
+// ImpairmentFromEnv reads ARTNET_IMPAIR, so whole suites can run against
+// degraded capture. It returns nil when the variable is unset or invalid.
+func ImpairmentFromEnv() *Impairment {
+	s := os.Getenv("ARTNET_IMPAIR")
+	if s == "" {
+		return nil
+	}
+	imp, err := ParseImpairment(s)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "artnet: ignoring ARTNET_IMPAIR: %v\n", err)
+		return nil
+	}
+	return &imp
+}
+
+func (imp Impairment) rng() *rand.Rand {
+	seed := imp.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return rand.New(rand.NewSource(seed))
+}
+
+// deliveries returns the delay of each copy of one packet that arrives:
+// none when it is dropped, two when it is duplicated.
+func (imp Impairment) deliveries(rng *rand.Rand) []time.Duration {
+	if rng.Float64() < imp.Drop {
+		return nil
+	}
+	copies := 1
+	if rng.Float64() < imp.Duplicate {
+		copies = 2
+	}
+
+	delays := make([]time.Duration, copies)
+	for i := range delays {
+		delays[i] = imp.Delay
+		if imp.Jitter > 0 {
+			delays[i] += time.Duration(rng.Int63n(int64(imp.Jitter) + 1))
+		}
+	}
+	return delays
+}
+
+// Impair applies an impairment to captured frames, as if they had crossed
+// the impaired network: dropped frames are removed, duplicates repeated, and
+// every frame's Timestamp delayed. The result is in arrival order.
+func Impair(frames []Frame, imp Impairment) []Frame {
+	rng := imp.rng()
+	var out []Frame
+	for _, frame := range frames {
+		for _, delay := range imp.deliveries(rng) {
+			f := frame
+			f.Timestamp = frame.Timestamp.Add(delay)
+			out = append(out, f)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Timestamp.Before(out[j].Timestamp) })
+	return out
+}
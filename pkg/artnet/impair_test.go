@@ -0,0 +1,97 @@
+package artnet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var streamStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// stream renders n sequenced frames of universe 0 at the given period.
+func stream(n int, period time.Duration) []Frame {
+	frames := make([]Frame, n)
+	for i := range frames {
+		frames[i] = Frame{Timestamp: streamStart.Add(time.Duration(i) * period), Sequence: byte(i%255 + 1)}
+	}
+	return frames
+}
+
+func TestParseImpairment(t *testing.T) {
+	imp, err := ParseImpairment("drop=0.05, dup=0.02,delay=5ms,jitter=10ms,seed=3")
+	require.NoError(t, err)
+	assert.Equal(t, Impairment{Drop: 0.05, Duplicate: 0.02, Delay: 5 * time.Millisecond, Jitter: 10 * time.Millisecond, Seed: 3}, imp)
+
+	imp, err = ParseImpairment("")
+	require.NoError(t, err)
+	assert.Equal(t, Impairment{}, imp)
+
+	for _, bad := range []string{"drop", "drop=2", "delay=soon", "loss=0.1"} {
+		_, err := ParseImpairment(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestImpair(t *testing.T) {
+	frames := stream(1000, 25*time.Millisecond)
+
+	t.Run("Repeatable", func(t *testing.T) {
+		imp := Impairment{Drop: 0.1, Duplicate: 0.1, Jitter: 30 * time.Millisecond, Seed: 42}
+		assert.Equal(t, Impair(frames, imp), Impair(frames, imp))
+	})
+
+	t.Run("DropAndDuplicateRates", func(t *testing.T) {
+		dropped := Impair(frames, Impairment{Drop: 0.2, Seed: 1})
+		assert.InDelta(t, 800, len(dropped), 60)
+		duplicated := Impair(frames, Impairment{Duplicate: 0.2, Seed: 1})
+		assert.InDelta(t, 1200, len(duplicated), 60)
+	})
+
+	t.Run("Delay", func(t *testing.T) {
+		delayed := Impair(frames[:3], Impairment{Delay: 5 * time.Millisecond})
+		require.Len(t, delayed, 3)
+		assert.Equal(t, streamStart.Add(5*time.Millisecond), delayed[0].Timestamp)
+	})
+
+	t.Run("JitterReorders", func(t *testing.T) {
+		out := Impair(frames, Impairment{Jitter: 100 * time.Millisecond, Seed: 1})
+		require.Len(t, out, len(frames))
+		reordered := false
+		for i := 1; i < len(out); i++ {
+			assert.False(t, out[i].Timestamp.Before(out[i-1].Timestamp), "Frames are in arrival order")
+			reordered = reordered || sequenceDiff(out[i-1].Sequence, out[i].Sequence) > 127
+		}
+		assert.True(t, reordered)
+	})
+}
+
+func TestReceiverImpairment(t *testing.T) {
+	receiver := NewReceiver("127.0.0.1:0")
+
+	receiver.SetImpairment(&Impairment{Drop: 1})
+	receiver.deliver(Frame{Sequence: 1})
+	assert.Empty(t, receiver.GetFrames(), "Dropped packets are never recorded")
+
+	receiver.SetImpairment(&Impairment{Duplicate: 1, Delay: 20 * time.Millisecond})
+	sent := time.Now()
+	receiver.deliver(Frame{Sequence: 2, Timestamp: sent})
+	assert.Empty(t, receiver.GetFrames(), "Delayed packets are not recorded yet")
+	require.Eventually(t, func() bool { return len(receiver.GetFrames()) == 2 }, time.Second, 5*time.Millisecond)
+	for _, frame := range receiver.GetFrames() {
+		assert.Equal(t, byte(2), frame.Sequence)
+		assert.GreaterOrEqual(t, frame.Timestamp.Sub(sent), 20*time.Millisecond, "Timestamps are arrival times")
+	}
+
+	receiver.SetImpairment(nil)
+	receiver.deliver(Frame{Sequence: 3})
+	assert.Len(t, receiver.GetFrames(), 3)
+
+	receiver.SetImpairment(&Impairment{Delay: 20 * time.Millisecond})
+	receiver.deliver(Frame{Sequence: 4})
+	require.NoError(t, receiver.Stop())
+	receiver.deliver(Frame{Sequence: 5})
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, receiver.GetFrames(), 3, "Nothing is recorded once the receiver is stopped, delayed frames included")
+}
@@ -0,0 +1,305 @@
+package artnet
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Manager shares one bound Art-Net socket between any number of
+// subscriptions, so captures in the same process never race for the port and
+// tests that capture can run in parallel. The socket is bound by the first
+// subscription and released when the last one closes.
+type Manager struct {
+	addr string
+
+	mu   sync.Mutex
+	conn *net.UDPConn
+	subs map[*Subscription]struct{}
+}
+
+// Subscription receives the frames and syncs the manager's socket sees while
+// it is open, narrowed by its options. Each subscription keeps its own
+// frames, so one test clearing or impairing its capture does not affect another.
+type Subscription struct {
+	manager   *Manager
+	store     *Receiver
+	universes map[int]bool
+	from      time.Time
+	until     time.Time
+	handler   Handler
+	closeOnce sync.Once
+}
+
+// Handler answers an Art-Net packet that is neither ArtDmx nor ArtSync, such
+// as ArtPoll or ArtRdm. local is the manager's bound address and from the
+// sender, which the returned replies are sent back to. packet is only valid
+// during the call.
+type Handler func(packet []byte, local, from *net.UDPAddr) [][]byte
+
+// Option narrows what a subscription captures.
+type Option func(*Subscription)
+
+// Universes keeps only frames for the given universes (Art-Net numbering).
+func Universes(universes ...int) Option {
+	return func(s *Subscription) {
+		s.universes = make(map[int]bool, len(universes))
+		for _, u := range universes {
+			s.universes[u] = true
+		}
+	}
+}
+
+// Window keeps only frames and syncs arriving between from and until. A zero
+// time leaves that end open.
+func Window(from, until time.Time) Option {
+	return func(s *Subscription) {
+		s.from, s.until = from, until
+	}
+}
+
+// Respond answers other Art-Net packets with h, from the manager's socket, so
+// a mock node can share the port with captures.
+func Respond(h Handler) Option {
+	return func(s *Subscription) {
+		s.handler = h
+	}
+}
+
+// NewManager creates a manager for addr, in the format ":6454" or "127.0.0.1:6454".
+func NewManager(addr string) *Manager {
+	if addr == "" {
+		addr = fmt.Sprintf(":%d", ArtNetPort)
+	}
+	return &Manager{addr: addr, subs: make(map[*Subscription]struct{})}
+}
+
+var (
+	sharedOnce    sync.Once
+	sharedManager *Manager
+)
+
+// SharedManager returns the process-wide manager for ListenAddrFromEnv.
+func SharedManager() *Manager {
+	sharedOnce.Do(func() {
+		sharedManager = NewManager(ListenAddrFromEnv())
+	})
+	return sharedManager
+}
+
+// ListenAddrFromEnv returns the address to capture Art-Net on: port
+// ARTNET_LISTEN_PORT (default 6454), on localhost only when ARTNET_BROADCAST
+// is 127.0.0.1.
+func ListenAddrFromEnv() string {
+	port := os.Getenv("ARTNET_LISTEN_PORT")
+	if port == "" {
+		port = strconv.Itoa(ArtNetPort)
+	}
+	if os.Getenv("ARTNET_BROADCAST") == "127.0.0.1" {
+		return "127.0.0.1:" + port
+	}
+	return ":" + port
+}
+
+var (
+	captureHooksMu sync.Mutex
+	captureHooks   []func(testing.TB, *Subscription)
+)
+
+// OnCapture registers a hook that Capture runs for every subscription it
+// opens, so other packages can attach captures to a test (see pkg/artifacts).
+func OnCapture(hook func(testing.TB, *Subscription)) {
+	captureHooksMu.Lock()
+	defer captureHooksMu.Unlock()
+	captureHooks = append(captureHooks, hook)
+}
+
+// Capture subscribes a test to the shared manager and closes the
+// subscription when the test ends. The test is skipped if the port cannot be
+// bound.
+func Capture(t testing.TB, opts ...Option) *Subscription {
+	t.Helper()
+	sub, err := SharedManager().Subscribe(opts...)
+	if err != nil {
+		t.Skipf("Could not start Art-Net receiver: %v", err)
+	}
+	t.Cleanup(sub.Close)
+
+	captureHooksMu.Lock()
+	hooks := captureHooks[:len(captureHooks):len(captureHooks)]
+	captureHooksMu.Unlock()
+	for _, hook := range hooks {
+		hook(t, sub)
+	}
+	return sub
+}
+
+// Subscribe opens a subscription, binding the socket if it is the first.
+// ARTNET_IMPAIR applies to each subscription independently.
+func (m *Manager) Subscribe(opts ...Option) (*Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.conn == nil {
+		udpAddr, err := net.ResolveUDPAddr("udp", m.addr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve UDP address: %w", err)
+		}
+		conn, err := net.ListenUDP("udp", udpAddr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen on UDP: %w", err)
+		}
+		m.conn = conn
+		go m.receiveLoop(conn)
+	}
+
+	sub := &Subscription{manager: m, store: NewReceiver(m.addr)}
+	for _, opt := range opts {
+		opt(sub)
+	}
+	m.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+// Addr returns the bound address, or nil while no subscription is open.
+func (m *Manager) Addr() net.Addr {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.conn == nil {
+		return nil
+	}
+	return m.conn.LocalAddr()
+}
+
+// Subscribers returns the number of open subscriptions.
+func (m *Manager) Subscribers() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.subs)
+}
+
+func (m *Manager) unsubscribe(sub *Subscription) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.subs, sub)
+	if len(m.subs) == 0 && m.conn != nil {
+		_ = m.conn.Close()
+		m.conn = nil
+	}
+}
+
+// receiveLoop reads conn until it is closed, fanning packets out to the
+// subscriptions open at the time.
+func (m *Manager) receiveLoop(conn *net.UDPConn) {
+	buf := make([]byte, 1024)
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		now := time.Now()
+
+		m.mu.Lock()
+		subs := make([]*Subscription, 0, len(m.subs))
+		for sub := range m.subs {
+			subs = append(subs, sub)
+		}
+		m.mu.Unlock()
+
+		isSync := isArtSync(buf[:n])
+		var frame Frame
+		if !isSync {
+			var ok bool
+			if frame, ok = parseArtNetPacket(buf[:n]); !ok {
+				m.answer(conn, subs, buf[:n], from)
+				continue
+			}
+		}
+
+		for _, sub := range subs {
+			if !sub.inWindow(now) {
+				continue
+			}
+			if isSync {
+				sub.store.recordSync(now)
+			} else if sub.universes == nil || sub.universes[frame.Universe] {
+				sub.store.deliver(frame)
+			}
+		}
+	}
+}
+
+// answer passes a packet to the subscriptions' handlers and sends their replies.
+func (m *Manager) answer(conn *net.UDPConn, subs []*Subscription, packet []byte, from *net.UDPAddr) {
+	local, _ := conn.LocalAddr().(*net.UDPAddr)
+	for _, sub := range subs {
+		if sub.handler == nil {
+			continue
+		}
+		for _, reply := range sub.handler(packet, local, from) {
+			_, _ = conn.WriteToUDP(reply, from)
+		}
+	}
+}
+
+func (s *Subscription) inWindow(at time.Time) bool {
+	return (s.from.IsZero() || !at.Before(s.from)) && (s.until.IsZero() || !at.After(s.until))
+}
+
+// Close ends the subscription, releasing the socket if it was the last, and
+// drops any impaired frames still being delayed. It is safe to call more
+// than once.
+func (s *Subscription) Close() {
+	s.closeOnce.Do(func() {
+		s.manager.unsubscribe(s)
+		_ = s.store.Stop()
+	})
+}
+
+// SetImpairment impairs this subscription's capture; see Receiver.SetImpairment.
+func (s *Subscription) SetImpairment(imp *Impairment) {
+	s.store.SetImpairment(imp)
+}
+
+// CaptureFrames clears the capture, waits for duration and returns the
+// frames received meanwhile.
+func (s *Subscription) CaptureFrames(ctx context.Context, duration time.Duration) ([]Frame, error) {
+	s.ClearFrames()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(duration):
+	}
+	return s.GetFrames(), nil
+}
+
+// GetFrames returns all captured frames.
+func (s *Subscription) GetFrames() []Frame {
+	return s.store.GetFrames()
+}
+
+// ClearFrames clears the captured frames and syncs.
+func (s *Subscription) ClearFrames() {
+	s.store.ClearFrames()
+}
+
+// GetSyncs returns the arrival times of the captured ArtSync packets.
+func (s *Subscription) GetSyncs() []time.Time {
+	return s.store.GetSyncs()
+}
+
+// GetLatestFrame returns the most recent frame for a universe.
+func (s *Subscription) GetLatestFrame(universe int) *Frame {
+	return s.store.GetLatestFrame(universe)
+}
+
+// GetChannelValue returns the current value of a specific channel.
+func (s *Subscription) GetChannelValue(universe, channel int) (byte, bool) {
+	return s.store.GetChannelValue(universe, channel)
+}
@@ -0,0 +1,152 @@
+package artnet
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// send writes packets to the manager's bound socket.
+func send(t *testing.T, m *Manager, packets ...[]byte) {
+	t.Helper()
+	conn, err := net.DialUDP("udp", nil, m.Addr().(*net.UDPAddr))
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+	for _, packet := range packets {
+		_, err := conn.Write(packet)
+		require.NoError(t, err)
+	}
+}
+
+func TestManagerFansOutToSubscriptions(t *testing.T) {
+	t.Setenv("ARTNET_IMPAIR", "")
+	m := NewManager("127.0.0.1:0")
+
+	all, err := m.Subscribe()
+	require.NoError(t, err)
+	defer all.Close()
+	second, err := m.Subscribe(Universes(1))
+	require.NoError(t, err)
+	defer second.Close()
+	assert.Equal(t, 2, m.Subscribers())
+
+	send(t, m,
+		buildDMXPacket(0, 1, []byte{10}),
+		buildSyncPacket(),
+		buildDMXPacket(1, 2, []byte{20}),
+	)
+	require.Eventually(t, func() bool {
+		return len(all.GetFrames()) == 2 && len(second.GetFrames()) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, 1, second.GetFrames()[0].Universe, "The universe filter drops other universes")
+	assert.Len(t, all.GetSyncs(), 1)
+	assert.Len(t, second.GetSyncs(), 1, "Syncs reach every subscription")
+	value, ok := all.GetChannelValue(1, 1)
+	assert.True(t, ok)
+	assert.Equal(t, byte(20), value)
+
+	all.ClearFrames()
+	assert.Empty(t, all.GetFrames())
+	assert.Len(t, second.GetFrames(), 1, "Clearing one subscription leaves the others")
+}
+
+func TestManagerWindow(t *testing.T) {
+	t.Setenv("ARTNET_IMPAIR", "")
+	m := NewManager("127.0.0.1:0")
+
+	closed, err := m.Subscribe(Window(time.Time{}, time.Now().Add(-time.Second)))
+	require.NoError(t, err)
+	defer closed.Close()
+	open, err := m.Subscribe(Window(time.Now(), time.Time{}))
+	require.NoError(t, err)
+	defer open.Close()
+
+	send(t, m, buildDMXPacket(0, 1, []byte{10}))
+	require.Eventually(t, func() bool { return len(open.GetFrames()) == 1 }, time.Second, 10*time.Millisecond)
+	assert.Empty(t, closed.GetFrames(), "Frames after the window are dropped")
+}
+
+func TestManagerReleasesSocket(t *testing.T) {
+	m := NewManager("127.0.0.1:0")
+
+	first, err := m.Subscribe()
+	require.NoError(t, err)
+	second, err := m.Subscribe()
+	require.NoError(t, err)
+	addr := m.Addr().String()
+
+	first.Close()
+	first.Close()
+	assert.NotNil(t, m.Addr(), "The socket stays bound while a subscription is open")
+	assert.Equal(t, 1, m.Subscribers())
+
+	second.Close()
+	assert.Nil(t, m.Addr())
+
+	// The port is free again for anyone, including another receiver
+	receiver := NewReceiver(addr)
+	require.NoError(t, receiver.Start())
+	require.NoError(t, receiver.Stop())
+
+	third, err := m.Subscribe()
+	require.NoError(t, err, "The manager rebinds on the next subscription")
+	defer third.Close()
+	send(t, m, buildDMXPacket(0, 1, []byte{30}))
+	require.Eventually(t, func() bool { return len(third.GetFrames()) == 1 }, time.Second, 10*time.Millisecond)
+}
+
+func TestCapture(t *testing.T) {
+	t.Setenv("ARTNET_BROADCAST", "127.0.0.1")
+	t.Setenv("ARTNET_LISTEN_PORT", "0")
+	assert.Equal(t, "127.0.0.1:0", ListenAddrFromEnv())
+
+	var sub *Subscription
+	t.Run("Test", func(t *testing.T) {
+		sub = Capture(t)
+		assert.Equal(t, 1, SharedManager().Subscribers())
+	})
+	if sub == nil {
+		t.Skip("Could not bind the shared manager")
+	}
+	assert.Equal(t, 0, SharedManager().Subscribers(), "Capture closes the subscription when the test ends")
+}
+
+func TestManagerRespond(t *testing.T) {
+	t.Setenv("ARTNET_IMPAIR", "")
+	m := NewManager("127.0.0.1:0")
+
+	capture, err := m.Subscribe()
+	require.NoError(t, err)
+	defer capture.Close()
+	local := make(chan *net.UDPAddr, 1)
+	node, err := m.Subscribe(Universes(), Respond(func(packet []byte, at, from *net.UDPAddr) [][]byte {
+		local <- at
+		return [][]byte{append([]byte("reply:"), packet[8:10]...)}
+	}))
+	require.NoError(t, err)
+	defer node.Close()
+
+	conn, err := net.DialUDP("udp", nil, m.Addr().(*net.UDPAddr))
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	poll := buildSyncPacket()
+	poll[8], poll[9] = 0x00, 0x20 // OpPoll
+	_, err = conn.Write(poll)
+	require.NoError(t, err)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	reply := make([]byte, 64)
+	n, err := conn.Read(reply)
+	require.NoError(t, err)
+	assert.Equal(t, "reply:\x00\x20", string(reply[:n]), "The handler's reply goes back to the sender")
+	assert.Equal(t, m.Addr().String(), (<-local).String())
+
+	_, err = conn.Write(buildDMXPacket(0, 1, []byte{10}))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return len(capture.GetFrames()) == 1 }, time.Second, 10*time.Millisecond)
+	assert.Empty(t, node.GetFrames(), "Universes() with none captures no frames")
+}
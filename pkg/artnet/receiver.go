@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/binary"
 	"fmt"
+	"math/rand"
 	"net"
 	"sync"
 	"time"
@@ -17,6 +18,10 @@ const (
 	// OpDMX is the Art-Net opcode for DMX data
 	OpDMX = 0x5000
 
+	// OpSync is the Art-Net opcode for ArtSync, which latches the ArtDmx
+	// packets sent since the previous sync onto every universe at once
+	OpSync = 0x5200
+
 	// DMXChannels is the number of channels in a DMX universe
 	DMXChannels = 512
 )
@@ -27,6 +32,10 @@ type Frame struct {
 	Universe  int
 	Sequence  byte
 	Channels  [DMXChannels]byte
+
+	// SyncWindow counts the ArtSync packets received before this frame.
+	// Frames sharing a SyncWindow are output together at the next sync.
+	SyncWindow int
 }
 
 // Receiver listens for Art-Net packets and captures DMX frames.
@@ -35,17 +44,45 @@ type Receiver struct {
 	conn   *net.UDPConn
 	mu     sync.RWMutex
 	frames []Frame
+	syncs  []time.Time
+
+	// updated is closed and replaced whenever a frame is recorded, waking
+	// CaptureUntil.
+	updated chan struct{}
+
+	impair *Impairment
+	rng    *rand.Rand
+
+	// pending holds the timers of delayed deliveries, which Stop cancels
+	pending map[*time.Timer]struct{}
+	stopped bool
 }
 
 // NewReceiver creates a new Art-Net receiver.
 // addr should be in the format ":6454" or "0.0.0.0:6454"
+// When ARTNET_IMPAIR is set, the receiver starts with that impairment.
 func NewReceiver(addr string) *Receiver {
 	if addr == "" {
 		addr = fmt.Sprintf(":%d", ArtNetPort)
 	}
-	return &Receiver{
-		addr:   addr,
-		frames: make([]Frame, 0),
+	r := &Receiver{
+		addr:    addr,
+		frames:  make([]Frame, 0),
+		updated: make(chan struct{}),
+	}
+	r.SetImpairment(ImpairmentFromEnv())
+	return r
+}
+
+// SetImpairment makes the receiver drop, duplicate and delay incoming DMX
+// packets as the impairment describes. nil restores perfect delivery.
+func (r *Receiver) SetImpairment(imp *Impairment) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.impair = imp
+	r.rng = nil
+	if imp != nil {
+		r.rng = imp.rng()
 	}
 }
 
@@ -62,14 +99,26 @@ func (r *Receiver) Start() error {
 	}
 
 	r.conn = conn
+	r.mu.Lock()
+	r.stopped = false
+	r.mu.Unlock()
 
 	go r.receiveLoop()
 
 	return nil
 }
 
-// Stop stops the receiver.
+// Stop stops the receiver. Impaired frames still being delayed are dropped,
+// and nothing more is recorded until the receiver is started again.
 func (r *Receiver) Stop() error {
+	r.mu.Lock()
+	r.stopped = true
+	for timer := range r.pending {
+		timer.Stop()
+	}
+	r.pending = nil
+	r.mu.Unlock()
+
 	if r.conn != nil {
 		return r.conn.Close()
 	}
@@ -117,6 +166,17 @@ func (r *Receiver) ClearFrames() {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.frames = make([]Frame, 0)
+	r.syncs = nil
+}
+
+// GetSyncs returns the arrival times of the captured ArtSync packets.
+func (r *Receiver) GetSyncs() []time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]time.Time, len(r.syncs))
+	copy(result, r.syncs)
+	return result
 }
 
 // GetLatestFrame returns the most recent frame for a universe.
@@ -162,6 +222,11 @@ func (r *Receiver) receiveLoop() {
 			return
 		}
 
+		if isArtSync(buf[:n]) {
+			r.recordSync(time.Now())
+			continue
+		}
+
 		if n < 18 {
 			continue // Too short for Art-Net DMX
 		}
@@ -171,10 +236,67 @@ func (r *Receiver) receiveLoop() {
 			continue
 		}
 
-		r.mu.Lock()
-		r.frames = append(r.frames, frame)
-		r.mu.Unlock()
+		r.deliver(frame)
+	}
+}
+
+// recordSync records the arrival of an ArtSync packet.
+func (r *Receiver) recordSync(at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.syncs = append(r.syncs, at)
+}
+
+// deliver records a frame, through the impairment if one is set.
+func (r *Receiver) deliver(frame Frame) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stopped {
+		return
+	}
+	if r.impair == nil {
+		r.appendLocked(frame)
+		return
+	}
+	for _, delay := range r.impair.deliveries(r.rng) {
+		if delay == 0 {
+			r.appendLocked(frame)
+			continue
+		}
+		var timer *time.Timer
+		timer = time.AfterFunc(delay, func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			if _, ok := r.pending[timer]; !ok {
+				return // the receiver was stopped meanwhile
+			}
+			delete(r.pending, timer)
+			f := frame
+			f.Timestamp = time.Now()
+			r.appendLocked(f)
+		})
+		if r.pending == nil {
+			r.pending = make(map[*time.Timer]struct{})
+		}
+		r.pending[timer] = struct{}{}
+	}
+}
+
+func (r *Receiver) appendLocked(frame Frame) {
+	frame.SyncWindow = len(r.syncs)
+	r.frames = append(r.frames, frame)
+	close(r.updated)
+	r.updated = make(chan struct{})
+}
+
+// isArtSync reports whether data is an ArtSync packet: the Art-Net header,
+// opcode, protocol version and two aux bytes.
+func isArtSync(data []byte) bool {
+	if len(data) < 14 || string(data[:8]) != "Art-Net\x00" {
+		return false
 	}
+	return binary.LittleEndian.Uint16(data[8:10]) == OpSync
 }
 
 func parseArtNetPacket(data []byte) (Frame, bool) {
@@ -0,0 +1,166 @@
+package artnet
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildDMXPacket builds an ArtDmx packet as lacylights-go sends it.
+func buildDMXPacket(universe uint16, sequence byte, data []byte) []byte {
+	packet := make([]byte, 18+len(data))
+	copy(packet, "Art-Net\x00")
+	binary.LittleEndian.PutUint16(packet[8:10], OpDMX)
+	binary.BigEndian.PutUint16(packet[10:12], 14) // protocol version
+	packet[12] = sequence
+	binary.LittleEndian.PutUint16(packet[14:16], universe)
+	binary.BigEndian.PutUint16(packet[16:18], uint16(len(data)))
+	copy(packet[18:], data)
+	return packet
+}
+
+// buildSyncPacket builds an ArtSync packet.
+func buildSyncPacket() []byte {
+	packet := make([]byte, 14)
+	copy(packet, "Art-Net\x00")
+	binary.LittleEndian.PutUint16(packet[8:10], OpSync)
+	binary.BigEndian.PutUint16(packet[10:12], 14) // protocol version
+	return packet
+}
+
+func TestParseArtNetPacket(t *testing.T) {
+	data := make([]byte, DMXChannels)
+	data[0] = 255
+	data[3] = 128
+	data[511] = 7
+
+	frame, ok := parseArtNetPacket(buildDMXPacket(2, 42, data))
+	require.True(t, ok)
+	assert.Equal(t, 2, frame.Universe)
+	assert.Equal(t, byte(42), frame.Sequence)
+	assert.Equal(t, byte(255), frame.Channels[0])
+	assert.Equal(t, byte(128), frame.Channels[3])
+	assert.Equal(t, byte(7), frame.Channels[511])
+
+	t.Run("ShortDataLeavesRestZero", func(t *testing.T) {
+		frame, ok := parseArtNetPacket(buildDMXPacket(0, 1, []byte{10, 20}))
+		require.True(t, ok)
+		assert.Equal(t, byte(20), frame.Channels[1])
+		assert.Equal(t, byte(0), frame.Channels[2])
+	})
+
+	t.Run("Rejects", func(t *testing.T) {
+		valid := buildDMXPacket(0, 1, []byte{1, 2, 3, 4})
+
+		wrongHeader := append([]byte{}, valid...)
+		copy(wrongHeader, "Art-Nex")
+
+		wrongOpcode := append([]byte{}, valid...)
+		binary.LittleEndian.PutUint16(wrongOpcode[8:10], 0x2000) // ArtPoll
+
+		giantLength := append([]byte{}, valid...)
+		binary.BigEndian.PutUint16(giantLength[16:18], 0xFFFF)
+
+		for name, packet := range map[string][]byte{
+			"Empty":       nil,
+			"Truncated":   valid[:17],
+			"WrongHeader": wrongHeader,
+			"WrongOpcode": wrongOpcode,
+			"GiantLength": giantLength,
+		} {
+			_, ok := parseArtNetPacket(packet)
+			assert.False(t, ok, name)
+		}
+	})
+}
+
+func TestIsArtSync(t *testing.T) {
+	assert.True(t, isArtSync(buildSyncPacket()))
+	assert.False(t, isArtSync(buildSyncPacket()[:13]), "Truncated")
+	assert.False(t, isArtSync(buildDMXPacket(0, 1, []byte{1, 2})), "ArtDmx")
+
+	_, ok := parseArtNetPacket(buildSyncPacket())
+	assert.False(t, ok, "ArtSync is not a DMX frame")
+}
+
+func TestReceiverSyncWindows(t *testing.T) {
+	receiver := NewReceiver("127.0.0.1:0")
+	require.NoError(t, receiver.Start())
+	defer func() { _ = receiver.Stop() }()
+
+	conn, err := net.DialUDP("udp", nil, receiver.conn.LocalAddr().(*net.UDPAddr))
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	for _, packet := range [][]byte{
+		buildDMXPacket(0, 1, []byte{10}),
+		buildSyncPacket(),
+		buildDMXPacket(0, 2, []byte{20}),
+		buildDMXPacket(1, 2, []byte{20}),
+		buildSyncPacket(),
+	} {
+		_, err := conn.Write(packet)
+		require.NoError(t, err)
+	}
+
+	require.Eventually(t, func() bool {
+		return len(receiver.GetSyncs()) == 2 && len(receiver.GetFrames()) == 3
+	}, time.Second, 10*time.Millisecond)
+
+	frames := receiver.GetFrames()
+	assert.Equal(t, 0, frames[0].SyncWindow, "Frames before any sync are in window 0")
+	assert.Equal(t, 1, frames[1].SyncWindow)
+	assert.Equal(t, 1, frames[2].SyncWindow, "Both universes of one step share a window")
+
+	receiver.ClearFrames()
+	assert.Empty(t, receiver.GetSyncs(), "ClearFrames also clears syncs")
+}
+
+// FuzzParseArtNetPacket ensures malformed UDP payloads never panic the parser and
+// that accepted packets decode consistently with their header. The corpus in
+// testdata/fuzz adds packets as they appear in a capture: a lit universe, an
+// ArtSync, an ArtPoll, an odd-length frame, and one shorter than its header.
+func FuzzParseArtNetPacket(f *testing.F) {
+	full := make([]byte, DMXChannels)
+	for i := range full {
+		full[i] = byte(i)
+	}
+
+	// Frames shaped like real lacylights-go output: full universes, a sparse short frame,
+	// and an oversize payload that must be truncated to 512 channels
+	f.Add(buildDMXPacket(0, 1, full))
+	f.Add(buildDMXPacket(3, 255, full))
+	f.Add(buildDMXPacket(0, 0, []byte{255, 0, 128}))
+	f.Add(buildDMXPacket(0, 9, make([]byte, 600)))
+	f.Add([]byte("Art-Net\x00"))
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		frame, ok := parseArtNetPacket(data)
+		if !ok {
+			return
+		}
+
+		length := int(binary.BigEndian.Uint16(data[16:18]))
+		if length > DMXChannels {
+			length = DMXChannels
+		}
+		if frame.Universe != int(binary.LittleEndian.Uint16(data[14:16])) {
+			t.Fatalf("universe mismatch: %d", frame.Universe)
+		}
+		for i := 0; i < length; i++ {
+			if frame.Channels[i] != data[18+i] {
+				t.Fatalf("channel %d mismatch: %d vs %d", i+1, frame.Channels[i], data[18+i])
+			}
+		}
+		for i := length; i < DMXChannels; i++ {
+			if frame.Channels[i] != 0 {
+				t.Fatalf("channel %d beyond length %d should be zero", i+1, length)
+			}
+		}
+	})
+}
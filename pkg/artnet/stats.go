@@ -0,0 +1,140 @@
+package artnet
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// StreamStats describes the frames captured for one universe, and how far
+// the capture departs from a clean stream. Lost, Duplicates and Late come
+// from the ArtDmx sequence numbers; when the sender does not sequence its
+// packets, Lost is estimated from gaps and the others stay zero.
+type StreamStats struct {
+	Universe     int
+	Frames       int           // frames used, after dropping duplicates and late arrivals
+	Duration     time.Duration // first to last frame used
+	MedianPeriod time.Duration
+	MaxGap       time.Duration
+	Lost         int
+	Duplicates   int
+	Late         int // frames that arrived after a later frame
+}
+
+// Stats analyses the frames for universe (Art-Net numbering). Duplicated and
+// late frames are left out of the timing so they don't distort the rate.
+func Stats(frames []Frame, universe int) StreamStats {
+	s := StreamStats{Universe: universe}
+	var sequenced bool
+	var last byte
+	var kept []time.Time
+
+	for _, frame := range frames {
+		if frame.Universe != universe {
+			continue
+		}
+		if frame.Sequence != 0 {
+			sequenced = true
+			if last != 0 {
+				switch diff := sequenceDiff(last, frame.Sequence); {
+				case diff == 0:
+					s.Duplicates++
+					continue
+				case diff > 127:
+					s.Late++
+					if s.Lost > 0 {
+						s.Lost-- // it was counted lost when the later frame arrived
+					}
+					continue
+				default:
+					s.Lost += diff - 1
+				}
+			}
+			last = frame.Sequence
+		}
+		kept = append(kept, frame.Timestamp)
+	}
+
+	s.Frames = len(kept)
+	if len(kept) < 2 {
+		return s
+	}
+	s.Duration = kept[len(kept)-1].Sub(kept[0])
+
+	periods := make([]time.Duration, len(kept)-1)
+	for i := 1; i < len(kept); i++ {
+		periods[i-1] = kept[i].Sub(kept[i-1])
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i] < periods[j] })
+	s.MedianPeriod = periods[len(periods)/2]
+	s.MaxGap = periods[len(periods)-1]
+
+	if !sequenced && s.MedianPeriod > 0 {
+		for _, p := range periods {
+			if missing := int((p+s.MedianPeriod/2)/s.MedianPeriod) - 1; missing > 0 {
+				s.Lost += missing
+			}
+		}
+	}
+	return s
+}
+
+// sequenceDiff returns how far cur is after last in the 1-255 sequence,
+// which wraps from 255 to 1. Values over 127 mean cur is older than last.
+func sequenceDiff(last, cur byte) int {
+	return ((int(cur) - 1) - (int(last) - 1) + 255) % 255
+}
+
+// Rate returns the frame rate implied by the median period, in Hz.
+func (s StreamStats) Rate() float64 {
+	if s.MedianPeriod <= 0 {
+		return 0
+	}
+	return float64(time.Second) / float64(s.MedianPeriod)
+}
+
+// Degraded reports whether frames were lost, duplicated or reordered on the
+// way to the receiver, so timing assertions should allow for it.
+func (s StreamStats) Degraded() bool {
+	return s.Lost > 0 || s.Duplicates > 0 || s.Late > 0
+}
+
+// Diagnostics describes each way the capture is degraded, one line each.
+func (s StreamStats) Diagnostics() []string {
+	var lines []string
+	if s.Lost > 0 {
+		lines = append(lines, fmt.Sprintf("universe %d: %d of %d frames lost (%.1f%%)",
+			s.Universe, s.Lost, s.Frames+s.Lost, float64(s.Lost)*100/float64(s.Frames+s.Lost)))
+	}
+	if s.Duplicates > 0 {
+		lines = append(lines, fmt.Sprintf("universe %d: %d duplicate frames ignored", s.Universe, s.Duplicates))
+	}
+	if s.Late > 0 {
+		lines = append(lines, fmt.Sprintf("universe %d: %d frames arrived out of order and were ignored", s.Universe, s.Late))
+	}
+	if s.Degraded() && s.MedianPeriod > 0 {
+		lines = append(lines, fmt.Sprintf("universe %d: largest gap %v (%.1f periods of %v)", s.Universe,
+			s.MaxGap.Round(time.Millisecond), float64(s.MaxGap)/float64(s.MedianPeriod), s.MedianPeriod.Round(time.Millisecond)))
+	}
+	return lines
+}
+
+// InOrder returns the frames without duplicates and late arrivals, judged by
+// sequence number per universe, so analysis sees each frame once and in the
+// order it was sent. Unsequenced frames are kept as they are.
+func InOrder(frames []Frame) []Frame {
+	last := make(map[int]byte)
+	out := make([]Frame, 0, len(frames))
+	for _, frame := range frames {
+		if frame.Sequence != 0 {
+			if prev := last[frame.Universe]; prev != 0 {
+				if diff := sequenceDiff(prev, frame.Sequence); diff == 0 || diff > 127 {
+					continue
+				}
+			}
+			last[frame.Universe] = frame.Sequence
+		}
+		out = append(out, frame)
+	}
+	return out
+}
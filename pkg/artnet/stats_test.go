@@ -0,0 +1,82 @@
+package artnet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(t *testing.T) {
+	frames := stream(400, 25*time.Millisecond)
+
+	t.Run("Clean", func(t *testing.T) {
+		s := Stats(frames, 0)
+		assert.Equal(t, 400, s.Frames)
+		assert.Equal(t, 25*time.Millisecond, s.MedianPeriod)
+		assert.InDelta(t, 40, s.Rate(), 0.01)
+		assert.False(t, s.Degraded())
+		assert.Empty(t, s.Diagnostics())
+		assert.Equal(t, 0, Stats(frames, 1).Frames, "Other universes are ignored")
+	})
+
+	t.Run("RateSurvivesImpairment", func(t *testing.T) {
+		for seed := int64(1); seed <= 10; seed++ {
+			impaired := Impair(frames, Impairment{Drop: 0.1, Duplicate: 0.1, Delay: 5 * time.Millisecond, Jitter: 10 * time.Millisecond, Seed: seed})
+			s := Stats(impaired, 0)
+			assert.InDelta(t, 40, s.Rate(), 40*0.15, "seed %d", seed)
+			assert.True(t, s.Degraded(), "seed %d", seed)
+			assert.InDelta(t, 40, s.Lost, 20, "seed %d", seed)
+			// Only frames lost from the very start or end go unnoticed.
+			assert.InDelta(t, 400, s.Frames+s.Lost, 3, "seed %d: every sent frame is used or lost", seed)
+			assert.NotEmpty(t, s.Diagnostics())
+		}
+	})
+
+	t.Run("Diagnostics", func(t *testing.T) {
+		// 1, 2, 2, 4, 3, 5: a duplicate, and frame 3 arriving after frame 4
+		order := []int{0, 1, 1, 3, 2, 4}
+		var got []Frame
+		for _, i := range order {
+			got = append(got, frames[i])
+		}
+		s := Stats(got, 0)
+		assert.Equal(t, 1, s.Duplicates)
+		assert.Equal(t, 1, s.Late)
+		assert.Equal(t, 0, s.Lost, "The late frame is not counted lost")
+		assert.Equal(t, []string{
+			"universe 0: 1 duplicate frames ignored",
+			"universe 0: 1 frames arrived out of order and were ignored",
+			"universe 0: largest gap 50ms (2.0 periods of 25ms)",
+		}, s.Diagnostics())
+	})
+
+	t.Run("SequenceWraps", func(t *testing.T) {
+		s := Stats(frames[250:260], 0)
+		assert.Equal(t, byte(255), frames[254].Sequence)
+		assert.Equal(t, byte(1), frames[255].Sequence)
+		assert.False(t, s.Degraded(), "255 is followed by 1")
+	})
+
+	t.Run("UnsequencedLossFromGaps", func(t *testing.T) {
+		var got []Frame
+		for i, f := range frames[:20] {
+			if i != 5 && i != 6 {
+				f.Sequence = 0
+				got = append(got, f)
+			}
+		}
+		s := Stats(got, 0)
+		assert.Equal(t, 2, s.Lost)
+		assert.Equal(t, 75*time.Millisecond, s.MaxGap)
+	})
+}
+
+func TestInOrder(t *testing.T) {
+	frames := stream(5, 25*time.Millisecond)
+	other := Frame{Universe: 1, Sequence: 1}
+	got := InOrder([]Frame{frames[0], frames[2], other, frames[1], frames[2], frames[3], {Universe: 0}})
+	require.Len(t, got, 5)
+	assert.Equal(t, []byte{1, 3, 1, 4, 0}, []byte{got[0].Sequence, got[1].Sequence, got[2].Sequence, got[3].Sequence, got[4].Sequence})
+}
@@ -0,0 +1,129 @@
+package artnet
+
+import "context"
+
+// Predicate decides when CaptureUntil stops. It is called once for each
+// captured frame, in arrival order, and returns true on the frame that ends
+// the capture. Predicates may keep state, so build a new one per capture,
+// and must not call back into the receiver.
+type Predicate func(Frame) bool
+
+// ChannelReaches stops on the first frame where channel (1-512) of universe
+// (Art-Net numbering) equals value.
+func ChannelReaches(universe, channel int, value byte) Predicate {
+	return ChannelMatches(universe, channel, func(v byte) bool { return v == value })
+}
+
+// ChannelMatches stops on the first frame where channel (1-512) of universe
+// satisfies cond, e.g. to wait for a fade to pass the halfway point.
+func ChannelMatches(universe, channel int, cond func(byte) bool) Predicate {
+	return func(f Frame) bool {
+		return f.Universe == universe && channel >= 1 && channel <= DMXChannels && cond(f.Channels[channel-1])
+	}
+}
+
+// FramesMatching stops once n frames have satisfied cond. The frames need not
+// be consecutive.
+func FramesMatching(n int, cond func(Frame) bool) Predicate {
+	matched := 0
+	return func(f Frame) bool {
+		if cond(f) {
+			matched++
+		}
+		return matched >= n
+	}
+}
+
+// UniverseFrames stops once n frames for universe have arrived.
+func UniverseFrames(universe, n int) Predicate {
+	return FramesMatching(n, func(f Frame) bool { return f.Universe == universe })
+}
+
+// Then stops once each predicate has been satisfied in turn, the next one
+// only seeing frames after the previous one stopped; for example a channel
+// reaching full and then a number of frames of hold after it.
+func Then(predicates ...Predicate) Predicate {
+	next := 0
+	return func(f Frame) bool {
+		if next < len(predicates) && predicates[next](f) {
+			next++
+		}
+		return next >= len(predicates)
+	}
+}
+
+// CaptureUntil starts the receiver, captures frames until the predicate is
+// satisfied and returns them, ending with the frame that satisfied it. If ctx
+// ends first, it returns the frames captured so far with ctx's error, so a
+// failing test can still report what it saw.
+func (r *Receiver) CaptureUntil(ctx context.Context, until Predicate) ([]Frame, error) {
+	if err := r.Start(); err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.Stop() }()
+
+	r.ClearFrames()
+	return r.waitUntil(ctx, until)
+}
+
+// CaptureUntil clears the capture and waits until the predicate is
+// satisfied; see Receiver.CaptureUntil. Use a ctx with a deadline: the
+// capture otherwise waits for as long as the predicate is unsatisfied.
+func (s *Subscription) CaptureUntil(ctx context.Context, until Predicate) ([]Frame, error) {
+	s.ClearFrames()
+	return s.store.waitUntil(ctx, until)
+}
+
+// WaitUntil waits, without clearing, until a frame captured from now on
+// satisfies the predicate, and returns that frame. It is the event-driven
+// replacement for sleeping a fixed time and reading GetChannelValue.
+func (s *Subscription) WaitUntil(ctx context.Context, until Predicate) (Frame, error) {
+	s.store.mu.RLock()
+	from := len(s.store.frames)
+	s.store.mu.RUnlock()
+
+	frames, err := s.store.waitFrom(ctx, from, until)
+	if err != nil {
+		return Frame{}, err
+	}
+	return frames[len(frames)-1], nil
+}
+
+// waitUntil feeds every captured frame to the predicate as it arrives and
+// returns the capture up to the one that satisfied it.
+func (r *Receiver) waitUntil(ctx context.Context, until Predicate) ([]Frame, error) {
+	frames, err := r.waitFrom(ctx, 0, until)
+	if err != nil {
+		return r.GetFrames(), err
+	}
+	return frames, nil
+}
+
+// waitFrom feeds frames from index from onward to the predicate and returns
+// the frames from..the satisfying frame.
+func (r *Receiver) waitFrom(ctx context.Context, from int, until Predicate) ([]Frame, error) {
+	seen := from
+	for {
+		r.mu.RLock()
+		if seen > len(r.frames) {
+			// The frames were cleared underneath us; start over.
+			from, seen = 0, 0
+		}
+		for ; seen < len(r.frames); seen++ {
+			if until(r.frames[seen]) {
+				result := make([]Frame, seen+1-from)
+				copy(result, r.frames[from:seen+1])
+				r.mu.RUnlock()
+				return result, nil
+			}
+		}
+		updated := r.updated
+		r.mu.RUnlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-updated:
+		}
+	}
+}
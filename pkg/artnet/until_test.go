@@ -0,0 +1,105 @@
+package artnet
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func frameWith(universe int, ch1 byte) Frame {
+	f := Frame{Universe: universe, Timestamp: time.Now()}
+	f.Channels[0] = ch1
+	return f
+}
+
+func TestPredicates(t *testing.T) {
+	reaches := ChannelReaches(0, 1, 255)
+	assert.False(t, reaches(frameWith(0, 254)))
+	assert.False(t, reaches(frameWith(1, 255)), "Other universes never match")
+	assert.True(t, reaches(frameWith(0, 255)))
+	assert.False(t, ChannelReaches(0, 0, 0)(frameWith(0, 0)), "Out-of-range channels never match")
+
+	count := UniverseFrames(1, 2)
+	assert.False(t, count(frameWith(1, 0)))
+	assert.False(t, count(frameWith(0, 0)))
+	assert.True(t, count(frameWith(1, 0)))
+
+	// Full, then two more frames of hold
+	hold := Then(ChannelReaches(0, 1, 255), UniverseFrames(0, 2))
+	assert.False(t, hold(frameWith(0, 100)))
+	assert.False(t, hold(frameWith(0, 255)), "The frame that reaches full does not count toward the hold")
+	assert.False(t, hold(frameWith(0, 255)))
+	assert.True(t, hold(frameWith(0, 255)))
+}
+
+func TestCaptureUntilStopsOnPredicate(t *testing.T) {
+	receiver := NewReceiver("127.0.0.1:0")
+	go func() {
+		for v := 0; v <= 255; v += 15 {
+			time.Sleep(time.Millisecond)
+			receiver.deliver(frameWith(0, byte(v)))
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	frames, err := receiver.waitUntil(ctx, ChannelMatches(0, 1, func(v byte) bool { return v >= 128 }))
+	require.NoError(t, err)
+	require.NotEmpty(t, frames)
+	assert.Equal(t, byte(135), frames[len(frames)-1].Channels[0], "The capture ends on the matching frame")
+	assert.Len(t, frames, 10)
+}
+
+func TestCaptureUntilTimesOut(t *testing.T) {
+	receiver := NewReceiver("127.0.0.1:0")
+	receiver.deliver(frameWith(0, 10))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	frames, err := receiver.waitUntil(ctx, ChannelReaches(0, 1, 255))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Len(t, frames, 1, "The frames captured before the deadline are returned")
+}
+
+func TestSubscriptionUntil(t *testing.T) {
+	t.Setenv("ARTNET_IMPAIR", "")
+	m := NewManager("127.0.0.1:0")
+	sub, err := m.Subscribe()
+	require.NoError(t, err)
+	defer sub.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// A frame already captured does not satisfy WaitUntil
+	send(t, m, buildDMXPacket(0, 1, []byte{255}))
+	require.Eventually(t, func() bool { return len(sub.GetFrames()) == 1 }, time.Second, 10*time.Millisecond)
+
+	done := make(chan Frame, 1)
+	go func() {
+		frame, err := sub.WaitUntil(ctx, ChannelReaches(0, 1, 255))
+		assert.NoError(t, err)
+		done <- frame
+	}()
+	time.Sleep(20 * time.Millisecond)
+	send(t, m, buildDMXPacket(0, 2, []byte{128}), buildDMXPacket(0, 3, []byte{255}))
+
+	frame := <-done
+	assert.Equal(t, byte(3), frame.Sequence)
+
+	conn, err := net.DialUDP("udp", nil, m.Addr().(*net.UDPAddr))
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_, _ = conn.Write(buildDMXPacket(0, 4, []byte{0}))
+		_, _ = conn.Write(buildDMXPacket(0, 5, []byte{0}))
+	}()
+	frames, err := sub.CaptureUntil(ctx, UniverseFrames(0, 2))
+	require.NoError(t, err)
+	assert.Len(t, frames, 2, "CaptureUntil starts from a cleared capture")
+}
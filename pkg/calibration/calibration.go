@@ -0,0 +1,261 @@
+// Package calibration measures how fast the server under test refreshes its
+// output and answers requests, so timing tolerances tuned on a developer
+// machine can stretch on a slower CI runner instead of flaking.
+//
+// The profile is measured once per test binary, on the first call to Get:
+//
+//	cal := calibration.Get(t)
+//	tolerance := cal.Duration(100 * time.Millisecond) // scaled for this machine
+//	frame := cal.FramePeriod()                          // measured output frame
+//	steps := cal.Frames(2)                              // scaled for the output rate only
+//
+// The frame rate is measured on a subscription to artnet.SharedManager, and
+// falls back to the advertised fade_update_rate_hz setting when Art-Net
+// cannot be captured.
+package calibration
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/bbernstein/lacylights-test/pkg/fixtures"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+)
+
+const (
+	// ReferenceFrameRate is the output rate, in Hz, the suites' tolerances were tuned at.
+	ReferenceFrameRate = 40.0
+
+	// ReferenceRoundTrip is the GraphQL round trip the suites' tolerances were tuned at.
+	ReferenceRoundTrip = 10 * time.Millisecond
+
+	// MaxScale caps the measured scale, so a broken server fails tests rather
+	// than widening every tolerance until nothing can fail.
+	MaxScale = 3.0
+
+	// roundTrips is how many requests the round trip is the median of.
+	roundTrips = 10
+
+	// captureTime is how long Art-Net is captured to measure the frame rate.
+	captureTime = time.Second
+
+	// calibrationUniverse and calibrationChannel place the calibration fixture
+	// at the top of universe 4, clear of the suites' fixtures.
+	calibrationUniverse = 4
+	calibrationChannel  = 509
+)
+
+// Frame rate sources, from most to least trustworthy.
+const (
+	SourceArtNet  = "artnet"  // measured from captured frames
+	SourceSetting = "setting" // the fade_update_rate_hz setting
+	SourceDefault = "default" // ReferenceFrameRate, nothing else was available
+)
+
+// Profile is the measured timing of the server under test.
+type Profile struct {
+	FrameRate   float64       // output frames per second
+	FrameSource string        // where FrameRate came from, one of the Source constants
+	RoundTrip   time.Duration // median GraphQL round trip, 0 if unmeasured
+
+	// override is TIMING_SCALE, when set.
+	override float64
+}
+
+// FramePeriod returns the time between output frames.
+func (p Profile) FramePeriod() time.Duration {
+	return time.Duration(float64(time.Second) / p.FrameRate)
+}
+
+// Scale returns the factor fixed tolerances are multiplied by: how much slower
+// the output rate and the round trip are than the reference, never below 1
+// and at most MaxScale. TIMING_SCALE replaces the measured value.
+func (p Profile) Scale() float64 {
+	if p.override > 0 {
+		return p.override
+	}
+	scale := p.frameFactor()
+	if p.RoundTrip > ReferenceRoundTrip {
+		scale *= float64(p.RoundTrip) / float64(ReferenceRoundTrip)
+	}
+	return min(scale, MaxScale)
+}
+
+// FrameScale returns the factor tolerances set by the output frame rate are
+// multiplied by: how much slower the output rate is than the reference, never
+// below 1 and at most MaxScale. A slow round trip does not widen them.
+// TIMING_SCALE replaces the measured value.
+func (p Profile) FrameScale() float64 {
+	if p.override > 0 {
+		return p.override
+	}
+	return min(p.frameFactor(), MaxScale)
+}
+
+// frameFactor is ReferenceFrameRate over the measured rate, at least 1.
+func (p Profile) frameFactor() float64 {
+	if p.FrameRate > 0 && p.FrameRate < ReferenceFrameRate {
+		return ReferenceFrameRate / p.FrameRate
+	}
+	return 1
+}
+
+// Duration scales a time tolerance.
+func (p Profile) Duration(d time.Duration) time.Duration {
+	return time.Duration(float64(d) * p.Scale())
+}
+
+// Value scales a numeric tolerance that the round trip widens as well as the
+// frame rate, such as a level polled over the API while it changes.
+func (p Profile) Value(v float64) float64 {
+	return v * p.Scale()
+}
+
+// Frames scales a tolerance that comes from output frame granularity alone,
+// such as how far a fade can move between two output frames.
+func (p Profile) Frames(v float64) float64 {
+	return v * p.FrameScale()
+}
+
+func (p Profile) String() string {
+	return fmt.Sprintf("%.1f Hz (%s), round trip %v, scale %.2f",
+		p.FrameRate, p.FrameSource, p.RoundTrip.Round(100*time.Microsecond), p.Scale())
+}
+
+var (
+	once    sync.Once
+	profile Profile
+)
+
+// Get returns the profile of the configured server, measuring it on first use.
+func Get(t testing.TB) Profile {
+	t.Helper()
+	once.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		profile = Measure(ctx, graphql.NewClient(""), artnet.SharedManager())
+		t.Logf("Timing calibration: %s", profile)
+	})
+	return profile
+}
+
+// Measure times the server's round trip and output frame rate. Anything it
+// cannot measure falls back to the next source, so it always returns a usable
+// profile. A nil manager skips the Art-Net capture.
+func Measure(ctx context.Context, client *graphql.Client, manager *artnet.Manager) Profile {
+	p := Profile{RoundTrip: measureRoundTrip(ctx, client)}
+	if s, err := strconv.ParseFloat(os.Getenv("TIMING_SCALE"), 64); err == nil && s > 0 {
+		p.override = s
+	}
+
+	if manager != nil {
+		if rate := measureFrameRate(ctx, client, manager); rate > 0 {
+			p.FrameRate, p.FrameSource = rate, SourceArtNet
+			return p
+		}
+	}
+	if rate := advertisedFrameRate(ctx, client); rate > 0 {
+		p.FrameRate, p.FrameSource = rate, SourceSetting
+		return p
+	}
+	p.FrameRate, p.FrameSource = ReferenceFrameRate, SourceDefault
+	return p
+}
+
+// measureRoundTrip returns the median time of a trivial query, or 0 if the
+// server does not answer.
+func measureRoundTrip(ctx context.Context, client *graphql.Client) time.Duration {
+	times := make([]time.Duration, 0, roundTrips)
+	for i := 0; i < roundTrips; i++ {
+		start := time.Now()
+		if err := client.Query(ctx, `query { __typename }`, nil, nil); err != nil {
+			return 0
+		}
+		times = append(times, time.Since(start))
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	return times[len(times)/2]
+}
+
+// measureFrameRate runs a sine effect on a scratch fixture, since the server
+// only sends at its full rate while output is changing, and returns the rate
+// of the captured frames. It returns 0 if Art-Net or effects are unavailable.
+func measureFrameRate(ctx context.Context, client *graphql.Client, manager *artnet.Manager) float64 {
+	receiver, err := manager.Subscribe(artnet.Universes(calibrationUniverse - 1))
+	if err != nil {
+		return 0
+	}
+	defer receiver.Close()
+	receiver.SetImpairment(nil)
+
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+	definitionID, err := fixtures.CreateParDefinition(ctx, client, "Calibration", "Calibration Par "+suffix)
+	if err != nil {
+		return 0
+	}
+	defer func() { _ = fixtures.DeleteFixtureDefinition(ctx, client, definitionID) }()
+	projectID, err := fixtures.CreateProject(ctx, client, "Calibration "+suffix)
+	if err != nil {
+		return 0
+	}
+	defer func() { _ = fixtures.DeleteProject(ctx, client, projectID) }()
+
+	fixtureID, err := fixtures.CreateFixture(ctx, client, projectID, definitionID,
+		fixtures.Fixture{Name: "Calibration Par", Universe: calibrationUniverse, StartChannel: calibrationChannel})
+	if err != nil {
+		return 0
+	}
+	effectID, err := fixtures.CreateWaveformEffect(ctx, client, projectID, "Calibration Sine", "SINE", 2.0, []string{fixtureID})
+	if err != nil {
+		return 0
+	}
+	err = client.Mutate(ctx, `mutation Activate($id: ID!) { activateEffect(effectId: $id, fadeTime: 0) }`,
+		map[string]interface{}{"id": effectID}, nil)
+	if err != nil {
+		return 0
+	}
+	defer func() {
+		_ = client.Mutate(ctx, `mutation Stop($id: ID!) { stopEffect(effectId: $id, fadeTime: 0) }`,
+			map[string]interface{}{"id": effectID}, nil)
+	}()
+
+	// Let the effect start before counting
+	time.Sleep(100 * time.Millisecond)
+	receiver.ClearFrames()
+	time.Sleep(captureTime)
+
+	stats := artnet.Stats(receiver.GetFrames(), calibrationUniverse-1)
+	if stats.Frames < 10 {
+		return 0
+	}
+	return stats.Rate()
+}
+
+// advertisedFrameRate returns the fade_update_rate_hz setting, or 0.
+func advertisedFrameRate(ctx context.Context, client *graphql.Client) float64 {
+	var resp struct {
+		Setting *struct {
+			Value string `json:"value"`
+		} `json:"setting"`
+	}
+	err := client.Query(ctx, `
+		query GetSetting($key: String!) {
+			setting(key: $key) { value }
+		}
+	`, map[string]interface{}{"key": "fade_update_rate_hz"}, &resp)
+	if err != nil || resp.Setting == nil {
+		return 0
+	}
+	rate, err := strconv.ParseFloat(resp.Setting.Value, 64)
+	if err != nil || rate <= 0 {
+		return 0
+	}
+	return rate
+}
@@ -0,0 +1,107 @@
+package calibration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScale(t *testing.T) {
+	fast := Profile{FrameRate: 44, RoundTrip: 2 * time.Millisecond}
+	assert.Equal(t, 1.0, fast.Scale(), "A faster machine never tightens tolerances")
+	assert.Equal(t, 100*time.Millisecond, fast.Duration(100*time.Millisecond))
+
+	slowOutput := Profile{FrameRate: 20, RoundTrip: 5 * time.Millisecond}
+	assert.InDelta(t, 2.0, slowOutput.Scale(), 1e-9)
+	assert.Equal(t, 50*time.Millisecond, slowOutput.FramePeriod())
+	assert.InDelta(t, 30.0, slowOutput.Value(15), 1e-9)
+
+	slowBoth := Profile{FrameRate: 30, RoundTrip: 15 * time.Millisecond}
+	assert.InDelta(t, 40.0/30*1.5, slowBoth.Scale(), 1e-9)
+
+	broken := Profile{FrameRate: 2, RoundTrip: time.Second}
+	assert.Equal(t, MaxScale, broken.Scale(), "The scale is capped")
+
+	overridden := Profile{FrameRate: 44, override: 2.5}
+	assert.Equal(t, 2.5, overridden.Scale())
+	assert.Equal(t, 2.5, overridden.FrameScale())
+}
+
+func TestFrameScale(t *testing.T) {
+	slowRoundTrip := Profile{FrameRate: 44, RoundTrip: 25 * time.Millisecond}
+	assert.InDelta(t, 2.5, slowRoundTrip.Scale(), 1e-9)
+	assert.Equal(t, 1.0, slowRoundTrip.FrameScale(), "A slow round trip does not widen frame tolerances")
+	assert.Equal(t, 2.0, slowRoundTrip.Frames(2))
+
+	slowBoth := Profile{FrameRate: 20, RoundTrip: 15 * time.Millisecond}
+	assert.InDelta(t, 2.0, slowBoth.FrameScale(), 1e-9)
+	assert.InDelta(t, 40.0, slowBoth.Frames(20), 1e-9)
+
+	broken := Profile{FrameRate: 2}
+	assert.Equal(t, MaxScale, broken.FrameScale(), "The frame scale is capped")
+}
+
+// settingServer answers __typename, and the fade_update_rate_hz setting with rate ("" for none).
+func settingServer(rate string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphql.Request
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		data := map[string]interface{}{"__typename": "Query"}
+		if strings.Contains(req.Query, "setting") {
+			data = map[string]interface{}{"setting": nil}
+			if rate != "" {
+				data["setting"] = map[string]interface{}{"value": rate}
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+}
+
+func TestMeasureFallsBack(t *testing.T) {
+	t.Setenv("TIMING_SCALE", "")
+	ctx := context.Background()
+
+	t.Run("Setting", func(t *testing.T) {
+		server := settingServer("30")
+		defer server.Close()
+
+		p := Measure(ctx, graphql.NewClient(server.URL), nil)
+		assert.Equal(t, 30.0, p.FrameRate)
+		assert.Equal(t, SourceSetting, p.FrameSource)
+		assert.Positive(t, p.RoundTrip)
+	})
+
+	t.Run("Default", func(t *testing.T) {
+		server := settingServer("")
+		defer server.Close()
+
+		p := Measure(ctx, graphql.NewClient(server.URL), nil)
+		assert.Equal(t, ReferenceFrameRate, p.FrameRate)
+		assert.Equal(t, SourceDefault, p.FrameSource)
+	})
+
+	t.Run("Unreachable", func(t *testing.T) {
+		server := settingServer("")
+		server.Close()
+
+		p := Measure(ctx, graphql.NewClient(server.URL), nil)
+		assert.Equal(t, SourceDefault, p.FrameSource)
+		assert.Zero(t, p.RoundTrip)
+		assert.Equal(t, 1.0, p.Scale())
+	})
+
+	t.Run("Override", func(t *testing.T) {
+		t.Setenv("TIMING_SCALE", "2")
+		server := settingServer("40")
+		defer server.Close()
+
+		assert.Equal(t, 2.0, Measure(ctx, graphql.NewClient(server.URL), nil).Scale())
+	})
+}
@@ -0,0 +1,186 @@
+// Package chaseassert finds the order and spacing in which fixtures light up
+// in captured Art-Net frames, for asserting on chase effects.
+package chaseassert
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+)
+
+// Activation is a fixture's channel rising through the threshold.
+type Activation struct {
+	Fixture int // index into the channels passed to Activations
+	Channel int // 1-indexed DMX channel
+	At      time.Time
+}
+
+// Activations returns every rising edge of the given channels, in time order.
+// Only frames for universe (Art-Net numbering, as in artnet.Frame) are used.
+// A channel activates on the first frame at or above threshold after a frame
+// below it, so a fixture already on when the capture starts is not reported
+// until it next turns on. Duplicated and late frames are ignored (see
+// artnet.InOrder) so a packet delivered twice can't light a fixture twice.
+func Activations(frames []artnet.Frame, universe int, channels []int, threshold int) []Activation {
+	var acts []Activation
+	prev := make([]int, len(channels))
+	for i := range prev {
+		prev[i] = -1
+	}
+
+	for _, frame := range artnet.InOrder(frames) {
+		if frame.Universe != universe {
+			continue
+		}
+		for i, ch := range channels {
+			v := int(frame.Channels[ch-1])
+			if prev[i] >= 0 && prev[i] < threshold && v >= threshold {
+				acts = append(acts, Activation{Fixture: i, Channel: ch, At: frame.Timestamp})
+			}
+			prev[i] = v
+		}
+	}
+	return acts
+}
+
+// Delays returns the time between each activation and the one before it.
+func Delays(acts []Activation) []time.Duration {
+	var delays []time.Duration
+	for i := 1; i < len(acts); i++ {
+		delays = append(delays, acts[i].At.Sub(acts[i-1].At))
+	}
+	return delays
+}
+
+// MedianDelay returns the median of Delays, or 0 with fewer than two activations.
+func MedianDelay(acts []Activation) time.Duration {
+	delays := Delays(acts)
+	if len(delays) == 0 {
+		return 0
+	}
+	sort.Slice(delays, func(i, j int) bool { return delays[i] < delays[j] })
+	return delays[len(delays)/2]
+}
+
+// Problem is an activation that breaks the expected chase.
+type Problem struct {
+	Index  int // position in the activations
+	Reason string
+}
+
+// Check compares activations with a chase firing the fixtures in order,
+// repeating, starting anywhere in the cycle. Every activation must be the
+// fixture after the previous one, and every delay must be within tolerance of
+// the median delay.
+func Check(acts []Activation, order []int, tolerance time.Duration) []Problem {
+	position := make(map[int]int, len(order))
+	for i, fixture := range order {
+		position[fixture] = i
+	}
+
+	var problems []Problem
+	median := MedianDelay(acts)
+	for i, act := range acts {
+		if _, ok := position[act.Fixture]; !ok {
+			problems = append(problems, Problem{Index: i, Reason: "fixture is not in the chase"})
+			continue
+		}
+		if i == 0 {
+			continue
+		}
+
+		if prevPos, ok := position[acts[i-1].Fixture]; ok {
+			if want := order[(prevPos+1)%len(order)]; act.Fixture != want {
+				problems = append(problems, Problem{Index: i, Reason: fmt.Sprintf("expected fixture %d", want+1)})
+			}
+		}
+
+		delay := act.At.Sub(acts[i-1].At)
+		if (delay - median).Abs() > tolerance {
+			problems = append(problems, Problem{Index: i, Reason: fmt.Sprintf("delay %v, median %v", delay.Round(time.Millisecond), median.Round(time.Millisecond))})
+		}
+	}
+	return problems
+}
+
+// Timeline renders activations one per line, relative to the first, with the
+// delay from the previous activation and any problems marked.
+func Timeline(acts []Activation, problems []Problem) string {
+	reasons := make(map[int][]string)
+	for _, p := range problems {
+		reasons[p.Index] = append(reasons[p.Index], p.Reason)
+	}
+
+	lines := make([]string, len(acts))
+	for i, act := range acts {
+		line := fmt.Sprintf("%+7dms  fixture %d (ch %3d)", act.At.Sub(acts[0].At).Milliseconds(), act.Fixture+1, act.Channel)
+		if i > 0 {
+			line += fmt.Sprintf("  +%dms", act.At.Sub(acts[i-1].At).Milliseconds())
+		}
+		if r := reasons[i]; len(r) > 0 {
+			line += "  <- " + strings.Join(r, "; ")
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ExpectChase fails the test with a timeline unless the activations fire the
+// fixtures in order with equal spacing, and at least one full cycle was seen.
+// It returns true when the chase matches.
+func ExpectChase(t testing.TB, acts []Activation, order []int, tolerance time.Duration) bool {
+	t.Helper()
+
+	if len(acts) < len(order) {
+		t.Errorf("Chase incomplete: %d activations for %d fixtures:\n%s", len(acts), len(order), Timeline(acts, nil))
+		return false
+	}
+	problems := Check(acts, order, tolerance)
+	if len(problems) == 0 {
+		return true
+	}
+	t.Errorf("Chase mismatch (%d problems, spacing tolerance %v):\n%s", len(problems), tolerance, Timeline(acts, problems))
+	return false
+}
+
+// ExpectChaseFrames is ExpectChase for a capture. When the capture is
+// degraded (lost, duplicated or reordered frames), the diagnostics are logged
+// and the tolerance widened by the extra time the largest gap hides, since an
+// edge can only be seen on the first frame that arrives after it.
+func ExpectChaseFrames(t testing.TB, frames []artnet.Frame, universe int, channels []int, threshold int, order []int, tolerance time.Duration) bool {
+	t.Helper()
+
+	stats := artnet.Stats(frames, universe)
+	diagnostics := stats.Diagnostics()
+	if stats.Degraded() {
+		for _, line := range diagnostics {
+			t.Logf("Degraded capture: %s", line)
+		}
+		if extra := stats.MaxGap - stats.MedianPeriod; extra > 0 {
+			tolerance += extra
+		}
+	}
+
+	acts := Activations(frames, universe, channels, threshold)
+	if len(acts) < len(order) {
+		t.Errorf("Chase incomplete: %d activations for %d fixtures:\n%s%s", len(acts), len(order), Timeline(acts, nil), diagnosticNote(diagnostics))
+		return false
+	}
+	problems := Check(acts, order, tolerance)
+	if len(problems) == 0 {
+		return true
+	}
+	t.Errorf("Chase mismatch (%d problems, spacing tolerance %v):\n%s%s", len(problems), tolerance, Timeline(acts, problems), diagnosticNote(diagnostics))
+	return false
+}
+
+func diagnosticNote(diagnostics []string) string {
+	if len(diagnostics) == 0 {
+		return ""
+	}
+	return "\ncapture was degraded:\n  " + strings.Join(diagnostics, "\n  ")
+}
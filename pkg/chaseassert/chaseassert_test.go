@@ -0,0 +1,155 @@
+package chaseassert
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	start    = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	channels = []int{1, 5, 9, 13}
+)
+
+// chaseFrames renders one frame per step in which only the fixture at
+// sequence[step] is on; -1 leaves every fixture off.
+func chaseFrames(sequence []int, step time.Duration) []artnet.Frame {
+	frames := make([]artnet.Frame, 0, len(sequence)+1)
+	frames = append(frames, artnet.Frame{Timestamp: start.Add(-step)})
+	for i, fixture := range sequence {
+		frame := artnet.Frame{Timestamp: start.Add(time.Duration(i) * step)}
+		if fixture >= 0 {
+			frame.Channels[channels[fixture]-1] = 255
+		}
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+func TestActivations(t *testing.T) {
+	frames := chaseFrames([]int{0, 1, 2, 3, 0}, 100*time.Millisecond)
+	frames = append(frames, artnet.Frame{Universe: 1, Timestamp: start.Add(time.Second)})
+	frames[len(frames)-1].Channels[4] = 255
+
+	acts := Activations(frames, 0, channels, 128)
+	require.Len(t, acts, 5, "The universe 1 frame should be ignored")
+	assert.Equal(t, Activation{Fixture: 1, Channel: 5, At: start.Add(100 * time.Millisecond)}, acts[1])
+	assert.Equal(t, []int{0, 1, 2, 3, 0}, fixtures(acts))
+
+	t.Run("OnAtStartIsNotAnActivation", func(t *testing.T) {
+		frames := chaseFrames([]int{0, 1}, 100*time.Millisecond)[1:]
+		assert.Equal(t, []int{1}, fixtures(Activations(frames, 0, channels, 128)))
+	})
+}
+
+func TestCheck(t *testing.T) {
+	order := []int{0, 1, 2, 3}
+
+	t.Run("StartsMidCycle", func(t *testing.T) {
+		acts := Activations(chaseFrames([]int{2, 3, 0, 1, 2}, 100*time.Millisecond), 0, channels, 128)
+		assert.Empty(t, Check(acts, order, 10*time.Millisecond))
+	})
+
+	t.Run("OutOfOrder", func(t *testing.T) {
+		acts := Activations(chaseFrames([]int{0, 2, 1, 3}, 100*time.Millisecond), 0, channels, 128)
+		problems := Check(acts, order, 10*time.Millisecond)
+		require.Len(t, problems, 3)
+		assert.Equal(t, Problem{Index: 1, Reason: "expected fixture 2"}, problems[0])
+	})
+
+	t.Run("UnevenSpacing", func(t *testing.T) {
+		frames := chaseFrames([]int{0, 1, -1, 2, 3}, 100*time.Millisecond)
+		acts := Activations(frames, 0, channels, 128)
+		problems := Check(acts, order, 10*time.Millisecond)
+		require.Len(t, problems, 1)
+		assert.Equal(t, 2, problems[0].Index)
+		assert.Equal(t, "delay 200ms, median 100ms", problems[0].Reason)
+	})
+}
+
+func TestTimeline(t *testing.T) {
+	acts := Activations(chaseFrames([]int{0, 2}, 250*time.Millisecond), 0, channels, 128)
+	want := "     +0ms  fixture 1 (ch   1)\n" +
+		"   +250ms  fixture 3 (ch   9)  +250ms  <- expected fixture 2"
+	assert.Equal(t, want, Timeline(acts, Check(acts, []int{0, 1, 2, 3}, time.Second)))
+}
+
+func TestExpectChase(t *testing.T) {
+	acts := Activations(chaseFrames([]int{0, 1, 2, 3}, 100*time.Millisecond), 0, channels, 128)
+	mock := &testing.T{}
+	assert.True(t, ExpectChase(mock, acts, []int{0, 1, 2, 3}, 10*time.Millisecond))
+	assert.False(t, mock.Failed())
+}
+
+// recorder captures what an assertion reports instead of failing the test.
+type recorder struct {
+	testing.TB
+	logs, errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Logf(format string, args ...any) {
+	r.logs = append(r.logs, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// streamFrames renders a sequenced 40Hz stream in which each fixture of
+// sequence stays on for 10 frames, as the server would send a chase.
+func streamFrames(sequence []int) []artnet.Frame {
+	const period, hold = 25 * time.Millisecond, 10
+	var frames []artnet.Frame
+	for i := 0; i < (len(sequence)+1)*hold; i++ {
+		frame := artnet.Frame{Timestamp: start.Add(time.Duration(i) * period), Sequence: byte(i%255 + 1)}
+		if step := i/hold - 1; step >= 0 {
+			frame.Channels[channels[sequence[step]]-1] = 255
+		}
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+func TestExpectChaseFramesImpaired(t *testing.T) {
+	order := []int{0, 1, 2, 3}
+	chase := []int{0, 1, 2, 3, 0, 1, 2, 3, 0, 1, 2, 3}
+	tolerance := 2 * 25 * time.Millisecond
+
+	t.Run("Clean", func(t *testing.T) {
+		rec := &recorder{TB: t}
+		assert.True(t, ExpectChaseFrames(rec, streamFrames(chase), 0, channels, 128, order, tolerance))
+		assert.Empty(t, rec.logs, "A clean capture has no diagnostics")
+	})
+
+	t.Run("LossDuplicationAndReordering", func(t *testing.T) {
+		for seed := int64(1); seed <= 20; seed++ {
+			frames := artnet.Impair(streamFrames(chase), artnet.Impairment{Drop: 0.1, Duplicate: 0.1, Jitter: 40 * time.Millisecond, Seed: seed})
+			rec := &recorder{TB: t}
+			assert.True(t, ExpectChaseFrames(rec, frames, 0, channels, 128, order, tolerance), "seed %d: %v", seed, rec.errors)
+			assert.NotEmpty(t, rec.logs, "seed %d: degraded capture should be reported", seed)
+		}
+	})
+
+	t.Run("WrongOrderStillFails", func(t *testing.T) {
+		frames := artnet.Impair(streamFrames([]int{0, 2, 1, 3}), artnet.Impairment{Drop: 0.1, Seed: 1})
+		rec := &recorder{TB: t}
+		assert.False(t, ExpectChaseFrames(rec, frames, 0, channels, 128, order, tolerance))
+		require.Len(t, rec.errors, 1)
+		assert.Contains(t, rec.errors[0], "expected fixture 2")
+		assert.Contains(t, rec.errors[0], "capture was degraded")
+	})
+}
+
+func fixtures(acts []Activation) []int {
+	var out []int
+	for _, a := range acts {
+		out = append(out, a.Fixture)
+	}
+	return out
+}
@@ -0,0 +1,401 @@
+// Package compat maps the server under test to the features the suite can rely on.
+//
+// Tests declare what they need instead of probing for it:
+//
+//	compat.Require(t, compat.Effects, compat.ArtNet)
+//
+// Schema features are read from introspection, each from the root field that
+// implements it, and Require gates on those probes alone. The server also
+// reports its version through systemInfo, and Matrix records which versions
+// are expected to have which features; Detect records where the probes
+// disagree with that record in Server.Mismatches, which the contracts/api
+// suite reports without blocking other tests. ArtNet is a runtime setting
+// rather than a schema feature and is read from systemInfo.artnetEnabled.
+//
+// The Require* helpers gate tests on individual schema fields for contracts the
+// server may not implement yet. They skip the test, or fail it when
+// PENDING_CONTRACTS is set, so unimplemented contracts show up as failures in a
+// run that is meant to track them.
+package compat
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+)
+
+// Feature is a capability a test can require.
+type Feature string
+
+const (
+	// Looks is the look entity (createLook, looks, setLookLive).
+	Looks Feature = "looks"
+
+	// Scenes is the pre-rename scene entity that looks replaced.
+	Scenes Feature = "scenes"
+
+	// Effects is the effect engine (createEffect, activateEffect).
+	// SKIP_EFFECT_TESTS turns it off regardless of the server.
+	Effects Feature = "effects"
+
+	// Undo is per-project undo/redo history.
+	Undo Feature = "undo"
+
+	// ArtNet means the server is sending Art-Net, so output can be captured.
+	// SKIP_FADE_TESTS turns it off regardless of the server.
+	ArtNet Feature = "artnet"
+)
+
+// Probe is the schema root field whose presence shows a feature is implemented.
+type Probe struct {
+	// Root is "query", "mutation" or "subscription".
+	Root string
+
+	// Field is the root field name.
+	Field string
+}
+
+// Span is the range of server versions that support a feature.
+type Span struct {
+	// Since is the first version with the feature; empty means always.
+	Since string
+
+	// Until is the first version without the feature; empty means still supported.
+	Until string
+
+	// Probe identifies the feature in the schema.
+	Probe Probe
+}
+
+// SchemaFeatures lists every feature that can be checked against the schema.
+// ArtNet is not listed because it depends on server configuration. The
+// versions are the matrix's expectation, not a source of truth: Detect
+// compares them with the probes and reports any feature they get wrong in
+// Server.Mismatches, which only contracts/api checks.
+var SchemaFeatures = map[Feature]Span{
+	Looks:   {Since: "1.0.0", Probe: Probe{Root: "mutation", Field: "createLook"}},
+	Scenes:  {Until: "1.0.0", Probe: Probe{Root: "mutation", Field: "createScene"}},
+	Effects: {Since: "1.1.0", Probe: Probe{Root: "mutation", Field: "createEffect"}},
+	Undo:    {Since: "1.2.0", Probe: Probe{Root: "mutation", Field: "undo"}},
+}
+
+// Matrix returns the schema features a server of the given version is
+// expected to support. It returns nil when the version cannot be parsed.
+func Matrix(version string) map[Feature]bool {
+	v, ok := parseVersion(version)
+	if !ok {
+		return nil
+	}
+
+	m := make(map[Feature]bool, len(SchemaFeatures))
+	for f, span := range SchemaFeatures {
+		m[f] = span.contains(v)
+	}
+	return m
+}
+
+// Server is what the suite knows about the server under test.
+type Server struct {
+	// Version is the version reported by systemInfo, or empty if none was reported.
+	Version string
+
+	// ArtNetEnabled is systemInfo.artnetEnabled.
+	ArtNetEnabled bool
+
+	// Mismatches lists the features whose probe disagrees with the matrix for
+	// Version. It is empty when no usable version was reported.
+	Mismatches []Feature
+
+	features map[Feature]bool
+}
+
+// Supports reports whether the server supports a feature.
+func (s *Server) Supports(f Feature) bool {
+	switch f {
+	case ArtNet:
+		return s.ArtNetEnabled && os.Getenv("SKIP_FADE_TESTS") == ""
+	case Effects:
+		return s.features[f] && os.Getenv("SKIP_EFFECT_TESTS") == ""
+	}
+	return s.features[f]
+}
+
+// Detect queries systemInfo, probes the schema for each feature, and checks
+// the probes against the matrix for the reported version.
+func Detect(ctx context.Context, client *graphql.Client) (*Server, error) {
+	var resp struct {
+		SystemInfo struct {
+			Version       string `json:"version"`
+			ArtnetEnabled bool   `json:"artnetEnabled"`
+		} `json:"systemInfo"`
+	}
+
+	hasVersion, err := client.HasTypeField(ctx, "SystemInfo", "version")
+	if err != nil {
+		return nil, err
+	}
+	query := `query SystemInfo { systemInfo { artnetEnabled } }`
+	if hasVersion {
+		query = `query SystemInfo { systemInfo { version artnetEnabled } }`
+	}
+	if err := client.Query(ctx, query, nil, &resp); err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		Version:       resp.SystemInfo.Version,
+		ArtNetEnabled: resp.SystemInfo.ArtnetEnabled,
+		features:      make(map[Feature]bool, len(SchemaFeatures)),
+	}
+	for f, span := range SchemaFeatures {
+		ok, err := HasProbe(ctx, client, span.Probe)
+		if err != nil {
+			return nil, err
+		}
+		s.features[f] = ok
+	}
+	if expected := Matrix(s.Version); expected != nil {
+		for f, ok := range s.features {
+			if expected[f] != ok {
+				s.Mismatches = append(s.Mismatches, f)
+			}
+		}
+		sort.Slice(s.Mismatches, func(i, j int) bool { return s.Mismatches[i] < s.Mismatches[j] })
+	}
+	return s, nil
+}
+
+// HasProbe reports whether the schema has the probe's root field.
+func HasProbe(ctx context.Context, client *graphql.Client, p Probe) (bool, error) {
+	switch p.Root {
+	case "query":
+		return client.HasQueryField(ctx, p.Field)
+	case "mutation":
+		return client.HasMutationField(ctx, p.Field)
+	case "subscription":
+		return client.HasSubscriptionField(ctx, p.Field)
+	}
+	return false, fmt.Errorf("compat: unknown probe root %q", p.Root)
+}
+
+var (
+	detectMu sync.Mutex
+	detected = map[string]*Server{}
+)
+
+// Current returns the server at the default endpoint, detecting it once per endpoint.
+func Current(ctx context.Context) (*Server, error) {
+	client := graphql.NewClient("")
+
+	detectMu.Lock()
+	defer detectMu.Unlock()
+
+	if s, ok := detected[client.Endpoint()]; ok {
+		return s, nil
+	}
+	s, err := Detect(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	detected[client.Endpoint()] = s
+	return s, nil
+}
+
+// Require skips the test unless the server supports every listed feature.
+// Support comes from the schema probes alone, whatever the matrix expects for
+// the server's version. A missing schema feature fails the test instead when
+// PENDING_CONTRACTS is set.
+func Require(t testing.TB, features ...Feature) {
+	t.Helper()
+
+	for _, f := range features {
+		if f == ArtNet && os.Getenv("SKIP_FADE_TESTS") != "" {
+			t.Skip("Skipping: SKIP_FADE_TESTS is set")
+		}
+		if f == Effects && os.Getenv("SKIP_EFFECT_TESTS") != "" {
+			t.Skip("Skipping: SKIP_EFFECT_TESTS is set")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	s, err := Current(ctx)
+	if err != nil {
+		t.Skipf("Skipping: cannot query systemInfo: %v", err)
+	}
+
+	for _, f := range features {
+		if s.Supports(f) {
+			continue
+		}
+		if f == ArtNet {
+			t.Skip("Skipping: Art-Net is not enabled on the server")
+		}
+		if os.Getenv("PENDING_CONTRACTS") != "" {
+			t.Fatalf("Server %s does not support %s", s.describe(), f)
+		}
+		t.Skipf("Skipping: server %s does not support %s", s.describe(), f)
+	}
+}
+
+func (s *Server) describe() string {
+	if s.Version == "" {
+		return "(no version reported)"
+	}
+	return s.Version
+}
+
+// DMXChecksDisabled reports whether SKIP_DMX_TESTS or SKIP_FADE_TESTS is set,
+// in which case tests should not assert on DMX output.
+func DMXChecksDisabled() bool {
+	return os.Getenv("SKIP_DMX_TESTS") != "" || os.Getenv("SKIP_FADE_TESTS") != ""
+}
+
+// RequireQuery gates a test on a root query field.
+func RequireQuery(t testing.TB, client *graphql.Client, name, expected string) {
+	t.Helper()
+	requireField(t, client, Probe{Root: "query", Field: name}, expected)
+}
+
+// RequireMutation gates a test on a root mutation field.
+func RequireMutation(t testing.TB, client *graphql.Client, name, expected string) {
+	t.Helper()
+	requireField(t, client, Probe{Root: "mutation", Field: name}, expected)
+}
+
+// RequireSubscription gates a test on a root subscription field.
+func RequireSubscription(t testing.TB, client *graphql.Client, name, expected string) {
+	t.Helper()
+	requireField(t, client, Probe{Root: "subscription", Field: name}, expected)
+}
+
+// RequireTypeField gates a test on a field of an object or input type.
+func RequireTypeField(t testing.TB, client *graphql.Client, typeName, field, expected string) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ok, err := client.HasTypeField(ctx, typeName, field)
+	gate(t, ok, err, typeName+"."+field, expected)
+}
+
+// RequireQueryArgument gates a test on an argument of a root query field.
+func RequireQueryArgument(t testing.TB, client *graphql.Client, field, arg, expected string) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ok, err := client.HasQueryArgument(ctx, field, arg)
+	gate(t, ok, err, "query "+field+"("+arg+")", expected)
+}
+
+// RequireMutationArgument gates a test on an argument of a root mutation field.
+func RequireMutationArgument(t testing.TB, client *graphql.Client, field, arg, expected string) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ok, err := client.HasMutationArgument(ctx, field, arg)
+	gate(t, ok, err, "mutation "+field+"("+arg+")", expected)
+}
+
+// RequireSetting gates a test on a system setting the server knows about.
+func RequireSetting(t testing.TB, client *graphql.Client, key, expected string) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var resp struct {
+		Setting *struct {
+			Key string `json:"key"`
+		} `json:"setting"`
+	}
+	err := client.Query(ctx, `query GetSetting($key: String!) { setting(key: $key) { key } }`,
+		map[string]interface{}{"key": key}, &resp)
+	gate(t, resp.Setting != nil, err, "setting "+key, expected)
+}
+
+func requireField(t testing.TB, client *graphql.Client, p Probe, expected string) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ok, err := HasProbe(ctx, client, p)
+	gate(t, ok, err, p.Root+" "+p.Field, expected)
+}
+
+// gate skips or fails the test for a missing schema element.
+func gate(t testing.TB, ok bool, err error, what, expected string) {
+	t.Helper()
+
+	if err != nil {
+		t.Skipf("Skipping: cannot introspect schema: %v", err)
+	}
+	if ok {
+		return
+	}
+
+	if os.Getenv("PENDING_CONTRACTS") != "" {
+		t.Fatalf("%s is not implemented; expected: %s", what, expected)
+	}
+	t.Skipf("Skipping: %s is not implemented (set PENDING_CONTRACTS=1 to fail)", what)
+}
+
+// version is a parsed major.minor.patch version.
+type version [3]int
+
+// parseVersion accepts "1.2.3", "v1.2", "1.2.3-beta.1" and "1.2.3+build".
+func parseVersion(s string) (version, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		s = s[:i]
+	}
+	parts := strings.Split(s, ".")
+	if s == "" || len(parts) > 3 {
+		return version{}, false
+	}
+
+	var v version
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return version{}, false
+		}
+		v[i] = n
+	}
+	return v, true
+}
+
+func (v version) less(o version) bool {
+	for i := range v {
+		if v[i] != o[i] {
+			return v[i] < o[i]
+		}
+	}
+	return false
+}
+
+// contains reports whether v falls in the span. Bounds are assumed to parse.
+func (s Span) contains(v version) bool {
+	if since, ok := parseVersion(s.Since); ok && v.less(since) {
+		return false
+	}
+	if until, ok := parseVersion(s.Until); ok && !v.less(until) {
+		return false
+	}
+	return true
+}
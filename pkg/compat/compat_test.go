@@ -0,0 +1,208 @@
+package compat
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersion(t *testing.T) {
+	cases := map[string]version{
+		"1.2.3":        {1, 2, 3},
+		"v1.2.3":       {1, 2, 3},
+		"1.2":          {1, 2, 0},
+		"2":            {2, 0, 0},
+		"1.2.3-beta.1": {1, 2, 3},
+		"1.2.3+abc":    {1, 2, 3},
+	}
+	for in, want := range cases {
+		got, ok := parseVersion(in)
+		assert.True(t, ok, in)
+		assert.Equal(t, want, got, in)
+	}
+
+	for _, in := range []string{"", "dev", "1.x", "1.2.3.4", "-1.0"} {
+		_, ok := parseVersion(in)
+		assert.False(t, ok, in)
+	}
+}
+
+func TestMatrix(t *testing.T) {
+	assert.Nil(t, Matrix("dev"), "Unparsable versions have no matrix")
+
+	old := Matrix("0.9.5")
+	assert.True(t, old[Scenes])
+	assert.False(t, old[Looks])
+	assert.False(t, old[Effects])
+
+	renamed := Matrix("1.0.0")
+	assert.True(t, renamed[Looks], "Since is inclusive")
+	assert.False(t, renamed[Scenes], "Until is exclusive")
+	assert.False(t, renamed[Undo])
+
+	current := Matrix("v1.4.0-rc.1")
+	for _, f := range []Feature{Looks, Effects, Undo} {
+		assert.True(t, current[f], f)
+	}
+	assert.Len(t, current, len(SchemaFeatures))
+}
+
+// fakeServer answers systemInfo and the introspection queries the graphql
+// client sends, exposing the given mutation fields.
+func fakeServer(t *testing.T, systemInfo map[string]interface{}, mutations ...string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphql.Request
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		var data interface{}
+		switch {
+		case strings.Contains(req.Query, "__schema"):
+			data = map[string]interface{}{"__schema": map[string]interface{}{
+				"queryType":        map[string]interface{}{"name": "Query"},
+				"mutationType":     map[string]interface{}{"name": "Mutation"},
+				"subscriptionType": nil,
+			}}
+		case strings.Contains(req.Query, "__type"):
+			var fields []map[string]interface{}
+			switch req.Variables["name"] {
+			case "Mutation":
+				for _, m := range mutations {
+					fields = append(fields, map[string]interface{}{"name": m})
+				}
+			case "SystemInfo":
+				for name := range systemInfo {
+					fields = append(fields, map[string]interface{}{"name": name})
+				}
+			}
+			data = map[string]interface{}{"__type": map[string]interface{}{"fields": fields}}
+		case strings.Contains(req.Query, "systemInfo"):
+			data = map[string]interface{}{"systemInfo": systemInfo}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDetectChecksMatrix(t *testing.T) {
+	server := fakeServer(t, map[string]interface{}{"version": "1.1.0", "artnetEnabled": true}, "createLook")
+
+	s, err := Detect(context.Background(), graphql.NewClient(server.URL))
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", s.Version)
+	assert.True(t, s.Supports(Looks))
+	assert.False(t, s.Supports(Effects), "The schema wins over the matrix")
+	assert.Equal(t, []Feature{Effects}, s.Mismatches, "The matrix expects effects at 1.1.0")
+	assert.True(t, s.ArtNetEnabled)
+
+	agreeing := fakeServer(t, map[string]interface{}{"version": "1.1.0"}, "createLook", "createEffect")
+	s, err = Detect(context.Background(), graphql.NewClient(agreeing.URL))
+	require.NoError(t, err)
+	assert.Empty(t, s.Mismatches)
+}
+
+func TestDetectProbesWithoutVersion(t *testing.T) {
+	server := fakeServer(t, map[string]interface{}{"artnetEnabled": false}, "createLook", "undo")
+
+	s, err := Detect(context.Background(), graphql.NewClient(server.URL))
+	require.NoError(t, err)
+	assert.Empty(t, s.Version)
+	assert.Empty(t, s.Mismatches, "Without a version there is no matrix to disagree with")
+	assert.True(t, s.Supports(Looks))
+	assert.True(t, s.Supports(Undo))
+	assert.False(t, s.Supports(Effects))
+	assert.False(t, s.Supports(Scenes))
+	assert.False(t, s.Supports(ArtNet))
+}
+
+func TestArtNetHonorsSkipFadeTests(t *testing.T) {
+	s := &Server{ArtNetEnabled: true}
+	assert.True(t, s.Supports(ArtNet))
+
+	t.Setenv("SKIP_FADE_TESTS", "1")
+	assert.False(t, s.Supports(ArtNet))
+}
+
+func TestEffectsHonorsSkipEffectTests(t *testing.T) {
+	s := &Server{features: map[Feature]bool{Effects: true}}
+	assert.True(t, s.Supports(Effects))
+
+	t.Setenv("SKIP_EFFECT_TESTS", "1")
+	assert.False(t, s.Supports(Effects))
+}
+
+func TestDMXChecksDisabled(t *testing.T) {
+	t.Setenv("SKIP_DMX_TESTS", "")
+	t.Setenv("SKIP_FADE_TESTS", "")
+	assert.False(t, DMXChecksDisabled())
+
+	t.Setenv("SKIP_DMX_TESTS", "1")
+	assert.True(t, DMXChecksDisabled())
+
+	t.Setenv("SKIP_DMX_TESTS", "")
+	t.Setenv("SKIP_FADE_TESTS", "1")
+	assert.True(t, DMXChecksDisabled())
+}
+
+func TestRequire(t *testing.T) {
+	server := fakeServer(t, map[string]interface{}{"version": "1.1.0", "artnetEnabled": false}, "createLook", "createEffect")
+	t.Setenv("GRAPHQL_ENDPOINT", server.URL)
+	t.Setenv("PENDING_CONTRACTS", "")
+	t.Setenv("SKIP_FADE_TESTS", "")
+	t.Setenv("SKIP_EFFECT_TESTS", "")
+
+	var supported, missing, artnet, effects bool
+	t.Run("Supported", func(t *testing.T) {
+		Require(t, Looks, Effects)
+		supported = true
+	})
+	t.Run("Missing", func(t *testing.T) {
+		Require(t, Undo)
+		missing = true
+	})
+	t.Run("ArtNet", func(t *testing.T) {
+		Require(t, ArtNet)
+		artnet = true
+	})
+	t.Run("SkipEffectTests", func(t *testing.T) {
+		t.Setenv("SKIP_EFFECT_TESTS", "1")
+		Require(t, Effects)
+		effects = true
+	})
+
+	assert.True(t, supported, "Supported features should not skip")
+	assert.False(t, missing, "Missing features should skip")
+	assert.False(t, artnet, "Disabled Art-Net should skip")
+	assert.False(t, effects, "SKIP_EFFECT_TESTS should skip effects")
+}
+
+func TestRequireIgnoresMatrix(t *testing.T) {
+	// The matrix expects neither effects at 1.0.0 nor undo missing at 9.0.0
+	for _, version := range []string{"1.0.0", "9.0.0"} {
+		server := fakeServer(t, map[string]interface{}{"version": version}, "createLook", "createEffect")
+		t.Setenv("GRAPHQL_ENDPOINT", server.URL)
+		t.Setenv("PENDING_CONTRACTS", "")
+		t.Setenv("SKIP_EFFECT_TESTS", "")
+
+		var effects, undo bool
+		t.Run(version, func(t *testing.T) {
+			t.Run("Effects", func(t *testing.T) {
+				Require(t, Effects)
+				effects = true
+			})
+			t.Run("Undo", func(t *testing.T) {
+				Require(t, Undo)
+				undo = true
+			})
+		})
+		assert.True(t, effects, "%s: a feature in the schema should run whatever the matrix says", version)
+		assert.False(t, undo, "%s: a feature missing from the schema should skip whatever the matrix says", version)
+	}
+}
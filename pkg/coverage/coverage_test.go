@@ -0,0 +1,209 @@
+package coverage
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSchema is a trimmed introspection result in the shape IntrospectionQuery returns.
+const testSchema = `{"data": {"__schema": {
+	"queryType": {"name": "Query"},
+	"mutationType": {"name": "Mutation"},
+	"subscriptionType": {"name": "Subscription"},
+	"types": [
+		{"name": "Query", "kind": "OBJECT", "fields": [
+			{"name": "project", "args": [{"name": "id"}], "type": {"kind": "OBJECT", "name": "Project"}},
+			{"name": "projects", "args": [{"name": "filter"}], "type": {"kind": "NON_NULL", "name": null,
+				"ofType": {"kind": "LIST", "name": null, "ofType": {"kind": "OBJECT", "name": "Project"}}}},
+			{"name": "dmxOutput", "args": [{"name": "universe"}], "type": {"kind": "LIST", "name": null, "ofType": {"kind": "SCALAR", "name": "Int"}}}
+		]},
+		{"name": "Mutation", "kind": "OBJECT", "fields": [
+			{"name": "createProject", "args": [{"name": "input"}], "type": {"kind": "OBJECT", "name": "Project"}},
+			{"name": "deleteProject", "args": [{"name": "id"}], "type": {"kind": "SCALAR", "name": "Boolean"}}
+		]},
+		{"name": "Subscription", "kind": "OBJECT", "fields": [
+			{"name": "projectUpdated", "args": [{"name": "projectId"}], "type": {"kind": "OBJECT", "name": "Project"}}
+		]},
+		{"name": "Project", "kind": "OBJECT", "fields": [
+			{"name": "id", "args": [], "type": {"kind": "SCALAR", "name": "ID"}},
+			{"name": "name", "args": [], "type": {"kind": "SCALAR", "name": "String"}},
+			{"name": "looks", "args": [{"name": "first"}], "type": {"kind": "LIST", "name": null, "ofType": {"kind": "OBJECT", "name": "Look"}}}
+		]},
+		{"name": "Look", "kind": "OBJECT", "fields": [
+			{"name": "id", "args": [], "type": {"kind": "SCALAR", "name": "ID"}},
+			{"name": "name", "args": [], "type": {"kind": "SCALAR", "name": "String"}}
+		]},
+		{"name": "__Type", "kind": "OBJECT", "fields": [{"name": "name", "args": [], "type": {"kind": "SCALAR", "name": "String"}}]},
+		{"name": "CreateProjectInput", "kind": "INPUT_OBJECT", "fields": null}
+	]
+}}}`
+
+func loadTestSchema(t *testing.T) *Schema {
+	schema, err := ParseSchema([]byte(testSchema))
+	require.NoError(t, err)
+	return schema
+}
+
+func TestParseSchema(t *testing.T) {
+	schema := loadTestSchema(t)
+	assert.Equal(t, "Mutation", schema.Mutation)
+	assert.Len(t, schema.Types, 5, "Introspection and input types are left out")
+
+	projects := schema.Types["Query"].Field("projects")
+	require.NotNil(t, projects)
+	assert.Equal(t, "Project", projects.Type, "Lists and non-null are unwrapped")
+	assert.Equal(t, []string{"filter"}, projects.Args)
+
+	_, err := ParseSchema([]byte(`{"__schema": {}}`))
+	assert.Error(t, err, "A schema without a query type is rejected")
+}
+
+func TestUsageAdd(t *testing.T) {
+	schema := loadTestSchema(t)
+
+	t.Run("FieldsAndArguments", func(t *testing.T) {
+		u := NewUsage()
+		u.Add(schema, `
+			query GetProject($id: ID!) {
+				project(id: $id) { id name looks { id } }
+			}
+		`, 3)
+		assert.Empty(t, u.Unparsed)
+		assert.Equal(t, map[string]int{
+			"Query.project":     3,
+			"Query.project(id)": 3,
+			"Project.id":        3,
+			"Project.name":      3,
+			"Project.looks":     3,
+			"Look.id":           3,
+		}, u.Counts)
+		assert.Equal(t, 3, u.Operations)
+	})
+
+	t.Run("AnonymousQueryWithLiterals", func(t *testing.T) {
+		u := NewUsage()
+		u.Add(schema, `query { dmxOutput(universe: 1) }`, 1)
+		u.Add(schema, `{ projects(filter: {name: "a, (b) {c}", tags: ["x"]}) { id } }`, 1)
+		assert.Empty(t, u.Unparsed)
+		assert.Equal(t, 1, u.Counts["Query.dmxOutput(universe)"])
+		assert.Equal(t, 1, u.Counts["Query.projects(filter)"])
+		assert.NotContains(t, u.Counts, "Query.projects(name)", "Object fields inside a value are not arguments")
+	})
+
+	t.Run("MutationAndSubscriptionRoots", func(t *testing.T) {
+		u := NewUsage()
+		u.Add(schema, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`, 1)
+		u.Add(schema, `subscription { projectUpdated(projectId: "1") { name } }`, 1)
+		assert.Equal(t, 1, u.Counts["Mutation.deleteProject(id)"])
+		assert.Equal(t, 1, u.Counts["Subscription.projectUpdated"])
+		assert.Equal(t, 1, u.Counts["Project.name"])
+	})
+
+	t.Run("AliasesFragmentsAndDirectives", func(t *testing.T) {
+		u := NewUsage()
+		u.Add(schema, `
+			# fragments may be defined after use
+			query Both {
+				first: project(id: "1") { ...ProjectFields }
+				second: project(id: "2") @include(if: true) {
+					... on Project { looks(first: 2) { ... @skip(if: false) { name } } }
+					__typename
+				}
+			}
+			fragment ProjectFields on Project { id name }
+		`, 1)
+		assert.Empty(t, u.Unparsed)
+		assert.Equal(t, 2, u.Counts["Query.project"], "Aliases count under the field name")
+		assert.Equal(t, 1, u.Counts["Project.id"])
+		assert.Equal(t, 1, u.Counts["Project.looks(first)"])
+		assert.Equal(t, 1, u.Counts["Look.name"])
+		assert.Empty(t, u.Unknown, "__typename is not reported")
+	})
+
+	t.Run("UnknownSelections", func(t *testing.T) {
+		u := NewUsage()
+		u.Add(schema, `{ project(id: "1", includeDeleted: true) { id color { red } } parkedChannels { channel } }`, 1)
+		assert.Equal(t, map[string]int{
+			"Query.project(includeDeleted)": 1,
+			"Project.color":                 1,
+			"Query.parkedChannels":          1,
+		}, u.Unknown)
+		assert.Equal(t, 1, u.Counts["Project.id"])
+	})
+
+	t.Run("Unparsed", func(t *testing.T) {
+		u := NewUsage()
+		u.Add(schema, `query { project(id: "1") { id `, 1)
+		u.Add(schema, `{ ...Missing }`, 1)
+		assert.Len(t, u.Unparsed, 2)
+		assert.Equal(t, 2, u.Operations)
+	})
+}
+
+func TestBuildReport(t *testing.T) {
+	schema := loadTestSchema(t)
+	u := NewUsage()
+	u.Add(schema, `{ project(id: "1") { id name } }`, 2)
+	u.Add(schema, `mutation { createProject(input: {name: "x"}) { id } }`, 1)
+	u.Add(schema, `{ project(id: "1") { color } }`, 1)
+
+	r := BuildReport(schema, u)
+	var names []string
+	for _, typ := range r.Types {
+		names = append(names, typ.Name)
+	}
+	assert.Equal(t, []string{"Query", "Mutation", "Subscription", "Look", "Project"}, names, "Roots first, then by name")
+
+	query := r.Types[0]
+	assert.Equal(t, "Query", query.Root)
+	assert.Equal(t, 1, query.Covered())
+	assert.Equal(t, []string{"dmxOutput", "project", "projects"}, []string{query.Fields[0].Name, query.Fields[1].Name, query.Fields[2].Name})
+	assert.Equal(t, 3, query.Fields[1].Calls)
+	assert.Empty(t, query.Fields[1].UntestedArgs())
+	assert.Equal(t, []string{"filter"}, query.Fields[2].UntestedArgs())
+
+	covered, total := r.Totals()
+	assert.Equal(t, 4, covered)
+	assert.Equal(t, 11, total)
+	assert.Equal(t, []string{"Project.color (1)"}, r.Unknown)
+
+	t.Run("Markdown", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, WriteMarkdown(&buf, r))
+		out := buf.String()
+		assert.Contains(t, out, "4 operations recorded. 4 of 11 fields covered (36%).")
+		assert.Contains(t, out, "| ✅ | `project` | 3 |  |")
+		assert.Contains(t, out, "| ❌ | `projects` | 0 | filter |")
+		assert.Contains(t, out, "- `Project.color (1)`")
+	})
+
+	t.Run("HTML", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, WriteHTML(&buf, r))
+		out := buf.String()
+		assert.Contains(t, out, "4 of 11 fields covered (36%)")
+		assert.Contains(t, out, `<tr class="miss"><td><code>projects</code></td><td class="n">0</td><td>filter</td></tr>`)
+		assert.Contains(t, out, `<h2 id="Project">Project (2/3)</h2>`)
+	})
+}
+
+func TestLoadRecordings(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "1.ndjson"),
+		[]byte(`{"time":"2024-01-01T00:00:00Z","durationMs":0,"query":"{ a }"}`+"\n\n"+`{"query":"{ b }"}`+"\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "2.ndjson"), []byte(`{"query":"{ a }"}`+"\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o644))
+
+	documents, err := LoadRecordings(dir)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"{ a }": 2, "{ b }": 1}, documents)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "3.ndjson"), []byte("not json\n"), 0o644))
+	_, err = LoadRecordings(dir)
+	assert.ErrorContains(t, err, "3.ndjson:1")
+}
@@ -0,0 +1,357 @@
+package coverage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Usage counts how often each schema coordinate was selected: "Type.field"
+// for fields and "Type.field(arg)" for arguments. Selections the schema does
+// not know are counted in Unknown under the same naming.
+type Usage struct {
+	Counts     map[string]int
+	Unknown    map[string]int
+	Operations int
+	Unparsed   []string
+}
+
+// NewUsage returns an empty Usage.
+func NewUsage() *Usage {
+	return &Usage{Counts: make(map[string]int), Unknown: make(map[string]int)}
+}
+
+// Add walks a GraphQL document sent count times and counts every field and
+// argument it selects. A document that cannot be parsed is kept in Unparsed
+// with the error and otherwise ignored.
+func (u *Usage) Add(schema *Schema, document string, count int) {
+	u.Operations += count
+
+	tokens, err := tokenize(document)
+	if err == nil {
+		w := &walker{schema: schema, usage: u, tokens: tokens, count: count, fragments: make(map[string]fragment)}
+		err = w.document()
+	}
+	if err != nil {
+		u.Unparsed = append(u.Unparsed, fmt.Sprintf("%v: %s", err, strings.Join(strings.Fields(document), " ")))
+	}
+}
+
+// Coordinate names a field, or one of its arguments when arg is not empty.
+func Coordinate(typeName, field, arg string) string {
+	if arg == "" {
+		return typeName + "." + field
+	}
+	return typeName + "." + field + "(" + arg + ")"
+}
+
+// tokenize splits a document into names, punctuators and literals, dropping
+// whitespace, commas and comments. Literals are kept as single tokens.
+func tokenize(doc string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(doc); {
+		c := doc[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(doc) && doc[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(doc[i:], "..."):
+			tokens = append(tokens, "...")
+			i += 3
+		case strings.HasPrefix(doc[i:], `"""`):
+			j := i + 3
+			for j < len(doc) && !(strings.HasPrefix(doc[j:], `"""`) && doc[j-1] != '\\') {
+				j++
+			}
+			if j >= len(doc) {
+				return nil, fmt.Errorf("unterminated block string")
+			}
+			tokens = append(tokens, doc[i:j+3])
+			i = j + 3
+		case c == '"':
+			j := i + 1
+			for j < len(doc) && doc[j] != '"' {
+				if doc[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(doc) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			tokens = append(tokens, doc[i:j+1])
+			i = j + 1
+		case strings.IndexByte("{}()[]:=@$!|&", c) >= 0:
+			tokens = append(tokens, string(c))
+			i++
+		case isNameStart(c) || c == '-' || c >= '0' && c <= '9':
+			j := i + 1
+			for j < len(doc) && (isNameStart(doc[j]) || doc[j] >= '0' && doc[j] <= '9' || doc[j] == '.' || doc[j] == '+' || doc[j] == '-') {
+				j++
+			}
+			tokens = append(tokens, doc[i:j])
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected character %q", c)
+		}
+	}
+	return tokens, nil
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// fragment is a named fragment definition: its type condition and where its
+// selection set starts.
+type fragment struct {
+	typeName string
+	start    int
+}
+
+type walker struct {
+	schema    *Schema
+	usage     *Usage
+	tokens    []string
+	count     int
+	fragments map[string]fragment
+	active    map[string]bool // fragments being walked, to stop cycles
+}
+
+func (w *walker) peek(pos int) string {
+	if pos < len(w.tokens) {
+		return w.tokens[pos]
+	}
+	return ""
+}
+
+// document finds the fragments first, since operations may spread a fragment
+// defined after them, then walks each operation from its root type.
+func (w *walker) document() error {
+	type operation struct {
+		root  string
+		start int
+	}
+	var operations []operation
+
+	for pos := 0; pos < len(w.tokens); {
+		root := w.schema.Query
+		switch w.tokens[pos] {
+		case "{":
+		case "fragment":
+			if w.peek(pos+2) != "on" {
+				return fmt.Errorf("malformed fragment")
+			}
+			name, typeName := w.peek(pos+1), w.peek(pos+3)
+			start := w.skipDirectives(pos + 4)
+			w.fragments[name] = fragment{typeName: typeName, start: start}
+			end, err := w.skipBlock(start, "{", "}")
+			if err != nil {
+				return err
+			}
+			pos = end
+			continue
+		case "query", "mutation", "subscription":
+			switch w.tokens[pos] {
+			case "mutation":
+				root = w.schema.Mutation
+			case "subscription":
+				root = w.schema.Subscription
+			}
+			pos++
+			if pos < len(w.tokens) && isNameStart(w.tokens[pos][0]) {
+				pos++
+			}
+			if w.peek(pos) == "(" {
+				end, err := w.skipBlock(pos, "(", ")")
+				if err != nil {
+					return err
+				}
+				pos = end
+			}
+			pos = w.skipDirectives(pos)
+		default:
+			return fmt.Errorf("unexpected %q at top level", w.tokens[pos])
+		}
+
+		if w.peek(pos) != "{" {
+			return fmt.Errorf("expected selection set")
+		}
+		operations = append(operations, operation{root: root, start: pos})
+		end, err := w.skipBlock(pos, "{", "}")
+		if err != nil {
+			return err
+		}
+		pos = end
+	}
+
+	for _, op := range operations {
+		w.active = make(map[string]bool)
+		if _, err := w.selectionSet(op.root, op.start); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// skipBlock returns the position after the close token matching the open token at pos.
+func (w *walker) skipBlock(pos int, open, close string) (int, error) {
+	if w.peek(pos) != open {
+		return pos, fmt.Errorf("expected %q", open)
+	}
+	depth := 0
+	for ; pos < len(w.tokens); pos++ {
+		switch w.tokens[pos] {
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return pos + 1, nil
+			}
+		}
+	}
+	return pos, fmt.Errorf("unclosed %q", open)
+}
+
+// skipDirectives returns the position after any directives at pos.
+func (w *walker) skipDirectives(pos int) int {
+	for w.peek(pos) == "@" {
+		pos += 2
+		if w.peek(pos) == "(" {
+			if end, err := w.skipBlock(pos, "(", ")"); err == nil {
+				pos = end
+			}
+		}
+	}
+	return pos
+}
+
+// selectionSet walks the selection set at pos as fields of typeName and
+// returns the position after it. An empty typeName walks without counting.
+func (w *walker) selectionSet(typeName string, pos int) (int, error) {
+	if w.peek(pos) != "{" {
+		return pos, fmt.Errorf("expected selection set")
+	}
+	pos++
+
+	for w.peek(pos) != "}" {
+		if pos >= len(w.tokens) {
+			return pos, fmt.Errorf("unclosed selection set")
+		}
+
+		if w.tokens[pos] == "..." {
+			var err error
+			if pos, err = w.spread(typeName, pos+1); err != nil {
+				return pos, err
+			}
+			continue
+		}
+
+		name := w.tokens[pos]
+		pos++
+		if w.peek(pos) == ":" {
+			name = w.peek(pos + 1)
+			pos += 2
+		}
+
+		var args []string
+		if w.peek(pos) == "(" {
+			end, err := w.skipBlock(pos, "(", ")")
+			if err != nil {
+				return pos, err
+			}
+			args = w.argumentNames(pos+1, end-1)
+			pos = end
+		}
+		pos = w.skipDirectives(pos)
+
+		childType := w.countField(typeName, name, args)
+		if w.peek(pos) == "{" {
+			var err error
+			if pos, err = w.selectionSet(childType, pos); err != nil {
+				return pos, err
+			}
+		}
+	}
+	return pos + 1, nil
+}
+
+// spread walks an inline fragment or fragment spread whose "..." ends before pos.
+func (w *walker) spread(typeName string, pos int) (int, error) {
+	switch {
+	case w.peek(pos) == "on":
+		cond := w.peek(pos + 1)
+		return w.selectionSet(cond, w.skipDirectives(pos+2))
+	case w.peek(pos) == "{" || w.peek(pos) == "@":
+		return w.selectionSet(typeName, w.skipDirectives(pos))
+	}
+
+	name := w.peek(pos)
+	pos = w.skipDirectives(pos + 1)
+	f, ok := w.fragments[name]
+	if !ok {
+		return pos, fmt.Errorf("unknown fragment %q", name)
+	}
+	if w.active[name] {
+		return pos, nil
+	}
+	w.active[name] = true
+	_, err := w.selectionSet(f.typeName, f.start)
+	w.active[name] = false
+	return pos, err
+}
+
+// argumentNames returns the names of the arguments between from and to, the
+// tokens inside an argument list's parentheses.
+func (w *walker) argumentNames(from, to int) []string {
+	var names []string
+	depth := 0
+	for pos := from; pos < to; pos++ {
+		switch w.tokens[pos] {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+		default:
+			if depth == 0 && w.peek(pos+1) == ":" {
+				names = append(names, w.tokens[pos])
+				pos++
+			}
+		}
+	}
+	return names
+}
+
+// countField counts a selected field and its arguments and returns the field's type.
+func (w *walker) countField(typeName, name string, args []string) string {
+	if typeName == "" || strings.HasPrefix(name, "__") {
+		return ""
+	}
+
+	typ := w.schema.Types[typeName]
+	var field *Field
+	if typ != nil {
+		field = typ.Field(name)
+	}
+	if field == nil {
+		w.usage.Unknown[Coordinate(typeName, name, "")] += w.count
+		return ""
+	}
+
+	w.usage.Counts[Coordinate(typeName, name, "")] += w.count
+	for _, arg := range args {
+		known := false
+		for _, a := range field.Args {
+			known = known || a == arg
+		}
+		if known {
+			w.usage.Counts[Coordinate(typeName, name, arg)] += w.count
+		} else {
+			w.usage.Unknown[Coordinate(typeName, name, arg)] += w.count
+		}
+	}
+	return field.Type
+}
@@ -0,0 +1,275 @@
+package coverage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ArgCoverage is how often one argument of a field was passed.
+type ArgCoverage struct {
+	Name  string
+	Calls int
+}
+
+// FieldCoverage is how often a field was selected, and with which arguments.
+type FieldCoverage struct {
+	Name  string
+	Calls int
+	Args  []ArgCoverage
+}
+
+// UntestedArgs returns the names of the arguments never passed.
+func (f FieldCoverage) UntestedArgs() []string {
+	var names []string
+	for _, a := range f.Args {
+		if a.Calls == 0 {
+			names = append(names, a.Name)
+		}
+	}
+	return names
+}
+
+// TypeCoverage is the coverage of one object or interface type.
+type TypeCoverage struct {
+	Name   string
+	Root   string // "Query", "Mutation" or "Subscription" for root types, otherwise empty
+	Fields []FieldCoverage
+}
+
+// Covered returns how many of the type's fields were selected at least once.
+func (t TypeCoverage) Covered() int {
+	n := 0
+	for _, f := range t.Fields {
+		if f.Calls > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// Percent returns the share of fields covered, 0-100.
+func (t TypeCoverage) Percent() float64 {
+	if len(t.Fields) == 0 {
+		return 100
+	}
+	return float64(t.Covered()) * 100 / float64(len(t.Fields))
+}
+
+// Report is the coverage matrix: root types first, then every other type by name.
+type Report struct {
+	Types      []TypeCoverage
+	Operations int
+	Unknown    []string // selections missing from the schema, with counts
+	Unparsed   []string
+}
+
+// Totals returns the covered and total field counts over every type.
+func (r *Report) Totals() (covered, total int) {
+	for _, t := range r.Types {
+		covered += t.Covered()
+		total += len(t.Fields)
+	}
+	return covered, total
+}
+
+// BuildReport combines a schema with the recorded usage.
+func BuildReport(schema *Schema, usage *Usage) *Report {
+	r := &Report{Operations: usage.Operations, Unparsed: append([]string(nil), usage.Unparsed...)}
+	sort.Strings(r.Unparsed)
+
+	roots := map[string]string{}
+	var rootOrder []string
+	for _, root := range []struct{ label, name string }{
+		{"Query", schema.Query},
+		{"Mutation", schema.Mutation},
+		{"Subscription", schema.Subscription},
+	} {
+		if root.name != "" && schema.Types[root.name] != nil {
+			roots[root.name] = root.label
+			rootOrder = append(rootOrder, root.name)
+		}
+	}
+
+	var others []string
+	for name := range schema.Types {
+		if _, ok := roots[name]; !ok {
+			others = append(others, name)
+		}
+	}
+	sort.Strings(others)
+
+	for _, name := range append(rootOrder, others...) {
+		typ := schema.Types[name]
+		tc := TypeCoverage{Name: name, Root: roots[name]}
+		for _, f := range typ.Fields {
+			fc := FieldCoverage{Name: f.Name, Calls: usage.Counts[Coordinate(name, f.Name, "")]}
+			for _, arg := range f.Args {
+				fc.Args = append(fc.Args, ArgCoverage{Name: arg, Calls: usage.Counts[Coordinate(name, f.Name, arg)]})
+			}
+			tc.Fields = append(tc.Fields, fc)
+		}
+		sort.Slice(tc.Fields, func(i, j int) bool { return tc.Fields[i].Name < tc.Fields[j].Name })
+		r.Types = append(r.Types, tc)
+	}
+
+	for coord, n := range usage.Unknown {
+		r.Unknown = append(r.Unknown, fmt.Sprintf("%s (%d)", coord, n))
+	}
+	sort.Strings(r.Unknown)
+	return r
+}
+
+// LoadRecordings reads the operations in every *.ndjson file in dir, as
+// written by the graphql recorders, and returns how often each was sent.
+func LoadRecordings(dir string) (map[string]int, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.ndjson"))
+	if err != nil {
+		return nil, err
+	}
+
+	documents := make(map[string]int)
+	for _, path := range paths {
+		if err := loadRecording(path, documents); err != nil {
+			return nil, err
+		}
+	}
+	return documents, nil
+}
+
+func loadRecording(path string, documents map[string]int) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var ex struct {
+			Query string `json:"query"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &ex); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if ex.Query != "" {
+			documents[ex.Query]++
+		}
+	}
+	return scanner.Err()
+}
+
+// WriteMarkdown renders the report as a summary and one table per type.
+func WriteMarkdown(w io.Writer, r *Report) error {
+	var b strings.Builder
+	covered, total := r.Totals()
+	fmt.Fprintf(&b, "# Contract Coverage\n\n")
+	fmt.Fprintf(&b, "%d operations recorded. %d of %d fields covered (%.0f%%).\n\n", r.Operations, covered, total, percent(covered, total))
+
+	b.WriteString("| Type | Covered | Fields | % |\n|---|---:|---:|---:|\n")
+	for _, t := range r.Types {
+		fmt.Fprintf(&b, "| %s | %d | %d | %.0f%% |\n", typeLabel(t), t.Covered(), len(t.Fields), t.Percent())
+	}
+
+	for _, t := range r.Types {
+		fmt.Fprintf(&b, "\n## %s (%d/%d)\n\n", typeLabel(t), t.Covered(), len(t.Fields))
+		b.WriteString("| | Field | Calls | Untested arguments |\n|---|---|---:|---|\n")
+		for _, f := range t.Fields {
+			mark := "✅"
+			if f.Calls == 0 {
+				mark = "❌"
+			}
+			fmt.Fprintf(&b, "| %s | `%s` | %d | %s |\n", mark, f.Name, f.Calls, strings.Join(f.UntestedArgs(), ", "))
+		}
+	}
+
+	if len(r.Unknown) > 0 {
+		b.WriteString("\n## Not in schema\n\nSelected by the suites but missing from the schema:\n\n")
+		for _, u := range r.Unknown {
+			fmt.Fprintf(&b, "- `%s`\n", u)
+		}
+	}
+	if len(r.Unparsed) > 0 {
+		b.WriteString("\n## Unparsed operations\n\n")
+		for _, u := range r.Unparsed {
+			fmt.Fprintf(&b, "- %s\n", u)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"label": typeLabel,
+	"join":  func(s []string) string { return strings.Join(s, ", ") },
+	"pct":   func(covered, total int) string { return fmt.Sprintf("%.0f%%", percent(covered, total)) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Contract Coverage</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 2px 8px; text-align: left; }
+td.n { text-align: right; }
+tr.hit { background: #e6f4e6; }
+tr.miss { background: #fbe4e4; }
+</style>
+</head>
+<body>
+<h1>Contract Coverage</h1>
+{{$covered := .Covered}}{{$total := .Total}}
+<p>{{.Report.Operations}} operations recorded. {{$covered}} of {{$total}} fields covered ({{pct $covered $total}}).</p>
+<table>
+<tr><th>Type</th><th>Covered</th><th>Fields</th><th>%</th></tr>
+{{range .Report.Types}}<tr><td><a href="#{{.Name}}">{{label .}}</a></td><td class="n">{{.Covered}}</td><td class="n">{{len .Fields}}</td><td class="n">{{pct .Covered (len .Fields)}}</td></tr>
+{{end}}</table>
+{{range .Report.Types}}
+<h2 id="{{.Name}}">{{label .}} ({{.Covered}}/{{len .Fields}})</h2>
+<table>
+<tr><th>Field</th><th>Calls</th><th>Untested arguments</th></tr>
+{{range .Fields}}<tr class="{{if .Calls}}hit{{else}}miss{{end}}"><td><code>{{.Name}}</code></td><td class="n">{{.Calls}}</td><td>{{join .UntestedArgs}}</td></tr>
+{{end}}</table>
+{{end}}
+{{if .Report.Unknown}}<h2>Not in schema</h2>
+<ul>{{range .Report.Unknown}}<li><code>{{.}}</code></li>{{end}}</ul>{{end}}
+{{if .Report.Unparsed}}<h2>Unparsed operations</h2>
+<ul>{{range .Report.Unparsed}}<li>{{.}}</li>{{end}}</ul>{{end}}
+</body>
+</html>
+`))
+
+// WriteHTML renders the report as a standalone HTML page.
+func WriteHTML(w io.Writer, r *Report) error {
+	covered, total := r.Totals()
+	return htmlReport.Execute(w, struct {
+		Report         *Report
+		Covered, Total int
+	}{r, covered, total})
+}
+
+func typeLabel(t TypeCoverage) string {
+	if t.Root != "" && t.Root != t.Name {
+		return t.Root + " (" + t.Name + ")"
+	}
+	return t.Name
+}
+
+func percent(covered, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return float64(covered) * 100 / float64(total)
+}
@@ -0,0 +1,164 @@
+// Package coverage measures how much of the server's GraphQL schema the
+// contract suites exercise. Operations recorded with GRAPHQL_COVERAGE_DIR are
+// walked against the introspected schema, and every field and argument they
+// select is counted.
+package coverage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+)
+
+// IntrospectionQuery fetches the parts of the schema coverage is measured on.
+const IntrospectionQuery = `
+	query CoverageIntrospection {
+		__schema {
+			queryType { name }
+			mutationType { name }
+			subscriptionType { name }
+			types {
+				name
+				kind
+				fields(includeDeprecated: true) {
+					name
+					args { name }
+					type { ...TypeRef }
+				}
+			}
+		}
+	}
+	fragment TypeRef on __Type {
+		kind name
+		ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } }
+	}
+`
+
+// Field is a field of an object or interface type.
+type Field struct {
+	Name string
+	Args []string
+	Type string // named type, with lists and non-null unwrapped
+}
+
+// Type is an object or interface type.
+type Type struct {
+	Name   string
+	Kind   string
+	Fields []Field
+}
+
+// Field returns the named field, or nil.
+func (t *Type) Field(name string) *Field {
+	for i := range t.Fields {
+		if t.Fields[i].Name == name {
+			return &t.Fields[i]
+		}
+	}
+	return nil
+}
+
+// Schema is the object and interface types of a schema and its root type names.
+type Schema struct {
+	Query        string
+	Mutation     string
+	Subscription string
+	Types        map[string]*Type
+}
+
+type typeRef struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	OfType *typeRef `json:"ofType"`
+}
+
+// named unwraps lists and non-null to the underlying type name.
+func (r *typeRef) named() string {
+	for r != nil && r.Name == "" {
+		r = r.OfType
+	}
+	if r == nil {
+		return ""
+	}
+	return r.Name
+}
+
+type introspection struct {
+	Schema struct {
+		QueryType        *struct{ Name string } `json:"queryType"`
+		MutationType     *struct{ Name string } `json:"mutationType"`
+		SubscriptionType *struct{ Name string } `json:"subscriptionType"`
+		Types            []struct {
+			Name   string `json:"name"`
+			Kind   string `json:"kind"`
+			Fields []struct {
+				Name string `json:"name"`
+				Args []struct {
+					Name string `json:"name"`
+				} `json:"args"`
+				Type typeRef `json:"type"`
+			} `json:"fields"`
+		} `json:"types"`
+	} `json:"__schema"`
+}
+
+// LoadSchema introspects the server's schema.
+func LoadSchema(ctx context.Context, client *graphql.Client) (*Schema, error) {
+	var resp introspection
+	if err := client.Query(ctx, IntrospectionQuery, nil, &resp); err != nil {
+		return nil, fmt.Errorf("introspection: %w", err)
+	}
+	return newSchema(resp)
+}
+
+// ParseSchema reads an introspection result saved as JSON, either the data
+// object ({"__schema": ...}) or a full response ({"data": {"__schema": ...}}).
+func ParseSchema(data []byte) (*Schema, error) {
+	var wrapped struct {
+		Data *introspection `json:"data"`
+	}
+	if err := json.Unmarshal(data, &wrapped); err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+	if wrapped.Data != nil {
+		return newSchema(*wrapped.Data)
+	}
+
+	var resp introspection
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+	return newSchema(resp)
+}
+
+func newSchema(resp introspection) (*Schema, error) {
+	if resp.Schema.QueryType == nil {
+		return nil, fmt.Errorf("introspection result has no query type")
+	}
+
+	s := &Schema{Query: resp.Schema.QueryType.Name, Types: make(map[string]*Type)}
+	if resp.Schema.MutationType != nil {
+		s.Mutation = resp.Schema.MutationType.Name
+	}
+	if resp.Schema.SubscriptionType != nil {
+		s.Subscription = resp.Schema.SubscriptionType.Name
+	}
+
+	for _, t := range resp.Schema.Types {
+		if (t.Kind != "OBJECT" && t.Kind != "INTERFACE") || len(t.Name) >= 2 && t.Name[:2] == "__" {
+			continue
+		}
+		typ := &Type{Name: t.Name, Kind: t.Kind}
+		for _, f := range t.Fields {
+			field := Field{Name: f.Name, Type: f.Type.named()}
+			for _, a := range f.Args {
+				field.Args = append(field.Args, a.Name)
+			}
+			typ.Fields = append(typ.Fields, field)
+		}
+		s.Types[t.Name] = typ
+	}
+	return s, nil
+}
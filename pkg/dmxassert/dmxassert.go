@@ -0,0 +1,146 @@
+// Package dmxassert provides DMX frame assertions with per-channel diffs.
+package dmxassert
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+)
+
+// Any is a wildcard expected value: the channel is not checked.
+const Any = -1
+
+// ChannelDiff describes one channel that is outside tolerance.
+// Channel is 1-indexed to match DMX addressing.
+type ChannelDiff struct {
+	Channel  int
+	Expected int
+	Actual   int
+	Delta    int
+}
+
+// String returns a human-readable representation of the difference.
+func (d ChannelDiff) String() string {
+	return fmt.Sprintf("ch %3d: expected %3d, got %3d (delta %+d)", d.Channel, d.Expected, d.Actual, d.Delta)
+}
+
+// Option configures which channels are compared.
+type Option func(*options)
+
+type options struct {
+	ignore map[int]bool
+	msg    string
+}
+
+func newOptions(opts []Option) options {
+	o := options{ignore: make(map[int]bool)}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Ignore skips the given 1-indexed channels.
+func Ignore(channels ...int) Option {
+	return func(o *options) {
+		for _, ch := range channels {
+			o.ignore[ch] = true
+		}
+	}
+}
+
+// IgnoreRange skips the 1-indexed channels from first to last inclusive.
+func IgnoreRange(first, last int) Option {
+	return func(o *options) {
+		for ch := first; ch <= last; ch++ {
+			o.ignore[ch] = true
+		}
+	}
+}
+
+// Msg labels ExpectFrame's failure with the step being checked, formatted
+// as by fmt.Sprintf. DiffFrames ignores it.
+func Msg(format string, args ...interface{}) Option {
+	return func(o *options) {
+		o.msg = fmt.Sprintf(format, args...)
+	}
+}
+
+// DiffFrames compares got against want and returns every channel outside tolerance.
+// Only the first len(want) channels are compared; want entries set to Any and
+// ignored channels are skipped. A channel missing from got is reported with Actual -1.
+func DiffFrames(got, want []int, tolerance int, opts ...Option) []ChannelDiff {
+	o := newOptions(opts)
+
+	var diffs []ChannelDiff
+	for i, expected := range want {
+		channel := i + 1
+		if expected == Any || o.ignore[channel] {
+			continue
+		}
+
+		actual := -1
+		if i < len(got) {
+			actual = got[i]
+		}
+
+		delta := actual - expected
+		if actual >= 0 && abs(delta) <= tolerance {
+			continue
+		}
+		diffs = append(diffs, ChannelDiff{
+			Channel:  channel,
+			Expected: expected,
+			Actual:   actual,
+			Delta:    delta,
+		})
+	}
+	return diffs
+}
+
+// FormatDiffs renders diffs one per line for failure messages.
+func FormatDiffs(diffs []ChannelDiff) string {
+	lines := make([]string, len(diffs))
+	for i, d := range diffs {
+		lines[i] = d.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ExpectFrame fails the test with a per-channel diff if got does not match want
+// within tolerance. It returns true when the frames match.
+func ExpectFrame(t testing.TB, got, want []int, tolerance int, opts ...Option) bool {
+	t.Helper()
+
+	diffs := DiffFrames(got, want, tolerance, opts...)
+	if len(diffs) == 0 {
+		return true
+	}
+	prefix := ""
+	if o := newOptions(opts); o.msg != "" {
+		prefix = o.msg + ": "
+	}
+	t.Errorf("%sDMX frame mismatch (%d channels outside tolerance %d):\n%s", prefix, len(diffs), tolerance, FormatDiffs(diffs))
+	return false
+}
+
+// FromArtNet converts a captured Art-Net frame to the []int form returned by dmxOutput.
+func FromArtNet(frame *artnet.Frame) []int {
+	if frame == nil {
+		return nil
+	}
+	values := make([]int, artnet.DMXChannels)
+	for i, v := range frame.Channels {
+		values[i] = int(v)
+	}
+	return values
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,76 @@
+package dmxassert
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffFrames(t *testing.T) {
+	got := []int{255, 128, 0, 10, 99}
+
+	t.Run("WithinTolerance", func(t *testing.T) {
+		assert.Empty(t, DiffFrames(got, []int{254, 130, 1}, 2))
+	})
+
+	t.Run("ReportsOutOfTolerance", func(t *testing.T) {
+		diffs := DiffFrames(got, []int{255, 100, 0, 20}, 2)
+		require.Len(t, diffs, 2)
+		assert.Equal(t, ChannelDiff{Channel: 2, Expected: 100, Actual: 128, Delta: 28}, diffs[0])
+		assert.Equal(t, ChannelDiff{Channel: 4, Expected: 20, Actual: 10, Delta: -10}, diffs[1])
+		assert.Equal(t, "ch   2: expected 100, got 128 (delta +28)", diffs[0].String())
+	})
+
+	t.Run("WildcardsAndIgnores", func(t *testing.T) {
+		want := []int{0, Any, 255, 0, 0}
+		diffs := DiffFrames(got, want, 0, Ignore(1), IgnoreRange(3, 4))
+		require.Len(t, diffs, 1)
+		assert.Equal(t, 5, diffs[0].Channel)
+	})
+
+	t.Run("MissingChannel", func(t *testing.T) {
+		diffs := DiffFrames([]int{1}, []int{1, 0}, 5)
+		require.Len(t, diffs, 1)
+		assert.Equal(t, -1, diffs[0].Actual)
+	})
+}
+
+// errorRecorder is a testing.TB that keeps what Errorf reports.
+type errorRecorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *errorRecorder) Helper() {}
+
+func (r *errorRecorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestExpectFrame(t *testing.T) {
+	mock := &testing.T{}
+	assert.True(t, ExpectFrame(mock, []int{10, 20}, []int{10, 20}, 0))
+	assert.False(t, mock.Failed())
+
+	r := &errorRecorder{}
+	assert.False(t, ExpectFrame(r, []int{10, 20}, []int{10, 30}, 0, Msg("after %s", "cancel")))
+	require.Len(t, r.errors, 1)
+	assert.True(t, strings.HasPrefix(r.errors[0], "after cancel: DMX frame mismatch"), r.errors[0])
+	assert.Contains(t, r.errors[0], "ch   2: expected  30, got  20")
+}
+
+func TestFromArtNet(t *testing.T) {
+	assert.Nil(t, FromArtNet(nil))
+
+	frame := &artnet.Frame{}
+	frame.Channels[0] = 255
+	frame.Channels[511] = 7
+	values := FromArtNet(frame)
+	require.Len(t, values, artnet.DMXChannels)
+	assert.Equal(t, 255, values[0])
+	assert.Equal(t, 7, values[511])
+}
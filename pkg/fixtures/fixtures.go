@@ -0,0 +1,196 @@
+// Package fixtures creates projects, fixtures, looks, cue lists and effects
+// on the server under test. It returns errors rather than failing a test, so
+// the same code seeds demo rigs from cmd/seed and builds state in test suites;
+// TestProject wraps it for suites, failing the test and cleaning up after it.
+package fixtures
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+)
+
+// ParChannels is the channel layout of the 4-channel LED par the suites use:
+// dimmer, red, green and blue at offsets 0-3.
+var ParChannels = []map[string]interface{}{
+	{"name": "Dimmer", "type": "INTENSITY", "offset": 0, "minValue": 0, "maxValue": 255, "defaultValue": 0},
+	{"name": "Red", "type": "RED", "offset": 1, "minValue": 0, "maxValue": 255, "defaultValue": 0},
+	{"name": "Green", "type": "GREEN", "offset": 2, "minValue": 0, "maxValue": 255, "defaultValue": 0},
+	{"name": "Blue", "type": "BLUE", "offset": 3, "minValue": 0, "maxValue": 255, "defaultValue": 0},
+}
+
+// Fixture is a fixture instance to patch.
+type Fixture struct {
+	Name         string
+	Universe     int
+	StartChannel int
+}
+
+// FixtureValues are the channel values a look sets on one fixture, by offset.
+type FixtureValues struct {
+	FixtureID string
+	Values    []int
+}
+
+// Cue is one cue of a cue list.
+type Cue struct {
+	Name     string
+	LookID   string
+	FadeTime float64
+}
+
+// createID runs a create mutation and returns the id it reports.
+func createID(ctx context.Context, client *graphql.Client, field, inputType string, input map[string]interface{}) (string, error) {
+	var resp map[string]struct {
+		ID string `json:"id"`
+	}
+	err := client.Mutate(ctx, fmt.Sprintf(`
+		mutation Create($input: %s!) {
+			%s(input: $input) { id }
+		}
+	`, inputType, field), map[string]interface{}{"input": input}, &resp)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", field, err)
+	}
+	if resp[field].ID == "" {
+		return "", fmt.Errorf("%s: no id returned", field)
+	}
+	return resp[field].ID, nil
+}
+
+// CreateProject creates an empty project.
+func CreateProject(ctx context.Context, client *graphql.Client, name string) (string, error) {
+	return createID(ctx, client, "createProject", "CreateProjectInput", map[string]interface{}{"name": name})
+}
+
+// DeleteProject deletes a project and everything in it.
+func DeleteProject(ctx context.Context, client *graphql.Client, id string) error {
+	return client.Mutate(ctx, `mutation DeleteProject($id: ID!) { deleteProject(id: $id) }`,
+		map[string]interface{}{"id": id}, nil)
+}
+
+// CreateParDefinition creates a fixture definition with the ParChannels layout.
+// Models must be unique per manufacturer.
+func CreateParDefinition(ctx context.Context, client *graphql.Client, manufacturer, model string) (string, error) {
+	return CreateDefinition(ctx, client, manufacturer, model, "LED_PAR", ParChannels)
+}
+
+// CreateDefinition creates a fixture definition of a type with the given channels.
+// Models must be unique per manufacturer.
+func CreateDefinition(ctx context.Context, client *graphql.Client, manufacturer, model, fixtureType string, channels []map[string]interface{}) (string, error) {
+	return createID(ctx, client, "createFixtureDefinition", "CreateFixtureDefinitionInput", map[string]interface{}{
+		"manufacturer": manufacturer,
+		"model":        model,
+		"type":         fixtureType,
+		"channels":     channels,
+	})
+}
+
+// DeleteFixtureDefinition deletes a fixture definition that no fixture uses.
+func DeleteFixtureDefinition(ctx context.Context, client *graphql.Client, id string) error {
+	return client.Mutate(ctx, `mutation DeleteFixtureDefinition($id: ID!) { deleteFixtureDefinition(id: $id) }`,
+		map[string]interface{}{"id": id}, nil)
+}
+
+// CreateFixture patches one fixture of a definition into a project.
+func CreateFixture(ctx context.Context, client *graphql.Client, projectID, definitionID string, f Fixture) (string, error) {
+	return createID(ctx, client, "createFixtureInstance", "CreateFixtureInstanceInput", map[string]interface{}{
+		"projectId":    projectID,
+		"definitionId": definitionID,
+		"name":         f.Name,
+		"universe":     f.Universe,
+		"startChannel": f.StartChannel,
+	})
+}
+
+// CreateLook creates a look setting each fixture's channels from offset 0.
+func CreateLook(ctx context.Context, client *graphql.Client, projectID, name string, values []FixtureValues) (string, error) {
+	fixtureValues := make([]map[string]interface{}, len(values))
+	for i, fv := range values {
+		channels := make([]map[string]interface{}, len(fv.Values))
+		for offset, value := range fv.Values {
+			channels[offset] = map[string]interface{}{"offset": offset, "value": value}
+		}
+		fixtureValues[i] = map[string]interface{}{"fixtureId": fv.FixtureID, "channels": channels}
+	}
+	return createID(ctx, client, "createLook", "CreateLookInput", map[string]interface{}{
+		"projectId":     projectID,
+		"name":          name,
+		"fixtureValues": fixtureValues,
+	})
+}
+
+// ActivateLook makes a look live.
+func ActivateLook(ctx context.Context, client *graphql.Client, lookID string) error {
+	return client.Mutate(ctx, `mutation SetLookLive($lookId: ID!) { setLookLive(lookId: $lookId) }`,
+		map[string]interface{}{"lookId": lookID}, nil)
+}
+
+// CreateCueList creates a cue list with cues numbered from 1 in order.
+func CreateCueList(ctx context.Context, client *graphql.Client, projectID, name string, cues []Cue) (string, error) {
+	cueListID, err := createID(ctx, client, "createCueList", "CreateCueListInput", map[string]interface{}{
+		"projectId": projectID,
+		"name":      name,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	for i, cue := range cues {
+		_, err := createID(ctx, client, "createCue", "CreateCueInput", map[string]interface{}{
+			"cueListId":   cueListID,
+			"lookId":      cue.LookID,
+			"name":        cue.Name,
+			"cueNumber":   float64(i + 1),
+			"fadeInTime":  cue.FadeTime,
+			"fadeOutTime": cue.FadeTime,
+		})
+		if err != nil {
+			return cueListID, fmt.Errorf("cue %d: %w", i+1, err)
+		}
+	}
+	return cueListID, nil
+}
+
+// CreateWaveformEffect creates a waveform effect on channel offset 0 of each
+// fixture, spreading phaseOffset evenly across them in order.
+func CreateWaveformEffect(ctx context.Context, client *graphql.Client, projectID, name, waveform string, frequency float64, fixtureIDs []string) (string, error) {
+	effectID, err := createID(ctx, client, "createEffect", "CreateEffectInput", map[string]interface{}{
+		"projectId":  projectID,
+		"name":       name,
+		"effectType": "WAVEFORM",
+		"waveform":   waveform,
+		"frequency":  frequency,
+		"amplitude":  100.0,
+		"offset":     50.0,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	for i, fixtureID := range fixtureIDs {
+		effectFixtureID, err := createID(ctx, client, "addFixtureToEffect", "AddFixtureToEffectInput", map[string]interface{}{
+			"effectId":    effectID,
+			"fixtureId":   fixtureID,
+			"phaseOffset": float64(i) * 360 / float64(len(fixtureIDs)),
+			"effectOrder": i + 1,
+		})
+		if err != nil {
+			return effectID, err
+		}
+
+		err = client.Mutate(ctx, `
+			mutation AddChannel($effectFixtureId: ID!, $input: EffectChannelInput!) {
+				addChannelToEffectFixture(effectFixtureId: $effectFixtureId, input: $input) { id }
+			}
+		`, map[string]interface{}{
+			"effectFixtureId": effectFixtureID,
+			"input":           map[string]interface{}{"channelOffset": 0},
+		}, nil)
+		if err != nil {
+			return effectID, fmt.Errorf("addChannelToEffectFixture: %w", err)
+		}
+	}
+	return effectID, nil
+}
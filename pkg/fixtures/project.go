@@ -0,0 +1,107 @@
+package fixtures
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+)
+
+// Settle is how long output is given to follow a change made over the API,
+// such as a look going live, before a test reads it back.
+const Settle = 300 * time.Millisecond
+
+// cleanupTimeout bounds the requests a TestProject makes when its test ends.
+const cleanupTimeout = 15 * time.Second
+
+// DimmerChannels is the channel layout of a single-channel dimmer.
+var DimmerChannels = []map[string]interface{}{
+	{"name": "Intensity", "type": "INTENSITY", "offset": 0, "minValue": 0, "maxValue": 255, "defaultValue": 0},
+}
+
+// TestProject is a project with a fixture definition of its own, created for
+// one test and deleted when it ends. Unlike the rest of the package, its
+// methods fail the test rather than return errors.
+type TestProject struct {
+	ID           string
+	DefinitionID string
+
+	client   *graphql.Client
+	cleanups []func(context.Context)
+}
+
+// NewParProject creates a TestProject whose definition is the ParChannels par.
+func NewParProject(t testing.TB, ctx context.Context, client *graphql.Client, name string) *TestProject {
+	t.Helper()
+	return NewTestProject(t, ctx, client, name, "LED_PAR", ParChannels)
+}
+
+// NewTestProject creates "<name> Test Project" and a definition of the type
+// and channels from manufacturer "Test <name>". When the test ends it runs
+// the OnCleanup hooks, blacks out output, and deletes both.
+func NewTestProject(t testing.TB, ctx context.Context, client *graphql.Client, name, fixtureType string, channels []map[string]interface{}) *TestProject {
+	t.Helper()
+	p := &TestProject{client: client}
+
+	var err error
+	p.DefinitionID, err = CreateDefinition(ctx, client, "Test "+name, fmt.Sprintf("%s %d", name, time.Now().UnixNano()), fixtureType, channels)
+	if err != nil {
+		t.Fatalf("Failed to create %s fixture definition: %v", name, err)
+	}
+	p.ID, err = CreateProject(ctx, client, name+" Test Project")
+	if err != nil {
+		_ = DeleteFixtureDefinition(ctx, client, p.DefinitionID)
+		t.Fatalf("Failed to create %s project: %v", name, err)
+	}
+
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+		defer cancel()
+		for _, cleanup := range p.cleanups {
+			cleanup(ctx)
+		}
+		_ = client.Mutate(ctx, `mutation { fadeToBlack(fadeOutTime: 0) }`, nil, nil)
+		_ = DeleteProject(ctx, client, p.ID)
+		_ = DeleteFixtureDefinition(ctx, client, p.DefinitionID)
+	})
+	return p
+}
+
+// OnCleanup registers fn to run when the test ends, before output is blacked
+// out and the project deleted, for state outside the project such as parked
+// channels. Hooks run in the order they were registered.
+func (p *TestProject) OnCleanup(fn func(ctx context.Context)) {
+	p.cleanups = append(p.cleanups, fn)
+}
+
+// AddFixture patches a fixture of the project's definition and returns its ID.
+func (p *TestProject) AddFixture(t testing.TB, ctx context.Context, f Fixture) string {
+	t.Helper()
+	id, err := CreateFixture(ctx, p.client, p.ID, p.DefinitionID, f)
+	if err != nil {
+		t.Fatalf("Failed to create fixture %s: %v", f.Name, err)
+	}
+	return id
+}
+
+// AddLook creates a look in the project and returns its ID.
+func (p *TestProject) AddLook(t testing.TB, ctx context.Context, name string, values []FixtureValues) string {
+	t.Helper()
+	id, err := CreateLook(ctx, p.client, p.ID, name, values)
+	if err != nil {
+		t.Fatalf("Failed to create look %s: %v", name, err)
+	}
+	return id
+}
+
+// SetLookLive makes a look live and waits Settle for output to follow,
+// failing the test if the server refuses.
+func SetLookLive(t testing.TB, ctx context.Context, client *graphql.Client, lookID string) {
+	t.Helper()
+	if err := ActivateLook(ctx, client, lookID); err != nil {
+		t.Fatalf("Failed to set look live: %v", err)
+	}
+	time.Sleep(Settle)
+}
@@ -0,0 +1,36 @@
+package fixtures
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTestProject(t *testing.T) {
+	f, client := newFakeServer(t)
+	ctx := context.Background()
+
+	var hooked bool
+	t.Run("Test", func(t *testing.T) {
+		project := NewParProject(t, ctx, client, "Probe")
+		project.OnCleanup(func(ctx context.Context) {
+			hooked = true
+			assert.Zero(t, f.calls["deleteProject"], "Hooks run before the project is deleted")
+		})
+		assert.Equal(t, "createProject-1", project.ID)
+		assert.Equal(t, "createFixtureDefinition-1", project.DefinitionID)
+
+		fixtureID := project.AddFixture(t, ctx, Fixture{Name: "Probe Par", Universe: 1, StartChannel: 1})
+		lookID := project.AddLook(t, ctx, "Probe Look", []FixtureValues{{FixtureID: fixtureID, Values: []int{255}}})
+		SetLookLive(t, ctx, client, lookID)
+	})
+
+	assert.True(t, hooked)
+	assert.Equal(t, "Test Probe", f.variables["createFixtureDefinition"][0]["input"].(map[string]interface{})["manufacturer"])
+	assert.Equal(t, "Probe Test Project", f.variables["createProject"][0]["input"].(map[string]interface{})["name"])
+	assert.Equal(t, "createLook-1", f.variables["setLookLive"][0]["lookId"])
+	for _, field := range []string{"fadeToBlack", "deleteProject", "deleteFixtureDefinition"} {
+		assert.Equal(t, 1, f.calls[field], "%s should run when the test ends", field)
+	}
+}
@@ -0,0 +1,247 @@
+package fixtures
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+)
+
+// SeedManufacturer marks the fixture definitions Seed creates, so Teardown can find them.
+const SeedManufacturer = "LacyLights Seed"
+
+// Size is how much a seeded rig contains, per project.
+type Size struct {
+	Projects    int
+	Fixtures    int
+	Looks       int
+	CueLists    int
+	CuesPerList int
+	Effects     int
+}
+
+// DefaultSize is a realistic demo rig for manual QA and benchmarking.
+var DefaultSize = Size{
+	Projects:    1,
+	Fixtures:    64,
+	Looks:       40,
+	CueLists:    3,
+	CuesPerList: 10,
+	Effects:     10,
+}
+
+// Validate reports a size that cannot be seeded.
+func (s Size) Validate() error {
+	switch {
+	case s.Projects < 1:
+		return fmt.Errorf("need at least 1 project, got %d", s.Projects)
+	case s.Fixtures < 1:
+		return fmt.Errorf("need at least 1 fixture, got %d", s.Fixtures)
+	case s.Looks < 0 || s.CueLists < 0 || s.CuesPerList < 0 || s.Effects < 0:
+		return fmt.Errorf("counts must not be negative")
+	case s.CueLists > 0 && s.CuesPerList > 0 && s.Looks == 0:
+		return fmt.Errorf("cues need at least 1 look")
+	}
+	return nil
+}
+
+// RigProject is one seeded project and the IDs created in it.
+type RigProject struct {
+	ID         string
+	FixtureIDs []string
+	LookIDs    []string
+	CueListIDs []string
+	EffectIDs  []string
+}
+
+// Rig is everything Seed created.
+type Rig struct {
+	DefinitionID string
+	Projects     []RigProject
+}
+
+// parsPerUniverse fit in a universe at 4 channels each.
+const parsPerUniverse = 512 / 4
+
+// waveforms are cycled through by the seeded effects.
+var waveforms = []string{"SINE", "SQUARE", "TRIANGLE", "SAWTOOTH", "COSINE"}
+
+// Patch returns the address of the i-th (0-based) par: packed from channel 1,
+// moving to the next universe when one is full.
+func Patch(i int) (universe, startChannel int) {
+	return i/parsPerUniverse + 1, (i%parsPerUniverse)*4 + 1
+}
+
+// LookValues returns the dimmer, red, green and blue of fixture f in look l.
+// The pattern is deterministic, so reseeding gives the same rig, and varied
+// enough that neighbouring looks and fixtures differ.
+func LookValues(l, f int) []int {
+	return []int{
+		255 - (l*37+f*11)%128,
+		(l*53 + f*17) % 256,
+		(l*97 + f*29) % 256,
+		(l*151 + f*41) % 256,
+	}
+}
+
+// Seed creates size.Projects projects named "<name>" or "<name> N", each with
+// the same rig, and one shared fixture definition. On error the returned Rig
+// holds what was created so far, for Teardown or inspection.
+func Seed(ctx context.Context, client *graphql.Client, name string, size Size) (*Rig, error) {
+	if err := size.Validate(); err != nil {
+		return nil, err
+	}
+
+	rig := &Rig{}
+	var err error
+	rig.DefinitionID, err = CreateParDefinition(ctx, client, SeedManufacturer, name+" Par")
+	if err != nil {
+		return rig, err
+	}
+
+	for p := 0; p < size.Projects; p++ {
+		projectName := name
+		if size.Projects > 1 {
+			projectName = fmt.Sprintf("%s %d", name, p+1)
+		}
+		project, err := seedProject(ctx, client, rig.DefinitionID, projectName, size)
+		if project != nil {
+			rig.Projects = append(rig.Projects, *project)
+		}
+		if err != nil {
+			return rig, fmt.Errorf("project %q: %w", projectName, err)
+		}
+	}
+	return rig, nil
+}
+
+func seedProject(ctx context.Context, client *graphql.Client, definitionID, name string, size Size) (*RigProject, error) {
+	id, err := CreateProject(ctx, client, name)
+	if err != nil {
+		return nil, err
+	}
+	project := &RigProject{ID: id}
+
+	for f := 0; f < size.Fixtures; f++ {
+		universe, start := Patch(f)
+		fixtureID, err := CreateFixture(ctx, client, id, definitionID, Fixture{
+			Name:         fmt.Sprintf("Par %d", f+1),
+			Universe:     universe,
+			StartChannel: start,
+		})
+		if err != nil {
+			return project, err
+		}
+		project.FixtureIDs = append(project.FixtureIDs, fixtureID)
+	}
+
+	for l := 0; l < size.Looks; l++ {
+		values := make([]FixtureValues, len(project.FixtureIDs))
+		for f, fixtureID := range project.FixtureIDs {
+			values[f] = FixtureValues{FixtureID: fixtureID, Values: LookValues(l, f)}
+		}
+		lookID, err := CreateLook(ctx, client, id, fmt.Sprintf("Look %d", l+1), values)
+		if err != nil {
+			return project, err
+		}
+		project.LookIDs = append(project.LookIDs, lookID)
+	}
+
+	for c := 0; c < size.CueLists; c++ {
+		cues := make([]Cue, size.CuesPerList)
+		for q := range cues {
+			cues[q] = Cue{
+				Name:     fmt.Sprintf("Cue %d", q+1),
+				LookID:   project.LookIDs[(c*size.CuesPerList+q)%len(project.LookIDs)],
+				FadeTime: float64(1 + q%3),
+			}
+		}
+		cueListID, err := CreateCueList(ctx, client, id, fmt.Sprintf("Act %d", c+1), cues)
+		if cueListID != "" {
+			project.CueListIDs = append(project.CueListIDs, cueListID)
+		}
+		if err != nil {
+			return project, err
+		}
+	}
+
+	// Each effect runs across a block of up to 8 consecutive fixtures
+	for e := 0; e < size.Effects; e++ {
+		first := (e * 8) % len(project.FixtureIDs)
+		last := first + 8
+		if last > len(project.FixtureIDs) {
+			last = len(project.FixtureIDs)
+		}
+		effectID, err := CreateWaveformEffect(ctx, client, id, fmt.Sprintf("Effect %d", e+1),
+			waveforms[e%len(waveforms)], 0.5+float64(e%4)*0.25, project.FixtureIDs[first:last])
+		if effectID != "" {
+			project.EffectIDs = append(project.EffectIDs, effectID)
+		}
+		if err != nil {
+			return project, err
+		}
+	}
+	return project, nil
+}
+
+// Teardown deletes every project named name or "name N", then the seed
+// fixture definitions for name. It returns how many projects it deleted.
+func Teardown(ctx context.Context, client *graphql.Client, name string) (int, error) {
+	var projectsResp struct {
+		Projects []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"projects"`
+	}
+	if err := client.Query(ctx, `query ListProjects { projects { id name } }`, nil, &projectsResp); err != nil {
+		return 0, fmt.Errorf("projects: %w", err)
+	}
+
+	deleted := 0
+	for _, p := range projectsResp.Projects {
+		if !seededName(p.Name, name) {
+			continue
+		}
+		if err := DeleteProject(ctx, client, p.ID); err != nil {
+			return deleted, fmt.Errorf("deleteProject %q: %w", p.Name, err)
+		}
+		deleted++
+	}
+
+	var defsResp struct {
+		FixtureDefinitions []struct {
+			ID           string `json:"id"`
+			Manufacturer string `json:"manufacturer"`
+			Model        string `json:"model"`
+		} `json:"fixtureDefinitions"`
+	}
+	if err := client.Query(ctx, `query ListDefinitions { fixtureDefinitions { id manufacturer model } }`, nil, &defsResp); err != nil {
+		return deleted, fmt.Errorf("fixtureDefinitions: %w", err)
+	}
+	for _, d := range defsResp.FixtureDefinitions {
+		if d.Manufacturer == SeedManufacturer && d.Model == name+" Par" {
+			if err := DeleteFixtureDefinition(ctx, client, d.ID); err != nil {
+				return deleted, fmt.Errorf("deleteFixtureDefinition %q: %w", d.Model, err)
+			}
+		}
+	}
+	return deleted, nil
+}
+
+// seededName reports whether project is name itself or "name N".
+func seededName(project, name string) bool {
+	if project == name {
+		return true
+	}
+	suffix, ok := strings.CutPrefix(project, name+" ")
+	if !ok || suffix == "" {
+		return false
+	}
+	for _, r := range suffix {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
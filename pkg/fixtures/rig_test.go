@@ -0,0 +1,189 @@
+package fixtures
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var rootField = regexp.MustCompile(`\{\s*(\w+)`)
+
+// fakeServer answers create mutations with sequential ids and records every
+// root field requested, with its variables.
+type fakeServer struct {
+	mu        sync.Mutex
+	calls     map[string]int
+	variables map[string][]map[string]interface{}
+	projects  []map[string]string
+	defs      []map[string]string
+	failOn    string
+}
+
+func newFakeServer(t *testing.T) (*fakeServer, *graphql.Client) {
+	f := &fakeServer{calls: make(map[string]int), variables: make(map[string][]map[string]interface{})}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query     string                 `json:"query"`
+			Variables map[string]interface{} `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		field := rootField.FindStringSubmatch(req.Query)[1]
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.calls[field]++
+		f.variables[field] = append(f.variables[field], req.Variables)
+
+		var data interface{}
+		switch {
+		case field == f.failOn:
+			_, _ = fmt.Fprintf(w, `{"errors":[{"message":"%s failed"}]}`, field)
+			return
+		case field == "projects":
+			data = f.projects
+		case field == "fixtureDefinitions":
+			data = f.defs
+		case field == "deleteProject" || field == "deleteFixtureDefinition":
+			data = true
+		default:
+			data = map[string]string{"id": fmt.Sprintf("%s-%d", field, f.calls[field])}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{field: data}})
+	}))
+	t.Cleanup(server.Close)
+	return f, graphql.NewClient(server.URL)
+}
+
+func TestSeed(t *testing.T) {
+	f, client := newFakeServer(t)
+	size := Size{Projects: 2, Fixtures: 5, Looks: 3, CueLists: 2, CuesPerList: 4, Effects: 2}
+
+	rig, err := Seed(context.Background(), client, "Demo", size)
+	require.NoError(t, err)
+
+	assert.Equal(t, "createFixtureDefinition-1", rig.DefinitionID)
+	require.Len(t, rig.Projects, 2)
+	for _, p := range rig.Projects {
+		assert.Len(t, p.FixtureIDs, 5)
+		assert.Len(t, p.LookIDs, 3)
+		assert.Len(t, p.CueListIDs, 2)
+		assert.Len(t, p.EffectIDs, 2)
+	}
+
+	assert.Equal(t, map[string]int{
+		"createFixtureDefinition":   1,
+		"createProject":             2,
+		"createFixtureInstance":     10,
+		"createLook":                6,
+		"createCueList":             4,
+		"createCue":                 16,
+		"createEffect":              4,
+		"addFixtureToEffect":        14, // fixtures 1-5, then 4-5, per project
+		"addChannelToEffectFixture": 14,
+	}, f.calls)
+
+	projectNames := []interface{}{
+		f.variables["createProject"][0]["input"].(map[string]interface{})["name"],
+		f.variables["createProject"][1]["input"].(map[string]interface{})["name"],
+	}
+	assert.Equal(t, []interface{}{"Demo 1", "Demo 2"}, projectNames)
+
+	t.Run("CuesCycleLooks", func(t *testing.T) {
+		var looks []interface{}
+		for _, v := range f.variables["createCue"][:8] {
+			looks = append(looks, v["input"].(map[string]interface{})["lookId"])
+		}
+		// Second cue list continues where the first left off
+		assert.Equal(t, []interface{}{
+			"createLook-1", "createLook-2", "createLook-3", "createLook-1",
+			"createLook-2", "createLook-3", "createLook-1", "createLook-2",
+		}, looks)
+	})
+
+	t.Run("PartialRigOnError", func(t *testing.T) {
+		f, client := newFakeServer(t)
+		f.failOn = "createCueList"
+
+		rig, err := Seed(context.Background(), client, "Demo", size)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `project "Demo 1"`)
+		require.Len(t, rig.Projects, 1, "The partly seeded project is still reported")
+		assert.Len(t, rig.Projects[0].LookIDs, 3)
+	})
+}
+
+func TestSizeValidate(t *testing.T) {
+	assert.NoError(t, DefaultSize.Validate())
+	assert.NoError(t, Size{Projects: 1, Fixtures: 1}.Validate())
+	assert.Error(t, Size{Projects: 0, Fixtures: 1}.Validate())
+	assert.Error(t, Size{Projects: 1, Fixtures: 0}.Validate())
+	assert.Error(t, Size{Projects: 1, Fixtures: 1, Effects: -1}.Validate())
+	assert.Error(t, Size{Projects: 1, Fixtures: 1, CueLists: 1, CuesPerList: 1}.Validate(), "Cues without looks")
+}
+
+func TestPatch(t *testing.T) {
+	for _, tc := range []struct{ index, universe, start int }{
+		{0, 1, 1},
+		{1, 1, 5},
+		{127, 1, 509},
+		{128, 2, 1},
+	} {
+		universe, start := Patch(tc.index)
+		assert.Equal(t, tc.universe, universe, "par %d universe", tc.index)
+		assert.Equal(t, tc.start, start, "par %d start channel", tc.index)
+	}
+}
+
+func TestLookValues(t *testing.T) {
+	for l := 0; l < 40; l++ {
+		for f := 0; f < 64; f++ {
+			values := LookValues(l, f)
+			require.Len(t, values, len(ParChannels))
+			assert.GreaterOrEqual(t, values[0], 128, "Dimmer should stay visibly on")
+			for _, v := range values {
+				require.True(t, v >= 0 && v <= 255, "look %d fixture %d: %v out of range", l, f, values)
+			}
+		}
+	}
+	assert.NotEqual(t, LookValues(0, 0), LookValues(1, 0))
+	assert.NotEqual(t, LookValues(0, 0), LookValues(0, 1))
+}
+
+func TestTeardown(t *testing.T) {
+	f, client := newFakeServer(t)
+	f.projects = []map[string]string{
+		{"id": "1", "name": "Demo"},
+		{"id": "2", "name": "Demo 2"},
+		{"id": "3", "name": "Demo Extra"},
+		{"id": "4", "name": "Other 1"},
+		{"id": "5", "name": "Demo "},
+	}
+	f.defs = []map[string]string{
+		{"id": "d1", "manufacturer": SeedManufacturer, "model": "Demo Par"},
+		{"id": "d2", "manufacturer": SeedManufacturer, "model": "Other Par"},
+		{"id": "d3", "manufacturer": "Generic", "model": "Demo Par"},
+	}
+
+	deleted, err := Teardown(context.Background(), client, "Demo")
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	var projectIDs, defIDs []interface{}
+	for _, v := range f.variables["deleteProject"] {
+		projectIDs = append(projectIDs, v["id"])
+	}
+	for _, v := range f.variables["deleteFixtureDefinition"] {
+		defIDs = append(defIDs, v["id"])
+	}
+	assert.Equal(t, []interface{}{"1", "2"}, projectIDs)
+	assert.Equal(t, []interface{}{"d1"}, defIDs)
+}
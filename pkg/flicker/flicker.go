@@ -0,0 +1,171 @@
+// Package flicker finds transient glitches in captured Art-Net frames: values
+// that jump for a single frame and come straight back. Sampling the output
+// every 100 ms almost never lands on such a frame, but on stage it is a
+// visible flash or blink.
+package flicker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+)
+
+// Glitch is one frame whose value departs from both of its neighbours in the
+// same direction: a spike above them or a dip below them.
+type Glitch struct {
+	Channel int // 1-indexed DMX channel
+	Frame   int // index into the universe's in-order frames
+	At      time.Time
+	Before  int
+	Value   int
+	After   int
+}
+
+func (g Glitch) String() string {
+	kind := "spike"
+	if g.Value < g.Before {
+		kind = "dip"
+	}
+	return fmt.Sprintf("ch %d frame %d: %s %d -> %d -> %d", g.Channel, g.Frame, kind, g.Before, g.Value, g.After)
+}
+
+// universeFrames returns the frames for universe (Art-Net numbering) with
+// duplicated and late frames dropped (see artnet.InOrder), so a packet
+// delivered out of order is not mistaken for a glitch.
+func universeFrames(frames []artnet.Frame, universe int) []artnet.Frame {
+	var out []artnet.Frame
+	for _, frame := range artnet.InOrder(frames) {
+		if frame.Universe == universe {
+			out = append(out, frame)
+		}
+	}
+	return out
+}
+
+// Find returns every single-frame spike or dip on the given channels: a frame
+// more than threshold above both neighbours, or more than threshold below both.
+// Fades in either direction, steps and smooth waveforms never qualify, since
+// each frame lies between or level with one of its neighbours.
+func Find(frames []artnet.Frame, universe int, channels []int, threshold int) []Glitch {
+	in := universeFrames(frames, universe)
+	var glitches []Glitch
+	for i := 1; i+1 < len(in); i++ {
+		for _, ch := range channels {
+			before := int(in[i-1].Channels[ch-1])
+			value := int(in[i].Channels[ch-1])
+			after := int(in[i+1].Channels[ch-1])
+			spike := value-before > threshold && value-after > threshold
+			dip := before-value > threshold && after-value > threshold
+			if spike || dip {
+				glitches = append(glitches, Glitch{Channel: ch, Frame: i, At: in[i].Timestamp, Before: before, Value: value, After: after})
+			}
+		}
+	}
+	return glitches
+}
+
+// Histogram counts how many frames held each value of one channel.
+type Histogram [256]int
+
+// ChannelHistogram returns the histogram of channel (1-indexed) over the
+// universe's in-order frames.
+func ChannelHistogram(frames []artnet.Frame, universe, channel int) Histogram {
+	var h Histogram
+	for _, frame := range universeFrames(frames, universe) {
+		h[frame.Channels[channel-1]]++
+	}
+	return h
+}
+
+// Frames returns the number of frames counted.
+func (h Histogram) Frames() int {
+	n := 0
+	for _, count := range h {
+		n += count
+	}
+	return n
+}
+
+// Outside returns the number of frames more than tolerance away from want.
+func (h Histogram) Outside(want, tolerance int) int {
+	n := 0
+	for value, count := range h {
+		if abs(value-want) > tolerance {
+			n += count
+		}
+	}
+	return n
+}
+
+// String lists the values seen with their frame counts, most frequent first,
+// such as "255x38 0x1".
+func (h Histogram) String() string {
+	var values []int
+	for value, count := range h {
+		if count > 0 {
+			values = append(values, value)
+		}
+	}
+	sort.SliceStable(values, func(i, j int) bool { return h[values[i]] > h[values[j]] })
+
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = fmt.Sprintf("%dx%d", value, h[value])
+	}
+	return strings.Join(parts, " ")
+}
+
+// ExpectNoFlicker fails the test, listing the glitches, if any of the channels
+// spikes or dips by more than threshold for a single frame. It returns true
+// when the capture is clean.
+func ExpectNoFlicker(t testing.TB, frames []artnet.Frame, universe int, channels []int, threshold int) bool {
+	t.Helper()
+
+	glitches := Find(frames, universe, channels, threshold)
+	if len(glitches) == 0 {
+		return true
+	}
+	lines := make([]string, len(glitches))
+	for i, g := range glitches {
+		lines[i] = g.String()
+	}
+	t.Errorf("Flicker: %d single-frame glitches (threshold %d):\n  %s%s",
+		len(glitches), threshold, strings.Join(lines, "\n  "), diagnosticNote(frames, universe))
+	return false
+}
+
+// ExpectSteady fails the test with the channel's histogram unless every frame
+// is within tolerance of want. It returns true when the channel held steady.
+func ExpectSteady(t testing.TB, frames []artnet.Frame, universe, channel, want, tolerance int) bool {
+	t.Helper()
+
+	h := ChannelHistogram(frames, universe, channel)
+	off := h.Outside(want, tolerance)
+	if off == 0 {
+		return true
+	}
+	t.Errorf("Channel %d should hold %d±%d but %d of %d frames did not: %s%s",
+		channel, want, tolerance, off, h.Frames(), h, diagnosticNote(frames, universe))
+	return false
+}
+
+// diagnosticNote describes a degraded capture, which explains some gaps but
+// never a glitch, since duplicated and late frames are dropped before analysis.
+func diagnosticNote(frames []artnet.Frame, universe int) string {
+	diagnostics := artnet.Stats(frames, universe).Diagnostics()
+	if len(diagnostics) == 0 {
+		return ""
+	}
+	return "\ncapture was degraded:\n  " + strings.Join(diagnostics, "\n  ")
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,73 @@
+package flicker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// channelFrames renders one 25 ms frame per value, with channel 1 set to it
+// and channel 2 held at 100.
+func channelFrames(values ...int) []artnet.Frame {
+	frames := make([]artnet.Frame, len(values))
+	for i, v := range values {
+		frames[i] = artnet.Frame{Timestamp: start.Add(time.Duration(i) * 25 * time.Millisecond), Sequence: byte(i + 1)}
+		frames[i].Channels[0] = byte(v)
+		frames[i].Channels[1] = 100
+	}
+	return frames
+}
+
+func TestFind(t *testing.T) {
+	t.Run("FadesAndStepsAreClean", func(t *testing.T) {
+		assert.Empty(t, Find(channelFrames(0, 40, 80, 120, 160, 200, 255, 255), 0, []int{1, 2}, 5))
+		assert.Empty(t, Find(channelFrames(255, 255, 0, 0, 255, 255), 0, []int{1}, 5), "A step that holds is not a glitch")
+		assert.Empty(t, Find(channelFrames(120, 127, 128, 127, 120), 0, []int{1}, 5), "A smooth peak is within threshold")
+	})
+
+	t.Run("SpikeAndDip", func(t *testing.T) {
+		glitches := Find(channelFrames(255, 255, 0, 255, 255, 100, 101, 255, 100, 100), 0, []int{1, 2}, 5)
+		require.Len(t, glitches, 2)
+		assert.Equal(t, Glitch{Channel: 1, Frame: 2, At: start.Add(50 * time.Millisecond), Before: 255, Value: 0, After: 255}, glitches[0])
+		assert.Equal(t, "ch 1 frame 2: dip 255 -> 0 -> 255", glitches[0].String())
+		assert.Equal(t, "ch 1 frame 7: spike 101 -> 255 -> 100", glitches[1].String())
+	})
+
+	t.Run("OtherUniversesAndLateFramesIgnored", func(t *testing.T) {
+		frames := channelFrames(200, 200, 200)
+		other := artnet.Frame{Universe: 1, Timestamp: start.Add(30 * time.Millisecond)}
+		late := frames[0]
+		late.Channels[0] = 0
+		frames = append(frames[:2], other, late, frames[2])
+		assert.Empty(t, Find(frames, 0, []int{1}, 5))
+	})
+}
+
+func TestChannelHistogram(t *testing.T) {
+	h := ChannelHistogram(channelFrames(255, 255, 0, 255, 250), 0, 1)
+	assert.Equal(t, 5, h.Frames())
+	assert.Equal(t, 3, h[255])
+	assert.Equal(t, 1, h.Outside(255, 5))
+	assert.Equal(t, "255x3 0x1 250x1", h.String())
+}
+
+func TestExpectSteady(t *testing.T) {
+	assert.True(t, ExpectSteady(t, channelFrames(255, 254, 255), 0, 1, 255, 1))
+
+	mock := &testing.T{}
+	assert.False(t, ExpectSteady(mock, channelFrames(255, 0, 255), 0, 1, 255, 1))
+	assert.True(t, mock.Failed())
+}
+
+func TestExpectNoFlicker(t *testing.T) {
+	assert.True(t, ExpectNoFlicker(t, channelFrames(0, 100, 200, 255), 0, []int{1}, 5))
+
+	mock := &testing.T{}
+	assert.False(t, ExpectNoFlicker(mock, channelFrames(0, 255, 0), 0, []int{1}, 5))
+	assert.True(t, mock.Failed())
+}
@@ -0,0 +1,119 @@
+package graphql
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync/atomic"
+)
+
+// Automatic persisted queries (APQ) send a query's SHA-256 hash instead of
+// its document. A server that has seen the hash runs the query registered
+// under it; one that has not answers PersistedQueryNotFound and the client
+// resends the document with the hash to register it. A server without APQ
+// answers PersistedQueryNotSupported, or ignores the extension and rejects
+// the request for having no document, and the client goes back to sending
+// documents. Any other failure is returned as is: the query may have run, so
+// resending it could run a mutation twice.
+const (
+	PersistedQueryNotFound     = "PERSISTED_QUERY_NOT_FOUND"
+	PersistedQueryNotSupported = "PERSISTED_QUERY_NOT_SUPPORTED"
+)
+
+// PersistedQueryHash returns the hex SHA-256 hash APQ identifies a query by.
+func PersistedQueryHash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+// PersistedQueryExtensions returns the request extensions that send a query by hash.
+func PersistedQueryExtensions(hash string) map[string]interface{} {
+	return map[string]interface{}{
+		"persistedQuery": map[string]interface{}{"version": 1, "sha256Hash": hash},
+	}
+}
+
+// PersistedQueryError returns PersistedQueryNotFound or
+// PersistedQueryNotSupported when err, or the errors in resp, carry one,
+// matching the extensions.code or the PersistedQueryNotFound style message
+// servers send. It returns "" otherwise.
+func PersistedQueryError(resp *Response, err error) string {
+	errs := ResponseErrors(err)
+	if resp != nil {
+		errs = append(errs, resp.Errors...)
+	}
+	for _, e := range errs {
+		switch {
+		case e.Code() == PersistedQueryNotFound || strings.EqualFold(e.Message, "PersistedQueryNotFound"):
+			return PersistedQueryNotFound
+		case e.Code() == PersistedQueryNotSupported || strings.EqualFold(e.Message, "PersistedQueryNotSupported"):
+			return PersistedQueryNotSupported
+		}
+	}
+	return ""
+}
+
+// missingDocumentMessages are how servers that ignore the persisted query
+// extension reject a request without a document.
+var missingDocumentMessages = []string{
+	"must provide query",
+	"no operation provided",
+	"must contain a non-empty `query`",
+}
+
+// missingDocument reports whether a hash-only request was rejected for having
+// no document, before anything ran: there are errors saying so and no data.
+func missingDocument(resp *Response, err error) bool {
+	if resp != nil && len(resp.Data) > 0 && string(resp.Data) != "null" {
+		return false
+	}
+	errs := ResponseErrors(err)
+	if resp != nil {
+		errs = append(errs, resp.Errors...)
+	}
+	for _, e := range errs {
+		message := strings.ToLower(e.Message)
+		for _, m := range missingDocumentMessages {
+			if strings.Contains(message, m) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PersistedQueryStats counts how a client's APQ requests went.
+type PersistedQueryStats struct {
+	// Hits were answered from the hash alone.
+	Hits int64
+
+	// Registered had to resend the document after PersistedQueryNotFound.
+	Registered int64
+
+	// Unsupported is set once the client has gone back to sending documents.
+	Unsupported bool
+}
+
+// persistedQueries is a client's APQ state.
+type persistedQueries struct {
+	hits       atomic.Int64
+	registered atomic.Int64
+
+	// confirmed is set once the server has answered a hash, after which a
+	// request rejected for having no document is not taken for missing APQ.
+	confirmed   atomic.Bool
+	unsupported atomic.Bool
+}
+
+// PersistedQueryStats returns the client's APQ counts, or zero stats when
+// persisted queries are off.
+func (c *Client) PersistedQueryStats() PersistedQueryStats {
+	if c.apq == nil {
+		return PersistedQueryStats{}
+	}
+	return PersistedQueryStats{
+		Hits:        c.apq.hits.Load(),
+		Registered:  c.apq.registered.Load(),
+		Unsupported: c.apq.unsupported.Load(),
+	}
+}
@@ -0,0 +1,199 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// apqServer answers every query with {"ok": true}. In mode "apq" it keeps a
+// registry of persisted queries, in "unsupported" it rejects hashes with
+// PersistedQueryNotSupported, and in "ignore" it fails requests without a
+// document the way a server that knows nothing of APQ would; "partial" is
+// "ignore" answering with data as well. The first unavailable requests get a
+// 503. It records whether each request carried a document.
+type apqServer struct {
+	mode        string
+	unavailable int
+
+	mu        sync.Mutex
+	registry  map[string]string
+	documents []bool
+}
+
+func newAPQServer(t *testing.T, mode string) (*apqServer, *httptest.Server) {
+	s := &apqServer{mode: mode, registry: map[string]string{}}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	return s, server
+}
+
+func (s *apqServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Query      string `json:"query"`
+		Extensions struct {
+			PersistedQuery *struct {
+				SHA256Hash string `json:"sha256Hash"`
+			} `json:"persistedQuery"`
+		} `json:"extensions"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&req)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.documents = append(s.documents, req.Query != "")
+
+	fail := func(status int, message, code string) {
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"errors": []map[string]interface{}{{"message": message, "extensions": map[string]interface{}{"code": code}}},
+		})
+	}
+	if s.unavailable > 0 {
+		s.unavailable--
+		fail(http.StatusServiceUnavailable, "try again later", "UNAVAILABLE")
+		return
+	}
+	if s.mode == "partial" && req.Query == "" {
+		_, _ = w.Write([]byte(`{"data":{"ok":true},"errors":[{"message":"Must provide query string."}]}`))
+		return
+	}
+	if pq := req.Extensions.PersistedQuery; pq != nil && s.mode != "ignore" && s.mode != "partial" {
+		switch {
+		case s.mode == "unsupported":
+			fail(http.StatusOK, "PersistedQueryNotSupported", PersistedQueryNotSupported)
+			return
+		case req.Query == "" && s.registry[pq.SHA256Hash] == "":
+			fail(http.StatusOK, "PersistedQueryNotFound", PersistedQueryNotFound)
+			return
+		case req.Query != "" && PersistedQueryHash(req.Query) != pq.SHA256Hash:
+			fail(http.StatusBadRequest, "provided sha does not match query", "BAD_USER_INPUT")
+			return
+		case req.Query != "":
+			s.registry[pq.SHA256Hash] = req.Query
+		}
+	} else if req.Query == "" {
+		fail(http.StatusUnprocessableEntity, "no operation provided", "GRAPHQL_VALIDATION_FAILED")
+		return
+	}
+	_, _ = w.Write([]byte(`{"data":{"ok":true}}`))
+}
+
+// sentDocuments returns, per request so far, whether it carried a document.
+func (s *apqServer) sentDocuments() []bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]bool(nil), s.documents...)
+}
+
+func TestPersistedQueryHash(t *testing.T) {
+	assert.Equal(t, "8995e953e895e960e470a1ee90e4b29520981980dcbc5e51ce0d7a2169b7049e", PersistedQueryHash(`query { __typename }`))
+}
+
+func TestPersistedQueryError(t *testing.T) {
+	assert.Equal(t, PersistedQueryNotFound, PersistedQueryError(&Response{Errors: []GraphQLError{{Message: "PersistedQueryNotFound"}}}, nil))
+	assert.Equal(t, PersistedQueryNotSupported, PersistedQueryError(nil,
+		Errors{{Message: "not supported", Extensions: map[string]interface{}{"code": PersistedQueryNotSupported}}}))
+	assert.Equal(t, PersistedQueryNotFound, PersistedQueryError(nil,
+		newStatusError(http.StatusBadRequest, []byte(`{"errors":[{"message":"PersistedQueryNotFound"}]}`))))
+	assert.Empty(t, PersistedQueryError(&Response{Errors: []GraphQLError{{Message: "field not found"}}}, nil))
+	assert.Empty(t, PersistedQueryError(&Response{}, nil))
+}
+
+func TestClientPersistedQueries(t *testing.T) {
+	ctx := context.Background()
+	query := func(t *testing.T, client *Client) {
+		var resp struct {
+			OK bool `json:"ok"`
+		}
+		require.NoError(t, client.Query(ctx, `query { ok }`, nil, &resp))
+		assert.True(t, resp.OK)
+	}
+
+	t.Run("RegistersThenHashes", func(t *testing.T) {
+		server, httpServer := newAPQServer(t, "apq")
+		client := NewClientWithOptions(httpServer.URL, ClientOptions{PersistedQueries: true})
+		for i := 0; i < 3; i++ {
+			query(t, client)
+		}
+		assert.Equal(t, []bool{false, true, false, false}, server.sentDocuments(),
+			"Only the registering request should carry the document")
+		assert.Equal(t, PersistedQueryStats{Hits: 2, Registered: 1}, client.PersistedQueryStats())
+
+		// A second client finds the query already registered
+		other := NewClientWithOptions(httpServer.URL, ClientOptions{PersistedQueries: true})
+		query(t, other)
+		assert.Equal(t, PersistedQueryStats{Hits: 1}, other.PersistedQueryStats())
+	})
+
+	t.Run("NotSupported", func(t *testing.T) {
+		server, httpServer := newAPQServer(t, "unsupported")
+		client := NewClientWithOptions(httpServer.URL, ClientOptions{PersistedQueries: true})
+		query(t, client)
+		query(t, client)
+		assert.Equal(t, []bool{false, true, true}, server.sentDocuments(), "The client should stop hashing after PersistedQueryNotSupported")
+		assert.True(t, client.PersistedQueryStats().Unsupported)
+	})
+
+	t.Run("IgnoredByServer", func(t *testing.T) {
+		server, httpServer := newAPQServer(t, "ignore")
+		client := NewClientWithOptions(httpServer.URL, ClientOptions{PersistedQueries: true})
+		query(t, client)
+		query(t, client)
+		assert.Equal(t, []bool{false, true, true}, server.sentDocuments(), "The client should fall back when the server needs a document")
+		assert.True(t, client.PersistedQueryStats().Unsupported)
+	})
+
+	t.Run("TransientFailure", func(t *testing.T) {
+		server, httpServer := newAPQServer(t, "apq")
+		server.unavailable = 1
+		client := NewClientWithOptions(httpServer.URL, ClientOptions{PersistedQueries: true})
+		err := client.Query(ctx, `query { ok }`, nil, nil)
+		assert.Equal(t, http.StatusServiceUnavailable, HTTPStatus(err))
+		query(t, client)
+		assert.Equal(t, []bool{false, false, true}, server.sentDocuments(),
+			"A failed request should not be resent, nor turn persisted queries off")
+		assert.False(t, client.PersistedQueryStats().Unsupported)
+	})
+
+	t.Run("NoResendAfterData", func(t *testing.T) {
+		server, httpServer := newAPQServer(t, "partial")
+		client := NewClientWithOptions(httpServer.URL, ClientOptions{PersistedQueries: true})
+		resp, err := client.Execute(ctx, `mutation { ok }`, nil)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"ok":true}`, string(resp.Data))
+		assert.Equal(t, []bool{false}, server.sentDocuments(), "A request that returned data has run and should not be resent")
+	})
+
+	t.Run("QueryErrorsAfterConfirmed", func(t *testing.T) {
+		_, httpServer := newAPQServer(t, "apq")
+		client := NewClientWithOptions(httpServer.URL, ClientOptions{PersistedQueries: true})
+		query(t, client)
+		// Once the server has answered a hash, a hit carrying errors is the query's own failure
+		_, err := client.Send(ctx, Request{Query: `query { ok }`, Extensions: PersistedQueryExtensions("bad")})
+		assert.Equal(t, http.StatusBadRequest, HTTPStatus(err), "The server should reject a mismatched hash")
+		query(t, client)
+		assert.False(t, client.PersistedQueryStats().Unsupported)
+	})
+
+	t.Run("Off", func(t *testing.T) {
+		server, httpServer := newAPQServer(t, "apq")
+		client := NewClient(httpServer.URL)
+		query(t, client)
+		assert.Equal(t, []bool{true}, server.sentDocuments())
+		assert.Equal(t, PersistedQueryStats{}, client.PersistedQueryStats())
+	})
+
+	t.Run("Env", func(t *testing.T) {
+		t.Setenv("GRAPHQL_APQ", "1")
+		server, httpServer := newAPQServer(t, "apq")
+		query(t, NewClient(httpServer.URL))
+		assert.Equal(t, []bool{false, true}, server.sentDocuments(), "GRAPHQL_APQ=1 should turn persisted queries on")
+	})
+}
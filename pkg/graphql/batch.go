@@ -0,0 +1,355 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// BatchOperation is one operation sent by QueryBatch.
+type BatchOperation struct {
+	// Query is a document with a single query or mutation and no fragments.
+	Query     string
+	Variables map[string]interface{}
+
+	// Result receives the operation's data, as with Query. It may be nil.
+	Result interface{}
+
+	// Err is set by QueryBatch when this operation failed.
+	Err error
+}
+
+// QueryBatch sends several operations in one HTTP request and fills in each
+// operation's Result and Err. The operations are merged into a single
+// document, their root fields aliased and variables renamed apart, so it works
+// with servers that don't accept batched requests. Mutations in one document
+// run in order, so later operations see the effects of earlier ones, but they
+// cannot use another operation's result.
+//
+// The returned error joins every operation's error, or reports that the batch
+// could not be built or sent.
+func (c *Client) QueryBatch(ctx context.Context, ops []BatchOperation) error {
+	if len(ops) == 0 {
+		return nil
+	}
+
+	merged, keys, err := mergeOperations(ops)
+	if err != nil {
+		return err
+	}
+	variables := make(map[string]interface{})
+	for i, op := range ops {
+		for name, value := range op.Variables {
+			variables[batchPrefix(i)+name] = value
+		}
+	}
+
+	resp, err := c.Execute(ctx, merged, variables)
+	if err != nil {
+		for i := range ops {
+			ops[i].Err = err
+		}
+		return err
+	}
+
+	var data map[string]json.RawMessage
+	if len(resp.Data) > 0 {
+		if err := json.Unmarshal(resp.Data, &data); err != nil {
+			return fmt.Errorf("failed to unmarshal response: %w", err)
+		}
+	}
+
+	// Errors are routed by the alias at the start of their path; any other
+	// error, such as a validation failure, belongs to every operation.
+	opErrors := make([]Errors, len(ops))
+	for _, e := range resp.Errors {
+		i, key, ok := splitBatchKey(e.Path, keys)
+		if !ok {
+			for j := range opErrors {
+				opErrors[j] = append(opErrors[j], e)
+			}
+			continue
+		}
+		e.Path = append([]interface{}{key}, e.Path[1:]...)
+		opErrors[i] = append(opErrors[i], e)
+	}
+
+	var errs []error
+	for i := range ops {
+		ops[i].Err = nil
+		switch {
+		case len(opErrors[i]) > 0:
+			ops[i].Err = opErrors[i]
+		case data == nil:
+			ops[i].Err = fmt.Errorf("no data: another operation in the batch failed")
+		case ops[i].Result != nil:
+			ops[i].Err = unmarshalBatchResult(data, i, keys[i], ops[i].Result)
+		}
+		if ops[i].Err != nil {
+			errs = append(errs, fmt.Errorf("operation %d: %w", i, ops[i].Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func batchPrefix(i int) string {
+	return fmt.Sprintf("b%d_", i)
+}
+
+// splitBatchKey finds the operation an error path belongs to and the field's
+// original response key.
+func splitBatchKey(path []interface{}, keys [][]string) (int, string, bool) {
+	if len(path) == 0 {
+		return 0, "", false
+	}
+	alias, _ := path[0].(string)
+	for i, opKeys := range keys {
+		for _, key := range opKeys {
+			if alias == batchPrefix(i)+key {
+				return i, key, true
+			}
+		}
+	}
+	return 0, "", false
+}
+
+func unmarshalBatchResult(data map[string]json.RawMessage, i int, keys []string, result interface{}) error {
+	fields := make(map[string]json.RawMessage, len(keys))
+	for _, key := range keys {
+		if raw, ok := data[batchPrefix(i)+key]; ok {
+			fields[key] = raw
+		}
+	}
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, result); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// mergeOperations builds one document from the operations and returns it with
+// the response keys of each operation's root fields.
+func mergeOperations(ops []BatchOperation) (string, [][]string, error) {
+	var kind string
+	var definitions, selections []string
+	keys := make([][]string, len(ops))
+
+	for i, op := range ops {
+		opKind, defs, body, err := splitOperation(renameVariables(op.Query, batchPrefix(i)))
+		if err != nil {
+			return "", nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+		if kind == "" {
+			kind = opKind
+		} else if opKind != kind {
+			return "", nil, fmt.Errorf("operation %d: cannot batch a %s with a %s", i, opKind, kind)
+		}
+
+		aliased, opKeys, err := aliasRootFields(body, batchPrefix(i))
+		if err != nil {
+			return "", nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+		if defs != "" {
+			definitions = append(definitions, defs)
+		}
+		selections = append(selections, aliased)
+		keys[i] = opKeys
+	}
+
+	var b strings.Builder
+	b.WriteString(kind + " Batch")
+	if len(definitions) > 0 {
+		b.WriteString("(" + strings.Join(definitions, ", ") + ")")
+	}
+	b.WriteString(" {\n" + strings.Join(selections, "\n") + "\n}")
+	return b.String(), keys, nil
+}
+
+// skipIgnored returns the position after a string literal or comment starting
+// at i, or i when there is none there.
+func skipIgnored(doc string, i int) int {
+	switch {
+	case strings.HasPrefix(doc[i:], `"""`):
+		if end := strings.Index(doc[i+3:], `"""`); end >= 0 {
+			return i + 3 + end + 3
+		}
+		return len(doc)
+	case doc[i] == '"':
+		for j := i + 1; j < len(doc); j++ {
+			switch doc[j] {
+			case '\\':
+				j++
+			case '"':
+				return j + 1
+			}
+		}
+		return len(doc)
+	case doc[i] == '#':
+		if end := strings.IndexByte(doc[i:], '\n'); end >= 0 {
+			return i + end
+		}
+		return len(doc)
+	}
+	return i
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// renameVariables prefixes every $variable outside strings and comments.
+func renameVariables(doc, prefix string) string {
+	var b strings.Builder
+	for i := 0; i < len(doc); {
+		if next := skipIgnored(doc, i); next != i {
+			b.WriteString(doc[i:next])
+			i = next
+			continue
+		}
+		b.WriteByte(doc[i])
+		if doc[i] == '$' {
+			b.WriteString(prefix)
+		}
+		i++
+	}
+	return b.String()
+}
+
+// splitOperation returns an operation's kind, the variable definitions inside
+// its parentheses, and the body of its selection set.
+func splitOperation(doc string) (kind, definitions, body string, err error) {
+	open, close := -1, -1
+	parens, braces := 0, 0
+	for i := 0; i < len(doc) && close < 0; {
+		if next := skipIgnored(doc, i); next != i {
+			i = next
+			continue
+		}
+		switch doc[i] {
+		case '(':
+			parens++
+		case ')':
+			parens--
+		case '{':
+			if parens == 0 {
+				if braces == 0 {
+					open = i
+				}
+				braces++
+			}
+		case '}':
+			if parens == 0 {
+				braces--
+				if braces == 0 {
+					close = i
+				}
+			}
+		}
+		i++
+	}
+	if open < 0 || close < 0 {
+		return "", "", "", fmt.Errorf("no selection set")
+	}
+	if rest := strings.TrimSpace(stripComments(doc[close+1:])); rest != "" {
+		return "", "", "", fmt.Errorf("batched documents must hold a single operation without fragments")
+	}
+
+	header := strings.TrimSpace(stripComments(doc[:open]))
+	kind = "query"
+	for _, k := range []string{"query", "mutation", "subscription"} {
+		if header == k || strings.HasPrefix(header, k) && !isNameChar(header[len(k)]) {
+			kind = k
+		}
+	}
+	if kind == "subscription" {
+		return "", "", "", fmt.Errorf("subscriptions cannot be batched")
+	}
+	if header != "" && !strings.HasPrefix(header, kind) {
+		return "", "", "", fmt.Errorf("batched documents must hold a single operation without fragments")
+	}
+	if start := strings.IndexByte(header, '('); start >= 0 {
+		end := strings.LastIndexByte(header, ')')
+		if end < start {
+			return "", "", "", fmt.Errorf("unclosed variable definitions")
+		}
+		definitions = strings.TrimSpace(header[start+1 : end])
+	}
+	return kind, definitions, doc[open+1 : close], nil
+}
+
+func stripComments(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		if next := skipIgnored(s, i); next != i {
+			if s[i] != '#' {
+				b.WriteString(s[i:next])
+			}
+			i = next
+			continue
+		}
+		b.WriteByte(s[i])
+		i++
+	}
+	return b.String()
+}
+
+// aliasRootFields gives every field at the top of a selection set body an
+// alias starting with prefix, and returns the body with each field's
+// original response key.
+func aliasRootFields(body, prefix string) (string, []string, error) {
+	var b strings.Builder
+	var keys []string
+	depth := 0 // nesting of (), [] and {} inside the body
+	afterColon, afterAt := false, false
+
+	for i := 0; i < len(body); {
+		if next := skipIgnored(body, i); next != i {
+			b.WriteString(body[i:next])
+			i = next
+			continue
+		}
+		c := body[i]
+		switch {
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		case depth == 0 && strings.HasPrefix(body[i:], "..."):
+			return "", nil, fmt.Errorf("fragment spreads cannot be batched at the top level")
+		case depth == 0 && isNameChar(c) && !(c >= '0' && c <= '9'):
+			end := i
+			for end < len(body) && isNameChar(body[end]) {
+				end++
+			}
+			name := body[i:end]
+			if !afterColon && !afterAt {
+				rest := strings.TrimLeft(body[end:], " \t\r\n,")
+				if strings.HasPrefix(rest, ":") {
+					b.WriteString(prefix + name)
+				} else {
+					b.WriteString(prefix + name + ": " + name)
+				}
+				keys = append(keys, name)
+			} else {
+				b.WriteString(name)
+			}
+			afterColon, afterAt = false, false
+			i = end
+			continue
+		}
+		if depth == 0 && c != ' ' && c != '\t' && c != '\r' && c != '\n' && c != ',' {
+			afterColon, afterAt = c == ':', c == '@'
+		}
+		b.WriteByte(c)
+		i++
+	}
+	if len(keys) == 0 {
+		return "", nil, fmt.Errorf("empty selection set")
+	}
+	return b.String(), keys, nil
+}
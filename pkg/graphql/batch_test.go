@@ -0,0 +1,111 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeOperations(t *testing.T) {
+	merged, keys, err := mergeOperations([]BatchOperation{
+		{Query: `
+			# create the project first
+			mutation CreateProject($input: CreateProjectInput!) {
+				createProject(input: $input) { id }
+			}`},
+		{Query: `mutation($input: CreateLookBoardInput!, $fade: Float = 1.0) {
+			board: createLookBoard(input: $input) @include(if: true) { id name(format: "$notAVariable") }
+			__typename
+		}`},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"createProject"}, {"board", "__typename"}}, keys)
+	assert.Equal(t, `mutation Batch($b0_input: CreateProjectInput!, $b1_input: CreateLookBoardInput!, $b1_fade: Float = 1.0) {
+
+				b0_createProject: createProject(input: $b0_input) { id }
+			
+
+			b1_board: createLookBoard(input: $b1_input) @include(if: true) { id name(format: "$notAVariable") }
+			b1___typename: __typename
+		
+}`, merged)
+
+	merged, _, err = mergeOperations([]BatchOperation{{Query: `{ projects { id } }`}, {Query: `query { a: systemInfo { version } }`}})
+	require.NoError(t, err)
+	assert.Equal(t, "query Batch {\n b0_projects: projects { id } \n b1_a: systemInfo { version } \n}", merged)
+
+	for name, ops := range map[string][]BatchOperation{
+		"MixedKinds":   {{Query: `{ a }`}, {Query: `mutation { b }`}},
+		"Subscription": {{Query: `subscription { a }`}},
+		"Fragments":    {{Query: `{ ...F } fragment F on Query { a }`}},
+		"TwoDocuments": {{Query: `{ a } { b }`}},
+		"Unclosed":     {{Query: `{ a { b }`}},
+	} {
+		_, _, err := mergeOperations(ops)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestQueryBatch(t *testing.T) {
+	var requests []Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"b0_createProject": map[string]interface{}{"id": "p1"},
+				"b1_createProject": nil,
+				"b2_board":         map[string]interface{}{"id": "b1"},
+			},
+			"errors": []map[string]interface{}{
+				{"message": "name taken", "path": []interface{}{"b1_createProject", "name"}, "extensions": map[string]interface{}{"code": "CONFLICT"}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	var first, second struct {
+		CreateProject *struct{ ID string } `json:"createProject"`
+	}
+	var board struct {
+		Board struct{ ID string } `json:"board"`
+	}
+	create := `mutation($input: CreateProjectInput!) { createProject(input: $input) { id } }`
+	ops := []BatchOperation{
+		{Query: create, Variables: map[string]interface{}{"input": map[string]interface{}{"name": "A"}}, Result: &first},
+		{Query: create, Variables: map[string]interface{}{"input": map[string]interface{}{"name": "B"}}, Result: &second},
+		{Query: `mutation { board: createLookBoard(input: {name: "x"}) { id } }`, Result: &board},
+	}
+	err := NewClient(server.URL).QueryBatch(context.Background(), ops)
+
+	require.Len(t, requests, 1, "All operations go in one request")
+	assert.Equal(t, map[string]interface{}{"name": "A"}, requests[0].Variables["b0_input"])
+	assert.Equal(t, map[string]interface{}{"name": "B"}, requests[0].Variables["b1_input"])
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "operation 1")
+	assert.NoError(t, ops[0].Err)
+	assert.Equal(t, "p1", first.CreateProject.ID)
+	assert.Equal(t, []string{"CONFLICT"}, ErrorCodes(ops[1].Err))
+	assert.Equal(t, []interface{}{"createProject", "name"}, ResponseErrors(ops[1].Err)[0].Path, "Paths use the original field names")
+	assert.NoError(t, ops[2].Err)
+	assert.Equal(t, "b1", board.Board.ID)
+}
+
+func TestQueryBatchDocumentError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data": null, "errors": [{"message": "Cannot query field \"nope\""}]}`))
+	}))
+	defer server.Close()
+
+	ops := []BatchOperation{{Query: `{ a }`}, {Query: `{ nope }`}}
+	require.Error(t, NewClient(server.URL).QueryBatch(context.Background(), ops))
+	assert.Error(t, ops[0].Err, "Errors without a path belong to every operation")
+	assert.Error(t, ops[1].Err)
+}
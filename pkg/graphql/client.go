@@ -9,6 +9,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 )
 
@@ -18,31 +19,103 @@ import (
 type Client struct {
 	endpoint   string
 	httpClient *http.Client
+	recorder   *Recorder
+	headers    map[string]string
+	apq        *persistedQueries
+}
+
+// ClientOptions configures optional client behavior.
+type ClientOptions struct {
+	// Timeout is the HTTP timeout per request (default 30s).
+	Timeout time.Duration
+
+	// RecordTo is an NDJSON file that receives every exchange. Empty disables file recording.
+	RecordTo string
+
+	// KeepLast is the number of recent exchanges kept in memory for failure reports.
+	KeepLast int
+
+	// Transport overrides the HTTP transport, e.g. with a ReplayTransport.
+	// When nil and REPLAY_DIR is set, recorded exchanges in that directory are replayed.
+	Transport http.RoundTripper
+
+	// Headers are sent with every request, e.g. a client identity for attribution.
+	Headers map[string]string
+
+	// Connections tunes keep-alive and HTTP/2 when Transport is nil. Clients
+	// with the same settings share one connection pool.
+	Connections TransportOptions
+
+	// PersistedQueries sends queries as automatic persisted queries, by hash,
+	// falling back to the full document (as when GRAPHQL_APQ=1). It is off
+	// when replaying, since recordings are matched by query text.
+	PersistedQueries bool
 }
 
 // NewClient creates a new GraphQL client.
 func NewClient(endpoint string) *Client {
+	return NewClientWithOptions(endpoint, ClientOptions{})
+}
+
+// NewClientWithOptions creates a new GraphQL client with the given options.
+// If the record file cannot be opened the client still works, without file recording.
+func NewClientWithOptions(endpoint string, opts ClientOptions) *Client {
 	if endpoint == "" {
 		endpoint = os.Getenv("GRAPHQL_ENDPOINT")
 	}
 	if endpoint == "" {
 		endpoint = "http://localhost:4001/graphql"
 	}
+	if opts.Timeout == 0 {
+		opts.Timeout = 30 * time.Second
+	}
+	if opts.Transport == nil {
+		if dir := os.Getenv("REPLAY_DIR"); dir != "" {
+			opts.Transport = replayTransportOrError(dir)
+			opts.PersistedQueries = false
+		} else {
+			if on, err := strconv.ParseBool(os.Getenv("GRAPHQL_APQ")); err == nil {
+				opts.PersistedQueries = opts.PersistedQueries || on
+			}
+			opts.Transport = SharedTransport(opts.Connections)
+		}
+	}
 
-	return &Client{
+	c := &Client{
 		endpoint: endpoint,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   opts.Timeout,
+			Transport: opts.Transport,
 		},
+		headers: opts.Headers,
+	}
+	if opts.PersistedQueries {
+		c.apq = &persistedQueries{}
 	}
+
+	if opts.RecordTo != "" || opts.KeepLast > 0 {
+		recorder, err := NewRecorder(opts.RecordTo, opts.KeepLast)
+		if err != nil {
+			recorder, _ = NewRecorder("", opts.KeepLast)
+		}
+		c.recorder = recorder
+	}
+
+	runClientHooks(c)
+	return c
 }
 
+// Recorder returns the client's exchange recorder, or nil if recording is disabled.
+func (c *Client) Recorder() *Recorder {
+	return c.recorder
+}
 
 // Request represents a GraphQL request.
 type Request struct {
-	Query         string                 `json:"query"`
+	Query         string                 `json:"query,omitempty"`
 	Variables     map[string]interface{} `json:"variables,omitempty"`
 	OperationName string                 `json:"operationName,omitempty"`
+	Extensions    map[string]interface{} `json:"extensions,omitempty"`
 }
 
 // Response represents a GraphQL response.
@@ -66,7 +139,7 @@ func (c *Client) Query(ctx context.Context, query string, variables map[string]i
 	}
 
 	if len(resp.Errors) > 0 {
-		return fmt.Errorf("graphql errors: %v", resp.Errors)
+		return Errors(resp.Errors)
 	}
 
 	if result != nil {
@@ -85,44 +158,105 @@ func (c *Client) Mutate(ctx context.Context, mutation string, variables map[stri
 
 // Execute executes a GraphQL request and returns the raw response.
 func (c *Client) Execute(ctx context.Context, query string, variables map[string]interface{}) (*Response, error) {
+	RecordCoverage(query)
+
+	if c.recorder == nil {
+		resp, _, _, err := c.execute(ctx, query, variables)
+		return resp, err
+	}
+
+	start := time.Now()
+	resp, status, body, err := c.execute(ctx, query, variables)
+	c.recorder.Record(newExchange(start, query, variables, status, body, err))
+	return resp, err
+}
+
+// execute performs the HTTP round trip, also returning the status code and raw body for recording.
+// With persisted queries on, it sends the query's hash first and the document only when needed.
+func (c *Client) execute(ctx context.Context, query string, variables map[string]interface{}) (*Response, int, []byte, error) {
 	req := Request{
 		Query:     query,
 		Variables: variables,
 	}
+	if c.apq == nil || c.apq.unsupported.Load() {
+		return c.send(ctx, req)
+	}
 
+	hash := PersistedQueryHash(query)
+	resp, status, body, err := c.send(ctx, Request{Variables: variables, Extensions: PersistedQueryExtensions(hash)})
+	switch PersistedQueryError(resp, err) {
+	case PersistedQueryNotFound:
+		c.apq.confirmed.Store(true)
+		c.apq.registered.Add(1)
+		req.Extensions = PersistedQueryExtensions(hash)
+		return c.send(ctx, req)
+	case PersistedQueryNotSupported:
+		c.apq.unsupported.Store(true)
+		return c.send(ctx, req)
+	}
+	if !c.apq.confirmed.Load() && missingDocument(resp, err) {
+		// A server that ignores the extension rejects the request for having
+		// no document without running it, so the document can be sent instead
+		c.apq.unsupported.Store(true)
+		return c.send(ctx, req)
+	}
+	if resp != nil {
+		// Answered from the hash; any errors are the query's own
+		c.apq.confirmed.Store(true)
+		c.apq.hits.Add(1)
+	}
+	return resp, status, body, err
+}
+
+// Send posts a request exactly as given, without persisted query handling or
+// recording, for tests of the request format itself.
+func (c *Client) Send(ctx context.Context, req Request) (*Response, error) {
+	resp, _, _, err := c.send(ctx, req)
+	return resp, err
+}
+
+// send encodes a request and posts it.
+func (c *Client) send(ctx context.Context, req Request) (*Response, int, []byte, error) {
 	body, err := json.Marshal(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, 0, nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
+	return c.post(ctx, "application/json", body)
+}
 
+// post sends a request body to the endpoint and decodes the GraphQL response.
+func (c *Client) post(ctx context.Context, contentType string, body []byte) (*Response, int, []byte, error) {
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.endpoint, bytes.NewReader(body))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, 0, nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Content-Type", contentType)
+	for name, value := range c.headers {
+		httpReq.Header.Set(name, value)
+	}
 
 	httpResp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, 0, nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer func() { _ = httpResp.Body.Close() }()
 
 	respBody, err := io.ReadAll(httpResp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, httpResp.StatusCode, nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	if httpResp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", httpResp.StatusCode, string(respBody))
+		return nil, httpResp.StatusCode, respBody, newStatusError(httpResp.StatusCode, respBody)
 	}
 
 	var resp Response
 	if err := json.Unmarshal(respBody, &resp); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+		return nil, httpResp.StatusCode, respBody, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
-	return &resp, nil
+	return &resp, httpResp.StatusCode, respBody, nil
 }
 
 // ExecuteRaw executes a GraphQL request and returns the raw JSON response.
@@ -133,7 +267,7 @@ func (c *Client) ExecuteRaw(ctx context.Context, query string, variables map[str
 	}
 
 	if len(resp.Errors) > 0 {
-		return nil, fmt.Errorf("graphql errors: %v", resp.Errors)
+		return nil, Errors(resp.Errors)
 	}
 
 	return resp.Data, nil
@@ -224,4 +358,3 @@ func compareArrays(a, b []interface{}, path string) (bool, string) {
 
 	return true, ""
 }
-
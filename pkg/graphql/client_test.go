@@ -0,0 +1,31 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientSendsConfiguredHeaders(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{}})
+	}))
+	defer server.Close()
+
+	client := NewClientWithOptions(server.URL, ClientOptions{
+		Headers: map[string]string{"X-Client-Id": "console-a"},
+	})
+	require.NoError(t, client.Query(context.Background(), `query { __typename }`, nil, nil))
+	assert.Equal(t, "console-a", got.Get("X-Client-Id"))
+	assert.Equal(t, "application/json", got.Get("Content-Type"))
+
+	require.NoError(t, NewClient(server.URL).Query(context.Background(), `query { __typename }`, nil, nil))
+	assert.Empty(t, got.Get("X-Client-Id"), "Headers must not leak between clients")
+}
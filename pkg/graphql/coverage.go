@@ -0,0 +1,39 @@
+package graphql
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// coverage is the process-wide log of operations sent, written when
+// GRAPHQL_COVERAGE_DIR is set so cmd/coverage-report can see what the
+// suites exercise.
+var coverage struct {
+	once     sync.Once
+	recorder *Recorder
+}
+
+// RecordCoverage logs an operation to <GRAPHQL_COVERAGE_DIR>/<pid>.ndjson.
+// Clients call it for every request; other transports, such as the WebSocket
+// client, call it for their subscriptions. It does nothing when the variable
+// is unset.
+func RecordCoverage(query string) {
+	coverage.once.Do(func() {
+		dir := os.Getenv("GRAPHQL_COVERAGE_DIR")
+		if dir == "" {
+			return
+		}
+		recorder, err := NewRecorder(filepath.Join(dir, fmt.Sprintf("%d.ndjson", os.Getpid())), 0)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "graphql: coverage recording disabled: %v\n", err)
+			return
+		}
+		coverage.recorder = recorder
+	})
+	if coverage.recorder != nil {
+		coverage.recorder.Record(Exchange{Time: time.Now(), Query: query})
+	}
+}
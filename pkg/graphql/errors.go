@@ -0,0 +1,81 @@
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Errors is the list of GraphQL errors in a response, returned by Query, Mutate
+// and ExecuteRaw when the server reports any. Use errors.As or ErrorCodes to
+// inspect it.
+type Errors []GraphQLError
+
+// Error formats the errors the same way the client always has.
+func (e Errors) Error() string {
+	return fmt.Sprintf("graphql errors: %v", []GraphQLError(e))
+}
+
+// Code returns the error's extensions.code, or "" when the server sent none.
+func (e GraphQLError) Code() string {
+	code, _ := e.Extensions["code"].(string)
+	return code
+}
+
+// StatusError is returned when the server answers with a non-200 status.
+// Errors holds any GraphQL errors found in the body, as servers send for
+// requests that fail validation.
+type StatusError struct {
+	StatusCode int
+	Body       []byte
+	Errors     Errors
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d, body: %s", e.StatusCode, string(e.Body))
+}
+
+// newStatusError builds a StatusError, decoding GraphQL errors from the body when present.
+func newStatusError(status int, body []byte) *StatusError {
+	statusErr := &StatusError{StatusCode: status, Body: body}
+	var resp Response
+	if json.Unmarshal(body, &resp) == nil {
+		statusErr.Errors = resp.Errors
+	}
+	return statusErr
+}
+
+// ResponseErrors returns the GraphQL errors carried by err, whether the server
+// sent them with a 200 or with an error status. It returns nil for other errors.
+func ResponseErrors(err error) Errors {
+	var gqlErrs Errors
+	if errors.As(err, &gqlErrs) {
+		return gqlErrs
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Errors
+	}
+	return nil
+}
+
+// ErrorCodes returns the extensions.code of every GraphQL error carried by err,
+// skipping errors without one.
+func ErrorCodes(err error) []string {
+	var codes []string
+	for _, e := range ResponseErrors(err) {
+		if code := e.Code(); code != "" {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// HTTPStatus returns the status code of a StatusError, or 0 when err is not one.
+func HTTPStatus(err error) int {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode
+	}
+	return 0
+}
@@ -0,0 +1,60 @@
+package graphql
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypedErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/not-found":
+			_, _ = w.Write([]byte(`{"data":null,"errors":[` +
+				`{"message":"look not found","path":["deleteLook"],"extensions":{"code":"NOT_FOUND"}},` +
+				`{"message":"no code"}]}`))
+		case "/invalid":
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`{"errors":[{"message":"bad variable","extensions":{"code":"GRAPHQL_VALIDATION_FAILED"}}]}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte("internal error"))
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	t.Run("ResponseErrors", func(t *testing.T) {
+		err := NewClient(server.URL+"/not-found").Mutate(ctx, `mutation { deleteLook(id: "x") }`, nil, nil)
+		require.Error(t, err)
+
+		errs := ResponseErrors(err)
+		require.Len(t, errs, 2)
+		assert.Equal(t, "NOT_FOUND", errs[0].Code())
+		assert.Empty(t, errs[1].Code())
+		assert.Equal(t, []string{"NOT_FOUND"}, ErrorCodes(err))
+		assert.Zero(t, HTTPStatus(err))
+		assert.Contains(t, err.Error(), "graphql errors: ")
+		assert.Contains(t, err.Error(), "look not found")
+	})
+
+	t.Run("StatusWithErrors", func(t *testing.T) {
+		_, err := NewClient(server.URL+"/invalid").ExecuteRaw(ctx, `mutation { deleteLook }`, nil)
+		require.Error(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, HTTPStatus(err))
+		assert.Equal(t, []string{"GRAPHQL_VALIDATION_FAILED"}, ErrorCodes(err))
+	})
+
+	t.Run("StatusWithoutErrors", func(t *testing.T) {
+		err := NewClient(server.URL+"/crash").Query(ctx, `query { __typename }`, nil, nil)
+		require.Error(t, err)
+		assert.Equal(t, http.StatusInternalServerError, HTTPStatus(err))
+		assert.Nil(t, ResponseErrors(err))
+		assert.Equal(t, "unexpected status code: 500, body: internal error", err.Error())
+	})
+}
@@ -0,0 +1,261 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// DefaultKeepLast is the number of exchanges NewTestClient reports on failure.
+const DefaultKeepLast = 20
+
+// Exchange is a single recorded request/response pair.
+type Exchange struct {
+	Time       time.Time              `json:"time"`
+	DurationMs float64                `json:"durationMs"`
+	Query      string                 `json:"query"`
+	Variables  map[string]interface{} `json:"variables,omitempty"`
+	Status     int                    `json:"status,omitempty"`
+	Response   json.RawMessage        `json:"response,omitempty"`
+	RawBody    string                 `json:"rawBody,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+// newExchange builds an Exchange, keeping non-JSON bodies as text.
+func newExchange(start time.Time, query string, variables map[string]interface{}, status int, body []byte, err error) Exchange {
+	ex := Exchange{
+		Time:       start,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+		Query:      query,
+		Variables:  variables,
+		Status:     status,
+	}
+	if len(body) > 0 {
+		if json.Valid(body) {
+			ex.Response = json.RawMessage(body)
+		} else {
+			ex.RawBody = string(body)
+		}
+	}
+	if err != nil {
+		ex.Error = err.Error()
+	}
+	return ex
+}
+
+// String returns a compact multi-line summary of the exchange.
+func (e Exchange) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%.1fms, status %d)\n", e.Time.Format(time.RFC3339Nano), e.DurationMs, e.Status)
+	fmt.Fprintf(&b, "  query: %s\n", strings.Join(strings.Fields(e.Query), " "))
+	if len(e.Variables) > 0 {
+		vars, _ := json.Marshal(e.Variables)
+		fmt.Fprintf(&b, "  variables: %s\n", vars)
+	}
+	switch {
+	case len(e.Response) > 0:
+		fmt.Fprintf(&b, "  response: %s\n", e.Response)
+	case e.RawBody != "":
+		fmt.Fprintf(&b, "  body: %s\n", e.RawBody)
+	}
+	if e.Error != "" {
+		fmt.Fprintf(&b, "  error: %s\n", e.Error)
+	}
+	return b.String()
+}
+
+// Recorder writes exchanges to an NDJSON file and keeps the most recent ones in memory.
+type Recorder struct {
+	mu       sync.Mutex
+	file     *os.File
+	keepLast int
+	recent   []Exchange
+}
+
+var (
+	recordPathsMu sync.Mutex
+	recordPaths   = map[string]bool{}
+)
+
+// NewRecorder creates a recorder. An empty path records in memory only.
+// The first recorder for a path in a process truncates the file; later ones,
+// such as a test's second client, append to it.
+func NewRecorder(path string, keepLast int) (*Recorder, error) {
+	r := &Recorder{keepLast: keepLast}
+	if path == "" {
+		return r, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create record directory: %w", err)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+
+	recordPathsMu.Lock()
+	defer recordPathsMu.Unlock()
+	flag := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	if !recordPaths[abs] {
+		flag |= os.O_TRUNC
+	}
+	file, err := os.OpenFile(path, flag, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create record file: %w", err)
+	}
+	recordPaths[abs] = true
+	r.file = file
+	return r, nil
+}
+
+// Record appends an exchange to the file and the in-memory window.
+func (r *Recorder) Record(ex Exchange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file != nil {
+		if line, err := json.Marshal(ex); err == nil {
+			_, _ = r.file.Write(append(line, '\n'))
+		}
+	}
+
+	if r.keepLast > 0 {
+		r.recent = append(r.recent, ex)
+		if len(r.recent) > r.keepLast {
+			r.recent = r.recent[len(r.recent)-r.keepLast:]
+		}
+	}
+}
+
+// Last returns up to n of the most recent exchanges, oldest first.
+func (r *Recorder) Last(n int) []Exchange {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if n > len(r.recent) {
+		n = len(r.recent)
+	}
+	result := make([]Exchange, n)
+	copy(result, r.recent[len(r.recent)-n:])
+	return result
+}
+
+// Close closes the record file, if any.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+var (
+	clientHooksMu sync.Mutex
+	clientHooks   []func(*Client)
+
+	testClientHooksMu sync.Mutex
+	testClientHooks   []func(testing.TB, *Client)
+)
+
+// OnNewClient registers a hook that NewClientWithOptions runs for every client
+// it creates, NewTestClient's included, before returning it. It lets other
+// packages record plain clients too (see pkg/artifacts).
+func OnNewClient(hook func(*Client)) {
+	clientHooksMu.Lock()
+	defer clientHooksMu.Unlock()
+	clientHooks = append(clientHooks, hook)
+}
+
+// runClientHooks runs the OnNewClient hooks on a new client.
+func runClientHooks(c *Client) {
+	clientHooksMu.Lock()
+	hooks := clientHooks[:len(clientHooks):len(clientHooks)]
+	clientHooksMu.Unlock()
+	for _, hook := range hooks {
+		hook(c)
+	}
+}
+
+// KeepLast makes the client keep at least its last n exchanges in memory,
+// adding an in-memory recorder if it has none. Call it before the client is
+// used, as OnNewClient hooks do.
+func (c *Client) KeepLast(n int) {
+	if c.recorder == nil {
+		c.recorder, _ = NewRecorder("", n)
+		return
+	}
+	c.recorder.mu.Lock()
+	defer c.recorder.mu.Unlock()
+	c.recorder.keepLast = max(c.recorder.keepLast, n)
+}
+
+// OnTestClient registers a hook that NewTestClient runs for every client it
+// creates, so other packages can attach clients to a test without importing
+// into this one (see pkg/artifacts).
+func OnTestClient(hook func(testing.TB, *Client)) {
+	testClientHooksMu.Lock()
+	defer testClientHooksMu.Unlock()
+	testClientHooks = append(testClientHooks, hook)
+}
+
+// NewTestClient creates a client that keeps the last DefaultKeepLast exchanges and
+// logs them if the test fails. When GRAPHQL_RECORD_DIR is set, every exchange is
+// also written to <dir>/<test name>.ndjson, which all of a test's clients share.
+// When REPLAY_DIR is set, the test's recording is replayed instead of contacting
+// the server; tests without a recording are skipped.
+func NewTestClient(t testing.TB, endpoint string) *Client {
+	t.Helper()
+
+	opts := ClientOptions{KeepLast: DefaultKeepLast}
+	if dir := os.Getenv("REPLAY_DIR"); dir != "" {
+		path := filepath.Join(dir, recordFileName(t.Name()))
+		if _, err := os.Stat(path); err != nil {
+			t.Skipf("Skipping: no recording for %s in REPLAY_DIR", t.Name())
+		}
+		transport, err := NewReplayTransport(path)
+		if err != nil {
+			t.Fatalf("Failed to load recording: %v", err)
+		}
+		opts.Transport = transport
+	} else if dir := os.Getenv("GRAPHQL_RECORD_DIR"); dir != "" {
+		opts.RecordTo = filepath.Join(dir, recordFileName(t.Name()))
+	}
+
+	c := NewClientWithOptions(endpoint, opts)
+	t.Cleanup(func() {
+		if t.Failed() {
+			exchanges := c.recorder.Last(DefaultKeepLast)
+			var b strings.Builder
+			fmt.Fprintf(&b, "last %d GraphQL exchanges:\n", len(exchanges))
+			for _, ex := range exchanges {
+				b.WriteString(ex.String())
+			}
+			t.Log(b.String())
+		}
+		_ = c.recorder.Close()
+	})
+
+	testClientHooksMu.Lock()
+	hooks := testClientHooks[:len(testClientHooks):len(testClientHooks)]
+	testClientHooksMu.Unlock()
+	for _, hook := range hooks {
+		hook(t, c)
+	}
+	return c
+}
+
+// recordFileName turns a test name (which may contain subtest slashes) into a file name.
+func recordFileName(testName string) string {
+	replacer := strings.NewReplacer("/", "__", " ", "_", ":", "_")
+	return replacer.Replace(testName) + ".ndjson"
+}
@@ -0,0 +1,111 @@
+package graphql
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorderWritesNDJSONAndKeepsLast(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Query == "boom" {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"ok":true}}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "nested", "exchanges.ndjson")
+	client := NewClientWithOptions(server.URL, ClientOptions{RecordTo: path, KeepLast: 2})
+	require.NotNil(t, client.Recorder())
+
+	ctx := context.Background()
+	require.NoError(t, client.Query(ctx, "query { ok }", map[string]interface{}{"n": 1}, nil))
+	require.NoError(t, client.Query(ctx, "query { ok }", map[string]interface{}{"n": 2}, nil))
+	require.Error(t, client.Query(ctx, "boom", nil, nil))
+	require.NoError(t, client.Recorder().Close())
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer func() { _ = file.Close() }()
+
+	var lines []Exchange
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var ex Exchange
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &ex))
+		lines = append(lines, ex)
+	}
+	require.Len(t, lines, 3)
+	assert.JSONEq(t, `{"data":{"ok":true}}`, string(lines[0].Response))
+	assert.Equal(t, http.StatusInternalServerError, lines[2].Status)
+	assert.Contains(t, lines[2].RawBody, "internal error")
+	assert.NotEmpty(t, lines[2].Error)
+
+	last := client.Recorder().Last(10)
+	require.Len(t, last, 2, "Only KeepLast exchanges should be retained in memory")
+	assert.Equal(t, 2, last[0].Variables["n"])
+	assert.Equal(t, "boom", last[1].Query)
+}
+
+func TestClientWithoutRecordingHasNoRecorder(t *testing.T) {
+	client := NewClient("http://127.0.0.1:0/graphql")
+	assert.Nil(t, client.Recorder())
+}
+
+func TestKeepLast(t *testing.T) {
+	client := NewClient("http://127.0.0.1:0/graphql")
+	client.KeepLast(2)
+	require.NotNil(t, client.Recorder(), "KeepLast should add an in-memory recorder")
+	for i := 0; i < 3; i++ {
+		client.Recorder().Record(Exchange{Query: "query { ok }"})
+	}
+	assert.Len(t, client.Recorder().Last(10), 2)
+
+	recording := NewClientWithOptions("http://127.0.0.1:0/graphql", ClientOptions{KeepLast: 5})
+	recording.KeepLast(1)
+	for i := 0; i < 3; i++ {
+		recording.Recorder().Record(Exchange{Query: "query { ok }"})
+	}
+	assert.Len(t, recording.Recorder().Last(10), 3, "KeepLast never shrinks the window")
+}
+
+func TestRecordersShareFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "TestShared.ndjson")
+	require.NoError(t, os.WriteFile(path, []byte(`{"query":"stale"}`+"\n"), 0o644))
+
+	// Two clients in one test, as when a helper makes its own NewTestClient
+	for _, query := range []string{"query { first }", "query { second }"} {
+		recorder, err := NewRecorder(path, 0)
+		require.NoError(t, err)
+		recorder.Record(Exchange{Query: query})
+		require.NoError(t, recorder.Close())
+	}
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var queries []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var ex Exchange
+		require.NoError(t, json.Unmarshal([]byte(line), &ex))
+		queries = append(queries, ex.Query)
+	}
+	assert.Equal(t, []string{"query { first }", "query { second }"}, queries,
+		"The first recorder should truncate a previous run, the second append")
+}
+
+func TestRecordFileName(t *testing.T) {
+	assert.Equal(t, "TestLook__Create_Look.ndjson", recordFileName("TestLook/Create Look"))
+}
@@ -0,0 +1,251 @@
+package graphql
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ReplayTransport is an http.RoundTripper that answers GraphQL requests from
+// recorded exchanges instead of a live server.
+//
+// Requests are matched by query text (whitespace-insensitive). Among unused
+// recordings of the same query, one with identical variables is preferred;
+// otherwise the one sharing the most variable values, so a request whose
+// variables contain a generated name still finds its own recording whatever
+// order the requests come in. Ties go to the earliest recording.
+type ReplayTransport struct {
+	mu      sync.Mutex
+	byQuery map[string][]*replayEntry
+}
+
+type replayEntry struct {
+	exchange  Exchange
+	variables string
+	leaves    map[string]string
+	used      bool
+}
+
+// NewReplayTransport loads exchanges from an NDJSON file, or from every *.ndjson
+// file in a directory.
+func NewReplayTransport(path string) (*ReplayTransport, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open replay source: %w", err)
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		files, err = filepath.Glob(filepath.Join(path, "*.ndjson"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list replay files: %w", err)
+		}
+	}
+
+	rt := &ReplayTransport{byQuery: make(map[string][]*replayEntry)}
+	for _, file := range files {
+		if err := rt.load(file); err != nil {
+			return nil, err
+		}
+	}
+	return rt, nil
+}
+
+func (rt *ReplayTransport) load(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open replay file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var ex Exchange
+		if err := json.Unmarshal(scanner.Bytes(), &ex); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		key := normalizeQuery(ex.Query)
+		rt.byQuery[key] = append(rt.byQuery[key], &replayEntry{
+			exchange:  ex,
+			variables: canonicalVariables(ex.Variables),
+			leaves:    variableLeaves(ex.Variables),
+		})
+	}
+	return scanner.Err()
+}
+
+// failingTransport reports a fixed error for every request.
+type failingTransport struct {
+	err error
+}
+
+func (f failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, f.err
+}
+
+// replayTransportOrError loads a ReplayTransport, deferring any load error to request time
+// so a misconfigured REPLAY_DIR fails the tests that use it rather than the process.
+func replayTransportOrError(path string) http.RoundTripper {
+	rt, err := NewReplayTransport(path)
+	if err != nil {
+		return failingTransport{err: err}
+	}
+	return rt
+}
+
+// Remaining returns the number of recorded exchanges not yet replayed.
+func (rt *ReplayTransport) Remaining() int {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	n := 0
+	for _, entries := range rt.byQuery {
+		for _, e := range entries {
+			if !e.used {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// RoundTrip implements http.RoundTripper.
+func (rt *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var gqlReq Request
+	if req.Body != nil {
+		defer func() { _ = req.Body.Close() }()
+		if err := decodeReplayRequest(req, &gqlReq); err != nil {
+			return nil, fmt.Errorf("replay: failed to decode request: %w", err)
+		}
+	}
+
+	entry := rt.match(normalizeQuery(gqlReq.Query), canonicalVariables(gqlReq.Variables), variableLeaves(gqlReq.Variables))
+	if entry == nil {
+		return nil, fmt.Errorf("replay: no recorded exchange for query: %s", normalizeQuery(gqlReq.Query))
+	}
+
+	ex := entry.exchange
+	if ex.Status == 0 {
+		// The recorded request never got an HTTP response
+		return nil, errors.New(ex.Error)
+	}
+
+	body := []byte(ex.Response)
+	if len(body) == 0 {
+		body = []byte(ex.RawBody)
+	}
+	return &http.Response{
+		StatusCode: ex.Status,
+		Status:     fmt.Sprintf("%d %s", ex.Status, http.StatusText(ex.Status)),
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+// decodeReplayRequest reads the GraphQL request from a JSON body, or from the
+// "operations" part of a multipart upload (see MutateWithFiles).
+func decodeReplayRequest(req *http.Request, gqlReq *Request) error {
+	mediaType, params, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return json.NewDecoder(req.Body).Decode(gqlReq)
+	}
+
+	reader := multipart.NewReader(req.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			return fmt.Errorf("no operations part: %w", err)
+		}
+		if part.FormName() == "operations" {
+			return json.NewDecoder(part).Decode(gqlReq)
+		}
+	}
+}
+
+func (rt *ReplayTransport) match(query, variables string, leaves map[string]string) *replayEntry {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	var best *replayEntry
+	bestScore := -1
+	for _, e := range rt.byQuery[query] {
+		if e.used {
+			continue
+		}
+		if e.variables == variables {
+			best = e
+			break
+		}
+		score := 0
+		for path, value := range leaves {
+			if e.leaves[path] == value {
+				score++
+			}
+		}
+		if score > bestScore {
+			best, bestScore = e, score
+		}
+	}
+	if best != nil {
+		best.used = true
+	}
+	return best
+}
+
+// normalizeQuery collapses whitespace so formatting differences do not break matching.
+func normalizeQuery(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// variableLeaves flattens decoded variables into their scalar values, JSON
+// encoded and keyed by path (such as "input.fixtureIds[1]").
+func variableLeaves(variables map[string]interface{}) map[string]string {
+	leaves := map[string]string{}
+	var walk func(path string, v interface{})
+	walk = func(path string, v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for key, value := range v {
+				walk(path+"."+key, value)
+			}
+		case []interface{}:
+			for i, value := range v {
+				walk(fmt.Sprintf("%s[%d]", path, i), value)
+			}
+		default:
+			data, _ := json.Marshal(v)
+			leaves[path] = string(data)
+		}
+	}
+	for key, value := range variables {
+		walk(key, value)
+	}
+	return leaves
+}
+
+// canonicalVariables returns a stable JSON encoding of the variables (map keys are sorted).
+func canonicalVariables(variables map[string]interface{}) string {
+	if len(variables) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(variables)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
@@ -0,0 +1,108 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayTransportReplaysRecordedExchanges(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		name, _ := req.Variables["name"].(string)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"createProject": map[string]interface{}{"name": name}},
+		})
+	}))
+
+	path := filepath.Join(t.TempDir(), "session.ndjson")
+	recording := NewClientWithOptions(server.URL, ClientOptions{RecordTo: path})
+
+	const query = `mutation CreateProject($name: String!) { createProject(name: $name) { name } }`
+	ctx := context.Background()
+	for _, name := range []string{"first", "second"} {
+		require.NoError(t, recording.Query(ctx, query, map[string]interface{}{"name": name}, nil))
+	}
+	require.NoError(t, recording.Recorder().Close())
+	server.Close()
+
+	transport, err := NewReplayTransport(path)
+	require.NoError(t, err)
+	replay := NewClientWithOptions("http://replay.invalid/graphql", ClientOptions{Transport: transport})
+
+	var resp struct {
+		CreateProject struct {
+			Name string `json:"name"`
+		} `json:"createProject"`
+	}
+
+	// Exact variable match is preferred over recorded order
+	reformatted := "mutation CreateProject($name: String!) {\n  createProject(name: $name) { name }\n}"
+	require.NoError(t, replay.Query(ctx, reformatted, map[string]interface{}{"name": "second"}, &resp))
+	assert.Equal(t, "second", resp.CreateProject.Name)
+
+	// Unmatched variables fall back to the next unused recording
+	require.NoError(t, replay.Query(ctx, query, map[string]interface{}{"name": "generated-123"}, &resp))
+	assert.Equal(t, "first", resp.CreateProject.Name)
+	assert.Equal(t, 0, transport.Remaining())
+
+	// Exhausted recordings surface as request errors
+	err = replay.Query(ctx, query, map[string]interface{}{"name": "third"}, &resp)
+	assert.ErrorContains(t, err, "no recorded exchange")
+}
+
+func TestReplayTransportMatchesByContent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		input := req.Variables["input"].(map[string]interface{})
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"createLook": map[string]interface{}{"projectId": input["projectId"]}},
+		})
+	}))
+
+	path := filepath.Join(t.TempDir(), "session.ndjson")
+	recording := NewClientWithOptions(server.URL, ClientOptions{RecordTo: path})
+
+	const query = `mutation CreateLook($input: CreateLookInput!) { createLook(input: $input) { projectId } }`
+	ctx := context.Background()
+	for _, project := range []string{"p1", "p2", "p3"} {
+		input := map[string]interface{}{"projectId": project, "name": "Look " + project + " 1700000000"}
+		require.NoError(t, recording.Query(ctx, query, map[string]interface{}{"input": input}, nil))
+	}
+	require.NoError(t, recording.Recorder().Close())
+	server.Close()
+
+	transport, err := NewReplayTransport(path)
+	require.NoError(t, err)
+	replay := NewClientWithOptions("http://replay.invalid/graphql", ClientOptions{Transport: transport})
+
+	// Generated names differ on replay and requests arrive in another order,
+	// but each still gets the recording made for its project
+	for _, project := range []string{"p3", "p1", "p2"} {
+		var resp struct {
+			CreateLook struct {
+				ProjectID string `json:"projectId"`
+			} `json:"createLook"`
+		}
+		input := map[string]interface{}{"projectId": project, "name": "Look " + project + " 1800000000"}
+		require.NoError(t, replay.Query(ctx, query, map[string]interface{}{"input": input}, &resp))
+		assert.Equal(t, project, resp.CreateLook.ProjectID)
+	}
+	assert.Equal(t, 0, transport.Remaining())
+}
+
+func TestReplayDirEnvWithMissingDirFailsRequests(t *testing.T) {
+	t.Setenv("REPLAY_DIR", filepath.Join(t.TempDir(), "missing"))
+
+	client := NewClient("http://replay.invalid/graphql")
+	err := client.Query(context.Background(), "query { ok }", nil, nil)
+	assert.ErrorContains(t, err, "failed to open replay source")
+}
@@ -0,0 +1,132 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+)
+
+// typeFieldsResponse is the introspection shape shared by the schema helpers.
+type typeFieldsResponse struct {
+	Type *struct {
+		Fields []struct {
+			Name string `json:"name"`
+		} `json:"fields"`
+		InputFields []struct {
+			Name string `json:"name"`
+		} `json:"inputFields"`
+	} `json:"__type"`
+}
+
+// HasQueryField reports whether the schema exposes a root query field with the given name.
+func (c *Client) HasQueryField(ctx context.Context, name string) (bool, error) {
+	return c.hasRootField(ctx, "queryType", name)
+}
+
+// HasMutationField reports whether the schema exposes a root mutation field with the given name.
+func (c *Client) HasMutationField(ctx context.Context, name string) (bool, error) {
+	return c.hasRootField(ctx, "mutationType", name)
+}
+
+// HasSubscriptionField reports whether the schema exposes a root subscription field with the given name.
+func (c *Client) HasSubscriptionField(ctx context.Context, name string) (bool, error) {
+	return c.hasRootField(ctx, "subscriptionType", name)
+}
+
+// HasTypeField reports whether the named object or input type has a field with the given name.
+// A missing type is reported as false without an error.
+func (c *Client) HasTypeField(ctx context.Context, typeName, fieldName string) (bool, error) {
+	var resp typeFieldsResponse
+	err := c.Query(ctx, `
+		query TypeFields($name: String!) {
+			__type(name: $name) {
+				fields { name }
+				inputFields { name }
+			}
+		}
+	`, map[string]interface{}{"name": typeName}, &resp)
+	if err != nil {
+		return false, err
+	}
+	if resp.Type == nil {
+		return false, nil
+	}
+
+	for _, f := range resp.Type.Fields {
+		if f.Name == fieldName {
+			return true, nil
+		}
+	}
+	for _, f := range resp.Type.InputFields {
+		if f.Name == fieldName {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// HasQueryArgument reports whether the root query field accepts the named argument.
+// A missing field is reported as false without an error.
+func (c *Client) HasQueryArgument(ctx context.Context, field, arg string) (bool, error) {
+	return c.hasRootArgument(ctx, "queryType", field, arg)
+}
+
+// HasMutationArgument reports whether the root mutation field accepts the named argument.
+// A missing field is reported as false without an error.
+func (c *Client) HasMutationArgument(ctx context.Context, field, arg string) (bool, error) {
+	return c.hasRootArgument(ctx, "mutationType", field, arg)
+}
+
+// hasRootArgument looks up an argument of a field on one of the schema's root operation types.
+func (c *Client) hasRootArgument(ctx context.Context, root, field, arg string) (bool, error) {
+	var resp struct {
+		Schema map[string]*struct {
+			Fields []struct {
+				Name string `json:"name"`
+				Args []struct {
+					Name string `json:"name"`
+				} `json:"args"`
+			} `json:"fields"`
+		} `json:"__schema"`
+	}
+	query := fmt.Sprintf(`query RootArgs { __schema { %s { fields { name args { name } } } } }`, root)
+	if err := c.Query(ctx, query, nil, &resp); err != nil {
+		return false, err
+	}
+
+	rootType := resp.Schema[root]
+	if rootType == nil {
+		return false, nil
+	}
+	for _, f := range rootType.Fields {
+		if f.Name != field {
+			continue
+		}
+		for _, a := range f.Args {
+			if a.Name == arg {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// hasRootField looks up a field on one of the schema's root operation types.
+func (c *Client) hasRootField(ctx context.Context, root, name string) (bool, error) {
+	var schemaResp struct {
+		Schema map[string]*struct {
+			Name string `json:"name"`
+		} `json:"__schema"`
+	}
+
+	query := fmt.Sprintf(`query RootType { __schema { %s { name } } }`, root)
+	if err := c.Query(ctx, query, nil, &schemaResp); err != nil {
+		return false, err
+	}
+
+	rootType := schemaResp.Schema[root]
+	if rootType == nil {
+		return false, nil
+	}
+
+	return c.HasTypeField(ctx, rootType.Name, name)
+}
@@ -0,0 +1,173 @@
+package graphql
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// SelectionSet derives a selection set from the response struct v (or a
+// pointer to one), so the fields a test reads are exactly the fields it asks
+// for. Each exported field selects its json tag name, or its Go name when
+// untagged; fields tagged json:"-" are skipped and untagged embedded structs
+// are flattened, as encoding/json decodes them. Structs, and slices, arrays
+// and pointers of structs, get nested selections; everything else, including
+// types that unmarshal themselves such as json.RawMessage, is a leaf.
+//
+// A graphql tag replaces the field's name in the selection, to pass
+// arguments or alias a field:
+//
+//	Look struct { ... } `json:"look" graphql:"look(id: $id)"`
+//	Warm struct { ... } `json:"warm" graphql:"warm: look(id: $warm)"`
+func SelectionSet(v interface{}) (string, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return "", fmt.Errorf("selection set: %T is not a struct", v)
+	}
+	var b strings.Builder
+	if err := writeSelection(&b, t, nil); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// BuildQuery prefixes the selection set of result with an operation header
+// such as "query GetLook($id: ID!)" or "mutation".
+func BuildQuery(operation string, result interface{}) (string, error) {
+	key := builtKey{operation, reflect.TypeOf(result)}
+	if doc, ok := builtQueries.Load(key); ok {
+		return doc.(string), nil
+	}
+	selection, err := SelectionSet(result)
+	if err != nil {
+		return "", err
+	}
+	doc := strings.TrimSpace(operation + " " + selection)
+	builtQueries.Store(key, doc)
+	return doc, nil
+}
+
+// builtKey identifies a document built by BuildQuery.
+type builtKey struct {
+	operation string
+	result    reflect.Type
+}
+
+// builtQueries caches BuildQuery documents, since suites build the same ones
+// on every call.
+var builtQueries sync.Map
+
+// QueryFor builds the document for result with BuildQuery and runs it like Query.
+func (c *Client) QueryFor(ctx context.Context, operation string, variables map[string]interface{}, result interface{}) error {
+	query, err := BuildQuery(operation, result)
+	if err != nil {
+		return err
+	}
+	return c.Query(ctx, query, variables, result)
+}
+
+// MutateFor builds the document for result with BuildQuery and runs it like Mutate.
+func (c *Client) MutateFor(ctx context.Context, operation string, variables map[string]interface{}, result interface{}) error {
+	return c.QueryFor(ctx, operation, variables, result)
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// isLeaf reports whether t is selected without a nested selection set.
+func isLeaf(t reflect.Type) bool {
+	if reflect.PointerTo(t).Implements(jsonUnmarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	return t.Kind() != reflect.Struct
+}
+
+// elemType unwraps pointers, slices and arrays down to the type that decides the selection.
+func elemType(t reflect.Type) reflect.Type {
+	for {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array:
+			if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+				return t // []byte decodes from a string
+			}
+			t = t.Elem()
+		default:
+			return t
+		}
+	}
+}
+
+// writeSelection writes "{ a b { c } }" for struct t. seen holds the structs
+// being expanded, since a recursive type has no finite selection.
+func writeSelection(b *strings.Builder, t reflect.Type, seen []reflect.Type) error {
+	for _, s := range seen {
+		if s == t {
+			return fmt.Errorf("selection set: %s refers to itself", t)
+		}
+	}
+	seen = append(seen, t)
+
+	b.WriteString("{")
+	n, err := writeFields(b, t, seen)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("selection set: %s selects no fields", t)
+	}
+	b.WriteString(" }")
+	return nil
+}
+
+// writeFields writes t's fields, flattening untagged embedded structs, and
+// returns how many it wrote.
+func writeFields(b *strings.Builder, t reflect.Type, seen []reflect.Type) (int, error) {
+	n := 0
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		jsonTag := f.Tag.Get("json")
+		name, _, _ := strings.Cut(jsonTag, ",")
+		if name == "-" && jsonTag == "-" {
+			continue
+		}
+
+		ft := elemType(f.Type)
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct && !isLeaf(ft) {
+			embedded, err := writeFields(b, ft, seen)
+			if err != nil {
+				return n, err
+			}
+			n += embedded
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if selector := f.Tag.Get("graphql"); selector != "" {
+			name = selector
+		}
+
+		b.WriteString(" ")
+		b.WriteString(name)
+		if !isLeaf(ft) {
+			b.WriteString(" ")
+			if err := writeSelection(b, ft, seen); err != nil {
+				return n, err
+			}
+		}
+		n++
+	}
+	return n, nil
+}
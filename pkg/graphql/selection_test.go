@@ -0,0 +1,107 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type selectionChannel struct {
+	Offset int `json:"offset"`
+	Value  int `json:"value"`
+}
+
+type selectionBase struct {
+	ID string `json:"id"`
+}
+
+type selectionLook struct {
+	selectionBase
+	Name          string          `json:"name"`
+	Description   *string         `json:"description"`
+	Tags          []string        `json:"tags"`
+	UpdatedAt     time.Time       `json:"updatedAt"`
+	Raw           json.RawMessage `json:"metadata"`
+	Skipped       string          `json:"-"`
+	Untagged      bool
+	internal      int
+	FixtureValues []struct {
+		Fixture  *selectionBase     `json:"fixture"`
+		Channels []selectionChannel `json:"channels,omitempty"`
+	} `json:"fixtureValues"`
+}
+
+type selectionLoop struct {
+	Next *selectionLoop `json:"next"`
+}
+
+func TestSelectionSet(t *testing.T) {
+	var look selectionLook
+	_ = look.internal
+	got, err := SelectionSet(&look)
+	require.NoError(t, err)
+	assert.Equal(t, "{ id name description tags updatedAt metadata Untagged fixtureValues { fixture { id } channels { offset value } } }", got)
+
+	var resp struct {
+		Look selectionLook `json:"look" graphql:"look(id: $id)"`
+		Warm struct {
+			Name string `json:"name"`
+		} `json:"warm" graphql:"warm: look(id: $warm)"`
+	}
+	got, err = SelectionSet(resp)
+	require.NoError(t, err)
+	assert.Contains(t, got, "{ look(id: $id) { id name ")
+	assert.Contains(t, got, " warm: look(id: $warm) { name } }")
+
+	_, err = SelectionSet(selectionLoop{})
+	assert.ErrorContains(t, err, "refers to itself")
+	_, err = SelectionSet(struct{ hidden int }{})
+	assert.ErrorContains(t, err, "selects no fields")
+	_, err = SelectionSet([]int{})
+	assert.ErrorContains(t, err, "is not a struct")
+}
+
+func TestBuildQuery(t *testing.T) {
+	var resp struct {
+		Look struct {
+			ID string `json:"id"`
+		} `json:"look" graphql:"look(id: $id)"`
+	}
+	doc, err := BuildQuery("query GetLook($id: ID!)", &resp)
+	require.NoError(t, err)
+	assert.Equal(t, "query GetLook($id: ID!) { look(id: $id) { id } }", doc)
+
+	again, err := BuildQuery("query GetLook($id: ID!)", &resp)
+	require.NoError(t, err)
+	assert.Equal(t, doc, again)
+	other, err := BuildQuery("query Other($id: ID!)", &resp)
+	require.NoError(t, err)
+	assert.Equal(t, "query Other($id: ID!) { look(id: $id) { id } }", other, "Documents are cached per operation")
+}
+
+func TestClientQueryFor(t *testing.T) {
+	var sent Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&sent)
+		_, _ = w.Write([]byte(`{"data":{"look":{"id":"l1","name":"Warm"}}}`))
+	}))
+	defer server.Close()
+
+	var resp struct {
+		Look struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"look" graphql:"look(id: $id)"`
+	}
+	err := NewClient(server.URL).QueryFor(context.Background(), "query GetLook($id: ID!)", map[string]interface{}{"id": "l1"}, &resp)
+	require.NoError(t, err)
+	assert.Equal(t, "query GetLook($id: ID!) { look(id: $id) { id name } }", sent.Query)
+	assert.Equal(t, "l1", sent.Variables["id"])
+	assert.Equal(t, "Warm", resp.Look.Name)
+}
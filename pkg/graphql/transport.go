@@ -0,0 +1,112 @@
+package graphql
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Default connection settings. The suites send thousands of sequential
+// requests, and parallel tests each hold a connection, so keep more idle
+// connections than net/http's two per host.
+const (
+	DefaultMaxIdleConns    = 16
+	DefaultIdleConnTimeout = 90 * time.Second
+)
+
+// TransportOptions configures the HTTP connections a client keeps to the server.
+type TransportOptions struct {
+	// MaxIdleConns is how many idle keep-alive connections are kept to the server
+	// (default DefaultMaxIdleConns, or GRAPHQL_MAX_IDLE_CONNS).
+	MaxIdleConns int
+
+	// IdleConnTimeout closes connections idle this long (default DefaultIdleConnTimeout).
+	IdleConnTimeout time.Duration
+
+	// DisableKeepAlives opens a new connection for every request, as when
+	// GRAPHQL_KEEPALIVE=0. Useful to measure what connection setup costs.
+	DisableKeepAlives bool
+
+	// HTTP2 negotiates HTTP/2 with https endpoints, as when GRAPHQL_HTTP2=1.
+	// Plain http endpoints, like a local lacylights-go, always use HTTP/1.1.
+	HTTP2 bool
+}
+
+// TransportOptionsFromEnv returns the defaults, overridden by
+// GRAPHQL_MAX_IDLE_CONNS, GRAPHQL_KEEPALIVE and GRAPHQL_HTTP2.
+func TransportOptionsFromEnv() TransportOptions {
+	opts := TransportOptions{MaxIdleConns: DefaultMaxIdleConns, IdleConnTimeout: DefaultIdleConnTimeout}
+	if n, err := strconv.Atoi(os.Getenv("GRAPHQL_MAX_IDLE_CONNS")); err == nil && n > 0 {
+		opts.MaxIdleConns = n
+	}
+	if on, err := strconv.ParseBool(os.Getenv("GRAPHQL_KEEPALIVE")); err == nil {
+		opts.DisableKeepAlives = !on
+	}
+	if on, err := strconv.ParseBool(os.Getenv("GRAPHQL_HTTP2")); err == nil {
+		opts.HTTP2 = on
+	}
+	return opts
+}
+
+// withDefaults fills unset fields from TransportOptionsFromEnv.
+func (o TransportOptions) withDefaults() TransportOptions {
+	env := TransportOptionsFromEnv()
+	if o.MaxIdleConns == 0 {
+		o.MaxIdleConns = env.MaxIdleConns
+	}
+	if o.IdleConnTimeout == 0 {
+		o.IdleConnTimeout = env.IdleConnTimeout
+	}
+	o.DisableKeepAlives = o.DisableKeepAlives || env.DisableKeepAlives
+	o.HTTP2 = o.HTTP2 || env.HTTP2
+	return o
+}
+
+var (
+	transportsMu sync.Mutex
+	transports   = make(map[TransportOptions]*http.Transport)
+)
+
+// SharedTransport returns the transport for opts, shared by every client
+// with the same options so the suites reuse one pool of connections instead
+// of each client opening its own.
+func SharedTransport(opts TransportOptions) *http.Transport {
+	opts = opts.withDefaults()
+
+	transportsMu.Lock()
+	defer transportsMu.Unlock()
+	if t, ok := transports[opts]; ok {
+		return t
+	}
+	t := NewTransport(opts)
+	transports[opts] = t
+	return t
+}
+
+// NewTransport returns a new transport configured by opts, without sharing.
+func NewTransport(opts TransportOptions) *http.Transport {
+	opts = opts.withDefaults()
+	t := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          opts.MaxIdleConns,
+		MaxIdleConnsPerHost:   opts.MaxIdleConns,
+		IdleConnTimeout:       opts.IdleConnTimeout,
+		DisableKeepAlives:     opts.DisableKeepAlives,
+		ForceAttemptHTTP2:     opts.HTTP2,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+	if !opts.HTTP2 {
+		// A non-nil empty map turns off HTTP/2 negotiation entirely.
+		t.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+	return t
+}
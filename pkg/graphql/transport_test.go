@@ -0,0 +1,106 @@
+package graphql
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingServer answers every query with empty data and counts the
+// connections clients open to it.
+func countingServer(t testing.TB) (*httptest.Server, *atomic.Int32) {
+	var conns atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{}}`))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	server.Start()
+	t.Cleanup(server.Close)
+	return server, &conns
+}
+
+func TestTransportOptionsFromEnv(t *testing.T) {
+	opts := TransportOptionsFromEnv()
+	assert.Equal(t, TransportOptions{MaxIdleConns: DefaultMaxIdleConns, IdleConnTimeout: DefaultIdleConnTimeout}, opts)
+
+	t.Setenv("GRAPHQL_MAX_IDLE_CONNS", "4")
+	t.Setenv("GRAPHQL_KEEPALIVE", "0")
+	t.Setenv("GRAPHQL_HTTP2", "true")
+	opts = TransportOptionsFromEnv()
+	assert.Equal(t, 4, opts.MaxIdleConns)
+	assert.True(t, opts.DisableKeepAlives)
+	assert.True(t, opts.HTTP2)
+
+	transport := NewTransport(TransportOptions{MaxIdleConns: 8})
+	assert.Equal(t, 8, transport.MaxIdleConnsPerHost, "Explicit options win over the environment")
+	assert.True(t, transport.DisableKeepAlives)
+	assert.True(t, transport.ForceAttemptHTTP2)
+}
+
+func TestSharedTransport(t *testing.T) {
+	assert.Same(t, SharedTransport(TransportOptions{}), SharedTransport(TransportOptions{}))
+	assert.NotSame(t, SharedTransport(TransportOptions{}), SharedTransport(TransportOptions{DisableKeepAlives: true}))
+	assert.NotNil(t, NewTransport(TransportOptions{}).TLSNextProto, "HTTP/2 is off unless asked for")
+}
+
+func TestClientReusesConnections(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("KeepAlive", func(t *testing.T) {
+		server, conns := countingServer(t)
+		client := NewClientWithOptions(server.URL, ClientOptions{Connections: TransportOptions{IdleConnTimeout: time.Minute}})
+		for i := 0; i < 20; i++ {
+			require.NoError(t, client.Query(ctx, `query { __typename }`, nil, nil))
+		}
+		require.NoError(t, NewClient(server.URL).Query(ctx, `query { __typename }`, nil, nil))
+		assert.LessOrEqual(t, conns.Load(), int32(2), "Sequential requests should share a connection")
+	})
+
+	t.Run("KeepAliveDisabled", func(t *testing.T) {
+		server, conns := countingServer(t)
+		client := NewClientWithOptions(server.URL, ClientOptions{Connections: TransportOptions{DisableKeepAlives: true}})
+		for i := 0; i < 5; i++ {
+			require.NoError(t, client.Query(ctx, `query { __typename }`, nil, nil))
+		}
+		assert.Equal(t, int32(5), conns.Load())
+	})
+}
+
+// BenchmarkClientQuery compares per-request latency with pooled keep-alive
+// connections against a new connection per request. Run with
+//
+//	go test -run '^$' -bench ClientQuery ./pkg/graphql/
+func BenchmarkClientQuery(b *testing.B) {
+	for _, bc := range []struct {
+		name string
+		opts TransportOptions
+	}{
+		{"KeepAlive", TransportOptions{}},
+		{"NoKeepAlive", TransportOptions{DisableKeepAlives: true}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			server, conns := countingServer(b)
+			client := NewClientWithOptions(server.URL, ClientOptions{Transport: NewTransport(bc.opts)})
+			ctx := context.Background()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := client.Query(ctx, `query { __typename }`, nil, nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(conns.Load())/float64(b.N), "conns/op")
+		})
+	}
+}
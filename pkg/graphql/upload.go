@@ -0,0 +1,168 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"strconv"
+	"time"
+)
+
+// Upload is a file sent with MutateWithFiles. Put it in the variables
+// wherever the operation expects an Upload scalar.
+type Upload struct {
+	FileName string
+
+	// ContentType defaults to application/octet-stream.
+	ContentType string
+
+	Content []byte
+}
+
+// MutateWithFiles executes a mutation whose variables hold Upload values,
+// following the GraphQL multipart request spec: an "operations" part with the
+// request and every file replaced by null, a "map" part from file part names
+// to the variable paths they fill, and one part per file. Uploads may appear
+// at any depth, in maps and in []interface{}, []map[string]interface{} or
+// []Upload slices. Without any uploads the request is sent as plain JSON.
+//
+// Recorded exchanges show the files as null; ReplayTransport matches on the
+// operations part, so uploads replay like any other request.
+func (c *Client) MutateWithFiles(ctx context.Context, mutation string, variables map[string]interface{}, result interface{}) error {
+	var files []Upload
+	var paths [][]string
+	stripped, _ := extractUploads(variables, "variables", &files, &paths)
+	if len(files) == 0 {
+		return c.Mutate(ctx, mutation, variables, result)
+	}
+	strippedVars, _ := stripped.(map[string]interface{})
+
+	RecordCoverage(mutation)
+	body, contentType, err := multipartBody(Request{Query: mutation, Variables: strippedVars}, files, paths)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	resp, status, respBody, err := c.post(ctx, contentType, body)
+	if c.recorder != nil {
+		c.recorder.Record(newExchange(start, mutation, strippedVars, status, respBody, err))
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(resp.Errors) > 0 {
+		return Errors(resp.Errors)
+	}
+	if result != nil {
+		if err := json.Unmarshal(resp.Data, result); err != nil {
+			return fmt.Errorf("failed to unmarshal response: %w", err)
+		}
+	}
+	return nil
+}
+
+// extractUploads returns a copy of v with every Upload replaced by nil,
+// appending each upload and its dotted object path. The bool reports whether
+// anything under v was replaced, so untouched values are shared, not copied.
+func extractUploads(v interface{}, path string, files *[]Upload, paths *[][]string) (interface{}, bool) {
+	switch val := v.(type) {
+	case Upload:
+		*files = append(*files, val)
+		*paths = append(*paths, []string{path})
+		return nil, true
+	case *Upload:
+		if val == nil {
+			return v, false
+		}
+		return extractUploads(*val, path, files, paths)
+	case map[string]interface{}:
+		var out map[string]interface{}
+		for key, item := range val {
+			if replaced, ok := extractUploads(item, path+"."+key, files, paths); ok {
+				if out == nil {
+					out = make(map[string]interface{}, len(val))
+					for k, v := range val {
+						out[k] = v
+					}
+				}
+				out[key] = replaced
+			}
+		}
+		if out == nil {
+			return v, false
+		}
+		return out, true
+	case []interface{}:
+		return extractFromSlice(len(val), func(i int) interface{} { return val[i] }, v, path, files, paths)
+	case []map[string]interface{}:
+		return extractFromSlice(len(val), func(i int) interface{} { return val[i] }, v, path, files, paths)
+	case []Upload:
+		return extractFromSlice(len(val), func(i int) interface{} { return val[i] }, v, path, files, paths)
+	}
+	return v, false
+}
+
+func extractFromSlice(n int, at func(int) interface{}, original interface{}, path string, files *[]Upload, paths *[][]string) (interface{}, bool) {
+	out := make([]interface{}, n)
+	found := false
+	for i := range out {
+		replaced, ok := extractUploads(at(i), path+"."+strconv.Itoa(i), files, paths)
+		out[i] = replaced
+		found = found || ok
+	}
+	if !found {
+		return original, false
+	}
+	return out, true
+}
+
+// multipartBody encodes a request and its files as a multipart request body
+// and returns it with its content type.
+func multipartBody(req Request, files []Upload, paths [][]string) ([]byte, string, error) {
+	operations, err := json.Marshal(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+	fileMap := make(map[string][]string, len(files))
+	for i := range files {
+		fileMap[strconv.Itoa(i)] = paths[i]
+	}
+	mapJSON, err := json.Marshal(fileMap)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to marshal file map: %w", err)
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if err := writer.WriteField("operations", string(operations)); err != nil {
+		return nil, "", err
+	}
+	if err := writer.WriteField("map", string(mapJSON)); err != nil {
+		return nil, "", err
+	}
+	for i, f := range files {
+		contentType := f.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%d"; filename=%q`, i, f.FileName))
+		header.Set("Content-Type", contentType)
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := part.Write(f.Content); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return body.Bytes(), writer.FormDataContentType(), nil
+}
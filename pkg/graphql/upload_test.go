@@ -0,0 +1,222 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// uploadHandler is a reference server for the multipart request spec. It puts
+// each file, as {name, type, content}, at the paths the map gives, and answers
+// with the resulting variables as data. Plain JSON requests echo the same way.
+func uploadHandler(t *testing.T, headers *http.Header) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if headers != nil {
+			*headers = r.Header.Clone()
+		}
+		var req Request
+		if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"variables": req.Variables}})
+			return
+		}
+
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+		require.NoError(t, json.Unmarshal([]byte(r.FormValue("operations")), &req))
+		var fileMap map[string][]string
+		require.NoError(t, json.Unmarshal([]byte(r.FormValue("map")), &fileMap))
+
+		if strings.Contains(req.Query, "fail") {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"errors": []map[string]interface{}{{"message": "upload rejected", "extensions": map[string]interface{}{"code": "BAD_USER_INPUT"}}},
+			})
+			return
+		}
+
+		root := map[string]interface{}{"variables": req.Variables}
+		for name, paths := range fileMap {
+			file, header, err := r.FormFile(name)
+			require.NoError(t, err, "The map names part %q", name)
+			content, _ := io.ReadAll(file)
+			value := map[string]interface{}{
+				"name":    header.Filename,
+				"type":    header.Header.Get("Content-Type"),
+				"content": string(content),
+			}
+			for _, path := range paths {
+				require.NoError(t, setPath(root, strings.Split(path, "."), value))
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": root})
+	})
+}
+
+// setPath replaces the null at an object path, as a spec server does.
+func setPath(node interface{}, path []string, value interface{}) error {
+	key := path[0]
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if len(path) == 1 {
+			if n[key] != nil {
+				return fmt.Errorf("%s is not null", key)
+			}
+			n[key] = value
+			return nil
+		}
+		return setPath(n[key], path[1:], value)
+	case []interface{}:
+		i, err := strconv.Atoi(key)
+		if err != nil || i >= len(n) {
+			return fmt.Errorf("bad index %q", key)
+		}
+		if len(path) == 1 {
+			if n[i] != nil {
+				return fmt.Errorf("%s is not null", key)
+			}
+			n[i] = value
+			return nil
+		}
+		return setPath(n[i], path[1:], value)
+	}
+	return fmt.Errorf("cannot descend into %T at %q", node, key)
+}
+
+type uploadedFile struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Content string `json:"content"`
+}
+
+func TestMutateWithFilesSingleFile(t *testing.T) {
+	var headers http.Header
+	server := httptest.NewServer(uploadHandler(t, &headers))
+	defer server.Close()
+
+	client := NewClientWithOptions(server.URL, ClientOptions{Headers: map[string]string{"X-Client-Id": "console-a"}})
+	var resp struct {
+		Variables struct {
+			File    uploadedFile `json:"file"`
+			Comment string       `json:"comment"`
+		} `json:"variables"`
+	}
+	err := client.MutateWithFiles(context.Background(), `mutation Upload($file: Upload!, $comment: String) { upload(file: $file, comment: $comment) }`,
+		map[string]interface{}{
+			"file":    Upload{FileName: "par.json", ContentType: "application/json", Content: []byte(`{"model":"Par"}`)},
+			"comment": "first",
+		}, &resp)
+	require.NoError(t, err)
+
+	assert.Equal(t, uploadedFile{Name: "par.json", Type: "application/json", Content: `{"model":"Par"}`}, resp.Variables.File)
+	assert.Equal(t, "first", resp.Variables.Comment)
+	assert.True(t, strings.HasPrefix(headers.Get("Content-Type"), "multipart/form-data"), headers.Get("Content-Type"))
+	assert.Equal(t, "console-a", headers.Get("X-Client-Id"), "Configured headers apply to uploads")
+}
+
+func TestMutateWithFilesListsAndNestedInput(t *testing.T) {
+	server := httptest.NewServer(uploadHandler(t, nil))
+	defer server.Close()
+
+	files := []Upload{
+		{FileName: "a.bin", Content: []byte("A")},
+		{FileName: "b.bin", Content: []byte("B")},
+	}
+	variables := map[string]interface{}{
+		"files": files,
+		"input": map[string]interface{}{
+			"name": "Show",
+			"attachments": []map[string]interface{}{
+				{"label": "plot", "file": &Upload{FileName: "plot.pdf", ContentType: "application/pdf", Content: []byte("%PDF")}},
+			},
+		},
+	}
+
+	var resp struct {
+		Variables struct {
+			Files []uploadedFile `json:"files"`
+			Input struct {
+				Name        string `json:"name"`
+				Attachments []struct {
+					Label string       `json:"label"`
+					File  uploadedFile `json:"file"`
+				} `json:"attachments"`
+			} `json:"input"`
+		} `json:"variables"`
+	}
+	require.NoError(t, NewClient(server.URL).MutateWithFiles(context.Background(),
+		`mutation Upload($files: [Upload!]!, $input: ShowInput!) { upload(files: $files, input: $input) }`, variables, &resp))
+
+	require.Len(t, resp.Variables.Files, 2)
+	assert.Equal(t, uploadedFile{Name: "a.bin", Type: "application/octet-stream", Content: "A"}, resp.Variables.Files[0],
+		"The content type defaults to application/octet-stream")
+	assert.Equal(t, "B", resp.Variables.Files[1].Content)
+	assert.Equal(t, "Show", resp.Variables.Input.Name)
+	require.Len(t, resp.Variables.Input.Attachments, 1)
+	assert.Equal(t, "plot", resp.Variables.Input.Attachments[0].Label)
+	assert.Equal(t, uploadedFile{Name: "plot.pdf", Type: "application/pdf", Content: "%PDF"}, resp.Variables.Input.Attachments[0].File)
+
+	// The caller's variables are not rewritten
+	assert.Equal(t, files, variables["files"])
+	attachment := variables["input"].(map[string]interface{})["attachments"].([]map[string]interface{})[0]
+	assert.IsType(t, &Upload{}, attachment["file"])
+}
+
+func TestMutateWithFilesErrors(t *testing.T) {
+	server := httptest.NewServer(uploadHandler(t, nil))
+	defer server.Close()
+
+	var resp struct{}
+	err := NewClient(server.URL).MutateWithFiles(context.Background(), `mutation { fail(file: $file) }`,
+		map[string]interface{}{"file": Upload{FileName: "x", Content: []byte("x")}}, &resp)
+	require.Error(t, err)
+	assert.Equal(t, []string{"BAD_USER_INPUT"}, ErrorCodes(err))
+}
+
+func TestMutateWithFilesWithoutFilesSendsJSON(t *testing.T) {
+	var headers http.Header
+	server := httptest.NewServer(uploadHandler(t, &headers))
+	defer server.Close()
+
+	var resp struct {
+		Variables map[string]interface{} `json:"variables"`
+	}
+	require.NoError(t, NewClient(server.URL).MutateWithFiles(context.Background(), `mutation Rename($name: String!) { rename(name: $name) }`,
+		map[string]interface{}{"name": "plain"}, &resp))
+	assert.Equal(t, "application/json", headers.Get("Content-Type"))
+	assert.Equal(t, "plain", resp.Variables["name"])
+}
+
+func TestMutateWithFilesRecordsAndReplays(t *testing.T) {
+	server := httptest.NewServer(uploadHandler(t, nil))
+	path := filepath.Join(t.TempDir(), "uploads.ndjson")
+	recording := NewClientWithOptions(server.URL, ClientOptions{RecordTo: path})
+
+	const mutation = `mutation Upload($file: Upload!) { upload(file: $file) }`
+	variables := map[string]interface{}{"file": Upload{FileName: "cues.csv", Content: []byte("1,Go")}}
+	ctx := context.Background()
+	require.NoError(t, recording.MutateWithFiles(ctx, mutation, variables, nil))
+	require.NoError(t, recording.Recorder().Close())
+	server.Close()
+
+	transport, err := NewReplayTransport(path)
+	require.NoError(t, err)
+	replay := NewClientWithOptions("http://replay.invalid/graphql", ClientOptions{Transport: transport})
+
+	var resp struct {
+		Variables struct {
+			File uploadedFile `json:"file"`
+		} `json:"variables"`
+	}
+	require.NoError(t, replay.MutateWithFiles(ctx, mutation, variables, &resp))
+	assert.Equal(t, "1,Go", resp.Variables.File.Content)
+	assert.Equal(t, 0, transport.Remaining(), "The upload matches its recording with files as null")
+}
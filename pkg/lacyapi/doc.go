@@ -0,0 +1,16 @@
+// Package lacyapi holds typed bindings for the server's GraphQL API,
+// generated by cmd/genapi into lacyapi_gen.go from schema.json, the part of
+// the server's schema they cover, in introspection format:
+//
+//	api := lacyapi.New(graphql.NewTestClient(t, ""))
+//	project, err := api.CreateProject(ctx, lacyapi.CreateProjectInput{Name: "Spring Show", Description: lacyapi.Ptr("Main stage")})
+//
+// Each method selects the scalar and enum fields of its result; nested
+// objects such as a look's fixtureValues are read with client.QueryFor and
+// a hand-written struct. schema.json holds the types the crud suite uses, not
+// the whole schema; TestLacyAPISchemaMatchesServer in contracts/api fails when
+// the server no longer matches it, and `make genapi` replaces it with a
+// running server's full introspection and regenerates the bindings.
+package lacyapi
+
+//go:generate go run ../../cmd/genapi -schema schema.json -out lacyapi_gen.go
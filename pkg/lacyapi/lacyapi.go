@@ -0,0 +1,6 @@
+package lacyapi
+
+// Ptr returns a pointer to v, for the nullable fields of input types.
+func Ptr[T any](v T) *T {
+	return &v
+}
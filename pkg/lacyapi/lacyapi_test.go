@@ -0,0 +1,49 @@
+package lacyapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/bbernstein/lacylights-test/pkg/apigen"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGeneratedUpToDate fails when lacyapi_gen.go was edited by hand or not
+// regenerated after schema.json changed.
+func TestGeneratedUpToDate(t *testing.T) {
+	data, err := os.ReadFile("schema.json")
+	require.NoError(t, err)
+	schema, err := apigen.Parse(data)
+	require.NoError(t, err)
+	want, err := apigen.Generate(schema, "lacyapi")
+	require.NoError(t, err)
+
+	got, err := os.ReadFile("lacyapi_gen.go")
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got), "Run go generate ./pkg/lacyapi")
+}
+
+func TestUpdateFixtureInstance(t *testing.T) {
+	var sent graphql.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&sent)
+		_, _ = w.Write([]byte(`{"data":{"updateFixtureInstance":{"id":"f1","name":"Par","universe":2,"tags":["front"]}}}`))
+	}))
+	defer server.Close()
+
+	fixture, err := New(graphql.NewClient(server.URL)).UpdateFixtureInstance(context.Background(), "f1",
+		UpdateFixtureInstanceInput{Universe: Ptr(2), Tags: []string{"front"}})
+	require.NoError(t, err)
+	assert.Equal(t, "mutation UpdateFixtureInstance($id: ID!, $input: UpdateFixtureInstanceInput!) "+
+		"{ updateFixtureInstance(id: $id, input: $input) { id name description manufacturer model universe startChannel channelCount tags } }", sent.Query)
+	assert.Equal(t, "f1", sent.Variables["id"])
+	assert.Equal(t, map[string]interface{}{"universe": float64(2), "tags": []interface{}{"front"}}, sent.Variables["input"],
+		"Unset nullable fields should be left out")
+	assert.Equal(t, &FixtureInstance{ID: "f1", Name: "Par", Universe: 2, Tags: []string{"front"}}, fixture)
+}
@@ -0,0 +1,227 @@
+// Package msc encodes and sends MIDI Show Control (MSC) messages, the SysEx
+// commands consoles use to trigger cues on other devices:
+//
+//	F0 7F <device> 02 <command format> <command> <cue> [00 <list> [00 <path>]] F7
+//
+// Messages are sent as raw SysEx bytes in UDP datagrams, one message per
+// datagram, to the address the server listens for MSC on (MSC_ADDR).
+package msc
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Command is an MSC command byte.
+type Command byte
+
+// Commands of the MSC specification the server may act on.
+const (
+	Go      Command = 0x01
+	Stop    Command = 0x02
+	Resume  Command = 0x03
+	TimedGo Command = 0x04
+	Load    Command = 0x05
+	Set     Command = 0x06
+	Fire    Command = 0x07
+	AllOff  Command = 0x08
+	Restore Command = 0x09
+	Reset   Command = 0x0A
+	GoOff   Command = 0x0B
+)
+
+var commandNames = map[Command]string{
+	Go: "GO", Stop: "STOP", Resume: "RESUME", TimedGo: "TIMED_GO", Load: "LOAD", Set: "SET",
+	Fire: "FIRE", AllOff: "ALL_OFF", Restore: "RESTORE", Reset: "RESET", GoOff: "GO_OFF",
+}
+
+func (c Command) String() string {
+	if name, ok := commandNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%02X", byte(c))
+}
+
+const (
+	// AllCall is the device ID every receiver responds to.
+	AllCall byte = 0x7F
+
+	// Lighting is the "Lighting (General)" command format.
+	Lighting byte = 0x01
+
+	sysExStart byte = 0xF0
+	sysExEnd   byte = 0xF7
+	universal  byte = 0x7F // real-time universal SysEx
+	subID      byte = 0x02 // MIDI Show Control
+)
+
+// Message is one MSC command. Cue, List and Path are cue numbers such as
+// "12" or "2.5"; an empty Cue addresses the current cue, and an empty List
+// the current cue list.
+type Message struct {
+	DeviceID byte
+	Format   byte
+	Command  Command
+	Cue      string
+	List     string
+	Path     string
+}
+
+// NewMessage returns a lighting message for all devices.
+func NewMessage(command Command, cue, list string) Message {
+	return Message{DeviceID: AllCall, Format: Lighting, Command: command, Cue: cue, List: list}
+}
+
+func (m Message) String() string {
+	s := m.Command.String()
+	if m.Cue != "" {
+		s += " " + m.Cue
+	}
+	if m.List != "" {
+		s += " list " + m.List
+	}
+	if m.Path != "" {
+		s += " path " + m.Path
+	}
+	return s
+}
+
+// Encode returns the message as a SysEx byte sequence.
+func (m Message) Encode() ([]byte, error) {
+	if m.DeviceID > 0x7F || m.Format > 0x7F || m.Command > 0x7F {
+		return nil, fmt.Errorf("msc: device, format and command must be 7-bit, got %02X %02X %02X", m.DeviceID, m.Format, byte(m.Command))
+	}
+	if m.Cue == "" && (m.List != "" || m.Path != "") {
+		return nil, errors.New("msc: a list or path needs a cue number")
+	}
+	if m.List == "" && m.Path != "" {
+		return nil, errors.New("msc: a path needs a list")
+	}
+
+	data := []byte{sysExStart, universal, m.DeviceID, subID, m.Format, byte(m.Command)}
+	for i, number := range []string{m.Cue, m.List, m.Path} {
+		if number == "" {
+			break
+		}
+		if err := validateNumber(number); err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			data = append(data, 0x00)
+		}
+		data = append(data, number...)
+	}
+	return append(data, sysExEnd), nil
+}
+
+// validateNumber checks a cue number is digits with at most one decimal point,
+// the only characters MSC cue numbers carry.
+func validateNumber(number string) error {
+	if strings.Count(number, ".") > 1 || strings.HasPrefix(number, ".") || strings.HasSuffix(number, ".") {
+		return fmt.Errorf("msc: invalid cue number %q", number)
+	}
+	for _, c := range number {
+		if c != '.' && (c < '0' || c > '9') {
+			return fmt.Errorf("msc: invalid cue number %q", number)
+		}
+	}
+	return nil
+}
+
+// Decode parses a SysEx byte sequence produced by Encode.
+func Decode(data []byte) (Message, error) {
+	if len(data) < 7 || data[0] != sysExStart || data[len(data)-1] != sysExEnd ||
+		data[1] != universal || data[3] != subID {
+		return Message{}, fmt.Errorf("msc: not an MSC message: % X", data)
+	}
+	m := Message{DeviceID: data[2], Format: data[4], Command: Command(data[5])}
+
+	if body := data[6 : len(data)-1]; len(body) > 0 {
+		numbers := strings.Split(string(body), "\x00")
+		if len(numbers) > 3 {
+			return Message{}, fmt.Errorf("msc: too many cue fields: % X", data)
+		}
+		for _, number := range numbers {
+			if err := validateNumber(number); err != nil {
+				return Message{}, err
+			}
+		}
+		fields := []*string{&m.Cue, &m.List, &m.Path}
+		for i, number := range numbers {
+			*fields[i] = number
+		}
+	}
+	return m, nil
+}
+
+// Sender sends MSC messages to one address.
+type Sender struct {
+	conn     net.Conn
+	deviceID byte
+}
+
+// Dial creates a sender for addr ("host:port"). Messages built with
+// NewMessage are sent to deviceID instead of all devices.
+func Dial(addr string, deviceID byte) (*Sender, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("msc: failed to dial %s: %w", addr, err)
+	}
+	return &Sender{conn: conn, deviceID: deviceID}, nil
+}
+
+// Send encodes and sends m.
+func (s *Sender) Send(m Message) error {
+	if m.DeviceID == AllCall {
+		m.DeviceID = s.deviceID
+	}
+	data, err := m.Encode()
+	if err != nil {
+		return err
+	}
+	if _, err := s.conn.Write(data); err != nil {
+		return fmt.Errorf("msc: failed to send %s: %w", m, err)
+	}
+	return nil
+}
+
+// Close releases the sender's socket.
+func (s *Sender) Close() error {
+	return s.conn.Close()
+}
+
+// DeviceIDFromEnv returns MSC_DEVICE_ID (0-127), or AllCall when it is unset
+// or invalid.
+func DeviceIDFromEnv() byte {
+	id, err := strconv.ParseUint(os.Getenv("MSC_DEVICE_ID"), 0, 7)
+	if err != nil {
+		return AllCall
+	}
+	return byte(id)
+}
+
+// Require returns a sender for MSC_ADDR, closed when the test ends. MSC is
+// fire-and-forget, so support cannot be probed: without MSC_ADDR the test is
+// skipped, or fails when PENDING_CONTRACTS is set.
+func Require(t testing.TB, expected string) *Sender {
+	t.Helper()
+
+	addr := os.Getenv("MSC_ADDR")
+	if addr == "" {
+		if os.Getenv("PENDING_CONTRACTS") != "" {
+			t.Fatalf("MSC_ADDR is not set; expected: %s", expected)
+		}
+		t.Skip("Skipping: MSC_ADDR is not set (set PENDING_CONTRACTS=1 to fail)")
+	}
+	sender, err := Dial(addr, DeviceIDFromEnv())
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	t.Cleanup(func() { _ = sender.Close() })
+	return sender
+}
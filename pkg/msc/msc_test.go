@@ -0,0 +1,86 @@
+package msc
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncode(t *testing.T) {
+	for _, tc := range []struct {
+		msg  Message
+		want []byte
+	}{
+		{NewMessage(Go, "", ""), []byte{0xF0, 0x7F, 0x7F, 0x02, 0x01, 0x01, 0xF7}},
+		{NewMessage(Go, "2.5", ""), []byte{0xF0, 0x7F, 0x7F, 0x02, 0x01, 0x01, '2', '.', '5', 0xF7}},
+		{NewMessage(Stop, "12", "3"), []byte{0xF0, 0x7F, 0x7F, 0x02, 0x01, 0x02, '1', '2', 0x00, '3', 0xF7}},
+		{Message{DeviceID: 5, Format: Lighting, Command: Resume, Cue: "1", List: "2", Path: "1"},
+			[]byte{0xF0, 0x7F, 0x05, 0x02, 0x01, 0x03, '1', 0x00, '2', 0x00, '1', 0xF7}},
+	} {
+		data, err := tc.msg.Encode()
+		require.NoError(t, err, tc.msg.String())
+		assert.Equal(t, tc.want, data, tc.msg.String())
+
+		decoded, err := Decode(data)
+		require.NoError(t, err)
+		assert.Equal(t, tc.msg, decoded, "Decode reverses Encode")
+	}
+}
+
+func TestEncodeRejects(t *testing.T) {
+	for name, msg := range map[string]Message{
+		"Letters":      NewMessage(Go, "1a", ""),
+		"TwoPoints":    NewMessage(Go, "1.2.3", ""),
+		"TrailingDot":  NewMessage(Go, "1.", ""),
+		"ListNoCue":    NewMessage(Go, "", "1"),
+		"EightBitID":   {DeviceID: 0x80, Format: Lighting, Command: Go},
+		"PathNoList":   {DeviceID: AllCall, Format: Lighting, Command: Go, Cue: "1", Path: "1"},
+		"EightBitCmd":  {DeviceID: AllCall, Format: Lighting, Command: 0x81},
+		"EightBitForm": {DeviceID: AllCall, Format: 0x90, Command: Go},
+	} {
+		_, err := msg.Encode()
+		assert.Error(t, err, name)
+	}
+
+	_, err := Decode([]byte{0xF0, 0x7E, 0x7F, 0x02, 0x01, 0x01, 0xF7})
+	assert.Error(t, err, "Non-real-time SysEx is not MSC")
+}
+
+func TestString(t *testing.T) {
+	assert.Equal(t, "GO 2.5 list 1", NewMessage(Go, "2.5", "1").String())
+	assert.Equal(t, "0x7E", Command(0x7E).String())
+}
+
+func TestSender(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	sender, err := Dial(conn.LocalAddr().String(), 9)
+	require.NoError(t, err)
+	defer func() { _ = sender.Close() }()
+
+	require.NoError(t, sender.Send(NewMessage(Go, "3", "")))
+	assert.Error(t, sender.Send(NewMessage(Go, "x", "")), "Invalid messages are not sent")
+
+	buf := make([]byte, 64)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	n, _, err := conn.ReadFromUDP(buf)
+	require.NoError(t, err)
+	msg, err := Decode(buf[:n])
+	require.NoError(t, err)
+	assert.Equal(t, Message{DeviceID: 9, Format: Lighting, Command: Go, Cue: "3"}, msg,
+		"All-call messages go to the sender's device")
+}
+
+func TestDeviceIDFromEnv(t *testing.T) {
+	t.Setenv("MSC_DEVICE_ID", "")
+	assert.Equal(t, AllCall, DeviceIDFromEnv())
+	t.Setenv("MSC_DEVICE_ID", "0x10")
+	assert.Equal(t, byte(16), DeviceIDFromEnv())
+	t.Setenv("MSC_DEVICE_ID", "200")
+	assert.Equal(t, AllCall, DeviceIDFromEnv(), "IDs above 127 are invalid")
+}
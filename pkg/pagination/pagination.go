@@ -0,0 +1,137 @@
+// Package pagination checks that paginated list queries follow one contract:
+// consistent totals, correct hasMore and page sizes, stable ordering, and
+// pages that neither overlap nor skip items.
+package pagination
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// AnyTotal tells Check not to compare the reported total with a known count,
+// for lists whose size the test cannot predict.
+const AnyTotal = -1
+
+// Page is one page of a list query, reduced to what the contract covers.
+type Page struct {
+	IDs        []string
+	Total      int
+	Page       int
+	PerPage    int
+	TotalPages int
+	HasMore    bool
+}
+
+// Fetcher requests one page. Pages are 1-indexed.
+type Fetcher func(ctx context.Context, page, perPage int) (Page, error)
+
+// Violations walks every page of the list plus one page past the end and
+// returns every way the responses break the pagination contract. want is the
+// number of items the list should hold, or AnyTotal.
+func Violations(ctx context.Context, fetch Fetcher, perPage, want int) ([]string, error) {
+	var problems []string
+	report := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	first, err := fetch(ctx, 1, perPage)
+	if err != nil {
+		return nil, fmt.Errorf("page 1: %w", err)
+	}
+	total := first.Total
+	if want != AnyTotal && total != want {
+		report("total is %d, want %d", total, want)
+	}
+	lastPage := (total + perPage - 1) / perPage
+
+	var ids []string
+	seen := make(map[string]int)
+	for page := 1; page <= lastPage+1; page++ {
+		p := first
+		if page > 1 {
+			if p, err = fetch(ctx, page, perPage); err != nil {
+				return nil, fmt.Errorf("page %d: %w", page, err)
+			}
+		}
+
+		if p.Total != total {
+			report("page %d: total is %d, page 1 said %d", page, p.Total, total)
+		}
+		if p.Page != page {
+			report("page %d: reports page %d", page, p.Page)
+		}
+		if p.PerPage != perPage {
+			report("page %d: reports perPage %d, requested %d", page, p.PerPage, perPage)
+		}
+		if p.TotalPages != lastPage {
+			report("page %d: totalPages is %d, want %d", page, p.TotalPages, lastPage)
+		}
+		if p.HasMore != (page < lastPage) {
+			report("page %d of %d: hasMore is %v", page, lastPage, p.HasMore)
+		}
+
+		size := 0
+		switch {
+		case page < lastPage:
+			size = perPage
+		case page == lastPage:
+			size = total - perPage*(lastPage-1)
+		}
+		if len(p.IDs) != size {
+			report("page %d: %d items, want %d", page, len(p.IDs), size)
+		}
+
+		for _, id := range p.IDs {
+			if prev, dup := seen[id]; dup {
+				report("page %d: item %s already returned on page %d", page, id, prev)
+				continue
+			}
+			seen[id] = page
+			ids = append(ids, id)
+		}
+	}
+
+	if len(ids) != total {
+		report("pages hold %d distinct items, total is %d", len(ids), total)
+	}
+
+	again, err := fetch(ctx, 1, perPage)
+	if err != nil {
+		return nil, fmt.Errorf("page 1 again: %w", err)
+	}
+	if strings.Join(again.IDs, ",") != strings.Join(first.IDs, ",") {
+		report("page 1 changed between two identical requests")
+	}
+
+	// One page holding everything must list the items in the same order.
+	if total > 0 {
+		all, err := fetch(ctx, 1, total)
+		if err != nil {
+			return nil, fmt.Errorf("single page of %d: %w", total, err)
+		}
+		if strings.Join(all.IDs, ",") != strings.Join(ids, ",") {
+			report("ordering differs between perPage %d and perPage %d", perPage, total)
+		}
+	}
+
+	return problems, nil
+}
+
+// Check fails the test with every pagination contract violation found.
+// It returns true when the list conforms.
+func Check(t testing.TB, ctx context.Context, fetch Fetcher, perPage, want int) bool {
+	t.Helper()
+
+	problems, err := Violations(ctx, fetch, perPage, want)
+	if err != nil {
+		t.Errorf("Pagination check failed to fetch: %v", err)
+		return false
+	}
+	if len(problems) == 0 {
+		return true
+	}
+	t.Errorf("Pagination contract violated (%d problems):\n%s", len(problems), strings.Join(problems, "\n"))
+	return false
+}
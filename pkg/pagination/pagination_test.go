@@ -0,0 +1,112 @@
+package pagination
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sliceFetcher pages through items the way a conforming server would.
+func sliceFetcher(items []string) Fetcher {
+	return func(_ context.Context, page, perPage int) (Page, error) {
+		start := min((page-1)*perPage, len(items))
+		end := min(start+perPage, len(items))
+		totalPages := (len(items) + perPage - 1) / perPage
+		return Page{
+			IDs:        items[start:end],
+			Total:      len(items),
+			Page:       page,
+			PerPage:    perPage,
+			TotalPages: totalPages,
+			HasMore:    page < totalPages,
+		}, nil
+	}
+}
+
+func makeItems(n int) []string {
+	items := make([]string, n)
+	for i := range items {
+		items[i] = fmt.Sprintf("item-%d", i)
+	}
+	return items
+}
+
+func TestViolationsConforming(t *testing.T) {
+	for _, n := range []int{0, 1, 3, 7, 9} {
+		problems, err := Violations(context.Background(), sliceFetcher(makeItems(n)), 3, n)
+		require.NoError(t, err)
+		assert.Empty(t, problems, "%d items", n)
+	}
+
+	problems, err := Violations(context.Background(), sliceFetcher(makeItems(5)), 2, AnyTotal)
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+}
+
+func TestViolationsDetected(t *testing.T) {
+	ctx := context.Background()
+	items := makeItems(7)
+
+	t.Run("WrongTotal", func(t *testing.T) {
+		problems, err := Violations(ctx, sliceFetcher(items), 3, 8)
+		require.NoError(t, err)
+		assert.Contains(t, problems, "total is 7, want 8")
+	})
+
+	t.Run("HasMoreOnLastPage", func(t *testing.T) {
+		fetch := func(ctx context.Context, page, perPage int) (Page, error) {
+			p, err := sliceFetcher(items)(ctx, page, perPage)
+			p.HasMore = true
+			return p, err
+		}
+		problems, err := Violations(ctx, fetch, 3, 7)
+		require.NoError(t, err)
+		assert.Contains(t, problems, "page 3 of 3: hasMore is true")
+	})
+
+	t.Run("OverlappingPages", func(t *testing.T) {
+		// Off-by-one offset: each page repeats the last item of the previous one
+		fetch := func(ctx context.Context, page, perPage int) (Page, error) {
+			p, err := sliceFetcher(items)(ctx, page, perPage)
+			if page > 3 {
+				return p, err
+			}
+			start := max((page-1)*perPage-1, 0)
+			p.IDs = items[start:min(start+perPage, len(items))]
+			return p, err
+		}
+		problems, err := Violations(ctx, fetch, 3, 7)
+		require.NoError(t, err)
+		assert.Contains(t, problems, "page 2: item item-2 already returned on page 1")
+		assert.Contains(t, problems, "page 3: 2 items, want 1")
+	})
+
+	t.Run("UnstableOrder", func(t *testing.T) {
+		calls := 0
+		fetch := func(ctx context.Context, page, perPage int) (Page, error) {
+			calls++
+			if calls > 1 {
+				reversed := make([]string, len(items))
+				for i, id := range items {
+					reversed[len(items)-1-i] = id
+				}
+				return sliceFetcher(reversed)(ctx, page, perPage)
+			}
+			return sliceFetcher(items)(ctx, page, perPage)
+		}
+		problems, err := Violations(ctx, fetch, 7, 7)
+		require.NoError(t, err)
+		assert.Contains(t, problems, "page 1 changed between two identical requests")
+	})
+
+	t.Run("FetchError", func(t *testing.T) {
+		fetch := func(context.Context, int, int) (Page, error) {
+			return Page{}, fmt.Errorf("boom")
+		}
+		_, err := Violations(ctx, fetch, 3, 7)
+		assert.EqualError(t, err, "page 1: boom")
+	})
+}
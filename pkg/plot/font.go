@@ -0,0 +1,96 @@
+package plot
+
+import (
+	"image"
+	"image/color"
+	"strings"
+)
+
+// glyphs is a 3x5 pixel font, rows top to bottom. Lowercase letters are
+// drawn as capitals and characters without a glyph as '?'.
+var glyphs = map[rune][5]string{
+	'0': {"###", "#.#", "#.#", "#.#", "###"},
+	'1': {".#.", "##.", ".#.", ".#.", "###"},
+	'2': {"###", "..#", "###", "#..", "###"},
+	'3': {"###", "..#", ".##", "..#", "###"},
+	'4': {"#.#", "#.#", "###", "..#", "..#"},
+	'5': {"###", "#..", "###", "..#", "###"},
+	'6': {"###", "#..", "###", "#.#", "###"},
+	'7': {"###", "..#", "..#", ".#.", ".#."},
+	'8': {"###", "#.#", "###", "#.#", "###"},
+	'9': {"###", "#.#", "###", "..#", "###"},
+	'A': {".#.", "#.#", "###", "#.#", "#.#"},
+	'B': {"##.", "#.#", "##.", "#.#", "##."},
+	'C': {".##", "#..", "#..", "#..", ".##"},
+	'D': {"##.", "#.#", "#.#", "#.#", "##."},
+	'E': {"###", "#..", "##.", "#..", "###"},
+	'F': {"###", "#..", "##.", "#..", "#.."},
+	'G': {".##", "#..", "#.#", "#.#", ".##"},
+	'H': {"#.#", "#.#", "###", "#.#", "#.#"},
+	'I': {"###", ".#.", ".#.", ".#.", "###"},
+	'J': {"..#", "..#", "..#", "#.#", ".#."},
+	'K': {"#.#", "#.#", "##.", "#.#", "#.#"},
+	'L': {"#..", "#..", "#..", "#..", "###"},
+	'M': {"#.#", "###", "###", "#.#", "#.#"},
+	'N': {"##.", "#.#", "#.#", "#.#", "#.#"},
+	'O': {".#.", "#.#", "#.#", "#.#", ".#."},
+	'P': {"##.", "#.#", "##.", "#..", "#.."},
+	'Q': {".#.", "#.#", "#.#", "##.", ".##"},
+	'R': {"##.", "#.#", "##.", "#.#", "#.#"},
+	'S': {".##", "#..", ".#.", "..#", "##."},
+	'T': {"###", ".#.", ".#.", ".#.", ".#."},
+	'U': {"#.#", "#.#", "#.#", "#.#", "###"},
+	'V': {"#.#", "#.#", "#.#", "#.#", ".#."},
+	'W': {"#.#", "#.#", "###", "###", "#.#"},
+	'X': {"#.#", "#.#", ".#.", "#.#", "#.#"},
+	'Y': {"#.#", "#.#", ".#.", ".#.", ".#."},
+	'Z': {"###", "..#", ".#.", "#..", "###"},
+	' ': {"...", "...", "...", "...", "..."},
+	'.': {"...", "...", "...", "...", ".#."},
+	',': {"...", "...", "...", ".#.", "#.."},
+	'-': {"...", "...", "###", "...", "..."},
+	'_': {"...", "...", "...", "...", "###"},
+	':': {"...", ".#.", "...", ".#.", "..."},
+	'=': {"...", "###", "...", "###", "..."},
+	'/': {"..#", "..#", ".#.", "#..", "#.."},
+	'(': {".#.", "#..", "#..", "#..", ".#."},
+	')': {".#.", "..#", "..#", "..#", ".#."},
+	'%': {"#.#", "..#", ".#.", "#..", "#.#"},
+	'?': {"###", "..#", ".#.", "...", ".#."},
+}
+
+const (
+	// textScale is the size of a font pixel in image pixels.
+	textScale = 2
+	// charWidth and charHeight are the advance and height of a character.
+	charWidth  = 4 * textScale
+	charHeight = 5 * textScale
+)
+
+// textWidth is the width drawText uses for s.
+func textWidth(s string) int {
+	return len([]rune(s)) * charWidth
+}
+
+// drawText draws s with its top left corner at (x, y).
+func drawText(img *image.RGBA, x, y int, s string, c color.Color) {
+	for _, r := range strings.ToUpper(s) {
+		g, ok := glyphs[r]
+		if !ok {
+			g = glyphs['?']
+		}
+		for row, line := range g {
+			for col, px := range line {
+				if px != '#' {
+					continue
+				}
+				for dy := 0; dy < textScale; dy++ {
+					for dx := 0; dx < textScale; dx++ {
+						img.Set(x+col*textScale+dx, y+row*textScale+dy, c)
+					}
+				}
+			}
+		}
+		x += charWidth
+	}
+}
@@ -0,0 +1,299 @@
+// Package plot renders channel-vs-time line charts of captured DMX as PNG,
+// so a failing fade or effect can be looked at instead of read as arrays of
+// numbers. It uses only the standard library:
+//
+//	chart := plot.Chart{Title: "Fade to 255", Series: plot.Channels(frames, 0, 1, 2, 3)}
+//	err := chart.WriteFile("fade.png")
+//
+// pkg/artifacts adds charts of every capture to failing tests' artifacts.
+package plot
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+)
+
+// Default chart size in pixels.
+const (
+	DefaultWidth  = 900
+	DefaultHeight = 400
+)
+
+// Point is a value at a time, measured from the start of the chart.
+type Point struct {
+	At    time.Duration
+	Value float64
+}
+
+// Series is one line of a chart.
+type Series struct {
+	Name   string
+	Points []Point
+}
+
+// Chart is a line chart of values over time. The zero value of YMin and
+// YMax plots the DMX range 0-255; Width and Height default to
+// DefaultWidth and DefaultHeight.
+type Chart struct {
+	Title         string
+	Series        []Series
+	YMin, YMax    float64
+	Width, Height int
+}
+
+// palette colors series in order, repeating after eight.
+var palette = []color.RGBA{
+	{0xd6, 0x27, 0x28, 0xff},
+	{0x1f, 0x77, 0xb4, 0xff},
+	{0x2c, 0xa0, 0x2c, 0xff},
+	{0xff, 0x7f, 0x0e, 0xff},
+	{0x94, 0x67, 0xbd, 0xff},
+	{0x8c, 0x56, 0x4b, 0xff},
+	{0x17, 0xbe, 0xcf, 0xff},
+	{0x7f, 0x7f, 0x7f, 0xff},
+}
+
+var (
+	background = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	gridColor  = color.RGBA{0xe0, 0xe0, 0xe0, 0xff}
+	axisColor  = color.RGBA{0x40, 0x40, 0x40, 0xff}
+)
+
+// Margins around the plot area, leaving room for the title, axis labels and legend.
+const (
+	marginLeft   = 40
+	marginTop    = 24
+	marginBottom = 24
+	legendWidth  = 120
+	ticks        = 5
+)
+
+// Channels returns a series per DMX channel (1-indexed) of universe
+// (Art-Net numbering), with times measured from the universe's first frame.
+func Channels(frames []artnet.Frame, universe int, channels ...int) []Series {
+	var start time.Time
+	series := make([]Series, len(channels))
+	for i, ch := range channels {
+		series[i].Name = fmt.Sprintf("ch %d", ch)
+	}
+	for _, f := range frames {
+		if f.Universe != universe {
+			continue
+		}
+		if start.IsZero() {
+			start = f.Timestamp
+		}
+		for i, ch := range channels {
+			if ch < 1 || ch > artnet.DMXChannels {
+				continue
+			}
+			series[i].Points = append(series[i].Points, Point{At: f.Timestamp.Sub(start), Value: float64(f.Channels[ch-1])})
+		}
+	}
+	return series
+}
+
+// ChangedChannels returns up to max channels (1-indexed) of universe whose
+// value changed during frames, those changing most first and then in
+// channel order.
+func ChangedChannels(frames []artnet.Frame, universe, max int) []int {
+	var low, high [artnet.DMXChannels]byte
+	seen := false
+	for _, f := range frames {
+		if f.Universe != universe {
+			continue
+		}
+		for i, v := range f.Channels {
+			if !seen || v < low[i] {
+				low[i] = v
+			}
+			if !seen || v > high[i] {
+				high[i] = v
+			}
+		}
+		seen = true
+	}
+
+	var changed []int
+	for i := range high {
+		if high[i] != low[i] {
+			changed = append(changed, i+1)
+		}
+	}
+	sort.SliceStable(changed, func(i, j int) bool {
+		return high[changed[i]-1]-low[changed[i]-1] > high[changed[j]-1]-low[changed[j]-1]
+	})
+	if len(changed) > max {
+		changed = changed[:max]
+	}
+	sort.Ints(changed)
+	return changed
+}
+
+// Samples returns a series of values taken every interval, such as a test
+// polling dmxOutput.
+func Samples(name string, interval time.Duration, values []int) Series {
+	s := Series{Name: name, Points: make([]Point, len(values))}
+	for i, v := range values {
+		s.Points[i] = Point{At: time.Duration(i) * interval, Value: float64(v)}
+	}
+	return s
+}
+
+// Render draws the chart.
+func (c Chart) Render() *image.RGBA {
+	width, height := c.Width, c.Height
+	if width <= 0 {
+		width = DefaultWidth
+	}
+	if height <= 0 {
+		height = DefaultHeight
+	}
+	yMin, yMax := c.YMin, c.YMax
+	if yMin == 0 && yMax == 0 {
+		yMax = 255
+	}
+	var duration time.Duration
+	for _, s := range c.Series {
+		for _, p := range s.Points {
+			if p.At > duration {
+				duration = p.At
+			}
+		}
+	}
+	if duration == 0 {
+		duration = time.Second
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fill(img, img.Bounds(), background)
+	area := image.Rect(marginLeft, marginTop, width-legendWidth, height-marginBottom)
+	x := func(at time.Duration) int {
+		return area.Min.X + int(math.Round(float64(at)/float64(duration)*float64(area.Dx()-1)))
+	}
+	y := func(v float64) int {
+		frac := (v - yMin) / (yMax - yMin)
+		return area.Max.Y - 1 - int(math.Round(math.Max(0, math.Min(1, frac))*float64(area.Dy()-1)))
+	}
+
+	drawText(img, marginLeft, (marginTop-charHeight)/2, c.Title, axisColor)
+	for i := 0; i <= ticks; i++ {
+		v := yMin + (yMax-yMin)*float64(i)/ticks
+		py := y(v)
+		line(img, area.Min.X, py, area.Max.X-1, py, gridColor)
+		label := trimFloat(v)
+		drawText(img, area.Min.X-textWidth(label)-4, py-charHeight/2, label, axisColor)
+
+		at := duration * time.Duration(i) / ticks
+		px := x(at)
+		line(img, px, area.Min.Y, px, area.Max.Y-1, gridColor)
+		label = trimFloat(at.Seconds()) + "s"
+		lx := px - textWidth(label)/2
+		if lx+textWidth(label) > area.Max.X {
+			lx = area.Max.X - textWidth(label)
+		}
+		drawText(img, lx, area.Max.Y+4, label, axisColor)
+	}
+	line(img, area.Min.X, area.Min.Y, area.Min.X, area.Max.Y-1, axisColor)
+	line(img, area.Min.X, area.Max.Y-1, area.Max.X-1, area.Max.Y-1, axisColor)
+
+	for i, s := range c.Series {
+		col := palette[i%len(palette)]
+		for j := 1; j < len(s.Points); j++ {
+			a, b := s.Points[j-1], s.Points[j]
+			line(img, x(a.At), y(a.Value), x(b.At), y(b.Value), col)
+		}
+		if len(s.Points) == 1 {
+			p := s.Points[0]
+			fill(img, image.Rect(x(p.At)-1, y(p.Value)-1, x(p.At)+2, y(p.Value)+2), col)
+		}
+
+		ly := area.Min.Y + i*(charHeight+6)
+		fill(img, image.Rect(area.Max.X+10, ly, area.Max.X+22, ly+charHeight), col)
+		drawText(img, area.Max.X+28, ly, s.Name, axisColor)
+	}
+	return img
+}
+
+// WritePNG encodes the chart as PNG.
+func (c Chart) WritePNG(w io.Writer) error {
+	return png.Encode(w, c.Render())
+}
+
+// WriteFile writes the chart as a PNG file.
+func (c Chart) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := c.WritePNG(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// trimFloat formats v with up to two decimals and no trailing zeros.
+func trimFloat(v float64) string {
+	s := fmt.Sprintf("%.2f", v)
+	for s[len(s)-1] == '0' {
+		s = s[:len(s)-1]
+	}
+	if s[len(s)-1] == '.' {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+func fill(img *image.RGBA, r image.Rectangle, c color.Color) {
+	r = r.Intersect(img.Bounds())
+	for py := r.Min.Y; py < r.Max.Y; py++ {
+		for px := r.Min.X; px < r.Max.X; px++ {
+			img.Set(px, py, c)
+		}
+	}
+}
+
+// line draws from (x0, y0) to (x1, y1) with Bresenham's algorithm.
+func line(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		img.Set(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,85 @@
+package plot
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fadeFrames fades channel 1 of universe 0 up over a second, with channel 3
+// stepping down halfway and channel 2 fixed.
+func fadeFrames() []artnet.Frame {
+	start := time.Now()
+	var frames []artnet.Frame
+	for i := 0; i <= 40; i++ {
+		f := artnet.Frame{Timestamp: start.Add(time.Duration(i) * 25 * time.Millisecond)}
+		f.Channels[0] = byte(i * 255 / 40)
+		f.Channels[1] = 128
+		f.Channels[2] = 200
+		if i >= 20 {
+			f.Channels[2] = 190
+		}
+		frames = append(frames, f)
+		frames = append(frames, artnet.Frame{Timestamp: f.Timestamp, Universe: 1})
+	}
+	return frames
+}
+
+func TestChannels(t *testing.T) {
+	series := Channels(fadeFrames(), 0, 1, 2, 600)
+	require.Len(t, series, 3)
+	assert.Equal(t, "ch 1", series[0].Name)
+	require.Len(t, series[0].Points, 41, "Only the universe's frames are charted")
+	assert.Equal(t, Point{At: 0, Value: 0}, series[0].Points[0])
+	assert.Equal(t, Point{At: time.Second, Value: 255}, series[0].Points[40])
+	assert.Equal(t, 128.0, series[1].Points[10].Value)
+	assert.Empty(t, series[2].Points, "Channels outside the universe are empty")
+}
+
+func TestChangedChannels(t *testing.T) {
+	frames := fadeFrames()
+	assert.Equal(t, []int{1, 3}, ChangedChannels(frames, 0, 8))
+	assert.Equal(t, []int{1}, ChangedChannels(frames, 0, 1), "The channels changing most are kept")
+	assert.Empty(t, ChangedChannels(frames, 1, 8))
+	assert.Empty(t, ChangedChannels(frames, 5, 8))
+}
+
+func TestSamples(t *testing.T) {
+	s := Samples("dimmer", 100*time.Millisecond, []int{10, 20, 30})
+	assert.Equal(t, []Point{{0, 10}, {100 * time.Millisecond, 20}, {200 * time.Millisecond, 30}}, s.Points)
+}
+
+func TestRender(t *testing.T) {
+	chart := Chart{Title: "Fade up", Series: Channels(fadeFrames(), 0, 1, 3), Width: 300, Height: 200}
+	img := chart.Render()
+	assert.Equal(t, 300, img.Bounds().Dx())
+	assert.Equal(t, 200, img.Bounds().Dy())
+
+	// The fade runs from the bottom left to the top right of the plot area
+	area := [2]int{marginLeft, 200 - marginBottom - 1}
+	assert.Equal(t, palette[0], img.RGBAAt(area[0], area[1]), "The fade starts at 0")
+	assert.Equal(t, palette[0], img.RGBAAt(300-legendWidth-1, marginTop), "The fade ends at 255")
+	assert.Equal(t, palette[1], img.RGBAAt(300-legendWidth+10, marginTop+charHeight+6), "The legend shows the second series")
+
+	var buf bytes.Buffer
+	require.NoError(t, chart.WritePNG(&buf))
+	decoded, err := png.Decode(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, img.Bounds(), decoded.Bounds())
+
+	empty := Chart{}.Render()
+	assert.Equal(t, DefaultWidth, empty.Bounds().Dx())
+	assert.Equal(t, color.RGBA{0xff, 0xff, 0xff, 0xff}, empty.RGBAAt(DefaultWidth-1, 0))
+}
+
+func TestTrimFloat(t *testing.T) {
+	for v, want := range map[float64]string{0: "0", 0.5: "0.5", 1.25: "1.25", 255: "255", 51.2: "51.2"} {
+		assert.Equal(t, want, trimFloat(v))
+	}
+}
@@ -0,0 +1,185 @@
+// Package rdm provides a mock RDM responder reachable over Art-Net, so the
+// server's RDM discovery can be tested without physical fixtures.
+//
+// The responder answers ArtPoll, ArtTodRequest, ArtTodControl and ArtRdm
+// packets for a configured set of devices, following Art-Net 4 and ANSI E1.20.
+package rdm
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// UID is a 48-bit RDM unique ID: a 16-bit ESTA manufacturer ID and a 32-bit device ID.
+type UID [6]byte
+
+// BroadcastUID addresses every device on a port.
+var BroadcastUID = UID{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
+
+// NewUID builds a UID from its manufacturer and device parts.
+func NewUID(manufacturer uint16, device uint32) UID {
+	var u UID
+	binary.BigEndian.PutUint16(u[0:2], manufacturer)
+	binary.BigEndian.PutUint32(u[2:6], device)
+	return u
+}
+
+// ParseUID parses the conventional "MMMM:DDDDDDDD" hex form.
+func ParseUID(s string) (UID, error) {
+	manufacturer, device, ok := strings.Cut(s, ":")
+	if !ok || len(manufacturer) != 4 || len(device) != 8 {
+		return UID{}, fmt.Errorf("invalid RDM UID %q: want MMMM:DDDDDDDD", s)
+	}
+	m, err := strconv.ParseUint(manufacturer, 16, 16)
+	if err != nil {
+		return UID{}, fmt.Errorf("invalid RDM UID %q: %w", s, err)
+	}
+	d, err := strconv.ParseUint(device, 16, 32)
+	if err != nil {
+		return UID{}, fmt.Errorf("invalid RDM UID %q: %w", s, err)
+	}
+	return NewUID(uint16(m), uint32(d)), nil
+}
+
+// Manufacturer returns the ESTA manufacturer ID.
+func (u UID) Manufacturer() uint16 {
+	return binary.BigEndian.Uint16(u[0:2])
+}
+
+// Device returns the device ID.
+func (u UID) Device() uint32 {
+	return binary.BigEndian.Uint32(u[2:6])
+}
+
+// String formats the UID as "MMMM:DDDDDDDD" in upper-case hex.
+func (u UID) String() string {
+	return fmt.Sprintf("%04X:%08X", u.Manufacturer(), u.Device())
+}
+
+const (
+	// StartCode is the DMX start code of RDM packets; Art-Net leaves it off the wire
+	StartCode = 0xCC
+
+	// SubStartCode is the second byte of every RDM message
+	SubStartCode = 0x01
+
+	// headerLength is the RDM message length without parameter data, start code included
+	headerLength = 24
+)
+
+// Command classes
+const (
+	CCDiscovery         = 0x10
+	CCDiscoveryResponse = 0x11
+	CCGet               = 0x20
+	CCGetResponse       = 0x21
+	CCSet               = 0x30
+	CCSetResponse       = 0x31
+)
+
+// Response types
+const (
+	ResponseAck        = 0x00
+	ResponseNackReason = 0x02
+)
+
+// NACK reason codes
+const (
+	NackUnknownPID     = 0x0000
+	NackFormatError    = 0x0001
+	NackUnsupportedCC  = 0x0005
+	NackDataOutOfRange = 0x0006
+)
+
+// Parameter IDs the responder supports
+const (
+	PIDSupportedParameters    = 0x0050
+	PIDDeviceInfo             = 0x0060
+	PIDDeviceModelDescription = 0x0080
+	PIDManufacturerLabel      = 0x0081
+	PIDDeviceLabel            = 0x0082
+	PIDDMXStartAddress        = 0x00F0
+	PIDIdentifyDevice         = 0x1000
+)
+
+// Packet is an RDM message.
+type Packet struct {
+	Destination  UID
+	Source       UID
+	Transaction  byte
+	PortID       byte // the response type in responses
+	MessageCount byte
+	SubDevice    uint16
+	CommandClass byte
+	PID          uint16
+	Data         []byte
+}
+
+// checksum is the 16-bit sum of every byte up to the checksum, start code included.
+func checksum(message []byte) uint16 {
+	var sum uint16
+	for _, b := range message {
+		sum += uint16(b)
+	}
+	return sum
+}
+
+// Encode returns the packet as carried in ArtRdm: without the start code,
+// with the checksum.
+func (p Packet) Encode() []byte {
+	message := make([]byte, headerLength+len(p.Data), headerLength+len(p.Data)+2)
+	message[0] = StartCode
+	message[1] = SubStartCode
+	message[2] = byte(headerLength + len(p.Data))
+	copy(message[3:9], p.Destination[:])
+	copy(message[9:15], p.Source[:])
+	message[15] = p.Transaction
+	message[16] = p.PortID
+	message[17] = p.MessageCount
+	binary.BigEndian.PutUint16(message[18:20], p.SubDevice)
+	message[20] = p.CommandClass
+	binary.BigEndian.PutUint16(message[21:23], p.PID)
+	message[23] = byte(len(p.Data))
+	copy(message[24:], p.Data)
+	message = binary.BigEndian.AppendUint16(message, checksum(message))
+	return message[1:]
+}
+
+// Decode parses an RDM packet as carried in ArtRdm, without the start code.
+// A payload that starts with the start code is accepted too.
+func Decode(data []byte) (Packet, error) {
+	if len(data) > 0 && data[0] == StartCode {
+		data = data[1:]
+	}
+	message := append([]byte{StartCode}, data...)
+
+	if len(message) < headerLength+2 {
+		return Packet{}, fmt.Errorf("rdm packet too short: %d bytes", len(data))
+	}
+	if message[1] != SubStartCode {
+		return Packet{}, fmt.Errorf("rdm sub start code 0x%02X", message[1])
+	}
+	length := int(message[2])
+	pdl := int(message[23])
+	if length != headerLength+pdl || len(message) < length+2 {
+		return Packet{}, fmt.Errorf("rdm message length %d does not fit parameter data %d in %d bytes", length, pdl, len(data))
+	}
+	if got, want := binary.BigEndian.Uint16(message[length:length+2]), checksum(message[:length]); got != want {
+		return Packet{}, fmt.Errorf("rdm checksum 0x%04X, want 0x%04X", got, want)
+	}
+
+	p := Packet{
+		Transaction:  message[15],
+		PortID:       message[16],
+		MessageCount: message[17],
+		SubDevice:    binary.BigEndian.Uint16(message[18:20]),
+		CommandClass: message[20],
+		PID:          binary.BigEndian.Uint16(message[21:23]),
+		Data:         append([]byte(nil), message[24:length]...),
+	}
+	copy(p.Destination[:], message[3:9])
+	copy(p.Source[:], message[9:15])
+	return p, nil
+}
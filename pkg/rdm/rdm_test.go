@@ -0,0 +1,280 @@
+package rdm
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	parUID    = NewUID(0x7A70, 0x00000101)
+	dimmerUID = NewUID(0x7A70, 0x00000102)
+	otherUID  = NewUID(0x0001, 0x000000FF)
+	hostUID   = NewUID(0x7A70, 0xFFFFFFF0)
+)
+
+func testResponder() *Responder {
+	return NewResponder(nil,
+		Device{UID: parUID, Universe: 1, ManufacturerLabel: "LacyLights", ModelDescription: "RDM Par",
+			DeviceLabel: "Stage Left", ModelID: 0x0042, Footprint: 4, StartAddress: 1},
+		Device{UID: dimmerUID, Universe: 1, ManufacturerLabel: "LacyLights", ModelDescription: "RDM Dimmer",
+			ModelID: 0x0043, Footprint: 1, StartAddress: 20},
+		Device{UID: otherUID, Universe: 2, ManufacturerLabel: "Other", ModelDescription: "Far Away",
+			Footprint: 8, StartAddress: 100},
+	)
+}
+
+// rdmRequest wraps an RDM request to the device on universe 1.
+func rdmRequest(destination UID, cc byte, pid uint16, data []byte) []byte {
+	return artRdm(0, Packet{
+		Destination:  destination,
+		Source:       hostUID,
+		Transaction:  7,
+		PortID:       1,
+		CommandClass: cc,
+		PID:          pid,
+		Data:         data,
+	}.Encode())
+}
+
+// decodeReply unwraps the single ArtRdm reply to a request.
+func decodeReply(t *testing.T, replies [][]byte) Packet {
+	t.Helper()
+	require.Len(t, replies, 1)
+	require.Equal(t, uint16(OpRdm), binary.LittleEndian.Uint16(replies[0][8:10]))
+	p, err := Decode(replies[0][24:])
+	require.NoError(t, err)
+	return p
+}
+
+func TestUID(t *testing.T) {
+	assert.Equal(t, "7A70:00000101", parUID.String())
+	assert.Equal(t, uint16(0x7A70), parUID.Manufacturer())
+	assert.Equal(t, uint32(0x101), parUID.Device())
+
+	parsed, err := ParseUID("7a70:00000101")
+	require.NoError(t, err)
+	assert.Equal(t, parUID, parsed)
+
+	for _, bad := range []string{"", "7A70", "7A70-00000101", "7A70:101", "ZZZZ:00000101"} {
+		_, err := ParseUID(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestPacketRoundTrip(t *testing.T) {
+	p := Packet{
+		Destination:  parUID,
+		Source:       hostUID,
+		Transaction:  3,
+		PortID:       1,
+		SubDevice:    0,
+		CommandClass: CCSet,
+		PID:          PIDDMXStartAddress,
+		Data:         []byte{0x00, 0x64},
+	}
+	encoded := p.Encode()
+	assert.Equal(t, byte(SubStartCode), encoded[0], "Art-Net leaves off the start code")
+	assert.Equal(t, byte(headerLength+2), encoded[1])
+
+	decoded, err := Decode(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, p, decoded)
+
+	withStartCode, err := Decode(append([]byte{StartCode}, encoded...))
+	require.NoError(t, err)
+	assert.Equal(t, p, withStartCode)
+
+	t.Run("Rejects", func(t *testing.T) {
+		badChecksum := append([]byte{}, encoded...)
+		badChecksum[len(badChecksum)-1]++
+
+		badLength := append([]byte{}, encoded...)
+		badLength[1] = 40
+
+		for name, data := range map[string][]byte{
+			"Empty":       nil,
+			"Truncated":   encoded[:20],
+			"BadChecksum": badChecksum,
+			"BadLength":   badLength,
+		} {
+			_, err := Decode(data)
+			assert.Error(t, err, name)
+		}
+	})
+}
+
+func TestResponderPoll(t *testing.T) {
+	r := testResponder()
+	replies := r.handle(header(OpPoll, 14), net.IPv4(127, 0, 0, 1))
+	require.Len(t, replies, 2, "One reply per universe with devices")
+
+	for i, reply := range replies {
+		require.Len(t, reply, pollReplyLength)
+		assert.Equal(t, uint16(OpPollReply), binary.LittleEndian.Uint16(reply[8:10]))
+		assert.Equal(t, []byte{127, 0, 0, 1}, reply[10:14])
+		assert.Equal(t, byte(0x02), reply[23]&0x02, "Status1 should advertise RDM")
+		assert.Equal(t, byte(i), reply[190], "SwOut is the Art-Net universe")
+	}
+	assert.Equal(t, 1, r.RequestCount(OpPoll))
+}
+
+func TestResponderSharesManager(t *testing.T) {
+	t.Setenv("ARTNET_IMPAIR", "")
+	m := artnet.NewManager("127.0.0.1:0")
+	capture, err := m.Subscribe()
+	require.NoError(t, err)
+	defer capture.Close()
+
+	r := NewResponder(m, Device{UID: parUID, Universe: 1, Footprint: 4, StartAddress: 1})
+	require.NoError(t, r.Start(), "The responder should join the bound socket, not bind its own")
+	assert.Equal(t, 2, m.Subscribers())
+
+	conn, err := net.DialUDP("udp", nil, m.Addr().(*net.UDPAddr))
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+	_, err = conn.Write(header(OpPoll, 14))
+	require.NoError(t, err)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	reply := make([]byte, 512)
+	n, err := conn.Read(reply)
+	require.NoError(t, err)
+	require.Equal(t, pollReplyLength, n)
+	assert.Equal(t, uint16(OpPollReply), binary.LittleEndian.Uint16(reply[8:10]))
+	assert.Equal(t, []byte{127, 0, 0, 1}, reply[10:14], "The reply advertises the socket's address")
+
+	dmx := header(artnet.OpDMX, 20)
+	binary.BigEndian.PutUint16(dmx[16:18], 2)
+	_, err = conn.Write(dmx)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return len(capture.GetFrames()) == 1 }, time.Second, 10*time.Millisecond,
+		"Captures on the manager still see DMX")
+
+	require.NoError(t, r.Stop())
+	assert.Equal(t, 1, m.Subscribers(), "Stopping the responder leaves the capture's socket bound")
+}
+
+func TestResponderTod(t *testing.T) {
+	r := testResponder()
+
+	request := header(OpTodRequest, 26)
+	request[22] = todCommandFull
+	request[23] = 2 // AdCount
+	request[24] = 0
+	request[25] = 1
+
+	replies := r.handle(request, nil)
+	require.Len(t, replies, 2)
+
+	uids := func(packet []byte) []UID {
+		var out []UID
+		for i := 0; i < int(packet[27]); i++ {
+			var u UID
+			copy(u[:], packet[28+6*i:])
+			out = append(out, u)
+		}
+		return out
+	}
+	assert.Equal(t, byte(0), replies[0][23])
+	assert.Equal(t, []UID{parUID, dimmerUID}, uids(replies[0]))
+	assert.Equal(t, byte(1), replies[1][23])
+	assert.Equal(t, []UID{otherUID}, uids(replies[1]))
+
+	t.Run("FlushRepliesForOnePort", func(t *testing.T) {
+		flush := header(OpTodControl, 24)
+		flush[22] = todControlFlush
+		flush[23] = 1
+		replies := r.handle(flush, nil)
+		require.Len(t, replies, 1)
+		assert.Equal(t, []UID{otherUID}, uids(replies[0]))
+	})
+
+	t.Run("EmptyPortStillAnswers", func(t *testing.T) {
+		request[23] = 1
+		request[24] = 9
+		replies := r.handle(request[:25], nil)
+		require.Len(t, replies, 1)
+		assert.Empty(t, uids(replies[0]))
+	})
+}
+
+func TestResponderRdm(t *testing.T) {
+	r := testResponder()
+
+	t.Run("DeviceInfo", func(t *testing.T) {
+		p := decodeReply(t, r.handle(rdmRequest(parUID, CCGet, PIDDeviceInfo, nil), nil))
+		assert.Equal(t, hostUID, p.Destination)
+		assert.Equal(t, parUID, p.Source)
+		assert.Equal(t, byte(7), p.Transaction)
+		assert.Equal(t, byte(ResponseAck), p.PortID)
+		assert.Equal(t, byte(CCGetResponse), p.CommandClass)
+		require.Len(t, p.Data, 19)
+		assert.Equal(t, uint16(0x0042), binary.BigEndian.Uint16(p.Data[2:4]), "model ID")
+		assert.Equal(t, uint16(4), binary.BigEndian.Uint16(p.Data[10:12]), "footprint")
+		assert.Equal(t, uint16(1), binary.BigEndian.Uint16(p.Data[14:16]), "start address")
+	})
+
+	t.Run("Labels", func(t *testing.T) {
+		for pid, want := range map[uint16]string{
+			PIDManufacturerLabel:      "LacyLights",
+			PIDDeviceModelDescription: "RDM Par",
+			PIDDeviceLabel:            "Stage Left",
+		} {
+			p := decodeReply(t, r.handle(rdmRequest(parUID, CCGet, pid, nil), nil))
+			assert.Equal(t, want, string(p.Data), "PID 0x%04X", pid)
+		}
+	})
+
+	t.Run("SetStartAddress", func(t *testing.T) {
+		p := decodeReply(t, r.handle(rdmRequest(dimmerUID, CCSet, PIDDMXStartAddress, []byte{0x01, 0x00}), nil))
+		assert.Equal(t, byte(ResponseAck), p.PortID)
+		assert.Equal(t, byte(CCSetResponse), p.CommandClass)
+
+		d, ok := r.Device(dimmerUID)
+		require.True(t, ok)
+		assert.Equal(t, uint16(256), d.StartAddress)
+	})
+
+	t.Run("NackOutOfRange", func(t *testing.T) {
+		p := decodeReply(t, r.handle(rdmRequest(parUID, CCSet, PIDDMXStartAddress, []byte{0x01, 0xFF}), nil))
+		assert.Equal(t, byte(ResponseNackReason), p.PortID)
+		assert.Equal(t, uint16(NackDataOutOfRange), binary.BigEndian.Uint16(p.Data))
+	})
+
+	t.Run("NackUnknownPID", func(t *testing.T) {
+		p := decodeReply(t, r.handle(rdmRequest(parUID, CCGet, 0x8001, nil), nil))
+		assert.Equal(t, byte(ResponseNackReason), p.PortID)
+		assert.Equal(t, uint16(NackUnknownPID), binary.BigEndian.Uint16(p.Data))
+	})
+
+	t.Run("BroadcastSetIsSilent", func(t *testing.T) {
+		replies := r.handle(rdmRequest(BroadcastUID, CCSet, PIDIdentifyDevice, []byte{1}), nil)
+		assert.Empty(t, replies)
+		for _, uid := range []UID{parUID, dimmerUID} {
+			d, _ := r.Device(uid)
+			assert.True(t, d.Identify, uid.String())
+		}
+		d, _ := r.Device(otherUID)
+		assert.False(t, d.Identify, "Devices on other universes ignore the broadcast")
+	})
+
+	t.Run("IgnoresOtherPortsAndUnknownDevices", func(t *testing.T) {
+		assert.Empty(t, r.handle(rdmRequest(otherUID, CCGet, PIDDeviceInfo, nil), nil))
+		assert.Empty(t, r.handle(rdmRequest(NewUID(0x7A70, 0xDEAD), CCGet, PIDDeviceInfo, nil), nil))
+	})
+
+	assert.Positive(t, r.RequestCount(OpRdm))
+}
+
+func TestResponderIgnoresOtherPackets(t *testing.T) {
+	r := testResponder()
+	dmx := header(0x5000, 18+512)
+	assert.Empty(t, r.handle(dmx, nil))
+	assert.Empty(t, r.handle([]byte("not art-net"), nil))
+	assert.Zero(t, r.RequestCount(0x5000))
+}
@@ -0,0 +1,423 @@
+package rdm
+
+import (
+	"encoding/binary"
+	"net"
+	"sort"
+	"sync"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+)
+
+// Art-Net opcodes the responder handles or sends
+const (
+	OpPoll       = 0x2000
+	OpPollReply  = 0x2100
+	OpTodRequest = 0x8000
+	OpTodData    = 0x8100
+	OpTodControl = 0x8200
+	OpRdm        = 0x8300
+
+	protocolVersion = 14
+	pollReplyLength = 239
+
+	// todCommandFull answers a full table of devices request
+	todCommandFull = 0x00
+	// todControlFlush asks a node to rediscover its devices
+	todControlFlush = 0x01
+
+	// maxTodUIDs is how many UIDs fit in one ArtTodData packet
+	maxTodUIDs = 200
+)
+
+// Device is a mocked RDM fixture.
+type Device struct {
+	UID UID
+	// Universe is the 1-based lacylights universe; on the wire it is Art-Net port address Universe-1
+	Universe          int
+	ManufacturerLabel string
+	ModelDescription  string
+	DeviceLabel       string
+	ModelID           uint16
+	Footprint         uint16
+	StartAddress      uint16
+	Identify          bool
+}
+
+// portAddress is the Art-Net port address of the device's universe.
+func (d *Device) portAddress() uint16 {
+	return uint16(d.Universe - 1)
+}
+
+// Responder is an Art-Net node with mocked RDM devices behind its output ports.
+// It answers on an artnet.Manager's socket, so it shares the Art-Net port with
+// any captures on the same manager instead of competing for it.
+type Responder struct {
+	manager  *artnet.Manager
+	sub      *artnet.Subscription
+	mu       sync.Mutex
+	devices  []*Device
+	requests map[uint16]int
+}
+
+// NewResponder creates a responder for the given devices on manager, or on
+// artnet.SharedManager() (see artnet.ListenAddrFromEnv) when manager is nil.
+func NewResponder(manager *artnet.Manager, devices ...Device) *Responder {
+	if manager == nil {
+		manager = artnet.SharedManager()
+	}
+	r := &Responder{manager: manager, requests: make(map[uint16]int)}
+	for _, d := range devices {
+		d := d
+		r.devices = append(r.devices, &d)
+	}
+	return r
+}
+
+// Start begins answering Art-Net packets, binding the manager's socket if no
+// capture has yet. The responder keeps none of the DMX frames it sees.
+func (r *Responder) Start() error {
+	sub, err := r.manager.Subscribe(artnet.Universes(), artnet.Respond(r.answer))
+	if err != nil {
+		return err
+	}
+	r.sub = sub
+	return nil
+}
+
+// Stop stops the responder, releasing the socket if nothing else uses it.
+func (r *Responder) Stop() error {
+	if r.sub != nil {
+		r.sub.Close()
+	}
+	return nil
+}
+
+// Device returns the current state of a device, including changes made over RDM.
+func (r *Responder) Device(uid UID) (Device, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if d := r.find(uid); d != nil {
+		return *d, true
+	}
+	return Device{}, false
+}
+
+// SetStartAddress readdresses a device, as a tech would from its menu.
+func (r *Responder) SetStartAddress(uid UID, address uint16) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if d := r.find(uid); d != nil {
+		d.StartAddress = address
+		return true
+	}
+	return false
+}
+
+// RequestCount returns how many packets with the Art-Net opcode have been handled.
+func (r *Responder) RequestCount(opcode uint16) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.requests[opcode]
+}
+
+func (r *Responder) find(uid UID) *Device {
+	for _, d := range r.devices {
+		if d.UID == uid {
+			return d
+		}
+	}
+	return nil
+}
+
+// portAddresses returns the distinct Art-Net port addresses with devices, in order.
+func (r *Responder) portAddresses() []uint16 {
+	seen := make(map[uint16]bool)
+	var ports []uint16
+	for _, d := range r.devices {
+		if pa := d.portAddress(); !seen[pa] {
+			seen[pa] = true
+			ports = append(ports, pa)
+		}
+	}
+	sort.Slice(ports, func(i, j int) bool { return ports[i] < ports[j] })
+	return ports
+}
+
+// answer is the responder's artnet.Handler.
+func (r *Responder) answer(packet []byte, local, _ *net.UDPAddr) [][]byte {
+	return r.handle(packet, localIP(local))
+}
+
+// localIP is the address advertised in ArtPollReply.
+func localIP(local *net.UDPAddr) net.IP {
+	if local != nil && local.IP != nil && !local.IP.IsUnspecified() {
+		return local.IP
+	}
+	return net.IPv4(127, 0, 0, 1)
+}
+
+// handle answers one Art-Net packet and returns the replies to send back.
+func (r *Responder) handle(packet []byte, ip net.IP) [][]byte {
+	if len(packet) < 12 || string(packet[:8]) != "Art-Net\x00" {
+		return nil
+	}
+	opcode := binary.LittleEndian.Uint16(packet[8:10])
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch opcode {
+	case OpPoll:
+		r.requests[opcode]++
+		return r.pollReplies(ip)
+
+	case OpTodRequest:
+		// Net at 21, Command at 22, AdCount at 23, Address[32] from 24
+		if len(packet) < 24 || packet[22] != todCommandFull {
+			return nil
+		}
+		r.requests[opcode]++
+		netBits := uint16(packet[21]&0x7F) << 8
+		count := int(packet[23])
+		if count > 32 || len(packet) < 24+count {
+			return nil
+		}
+		var replies [][]byte
+		for _, address := range packet[24 : 24+count] {
+			replies = append(replies, r.todData(netBits|uint16(address))...)
+		}
+		return replies
+
+	case OpTodControl:
+		// Net at 21, Command at 22, Address at 23
+		if len(packet) < 24 || packet[22] != todControlFlush {
+			return nil
+		}
+		r.requests[opcode]++
+		return r.todData(uint16(packet[21]&0x7F)<<8 | uint16(packet[23]))
+
+	case OpRdm:
+		// Net at 21, Command at 22, Address at 23, RDM packet from 24
+		if len(packet) < 25 || packet[22] != 0x00 {
+			return nil
+		}
+		r.requests[opcode]++
+		portAddress := uint16(packet[21]&0x7F)<<8 | uint16(packet[23])
+		request, err := Decode(packet[24:])
+		if err != nil {
+			return nil
+		}
+		response, ok := r.respond(portAddress, request)
+		if !ok {
+			return nil
+		}
+		return [][]byte{artRdm(portAddress, response.Encode())}
+	}
+
+	return nil
+}
+
+// header starts an Art-Net packet of the given length.
+func header(opcode uint16, length int) []byte {
+	packet := make([]byte, length)
+	copy(packet, "Art-Net\x00")
+	binary.LittleEndian.PutUint16(packet[8:10], opcode)
+	binary.BigEndian.PutUint16(packet[10:12], protocolVersion)
+	return packet
+}
+
+// pollReplies advertises one RDM-capable output port per universe with devices.
+func (r *Responder) pollReplies(ip net.IP) [][]byte {
+	var replies [][]byte
+	for i, pa := range r.portAddresses() {
+		reply := make([]byte, pollReplyLength)
+		copy(reply, "Art-Net\x00")
+		binary.LittleEndian.PutUint16(reply[8:10], OpPollReply)
+		copy(reply[10:14], ip.To4())
+		binary.LittleEndian.PutUint16(reply[14:16], artnet.ArtNetPort)
+		reply[18] = byte(pa >> 8 & 0x7F) // NetSwitch
+		reply[19] = byte(pa >> 4 & 0x0F) // SubSwitch
+		reply[23] = 0x02                 // Status1: RDM capable
+		copy(reply[26:44], "RDM Mock")
+		copy(reply[44:108], "lacylights-test RDM responder")
+		binary.BigEndian.PutUint16(reply[172:174], 1) // NumPorts
+		reply[174] = 0x80                             // PortTypes[0]: DMX512 output
+		reply[182] = 0x80                             // GoodOutput[0]: data transmitting
+		reply[190] = byte(pa & 0x0F)                  // SwOut[0]
+		reply[211] = byte(i + 1)                      // BindIndex
+		replies = append(replies, reply)
+	}
+	return replies
+}
+
+// todData lists the UIDs on a port, split across packets as needed.
+func (r *Responder) todData(portAddress uint16) [][]byte {
+	var uids []UID
+	for _, d := range r.devices {
+		if d.portAddress() == portAddress {
+			uids = append(uids, d.UID)
+		}
+	}
+
+	var replies [][]byte
+	for block := 0; block == 0 || block*maxTodUIDs < len(uids); block++ {
+		chunk := uids[block*maxTodUIDs : min(len(uids), (block+1)*maxTodUIDs)]
+		packet := header(OpTodData, 28+6*len(chunk))
+		packet[12] = 0x01 // RdmVer
+		packet[13] = 1    // Port
+		packet[21] = byte(portAddress >> 8 & 0x7F)
+		packet[22] = todCommandFull
+		packet[23] = byte(portAddress)
+		binary.BigEndian.PutUint16(packet[24:26], uint16(len(uids)))
+		packet[26] = byte(block)
+		packet[27] = byte(len(chunk))
+		for i, uid := range chunk {
+			copy(packet[28+6*i:], uid[:])
+		}
+		replies = append(replies, packet)
+	}
+	return replies
+}
+
+// artRdm wraps an encoded RDM packet for the port.
+func artRdm(portAddress uint16, rdmPacket []byte) []byte {
+	packet := header(OpRdm, 24+len(rdmPacket))
+	packet[12] = 0x01 // RdmVer
+	packet[21] = byte(portAddress >> 8 & 0x7F)
+	packet[23] = byte(portAddress)
+	copy(packet[24:], rdmPacket)
+	return packet
+}
+
+// respond answers an RDM request for a device on the port. Broadcast SETs
+// are applied to every device on the port without a response.
+func (r *Responder) respond(portAddress uint16, request Packet) (Packet, bool) {
+	if request.Destination == BroadcastUID {
+		if request.CommandClass == CCSet {
+			for _, d := range r.devices {
+				if d.portAddress() == portAddress {
+					d.apply(request)
+				}
+			}
+		}
+		return Packet{}, false
+	}
+
+	d := r.find(request.Destination)
+	if d == nil || d.portAddress() != portAddress {
+		return Packet{}, false
+	}
+
+	response := Packet{
+		Destination:  request.Source,
+		Source:       d.UID,
+		Transaction:  request.Transaction,
+		PortID:       ResponseAck,
+		SubDevice:    request.SubDevice,
+		CommandClass: request.CommandClass + 1,
+		PID:          request.PID,
+	}
+
+	var nack uint16
+	switch request.CommandClass {
+	case CCGet:
+		response.Data, nack = d.get(request.PID)
+	case CCSet:
+		nack = d.apply(request)
+	default:
+		nack = NackUnsupportedCC
+	}
+
+	if nack != noNack {
+		response.PortID = ResponseNackReason
+		response.Data = binary.BigEndian.AppendUint16(nil, nack)
+	}
+	return response, true
+}
+
+// noNack marks a request that was acknowledged.
+const noNack = 0xFFFF
+
+// get returns the parameter data for a GET.
+func (d *Device) get(pid uint16) ([]byte, uint16) {
+	switch pid {
+	case PIDSupportedParameters:
+		var data []byte
+		for _, p := range []uint16{PIDDeviceModelDescription, PIDManufacturerLabel, PIDDeviceLabel} {
+			data = binary.BigEndian.AppendUint16(data, p)
+		}
+		return data, noNack
+
+	case PIDDeviceInfo:
+		data := make([]byte, 19)
+		binary.BigEndian.PutUint16(data[0:2], 0x0100) // RDM protocol 1.0
+		binary.BigEndian.PutUint16(data[2:4], d.ModelID)
+		binary.BigEndian.PutUint16(data[4:6], 0x0101) // Product category: fixed fixture
+		binary.BigEndian.PutUint32(data[6:10], 1)     // Software version
+		binary.BigEndian.PutUint16(data[10:12], d.Footprint)
+		data[12] = 1 // Current personality
+		data[13] = 1 // Personality count
+		binary.BigEndian.PutUint16(data[14:16], d.StartAddress)
+		// Sub-device count and sensor count stay zero
+		return data, noNack
+
+	case PIDDeviceModelDescription:
+		return label(d.ModelDescription), noNack
+	case PIDManufacturerLabel:
+		return label(d.ManufacturerLabel), noNack
+	case PIDDeviceLabel:
+		return label(d.DeviceLabel), noNack
+
+	case PIDDMXStartAddress:
+		return binary.BigEndian.AppendUint16(nil, d.StartAddress), noNack
+
+	case PIDIdentifyDevice:
+		if d.Identify {
+			return []byte{1}, noNack
+		}
+		return []byte{0}, noNack
+	}
+	return nil, NackUnknownPID
+}
+
+// apply performs a SET and returns its NACK reason, or noNack.
+func (d *Device) apply(request Packet) uint16 {
+	switch request.PID {
+	case PIDDMXStartAddress:
+		if len(request.Data) != 2 {
+			return NackFormatError
+		}
+		address := binary.BigEndian.Uint16(request.Data)
+		if address < 1 || int(address)+int(d.Footprint)-1 > 512 {
+			return NackDataOutOfRange
+		}
+		d.StartAddress = address
+		return noNack
+
+	case PIDDeviceLabel:
+		if len(request.Data) > 32 {
+			return NackFormatError
+		}
+		d.DeviceLabel = string(request.Data)
+		return noNack
+
+	case PIDIdentifyDevice:
+		if len(request.Data) != 1 || request.Data[0] > 1 {
+			return NackFormatError
+		}
+		d.Identify = request.Data[0] == 1
+		return noNack
+	}
+	return NackUnknownPID
+}
+
+// label truncates a string to the 32 bytes RDM allows for labels.
+func label(s string) []byte {
+	if len(s) > 32 {
+		s = s[:32]
+	}
+	return []byte(s)
+}
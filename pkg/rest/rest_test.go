@@ -0,0 +1,124 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBaseURLFromEnv(t *testing.T) {
+	t.Setenv("REST_BASE_URL", "")
+	t.Setenv("GRAPHQL_ENDPOINT", "")
+	assert.Equal(t, DefaultBaseURL, BaseURLFromEnv())
+
+	t.Setenv("GRAPHQL_ENDPOINT", "http://lights.local:4001/graphql")
+	assert.Equal(t, "http://lights.local:4001", BaseURLFromEnv(), "The GraphQL path is dropped")
+
+	t.Setenv("REST_BASE_URL", "http://other:8080")
+	assert.Equal(t, "http://other:8080", BaseURLFromEnv(), "REST_BASE_URL wins")
+
+	assert.Equal(t, "http://other:8080", NewClient("http://other:8080/").BaseURL(), "Trailing slashes are trimmed")
+}
+
+func TestGetJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			_, _ = w.Write([]byte(`{"status":"ok"}`))
+		case "/text":
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte("ok"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	ctx := context.Background()
+
+	resp, err := client.Get(ctx, "/health")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.MediaType(), "Parameters are dropped")
+	var health struct {
+		Status string `json:"status"`
+	}
+	require.NoError(t, resp.JSON(&health))
+	assert.Equal(t, "ok", health.Status)
+
+	resp, err = client.Get(ctx, "/text")
+	require.NoError(t, err)
+	assert.Error(t, resp.JSON(&health), "A text body is not decoded as JSON")
+
+	resp, err = client.Get(ctx, "/missing")
+	require.NoError(t, err, "Error statuses are responses, not errors")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestPostFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, header, err := r.FormFile("definition")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		content, _ := io.ReadAll(file)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", `attachment; filename="echo.json"`)
+		_ = json.NewEncoder(w).Encode(map[string]string{"name": header.Filename, "content": string(content)})
+	}))
+	defer server.Close()
+
+	resp, err := NewClient(server.URL).PostFile(context.Background(), "/upload", "definition", "par.json", []byte(`{"model":"Par"}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(resp.Body))
+
+	var echo map[string]string
+	require.NoError(t, resp.JSON(&echo))
+	assert.Equal(t, "par.json", echo["name"])
+	assert.Equal(t, `{"model":"Par"}`, echo["content"])
+	assert.Equal(t, "echo.json", resp.Filename())
+}
+
+func TestRequireEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/health":
+			_, _ = w.Write([]byte("ok"))
+		case r.URL.Path == "/upload" && r.Method != http.MethodPost:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("PENDING_CONTRACTS", "")
+	client := NewClient(server.URL)
+
+	var present, missing, wrongMethod bool
+	t.Run("Present", func(t *testing.T) {
+		resp := RequireEndpoint(t, client, http.MethodGet, "/health", "GET /health")
+		assert.Equal(t, "ok", string(resp.Body))
+		present = true
+	})
+	t.Run("Missing", func(t *testing.T) {
+		RequireEndpoint(t, client, http.MethodGet, "/nope", "GET /nope")
+		missing = true
+	})
+	t.Run("WrongMethod", func(t *testing.T) {
+		RequireEndpoint(t, client, http.MethodGet, "/upload", "POST /upload")
+		wrongMethod = true
+	})
+
+	assert.True(t, present, "An answering endpoint should not skip")
+	assert.False(t, missing, "A 404 should skip")
+	assert.False(t, wrongMethod, "A 405 should skip")
+}
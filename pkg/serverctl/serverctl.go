@@ -0,0 +1,141 @@
+// Package serverctl stops, starts and restarts the server under test.
+//
+// Restart tests are destructive to whatever else is using the server, so they
+// only run when RESTART_TESTS=1 and a way to control the server is configured:
+// either SERVER_RESTART_CMD, or both SERVER_STOP_CMD and SERVER_START_CMD.
+// Commands are run with "sh -c" and must leave the database in place.
+package serverctl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+)
+
+// DefaultReadyTimeout bounds how long Restart and Start wait for the server to answer.
+const DefaultReadyTimeout = 30 * time.Second
+
+// ErrNotConfigured is returned by FromEnv when no control commands are set.
+var ErrNotConfigured = errors.New("serverctl: set SERVER_RESTART_CMD, or SERVER_STOP_CMD and SERVER_START_CMD")
+
+// Controller runs the configured shell commands and waits for the server to come back.
+type Controller struct {
+	// RestartCmd restarts the server in one step. Used when StopCmd/StartCmd are empty.
+	RestartCmd string
+
+	// StopCmd stops the server. It should return once the server has exited.
+	StopCmd string
+
+	// StartCmd starts the server. It may return before the server is ready.
+	StartCmd string
+
+	// Endpoint is polled for readiness; empty uses the graphql client default.
+	Endpoint string
+
+	// ReadyTimeout bounds the readiness wait (default DefaultReadyTimeout).
+	ReadyTimeout time.Duration
+}
+
+// FromEnv builds a Controller from SERVER_RESTART_CMD, SERVER_STOP_CMD and SERVER_START_CMD.
+func FromEnv() (*Controller, error) {
+	c := &Controller{
+		RestartCmd: os.Getenv("SERVER_RESTART_CMD"),
+		StopCmd:    os.Getenv("SERVER_STOP_CMD"),
+		StartCmd:   os.Getenv("SERVER_START_CMD"),
+	}
+	if c.RestartCmd == "" && (c.StopCmd == "" || c.StartCmd == "") {
+		return nil, ErrNotConfigured
+	}
+	return c, nil
+}
+
+// Require returns a Controller from the environment, skipping the test unless
+// RESTART_TESTS=1 and the control commands are configured.
+func Require(t testing.TB) *Controller {
+	t.Helper()
+
+	if os.Getenv("RESTART_TESTS") != "1" {
+		t.Skip("Skipping restart test: set RESTART_TESTS=1 to enable")
+	}
+	c, err := FromEnv()
+	if err != nil {
+		t.Skipf("Skipping restart test: %v", err)
+	}
+	return c
+}
+
+// Stop runs StopCmd, or does nothing when only RestartCmd is configured.
+func (c *Controller) Stop(ctx context.Context) error {
+	if c.StopCmd == "" {
+		return nil
+	}
+	return run(ctx, c.StopCmd)
+}
+
+// Start runs StartCmd and waits for the server to become ready.
+func (c *Controller) Start(ctx context.Context) error {
+	if c.StartCmd == "" {
+		return errors.New("serverctl: SERVER_START_CMD is not set")
+	}
+	if err := run(ctx, c.StartCmd); err != nil {
+		return err
+	}
+	return c.WaitReady(ctx)
+}
+
+// Restart stops and starts the server, or runs RestartCmd, then waits for it to become ready.
+func (c *Controller) Restart(ctx context.Context) error {
+	if c.StopCmd != "" && c.StartCmd != "" {
+		if err := c.Stop(ctx); err != nil {
+			return err
+		}
+		return c.Start(ctx)
+	}
+	if err := run(ctx, c.RestartCmd); err != nil {
+		return err
+	}
+	return c.WaitReady(ctx)
+}
+
+// WaitReady polls the GraphQL endpoint until it answers a trivial query.
+func (c *Controller) WaitReady(ctx context.Context) error {
+	timeout := c.ReadyTimeout
+	if timeout == 0 {
+		timeout = DefaultReadyTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := graphql.NewClientWithOptions(c.Endpoint, graphql.ClientOptions{Timeout: 2 * time.Second})
+
+	var lastErr error
+	for {
+		var resp struct {
+			Typename string `json:"__typename"`
+		}
+		lastErr = client.Query(ctx, `query { __typename }`, nil, &resp)
+		if lastErr == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("serverctl: server not ready after %v: %w", timeout, lastErr)
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+}
+
+func run(ctx context.Context, command string) error {
+	out, err := exec.CommandContext(ctx, "sh", "-c", command).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("serverctl: %q failed: %w\n%s", command, err, out)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package serverctl
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("SERVER_RESTART_CMD", "")
+	t.Setenv("SERVER_STOP_CMD", "")
+	t.Setenv("SERVER_START_CMD", "")
+	_, err := FromEnv()
+	assert.ErrorIs(t, err, ErrNotConfigured)
+
+	t.Setenv("SERVER_STOP_CMD", "true")
+	_, err = FromEnv()
+	assert.ErrorIs(t, err, ErrNotConfigured, "Stop without start is not enough")
+
+	t.Setenv("SERVER_START_CMD", "true")
+	c, err := FromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "true", c.StopCmd)
+
+	t.Setenv("SERVER_STOP_CMD", "")
+	t.Setenv("SERVER_START_CMD", "")
+	t.Setenv("SERVER_RESTART_CMD", "true")
+	c, err = FromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "true", c.RestartCmd)
+}
+
+func TestRestartRunsCommandsAndWaitsForReady(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Unavailable for the first two polls, like a server still booting
+		if requests.Add(1) <= 2 {
+			http.Error(w, "starting", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"__typename":"Query"}}`))
+	}))
+	defer server.Close()
+
+	marker := filepath.Join(t.TempDir(), "order")
+	c := &Controller{
+		StopCmd:  "echo stop >> " + marker,
+		StartCmd: "echo start >> " + marker,
+		Endpoint: server.URL,
+	}
+
+	require.NoError(t, c.Restart(context.Background()))
+
+	out, err := os.ReadFile(marker)
+	require.NoError(t, err)
+	assert.Equal(t, "stop\nstart\n", string(out))
+	assert.GreaterOrEqual(t, requests.Load(), int32(3))
+}
+
+func TestRestartReportsCommandFailure(t *testing.T) {
+	c := &Controller{RestartCmd: "echo nope; exit 3"}
+	err := c.Restart(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "nope")
+}
+
+func TestWaitReadyTimesOut(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	c := &Controller{Endpoint: server.URL, ReadyTimeout: 600 * time.Millisecond}
+	start := time.Now()
+	err := c.WaitReady(context.Background())
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 3*time.Second)
+}
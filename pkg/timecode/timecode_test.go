@@ -0,0 +1,114 @@
+package timecode
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAt(t *testing.T) {
+	assert.Equal(t, Timecode{Seconds: 1, Frames: 12, Rate: EBU}, At(1480*time.Millisecond, EBU))
+	assert.Equal(t, Timecode{Hours: 1, Minutes: 2, Seconds: 3, Frames: 23, Rate: Film},
+		At(time.Hour+2*time.Minute+3*time.Second+999*time.Millisecond, Film))
+	assert.Equal(t, "00:00:01:12", At(1480*time.Millisecond, EBU).String())
+
+	tc := Timecode{Minutes: 10, Seconds: 5, Frames: 15, Rate: SMPTE}
+	assert.Equal(t, 10*time.Minute+5500*time.Millisecond, tc.Duration())
+	assert.Equal(t, tc, At(tc.Duration(), SMPTE), "A frame's start is inside the frame")
+}
+
+func TestParse(t *testing.T) {
+	tc, err := Parse("00:00:03:12", EBU)
+	require.NoError(t, err)
+	assert.Equal(t, Timecode{Seconds: 3, Frames: 12, Rate: EBU}, tc)
+
+	for _, s := range []string{"", "0:00:03:12", "00:00:03", "00:60:00:00", "00:00:00:25", "aa:bb:cc:dd"} {
+		_, err := Parse(s, EBU)
+		assert.Error(t, err, "%q should not parse", s)
+	}
+	_, err = Parse("00:00:00:29", SMPTE)
+	assert.NoError(t, err)
+}
+
+func TestEncode(t *testing.T) {
+	packet, err := Timecode{Hours: 1, Minutes: 2, Seconds: 3, Frames: 4, Rate: SMPTE}.Encode()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{'A', 'r', 't', '-', 'N', 'e', 't', 0, 0x00, 0x97, 0, 14, 0, 0, 4, 3, 2, 1, 3}, packet)
+
+	tc, err := Decode(packet)
+	require.NoError(t, err)
+	assert.Equal(t, Timecode{Hours: 1, Minutes: 2, Seconds: 3, Frames: 4, Rate: SMPTE}, tc)
+
+	_, err = Timecode{Frames: 24, Rate: Film}.Encode()
+	assert.Error(t, err, "24fps has no frame 24")
+	_, err = Timecode{Rate: 4}.Encode()
+	assert.Error(t, err)
+	_, err = Decode(packet[:18])
+	assert.Error(t, err)
+	packet[8] = 0x50
+	_, err = Decode(packet)
+	assert.Error(t, err, "ArtDmx is not timecode")
+}
+
+// receive reads timecode packets from conn until it has been quiet for idle.
+func receive(t *testing.T, conn *net.UDPConn, idle time.Duration) []Timecode {
+	var received []Timecode
+	buf := make([]byte, 64)
+	for {
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(idle)))
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			return received
+		}
+		tc, err := Decode(buf[:n])
+		require.NoError(t, err)
+		received = append(received, tc)
+	}
+}
+
+func TestGenerator(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	g, err := Dial(conn.LocalAddr().String(), EBU)
+	require.NoError(t, err)
+	defer func() { _ = g.Close() }()
+
+	assert.Empty(t, receive(t, conn, 100*time.Millisecond), "A new generator is stopped")
+
+	g.Start(10 * time.Second)
+	time.Sleep(200 * time.Millisecond)
+	paused := g.Pause()
+	running := receive(t, conn, 100*time.Millisecond)
+	require.NotEmpty(t, running)
+	assert.Equal(t, "00:00:10:00", running[0].String(), "Start sends its first frame at once")
+	assert.InDelta(t, 5, len(running), 2, "One packet per 40ms frame")
+	for i := 1; i < len(running); i++ {
+		assert.Equal(t, running[i-1].Duration()+EBU.FrameDuration(), running[i].Duration(), "Frames are consecutive")
+	}
+	assert.LessOrEqual(t, At(paused, EBU).Duration()-running[len(running)-1].Duration(), EBU.FrameDuration(),
+		"Pause stops within a frame of the last frame sent")
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Empty(t, receive(t, conn, 100*time.Millisecond), "A paused generator sends nothing")
+	assert.Equal(t, paused, g.Position())
+
+	g.Resume()
+	time.Sleep(50 * time.Millisecond)
+	resumed := receive(t, conn, 30*time.Millisecond)
+	require.NotEmpty(t, resumed)
+	assert.Equal(t, At(paused, EBU), resumed[0], "Resume continues from the paused frame")
+
+	g.Locate(time.Hour)
+	time.Sleep(50 * time.Millisecond)
+	g.Pause()
+	located := receive(t, conn, 50*time.Millisecond)
+	require.NotEmpty(t, located)
+	assert.Contains(t, located, At(time.Hour, EBU), "Locate jumps the running transport")
+	assert.GreaterOrEqual(t, located[len(located)-1].Duration(), time.Hour)
+	assert.NoError(t, g.Err())
+}
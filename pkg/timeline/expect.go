@@ -0,0 +1,159 @@
+package timeline
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+)
+
+// Matcher selects timeline events.
+type Matcher struct {
+	Description string
+	Match       func(Event) bool
+}
+
+func (m Matcher) String() string {
+	return m.Description
+}
+
+// Mutation matches mutations whose first root field is field.
+func Mutation(field string) Matcher {
+	return Matcher{
+		Description: "mutation " + field,
+		Match: func(ev Event) bool {
+			return ev.Kind == KindCall && ev.Operation == "mutation" && ev.Field == field
+		},
+	}
+}
+
+// Call matches any operation whose first root field is field.
+func Call(field string) Matcher {
+	return Matcher{
+		Description: "call " + field,
+		Match:       func(ev Event) bool { return ev.Kind == KindCall && ev.Field == field },
+	}
+}
+
+// ChannelStartsMoving matches the frames in which channel (1-512) of universe
+// (Art-Net numbering) differs from the previous frame of that universe.
+func ChannelStartsMoving(universe, channel int) Matcher {
+	return Matcher{
+		Description: fmt.Sprintf("u%d ch%d moving", universe, channel),
+		Match: func(ev Event) bool {
+			return isChannelFrame(ev, universe, channel) && ev.Previous != nil &&
+				ev.Frame.Channels[channel-1] != ev.Previous.Channels[channel-1]
+		},
+	}
+}
+
+// ChannelReaches matches frames in which channel (1-512) of universe equals value.
+func ChannelReaches(universe, channel int, value byte) Matcher {
+	return Matcher{
+		Description: fmt.Sprintf("u%d ch%d at %d", universe, channel, value),
+		Match: func(ev Event) bool {
+			return isChannelFrame(ev, universe, channel) && ev.Frame.Channels[channel-1] == value
+		},
+	}
+}
+
+// FrameMatching matches frames satisfying cond.
+func FrameMatching(description string, cond func(artnet.Frame) bool) Matcher {
+	return Matcher{
+		Description: description,
+		Match:       func(ev Event) bool { return ev.Kind == KindFrame && cond(*ev.Frame) },
+	}
+}
+
+func isChannelFrame(ev Event, universe, channel int) bool {
+	return ev.Kind == KindFrame && ev.Frame.Universe == universe && channel >= 1 && channel <= artnet.DMXChannels
+}
+
+// Expectation is a set of matched events that further expectations are
+// measured from. Once an expectation fails, those chained after it are
+// skipped, so one missing event reports one failure.
+type Expectation struct {
+	t       testing.TB
+	tl      *Timeline
+	what    string
+	matched []int
+	gaps    []time.Duration
+}
+
+// Expect requires an event matching m and anchors on the first one.
+func (tl *Timeline) Expect(t testing.TB, m Matcher) *Expectation {
+	t.Helper()
+	e := &Expectation{t: t, tl: tl, what: m.String()}
+	if i := tl.Find(0, m); i >= 0 {
+		e.matched = []int{i}
+	} else {
+		t.Errorf("Timeline has no %s\n%s", m, tl)
+	}
+	return e
+}
+
+// ExpectEach requires at least one event matching m and anchors on all of them.
+func (tl *Timeline) ExpectEach(t testing.TB, m Matcher) *Expectation {
+	t.Helper()
+	e := &Expectation{t: t, tl: tl, what: m.String()}
+	for i := tl.Find(0, m); i >= 0; i = tl.Find(i+1, m) {
+		e.matched = append(e.matched, i)
+	}
+	if len(e.matched) == 0 {
+		t.Errorf("Timeline has no %s\n%s", m, tl)
+	}
+	return e
+}
+
+// FollowedWithin requires that, after each anchored event, the next event
+// matching m comes within d. The returned expectation anchors on those
+// events, and its Latencies are the measured gaps.
+func (e *Expectation) FollowedWithin(d time.Duration, m Matcher) *Expectation {
+	e.t.Helper()
+	next := &Expectation{t: e.t, tl: e.tl, what: m.String()}
+	if len(e.matched) == 0 {
+		return next
+	}
+
+	var misses []string
+	for _, anchor := range e.matched {
+		at := e.tl.events[anchor].At
+		i := e.tl.Find(anchor+1, m)
+		if i < 0 {
+			misses = append(misses, fmt.Sprintf("%s at %s: never followed by %s\n%s",
+				e.what, at.Format("15:04:05.000"), m, e.tl.Around(at, 2*d)))
+			continue
+		}
+		gap := e.tl.events[i].At.Sub(at)
+		if gap > d {
+			misses = append(misses, fmt.Sprintf("%s at %s: followed by %s after %v, want within %v\n%s",
+				e.what, at.Format("15:04:05.000"), m, gap.Round(100*time.Microsecond), d, e.tl.Around(at, gap+d)))
+			continue
+		}
+		next.matched = append(next.matched, i)
+		next.gaps = append(next.gaps, gap)
+	}
+
+	if len(misses) > 0 {
+		e.t.Errorf("%d of %d %s not followed by %s within %v; first:\n%s",
+			len(misses), len(e.matched), e.what, m, d, misses[0])
+		next.matched = nil
+	}
+	return next
+}
+
+// Events returns the anchored events.
+func (e *Expectation) Events() []Event {
+	events := make([]Event, len(e.matched))
+	for i, index := range e.matched {
+		events[i] = e.tl.events[index]
+	}
+	return events
+}
+
+// Latencies returns the gap from each previous anchor to the event matched
+// by FollowedWithin, in order. It is empty for Expect and ExpectEach.
+func (e *Expectation) Latencies() []time.Duration {
+	return e.gaps
+}
@@ -0,0 +1,220 @@
+// Package timeline merges a client's GraphQL calls and the Art-Net frames
+// captured meanwhile into one ordered timeline, so tests can assert how
+// output follows the calls that caused it:
+//
+//	client := graphql.NewClientWithOptions("", graphql.ClientOptions{KeepLast: 100})
+//	receiver := artnet.Capture(t)
+//	... // call nextCue, wait for the fade
+//	tl := timeline.New(client.Recorder().Last(100), receiver.GetFrames())
+//	tl.Expect(t, timeline.Mutation("nextCue")).
+//		FollowedWithin(100*time.Millisecond, timeline.ChannelStartsMoving(0, 1))
+//
+// On failure the events around the expectation are printed, with frames
+// reduced to the channels that changed.
+package timeline
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+)
+
+// Kind is the kind of an Event.
+type Kind int
+
+const (
+	// KindCall is a GraphQL request, placed when it was sent.
+	KindCall Kind = iota
+	// KindFrame is a captured Art-Net frame, placed when it arrived.
+	KindFrame
+)
+
+// Event is one entry of a timeline.
+type Event struct {
+	At   time.Time
+	Kind Kind
+
+	// Call events: the operation type ("query", "mutation" or
+	// "subscription"), its first root field, the exchange and when the
+	// response arrived.
+	Operation string
+	Field     string
+	Exchange  *graphql.Exchange
+	Returned  time.Time
+
+	// Frame events: the frame, and the previous frame of the same universe
+	// (nil for the first).
+	Frame    *artnet.Frame
+	Previous *artnet.Frame
+}
+
+// Timeline is an ordered, immutable sequence of events.
+type Timeline struct {
+	events []Event
+}
+
+// New merges exchanges and frames into a timeline ordered by time. Frames of
+// the same universe keep their capture order.
+func New(exchanges []graphql.Exchange, frames []artnet.Frame) *Timeline {
+	events := make([]Event, 0, len(exchanges)+len(frames))
+	for i := range exchanges {
+		ex := &exchanges[i]
+		operation, field := rootField(ex.Query)
+		events = append(events, Event{
+			At:        ex.Time,
+			Kind:      KindCall,
+			Operation: operation,
+			Field:     field,
+			Exchange:  ex,
+			Returned:  ex.Time.Add(time.Duration(ex.DurationMs * float64(time.Millisecond))),
+		})
+	}
+	previous := map[int]*artnet.Frame{}
+	for i := range frames {
+		frame := &frames[i]
+		events = append(events, Event{At: frame.Timestamp, Kind: KindFrame, Frame: frame, Previous: previous[frame.Universe]})
+		previous[frame.Universe] = frame
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].At.Before(events[j].At) })
+	return &Timeline{events: events}
+}
+
+// Events returns the events in order.
+func (tl *Timeline) Events() []Event {
+	return tl.events
+}
+
+// Find returns the index of the first event at or after index from that
+// matches m, or -1.
+func (tl *Timeline) Find(from int, m Matcher) int {
+	for i := max(from, 0); i < len(tl.events); i++ {
+		if m.Match(tl.events[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// String renders every event; see Around.
+func (tl *Timeline) String() string {
+	return tl.render(0, len(tl.events), time.Time{})
+}
+
+// Around renders the events within window of at, with times relative to at.
+func (tl *Timeline) Around(at time.Time, window time.Duration) string {
+	from := sort.Search(len(tl.events), func(i int) bool { return !tl.events[i].At.Before(at.Add(-window)) })
+	to := sort.Search(len(tl.events), func(i int) bool { return tl.events[i].At.After(at.Add(window)) })
+	return tl.render(from, to, at)
+}
+
+// render prints events[from:to], one per line, collapsing runs of frames
+// that changed nothing.
+func (tl *Timeline) render(from, to int, origin time.Time) string {
+	if from >= to {
+		return "  (no events)\n"
+	}
+	if origin.IsZero() {
+		origin = tl.events[from].At
+	}
+
+	var b strings.Builder
+	unchanged := 0
+	flush := func() {
+		if unchanged > 0 {
+			fmt.Fprintf(&b, "  %8s  %d unchanged frame(s)\n", "", unchanged)
+			unchanged = 0
+		}
+	}
+	for _, ev := range tl.events[from:to] {
+		if ev.Kind == KindFrame && ev.Previous != nil && ev.Previous.Channels == ev.Frame.Channels {
+			unchanged++
+			continue
+		}
+		flush()
+		fmt.Fprintf(&b, "  %8s  %s\n", formatOffset(ev.At.Sub(origin)), ev)
+	}
+	flush()
+	return b.String()
+}
+
+func formatOffset(d time.Duration) string {
+	sign := "+"
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	return sign + d.Round(100*time.Microsecond).String()
+}
+
+// String describes the event on one line.
+func (ev Event) String() string {
+	if ev.Kind == KindCall {
+		s := fmt.Sprintf("%s %s (returned after %v)", ev.Operation, ev.Field, ev.Returned.Sub(ev.At).Round(100*time.Microsecond))
+		if ev.Exchange != nil && ev.Exchange.Error != "" {
+			s += ": " + ev.Exchange.Error
+		}
+		return s
+	}
+
+	var changes []string
+	for i, v := range ev.Frame.Channels {
+		before := byte(0)
+		if ev.Previous != nil {
+			before = ev.Previous.Channels[i]
+		}
+		if v != before {
+			changes = append(changes, fmt.Sprintf("ch%d %d->%d", i+1, before, v))
+		}
+	}
+	if len(changes) == 0 {
+		return fmt.Sprintf("frame u%d seq %d: unchanged", ev.Frame.Universe, ev.Frame.Sequence)
+	}
+	const maxChanges = 8
+	if len(changes) > maxChanges {
+		changes = append(changes[:maxChanges], fmt.Sprintf("... %d more", len(changes)-maxChanges))
+	}
+	return fmt.Sprintf("frame u%d seq %d: %s", ev.Frame.Universe, ev.Frame.Sequence, strings.Join(changes, ", "))
+}
+
+// rootField returns the operation type and first root field of a GraphQL
+// document, skipping an alias.
+func rootField(query string) (operation, field string) {
+	doc := strings.TrimSpace(query)
+	operation = "query"
+	for _, op := range []string{"mutation", "subscription", "query"} {
+		if strings.HasPrefix(doc, op) {
+			operation = op
+			break
+		}
+	}
+
+	brace := strings.IndexByte(doc, '{')
+	if brace < 0 {
+		return operation, ""
+	}
+	rest := doc[brace+1:]
+	name := nextName(&rest)
+	if r := strings.TrimLeft(rest, " \t\r\n,"); strings.HasPrefix(r, ":") {
+		rest = r[1:]
+		name = nextName(&rest)
+	}
+	return operation, name
+}
+
+// nextName consumes and returns the next GraphQL name in *s.
+func nextName(s *string) string {
+	r := strings.TrimLeft(*s, " \t\r\n,")
+	end := 0
+	for end < len(r) {
+		c := r[end]
+		if c != '_' && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (end == 0 || c < '0' || c > '9') {
+			break
+		}
+		end++
+	}
+	*s = r[end:]
+	return r[:end]
+}
@@ -0,0 +1,120 @@
+package timeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bbernstein/lacylights-test/pkg/artnet"
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func at(ms int) time.Time {
+	return start.Add(time.Duration(ms) * time.Millisecond)
+}
+
+// frames renders one 25 ms frame per value on universe 0, channel 1.
+func frames(values ...int) []artnet.Frame {
+	result := make([]artnet.Frame, len(values))
+	for i, v := range values {
+		result[i] = artnet.Frame{Timestamp: at(i * 25), Sequence: byte(i + 1)}
+		result[i].Channels[0] = byte(v)
+	}
+	return result
+}
+
+func call(ms int, query string) graphql.Exchange {
+	return graphql.Exchange{Time: at(ms), DurationMs: 4, Query: query}
+}
+
+func TestRootField(t *testing.T) {
+	for query, want := range map[string][2]string{
+		`mutation { nextCue(cueListId: "1") }`:                                    {"mutation", "nextCue"},
+		"\n\t\tmutation Go($id: ID!) {\n\t\t\tgo: nextCue(cueListId: $id)\n\t\t}": {"mutation", "nextCue"},
+		`query GetProject($id: ID!) { project(id: $id) { id } }`:                  {"query", "project"},
+		`{ __typename }`: {"query", "__typename"},
+	} {
+		operation, field := rootField(query)
+		assert.Equal(t, want, [2]string{operation, field}, query)
+	}
+}
+
+func TestNewOrdersEvents(t *testing.T) {
+	tl := New(
+		[]graphql.Exchange{call(30, `mutation { nextCue(cueListId: "1") }`), call(5, `{ __typename }`)},
+		frames(0, 0, 10),
+	)
+
+	var order []string
+	for _, ev := range tl.Events() {
+		if ev.Kind == KindCall {
+			order = append(order, ev.Field)
+		} else {
+			order = append(order, "frame")
+		}
+	}
+	assert.Equal(t, []string{"frame", "__typename", "frame", "nextCue", "frame"}, order)
+
+	events := tl.Events()
+	assert.Equal(t, at(34), events[3].Returned)
+	assert.Nil(t, events[0].Previous)
+	assert.Equal(t, events[2].Frame, events[4].Previous, "Frames link to the previous frame of their universe")
+}
+
+func TestFollowedWithin(t *testing.T) {
+	// nextCue at 30 ms; channel 1 moves in the frame at 50 ms and is full at 100 ms
+	tl := New([]graphql.Exchange{call(30, `mutation { nextCue(cueListId: "1") }`)}, frames(0, 0, 100, 200, 255, 255))
+
+	moving := tl.Expect(t, Mutation("nextCue")).FollowedWithin(50*time.Millisecond, ChannelStartsMoving(0, 1))
+	assert.Equal(t, []time.Duration{20 * time.Millisecond}, moving.Latencies())
+	full := moving.FollowedWithin(60*time.Millisecond, ChannelReaches(0, 1, 255))
+	require.Len(t, full.Events(), 1)
+	assert.Equal(t, at(100), full.Events()[0].At)
+
+	t.Run("TooSlow", func(t *testing.T) {
+		mock := &testing.T{}
+		next := tl.Expect(mock, Mutation("nextCue")).FollowedWithin(10*time.Millisecond, ChannelStartsMoving(0, 1))
+		assert.True(t, mock.Failed())
+		assert.Empty(t, next.Events(), "A failed expectation anchors nothing")
+	})
+
+	t.Run("Never", func(t *testing.T) {
+		mock := &testing.T{}
+		tl.Expect(mock, Mutation("nextCue")).FollowedWithin(time.Second, ChannelReaches(0, 2, 255))
+		assert.True(t, mock.Failed())
+	})
+
+	t.Run("MissingAnchor", func(t *testing.T) {
+		mock := &testing.T{}
+		tl.Expect(mock, Mutation("previousCue")).FollowedWithin(time.Second, ChannelStartsMoving(0, 1))
+		assert.True(t, mock.Failed())
+	})
+}
+
+func TestExpectEach(t *testing.T) {
+	tl := New([]graphql.Exchange{
+		call(10, `mutation { nextCue(cueListId: "1") }`),
+		call(60, `mutation { nextCue(cueListId: "1") }`),
+	}, frames(0, 50, 50, 120, 120))
+
+	each := tl.ExpectEach(t, Mutation("nextCue")).FollowedWithin(20*time.Millisecond, ChannelStartsMoving(0, 1))
+	assert.Equal(t, []time.Duration{15 * time.Millisecond, 15 * time.Millisecond}, each.Latencies())
+
+	mock := &testing.T{}
+	tl.ExpectEach(mock, Mutation("nextCue")).FollowedWithin(10*time.Millisecond, ChannelStartsMoving(0, 1))
+	assert.True(t, mock.Failed())
+}
+
+func TestRender(t *testing.T) {
+	tl := New([]graphql.Exchange{call(30, `mutation { nextCue(cueListId: "1") }`)}, frames(0, 0, 0, 100))
+	assert.Equal(t, ""+
+		"     -30ms  frame u0 seq 1: unchanged\n"+
+		"            1 unchanged frame(s)\n"+
+		"       +0s  mutation nextCue (returned after 4ms)\n"+
+		"            1 unchanged frame(s)\n"+
+		"     +45ms  frame u0 seq 4: ch1 0->100\n",
+		tl.Around(at(30), 50*time.Millisecond))
+}
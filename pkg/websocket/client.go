@@ -11,6 +11,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/bbernstein/lacylights-test/pkg/graphql"
 	"github.com/gorilla/websocket"
 )
 
@@ -127,6 +128,8 @@ func (c *Client) Close() error {
 
 // Subscribe starts a subscription and returns a channel for receiving messages.
 func (c *Client) Subscribe(ctx context.Context, query string, variables map[string]interface{}) (<-chan *Message, string, error) {
+	graphql.RecordCoverage(query)
+
 	c.mu.Lock()
 	c.msgID++
 	id := fmt.Sprintf("sub_%d", c.msgID)